@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+)
+
+const usage = `Usage: dataseap-migrate <command> [flags] <desired-state files or directories>...
+
+Commands:
+  plan     Show the changes that bring the live schema to the desired state
+  apply    Plan and execute the changes, recording the migration in the history
+  history  Show the most recent migrations
+
+Run "dataseap-migrate <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "plan":
+		err = runPlan(args)
+	case "apply":
+		err = runApply(args)
+	case "history":
+		err = runHistory(args)
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	configPath := fs.String("config", "", "path of the DataSeaP configuration file")
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	_ = fs.Parse(args)
+
+	desired, err := loadDesiredState(fs.Args())
+	if err != nil {
+		return err
+	}
+	svc, err := newService(*configPath)
+	if err != nil {
+		return err
+	}
+	plan, err := svc.Plan(context.Background(), desired)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(plan)
+	}
+	printPlan(plan)
+	return nil
+}
+
+func runApply(args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	configPath := fs.String("config", "", "path of the DataSeaP configuration file")
+	allowDestructive := fs.Bool("allow-destructive", false, "execute destructive changes such as dropped tables, columns and indexes")
+	checksum := fs.String("checksum", "", "checksum of the reviewed plan; the apply is refused when the plan changed")
+	appliedBy := fs.String("applied-by", os.Getenv("USER"), "operator recorded in the migration history")
+	_ = fs.Parse(args)
+
+	desired, err := loadDesiredState(fs.Args())
+	if err != nil {
+		return err
+	}
+	svc, err := newService(*configPath)
+	if err != nil {
+		return err
+	}
+	plan, record, err := svc.Apply(context.Background(), desired, migration.ApplyOptions{
+		AllowDestructive: *allowDestructive,
+		ExpectedChecksum: *checksum,
+		AppliedBy:        *appliedBy,
+	})
+	if plan != nil {
+		printPlan(plan)
+	}
+	if record != nil {
+		fmt.Printf("\nMigration %s: %s, %d of %d statements applied\n", record.ID, record.Status, record.Applied, len(record.Statements))
+	}
+	return err
+}
+
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	configPath := fs.String("config", "", "path of the DataSeaP configuration file")
+	limit := fs.Int("limit", 20, "number of migrations to show")
+	asJSON := fs.Bool("json", false, "print the history as JSON")
+	_ = fs.Parse(args)
+
+	svc, err := newService(*configPath)
+	if err != nil {
+		return err
+	}
+	records, err := svc.History(context.Background(), *limit)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(records)
+	}
+	for _, r := range records {
+		fmt.Printf("%s  %s  %-7s  %d/%d  %s  %s\n", r.StartedAt.Format("2006-01-02 15:04:05"), r.ID, r.Status, r.Applied, len(r.Statements), r.AppliedBy, r.Checksum)
+		if r.Error != "" {
+			fmt.Printf("    error: %s\n", r.Error)
+		}
+	}
+	return nil
+}
+
+func loadDesiredState(paths []string) (*model.DesiredState, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no desired-state files or directories given")
+	}
+	return migration.LoadDesiredState(paths...)
+}
+
+func newService(configPath string) (migration.Service, error) {
+	var paths []string
+	if configPath != "" {
+		paths = append(paths, configPath)
+	}
+	cfg, err := config.LoadConfig(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	client, err := starrocks.NewClient(cfg.StarRocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize StarRocks client: %w", err)
+	}
+	ddlExecutor, err := starrocks.NewDDLExecutor(client, cfg.StarRocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize StarRocks DDL executor: %w", err)
+	}
+	history, err := migration.NewHistoryStore(client, ddlExecutor, cfg.Migration)
+	if err != nil {
+		return nil, err
+	}
+	return migration.NewService(metadata.NewService(ddlExecutor), ddlExecutor, history), nil
+}
+
+func printPlan(plan *model.Plan) {
+	if len(plan.Changes) == 0 {
+		fmt.Println("No changes. The live schema matches the desired state.")
+	}
+	for i, c := range plan.Changes {
+		marker := ""
+		if c.Destructive {
+			marker = " [DESTRUCTIVE]"
+		}
+		fmt.Printf("%d. %s %s%s\n", i+1, c.Kind, c.Object, marker)
+		if c.Reason != "" {
+			fmt.Printf("   -- %s\n", c.Reason)
+		}
+		fmt.Printf("   %s;\n", strings.ReplaceAll(c.Statement, "\n", "\n   "))
+	}
+	for _, w := range plan.Warnings {
+		fmt.Printf("WARNING: %s\n", w)
+	}
+	if len(plan.Changes) > 0 {
+		fmt.Printf("\n%d changes, %d destructive. Checksum: %s\n", len(plan.Changes), len(plan.Destructive()), plan.Checksum)
+	}
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -64,7 +64,54 @@ func NewApplication(cfg *config.Config) (*Application, error) {
 
 	// 4. 初始化领域服务 (Domain Services)
 	// 4. Initialize Domain Services
-	// ingestionService := ingestion.NewService(...)
+	// ddlExecutor, err := starrocks.NewDDLExecutor(starrocksClient, cfg.StarRocks)
+	// if err != nil {
+	//     return nil, fmt.Errorf("failed to initialize StarRocks DDL executor: %w", err)
+	// }
+	// metadataService := metadata.NewService(ddlExecutor)
+	// var ingestionOpts []ingestion.Option
+	// if len(cfg.Ingestion.Parsers) > 0 {
+	//     parsers, err := parser.NewRegistry(cfg.Ingestion.Parsers)
+	//     if err != nil {
+	//         return nil, fmt.Errorf("failed to initialize payload parsers: %w", err)
+	//     }
+	//     ingestionOpts = append(ingestionOpts, ingestion.WithPayloadParsers(parsers))
+	// }
+	// if cfg.Ingestion.Transform.Enabled {
+	//     transformer, err := transform.NewFromConfig(cfg.Ingestion.Transform)
+	//     if err != nil {
+	//         return nil, fmt.Errorf("failed to load transformation rules: %w", err)
+	//     }
+	//     ingestionOpts = append(ingestionOpts, ingestion.WithTransformer(transformer))
+	// }
+	// if cfg.Ingestion.Schema.Enabled {
+	//     ingestionOpts = append(ingestionOpts, ingestion.WithSchemaRegistry(schema.NewRegistry(metadataService, cfg.Ingestion.Schema)))
+	// }
+	// if cfg.Ingestion.RateLimit.Enabled {
+	//     ingestionOpts = append(ingestionOpts, ingestion.WithRateLimiter(ratelimit.New(cfg.Ingestion.RateLimit)))
+	// }
+	// if cfg.Ingestion.Dedup.Enabled {
+	//     ingestionOpts = append(ingestionOpts, ingestion.WithDeduplication(dedup.New(cfg.Ingestion.Dedup)))
+	// }
+	// if cfg.Ingestion.AutoProvision.Enabled {
+	//     ingestionOpts = append(ingestionOpts, ingestion.WithDDLExecutor(ddlExecutor))
+	// }
+	// if cfg.Ingestion.DeadLetter.Enabled {
+	//     dlStore, err := deadletter.NewStore(cfg.Ingestion.DeadLetter, pulsarClient)
+	//     if err != nil {
+	//         return nil, fmt.Errorf("failed to initialize dead-letter store: %w", err)
+	//     }
+	//     app.AddShutdownFunc(func(ctx context.Context) error { return dlStore.Close() })
+	//     ingestionOpts = append(ingestionOpts, ingestion.WithDeadLetterStore(dlStore))
+	// }
+	// ingestionService := ingestion.NewService(starrocksClient, pulsarClient, cfg.Ingestion, ingestionOpts...)
+	// deadLetterService := deadletter.NewService(dlStore, ingestionService)
+	// if cfg.Ingestion.Mode == constants.IngestionModePulsar {
+	//     sink := ingestion.NewSinkWorker(pulsarClient, starrocksClient, cfg.Ingestion, ingestionOpts...)
+	//     sinkCtx, stopSink := context.WithCancel(context.Background())
+	//     go func() { _ = sink.Run(sinkCtx) }()
+	//     app.AddShutdownFunc(func(ctx context.Context) error { stopSink(); return nil })
+	// }
 	// queryService := query.NewService(...)
 	// l.Info("Domain services initialized (placeholder).")
 
@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/turtacn/dataseap/pkg/common/constants"
 	"github.com/turtacn/dataseap/pkg/common/errors"
 	"github.com/turtacn/dataseap/pkg/config"
 	"github.com/turtacn/dataseap/pkg/logger"
@@ -23,11 +24,12 @@ import (
 // starrocksClient implements the Client interface for StarRocks.
 // starrocksClient 实现StarRocks的Client接口。
 type starrocksClient struct {
-	cfg        config.StarRocksConfig
-	httpClient *http.Client
-	feHosts    []string // FE节点列表 (host:http_port) FE node list (host:http_port)
-	loadURLIdx int      // 用于轮询Load URL的索引 Index for round-robin Load URL
-	mu         sync.RWMutex
+	cfg         config.StarRocksConfig
+	httpClient  *http.Client
+	queryClient *http.Client // 不设超时的httpClient，查询由其截止时间限制 httpClient without a timeout, queries are bounded by their deadline
+	feHosts     []string     // FE节点列表 (host:http_port) FE node list (host:http_port)
+	loadURLIdx  int          // 用于轮询Load URL的索引 Index for round-robin Load URL
+	mu          sync.RWMutex
 }
 
 // NewClient creates a new StarRocks client.
@@ -73,7 +75,8 @@ func NewClient(cfg config.StarRocksConfig) (Client, error) {
 			Timeout:   timeout,
 			Transport: transport,
 		},
-		loadURLIdx: rand.Intn(len(parsedHosts)), // 随机起始点 Random starting point
+		queryClient: &http.Client{Transport: transport},
+		loadURLIdx:  rand.Intn(len(parsedHosts)), // 随机起始点 Random starting point
 	}, nil
 }
 
@@ -117,47 +120,22 @@ func (c *starrocksClient) Execute(ctx context.Context, query string, args ...int
 		l.Warnw("Execute called with args, but StarRocks HTTP API does not support native parameterization. Ensure query is pre-formatted safely.", "argsCount", len(args))
 		// return nil, errors.New(errors.InvalidArgument, "parameterized queries via this basic HTTP client are not directly supported; pre-format your SQL safely")
 	}
+	return c.ExecuteWithOptions(ctx, query, nil)
+}
 
-	// 使用 /api/query/action 端点执行SQL
-	// Use /api/query/action endpoint to execute SQL
-	// FE host is chosen by getQueryURL which internally uses c.cfg.Database for the path construction.
-	// For a generic query API, we might need a different URL construction.
-	// Let's assume a generic query URL for now.
-	// The path /api/{db} used in getQueryURL() is for _Stream_Load, not generic query.
-	// A common endpoint for SQL is often /api/query or similar.
-	// StarRocks documentation suggests POST to /api/v1/query for SQL statements.
-	// Let's assume we pick one FE and construct /api/v1/query for it.
-
-	feHostOnly := strings.Split(c.feHosts[rand.Intn(len(c.feHosts))], ":")[0]
-	queryPort := c.cfg.QueryPort
-	if queryPort == 0 {
-		queryPort = 8030
-	}
-	srURL := fmt.Sprintf("http://%s:%d/api/v1/query", feHostOnly, queryPort)
-
-	payload := map[string]string{"sql": query}
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		l.Errorw("Failed to marshal query payload", "error", err)
-		return nil, errors.Wrap(err, errors.SerializationError, "failed to marshal query payload")
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srURL, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		l.Errorw("Failed to create HTTP request", "url", srURL, "error", err)
-		return nil, errors.Wrap(err, errors.NetworkError, "failed to create HTTP request for StarRocks query")
-	}
-
-	req.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.cfg.User+":"+c.cfg.Password)))
-	if c.cfg.Database != "" {
-		req.Header.Set("Database", c.cfg.Database) // Set database via header
-	}
-
-	resp, err := c.httpClient.Do(req)
+// ExecuteWithOptions performs a query in the database, workload group and with the timeout of opts. The timeout
+// is both the query_timeout session variable and the deadline of the request; without one, the request is bounded
+// by the configured query timeout unless ctx already has a deadline.
+// ExecuteWithOptions 使用opts中的数据库、工作负载组与超时时间执行查询。超时时间既是query_timeout会话变量，也是请求的
+// 截止时间；未指定时，若ctx没有截止时间，请求受配置的查询超时限制。
+func (c *starrocksClient) ExecuteWithOptions(ctx context.Context, query string, opts *ExecuteOptions) (*QueryResult, error) {
+	l := logger.L().With("method", "ExecuteWithOptions", "query", query)
+	ctx, cancel := c.queryContext(ctx, opts)
+	defer cancel()
+
+	resp, srURL, err := c.postQuery(ctx, query, opts)
 	if err != nil {
-		l.Errorw("Failed to execute StarRocks query", "url", srURL, "error", err)
-		return nil, errors.Wrap(err, errors.NetworkError, "failed to execute StarRocks query")
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -167,11 +145,6 @@ func (c *starrocksClient) Execute(ctx context.Context, query string, args ...int
 		return nil, errors.Wrap(err, errors.NetworkError, "failed to read StarRocks query response body")
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		l.Errorw("StarRocks query failed", "url", srURL, "status", resp.Status, "response", string(bodyBytes))
-		return nil, errors.Newf(errors.DatabaseError, "StarRocks query failed: %s, Response: %s", resp.Status, string(bodyBytes))
-	}
-
 	var srResp struct {
 		Msg  string `json:"msg"`
 		Code int    `json:"code"` // 0 for success
@@ -187,7 +160,11 @@ func (c *starrocksClient) Execute(ctx context.Context, query string, args ...int
 		Count int `json:"count"` // Usually 0 for queries
 	}
 
-	if err := json.Unmarshal(bodyBytes, &srResp); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(bodyBytes))
+	if opts != nil && opts.UseNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(&srResp); err != nil {
 		l.Errorw("Failed to unmarshal StarRocks query response JSON", "response", string(bodyBytes), "error", err)
 		return nil, errors.Wrapf(err, errors.DeserializationError, "failed to unmarshal StarRocks response: %s", string(bodyBytes))
 	}
@@ -219,6 +196,127 @@ func (c *starrocksClient) Execute(ctx context.Context, query string, args ...int
 	return queryResult, nil
 }
 
+// ExecuteStream performs a query like ExecuteWithOptions, returning its rows as they are read from the response.
+// ExecuteStream 与ExecuteWithOptions一样执行查询，在读取响应的同时返回其数据行。
+func (c *starrocksClient) ExecuteStream(ctx context.Context, query string, opts *ExecuteOptions) (Rows, error) {
+	l := logger.L().With("method", "ExecuteStream", "query", query)
+	ctx, cancel := c.queryContext(ctx, opts)
+
+	resp, srURL, err := c.postQuery(ctx, query, opts)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rows := newStreamRows(ctx, cancel, resp.Body)
+	if opts != nil && opts.UseNumber {
+		rows.dec.UseNumber()
+	}
+	if err := rows.open(); err != nil {
+		l.Errorw("Failed to read StarRocks query response", "url", srURL, "error", err)
+		rows.Close()
+		return nil, err
+	}
+	return rows, nil
+}
+
+// queryContext bounds ctx by the timeout of opts or, when neither opts nor ctx set one, by the configured query timeout.
+// queryContext 用opts中的超时时间限制ctx；opts与ctx均未设置时，使用配置的查询超时。
+func (c *starrocksClient) queryContext(ctx context.Context, opts *ExecuteOptions) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(0)
+	if opts != nil {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	if _, ok := ctx.Deadline(); timeout <= 0 && ok {
+		return context.WithCancel(ctx)
+	}
+	if timeout <= 0 {
+		timeout = time.Duration(c.cfg.QueryTimeout) * time.Second
+		if c.cfg.QueryTimeout <= 0 {
+			timeout = time.Duration(constants.StarRocksDefaultQueryTimeout) * time.Second
+		}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// postQuery sends a query to an FE node, returning the response once StarRocks accepted it. The caller closes
+// its body.
+// postQuery 将查询发送到一个FE节点，StarRocks接受后返回响应，由调用方关闭响应体。
+func (c *starrocksClient) postQuery(ctx context.Context, query string, opts *ExecuteOptions) (*http.Response, string, error) {
+	l := logger.L().With("method", "postQuery", "query", query)
+	if opts == nil {
+		opts = &ExecuteOptions{}
+	}
+	sessionVariables := make(map[string]string, len(opts.SessionVariables)+2)
+	for k, v := range opts.SessionVariables {
+		sessionVariables[k] = v
+	}
+	if opts.TimeoutSeconds > 0 {
+		sessionVariables[SessionVariableQueryTimeout] = strconv.Itoa(opts.TimeoutSeconds)
+	}
+	if opts.WorkloadGroup != "" {
+		sessionVariables[SessionVariableWorkloadGroup] = opts.WorkloadGroup
+	}
+	database := opts.Database
+	if database == "" {
+		database = c.cfg.Database
+	}
+
+	// 使用 /api/query/action 端点执行SQL
+	// Use /api/query/action endpoint to execute SQL
+	// FE host is chosen by getQueryURL which internally uses c.cfg.Database for the path construction.
+	// For a generic query API, we might need a different URL construction.
+	// Let's assume a generic query URL for now.
+	// The path /api/{db} used in getQueryURL() is for _Stream_Load, not generic query.
+	// A common endpoint for SQL is often /api/query or similar.
+	// StarRocks documentation suggests POST to /api/v1/query for SQL statements.
+	// Let's assume we pick one FE and construct /api/v1/query for it.
+
+	feHostOnly := strings.Split(c.feHosts[rand.Intn(len(c.feHosts))], ":")[0]
+	queryPort := c.cfg.QueryPort
+	if queryPort == 0 {
+		queryPort = 8030
+	}
+	srURL := fmt.Sprintf("http://%s:%d/api/v1/query", feHostOnly, queryPort)
+
+	payload := struct {
+		SQL              string            `json:"sql"`
+		SessionVariables map[string]string `json:"sessionVariables,omitempty"`
+	}{SQL: query, SessionVariables: sessionVariables}
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		l.Errorw("Failed to marshal query payload", "error", err)
+		return nil, "", errors.Wrap(err, errors.SerializationError, "failed to marshal query payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srURL, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		l.Errorw("Failed to create HTTP request", "url", srURL, "error", err)
+		return nil, "", errors.Wrap(err, errors.NetworkError, "failed to create HTTP request for StarRocks query")
+	}
+
+	req.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.cfg.User+":"+c.cfg.Password)))
+	if database != "" {
+		req.Header.Set("Database", database) // Set database via header
+	}
+
+	resp, err := c.queryClient.Do(req)
+	if err != nil {
+		l.Errorw("Failed to execute StarRocks query", "url", srURL, "error", err)
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, "", errors.Wrap(err, errors.TimeoutError, "StarRocks query timed out")
+		}
+		return nil, "", errors.Wrap(err, errors.NetworkError, "failed to execute StarRocks query")
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		l.Errorw("StarRocks query failed", "url", srURL, "status", resp.Status, "response", string(body))
+		return nil, "", errors.Newf(errors.DatabaseError, "StarRocks query failed: %s, Response: %s", resp.Status, string(body))
+	}
+	return resp, srURL, nil
+}
+
 // StreamLoad ingests data using StarRocks Stream Load.
 // StreamLoad 使用StarRocks Stream Load导入数据。
 func (c *starrocksClient) StreamLoad(ctx context.Context, database, table string, data io.Reader, opts *StreamLoadOptions) (*StreamLoadResponse, error) {
@@ -261,6 +359,9 @@ func (c *starrocksClient) StreamLoad(ctx context.Context, database, table string
 		req.Header.Set("max_filter_ratio", fmt.Sprintf("%f", opts.MaxFilterRatio))
 	}
 	req.Header.Set("timeout", strconv.Itoa(opts.TimeoutSeconds))
+	if opts.Label != "" {
+		req.Header.Set("label", opts.Label)
+	}
 
 	if opts.TwoPhaseCommit && opts.TransactionID != "" {
 		req.Header.Set("txn_id", opts.TransactionID)
@@ -300,7 +401,11 @@ func (c *starrocksClient) StreamLoad(ctx context.Context, database, table string
 		return nil, errors.Wrapf(err, errors.DeserializationError, "failed to unmarshal StreamLoad response: %s", string(bodyBytes))
 	}
 
-	if srResp.Status != "Success" && srResp.Status != "Publish Timeout" { // Publish Timeout can sometimes be treated as a soft failure/retryable
+	if isLabelAlreadyExists(srResp.Status) {
+		l.Warnw("StreamLoad label already exists", "label", srResp.Label, "existing_txn_id", srResp.ExistingTxnID)
+		return &srResp, errors.Newf(errors.AlreadyExistsError, "StreamLoad label %s already exists (existing txn %d)", srResp.Label, srResp.ExistingTxnID)
+	}
+	if srResp.Status != StreamLoadStatusSuccess && srResp.Status != StreamLoadStatusPublishTimeout { // Publish Timeout can sometimes be treated as a soft failure/retryable
 		l.Errorw("StreamLoad operation failed", "status", srResp.Status, "message", srResp.Message, "response", string(bodyBytes))
 		// Return the full response even on failure, as it contains useful info like ErrorURL
 		return &srResp, errors.Newf(errors.DatabaseError, "StreamLoad failed: Status %s, Message: %s, ErrorURL: %s", srResp.Status, srResp.Message, srResp.ErrorURL)
@@ -310,6 +415,42 @@ func (c *starrocksClient) StreamLoad(ctx context.Context, database, table string
 	return &srResp, nil
 }
 
+// FetchLoadErrorLog downloads the error log referenced by a Stream Load ErrorURL. The URL comes from the load
+// response and may point at any host, so no credentials are sent with it; the BE serves error logs without them.
+// FetchLoadErrorLog 下载Stream Load ErrorURL所指向的错误日志。该URL来自导入响应，可能指向任意主机，因此请求不携带
+// 凭据；BE提供错误日志无需凭据。
+func (c *starrocksClient) FetchLoadErrorLog(ctx context.Context, errorURL string, maxBytes int) (string, error) {
+	l := logger.L().With("method", "FetchLoadErrorLog", "url", errorURL)
+
+	if errorURL == "" {
+		return "", errors.New(errors.InvalidArgument, "error URL cannot be empty")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, errorURL, nil)
+	if err != nil {
+		return "", errors.Wrap(err, errors.NetworkError, "failed to create error log request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		l.Warnw("Failed to fetch load error log", "error", err)
+		return "", errors.Wrap(err, errors.NetworkError, "failed to fetch load error log")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Newf(errors.DatabaseError, "failed to fetch load error log: %s", resp.Status)
+	}
+	var body io.Reader = resp.Body
+	if maxBytes > 0 {
+		body = io.LimitReader(resp.Body, int64(maxBytes))
+	}
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return "", errors.Wrap(err, errors.NetworkError, "failed to read load error log")
+	}
+	return string(bodyBytes), nil
+}
+
 // BeginTransaction begins a two-phase commit transaction for Stream Load.
 // BeginTransaction 开始一个两阶段提交事务 (用于Stream Load)。
 func (c *starrocksClient) BeginTransaction(ctx context.Context, database, table, label string, timeoutSeconds int) (int64, error) {
@@ -337,15 +478,20 @@ func (c *starrocksClient) BeginTransaction(ctx context.Context, database, table,
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
 	var srResp struct {
-		Status string `json:"Status"`
-		TxnID  int64  `json:"TxnId"`
-		Msg    string `json:"msg"`
+		Status        string `json:"Status"`
+		TxnID         int64  `json:"TxnId"`
+		ExistingTxnID int64  `json:"ExistingTxnId"`
+		Msg           string `json:"msg"`
 	}
 
 	if err := json.Unmarshal(bodyBytes, &srResp); err != nil {
 		return 0, errors.Wrapf(err, errors.DeserializationError, "failed to unmarshal begin transaction response: %s", string(bodyBytes))
 	}
 
+	if isLabelAlreadyExists(srResp.Status) {
+		l.Warnw("Transaction label already exists", "existing_txn_id", srResp.ExistingTxnID, "msg", srResp.Msg)
+		return srResp.ExistingTxnID, errors.Newf(errors.AlreadyExistsError, "transaction label %s already exists (existing txn %d)", label, srResp.ExistingTxnID)
+	}
 	if srResp.Status != "Success" {
 		l.Errorw("Failed to begin transaction", "status", srResp.Status, "msg", srResp.Msg)
 		return 0, errors.Newf(errors.DatabaseError, "failed to begin transaction: %s - %s", srResp.Status, srResp.Msg)
@@ -432,6 +578,49 @@ func (c *starrocksClient) AbortTransaction(ctx context.Context, database string,
 	return nil
 }
 
+// GetLoadState returns the state of the load job or transaction that used a label.
+// GetLoadState 返回使用某个标签的导入任务或事务的状态。
+func (c *starrocksClient) GetLoadState(ctx context.Context, database, label string) (string, error) {
+	l := logger.L().With("method", "GetLoadState", "database", database, "label", label)
+
+	// API: GET /api/{db}/get_load_state?label={label}
+	urlStr := fmt.Sprintf("%s/api/%s/get_load_state?label=%s", c.getFeBaseURL(), database, url.QueryEscape(label))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		return "", errors.Wrap(err, errors.NetworkError, "failed to create load state request")
+	}
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.cfg.User+":"+c.cfg.Password)))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, errors.NetworkError, "load state request failed")
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var srResp struct {
+		Status  string `json:"Status"`
+		Message string `json:"Message"`
+		State   string `json:"State"`
+	}
+	if err := json.Unmarshal(bodyBytes, &srResp); err != nil {
+		return "", errors.Wrapf(err, errors.DeserializationError, "failed to unmarshal load state response: %s", string(bodyBytes))
+	}
+	if !strings.EqualFold(srResp.Status, "OK") {
+		l.Errorw("Failed to get load state", "status", srResp.Status, "msg", srResp.Message)
+		return "", errors.Newf(errors.DatabaseError, "failed to get load state: %s - %s", srResp.Status, srResp.Message)
+	}
+	return strings.ToUpper(srResp.State), nil
+}
+
+// isLabelAlreadyExists reports whether a load status means the label has been used before.
+// StarRocks spells the status differently across APIs ("Label Already Exists", "LABEL_ALREADY_EXISTS").
+// isLabelAlreadyExists 判断加载状态是否表示该标签已被使用。
+func isLabelAlreadyExists(status string) bool {
+	normalized := strings.ReplaceAll(strings.ToUpper(status), "_", " ")
+	return normalized == strings.ToUpper(StreamLoadStatusLabelAlreadyExists)
+}
+
 // getFeBaseURL returns a base URL for one of the FE nodes.
 // getFeBaseURL 返回一个FE节点的基础URL。
 func (c *starrocksClient) getFeBaseURL() string {
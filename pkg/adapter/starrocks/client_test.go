@@ -0,0 +1,52 @@
+package starrocks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/config"
+)
+
+func TestFetchLoadErrorLog(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Query().Get("file") == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("Error: Value count does not match column count. Row: 1\tx"))
+	}))
+	defer server.Close()
+	c := &starrocksClient{cfg: config.StarRocksConfig{User: "root", Password: "secret"}, httpClient: server.Client()}
+
+	tests := []struct {
+		name     string
+		url      string
+		maxBytes int
+		want     string
+		wantErr  bool
+	}{
+		{name: "whole log", url: server.URL + "/api/_load_error_log?file=a", want: "Error: Value count does not match column count. Row: 1\tx"},
+		{name: "truncated log", url: server.URL + "/api/_load_error_log?file=a", maxBytes: 5, want: "Error"},
+		{name: "missing log", url: server.URL + "/api/_load_error_log?file=missing", wantErr: true},
+		{name: "empty url", url: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorization = ""
+			got, err := c.FetchLoadErrorLog(context.Background(), tt.url, tt.maxBytes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchLoadErrorLog() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FetchLoadErrorLog() = %q, want %q", got, tt.want)
+			}
+			if authorization != "" {
+				t.Errorf("FetchLoadErrorLog() sent Authorization %q to the error log host", authorization)
+			}
+		})
+	}
+}
@@ -0,0 +1,661 @@
+package starrocks
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// refreshSchedulePattern matches an async refresh schedule with an optional start time, e.g.,
+// "START('2024-01-01 00:00:00') EVERY(INTERVAL 1 HOUR)" or "EVERY 1 HOUR".
+// refreshSchedulePattern 匹配带有可选开始时间的异步刷新调度，例如 "START('2024-01-01 00:00:00') EVERY(INTERVAL 1 HOUR)"
+// 或 "EVERY 1 HOUR"。
+var refreshSchedulePattern = regexp.MustCompile(`(?i)^(?:START\s*\(\s*(?:'([^']*)'|"([^"]*)")\s*\)\s*)?(?:EVERY\s*)?\(?\s*(?:INTERVAL\s+)?(\d+)\s+(SECOND|MINUTE|HOUR|DAY|WEEK|MONTH|YEAR)S?\s*\)?$`)
+
+// columnTypePattern matches a column type made of type names, integer parameters and nested types, where the
+// field names of a STRUCT may be quoted with backticks, e.g., "DECIMAL(10,2)" or "STRUCT<`a b` INT>".
+// columnTypePattern 匹配由类型名、整数参数与嵌套类型组成的列类型，其中STRUCT的字段名可以用反引号括起，例如
+// "DECIMAL(10,2)" 或 "STRUCT<`a b` INT>"。
+var columnTypePattern = regexp.MustCompile("^(?:[A-Za-z0-9_<>(),: ]|`(?:[^`]|``)*`)+$")
+
+// aggregationTypes are the aggregation types a value column of an AGGREGATE table may use.
+// aggregationTypes 是AGGREGATE表的值列可使用的聚合类型。
+var aggregationTypes = map[string]bool{
+	"SUM":                 true,
+	"MAX":                 true,
+	"MIN":                 true,
+	"REPLACE":             true,
+	"REPLACE_IF_NOT_NULL": true,
+	"HLL_UNION":           true,
+	"BITMAP_UNION":        true,
+	"PERCENTILE_UNION":    true,
+}
+
+// dynamicPartitionTimeUnits are the time units supported by dynamic partitioning.
+// dynamicPartitionTimeUnits 是动态分区支持的时间单位。
+var dynamicPartitionTimeUnits = map[string]bool{"HOUR": true, "DAY": true, "WEEK": true, "MONTH": true, "YEAR": true}
+
+// BuildCreateTableDDL renders the CREATE TABLE statement of a table schema: columns with their aggregation types,
+// inline indexes, the keys clause, partitioning, distribution and properties, including the dynamic partition
+// properties. The schema is checked against the rules StarRocks applies, so that an invalid schema is reported
+// as InvalidArgument instead of failing on the server.
+// BuildCreateTableDDL 根据表结构生成CREATE TABLE语句：包括列及其聚合类型、内联索引、键子句、分区、分桶以及表属性
+// （含动态分区属性）。表结构会按StarRocks的规则进行检查，非法的表结构以InvalidArgument报告，而不是在服务端执行失败。
+func BuildCreateTableDDL(schema *TableSchemaDef) (string, error) {
+	if schema == nil || schema.TableName == "" {
+		return "", errors.New(errors.InvalidArgument, "table schema must have a table name")
+	}
+	if len(schema.Fields) == 0 {
+		return "", errors.Newf(errors.InvalidArgument, "table %s must have at least one column", schema.TableName)
+	}
+	keysType, keyColumns, err := resolveKeys(schema)
+	if err != nil {
+		return "", err
+	}
+	if err := checkColumns(schema, keysType, keyColumns); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE ")
+	if schema.IfNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+	sb.WriteString(qualifiedName(schema.DatabaseName, schema.TableName))
+	sb.WriteString(" (\n")
+	definitions := make([]string, 0, len(schema.Fields)+len(schema.Indexes))
+	for _, field := range schema.Fields {
+		definitions = append(definitions, "  "+columnDefinition(field))
+	}
+	for _, index := range schema.Indexes {
+		if index.IndexName == "" || len(index.Fields) == 0 {
+			return "", errors.Newf(errors.InvalidArgument, "index of table %s must have a name and at least one column", schema.TableName)
+		}
+		definitions = append(definitions, "  "+indexDefinition(index))
+	}
+	sb.WriteString(strings.Join(definitions, ",\n"))
+	sb.WriteString("\n)")
+
+	if keysType != "" {
+		sb.WriteString(fmt.Sprintf("\n%s KEY(%s)", keysType, identifierList(keyColumns)))
+	}
+	if schema.Comment != "" {
+		sb.WriteString("\nCOMMENT " + quoteString(schema.Comment))
+	}
+	properties := schema.Properties
+	if schema.Partition != nil {
+		partition, err := partitionClause(schema.TableName, schema.Partition, columnPositions(schema.Fields))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("\n" + partition)
+		if schema.Partition.Dynamic != nil {
+			if properties, err = withDynamicPartition(schema.TableName, properties, schema.Partition.Dynamic); err != nil {
+				return "", err
+			}
+		}
+	}
+	switch {
+	case len(schema.DistributionColumns) > 0:
+		sb.WriteString("\nDISTRIBUTED BY HASH(" + identifierList(schema.DistributionColumns) + ")")
+	case keysType != "" && keysType != KeysTypeDuplicate:
+		return "", errors.Newf(errors.InvalidArgument, "%s KEY table %s requires hash distribution columns", keysType, schema.TableName)
+	case schema.Buckets > 0:
+		sb.WriteString("\nDISTRIBUTED BY RANDOM")
+	}
+	if schema.Buckets > 0 {
+		sb.WriteString(fmt.Sprintf(" BUCKETS %d", schema.Buckets))
+	}
+	if len(properties) > 0 {
+		sb.WriteString("\nPROPERTIES " + propertiesClause(properties))
+	}
+	return sb.String(), nil
+}
+
+// resolveKeys returns the normalized keys type and the key columns of a schema. A schema without a keys type
+// but with IsKey columns is a DUPLICATE KEY table.
+// resolveKeys 返回表结构规范化后的键类型与键列。未指定键类型但有IsKey列的表结构视为DUPLICATE KEY表。
+func resolveKeys(schema *TableSchemaDef) (string, []string, error) {
+	keyColumns := schema.KeyColumns
+	if len(keyColumns) == 0 {
+		for _, field := range schema.Fields {
+			if field.IsKey {
+				keyColumns = append(keyColumns, field.Name)
+			}
+		}
+	}
+	keysType := NormalizeKeysType(schema.KeysType)
+	switch keysType {
+	case "":
+		if len(keyColumns) == 0 {
+			return "", nil, nil
+		}
+		keysType = KeysTypeDuplicate
+	case KeysTypeDuplicate, KeysTypeAggregate, KeysTypeUnique, KeysTypePrimary:
+	default:
+		return "", nil, errors.Newf(errors.InvalidArgument, "unknown keys type '%s' for table %s", schema.KeysType, schema.TableName)
+	}
+	if len(keyColumns) == 0 {
+		return "", nil, errors.Newf(errors.InvalidArgument, "%s KEY table %s must have at least one key column", keysType, schema.TableName)
+	}
+	return keysType, keyColumns, nil
+}
+
+// NormalizeKeysType upper-cases a keys type and strips a trailing " KEY", so that "duplicate key" and "DUPLICATE"
+// are the same keys type.
+// NormalizeKeysType 将键类型转为大写并去掉末尾的" KEY"，使"duplicate key"与"DUPLICATE"表示同一键类型。
+func NormalizeKeysType(keysType string) string {
+	keysType = strings.ToUpper(strings.TrimSpace(keysType))
+	keysType = strings.TrimSuffix(keysType, " KEYS")
+	return strings.TrimSpace(strings.TrimSuffix(keysType, " KEY"))
+}
+
+// checkColumns checks the columns against the keys: key columns must lead the table in key order, PRIMARY KEY
+// columns must not be nullable, and aggregation types are required on, and only allowed on, the value columns of
+// an AGGREGATE KEY table.
+// checkColumns 按键检查列：键列必须按键顺序位于表的最前面，PRIMARY KEY列不能为空，聚合类型只允许且必须出现在
+// AGGREGATE KEY表的值列上。
+func checkColumns(schema *TableSchemaDef, keysType string, keyColumns []string) error {
+	seen := make(map[string]bool, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if field.Name == "" || field.Type == "" {
+			return errors.Newf(errors.InvalidArgument, "column of table %s must have a name and a type", schema.TableName)
+		}
+		if err := checkColumnType(schema.TableName, field); err != nil {
+			return err
+		}
+		if seen[strings.ToLower(field.Name)] {
+			return errors.Newf(errors.InvalidArgument, "duplicate column '%s' in table %s", field.Name, schema.TableName)
+		}
+		seen[strings.ToLower(field.Name)] = true
+	}
+	for i, key := range keyColumns {
+		if i >= len(schema.Fields) || !strings.EqualFold(schema.Fields[i].Name, key) {
+			return errors.Newf(errors.InvalidArgument, "key columns of table %s must be its leading columns in key order, '%s' is not", schema.TableName, key)
+		}
+	}
+	for i, field := range schema.Fields {
+		aggregation := normalizeAggregationType(field.AggregationType)
+		if i < len(keyColumns) {
+			if aggregation != "" {
+				return errors.Newf(errors.InvalidArgument, "key column '%s' of table %s cannot have an aggregation type", field.Name, schema.TableName)
+			}
+			if keysType == KeysTypePrimary && field.IsNullable {
+				return errors.Newf(errors.InvalidArgument, "primary key column '%s' of table %s must be NOT NULL", field.Name, schema.TableName)
+			}
+			continue
+		}
+		switch {
+		case keysType != KeysTypeAggregate && aggregation != "":
+			return errors.Newf(errors.InvalidArgument, "column '%s' of table %s has aggregation type %s, which only AGGREGATE KEY tables allow", field.Name, schema.TableName, aggregation)
+		case keysType == KeysTypeAggregate && !aggregationTypes[aggregation]:
+			return errors.Newf(errors.InvalidArgument, "value column '%s' of AGGREGATE KEY table %s needs a valid aggregation type, got '%s'", field.Name, schema.TableName, field.AggregationType)
+		}
+	}
+	return nil
+}
+
+// partitionClause renders the PARTITION BY clause of a partition definition.
+// partitionClause 生成分区定义的PARTITION BY子句。
+func partitionClause(table string, partition *PartitionDef, positions map[string]int) (string, error) {
+	for _, column := range partition.Columns {
+		if _, ok := positions[strings.ToLower(column)]; !ok {
+			return "", errors.Newf(errors.InvalidArgument, "partition column '%s' is not a column of table %s", column, table)
+		}
+	}
+	switch strings.ToUpper(partition.Type) {
+	case "", PartitionTypeExpression:
+		if len(partition.Ranges) > 0 || partition.Dynamic != nil {
+			return "", errors.Newf(errors.InvalidArgument, "initial and dynamic partitions of table %s require RANGE partitioning", table)
+		}
+		if partition.Expression != "" {
+			expression, err := partitionExpression(table, partition.Expression, positions)
+			if err != nil {
+				return "", err
+			}
+			return "PARTITION BY " + expression, nil
+		}
+		if len(partition.Columns) == 0 {
+			return "", errors.Newf(errors.InvalidArgument, "expression partitioning of table %s needs an expression or columns", table)
+		}
+		return "PARTITION BY (" + identifierList(partition.Columns) + ")", nil
+	case PartitionTypeRange:
+		if len(partition.Columns) == 0 {
+			return "", errors.Newf(errors.InvalidArgument, "RANGE partitioning of table %s needs at least one column", table)
+		}
+		ranges := make([]string, 0, len(partition.Ranges))
+		for _, r := range partition.Ranges {
+			if r.Name == "" {
+				return "", errors.Newf(errors.InvalidArgument, "RANGE partition of table %s must have a name", table)
+			}
+			if (len(r.Lower) != 0 && len(r.Lower) != len(partition.Columns)) || (len(r.Upper) != 0 && len(r.Upper) != len(partition.Columns)) {
+				return "", errors.Newf(errors.InvalidArgument, "bounds of partition %s of table %s need one value per partition column", r.Name, table)
+			}
+			switch {
+			case len(r.Lower) == 0 && len(r.Upper) == 0:
+				ranges = append(ranges, fmt.Sprintf("  PARTITION %s VALUES LESS THAN MAXVALUE", quoteIdentifier(r.Name)))
+			case len(r.Lower) == 0:
+				ranges = append(ranges, fmt.Sprintf("  PARTITION %s VALUES LESS THAN %s", quoteIdentifier(r.Name), valueList(r.Upper)))
+			case len(r.Upper) == 0:
+				return "", errors.Newf(errors.InvalidArgument, "partition %s of table %s has a lower bound but no upper bound", r.Name, table)
+			default:
+				ranges = append(ranges, fmt.Sprintf("  PARTITION %s VALUES [%s, %s)", quoteIdentifier(r.Name), valueList(r.Lower), valueList(r.Upper)))
+			}
+		}
+		clause := "PARTITION BY RANGE(" + identifierList(partition.Columns) + ") ("
+		if len(ranges) > 0 {
+			clause += "\n" + strings.Join(ranges, ",\n") + "\n"
+		}
+		return clause + ")", nil
+	default:
+		return "", errors.Newf(errors.InvalidArgument, "unknown partition type '%s' for table %s", partition.Type, table)
+	}
+}
+
+// withDynamicPartition returns a copy of properties with the dynamic partition properties added.
+// withDynamicPartition 返回添加了动态分区属性的properties副本。
+func withDynamicPartition(table string, properties map[string]string, dynamic *DynamicPartitionDef) (map[string]string, error) {
+	unit := strings.ToUpper(dynamic.TimeUnit)
+	if !dynamicPartitionTimeUnits[unit] {
+		return nil, errors.Newf(errors.InvalidArgument, "unknown dynamic partition time unit '%s' for table %s", dynamic.TimeUnit, table)
+	}
+	if dynamic.End <= 0 {
+		return nil, errors.Newf(errors.InvalidArgument, "dynamic partitions of table %s must create at least one partition ahead", table)
+	}
+	if dynamic.Start > 0 {
+		return nil, errors.Newf(errors.InvalidArgument, "dynamic partition start of table %s must not be positive", table)
+	}
+	merged := make(map[string]string, len(properties)+6)
+	for k, v := range properties {
+		merged[k] = v
+	}
+	prefix := dynamic.Prefix
+	if prefix == "" {
+		prefix = "p"
+	}
+	merged["dynamic_partition.enable"] = "true"
+	merged["dynamic_partition.time_unit"] = unit
+	merged["dynamic_partition.end"] = strconv.Itoa(dynamic.End)
+	merged["dynamic_partition.prefix"] = prefix
+	if dynamic.Start < 0 {
+		merged["dynamic_partition.start"] = strconv.Itoa(dynamic.Start)
+	}
+	if dynamic.Buckets > 0 {
+		merged["dynamic_partition.buckets"] = strconv.Itoa(dynamic.Buckets)
+	}
+	if dynamic.HistoryPartitionNum > 0 {
+		merged["dynamic_partition.history_partition_num"] = strconv.Itoa(dynamic.HistoryPartitionNum)
+	}
+	return merged, nil
+}
+
+// BuildAddColumnsDDL renders an ALTER TABLE statement adding the given columns to a table.
+// BuildAddColumnsDDL 生成向表中添加给定列的ALTER TABLE语句。
+func BuildAddColumnsDDL(database, table string, fields []FieldSchemaDef) (string, error) {
+	if table == "" || len(fields) == 0 {
+		return "", errors.New(errors.InvalidArgument, "adding columns requires a table and at least one column")
+	}
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Name == "" || field.Type == "" {
+			return "", errors.Newf(errors.InvalidArgument, "column added to table %s must have a name and a type", table)
+		}
+		if err := checkColumnType(table, field); err != nil {
+			return "", err
+		}
+		columns = append(columns, alteredColumnDefinition(field))
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN (%s)", qualifiedName(database, table), strings.Join(columns, ", ")), nil
+}
+
+// checkColumnType rejects a column type that holds anything but a type, since the type is written to the DDL
+// as it is.
+// checkColumnType 拒绝包含类型以外内容的列类型，因为类型会原样写入DDL。
+func checkColumnType(table string, field FieldSchemaDef) error {
+	if !columnTypePattern.MatchString(field.Type) {
+		return errors.Newf(errors.InvalidArgument, "column '%s' of table %s has an invalid type %q", field.Name, table, field.Type)
+	}
+	return nil
+}
+
+// BuildDropColumnDDL renders an ALTER TABLE statement dropping a column from a table.
+// BuildDropColumnDDL 生成从表中删除列的ALTER TABLE语句。
+func BuildDropColumnDDL(database, table, column string) (string, error) {
+	if table == "" || column == "" {
+		return "", errors.New(errors.InvalidArgument, "dropping a column requires a table and a column name")
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", qualifiedName(database, table), quoteIdentifier(column)), nil
+}
+
+// BuildModifyColumnDDL renders an ALTER TABLE statement changing the definition of an existing column.
+// BuildModifyColumnDDL 生成修改已有列定义的ALTER TABLE语句。
+func BuildModifyColumnDDL(database, table string, field FieldSchemaDef) (string, error) {
+	if table == "" || field.Name == "" || field.Type == "" {
+		return "", errors.New(errors.InvalidArgument, "modifying a column requires a table, a column name and a type")
+	}
+	if err := checkColumnType(table, field); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", qualifiedName(database, table), alteredColumnDefinition(field)), nil
+}
+
+// BuildSchemaChangeDDL renders one ALTER TABLE statement adding, modifying and dropping columns of a table.
+// StarRocks runs a schema change as an asynchronous job and rejects another one on the table until it finishes,
+// so changes made together have to be sent as one statement.
+// BuildSchemaChangeDDL 生成一条添加、修改与删除表列的ALTER TABLE语句。StarRocks以异步任务执行模式变更，并在其完成
+// 之前拒绝该表上的其他模式变更，因此需要一起进行的变更必须作为一条语句发送。
+func BuildSchemaChangeDDL(database, table string, added, modified []FieldSchemaDef, dropped []string) (string, error) {
+	if table == "" || len(added)+len(modified)+len(dropped) == 0 {
+		return "", errors.New(errors.InvalidArgument, "a schema change requires a table and at least one column change")
+	}
+	clauses := make([]string, 0, len(modified)+len(dropped)+1)
+	if len(added) > 0 {
+		columns := make([]string, 0, len(added))
+		for _, field := range added {
+			if field.Name == "" || field.Type == "" {
+				return "", errors.Newf(errors.InvalidArgument, "column added to table %s must have a name and a type", table)
+			}
+			if err := checkColumnType(table, field); err != nil {
+				return "", err
+			}
+			columns = append(columns, alteredColumnDefinition(field))
+		}
+		clauses = append(clauses, "ADD COLUMN ("+strings.Join(columns, ", ")+")")
+	}
+	for _, field := range modified {
+		if field.Name == "" || field.Type == "" {
+			return "", errors.Newf(errors.InvalidArgument, "column modified in table %s must have a name and a type", table)
+		}
+		if err := checkColumnType(table, field); err != nil {
+			return "", err
+		}
+		clauses = append(clauses, "MODIFY COLUMN "+alteredColumnDefinition(field))
+	}
+	for _, column := range dropped {
+		if column == "" {
+			return "", errors.Newf(errors.InvalidArgument, "column dropped from table %s must have a name", table)
+		}
+		clauses = append(clauses, "DROP COLUMN "+quoteIdentifier(column))
+	}
+	return fmt.Sprintf("ALTER TABLE %s %s", qualifiedName(database, table), strings.Join(clauses, ", ")), nil
+}
+
+// BuildRenameTableDDL renders an ALTER TABLE statement renaming a table within its database.
+// BuildRenameTableDDL 生成在库内重命名表的ALTER TABLE语句。
+func BuildRenameTableDDL(database, table, newTable string) (string, error) {
+	if table == "" || newTable == "" {
+		return "", errors.New(errors.InvalidArgument, "renaming a table requires the current and the new table name")
+	}
+	return fmt.Sprintf("ALTER TABLE %s RENAME %s", qualifiedName(database, table), quoteIdentifier(newTable)), nil
+}
+
+// BuildDropTableDDL renders the DROP TABLE statement of a table.
+// BuildDropTableDDL 生成表的DROP TABLE语句。
+func BuildDropTableDDL(database, table string, ifExists bool) (string, error) {
+	if table == "" {
+		return "", errors.New(errors.InvalidArgument, "dropping a table requires a table name")
+	}
+	if ifExists {
+		return "DROP TABLE IF EXISTS " + qualifiedName(database, table), nil
+	}
+	return "DROP TABLE " + qualifiedName(database, table), nil
+}
+
+// columnDefinition renders a column as "`name` TYPE [AGGREGATION] [NOT] NULL [DEFAULT ...] [COMMENT ...]".
+// columnDefinition 将列生成为 "`name` TYPE [AGGREGATION] [NOT] NULL [DEFAULT ...] [COMMENT ...]"。
+func columnDefinition(field FieldSchemaDef) string {
+	var sb strings.Builder
+	sb.WriteString(quoteIdentifier(field.Name) + " " + field.Type)
+	if aggregation := normalizeAggregationType(field.AggregationType); aggregation != "" {
+		sb.WriteString(" " + aggregation)
+	}
+	if field.IsNullable {
+		sb.WriteString(" NULL")
+	} else {
+		sb.WriteString(" NOT NULL")
+	}
+	if field.DefaultValue != "" {
+		if strings.EqualFold(field.DefaultValue, "CURRENT_TIMESTAMP") {
+			sb.WriteString(" DEFAULT CURRENT_TIMESTAMP")
+		} else {
+			sb.WriteString(" DEFAULT " + quoteString(field.DefaultValue))
+		}
+	}
+	if field.Comment != "" {
+		sb.WriteString(" COMMENT " + quoteString(field.Comment))
+	}
+	return sb.String()
+}
+
+// BuildCreateIndexDDL renders an ALTER TABLE statement adding an index to a table.
+// BuildCreateIndexDDL 生成向表中添加索引的ALTER TABLE语句。
+func BuildCreateIndexDDL(database, table string, index *IndexDefinitionDef) (string, error) {
+	if table == "" || index == nil || index.IndexName == "" || index.IndexType == "" || len(index.Fields) == 0 {
+		return "", errors.New(errors.InvalidArgument, "creating an index requires a table, an index name, a type and at least one column")
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", qualifiedName(database, table), indexDefinition(*index)), nil
+}
+
+// BuildDropIndexDDL renders an ALTER TABLE statement dropping an index from a table.
+// BuildDropIndexDDL 生成从表中删除索引的ALTER TABLE语句。
+func BuildDropIndexDDL(database, table, indexName string) (string, error) {
+	if table == "" || indexName == "" {
+		return "", errors.New(errors.InvalidArgument, "dropping an index requires a table and an index name")
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", qualifiedName(database, table), quoteIdentifier(indexName)), nil
+}
+
+// BuildCreateMaterializedViewDDL renders the CREATE MATERIALIZED VIEW statement of a view: its comment, refresh
+// scheme, properties and defining query.
+// BuildCreateMaterializedViewDDL 生成物化视图的CREATE MATERIALIZED VIEW语句：包括注释、刷新方式、属性与定义查询。
+func BuildCreateMaterializedViewDDL(mv *MaterializedViewDef) (string, error) {
+	if mv == nil || mv.ViewName == "" {
+		return "", errors.New(errors.InvalidArgument, "materialized view must have a name")
+	}
+	query := strings.TrimRight(strings.TrimSpace(mv.Query), "; \t\n")
+	if query == "" {
+		return "", errors.Newf(errors.InvalidArgument, "materialized view %s must have a defining query", mv.ViewName)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("CREATE MATERIALIZED VIEW " + qualifiedName(mv.DatabaseName, mv.ViewName))
+	if mv.Comment != "" {
+		sb.WriteString("\nCOMMENT " + quoteString(mv.Comment))
+	}
+	refresh := strings.ToUpper(strings.TrimSpace(mv.RefreshType))
+	if refresh == "" && strings.TrimSpace(mv.RefreshSchedule) != "" {
+		refresh = "ASYNC" // a schedule implies asynchronous refresh
+	}
+	switch refresh {
+	case "":
+	case "ASYNC":
+		schedule, err := RefreshScheduleClause(mv.RefreshSchedule)
+		if err != nil {
+			return "", errors.Wrapf(err, errors.InvalidArgument, "invalid refresh schedule of materialized view %s", mv.ViewName)
+		}
+		sb.WriteString("\nREFRESH ASYNC")
+		if schedule != "" {
+			sb.WriteString(" " + schedule)
+		}
+	case "MANUAL":
+		if strings.TrimSpace(mv.RefreshSchedule) != "" {
+			return "", errors.Newf(errors.InvalidArgument, "materialized view %s has a refresh schedule but is refreshed manually", mv.ViewName)
+		}
+		sb.WriteString("\nREFRESH MANUAL")
+	default:
+		return "", errors.Newf(errors.InvalidArgument, "unsupported refresh type %q of materialized view %s", mv.RefreshType, mv.ViewName)
+	}
+	if len(mv.Properties) > 0 {
+		sb.WriteString("\nPROPERTIES " + propertiesClause(mv.Properties))
+	}
+	sb.WriteString("\nAS " + query)
+	return sb.String(), nil
+}
+
+// BuildDropMaterializedViewDDL renders the DROP MATERIALIZED VIEW statement of a view.
+// BuildDropMaterializedViewDDL 生成物化视图的DROP MATERIALIZED VIEW语句。
+func BuildDropMaterializedViewDDL(database, view string, ifExists bool) (string, error) {
+	if view == "" {
+		return "", errors.New(errors.InvalidArgument, "dropping a materialized view requires its name")
+	}
+	if ifExists {
+		return "DROP MATERIALIZED VIEW IF EXISTS " + qualifiedName(database, view), nil
+	}
+	return "DROP MATERIALIZED VIEW " + qualifiedName(database, view), nil
+}
+
+// RefreshScheduleClause renders the schedule of an asynchronously refreshed materialized view as
+// "[START('<time>')] EVERY(INTERVAL <n> <unit>)". The schedule may be written in that form or as "EVERY 1 HOUR",
+// "INTERVAL 1 HOUR" or "1 HOUR"; an empty schedule renders nothing, leaving StarRocks to refresh the view when its
+// base tables change.
+// RefreshScheduleClause 将异步刷新物化视图的调度生成为 "[START('<time>')] EVERY(INTERVAL <n> <unit>)"。调度可以按此形式
+// 书写，也可以写作 "EVERY 1 HOUR"、"INTERVAL 1 HOUR" 或 "1 HOUR"；空调度不生成任何内容，由StarRocks在基表变化时刷新视图。
+func RefreshScheduleClause(schedule string) (string, error) {
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		return "", nil
+	}
+	m := refreshSchedulePattern.FindStringSubmatch(schedule)
+	if m == nil {
+		return "", errors.Newf(errors.InvalidArgument, "unrecognized refresh schedule %q, expected e.g. \"EVERY(INTERVAL 1 HOUR)\"", schedule)
+	}
+	interval, err := strconv.Atoi(m[3])
+	if err != nil || interval <= 0 {
+		return "", errors.Newf(errors.InvalidArgument, "refresh interval of schedule %q must be a positive integer", schedule)
+	}
+	clause := fmt.Sprintf("EVERY(INTERVAL %d %s)", interval, strings.ToUpper(m[4]))
+	if start := m[1] + m[2]; start != "" {
+		clause = fmt.Sprintf("START(%s) %s", quoteString(start), clause)
+	}
+	return clause, nil
+}
+
+// BuildRefreshMaterializedViewDDL renders the statement refreshing a materialized view. With force the partitions
+// are refreshed even when their base tables did not change; with sync the statement returns once the refresh
+// finishes, otherwise it only submits the refresh task.
+// BuildRefreshMaterializedViewDDL 生成刷新物化视图的语句。force为true时即使基表未变化也刷新分区；sync为true时语句在
+// 刷新完成后才返回，否则仅提交刷新任务。
+func BuildRefreshMaterializedViewDDL(database, view string, force, sync bool) (string, error) {
+	if view == "" {
+		return "", errors.New(errors.InvalidArgument, "refreshing a materialized view requires its name")
+	}
+	statement := "REFRESH MATERIALIZED VIEW " + qualifiedName(database, view)
+	if force {
+		statement += " FORCE"
+	}
+	if sync {
+		return statement + " WITH SYNC MODE", nil
+	}
+	return statement + " WITH ASYNC MODE", nil
+}
+
+// BuildAlterMaterializedViewStateDDL renders the statement activating or deactivating a materialized view. An
+// inactive view is neither refreshed nor used to rewrite queries; activating it resumes its refresh schedule.
+// BuildAlterMaterializedViewStateDDL 生成激活或停用物化视图的语句。停用的视图既不刷新也不用于查询改写，激活后恢复其刷新调度。
+func BuildAlterMaterializedViewStateDDL(database, view string, active bool) (string, error) {
+	if view == "" {
+		return "", errors.New(errors.InvalidArgument, "altering a materialized view requires its name")
+	}
+	if active {
+		return "ALTER MATERIALIZED VIEW " + qualifiedName(database, view) + " ACTIVE", nil
+	}
+	return "ALTER MATERIALIZED VIEW " + qualifiedName(database, view) + " INACTIVE", nil
+}
+
+// alteredColumnDefinition renders a column of an ALTER TABLE statement, where StarRocks expects a key column to be
+// marked KEY in place of an aggregation type.
+// alteredColumnDefinition 生成ALTER TABLE语句中的列定义，StarRocks要求键列在聚合类型的位置标记为KEY。
+func alteredColumnDefinition(field FieldSchemaDef) string {
+	if !field.IsKey {
+		return columnDefinition(field)
+	}
+	field.AggregationType = ""
+	head := quoteIdentifier(field.Name) + " " + field.Type
+	return head + " KEY" + strings.TrimPrefix(columnDefinition(field), head)
+}
+
+// indexDefinition renders an index clause of a CREATE TABLE statement.
+// indexDefinition 生成CREATE TABLE语句中的索引子句。
+func indexDefinition(index IndexDefinitionDef) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("INDEX %s (%s) USING %s", quoteIdentifier(index.IndexName), identifierList(index.Fields), strings.ToUpper(index.IndexType)))
+	if len(index.Properties) > 0 {
+		sb.WriteString(" PROPERTIES" + propertiesClause(index.Properties))
+	}
+	if index.Comment != "" {
+		sb.WriteString(" COMMENT " + quoteString(index.Comment))
+	}
+	return sb.String()
+}
+
+// propertiesClause renders properties as ("k" = "v", ...) in key order, so that the DDL is deterministic.
+// propertiesClause 按键排序将属性生成为 ("k" = "v", ...)，以保证DDL的确定性。
+func propertiesClause(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s = %s", quoteProperty(k), quoteProperty(props[k])))
+	}
+	return "(" + strings.Join(pairs, ", ") + ")"
+}
+
+// normalizeAggregationType upper-cases an aggregation type; "NONE", as DESCRIBE reports key columns, is empty.
+// normalizeAggregationType 将聚合类型转为大写；DESCRIBE对键列报告的"NONE"视为空。
+func normalizeAggregationType(aggregation string) string {
+	aggregation = strings.ToUpper(strings.TrimSpace(aggregation))
+	if aggregation == "NONE" {
+		return ""
+	}
+	return aggregation
+}
+
+func columnPositions(fields []FieldSchemaDef) map[string]int {
+	positions := make(map[string]int, len(fields))
+	for i, field := range fields {
+		positions[strings.ToLower(field.Name)] = i
+	}
+	return positions
+}
+
+func valueList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteProperty(value)
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+func qualifiedName(database, table string) string {
+	if database == "" {
+		return quoteIdentifier(table)
+	}
+	return quoteIdentifier(database) + "." + quoteIdentifier(table)
+}
+
+func identifierList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func quoteProperty(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,319 @@
+package starrocks
+
+import (
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+func TestBuildCreateTableDDL(t *testing.T) {
+	eventFields := func() []FieldSchemaDef {
+		return []FieldSchemaDef{
+			{Name: "event_time", Type: "DATETIME", IsKey: true},
+			{Name: "host", Type: "VARCHAR(64)", IsKey: true},
+			{Name: "message", Type: "STRING", IsNullable: true, Comment: "raw 'line'"},
+		}
+	}
+	tests := []struct {
+		name    string
+		schema  *TableSchemaDef
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "plain table",
+			schema: &TableSchemaDef{
+				DatabaseName: "db",
+				TableName:    "t",
+				Fields:       []FieldSchemaDef{{Name: "id", Type: "BIGINT"}, {Name: "ts", Type: "DATETIME", DefaultValue: "current_timestamp"}},
+				IfNotExists:  true,
+			},
+			want: "CREATE TABLE IF NOT EXISTS `db`.`t` (\n  `id` BIGINT NOT NULL,\n  `ts` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP\n)",
+		},
+		{
+			name: "duplicate keys from key columns",
+			schema: &TableSchemaDef{
+				TableName:           "events",
+				Fields:              eventFields(),
+				DistributionColumns: []string{"host"},
+				Buckets:             8,
+				Properties:          map[string]string{"replication_num": "1", "compression": "ZSTD"},
+				Comment:             "events",
+			},
+			want: "CREATE TABLE `events` (\n" +
+				"  `event_time` DATETIME NOT NULL,\n" +
+				"  `host` VARCHAR(64) NOT NULL,\n" +
+				"  `message` STRING NULL COMMENT 'raw \\'line\\''\n" +
+				")\nDUPLICATE KEY(`event_time`, `host`)\nCOMMENT 'events'\nDISTRIBUTED BY HASH(`host`) BUCKETS 8\n" +
+				"PROPERTIES (\"compression\" = \"ZSTD\", \"replication_num\" = \"1\")",
+		},
+		{
+			name: "aggregate keys",
+			schema: &TableSchemaDef{
+				TableName: "stats",
+				KeysType:  "aggregate key",
+				Fields: []FieldSchemaDef{
+					{Name: "day", Type: "DATE", IsKey: true},
+					{Name: "hits", Type: "BIGINT", AggregationType: "sum"},
+				},
+				DistributionColumns: []string{"day"},
+			},
+			want: "CREATE TABLE `stats` (\n  `day` DATE NOT NULL,\n  `hits` BIGINT SUM NOT NULL\n)\nAGGREGATE KEY(`day`)\nDISTRIBUTED BY HASH(`day`)",
+		},
+		{
+			name:   "random distribution",
+			schema: &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "INT"}}, Buckets: 4},
+			want:   "CREATE TABLE `t` (\n  `a` INT NOT NULL\n)\nDISTRIBUTED BY RANDOM BUCKETS 4",
+		},
+		{
+			name: "range partitions",
+			schema: &TableSchemaDef{
+				TableName: "events",
+				Fields:    eventFields(),
+				Partition: &PartitionDef{
+					Type:    "range",
+					Columns: []string{"event_time"},
+					Ranges: []RangePartitionDef{
+						{Name: "p1", Lower: []string{"2024-01-01"}, Upper: []string{"2024-02-01"}},
+						{Name: "p0", Upper: []string{"2024-01-01"}},
+						{Name: "pmax"},
+					},
+				},
+			},
+			want: "CREATE TABLE `events` (\n" +
+				"  `event_time` DATETIME NOT NULL,\n" +
+				"  `host` VARCHAR(64) NOT NULL,\n" +
+				"  `message` STRING NULL COMMENT 'raw \\'line\\''\n" +
+				")\nDUPLICATE KEY(`event_time`, `host`)\nPARTITION BY RANGE(`event_time`) (\n" +
+				"  PARTITION `p1` VALUES [(\"2024-01-01\"), (\"2024-02-01\")),\n" +
+				"  PARTITION `p0` VALUES LESS THAN (\"2024-01-01\"),\n" +
+				"  PARTITION `pmax` VALUES LESS THAN MAXVALUE\n)",
+		},
+		{
+			name: "dynamic partitions",
+			schema: &TableSchemaDef{
+				TableName: "t",
+				Fields:    []FieldSchemaDef{{Name: "d", Type: "DATE"}},
+				Partition: &PartitionDef{Type: "RANGE", Columns: []string{"d"}, Dynamic: &DynamicPartitionDef{TimeUnit: "day", Start: -7, End: 3}},
+			},
+			want: "CREATE TABLE `t` (\n  `d` DATE NOT NULL\n)\nPARTITION BY RANGE(`d`) ()\n" +
+				"PROPERTIES (\"dynamic_partition.enable\" = \"true\", \"dynamic_partition.end\" = \"3\", " +
+				"\"dynamic_partition.prefix\" = \"p\", \"dynamic_partition.start\" = \"-7\", \"dynamic_partition.time_unit\" = \"DAY\")",
+		},
+		{
+			name: "expression partitioning",
+			schema: &TableSchemaDef{
+				TableName: "events",
+				Fields:    eventFields(),
+				Partition: &PartitionDef{Expression: "date_trunc('day',event_time)"},
+			},
+			want: "CREATE TABLE `events` (\n" +
+				"  `event_time` DATETIME NOT NULL,\n" +
+				"  `host` VARCHAR(64) NOT NULL,\n" +
+				"  `message` STRING NULL COMMENT 'raw \\'line\\''\n" +
+				")\nDUPLICATE KEY(`event_time`, `host`)\nPARTITION BY date_trunc('day', `event_time`)",
+		},
+		{
+			name: "column partitioning",
+			schema: &TableSchemaDef{
+				TableName: "t",
+				Fields:    []FieldSchemaDef{{Name: "region", Type: "VARCHAR(8)"}},
+				Partition: &PartitionDef{Columns: []string{"region"}},
+			},
+			want: "CREATE TABLE `t` (\n  `region` VARCHAR(8) NOT NULL\n)\nPARTITION BY (`region`)",
+		},
+		{name: "no table name", schema: &TableSchemaDef{Fields: []FieldSchemaDef{{Name: "a", Type: "INT"}}}, wantErr: true},
+		{name: "no columns", schema: &TableSchemaDef{TableName: "t"}, wantErr: true},
+		{name: "unknown keys type", schema: &TableSchemaDef{TableName: "t", KeysType: "HASH", Fields: []FieldSchemaDef{{Name: "a", Type: "INT", IsKey: true}}}, wantErr: true},
+		{name: "keys type without key columns", schema: &TableSchemaDef{TableName: "t", KeysType: "PRIMARY", Fields: []FieldSchemaDef{{Name: "a", Type: "INT"}}}, wantErr: true},
+		{
+			name:    "key column not leading",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "INT"}, {Name: "b", Type: "INT", IsKey: true}}},
+			wantErr: true,
+		},
+		{
+			name:    "nullable primary key",
+			schema:  &TableSchemaDef{TableName: "t", KeysType: "PRIMARY", Fields: []FieldSchemaDef{{Name: "a", Type: "INT", IsKey: true, IsNullable: true}}, DistributionColumns: []string{"a"}},
+			wantErr: true,
+		},
+		{
+			name:    "aggregate value column without aggregation",
+			schema:  &TableSchemaDef{TableName: "t", KeysType: "AGGREGATE", Fields: []FieldSchemaDef{{Name: "a", Type: "INT", IsKey: true}, {Name: "b", Type: "INT"}}, DistributionColumns: []string{"a"}},
+			wantErr: true,
+		},
+		{
+			name:    "aggregation outside an aggregate table",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "INT", IsKey: true}, {Name: "b", Type: "INT", AggregationType: "SUM"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unique keys without hash distribution",
+			schema:  &TableSchemaDef{TableName: "t", KeysType: "UNIQUE", Fields: []FieldSchemaDef{{Name: "a", Type: "INT", IsKey: true}}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate column",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "INT"}, {Name: "A", Type: "INT"}}},
+			wantErr: true,
+		},
+		{
+			name:    "type carrying sql",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "INT) ENGINE=mysql; DROP TABLE t; --"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown partition column",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "INT"}}, Partition: &PartitionDef{Type: "RANGE", Columns: []string{"b"}}},
+			wantErr: true,
+		},
+		{
+			name:    "range bounds of the wrong arity",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "INT"}}, Partition: &PartitionDef{Type: "RANGE", Columns: []string{"a"}, Ranges: []RangePartitionDef{{Name: "p", Upper: []string{"1", "2"}}}}},
+			wantErr: true,
+		},
+		{
+			name:    "dynamic partitions without range partitioning",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "DATE"}}, Partition: &PartitionDef{Columns: []string{"a"}, Dynamic: &DynamicPartitionDef{TimeUnit: "DAY", End: 3}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown dynamic partition time unit",
+			schema:  &TableSchemaDef{TableName: "t", Fields: []FieldSchemaDef{{Name: "a", Type: "DATE"}}, Partition: &PartitionDef{Type: "RANGE", Columns: []string{"a"}, Dynamic: &DynamicPartitionDef{TimeUnit: "SECOND", End: 3}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildCreateTableDDL(tt.schema)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildCreateTableDDL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, errors.InvalidArgument) {
+					t.Errorf("BuildCreateTableDDL() error code = %s, want InvalidArgument", errors.GetCode(err))
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BuildCreateTableDDL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionExpression(t *testing.T) {
+	positions := columnPositions([]FieldSchemaDef{{Name: "event_time"}, {Name: "Region"}})
+	tests := []struct {
+		expression string
+		want       string
+		wantErr    bool
+	}{
+		{expression: "event_time", want: "`event_time`"},
+		{expression: "date_trunc('day', event_time)", want: "date_trunc('day', `event_time`)"},
+		{expression: "time_slice(event_time, INTERVAL 1 hour), region", want: "time_slice(`event_time`, INTERVAL 1 HOUR), `region`"},
+		{expression: "str2date(`Region`, '%Y\\'%m')", want: "str2date(`Region`, '%Y\\'%m')"},
+		{expression: "(event_time, region)", want: "(`event_time`, `region`)"},
+		{expression: "f(g(event_time), 10)", want: "f(g(`event_time`), 10)"},
+		{expression: "unknown_column", wantErr: true},
+		{expression: "event_time; DROP TABLE t", wantErr: true},
+		{expression: "date_trunc('day', event_time)) -- ", wantErr: true},
+		{expression: "date_trunc('day, event_time)", wantErr: true},
+		{expression: "`event_time", wantErr: true},
+		{expression: "time_slice(event_time, INTERVAL 1 FORTNIGHT)", wantErr: true},
+		{expression: "time_slice(event_time, INTERVAL x HOUR)", wantErr: true},
+		{expression: "event_time + 1", wantErr: true},
+		{expression: "(SELECT 1)", wantErr: true},
+		{expression: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := partitionExpression("t", tt.expression, positions)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("partitionExpression(%q) error = %v, wantErr %v", tt.expression, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("partitionExpression(%q) = %q, want %q", tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestBuildAlterColumnDDL(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func() (string, error)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "add columns",
+			build: func() (string, error) {
+				return BuildAddColumnsDDL("db", "t", []FieldSchemaDef{{Name: "a", Type: "INT", IsNullable: true}, {Name: "b", Type: "STRUCT<`x y` INT>", DefaultValue: "1"}})
+			},
+			want: "ALTER TABLE `db`.`t` ADD COLUMN (`a` INT NULL, `b` STRUCT<`x y` INT> NOT NULL DEFAULT '1')",
+		},
+		{
+			name: "add column with a type carrying sql",
+			build: func() (string, error) {
+				return BuildAddColumnsDDL("db", "t", []FieldSchemaDef{{Name: "a", Type: "INT COMMENT 'x'"}})
+			},
+			wantErr: true,
+		},
+		{
+			name:    "add no columns",
+			build:   func() (string, error) { return BuildAddColumnsDDL("db", "t", nil) },
+			wantErr: true,
+		},
+		{
+			name: "modify column",
+			build: func() (string, error) {
+				return BuildModifyColumnDDL("", "t", FieldSchemaDef{Name: "a", Type: "BIGINT", IsNullable: true})
+			},
+			want: "ALTER TABLE `t` MODIFY COLUMN `a` BIGINT NULL",
+		},
+		{
+			name: "modify column with a type carrying sql",
+			build: func() (string, error) {
+				return BuildModifyColumnDDL("", "t", FieldSchemaDef{Name: "a", Type: "BIGINT; DROP TABLE t"})
+			},
+			wantErr: true,
+		},
+		{
+			name: "schema change",
+			build: func() (string, error) {
+				return BuildSchemaChangeDDL("db", "t",
+					[]FieldSchemaDef{{Name: "a", Type: "INT", IsNullable: true}, {Name: "b", Type: "DATE", IsNullable: true}},
+					[]FieldSchemaDef{{Name: "k", Type: "BIGINT", IsKey: true}},
+					[]string{"old"})
+			},
+			want: "ALTER TABLE `db`.`t` ADD COLUMN (`a` INT NULL, `b` DATE NULL), MODIFY COLUMN `k` BIGINT KEY NOT NULL, DROP COLUMN `old`",
+		},
+		{
+			name:  "schema change dropping columns only",
+			build: func() (string, error) { return BuildSchemaChangeDDL("", "t", nil, nil, []string{"a", "b"}) },
+			want:  "ALTER TABLE `t` DROP COLUMN `a`, DROP COLUMN `b`",
+		},
+		{
+			name: "schema change with a type carrying sql",
+			build: func() (string, error) {
+				return BuildSchemaChangeDDL("", "t", nil, []FieldSchemaDef{{Name: "a", Type: "INT; DROP TABLE t"}}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "schema change without changes",
+			build:   func() (string, error) { return BuildSchemaChangeDDL("db", "t", nil, nil, nil) },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
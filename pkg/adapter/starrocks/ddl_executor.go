@@ -50,31 +50,19 @@ func (e *starrocksDDLExecutor) executeDDL(ctx context.Context, ddl string) error
 }
 
 // CreateTable creates a new table based on the provided schema.
-// CreateTable 根据提供的schema创建新表。
+// The database defaults to the configured StarRocks database when the schema does not name one.
+// CreateTable 根据提供的schema创建新表。schema未指定数据库时使用配置的StarRocks数据库。
 func (e *starrocksDDLExecutor) CreateTable(ctx context.Context, schema *TableSchemaDef) error {
-	// TODO: Implement DDL generation from TableSchemaDef
-	// This would involve constructing a "CREATE TABLE ..." string.
-	// Example:
-	// var sb strings.Builder
-	// sb.WriteString(fmt.Sprintf("CREATE TABLE %s.%s (", schema.DatabaseName, schema.TableName))
-	// for i, field := range schema.Fields {
-	//     sb.WriteString(fmt.Sprintf("%s %s", field.Name, field.Type))
-	//     if !field.IsNullable {
-	//         sb.WriteString(" NOT NULL")
-	//     }
-	//     if field.Comment != "" {
-	//         sb.WriteString(fmt.Sprintf(" COMMENT '%s'", field.Comment))
-	//     }
-	//     if i < len(schema.Fields)-1 {
-	//         sb.WriteString(", ")
-	//     }
-	// }
-	// sb.WriteString(") ")
-	// // Add ENGINE, KEYS, PARTITION, DISTRIBUTION, PROPERTIES clauses
-	// ddl := sb.String()
-	// return e.executeDDL(ctx, ddl)
-	logger.L().Warnw("CreateTable DDL generation not fully implemented", "schema", schema)
-	return errors.New(errors.UnknownError, "CreateTable DDL generation not fully implemented")
+	if schema != nil && schema.DatabaseName == "" {
+		withDatabase := *schema
+		withDatabase.DatabaseName = e.cfg.Database
+		schema = &withDatabase
+	}
+	ddl, err := BuildCreateTableDDL(schema)
+	if err != nil {
+		return err
+	}
+	return e.executeDDL(ctx, ddl)
 }
 
 // AlterTable modifies an existing table.
@@ -94,7 +82,13 @@ func (e *starrocksDDLExecutor) AlterTable(ctx context.Context, database, table,
 // DropTable drops an existing table.
 // DropTable 删除现有表。
 func (e *starrocksDDLExecutor) DropTable(ctx context.Context, database, table string) error {
-	ddl := fmt.Sprintf("DROP TABLE IF EXISTS %s.%s", database, table)
+	if database == "" {
+		database = e.cfg.Database
+	}
+	ddl, err := BuildDropTableDDL(database, table, true)
+	if err != nil {
+		return err
+	}
 	return e.executeDDL(ctx, ddl)
 }
 
@@ -103,35 +97,32 @@ func (e *starrocksDDLExecutor) DropTable(ctx context.Context, database, table st
 func (e *starrocksDDLExecutor) CreateIndex(ctx context.Context, database, table string, index *IndexDefinitionDef) error {
 	// Example DDL: ALTER TABLE db.table ADD INDEX index_name (col1, col2) USING BITMAP COMMENT 'comment';
 	// Example DDL for Inverted: ALTER TABLE db.table ADD INDEX index_name (col_text) USING INVERTED PROPERTIES("parser" = "chinese") COMMENT 'comment';
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD INDEX %s (", database, table, index.IndexName))
-	sb.WriteString(strings.Join(index.Fields, ", "))
-	sb.WriteString(fmt.Sprintf(") USING %s", strings.ToUpper(index.IndexType)))
-
-	if len(index.Properties) > 0 {
-		sb.WriteString(" PROPERTIES(")
-		props := []string{}
-		for k, v := range index.Properties {
-			props = append(props, fmt.Sprintf("\"%s\" = \"%s\"", k, v))
-		}
-		sb.WriteString(strings.Join(props, ", "))
-		sb.WriteString(")")
-	}
-
-	if index.Comment != "" {
-		sb.WriteString(fmt.Sprintf(" COMMENT '%s'", index.Comment))
+	ddl, err := BuildCreateIndexDDL(database, table, index)
+	if err != nil {
+		return err
 	}
-	sb.WriteString(";")
-	return e.executeDDL(ctx, sb.String())
+	return e.executeDDL(ctx, ddl)
 }
 
 // DropIndex drops an index from a table.
 // DropIndex 从表中删除索引。
 func (e *starrocksDDLExecutor) DropIndex(ctx context.Context, database, table, indexName string) error {
-	ddl := fmt.Sprintf("ALTER TABLE %s.%s DROP INDEX %s", database, table, indexName)
+	ddl, err := BuildDropIndexDDL(database, table, indexName)
+	if err != nil {
+		return err
+	}
 	return e.executeDDL(ctx, ddl)
 }
 
+// ExecuteRawDDL executes a complete DDL statement.
+// ExecuteRawDDL 执行一条完整的DDL语句。
+func (e *starrocksDDLExecutor) ExecuteRawDDL(ctx context.Context, statement string) error {
+	if strings.TrimSpace(statement) == "" {
+		return errors.New(errors.InvalidArgument, "DDL statement cannot be empty")
+	}
+	return e.executeDDL(ctx, statement)
+}
+
 // GetTableSchema retrieves the schema of a specific table.
 // GetTableSchema 检索特定表的schema。
 func (e *starrocksDDLExecutor) GetTableSchema(ctx context.Context, database, table string) (*TableSchemaDef, error) {
@@ -172,8 +163,16 @@ func (e *starrocksDDLExecutor) GetTableSchema(ctx context.Context, database, tab
 		if fNull, ok := row[2].(string); ok {
 			field.IsNullable = (strings.ToUpper(fNull) == "YES")
 		}
-		// Key, Default, Extra can also be parsed
-		// For now, simple parsing.
+		if fKey, ok := row[3].(string); ok {
+			field.IsKey = strings.EqualFold(fKey, "true")
+		}
+		if fDefault, ok := row[4].(string); ok && !strings.EqualFold(fDefault, "NULL") {
+			field.DefaultValue = fDefault
+		}
+		// Extra holds the aggregation type of the value columns of AGGREGATE KEY tables.
+		if fExtra, ok := row[5].(string); ok && aggregationTypes[normalizeAggregationType(fExtra)] {
+			field.AggregationType = normalizeAggregationType(fExtra)
+		}
 		schema.Fields = append(schema.Fields, field)
 	}
 	return schema, nil
@@ -0,0 +1,277 @@
+package starrocks
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+var (
+	enginePattern       = regexp.MustCompile(`(?i)\bENGINE\s*=\s*(\w+)`)
+	keysPattern         = regexp.MustCompile(`(?i)\b(DUPLICATE|AGGREGATE|UNIQUE|PRIMARY)\s+KEY\s*\(`)
+	commentPattern      = regexp.MustCompile(`(?i)\bCOMMENT\s+(["'])`)
+	partitionByPattern  = regexp.MustCompile(`(?i)\bPARTITION\s+BY\s+`)
+	rangeListPattern    = regexp.MustCompile(`(?i)^(RANGE|LIST)\s*\(`)
+	distributionPattern = regexp.MustCompile(`(?i)\bDISTRIBUTED\s+BY\s+(?:HASH\s*\(([^)]*)\)|RANDOM)(?:\s+BUCKETS\s+(\d+|AUTO))?`)
+	clauseEndPattern    = regexp.MustCompile(`(?i)\b(DISTRIBUTED\s+BY|ORDER\s+BY|PROPERTIES)\b`)
+	propertiesPattern   = regexp.MustCompile(`(?i)\bPROPERTIES\s*\(`)
+	propertyPattern     = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*=\s*"((?:[^"\\]|\\.)*)"`)
+	quotedValuePattern  = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'`)
+	// rangePartitionPattern matches "PARTITION p VALUES [(lower), (upper))" and "PARTITION p VALUES LESS THAN (upper)".
+	// rangePartitionPattern 匹配 "PARTITION p VALUES [(lower), (upper))" 与 "PARTITION p VALUES LESS THAN (upper)"。
+	rangePartitionPattern = regexp.MustCompile("(?is)PARTITION\\s+`?([^`\\s]+)`?\\s+VALUES\\s+(?:\\[\\s*\\(([^)]*)\\)\\s*,\\s*\\(([^)]*)\\)\\s*\\)|LESS\\s+THAN\\s+(?:\\(([^)]*)\\)|(MAXVALUE)))")
+)
+
+// ParseCreateTableDDL parses the table-level clauses of a CREATE TABLE statement as SHOW CREATE TABLE reports it:
+// the engine, keys, comment, partitioning, distribution and properties. Columns are not parsed; DESCRIBE reports
+// them in a simpler form.
+// ParseCreateTableDDL 解析SHOW CREATE TABLE所报告的CREATE TABLE语句中的表级子句：引擎、键、注释、分区、分桶与属性。
+// 列不在此解析，DESCRIBE会以更简单的形式报告列。
+func ParseCreateTableDDL(statement string) (*TableDefinitionDef, error) {
+	open := strings.Index(statement, "(")
+	if open < 0 {
+		return nil, errors.New(errors.InvalidArgument, "CREATE TABLE statement has no column list")
+	}
+	end := matchingParen(statement, open)
+	if end < 0 {
+		return nil, errors.New(errors.InvalidArgument, "CREATE TABLE statement has an unterminated column list")
+	}
+	tail := statement[end+1:]
+	def := &TableDefinitionDef{Statement: strings.TrimSpace(statement)}
+
+	if m := enginePattern.FindStringSubmatch(tail); m != nil {
+		def.Engine = strings.ToUpper(m[1])
+	}
+	if loc := keysPattern.FindStringSubmatchIndex(tail); loc != nil {
+		if closing := matchingParen(tail, loc[1]-1); closing > 0 {
+			def.KeysType = strings.ToUpper(tail[loc[2]:loc[3]])
+			def.KeyColumns = splitIdentifiers(tail[loc[1]:closing])
+		}
+	}
+	// The table comment precedes PARTITION BY and DISTRIBUTED BY; the PROPERTIES values are never preceded by COMMENT.
+	if loc := commentPattern.FindStringSubmatchIndex(tail); loc != nil {
+		if value, ok := readQuotedString(tail[loc[2]:]); ok {
+			def.Comment = value
+		}
+	}
+
+	properties := map[string]string{}
+	if locs := propertiesPattern.FindAllStringIndex(tail, -1); len(locs) > 0 {
+		loc := locs[len(locs)-1]
+		if closing := matchingParen(tail, loc[1]-1); closing > 0 {
+			for _, m := range propertyPattern.FindAllStringSubmatch(tail[loc[1]:closing], -1) {
+				properties[unescape(m[1])] = unescape(m[2])
+			}
+		}
+	}
+
+	if loc := partitionByPattern.FindStringIndex(tail); loc != nil {
+		partition, clause, err := parsePartition(tail[loc[1]:])
+		if err != nil {
+			return nil, err
+		}
+		def.Partition = partition
+		def.PartitionClause = strings.TrimSpace(tail[loc[0]:loc[1]] + clause)
+		if partition.Type == PartitionTypeRange {
+			partition.Dynamic = takeDynamicPartition(properties)
+		}
+	}
+
+	if loc := distributionPattern.FindStringSubmatchIndex(tail); loc != nil {
+		def.DistributionClause = tail[loc[0]:loc[1]]
+		if loc[2] >= 0 {
+			def.DistributionColumns = splitIdentifiers(tail[loc[2]:loc[3]])
+		}
+		if loc[4] >= 0 {
+			def.Buckets, _ = strconv.Atoi(tail[loc[4]:loc[5]])
+		}
+	}
+	if len(properties) > 0 {
+		def.Properties = properties
+	}
+	return def, nil
+}
+
+// parsePartition parses what follows PARTITION BY and returns the partitioning with the text of the clause.
+// parsePartition 解析PARTITION BY之后的内容，返回分区方式及子句文本。
+func parsePartition(s string) (*PartitionDef, string, error) {
+	if loc := rangeListPattern.FindStringSubmatchIndex(s); loc != nil {
+		closing := matchingParen(s, loc[1]-1)
+		if closing < 0 {
+			return nil, "", errors.New(errors.InvalidArgument, "CREATE TABLE statement has an unterminated partition column list")
+		}
+		partition := &PartitionDef{Type: strings.ToUpper(s[loc[2]:loc[3]]), Columns: splitIdentifiers(s[loc[1]:closing])}
+		end := closing + 1
+		// The initial partitions follow the partition columns in parentheses.
+		if rest := strings.TrimLeft(s[end:], " \t\r\n"); strings.HasPrefix(rest, "(") {
+			open := len(s) - len(rest)
+			if listEnd := matchingParen(s, open); listEnd > 0 {
+				if partition.Type == PartitionTypeRange {
+					partition.Ranges = parseRangePartitions(s[open+1 : listEnd])
+				}
+				end = listEnd + 1
+			}
+		}
+		return partition, s[:end], nil
+	}
+
+	end := len(s)
+	if loc := clauseEndPattern.FindStringIndex(s); loc != nil {
+		end = loc[0]
+	}
+	clause := strings.TrimSpace(s[:end])
+	partition := &PartitionDef{Type: PartitionTypeExpression}
+	if strings.HasPrefix(clause, "(") && matchingParen(clause, 0) == len(clause)-1 && !strings.ContainsAny(clause[1:len(clause)-1], "()") {
+		partition.Columns = splitIdentifiers(clause[1 : len(clause)-1])
+	} else {
+		partition.Expression = clause
+	}
+	return partition, clause, nil
+}
+
+func parseRangePartitions(s string) []RangePartitionDef {
+	var ranges []RangePartitionDef
+	for _, m := range rangePartitionPattern.FindAllStringSubmatch(s, -1) {
+		r := RangePartitionDef{Name: m[1]}
+		switch {
+		case m[5] != "":
+			// LESS THAN MAXVALUE has neither bound.
+		case m[4] != "":
+			r.Upper = quotedValues(m[4])
+		default:
+			r.Lower = quotedValues(m[2])
+			if !strings.EqualFold(strings.TrimSpace(m[3]), "MAXVALUE") {
+				r.Upper = quotedValues(m[3])
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// takeDynamicPartition removes the dynamic partition properties that DynamicPartitionDef represents from
+// properties and returns them as a DynamicPartitionDef, or nil when dynamic partitioning is not enabled.
+// takeDynamicPartition 从properties中移除DynamicPartitionDef所表示的动态分区属性并以DynamicPartitionDef返回，
+// 未启用动态分区时返回nil。
+func takeDynamicPartition(properties map[string]string) *DynamicPartitionDef {
+	if !strings.EqualFold(properties["dynamic_partition.enable"], "true") {
+		return nil
+	}
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(properties[key])
+		delete(properties, key)
+		return n
+	}
+	dynamic := &DynamicPartitionDef{
+		TimeUnit:            strings.ToUpper(properties["dynamic_partition.time_unit"]),
+		Prefix:              properties["dynamic_partition.prefix"],
+		Start:               atoi("dynamic_partition.start"),
+		End:                 atoi("dynamic_partition.end"),
+		Buckets:             atoi("dynamic_partition.buckets"),
+		HistoryPartitionNum: atoi("dynamic_partition.history_partition_num"),
+	}
+	// StarRocks reports the smallest integer as the start of dynamic partitions that are never dropped.
+	if dynamic.Start <= -2147483648 {
+		dynamic.Start = 0
+	}
+	delete(properties, "dynamic_partition.enable")
+	delete(properties, "dynamic_partition.time_unit")
+	delete(properties, "dynamic_partition.prefix")
+	return dynamic
+}
+
+// matchingParen returns the position of the bracket closing the one at open, or -1. "(" and "[" open and ")" and
+// "]" close, since a RANGE partition bound "[(lower), (upper))" opens with "[" and closes with ")". Brackets in
+// quoted strings and identifiers are skipped.
+// matchingParen 返回与open处括号匹配的闭括号位置，不存在时返回-1。"(" 与 "[" 视为开括号，")" 与 "]" 视为闭括号，
+// 因为RANGE分区边界 "[(lower), (upper))" 以 "[" 开始、以 ")" 结束。引号内的括号会被跳过。
+func matchingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// readQuotedString reads the string literal at the start of s, which starts with its quote character.
+// readQuotedString 读取s开头的字符串字面量，s以其引号字符开始。
+func readQuotedString(s string) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				sb.WriteByte(s[i])
+			}
+		case quote:
+			return sb.String(), true
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+func splitIdentifiers(list string) []string {
+	var names []string
+	for _, part := range strings.Split(list, ",") {
+		if name := strings.Trim(strings.TrimSpace(part), "`"); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func quotedValues(list string) []string {
+	var values []string
+	for _, m := range quotedValuePattern.FindAllStringSubmatch(list, -1) {
+		if m[1] != "" || !strings.HasPrefix(m[0], "'") {
+			values = append(values, unescape(m[1]))
+		} else {
+			values = append(values, unescape(m[2]))
+		}
+	}
+	return values
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
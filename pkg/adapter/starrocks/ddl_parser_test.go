@@ -0,0 +1,134 @@
+package starrocks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCreateTableDDL(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      *TableDefinitionDef
+		wantErr   bool
+	}{
+		{
+			name: "duplicate keys with range and dynamic partitions",
+			statement: "CREATE TABLE `events` (\n" +
+				"  `event_time` datetime NOT NULL COMMENT \"\",\n" +
+				"  `host` varchar(64) NULL COMMENT \"\"\n" +
+				") ENGINE=OLAP\n" +
+				"DUPLICATE KEY(`event_time`, `host`)\n" +
+				"COMMENT \"security \\\"events\\\"\"\n" +
+				"PARTITION BY RANGE(`event_time`)\n" +
+				"(PARTITION p20240101 VALUES [(\"2024-01-01 00:00:00\"), (\"2024-01-02 00:00:00\")),\n" +
+				"PARTITION p_old VALUES LESS THAN (\"2023-01-01 00:00:00\"),\n" +
+				"PARTITION p_max VALUES LESS THAN (MAXVALUE))\n" +
+				"DISTRIBUTED BY HASH(`host`) BUCKETS 8\n" +
+				"PROPERTIES (\n" +
+				"\"replication_num\" = \"3\",\n" +
+				"\"dynamic_partition.enable\" = \"true\",\n" +
+				"\"dynamic_partition.time_unit\" = \"DAY\",\n" +
+				"\"dynamic_partition.start\" = \"-2147483648\",\n" +
+				"\"dynamic_partition.end\" = \"3\",\n" +
+				"\"dynamic_partition.prefix\" = \"p\"\n" +
+				");",
+			want: &TableDefinitionDef{
+				Engine:     "OLAP",
+				KeysType:   KeysTypeDuplicate,
+				KeyColumns: []string{"event_time", "host"},
+				Partition: &PartitionDef{
+					Type:    PartitionTypeRange,
+					Columns: []string{"event_time"},
+					Ranges: []RangePartitionDef{
+						{Name: "p20240101", Lower: []string{"2024-01-01 00:00:00"}, Upper: []string{"2024-01-02 00:00:00"}},
+						{Name: "p_old", Upper: []string{"2023-01-01 00:00:00"}},
+						{Name: "p_max"},
+					},
+					Dynamic: &DynamicPartitionDef{TimeUnit: "DAY", End: 3, Prefix: "p"},
+				},
+				DistributionColumns: []string{"host"},
+				DistributionClause:  "DISTRIBUTED BY HASH(`host`) BUCKETS 8",
+				Buckets:             8,
+				Properties:          map[string]string{"replication_num": "3"},
+				Comment:             `security "events"`,
+			},
+		},
+		{
+			name: "primary keys with expression partitioning",
+			statement: "CREATE TABLE `t` (\n  `id` bigint(20) NOT NULL,\n  `ts` datetime NOT NULL\n) ENGINE=OLAP\n" +
+				"PRIMARY KEY(`id`, `ts`)\n" +
+				"PARTITION BY date_trunc('day', `ts`)\n" +
+				"DISTRIBUTED BY HASH(`id`)\n" +
+				"ORDER BY(`ts`)\n" +
+				"PROPERTIES (\"compression\" = \"LZ4\");",
+			want: &TableDefinitionDef{
+				Engine:              "OLAP",
+				KeysType:            KeysTypePrimary,
+				KeyColumns:          []string{"id", "ts"},
+				Partition:           &PartitionDef{Type: PartitionTypeExpression, Expression: "date_trunc('day', `ts`)"},
+				DistributionColumns: []string{"id"},
+				DistributionClause:  "DISTRIBUTED BY HASH(`id`)",
+				Properties:          map[string]string{"compression": "LZ4"},
+			},
+		},
+		{
+			name:      "column partitioning and random distribution",
+			statement: "CREATE TABLE `t` (\n  `region` varchar(8) NULL\n) ENGINE=OLAP\nPARTITION BY (`region`)\nDISTRIBUTED BY RANDOM BUCKETS AUTO;",
+			want: &TableDefinitionDef{
+				Engine:             "OLAP",
+				Partition:          &PartitionDef{Type: PartitionTypeExpression, Columns: []string{"region"}},
+				DistributionClause: "DISTRIBUTED BY RANDOM BUCKETS AUTO",
+			},
+		},
+		{
+			name:      "no column list",
+			statement: "CREATE TABLE t",
+			wantErr:   true,
+		},
+		{
+			name:      "unterminated column list",
+			statement: "CREATE TABLE t (`a` int",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCreateTableDDL(tt.statement)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCreateTableDDL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// The clause texts are checked where they are stable; the statement is the input.
+			got.Statement, got.PartitionClause = "", ""
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCreateTableDDL() = %+v\nwant %+v", got, tt.want)
+				if got.Partition != nil && tt.want.Partition != nil {
+					t.Errorf("partition = %+v\nwant %+v", *got.Partition, *tt.want.Partition)
+				}
+			}
+		})
+	}
+}
+
+func TestParsedPartitionRendersBack(t *testing.T) {
+	fields := []FieldSchemaDef{{Name: "ts", Type: "DATETIME"}, {Name: "region", Type: "VARCHAR(8)"}}
+	tests := []string{
+		"PARTITION BY date_trunc('day', `ts`)",
+		"PARTITION BY (`region`)",
+		"PARTITION BY RANGE(`ts`) (\n  PARTITION `p1` VALUES [(\"2024-01-01\"), (\"2024-02-01\")),\n  PARTITION `pmax` VALUES LESS THAN MAXVALUE\n)",
+	}
+	for _, clause := range tests {
+		partition, _, err := parsePartition(clause[len("PARTITION BY "):])
+		if err != nil {
+			t.Errorf("parsePartition(%q) error = %v", clause, err)
+			continue
+		}
+		rendered, err := partitionClause("t", partition, columnPositions(fields))
+		if err != nil || rendered != clause {
+			t.Errorf("partitionClause() = %q, %v, want %q", rendered, err, clause)
+		}
+	}
+}
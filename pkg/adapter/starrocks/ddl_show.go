@@ -0,0 +1,314 @@
+package starrocks
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// mvQueryPattern finds where the defining query starts in a CREATE MATERIALIZED VIEW statement.
+// mvQueryPattern 用于定位CREATE MATERIALIZED VIEW语句中定义查询的起始位置。
+var mvQueryPattern = regexp.MustCompile(`(?is)\bAS\s+((?:SELECT|WITH)\b.*)$`)
+
+// mvSchedulePattern finds the async refresh schedule in a CREATE MATERIALIZED VIEW statement.
+// mvSchedulePattern 用于定位CREATE MATERIALIZED VIEW语句中的异步刷新调度。
+var mvSchedulePattern = regexp.MustCompile(`(?is)\bREFRESH\s+ASYNC\s+((?:START\s*\(\s*(?:'[^']*'|"[^"]*")\s*\)\s*)?EVERY\s*\(\s*INTERVAL\s+\d+\s+\w+\s*\))`)
+
+// showTimeLayout is the layout of DATETIME values in information_schema, in the time zone of the FE.
+// showTimeLayout 是information_schema中DATETIME值的格式，使用FE所在时区。
+const showTimeLayout = "2006-01-02 15:04:05"
+
+// ShowTables lists the base tables of a database using SHOW FULL TABLES.
+// ShowTables 使用SHOW FULL TABLES列出数据库中的基础表。
+func (e *starrocksDDLExecutor) ShowTables(ctx context.Context, database string) ([]string, error) {
+	if database == "" {
+		database = e.cfg.Database
+	}
+	result, err := e.show(ctx, "SHOW FULL TABLES FROM "+quoteIdentifier(database))
+	if err != nil {
+		return nil, err
+	}
+	columns := showColumns(result)
+	typeIdx, hasType := columns["table_type"]
+	tables := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if hasType && !strings.EqualFold(cellString(row, typeIdx), "BASE TABLE") {
+			continue
+		}
+		if name := cellString(row, 0); name != "" {
+			tables = append(tables, name)
+		}
+	}
+	return tables, nil
+}
+
+// ShowCreateTable reads the CREATE TABLE statement of a table using SHOW CREATE TABLE and parses it.
+// ShowCreateTable 使用SHOW CREATE TABLE读取表的建表语句并进行解析。
+func (e *starrocksDDLExecutor) ShowCreateTable(ctx context.Context, database, table string) (*TableDefinitionDef, error) {
+	if database == "" {
+		database = e.cfg.Database
+	}
+	result, err := e.show(ctx, "SHOW CREATE TABLE "+qualifiedName(database, table))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rows) == 0 {
+		return nil, errors.Newf(errors.NotFoundError, "table %s.%s not found", database, table)
+	}
+	idx, ok := showColumns(result)["create_table"]
+	if !ok {
+		idx = 1
+	}
+	statement := cellString(result.Rows[0], idx)
+	if statement == "" {
+		return nil, errors.New(errors.InternalError, "unexpected SHOW CREATE TABLE result format: no Create Table column")
+	}
+	return ParseCreateTableDDL(statement)
+}
+
+// ShowIndexes lists the indexes of a table using SHOW INDEX, with the columns of each index in index order.
+// ShowIndexes 使用SHOW INDEX列出表上的索引，每个索引的列按索引内顺序排列。
+func (e *starrocksDDLExecutor) ShowIndexes(ctx context.Context, database, table string) ([]*IndexDefinitionDef, error) {
+	if database == "" {
+		database = e.cfg.Database
+	}
+	result, err := e.show(ctx, "SHOW INDEX FROM "+qualifiedName(database, table))
+	if err != nil {
+		return nil, err
+	}
+	columns := showColumns(result)
+	nameIdx, ok := columns["key_name"]
+	if !ok {
+		return nil, errors.New(errors.InternalError, "unexpected SHOW INDEX result format: no Key_name column")
+	}
+
+	type indexColumn struct {
+		seq  int
+		name string
+	}
+	indexes := make(map[string]*IndexDefinitionDef)
+	indexColumns := make(map[string][]indexColumn)
+	var order []string
+	for _, row := range result.Rows {
+		name := cellString(row, nameIdx)
+		if name == "" {
+			continue
+		}
+		index, seen := indexes[name]
+		if !seen {
+			index = &IndexDefinitionDef{IndexName: name}
+			if idx, ok := columns["index_type"]; ok {
+				index.IndexType = normalizeIndexType(cellString(row, idx))
+			}
+			if idx, ok := columns["comment"]; ok {
+				index.Comment = cellString(row, idx)
+			}
+			indexes[name] = index
+			order = append(order, name)
+		}
+		column := indexColumn{seq: len(indexColumns[name]) + 1}
+		if idx, ok := columns["seq_in_index"]; ok {
+			if seq, err := strconv.Atoi(cellString(row, idx)); err == nil {
+				column.seq = seq
+			}
+		}
+		if idx, ok := columns["column_name"]; ok {
+			column.name = cellString(row, idx)
+		}
+		indexColumns[name] = append(indexColumns[name], column)
+	}
+
+	list := make([]*IndexDefinitionDef, 0, len(order))
+	for _, name := range order {
+		cols := indexColumns[name]
+		sort.SliceStable(cols, func(i, j int) bool { return cols[i].seq < cols[j].seq })
+		for _, col := range cols {
+			indexes[name].Fields = append(indexes[name].Fields, col.name)
+		}
+		list = append(list, indexes[name])
+	}
+	return list, nil
+}
+
+// ShowAlterColumnJobs lists the schema change jobs of a table using SHOW ALTER TABLE COLUMN, newest first.
+// ShowAlterColumnJobs 使用SHOW ALTER TABLE COLUMN列出表上的模式变更任务，最新的在前。
+func (e *starrocksDDLExecutor) ShowAlterColumnJobs(ctx context.Context, database, table string) ([]*AlterJobDef, error) {
+	if database == "" {
+		database = e.cfg.Database
+	}
+	result, err := e.show(ctx, fmt.Sprintf("SHOW ALTER TABLE COLUMN FROM %s WHERE TableName = %s ORDER BY CreateTime DESC",
+		quoteIdentifier(database), quoteString(table)))
+	if err != nil {
+		return nil, err
+	}
+	columns := showColumns(result)
+	stateIdx, ok := columns["state"]
+	if !ok {
+		return nil, errors.New(errors.InternalError, "unexpected SHOW ALTER TABLE COLUMN result format: no State column")
+	}
+	jobs := make([]*AlterJobDef, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		job := &AlterJobDef{TableName: table, State: strings.ToUpper(cellString(row, stateIdx))}
+		if idx, ok := columns["jobid"]; ok {
+			job.JobID = cellString(row, idx)
+		}
+		if idx, ok := columns["tablename"]; ok {
+			job.TableName = cellString(row, idx)
+		}
+		if idx, ok := columns["msg"]; ok {
+			job.Msg = cellString(row, idx)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// ShowMaterializedViews lists the materialized views of a database from information_schema.materialized_views,
+// with their activation state and last refresh.
+// ShowMaterializedViews 从information_schema.materialized_views列出数据库中的物化视图，包括其激活状态与最近一次刷新。
+func (e *starrocksDDLExecutor) ShowMaterializedViews(ctx context.Context, database string) ([]*MaterializedViewDef, error) {
+	if database == "" {
+		database = e.cfg.Database
+	}
+	return e.queryMaterializedViews(ctx, database, "")
+}
+
+// ShowMaterializedView reads a materialized view from information_schema.materialized_views.
+// ShowMaterializedView 从information_schema.materialized_views读取物化视图。
+func (e *starrocksDDLExecutor) ShowMaterializedView(ctx context.Context, database, view string) (*MaterializedViewDef, error) {
+	if database == "" {
+		database = e.cfg.Database
+	}
+	views, err := e.queryMaterializedViews(ctx, database, view)
+	if err != nil {
+		return nil, err
+	}
+	if len(views) == 0 {
+		return nil, errors.Newf(errors.NotFoundError, "materialized view %s does not exist", qualifiedName(database, view))
+	}
+	return views[0], nil
+}
+
+// queryMaterializedViews reads the materialized views of a database, or only the named one when view is set.
+// Columns are looked up by name since older StarRocks versions report fewer of them.
+// queryMaterializedViews 读取数据库中的物化视图，view非空时仅读取该视图。由于较旧的StarRocks版本报告的列较少，列按名称查找。
+func (e *starrocksDDLExecutor) queryMaterializedViews(ctx context.Context, database, view string) ([]*MaterializedViewDef, error) {
+	statement := "SELECT * FROM information_schema.materialized_views WHERE TABLE_SCHEMA = " + quoteString(database)
+	if view != "" {
+		statement += " AND TABLE_NAME = " + quoteString(view)
+	}
+	result, err := e.show(ctx, statement+" ORDER BY TABLE_NAME")
+	if err != nil {
+		return nil, err
+	}
+	columns := showColumns(result)
+	nameIdx, ok := columns["table_name"]
+	if !ok {
+		return nil, errors.New(errors.InternalError, "unexpected information_schema.materialized_views result format: no TABLE_NAME column")
+	}
+	cell := func(row []interface{}, column string) string {
+		if idx, ok := columns[column]; ok {
+			return cellString(row, idx)
+		}
+		return ""
+	}
+
+	views := make([]*MaterializedViewDef, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		mv := &MaterializedViewDef{
+			DatabaseName: database,
+			ViewName:     cellString(row, nameIdx),
+			RefreshType:  strings.ToUpper(cell(row, "refresh_type")),
+			Definition:   strings.TrimSpace(cell(row, "materialized_view_definition")),
+			Status: &MaterializedViewStatusDef{
+				IsActive:                strings.EqualFold(cell(row, "is_active"), "true"),
+				InactiveReason:          cell(row, "inactive_reason"),
+				TaskName:                cell(row, "task_name"),
+				LastRefreshState:        strings.ToUpper(cell(row, "last_refresh_state")),
+				LastRefreshStartTime:    parseShowTime(cell(row, "last_refresh_start_time")),
+				LastRefreshFinishedTime: parseShowTime(cell(row, "last_refresh_finished_time")),
+				LastRefreshErrorCode:    cell(row, "last_refresh_error_code"),
+				LastRefreshErrorMessage: cell(row, "last_refresh_error_message"),
+			},
+		}
+		mv.Status.LastRefreshDuration, _ = strconv.ParseFloat(cell(row, "last_refresh_duration"), 64)
+		mv.Status.Rows, _ = strconv.ParseInt(cell(row, "table_rows"), 10, 64)
+		// StarRocks reports error code 0 for a refresh that did not fail.
+		if mv.Status.LastRefreshErrorCode == "0" {
+			mv.Status.LastRefreshErrorCode = ""
+		}
+		// Some versions report only the defining query rather than the CREATE statement.
+		if upper := strings.ToUpper(mv.Definition); strings.HasPrefix(upper, "SELECT") || strings.HasPrefix(upper, "WITH") {
+			mv.Query = strings.TrimRight(mv.Definition, ";")
+		} else if m := mvQueryPattern.FindStringSubmatch(mv.Definition); m != nil {
+			mv.Query = strings.TrimRight(strings.TrimSpace(m[1]), ";")
+		}
+		if m := mvSchedulePattern.FindStringSubmatch(mv.Definition); m != nil {
+			mv.RefreshSchedule, _ = RefreshScheduleClause(m[1])
+		}
+		views = append(views, mv)
+	}
+	return views, nil
+}
+
+func (e *starrocksDDLExecutor) show(ctx context.Context, statement string) (*QueryResult, error) {
+	result, err := e.client.Execute(ctx, statement)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.DatabaseError, "failed to execute %s", statement)
+	}
+	if result.Error != nil {
+		return nil, errors.Wrapf(result.Error, errors.DatabaseError, "%s resulted in StarRocks error", statement)
+	}
+	return result, nil
+}
+
+// showColumns maps the lower-cased column names of a SHOW result, with spaces as underscores, to their positions.
+// showColumns 将SHOW结果中小写且空格替换为下划线的列名映射到其位置。
+func showColumns(result *QueryResult) map[string]int {
+	columns := make(map[string]int, len(result.Columns))
+	for i, name := range result.Columns {
+		columns[strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")] = i
+	}
+	return columns
+}
+
+func cellString(row []interface{}, idx int) string {
+	if idx < 0 || idx >= len(row) || row[idx] == nil {
+		return ""
+	}
+	switch v := row[idx].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// parseShowTime parses a DATETIME value of a SHOW or information_schema result, the zero time when it is empty or
+// malformed.
+// parseShowTime 解析SHOW或information_schema结果中的DATETIME值，为空或格式错误时返回零值。
+func parseShowTime(value string) time.Time {
+	t, err := time.ParseInLocation(showTimeLayout, strings.TrimSpace(value), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// normalizeIndexType reports StarRocks' GIN inverted indexes as INVERTED.
+// normalizeIndexType 将StarRocks的GIN倒排索引报告为INVERTED。
+func normalizeIndexType(indexType string) string {
+	indexType = strings.ToUpper(strings.TrimSpace(indexType))
+	if indexType == "GIN" {
+		return "INVERTED"
+	}
+	return indexType
+}
@@ -0,0 +1,92 @@
+package starrocks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// SampleTableStats reads the estimated size of a table from information_schema.tables and samples column
+// statistics with a single aggregation over the first sampleRows rows. Distinct counts use NDV, which is
+// approximate; they describe the sample, not the whole table.
+// SampleTableStats 从information_schema.tables读取表的估计大小，并对前sampleRows行执行一次聚合来采样列统计。
+// 不同值数使用近似的NDV计算，描述的是样本而非整张表。
+func (e *starrocksDDLExecutor) SampleTableStats(ctx context.Context, database, table string, columns []string, sampleRows int) (*TableStatsDef, error) {
+	if database == "" {
+		database = e.cfg.Database
+	}
+	if table == "" {
+		return nil, errors.New(errors.InvalidArgument, "table name cannot be empty")
+	}
+	if sampleRows <= 0 {
+		return nil, errors.Newf(errors.InvalidArgument, "invalid sample size %d", sampleRows)
+	}
+
+	sizes, err := e.show(ctx, fmt.Sprintf("SELECT TABLE_ROWS, DATA_LENGTH FROM information_schema.tables WHERE TABLE_SCHEMA = %s AND TABLE_NAME = %s",
+		quoteString(database), quoteString(table)))
+	if err != nil {
+		return nil, err
+	}
+	if len(sizes.Rows) == 0 {
+		return nil, errors.Newf(errors.NotFoundError, "table %s.%s not found", database, table)
+	}
+	stats := &TableStatsDef{}
+	stats.Rows, _ = strconv.ParseInt(cellString(sizes.Rows[0], 0), 10, 64)
+	stats.DataBytes, _ = strconv.ParseInt(cellString(sizes.Rows[0], 1), 10, 64)
+
+	selectList := []string{"COUNT(*) AS sampled_rows"}
+	for i, column := range columns {
+		col := quoteIdentifier(column)
+		text := "CAST(" + col + " AS STRING)"
+		selectList = append(selectList,
+			fmt.Sprintf("NDV(%s) AS ndv_%d", col, i),
+			fmt.Sprintf("COUNT(%s) AS non_null_%d", col, i),
+			fmt.Sprintf("AVG(CHAR_LENGTH(%s)) AS chars_%d", text, i),
+			fmt.Sprintf("AVG(LENGTH(%s)) AS bytes_%d", text, i),
+			fmt.Sprintf("SUM(CASE WHEN %s LIKE '%% %%' THEN 1 ELSE 0 END) AS spaced_%d", text, i),
+		)
+	}
+	sampled := make([]string, 0, len(columns))
+	for _, column := range columns {
+		sampled = append(sampled, quoteIdentifier(column))
+	}
+	if len(sampled) == 0 {
+		sampled = append(sampled, "1")
+	}
+	result, err := e.show(ctx, fmt.Sprintf("SELECT %s FROM (SELECT %s FROM %s LIMIT %d) sample",
+		strings.Join(selectList, ", "), strings.Join(sampled, ", "), qualifiedName(database, table), sampleRows))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rows) == 0 {
+		return nil, errors.New(errors.InternalError, "unexpected empty result when sampling table statistics")
+	}
+
+	row, names := result.Rows[0], showColumns(result)
+	cell := func(name string) float64 {
+		idx, ok := names[name]
+		if !ok {
+			return 0
+		}
+		v, _ := strconv.ParseFloat(cellString(row, idx), 64)
+		return v
+	}
+	stats.SampledRows = int64(cell("sampled_rows"))
+	for i, column := range columns {
+		cs := &ColumnStatsDef{
+			Name:      column,
+			Distinct:  int64(cell(fmt.Sprintf("ndv_%d", i))),
+			NonNull:   int64(cell(fmt.Sprintf("non_null_%d", i))),
+			AvgLength: cell(fmt.Sprintf("chars_%d", i)),
+			AvgBytes:  cell(fmt.Sprintf("bytes_%d", i)),
+		}
+		if cs.NonNull > 0 {
+			cs.WhitespaceRatio = cell(fmt.Sprintf("spaced_%d", i)) / float64(cs.NonNull)
+		}
+		stats.Columns = append(stats.Columns, cs)
+	}
+	return stats, nil
+}
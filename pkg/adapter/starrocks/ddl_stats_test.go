@@ -0,0 +1,132 @@
+package starrocks
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+)
+
+// fakeStatsClient answers the size query with sizes and the sampling query with sample, recording the statements.
+type fakeStatsClient struct {
+	Client
+	sizes      *QueryResult
+	sample     *QueryResult
+	err        error
+	statements []string
+}
+
+func (f *fakeStatsClient) Execute(_ context.Context, query string, _ ...interface{}) (*QueryResult, error) {
+	f.statements = append(f.statements, query)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if strings.Contains(query, "information_schema.tables") {
+		return f.sizes, nil
+	}
+	return f.sample, nil
+}
+
+func TestSampleTableStats(t *testing.T) {
+	sizes := &QueryResult{Columns: []string{"TABLE_ROWS", "DATA_LENGTH"}, Rows: [][]interface{}{{"2000000", "734003200"}}}
+	sample := &QueryResult{
+		Columns: []string{"sampled_rows", "ndv_0", "non_null_0", "chars_0", "bytes_0", "spaced_0", "ndv_1", "non_null_1", "chars_1", "bytes_1", "spaced_1"},
+		Rows:    [][]interface{}{{"1000", "12", "1000", "5.5", "5.5", "0", float64(950), "800", "42.25", "120.5", "600"}},
+	}
+
+	tests := []struct {
+		name           string
+		database       string
+		columns        []string
+		sampleRows     int
+		client         *fakeStatsClient
+		want           *TableStatsDef
+		wantStatements []string
+		wantCode       errors.ErrorCode
+	}{
+		{
+			name:       "columns are sampled in one statement",
+			database:   "logs",
+			columns:    []string{"level", "msg"},
+			sampleRows: 1000,
+			client:     &fakeStatsClient{sizes: sizes, sample: sample},
+			want: &TableStatsDef{
+				Rows: 2000000, DataBytes: 734003200, SampledRows: 1000,
+				Columns: []*ColumnStatsDef{
+					{Name: "level", Distinct: 12, NonNull: 1000, AvgLength: 5.5, AvgBytes: 5.5},
+					{Name: "msg", Distinct: 950, NonNull: 800, AvgLength: 42.25, AvgBytes: 120.5, WhitespaceRatio: 0.75},
+				},
+			},
+			wantStatements: []string{
+				"SELECT TABLE_ROWS, DATA_LENGTH FROM information_schema.tables WHERE TABLE_SCHEMA = 'logs' AND TABLE_NAME = 'events'",
+				"SELECT COUNT(*) AS sampled_rows, " +
+					"NDV(`level`) AS ndv_0, COUNT(`level`) AS non_null_0, AVG(CHAR_LENGTH(CAST(`level` AS STRING))) AS chars_0, " +
+					"AVG(LENGTH(CAST(`level` AS STRING))) AS bytes_0, SUM(CASE WHEN CAST(`level` AS STRING) LIKE '% %' THEN 1 ELSE 0 END) AS spaced_0, " +
+					"NDV(`msg`) AS ndv_1, COUNT(`msg`) AS non_null_1, AVG(CHAR_LENGTH(CAST(`msg` AS STRING))) AS chars_1, " +
+					"AVG(LENGTH(CAST(`msg` AS STRING))) AS bytes_1, SUM(CASE WHEN CAST(`msg` AS STRING) LIKE '% %' THEN 1 ELSE 0 END) AS spaced_1 " +
+					"FROM (SELECT `level`, `msg` FROM `logs`.`events` LIMIT 1000) sample",
+			},
+		},
+		{
+			name:       "size only",
+			sampleRows: 10,
+			client:     &fakeStatsClient{sizes: sizes, sample: &QueryResult{Columns: []string{"sampled_rows"}, Rows: [][]interface{}{{"10"}}}},
+			want:       &TableStatsDef{Rows: 2000000, DataBytes: 734003200, SampledRows: 10},
+			wantStatements: []string{
+				"SELECT TABLE_ROWS, DATA_LENGTH FROM information_schema.tables WHERE TABLE_SCHEMA = 'default_db' AND TABLE_NAME = 'events'",
+				"SELECT COUNT(*) AS sampled_rows FROM (SELECT 1 FROM `default_db`.`events` LIMIT 10) sample",
+			},
+		},
+		{
+			name:       "missing table",
+			database:   "logs",
+			sampleRows: 10,
+			client:     &fakeStatsClient{sizes: &QueryResult{}},
+			wantCode:   errors.NotFoundError,
+		},
+		{
+			name:       "failed query",
+			database:   "logs",
+			sampleRows: 10,
+			client:     &fakeStatsClient{err: errors.New(errors.NetworkError, "connection refused")},
+			wantCode:   errors.DatabaseError,
+		},
+		{
+			name:       "StarRocks error",
+			database:   "logs",
+			sampleRows: 10,
+			client:     &fakeStatsClient{sizes: sizes, sample: &QueryResult{Error: errors.New(errors.DatabaseError, "Unknown column")}},
+			wantCode:   errors.DatabaseError,
+		},
+		{name: "invalid sample size", database: "logs", client: &fakeStatsClient{}, wantCode: errors.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &starrocksDDLExecutor{client: tt.client, cfg: config.StarRocksConfig{Database: "default_db"}}
+			got, err := e.SampleTableStats(context.Background(), tt.database, "events", tt.columns, tt.sampleRows)
+			if tt.wantCode != "" {
+				if !errors.Is(err, tt.wantCode) {
+					t.Fatalf("SampleTableStats() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SampleTableStats() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SampleTableStats() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.client.statements, tt.wantStatements) {
+				t.Errorf("SampleTableStats() statements =\n%q\nwant\n%q", tt.client.statements, tt.wantStatements)
+			}
+		})
+	}
+
+	e := &starrocksDDLExecutor{client: &fakeStatsClient{}}
+	if _, err := e.SampleTableStats(context.Background(), "logs", "", nil, 10); !errors.Is(err, errors.InvalidArgument) {
+		t.Errorf("SampleTableStats() without a table error = %v, want %s", err, errors.InvalidArgument)
+	}
+}
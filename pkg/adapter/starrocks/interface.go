@@ -31,6 +31,52 @@ type QueryStats struct {
 	Message    string        // 其他信息或备注 Any other message or notes
 }
 
+// Rows is a cursor over the rows of a query, decoded from the response of StarRocks as Next reads them so that
+// only the current row is held in memory. Rows must be closed; closing them early stops reading the response.
+// Rows 是查询数据行的游标，Next读取时才从StarRocks的响应中解码，内存中只保存当前行。Rows必须关闭，提前关闭会停止读取响应。
+type Rows interface {
+	// Columns returns the column names of the rows.
+	// Columns 返回数据行的列名。
+	Columns() []string
+
+	// Next advances to the next row, returning false at the end of the rows or on an error.
+	// Next 前进到下一行，到达末尾或出错时返回false。
+	Next() bool
+
+	// Row returns the values of the current row.
+	// Row 返回当前行的值。
+	Row() []interface{}
+
+	// Err returns the error that ended the rows, if any.
+	// Err 返回导致读取结束的错误（如有）。
+	Err() error
+
+	// Stats returns the statistics of the query, complete once Next returned false.
+	// Stats 返回查询的统计信息，Next返回false后才完整。
+	Stats() *QueryStats
+
+	// Close stops reading the rows and releases the response.
+	// Close 停止读取数据行并释放响应。
+	Close() error
+}
+
+// ExecuteOptions holds per-statement options of a query.
+// ExecuteOptions 保存单条查询语句的选项。
+type ExecuteOptions struct {
+	Database         string            // 执行语句的数据库，为空时使用配置的数据库 Database the statement runs in, the configured database when empty
+	TimeoutSeconds   int               // query_timeout会话变量与请求截止时间，0表示使用配置的超时 query_timeout session variable and request deadline, 0 uses the configured timeout
+	WorkloadGroup    string            // 执行语句的工作负载组，为空时由StarRocks决定 Workload group the statement runs in, left to StarRocks when empty
+	SessionVariables map[string]string // 其他会话变量 Other session variables
+	UseNumber        bool              // 将结果中的数值解码为json.Number以保持其精确值 Decode result numbers as json.Number, keeping their exact value
+}
+
+// Session variables set by ExecuteOptions. StarRocks calls workload groups resource groups.
+// 由ExecuteOptions设置的会话变量。StarRocks将工作负载组称为资源组(resource group)。
+const (
+	SessionVariableQueryTimeout  = "query_timeout"
+	SessionVariableWorkloadGroup = "resource_group"
+)
+
 // StreamLoadOptions holds options for a StarRocks stream load operation.
 // StreamLoadOptions 保存 StarRocks Stream Load 操作的选项。
 type StreamLoadOptions struct {
@@ -44,8 +90,28 @@ type StreamLoadOptions struct {
 	MergeCondition  string            // (可选) 用于部分更新的合并条件 (Optional) Merge condition for partial updates
 	TwoPhaseCommit  bool              // (可选) 是否开启两阶段提交 (Optional) Enable two-phase commit
 	TransactionID   string            // (可选) 用于两阶段提交的事务ID (Optional) Transaction ID for two-phase commit
+	Label           string            // (可选) 加载任务标签，相同标签只会成功导入一次 (Optional) Load label, a label is only ever loaded once
 }
 
+// Stream Load status values returned by StarRocks.
+// StarRocks 返回的 Stream Load 状态值。
+const (
+	StreamLoadStatusSuccess            = "Success"
+	StreamLoadStatusPublishTimeout     = "Publish Timeout"
+	StreamLoadStatusLabelAlreadyExists = "Label Already Exists"
+)
+
+// Load states returned by GetLoadState for a label.
+// GetLoadState 针对标签返回的导入状态。
+const (
+	LoadStateUnknown   = "UNKNOWN"   // 标签未被使用 The label was not used
+	LoadStatePrepare   = "PREPARE"   // 仍在写入 Still loading
+	LoadStatePrepared  = "PREPARED"  // 已预提交，等待提交或中止 Pre-committed, waiting for commit or abort
+	LoadStateCommitted = "COMMITTED" // 已提交，尚未可见 Committed, not yet visible
+	LoadStateVisible   = "VISIBLE"   // 已提交且可见 Committed and visible
+	LoadStateAborted   = "ABORTED"   // 已中止 Aborted
+)
+
 // StreamLoadResponse holds the response from a StarRocks stream load operation.
 // StreamLoadResponse 保存 StarRocks Stream Load 操作的响应。
 type StreamLoadResponse struct {
@@ -75,14 +141,28 @@ type Client interface {
 	// Execute 执行 DQL (SELECT) 或 DML (INSERT, UPDATE, DELETE - OLAP场景下不常用) 查询。
 	Execute(ctx context.Context, query string, args ...interface{}) (*QueryResult, error)
 
+	// ExecuteWithOptions performs a query like Execute, in the database, workload group and with the timeout of opts.
+	// ExecuteWithOptions 与Execute一样执行查询，使用opts中的数据库、工作负载组与超时时间。
+	ExecuteWithOptions(ctx context.Context, query string, opts *ExecuteOptions) (*QueryResult, error)
+
+	// ExecuteStream performs a query like ExecuteWithOptions, returning rows that are read as the response arrives.
+	// ExecuteStream 与ExecuteWithOptions一样执行查询，返回随响应到达而读取的数据行。
+	ExecuteStream(ctx context.Context, query string, opts *ExecuteOptions) (Rows, error)
+
 	// StreamLoad ingests data into a StarRocks table using the Stream Load method.
 	// StreamLoad 使用 Stream Load 方法将数据导入到 StarRocks 表中。
 	// 'data' is an io.Reader providing the data to be loaded.
 	// 'data' 是一个提供待加载数据的 io.Reader。
 	StreamLoad(ctx context.Context, database, table string, data io.Reader, opts *StreamLoadOptions) (*StreamLoadResponse, error)
 
+	// FetchLoadErrorLog downloads the error log referenced by a Stream Load ErrorURL, truncated to maxBytes.
+	// FetchLoadErrorLog 下载Stream Load ErrorURL所指向的错误日志，最多读取maxBytes字节。
+	FetchLoadErrorLog(ctx context.Context, errorURL string, maxBytes int) (string, error)
+
 	// BeginTransaction (可选) 开始一个两阶段提交事务 (用于Stream Load)
 	// BeginTransaction (Optional) begins a two-phase commit transaction (for Stream Load).
+	// If the label is already in use it returns the existing transaction ID with an AlreadyExistsError.
+	// 如果标签已被使用，则返回已存在的事务ID以及 AlreadyExistsError。
 	BeginTransaction(ctx context.Context, database, table, label string, timeoutSeconds int) (int64, error) // Returns TxnID
 
 	// CommitTransaction (可选) 提交一个两阶段提交事务
@@ -93,6 +173,10 @@ type Client interface {
 	// AbortTransaction (Optional) aborts a two-phase commit transaction.
 	AbortTransaction(ctx context.Context, database string, txnID int64) error
 
+	// GetLoadState returns the state of the load job or transaction that used a label, one of the LoadState values.
+	// GetLoadState 返回使用某个标签的导入任务或事务的状态，取值为LoadState常量之一。
+	GetLoadState(ctx context.Context, database, label string) (string, error)
+
 	// Close terminates any open connections to StarRocks.
 	// Close 关闭所有到 StarRocks 的打开连接。
 	Close() error
@@ -106,20 +190,77 @@ type Client interface {
 // TableSchemaDef 定义表的结构信息
 // TableSchemaDef defines the schema information of a table.
 type TableSchemaDef struct {
-	DatabaseName string
-	TableName    string
-	Fields       []FieldSchemaDef
-	// ... 其他如分区、分桶、属性等信息
-	// ... Other info like partitioning, bucketing, properties etc.
+	DatabaseName        string
+	TableName           string
+	Fields              []FieldSchemaDef
+	KeysType            string               // 键类型 (DUPLICATE, AGGREGATE, UNIQUE, PRIMARY)，为空时按IsKey列使用DUPLICATE Keys type, DUPLICATE over the IsKey columns when empty
+	KeyColumns          []string             // 键列，为空时取IsKey为true的列 Key columns, the IsKey columns when empty
+	Indexes             []IndexDefinitionDef // 随建表一同创建的索引 Indexes created together with the table
+	Partition           *PartitionDef        // 分区方式，nil表示不分区 Partitioning, nil for an unpartitioned table
+	DistributionColumns []string             // 哈希分桶列，为空时随机分桶 Hash distribution columns, random distribution when empty
+	Buckets             int                  // 分桶数，0表示由StarRocks自动决定 Number of buckets, 0 lets StarRocks decide
+	Properties          map[string]string    // 表属性, 如 "replication_num" Table properties, e.g. "replication_num"
+	Comment             string               // 表注释 Table comment
+	IfNotExists         bool                 // 表已存在时不报错 Do not fail when the table already exists
+}
+
+// Keys types of StarRocks tables.
+// StarRocks 表的键类型。
+const (
+	KeysTypeDuplicate = "DUPLICATE"
+	KeysTypeAggregate = "AGGREGATE"
+	KeysTypeUnique    = "UNIQUE"
+	KeysTypePrimary   = "PRIMARY"
+)
+
+// Partitioning types of StarRocks tables.
+// StarRocks 表的分区方式。
+const (
+	PartitionTypeRange      = "RANGE"
+	PartitionTypeExpression = "EXPRESSION"
+)
+
+// PartitionDef 定义表的分区方式
+// PartitionDef defines how a table is partitioned.
+type PartitionDef struct {
+	Type       string               // RANGE 或 EXPRESSION RANGE or EXPRESSION
+	Columns    []string             // 分区列 Partition columns
+	Expression string               // EXPRESSION分区的表达式, 如 "date_trunc('day', event_time)"，为空时按Columns分区 Expression of EXPRESSION partitioning, e.g. "date_trunc('day', event_time)"; partitions by Columns when empty
+	Ranges     []RangePartitionDef  // RANGE分区的初始分区 Initial partitions of RANGE partitioning
+	Dynamic    *DynamicPartitionDef // (可选) RANGE分区的动态分区 (Optional) Dynamic partitions of RANGE partitioning
+}
+
+// RangePartitionDef 定义一个RANGE分区
+// RangePartitionDef defines one RANGE partition, either [Lower, Upper) or, when Lower is empty, LESS THAN Upper.
+type RangePartitionDef struct {
+	Name  string
+	Lower []string // 下界 (含)，每个分区列一个值 Inclusive lower bound, one value per partition column
+	Upper []string // 上界 (不含)，为空表示MAXVALUE Exclusive upper bound, MAXVALUE when empty
+}
+
+// DynamicPartitionDef 定义动态分区属性
+// DynamicPartitionDef holds the dynamic partition properties of a RANGE partitioned table.
+type DynamicPartitionDef struct {
+	TimeUnit            string // DAY, WEEK, MONTH, YEAR 或 HOUR DAY, WEEK, MONTH, YEAR or HOUR
+	Start               int    // 保留的历史分区数 (负数)，0表示不删除 Partitions kept in the past (negative), 0 never drops
+	End                 int    // 预先创建的未来分区数 Partitions created ahead
+	Prefix              string // 分区名前缀 Partition name prefix
+	Buckets             int    // 动态分区的分桶数，0表示沿用表的分桶数 Buckets of dynamic partitions, 0 uses the table's
+	HistoryPartitionNum int    // 建表时创建的历史分区数 History partitions created with the table
 }
 
 // FieldSchemaDef 定义表字段的结构信息
 // FieldSchemaDef defines the schema information of a table field.
 type FieldSchemaDef struct {
-	Name       string
-	Type       string // e.g., "INT", "VARCHAR(255)"
-	IsNullable bool
-	Comment    string
+	Name         string
+	Type         string // e.g., "INT", "VARCHAR(255)"
+	IsNullable   bool
+	IsKey        bool   // 是否为键列 Whether the column is a key column
+	DefaultValue string // 默认值 (无默认值时为空) Default value (empty when there is none)
+	Comment      string
+	// AggregationType AGGREGATE表值列的聚合类型 (如 SUM, MAX, REPLACE)
+	// AggregationType Aggregation type of a value column of an AGGREGATE table (e.g., SUM, MAX, REPLACE)
+	AggregationType string
 	// ... 其他属性
 	// ... Other properties
 }
@@ -134,6 +275,87 @@ type IndexDefinitionDef struct {
 	Comment    string
 }
 
+// TableDefinitionDef 定义SHOW CREATE TABLE报告的表结构之外的信息
+// TableDefinitionDef holds what SHOW CREATE TABLE reports about a table beyond its columns.
+type TableDefinitionDef struct {
+	Engine              string            // 表引擎, 如 "OLAP" Table engine, e.g. "OLAP"
+	KeysType            string            // 键类型 (DUPLICATE, AGGREGATE, UNIQUE, PRIMARY) Keys type
+	KeyColumns          []string          // 键列 Key columns
+	Partition           *PartitionDef     // 分区方式，nil表示不分区 Partitioning, nil for an unpartitioned table
+	PartitionClause     string            // 原始的PARTITION BY子句 The PARTITION BY clause as reported
+	DistributionColumns []string          // 哈希分桶列，随机分桶时为空 Hash distribution columns, empty for random distribution
+	DistributionClause  string            // 原始的DISTRIBUTED BY子句 The DISTRIBUTED BY clause as reported
+	Buckets             int               // 分桶数，0表示自动 Number of buckets, 0 when automatic
+	Properties          map[string]string // 表属性，不含Partition.Dynamic已表示的动态分区属性 Table properties, without the dynamic partition properties Partition.Dynamic represents
+	Comment             string            // 表注释 Table comment
+	Statement           string            // 完整的建表语句 The full CREATE TABLE statement
+}
+
+// Alter job states reported by SHOW ALTER TABLE COLUMN. Jobs in any other state are still running.
+// SHOW ALTER TABLE COLUMN报告的变更任务状态，其他状态的任务仍在执行中。
+const (
+	AlterJobStateFinished  = "FINISHED"
+	AlterJobStateCancelled = "CANCELLED"
+)
+
+// AlterJobDef 定义表模式变更任务的信息
+// AlterJobDef describes a schema change job of a table.
+type AlterJobDef struct {
+	JobID     string
+	TableName string
+	State     string // 任务状态，如 "RUNNING", "FINISHED" Job state, e.g. "RUNNING", "FINISHED"
+	Msg       string // 任务取消时的原因 Why the job was cancelled
+}
+
+// MaterializedViewDef 定义物化视图信息
+// MaterializedViewDef defines materialized view information.
+type MaterializedViewDef struct {
+	DatabaseName    string
+	ViewName        string
+	Query           string            // 定义物化视图的查询 The query defining the view
+	RefreshType     string            // "ASYNC", "MANUAL"
+	RefreshSchedule string            // 异步刷新调度，例如 "EVERY (INTERVAL 1 HOUR)" Async refresh schedule
+	Properties      map[string]string // 物化视图属性 Materialized view properties
+	Comment         string
+	Definition      string                     // 只读：完整的建视图语句 Read-only: full CREATE statement
+	Status          *MaterializedViewStatusDef // 只读：激活状态与最近一次刷新 Read-only: activation and last refresh
+}
+
+// MaterializedViewStatusDef 定义物化视图的激活状态与最近一次刷新的结果
+// MaterializedViewStatusDef defines the activation state of a materialized view and the outcome of its last refresh.
+type MaterializedViewStatusDef struct {
+	IsActive                bool
+	InactiveReason          string    // 视图被停用的原因 Why the view is inactive
+	TaskName                string    // 刷新任务名称 Name of the refresh task
+	LastRefreshState        string    // 例如 "SUCCESS", "FAILED", "RUNNING" E.g., "SUCCESS", "FAILED", "RUNNING"
+	LastRefreshStartTime    time.Time // 未刷新过时为零值 Zero when never refreshed
+	LastRefreshFinishedTime time.Time // 未完成时为零值 Zero when not finished
+	LastRefreshDuration     float64   // 秒 In seconds
+	LastRefreshErrorCode    string
+	LastRefreshErrorMessage string
+	Rows                    int64 // 视图的行数 Number of rows in the view
+}
+
+// TableStatsDef 定义对表的采样统计
+// TableStatsDef defines the statistics sampled from a table.
+type TableStatsDef struct {
+	Rows        int64             // information_schema报告的估计行数 Estimated row count information_schema reports
+	DataBytes   int64             // information_schema报告的数据大小 Data size information_schema reports
+	SampledRows int64             // 采样的行数 Number of rows sampled
+	Columns     []*ColumnStatsDef // 按请求顺序排列 In the order requested
+}
+
+// ColumnStatsDef 定义列在样本中的统计信息，文本度量基于列值的字符串形式
+// ColumnStatsDef defines the statistics of a column in the sample; text measures are of the values as strings.
+type ColumnStatsDef struct {
+	Name            string
+	Distinct        int64   // 近似不同值数 (NDV) Approximate number of distinct values (NDV)
+	NonNull         int64   // 非空值数 Number of non-null values
+	AvgLength       float64 // 平均字符数 Average length in characters
+	AvgBytes        float64 // 平均字节数 Average length in bytes
+	WhitespaceRatio float64 // 含空格的非空值所占比例 Share of non-null values containing a space
+}
+
 // WorkloadGroupDef 定义工作负载组信息
 // WorkloadGroupDef defines workload group information.
 type WorkloadGroupDef struct {
@@ -174,6 +396,40 @@ type DDLExecutor interface {
 	// GetTableSchema 检索特定表的schema。
 	GetTableSchema(ctx context.Context, database, table string) (*TableSchemaDef, error)
 
+	// ShowTables lists the base tables of a database, leaving out views and materialized views.
+	// ShowTables 列出数据库中的基础表，不包括视图与物化视图。
+	ShowTables(ctx context.Context, database string) ([]string, error)
+
+	// ShowCreateTable reads the CREATE TABLE statement of a table and parses its keys, partitioning, distribution
+	// and properties.
+	// ShowCreateTable 读取表的CREATE TABLE语句，并解析其中的键、分区、分桶与属性。
+	ShowCreateTable(ctx context.Context, database, table string) (*TableDefinitionDef, error)
+
+	// ShowIndexes lists the indexes of a table.
+	// ShowIndexes 列出表上的索引。
+	ShowIndexes(ctx context.Context, database, table string) ([]*IndexDefinitionDef, error)
+
+	// ShowAlterColumnJobs lists the schema change jobs of a table, newest first.
+	// ShowAlterColumnJobs 列出表上的模式变更任务，最新的在前。
+	ShowAlterColumnJobs(ctx context.Context, database, table string) ([]*AlterJobDef, error)
+
+	// ShowMaterializedViews lists the materialized views of a database.
+	// ShowMaterializedViews 列出数据库中的物化视图。
+	ShowMaterializedViews(ctx context.Context, database string) ([]*MaterializedViewDef, error)
+
+	// ShowMaterializedView reads a materialized view, returning a NotFoundError when it does not exist.
+	// ShowMaterializedView 读取物化视图，不存在时返回NotFoundError。
+	ShowMaterializedView(ctx context.Context, database, view string) (*MaterializedViewDef, error)
+
+	// SampleTableStats reads the estimated size of a table and samples statistics of columns of scalar types from
+	// up to sampleRows of its rows.
+	// SampleTableStats 读取表的估计大小，并从最多sampleRows行中采样标量类型列的统计信息。
+	SampleTableStats(ctx context.Context, database, table string, columns []string, sampleRows int) (*TableStatsDef, error)
+
+	// ExecuteRawDDL executes a complete DDL statement, such as one rendered by the Build*DDL functions.
+	// ExecuteRawDDL 执行一条完整的DDL语句，例如由Build*DDL函数生成的语句。
+	ExecuteRawDDL(ctx context.Context, statement string) error
+
 	// CreateWorkloadGroup creates a new workload group.
 	// CreateWorkloadGroup 创建一个新的工作负载组。
 	CreateWorkloadGroup(ctx context.Context, group *WorkloadGroupDef) error
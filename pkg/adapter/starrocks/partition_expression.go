@@ -0,0 +1,205 @@
+package starrocks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// intervalUnits are the time units an INTERVAL of a partition expression may use.
+// intervalUnits 是分区表达式中INTERVAL可使用的时间单位。
+var intervalUnits = map[string]bool{"SECOND": true, "MINUTE": true, "HOUR": true, "DAY": true, "WEEK": true, "MONTH": true, "YEAR": true}
+
+// partitionExpression parses the expression of EXPRESSION partitioning and renders it back. The expression is a
+// list of columns and function calls over columns, string and integer literals and intervals, e.g.,
+// "date_trunc('day', event_time)" or "time_slice(event_time, INTERVAL 1 HOUR), region"; anything else is rejected,
+// so that the expression cannot carry other SQL. Columns must be columns of the table.
+// partitionExpression 解析EXPRESSION分区的表达式并重新生成。表达式是列与函数调用组成的列表，函数参数可以是列、字符串
+// 与整数字面量以及时间间隔，例如 "date_trunc('day', event_time)" 或 "time_slice(event_time, INTERVAL 1 HOUR), region"；
+// 其他内容均被拒绝，确保表达式无法携带其他SQL。引用的列必须是表的列。
+func partitionExpression(table, expression string, positions map[string]int) (string, error) {
+	p := &expressionParser{s: expression, table: table, positions: positions}
+	rendered, err := p.list()
+	if err != nil {
+		return "", err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return "", p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return rendered, nil
+}
+
+// expressionParser is a recursive descent parser over a partition expression.
+// expressionParser 是针对分区表达式的递归下降解析器。
+type expressionParser struct {
+	s         string
+	pos       int
+	table     string
+	positions map[string]int
+}
+
+// list reads a comma separated list of terms.
+// list 读取以逗号分隔的项列表。
+func (p *expressionParser) list() (string, error) {
+	var terms []string
+	for {
+		term, err := p.term()
+		if err != nil {
+			return "", err
+		}
+		terms = append(terms, term)
+		if p.skipSpace(); p.pos >= len(p.s) || p.s[p.pos] != ',' {
+			return strings.Join(terms, ", "), nil
+		}
+		p.pos++
+	}
+}
+
+// term reads a parenthesized list, a string or integer literal, an interval, a function call or a column.
+// term 读取括号内的列表、字符串或整数字面量、时间间隔、函数调用或列。
+func (p *expressionParser) term() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return "", p.errorf("expected a column, a function call or a literal")
+	}
+	switch c := p.s[p.pos]; {
+	case c == '(':
+		p.pos++
+		inner, err := p.list()
+		if err != nil {
+			return "", err
+		}
+		return "(" + inner + ")", p.expect(')')
+	case c == '\'':
+		return p.stringLiteral()
+	case isDigit(c):
+		return p.integer(), nil
+	case c == '`':
+		name, err := p.quotedIdentifier()
+		if err != nil {
+			return "", err
+		}
+		return p.column(name)
+	}
+
+	name := p.word()
+	if name == "" {
+		return "", p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	if strings.EqualFold(name, "INTERVAL") {
+		return p.interval()
+	}
+	if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == '(' {
+		p.pos++
+		args, err := p.list()
+		if err != nil {
+			return "", err
+		}
+		return name + "(" + args + ")", p.expect(')')
+	}
+	return p.column(name)
+}
+
+// column renders a reference to a column of the table.
+// column 生成对表中列的引用。
+func (p *expressionParser) column(name string) (string, error) {
+	if _, ok := p.positions[strings.ToLower(name)]; !ok {
+		return "", errors.Newf(errors.InvalidArgument, "partition expression of table %s refers to '%s', which is not a column of the table", p.table, name)
+	}
+	return quoteIdentifier(name), nil
+}
+
+// interval reads the amount and unit following INTERVAL, e.g., "1 HOUR".
+// interval 读取INTERVAL之后的数量与单位，例如 "1 HOUR"。
+func (p *expressionParser) interval() (string, error) {
+	if p.skipSpace(); p.pos >= len(p.s) || !isDigit(p.s[p.pos]) {
+		return "", p.errorf("expected the amount of an interval")
+	}
+	amount := p.integer()
+	p.skipSpace()
+	unit := strings.ToUpper(p.word())
+	if !intervalUnits[unit] {
+		return "", p.errorf("unknown interval unit %q", unit)
+	}
+	return "INTERVAL " + amount + " " + unit, nil
+}
+
+// stringLiteral reads a single quoted string, in which a backslash escapes the following character.
+// stringLiteral 读取单引号字符串，其中反斜杠转义其后的字符。
+func (p *expressionParser) stringLiteral() (string, error) {
+	var sb strings.Builder
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '\\':
+			if i+1 < len(p.s) {
+				i++
+				sb.WriteByte(p.s[i])
+			}
+		case '\'':
+			p.pos = i + 1
+			return quoteString(sb.String()), nil
+		default:
+			sb.WriteByte(p.s[i])
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// quotedIdentifier reads a name quoted with backticks, in which a doubled backtick stands for one.
+// quotedIdentifier 读取用反引号括起的名称，其中两个连续的反引号表示一个反引号。
+func (p *expressionParser) quotedIdentifier() (string, error) {
+	var sb strings.Builder
+	for i := p.pos + 1; i < len(p.s); i++ {
+		if p.s[i] != '`' {
+			sb.WriteByte(p.s[i])
+			continue
+		}
+		if i+1 < len(p.s) && p.s[i+1] == '`' {
+			sb.WriteByte('`')
+			i++
+			continue
+		}
+		p.pos = i + 1
+		return sb.String(), nil
+	}
+	return "", p.errorf("unterminated quoted identifier")
+}
+
+func (p *expressionParser) word() string {
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] == '_' || isDigit(p.s[p.pos]) || ('a' <= p.s[p.pos] && p.s[p.pos] <= 'z') || ('A' <= p.s[p.pos] && p.s[p.pos] <= 'Z')) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *expressionParser) integer() string {
+	start := p.pos
+	for p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *expressionParser) expect(c byte) error {
+	if p.skipSpace(); p.pos >= len(p.s) || p.s[p.pos] != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *expressionParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *expressionParser) errorf(format string, args ...interface{}) error {
+	return errors.Newf(errors.InvalidArgument, "invalid partition expression %q of table %s at position %d: %s", p.s, p.table, p.pos, fmt.Sprintf(format, args...))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,267 @@
+package starrocks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// truncatedJSON is the message of the syntax error a json.Decoder reports when its input ends within a value.
+// truncatedJSON 是json.Decoder的输入在值中间结束时所报告的语法错误的消息。
+const truncatedJSON = "unexpected end of JSON input"
+
+// streamRows implements Rows over the response of a query. The response is walked token by token: open reads it
+// up to the start of data.result, Next decodes one row at a time, and the fields following the result are read
+// once it ends.
+// streamRows 基于查询的响应实现Rows。响应按词法单元逐个遍历：open读取到data.result开始处，Next每次解码一行，
+// 结果结束后再读取其后的字段。
+type streamRows struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	body     io.ReadCloser
+	dec      *json.Decoder
+	columns  []string
+	row      []interface{}
+	stats    *QueryStats
+	inResult bool // 解码器是否位于data.result数组中 Whether the decoder is inside the data.result array
+	code     int
+	msg      string
+	err      error
+}
+
+func newStreamRows(ctx context.Context, cancel context.CancelFunc, body io.ReadCloser) *streamRows {
+	return &streamRows{ctx: ctx, cancel: cancel, body: body, dec: json.NewDecoder(body), stats: &QueryStats{}}
+}
+
+// open reads the response up to its first row. A response without a result, such as an error, is read whole.
+// open 读取响应直到第一行。没有结果的响应（如错误）会被完整读取。
+func (r *streamRows) open() error {
+	if err := r.expectDelim('{'); err != nil {
+		return err
+	}
+	for r.dec.More() {
+		key, err := r.key()
+		if err != nil {
+			return err
+		}
+		if key != "data" {
+			if err := r.readField(key); err != nil {
+				return err
+			}
+			continue
+		}
+		// An error response may carry "data": null.
+		// 错误响应可能带有 "data": null。
+		present, err := r.expectDelimOrNull('{')
+		if err != nil {
+			return err
+		}
+		if !present {
+			continue
+		}
+		found, err := r.readData()
+		if err != nil || found {
+			return err
+		}
+	}
+	return r.close()
+}
+
+// readData reads the fields of data, stopping at the start of its result.
+// readData 读取data中的字段，在其result开始处停止。
+func (r *streamRows) readData() (bool, error) {
+	for r.dec.More() {
+		key, err := r.key()
+		if err != nil {
+			return false, err
+		}
+		switch key {
+		case "meta":
+			var meta []struct {
+				Name string `json:"name"`
+			}
+			if err := r.decode(&meta); err != nil {
+				return false, err
+			}
+			r.columns = make([]string, len(meta))
+			for i, m := range meta {
+				r.columns[i] = m.Name
+			}
+		case "result":
+			present, err := r.expectDelimOrNull('[')
+			if err != nil || !present {
+				return false, err
+			}
+			r.inResult = true
+			return true, nil
+		case "property":
+			var property map[string]interface{}
+			if err := r.decode(&property); err != nil {
+				return false, err
+			}
+			if val, ok := property["Time"].(string); ok { // e.g., "23ms"
+				r.stats.Message = fmt.Sprintf("Time: %s", val)
+			}
+		default:
+			if err := r.decode(&json.RawMessage{}); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, r.expectDelim('}')
+}
+
+// readField reads a top-level field other than data.
+// readField 读取data以外的顶层字段。
+func (r *streamRows) readField(key string) error {
+	switch key {
+	case "code":
+		return r.decode(&r.code)
+	case "msg":
+		return r.decode(&r.msg)
+	default:
+		return r.decode(&json.RawMessage{})
+	}
+}
+
+// close reads the rest of the response once the result ended, failing when StarRocks reported an error.
+// close 在结果结束后读取响应的其余部分，StarRocks报告错误时返回错误。
+func (r *streamRows) close() error {
+	for r.dec.More() {
+		key, err := r.key()
+		if err != nil {
+			return err
+		}
+		if err := r.readField(key); err != nil {
+			return err
+		}
+	}
+	if err := r.expectDelim('}'); err != nil {
+		return err
+	}
+	if r.code != 0 {
+		return errors.Newf(errors.DatabaseError, "StarRocks query error: Code %d, Msg: %s", r.code, r.msg)
+	}
+	return nil
+}
+
+func (r *streamRows) Columns() []string {
+	return r.columns
+}
+
+func (r *streamRows) Next() bool {
+	if r.err != nil || !r.inResult {
+		return false
+	}
+	if r.dec.More() {
+		r.row = nil
+		if r.err = r.decode(&r.row); r.err != nil {
+			return false
+		}
+		return true
+	}
+	r.inResult, r.row = false, nil
+	if r.err = r.expectDelim(']'); r.err != nil {
+		return false
+	}
+	var found bool
+	if found, r.err = r.readData(); r.err == nil && found {
+		r.err = errors.New(errors.DeserializationError, "StarRocks response has more than one result")
+	}
+	if r.err == nil {
+		r.err = r.close()
+	}
+	return false
+}
+
+func (r *streamRows) Row() []interface{} {
+	return r.row
+}
+
+func (r *streamRows) Err() error {
+	return r.err
+}
+
+func (r *streamRows) Stats() *QueryStats {
+	return r.stats
+}
+
+func (r *streamRows) Close() error {
+	r.cancel()
+	return r.body.Close()
+}
+
+func (r *streamRows) key() (string, error) {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return "", r.readError(err)
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", errors.Newf(errors.DeserializationError, "unexpected %v in StarRocks response, expected a field name", tok)
+	}
+	return key, nil
+}
+
+func (r *streamRows) expectDelim(delim json.Delim) error {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return r.readError(err)
+	}
+	if tok != delim {
+		return errors.Newf(errors.DeserializationError, "unexpected %v in StarRocks response, expected %v", tok, delim)
+	}
+	return nil
+}
+
+// expectDelimOrNull reads delim, or a null in its place, reporting whether the value was not null.
+// expectDelimOrNull 读取delim或代替它的null，并报告该值是否不为null。
+func (r *streamRows) expectDelimOrNull(delim json.Delim) (bool, error) {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return false, r.readError(err)
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if tok != delim {
+		return false, errors.Newf(errors.DeserializationError, "unexpected %v in StarRocks response, expected %v", tok, delim)
+	}
+	return true, nil
+}
+
+func (r *streamRows) decode(v interface{}) error {
+	if err := r.dec.Decode(v); err != nil {
+		return r.readError(err)
+	}
+	return nil
+}
+
+// readError classifies an error reading the response, telling a timeout or cancellation of the query apart from
+// a malformed or broken response.
+// readError 对读取响应时的错误进行分类，将查询超时或取消与格式错误、中断的响应区分开。
+func (r *streamRows) readError(err error) error {
+	switch {
+	case r.ctx.Err() == context.DeadlineExceeded:
+		return errors.Wrap(err, errors.TimeoutError, "StarRocks query timed out")
+	case r.ctx.Err() != nil:
+		return errors.Wrap(r.ctx.Err(), errors.NetworkError, "StarRocks query was canceled")
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return errors.Wrap(err, errors.NetworkError, "StarRocks query response ended unexpectedly")
+	}
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		// Token reports a response cut off between values as a syntax error.
+		// Token将在两个值之间被截断的响应报告为语法错误。
+		if syntaxErr.Error() == truncatedJSON {
+			return errors.Wrap(err, errors.NetworkError, "StarRocks query response ended unexpectedly")
+		}
+		return errors.Wrap(err, errors.DeserializationError, "failed to decode StarRocks response")
+	}
+	if _, ok := err.(*json.UnmarshalTypeError); ok {
+		return errors.Wrap(err, errors.DeserializationError, "failed to decode StarRocks response")
+	}
+	return errors.Wrap(err, errors.NetworkError, "failed to read StarRocks query response")
+}
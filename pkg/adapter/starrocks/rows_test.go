@@ -0,0 +1,201 @@
+package starrocks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// closeRecorder is a response body that records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestStreamRows(t *testing.T) {
+	const meta = `"meta":[{"name":"host","type":"varchar"},{"name":"n","type":"bigint"}]`
+
+	tests := []struct {
+		name        string
+		body        string
+		useNumber   bool
+		ctxErr      error // 读取前查询上下文的状态 State of the query context before reading
+		wantOpen    errors.ErrorCode
+		wantColumns []string
+		wantRows    [][]interface{}
+		wantErr     errors.ErrorCode
+		wantMessage string
+	}{
+		{
+			name:        "rows and trailing fields",
+			body:        `{"code":0,"msg":"","data":{"type":"result_set",` + meta + `,"result":[["a",1],["b",2]],"property":{"Time":"5ms"}},"count":0}`,
+			wantColumns: []string{"host", "n"},
+			wantRows:    [][]interface{}{{"a", float64(1)}, {"b", float64(2)}},
+			wantMessage: "Time: 5ms",
+		},
+		{
+			name:        "numbers kept as written",
+			body:        `{"data":{` + meta + `,"result":[["a",9007199254740993]]},"code":0}`,
+			useNumber:   true,
+			wantColumns: []string{"host", "n"},
+			wantRows:    [][]interface{}{{"a", json.Number("9007199254740993")}},
+		},
+		{
+			name:        "empty result",
+			body:        `{"code":0,"data":{` + meta + `,"result":[]}}`,
+			wantColumns: []string{"host", "n"},
+		},
+		{
+			name:        "no result",
+			body:        `{"code":0,"data":{` + meta + `,"result":null}}`,
+			wantColumns: []string{"host", "n"},
+		},
+		{
+			name:     "error body",
+			body:     `{"msg":"Unknown table 'logs.nope'","code":1,"data":null}`,
+			wantOpen: errors.DatabaseError,
+		},
+		{
+			name:     "error body without data",
+			body:     `{"code":5,"msg":"Access denied"}`,
+			wantOpen: errors.DatabaseError,
+		},
+		{
+			name:        "error code after the rows",
+			body:        `{"data":{` + meta + `,"result":[["a",1]]},"code":1,"msg":"Query was cancelled"}`,
+			wantColumns: []string{"host", "n"},
+			wantRows:    [][]interface{}{{"a", float64(1)}},
+			wantErr:     errors.DatabaseError,
+		},
+		{
+			name:        "truncated within the rows",
+			body:        `{"code":0,"data":{` + meta + `,"result":[["a",1],["b",`,
+			wantColumns: []string{"host", "n"},
+			wantRows:    [][]interface{}{{"a", float64(1)}},
+			wantErr:     errors.NetworkError,
+		},
+		{
+			name:        "truncated after the rows",
+			body:        `{"code":0,"data":{` + meta + `,"result":[["a",1]]`,
+			wantColumns: []string{"host", "n"},
+			wantRows:    [][]interface{}{{"a", float64(1)}},
+			wantErr:     errors.NetworkError,
+		},
+		{
+			name:     "truncated before the rows",
+			body:     `{"code":0,"da`,
+			wantOpen: errors.NetworkError,
+		},
+		{
+			name:     "empty body",
+			body:     ``,
+			wantOpen: errors.NetworkError,
+		},
+		{
+			name:        "malformed row",
+			body:        `{"code":0,"data":{` + meta + `,"result":[["a",1],{"host":"b"}]}}`,
+			wantColumns: []string{"host", "n"},
+			wantRows:    [][]interface{}{{"a", float64(1)}},
+			wantErr:     errors.DeserializationError,
+		},
+		{
+			name:        "second result",
+			body:        `{"code":0,"data":{` + meta + `,"result":[["a",1]],"result":[["b",2]]}}`,
+			wantColumns: []string{"host", "n"},
+			wantRows:    [][]interface{}{{"a", float64(1)}},
+			wantErr:     errors.DeserializationError,
+		},
+		{
+			name:     "not an object",
+			body:     `[1,2]`,
+			wantOpen: errors.DeserializationError,
+		},
+		{
+			name:        "timed out",
+			body:        `{"code":0,"data":{` + meta + `,"result":[["a",`,
+			ctxErr:      context.DeadlineExceeded,
+			wantColumns: []string{"host", "n"},
+			wantErr:     errors.TimeoutError,
+		},
+		{
+			name:        "canceled",
+			body:        `{"code":0,"data":{` + meta + `,"result":[["a",`,
+			ctxErr:      context.Canceled,
+			wantColumns: []string{"host", "n"},
+			wantErr:     errors.NetworkError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			switch tt.ctxErr {
+			case context.Canceled:
+				cancel()
+			case context.DeadlineExceeded:
+				var expire context.CancelFunc
+				ctx, expire = context.WithDeadline(ctx, time.Now().Add(-time.Second))
+				defer expire()
+			}
+			body := &closeRecorder{Reader: strings.NewReader(tt.body)}
+			canceled := false
+			rows := newStreamRows(ctx, func() { canceled = true }, body)
+			if tt.useNumber {
+				rows.dec.UseNumber()
+			}
+
+			err := rows.open()
+			if tt.wantOpen != "" {
+				if !errors.Is(err, tt.wantOpen) {
+					t.Fatalf("open() error = %v, want %s", err, tt.wantOpen)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+			if !reflect.DeepEqual(rows.Columns(), tt.wantColumns) {
+				t.Errorf("Columns() = %v, want %v", rows.Columns(), tt.wantColumns)
+			}
+
+			var got [][]interface{}
+			for rows.Next() {
+				got = append(got, rows.Row())
+			}
+			if !reflect.DeepEqual(got, tt.wantRows) {
+				t.Errorf("rows = %v, want %v", got, tt.wantRows)
+			}
+			if tt.wantErr != "" {
+				if !errors.Is(rows.Err(), tt.wantErr) {
+					t.Errorf("Err() = %v, want %s", rows.Err(), tt.wantErr)
+				}
+			} else if rows.Err() != nil {
+				t.Errorf("Err() = %v", rows.Err())
+			}
+			if rows.Stats().Message != tt.wantMessage {
+				t.Errorf("Stats().Message = %q, want %q", rows.Stats().Message, tt.wantMessage)
+			}
+
+			// Next keeps reporting the end, with the same error, once the rows are exhausted.
+			finalErr := rows.Err()
+			if rows.Next() || rows.Row() != nil || rows.Err() != finalErr {
+				t.Errorf("Next() after the end = true or changed the row or error to %v, %v", rows.Row(), rows.Err())
+			}
+
+			if err := rows.Close(); err != nil || !body.closed || !canceled {
+				t.Errorf("Close() = %v, body closed %v, query canceled %v", err, body.closed, canceled)
+			}
+		})
+	}
+}
@@ -66,6 +66,216 @@ const StarRocksDefaultConnectTimeout = 5
 // PulsarDefaultOperationTimeout is the default operation timeout for Pulsar in seconds.
 const PulsarDefaultOperationTimeout = 10
 
+// IngestionDefaultBatchSize 单次Stream Load的默认最大事件数
+// IngestionDefaultBatchSize is the default maximum number of events per Stream Load.
+const IngestionDefaultBatchSize = 5000
+
+// IngestionDefaultLoadTimeout Stream Load默认超时时间（秒）
+// IngestionDefaultLoadTimeout is the default Stream Load timeout in seconds.
+const IngestionDefaultLoadTimeout = 600
+
+// IngestionMaxLabelAttempts 精确一次模式下批次在之前的尝试被中止后最多使用的标签数
+// IngestionMaxLabelAttempts is the maximum number of labels an exactly-once batch is loaded under when its earlier
+// attempts were aborted.
+const IngestionMaxLabelAttempts = 5
+
+// IngestionModeDirect 事件直接写入StarRocks的采集模式
+// IngestionModeDirect is the ingestion mode that writes events straight into StarRocks.
+const IngestionModeDirect = "direct"
+
+// IngestionModePulsar 事件先发布到Pulsar再由Sink写入StarRocks的采集模式
+// IngestionModePulsar is the ingestion mode that publishes events to Pulsar and loads them through a sink.
+const IngestionModePulsar = "pulsar"
+
+// IngestionDefaultTopicPrefix 缓冲采集的默认主题前缀
+// IngestionDefaultTopicPrefix is the default topic prefix for buffered ingestion.
+const IngestionDefaultTopicPrefix = "persistent://public/default/dataseap-ingest-"
+
+// IngestionDefaultSinkSubscription StarRocks Sink的默认订阅名
+// IngestionDefaultSinkSubscription is the default subscription name of the StarRocks sink.
+const IngestionDefaultSinkSubscription = "dataseap-starrocks-sink"
+
+// IngestionDefaultSinkFlushMillis StarRocks Sink默认刷新间隔（毫秒）
+// IngestionDefaultSinkFlushMillis is the default StarRocks sink flush interval in milliseconds.
+const IngestionDefaultSinkFlushMillis = 1000
+
+// IngestionDefaultSinkLedgerTable 记录每条缓冲消息写入批次标签的默认台账表名
+// IngestionDefaultSinkLedgerTable is the default table recording the batch label each buffered message was loaded under.
+const IngestionDefaultSinkLedgerTable = "dataseap_sink_ledger"
+
+// IngestionSinkLedgerRetentionDays Sink台账记录的保留天数
+// IngestionSinkLedgerRetentionDays is the number of days Sink ledger rows are kept.
+const IngestionSinkLedgerRetentionDays = 7
+
+// DeadLetterBackendFile 基于本地文件的死信存储
+// DeadLetterBackendFile selects the local file-backed dead-letter store.
+const DeadLetterBackendFile = "file"
+
+// DeadLetterBackendPulsar 基于Pulsar DLQ主题的死信存储
+// DeadLetterBackendPulsar selects the Pulsar DLQ topic dead-letter store.
+const DeadLetterBackendPulsar = "pulsar"
+
+// DeadLetterDefaultDirectory 死信文件存储的默认目录
+// DeadLetterDefaultDirectory is the default directory of the file dead-letter store.
+const DeadLetterDefaultDirectory = "./data/deadletters"
+
+// DeadLetterDefaultTopic 默认的死信主题
+// DeadLetterDefaultTopic is the default dead-letter topic.
+const DeadLetterDefaultTopic = "persistent://public/default/dataseap-ingest-dlq"
+
+// DeadLetterDefaultMaxErrorLogBytes 死信中保留的错误日志默认最大字节数
+// DeadLetterDefaultMaxErrorLogBytes is the default maximum size of error logs kept in dead letters.
+const DeadLetterDefaultMaxErrorLogBytes = 64 << 10
+
+// IngestionDefaultSchemaRefreshSeconds 从表模式推导的事件模式的默认缓存时长（秒）
+// IngestionDefaultSchemaRefreshSeconds is the default lifetime of event schemas derived from table schemas, in seconds.
+const IngestionDefaultSchemaRefreshSeconds = 300
+
+// IngestionDefaultTransformRulesFile 默认的字段转换规则文件路径
+// IngestionDefaultTransformRulesFile is the default path of the field transformation rules file.
+const IngestionDefaultTransformRulesFile = "./config/transform_rules.yaml"
+
+// IngestionDefaultBulkChunkBytes 流式批量上传中单次Stream Load的默认最大字节数
+// IngestionDefaultBulkChunkBytes is the default maximum size of one Stream Load in a streaming bulk upload.
+const IngestionDefaultBulkChunkBytes = 64 << 20
+
+// IngestionDefaultBulkMaxLineBytes 流式批量上传中单行记录的默认最大字节数
+// IngestionDefaultBulkMaxLineBytes is the default maximum size of one record line in a streaming bulk upload.
+const IngestionDefaultBulkMaxLineBytes = 1 << 20
+
+// IngestStreamDefaultBatchSize gRPC流式上报每批次的默认最大记录数
+// IngestStreamDefaultBatchSize is the default maximum number of records per batch of a gRPC ingestion stream.
+const IngestStreamDefaultBatchSize = 1000
+
+// IngestStreamDefaultFlushMillis gRPC流式上报的默认最长刷新间隔（毫秒）
+// IngestStreamDefaultFlushMillis is the default maximum flush interval of a gRPC ingestion stream in milliseconds.
+const IngestStreamDefaultFlushMillis = 1000
+
+// IngestStreamDefaultBufferSize gRPC流式上报中等待处理的默认最大消息数
+// IngestStreamDefaultBufferSize is the default maximum number of pending messages of a gRPC ingestion stream.
+const IngestStreamDefaultBufferSize = 64
+
+// IngestionDefaultMaxInFlightBytes 采集路径中同时处理的默认最大字节数
+// IngestionDefaultMaxInFlightBytes is the default budget of bytes being processed by the ingestion path at once.
+const IngestionDefaultMaxInFlightBytes = 512 << 20
+
+// IngestionDefaultRetryAfterMillis 在途字节预算耗尽时建议客户端重试的默认等待时间（毫秒）
+// IngestionDefaultRetryAfterMillis is the default retry hint, in milliseconds, given when the in-flight byte budget is exhausted.
+const IngestionDefaultRetryAfterMillis = 1000
+
+// IngestionDefaultRateLimitIdleSeconds 空闲数据源令牌桶的默认回收时间（秒）
+// IngestionDefaultRateLimitIdleSeconds is the default time, in seconds, after which the token bucket of an idle data source is dropped.
+const IngestionDefaultRateLimitIdleSeconds = 600
+
+// IngestionDefaultDedupWindowSeconds 事件去重的默认时间窗口（秒）
+// IngestionDefaultDedupWindowSeconds is the default deduplication window of events, in seconds.
+const IngestionDefaultDedupWindowSeconds = 600
+
+// IngestionDefaultDedupMaxEntries 去重缓存中保留的默认最大键数量
+// IngestionDefaultDedupMaxEntries is the default maximum number of keys kept by the deduplication cache.
+const IngestionDefaultDedupMaxEntries = 500000
+
+// EventTimeActionReject 拒绝事件时间超出允许范围的事件
+// EventTimeActionReject rejects events whose time is out of the allowed range.
+const EventTimeActionReject = "reject"
+
+// EventTimeActionClamp 将超出允许范围的事件时间替换为接收时间
+// EventTimeActionClamp replaces an out-of-range event time with the time the event was received.
+const EventTimeActionClamp = "clamp"
+
+// EventTimeActionQuarantine 将事件时间超出允许范围的事件写入隔离表
+// EventTimeActionQuarantine loads events whose time is out of the allowed range into a quarantine table.
+const EventTimeActionQuarantine = "quarantine"
+
+// IngestionDefaultMaxLatenessSeconds 事件时间早于接收时间的默认最大允许秒数
+// IngestionDefaultMaxLatenessSeconds is the default maximum number of seconds an event time may lie before its receive time.
+const IngestionDefaultMaxLatenessSeconds = 7 * 24 * 3600
+
+// IngestionDefaultMaxFutureSeconds 事件时间晚于接收时间的默认最大允许秒数
+// IngestionDefaultMaxFutureSeconds is the default maximum number of seconds an event time may lie after its receive time.
+const IngestionDefaultMaxFutureSeconds = 300
+
+// IngestionQuarantineTableSuffix 未配置隔离表时，在目标表名后追加的后缀
+// IngestionQuarantineTableSuffix is appended to the target table name when no quarantine table is configured.
+const IngestionQuarantineTableSuffix = "_quarantine"
+
+// IngestionDefaultAutoProvisionTablePrefix 自动创建的表的默认名称前缀
+// IngestionDefaultAutoProvisionTablePrefix is the default name prefix of automatically provisioned tables.
+const IngestionDefaultAutoProvisionTablePrefix = "auto_"
+
+// IngestionDefaultInvertedIndexParser 自动创建的倒排索引的默认分词器
+// IngestionDefaultInvertedIndexParser is the default parser of automatically created inverted indexes.
+const IngestionDefaultInvertedIndexParser = "english"
+
+// MigrationDefaultHistoryTable 记录已应用的模式迁移的默认表名
+// MigrationDefaultHistoryTable is the default name of the table recording applied schema migrations.
+const MigrationDefaultHistoryTable = "dataseap_schema_migrations"
+
+// AdvisorDefaultMaxPatterns 查询顾问记住的默认最大查询模式数
+// AdvisorDefaultMaxPatterns is the default maximum number of query patterns the query advisor remembers.
+const AdvisorDefaultMaxPatterns = 2000
+
+// AdvisorDefaultWindowHours 查询模式在未被执行后保留的默认时长（小时）
+// AdvisorDefaultWindowHours is the default time, in hours, a query pattern is kept after it was last executed.
+const AdvisorDefaultWindowHours = 168
+
+// AdvisorDefaultMinExecutions 推荐物化视图或索引所需的默认最少执行次数
+// AdvisorDefaultMinExecutions is the default minimum number of executions for a materialized view or an index to be
+// recommended.
+const AdvisorDefaultMinExecutions = 10
+
+// AdvisorDefaultMinAvgDurationMillis 推荐物化视图所需的默认最小平均执行时长（毫秒）
+// AdvisorDefaultMinAvgDurationMillis is the default minimum average execution time, in milliseconds, for a
+// materialized view to be recommended.
+const AdvisorDefaultMinAvgDurationMillis = 500
+
+// AdvisorDefaultMaxRecommendations 每次返回的默认最大推荐数
+// AdvisorDefaultMaxRecommendations is the default maximum number of recommendations returned at once.
+const AdvisorDefaultMaxRecommendations = 20
+
+// AdvisorDefaultIndexSampleRows 推荐索引时采样列统计的默认行数
+// AdvisorDefaultIndexSampleRows is the default number of rows sampled for column statistics when recommending indexes.
+const AdvisorDefaultIndexSampleRows = 100000
+
+// AdvisorDefaultBitmapMaxCardinality 推荐位图索引的列在样本中的默认最大不同值数
+// AdvisorDefaultBitmapMaxCardinality is the default maximum number of distinct values in the sample of a column for
+// a bitmap index to be recommended.
+const AdvisorDefaultBitmapMaxCardinality = 10000
+
+// QueryDefaultAllowedStatements 默认允许通过查询API执行的只读语句类型
+// QueryDefaultAllowedStatements are the read-only statement types the query API executes by default.
+var QueryDefaultAllowedStatements = []string{"SELECT", "WITH", "EXPLAIN"}
+
+// QueryDefaultAllowedTableFunctions 默认允许通过查询API读取的表函数，它们只由参数生成行，不读取外部数据
+// QueryDefaultAllowedTableFunctions are the table functions the query API reads from by default. They generate rows
+// from their arguments and read no external data.
+var QueryDefaultAllowedTableFunctions = []string{"unnest", "generate_series"}
+
+// QueryDefaultCatalog StarRocks内部目录的名称，查询策略始终允许该目录
+// QueryDefaultCatalog is the name of the StarRocks internal catalog, which query policies always allow.
+const QueryDefaultCatalog = "default_catalog"
+
+// QueryMaxPageSize 分页SQL查询的最大每页行数
+// QueryMaxPageSize is the maximum number of rows in a page of a paginated SQL query.
+const QueryMaxPageSize = 10000
+
+// QueryPageTokenKeyBytes 未配置密钥时签名分页令牌的随机密钥长度（字节）
+// QueryPageTokenKeyBytes is the length in bytes of the random key signing page tokens when no secret is configured.
+const QueryPageTokenKeyBytes = 32
+
+// QueryStreamDefaultTimeout 未指定超时的流式SQL查询的超时时间（秒）
+// QueryStreamDefaultTimeout is the timeout in seconds of streamed SQL queries that do not set one.
+const QueryStreamDefaultTimeout = 3600
+
+// QueryStreamBatchRows 流式SQL查询每批发送与刷新的行数
+// QueryStreamBatchRows is the number of rows streamed SQL queries send or flush at a time.
+const QueryStreamBatchRows = 500
+
 // HeaderRequestID HTTP头部中用于追踪请求ID的键名
 // HeaderRequestID is the key name in HTTP headers for tracing request ID.
 const HeaderRequestID = "X-Request-ID"
+
+// HeaderUser HTTP头部中标识调用方的键名，由前置的认证网关设置，只有来自可信代理的请求才会采用
+// HeaderUser is the key name in HTTP headers naming the caller, set by an authenticating gateway in front of the
+// server. It is only honored on requests from the trusted proxies.
+const HeaderUser = "X-DataSeaP-User"
@@ -5,8 +5,9 @@ import "github.com/turtacn/dataseap/pkg/common/errors"
 // PaginationRequest 分页请求参数结构
 // PaginationRequest structure for pagination request parameters.
 type PaginationRequest struct {
-	Page     int `json:"page" form:"page" binding:"omitempty,min=1"`         // 页码 Page number
-	PageSize int `json:"pageSize" form:"pageSize" binding:"omitempty,min=1"` // 每页大小 Page size
+	Page      int    `json:"page" form:"page" binding:"omitempty,min=1"`         // 页码 Page number
+	PageSize  int    `json:"pageSize" form:"pageSize" binding:"omitempty,min=1"` // 每页大小 Page size
+	PageToken string `json:"pageToken,omitempty" form:"pageToken"`               // (可选) 上一页返回的下一页令牌，优先于Page (Optional) Next page token of the previous page, takes precedence over Page
 }
 
 // GetOffset 计算数据库查询的偏移量
@@ -40,9 +41,10 @@ func (pr *PaginationRequest) GetLimit() int {
 // PaginationResponse 分页响应参数结构
 // PaginationResponse structure for pagination response parameters.
 type PaginationResponse struct {
-	Page     int   `json:"page"`     // 当前页码 Current page number
-	PageSize int   `json:"pageSize"` // 每页大小 Page size
-	Total    int64 `json:"total"`    // 总记录数 Total number of records
+	Page          int    `json:"page"`                    // 当前页码 Current page number
+	PageSize      int    `json:"pageSize"`                // 每页大小 Page size
+	Total         int64  `json:"total"`                   // 总记录数 Total number of records
+	NextPageToken string `json:"nextPageToken,omitempty"` // 读取下一页的令牌，最后一页为空 Token reading the next page, empty on the last page
 }
 
 // APIResponse 通用API响应结构体
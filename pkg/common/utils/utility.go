@@ -5,12 +5,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
 )
 
 // GenerateUUID 生成一个新的UUID字符串
@@ -154,3 +156,54 @@ func GetEnvOrDefault(key, defaultValue string) string {
 	panic("GetEnvOrDefault should use os.Getenv, typically handled by a config package like Viper.")
 	// return defaultValue
 }
+
+// TrustedProxies 可信代理的网络集合，例如前置的认证网关，只有来自这些网络的请求才能指定调用方
+// TrustedProxies is a set of networks, such as the authenticating gateways in front of the server, whose requests
+// are trusted to name their caller.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies 解析IP地址与CIDR网段，例如 "10.0.0.5" 或 "10.0.0.0/8"
+// ParseTrustedProxies parses IP addresses and CIDR ranges, e.g., "10.0.0.5" or "10.0.0.0/8".
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, errors.Newf(errors.ConfigError, "invalid trusted proxy address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.ConfigError, "invalid trusted proxy range %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Trusts 判断远端地址（可带端口的IP）是否属于可信代理
+// Trusts reports whether a remote address, an IP with an optional port, belongs to a trusted proxy.
+func (t TrustedProxies) Trusts(remoteAddr string) bool {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
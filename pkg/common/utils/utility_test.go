@@ -0,0 +1,39 @@
+package utils
+
+import "testing"
+
+func TestTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.5", " 192.168.0.0/16 ", "fd00::/8", "::1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	tests := []struct {
+		remoteAddr string
+		want       bool
+	}{
+		{"10.0.0.5:41234", true},
+		{"10.0.0.5", true},
+		{"10.0.0.6:41234", false},
+		{"192.168.3.4:80", true},
+		{"[fd00::1]:443", true},
+		{"[::1]:8080", true},
+		{"[fe80::1]:443", false},
+		{"::ffff:10.0.0.5", true},
+		{"", false},
+		{"not-an-ip:80", false},
+	}
+	for _, tt := range tests {
+		if got := proxies.Trusts(tt.remoteAddr); got != tt.want {
+			t.Errorf("Trusts(%q) = %v, want %v", tt.remoteAddr, got, tt.want)
+		}
+	}
+	if (TrustedProxies(nil)).Trusts("127.0.0.1:80") {
+		t.Error("Trusts() of no proxies = true, want false")
+	}
+
+	for _, invalid := range []string{"10.0.0", "10.0.0.0/33", "gateway.local"} {
+		if _, err := ParseTrustedProxies([]string{invalid}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) error = nil, want an error", invalid)
+		}
+	}
+}
@@ -17,6 +17,10 @@ type Config struct {
 	Logger    logger.Config   `mapstructure:"logger" json:"logger" yaml:"logger"`
 	StarRocks StarRocksConfig `mapstructure:"starrocks" json:"starrocks" yaml:"starrocks"`
 	Pulsar    PulsarConfig    `mapstructure:"pulsar" json:"pulsar" yaml:"pulsar"`
+	Ingestion IngestionConfig `mapstructure:"ingestion" json:"ingestion" yaml:"ingestion"`
+	Migration MigrationConfig `mapstructure:"migration" json:"migration" yaml:"migration"`
+	Advisor   AdvisorConfig   `mapstructure:"advisor" json:"advisor" yaml:"advisor"`
+	Query     QueryConfig     `mapstructure:"query" json:"query" yaml:"query"`
 	// 可以添加其他配置项，例如数据库、缓存等
 	// Other configurations like database, cache can be added here
 }
@@ -24,13 +28,23 @@ type Config struct {
 // ServerConfig 服务器相关配置
 // ServerConfig holds server-related configurations.
 type ServerConfig struct {
-	Host           string `mapstructure:"host" json:"host" yaml:"host"`
-	Port           int    `mapstructure:"port" json:"port" yaml:"port"`
-	GRPCPort       int    `mapstructure:"grpcPort" json:"grpcPort" yaml:"grpcPort"`
-	Mode           string `mapstructure:"mode" json:"mode" yaml:"mode"`                         // "debug", "release", "test"
-	ReadTimeout    int    `mapstructure:"readTimeout" json:"readTimeout" yaml:"readTimeout"`    // 秒 seconds
-	WriteTimeout   int    `mapstructure:"writeTimeout" json:"writeTimeout" yaml:"writeTimeout"` // 秒 seconds
-	MaxHeaderBytes int    `mapstructure:"maxHeaderBytes" json:"maxHeaderBytes" yaml:"maxHeaderBytes"`
+	Host           string             `mapstructure:"host" json:"host" yaml:"host"`
+	Port           int                `mapstructure:"port" json:"port" yaml:"port"`
+	GRPCPort       int                `mapstructure:"grpcPort" json:"grpcPort" yaml:"grpcPort"`
+	Mode           string             `mapstructure:"mode" json:"mode" yaml:"mode"`                         // "debug", "release", "test"
+	ReadTimeout    int                `mapstructure:"readTimeout" json:"readTimeout" yaml:"readTimeout"`    // 秒 seconds
+	WriteTimeout   int                `mapstructure:"writeTimeout" json:"writeTimeout" yaml:"writeTimeout"` // 秒 seconds
+	MaxHeaderBytes int                `mapstructure:"maxHeaderBytes" json:"maxHeaderBytes" yaml:"maxHeaderBytes"`
+	IngestStream   IngestStreamConfig `mapstructure:"ingestStream" json:"ingestStream" yaml:"ingestStream"`       // gRPC流式上报配置 gRPC streaming ingestion settings
+	TrustedProxies []string           `mapstructure:"trustedProxies" json:"trustedProxies" yaml:"trustedProxies"` // 可通过X-DataSeaP-User指定调用方的认证网关地址或CIDR，为空时不信任任何请求 Addresses or CIDRs of authenticating gateways allowed to name the caller with X-DataSeaP-User; none when empty
+}
+
+// IngestStreamConfig gRPC流式上报配置
+// IngestStreamConfig holds settings for the streaming gRPC ingestion RPC.
+type IngestStreamConfig struct {
+	BatchSize   int `mapstructure:"batchSize" json:"batchSize" yaml:"batchSize"`       // 每批次的最大记录数 Max records per batch
+	FlushMillis int `mapstructure:"flushMillis" json:"flushMillis" yaml:"flushMillis"` // 最长刷新间隔（毫秒） Max flush interval in milliseconds
+	BufferSize  int `mapstructure:"bufferSize" json:"bufferSize" yaml:"bufferSize"`    // 等待处理的最大消息数，超出后停止读取以施加背压 Max pending messages; reading stops beyond it to apply backpressure
 }
 
 // StarRocksConfig StarRocks数据库配置
@@ -46,6 +60,63 @@ type StarRocksConfig struct {
 	LoadURL        string   `mapstructure:"loadUrl" json:"loadUrl" yaml:"loadUrl"`                      // e.g. "fe_host1:http_port;fe_host2:http_port" for stream load
 }
 
+// MigrationConfig 声明式模式迁移配置
+// MigrationConfig holds settings for declarative schema migrations.
+type MigrationConfig struct {
+	HistoryDatabase string            `mapstructure:"historyDatabase" json:"historyDatabase" yaml:"historyDatabase"` // 迁移历史表所在数据库 (默认为starrocks.database) Database of the history table (defaults to starrocks.database)
+	HistoryTable    string            `mapstructure:"historyTable" json:"historyTable" yaml:"historyTable"`          // 迁移历史表名 Name of the migration history table
+	Properties      map[string]string `mapstructure:"properties" json:"properties" yaml:"properties"`                // 创建历史表时的表属性, 如 "replication_num" Properties of the history table, e.g. "replication_num"
+}
+
+// AdvisorConfig 查询顾问配置
+// AdvisorConfig holds settings for the query advisor, which learns query patterns and recommends materialized views
+// and indexes.
+type AdvisorConfig struct {
+	Enabled              bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                                        // 是否记录执行的查询 Whether executed queries are recorded
+	MaxPatterns          int  `mapstructure:"maxPatterns" json:"maxPatterns" yaml:"maxPatterns"`                            // 记住的最大查询模式数，超出时淘汰最久未执行的 Max query patterns remembered; the least recently executed are evicted beyond it
+	WindowHours          int  `mapstructure:"windowHours" json:"windowHours" yaml:"windowHours"`                            // 未被执行的查询模式的保留时长 How long a pattern is kept after it was last executed
+	MinExecutions        int  `mapstructure:"minExecutions" json:"minExecutions" yaml:"minExecutions"`                      // 推荐所需的最少执行次数 Min executions for a recommendation
+	MinAvgDurationMillis int  `mapstructure:"minAvgDurationMillis" json:"minAvgDurationMillis" yaml:"minAvgDurationMillis"` // 推荐所需的最小平均执行时长 Min average execution time for a recommendation
+	MaxRecommendations   int  `mapstructure:"maxRecommendations" json:"maxRecommendations" yaml:"maxRecommendations"`       // 每次返回的最大推荐数 Max recommendations returned at once
+	IndexSampleRows      int  `mapstructure:"indexSampleRows" json:"indexSampleRows" yaml:"indexSampleRows"`                // 推荐索引时采样的行数 Rows sampled for column statistics when recommending indexes
+	BitmapMaxCardinality int  `mapstructure:"bitmapMaxCardinality" json:"bitmapMaxCardinality" yaml:"bitmapMaxCardinality"` // 推荐位图索引的最大不同值数 Max distinct values for a bitmap index
+}
+
+// QueryConfig 查询服务配置
+// QueryConfig holds settings for the query service.
+type QueryConfig struct {
+	Guard           QueryGuardConfig `mapstructure:"guard" json:"guard" yaml:"guard"`                               // SQL语句防护配置 SQL statement guardrails
+	PageTokenSecret string           `mapstructure:"pageTokenSecret" json:"pageTokenSecret" yaml:"pageTokenSecret"` // 签名分页令牌的密钥，需在所有实例间共享，为空时每个进程使用随机密钥 Secret signing page tokens, shared by all instances; a random per-process secret when empty
+}
+
+// QueryGuardConfig SQL语句防护配置
+// QueryGuardConfig holds the policies restricting the SQL statements callers of the query API may execute. The
+// caller of a request is named by the X-DataSeaP-User header, which is only trusted on requests from the
+// server.trustedProxies; other requests get the default policy.
+type QueryGuardConfig struct {
+	Enabled bool                   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	Default QueryPolicy            `mapstructure:"default" json:"default" yaml:"default"` // 默认策略 Default policy
+	Callers map[string]QueryPolicy `mapstructure:"callers" json:"callers" yaml:"callers"` // 调用方 -> 策略，未设置的列表继承默认策略 Caller -> policy, unset lists inherit the default
+}
+
+// QueryPolicy 单个调用方的SQL语句策略
+// QueryPolicy restricts the statement types, catalogs, databases, tables and table functions of the SQL statements a
+// caller executes. An empty allow list of statements, databases or tables allows everything the deny list does not
+// deny. Tables are "database.table" patterns with "*" wildcards; a pattern without a database matches the table in
+// any database. Catalogs other than the default catalog and table functions are denied unless allowed.
+type QueryPolicy struct {
+	AllowStatements []string `mapstructure:"allowStatements" json:"allowStatements" yaml:"allowStatements"` // 允许的语句类型，例如 "SELECT" Allowed statement types, e.g. "SELECT"
+	DenyStatements  []string `mapstructure:"denyStatements" json:"denyStatements" yaml:"denyStatements"`    // 拒绝的语句类型 Denied statement types
+	AllowDatabases  []string `mapstructure:"allowDatabases" json:"allowDatabases" yaml:"allowDatabases"`
+	DenyDatabases   []string `mapstructure:"denyDatabases" json:"denyDatabases" yaml:"denyDatabases"`
+	AllowTables     []string `mapstructure:"allowTables" json:"allowTables" yaml:"allowTables"`
+	DenyTables      []string `mapstructure:"denyTables" json:"denyTables" yaml:"denyTables"`
+	// 允许的外部目录，默认目录始终允许 Allowed external catalogs, the default catalog is always allowed
+	AllowCatalogs []string `mapstructure:"allowCatalogs" json:"allowCatalogs" yaml:"allowCatalogs"`
+	// 允许的表函数，例如 "unnest" Allowed table functions, e.g. "unnest"
+	AllowTableFunctions []string `mapstructure:"allowTableFunctions" json:"allowTableFunctions" yaml:"allowTableFunctions"`
+}
+
 // PulsarConfig Pulsar消息队列配置
 // PulsarConfig holds Pulsar message queue configurations.
 type PulsarConfig struct {
@@ -55,6 +126,129 @@ type PulsarConfig struct {
 	// More Pulsar specific configurations like TLS, Auth can be added
 }
 
+// IngestionConfig 数据采集配置
+// IngestionConfig holds data ingestion configurations.
+type IngestionConfig struct {
+	Mode           string                 `mapstructure:"mode" json:"mode" yaml:"mode"`                               // "direct" (直接写入StarRocks) 或 "pulsar" (经Pulsar缓冲) "direct" or "pulsar" (buffered)
+	Database       string                 `mapstructure:"database" json:"database" yaml:"database"`                   // 目标数据库 (默认为starrocks.database) Target database (defaults to starrocks.database)
+	TableMapping   map[string]string      `mapstructure:"tableMapping" json:"tableMapping" yaml:"tableMapping"`       // DataType -> 目标表 DataType -> target table
+	BatchSize      int                    `mapstructure:"batchSize" json:"batchSize" yaml:"batchSize"`                // 单次Stream Load的最大事件数 Max events per Stream Load
+	LoadTimeout    int                    `mapstructure:"loadTimeout" json:"loadTimeout" yaml:"loadTimeout"`          // 秒 seconds
+	MaxFilterRatio float64                `mapstructure:"maxFilterRatio" json:"maxFilterRatio" yaml:"maxFilterRatio"` // 允许被过滤的最大行比例 Max ratio of filtered rows tolerated
+	TwoPhaseCommit bool                   `mapstructure:"twoPhaseCommit" json:"twoPhaseCommit" yaml:"twoPhaseCommit"` // 使用确定性标签与2PC实现精确一次写入 Exactly-once loads with deterministic labels and 2PC
+	Buffer         IngestionBufferConfig  `mapstructure:"buffer" json:"buffer" yaml:"buffer"`                         // Pulsar缓冲模式配置 Pulsar buffered mode settings
+	DeadLetter     DeadLetterConfig       `mapstructure:"deadLetter" json:"deadLetter" yaml:"deadLetter"`             // 死信配置 Dead-letter settings
+	Schema         SchemaValidationConfig `mapstructure:"schema" json:"schema" yaml:"schema"`                         // 事件模式校验配置 Event schema validation settings
+	Transform      TransformConfig        `mapstructure:"transform" json:"transform" yaml:"transform"`                // 字段映射与转换配置 Field mapping and transformation settings
+	Parsers        map[string]string      `mapstructure:"parsers" json:"parsers" yaml:"parsers"`                      // DataType -> RawPayload格式 (syslog, syslog3164, syslog5424, cef, leef, ndjson) DataType -> RawPayload format
+	Bulk           BulkIngestConfig       `mapstructure:"bulk" json:"bulk" yaml:"bulk"`                               // 流式批量上传配置 Streaming bulk upload settings
+	RateLimit      RateLimitConfig        `mapstructure:"rateLimit" json:"rateLimit" yaml:"rateLimit"`                // 限流与背压配置 Rate limiting and backpressure settings
+	Dedup          DedupConfig            `mapstructure:"dedup" json:"dedup" yaml:"dedup"`                            // 事件去重配置 Event deduplication settings
+	EventTime      EventTimeConfig        `mapstructure:"eventTime" json:"eventTime" yaml:"eventTime"`                // 迟到与未来事件时间策略 Late and future event time policy
+	AutoProvision  AutoProvisionConfig    `mapstructure:"autoProvision" json:"autoProvision" yaml:"autoProvision"`    // 新数据类型的自动建表配置 Automatic table provisioning for new data types
+}
+
+// AutoProvisionConfig 新数据类型的自动建表配置
+// AutoProvisionConfig holds settings for creating the target table of a data type that has no table mapping.
+type AutoProvisionConfig struct {
+	Enabled             bool              `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	TablePrefix         string            `mapstructure:"tablePrefix" json:"tablePrefix" yaml:"tablePrefix"`                         // 表名前缀，表名为前缀+DataType Table name prefix, the table is prefix + DataType
+	Buckets             int               `mapstructure:"buckets" json:"buckets" yaml:"buckets"`                                     // 分桶数，0表示由StarRocks自动决定 Number of buckets, 0 lets StarRocks decide
+	Properties          map[string]string `mapstructure:"properties" json:"properties" yaml:"properties"`                            // 建表属性, 如 "replication_num" Table properties, e.g. "replication_num"
+	InvertedIndexes     bool              `mapstructure:"invertedIndexes" json:"invertedIndexes" yaml:"invertedIndexes"`             // 为文本字段创建倒排索引 Create inverted indexes on text fields
+	InvertedIndexParser string            `mapstructure:"invertedIndexParser" json:"invertedIndexParser" yaml:"invertedIndexParser"` // 倒排索引分词器 Parser of the inverted indexes
+	AddColumns          bool              `mapstructure:"addColumns" json:"addColumns" yaml:"addColumns"`                            // 出现新字段时自动添加列 Add columns when new fields appear
+}
+
+// EventTimeConfig 迟到与未来事件时间策略配置
+// EventTimeConfig holds the policy for events whose time lies too far before or after the time they were received.
+type EventTimeConfig struct {
+	Enabled   bool                       `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	Default   EventTimePolicy            `mapstructure:"default" json:"default" yaml:"default"`       // 默认策略 Default policy
+	DataTypes map[string]EventTimePolicy `mapstructure:"dataTypes" json:"dataTypes" yaml:"dataTypes"` // DataType -> 策略，零值字段继承默认策略 DataType -> policy, zero fields inherit the default
+}
+
+// EventTimePolicy 单个数据类型的事件时间策略
+// EventTimePolicy bounds the event time of one data type and says what to do with events outside the bounds.
+type EventTimePolicy struct {
+	MaxLatenessSeconds int64  `mapstructure:"maxLatenessSeconds" json:"maxLatenessSeconds" yaml:"maxLatenessSeconds"` // 事件时间早于接收时间的最大秒数，负数表示不限制 Max seconds before the receive time, negative is unlimited
+	MaxFutureSeconds   int64  `mapstructure:"maxFutureSeconds" json:"maxFutureSeconds" yaml:"maxFutureSeconds"`       // 事件时间晚于接收时间的最大秒数，负数表示不限制 Max seconds after the receive time, negative is unlimited
+	Action             string `mapstructure:"action" json:"action" yaml:"action"`                                     // "reject", "clamp" 或 "quarantine" "reject", "clamp" or "quarantine"
+	QuarantineTable    string `mapstructure:"quarantineTable" json:"quarantineTable" yaml:"quarantineTable"`          // 隔离表，默认为目标表加"_quarantine" Quarantine table, defaults to the target table + "_quarantine"
+}
+
+// DedupConfig 事件去重配置
+// DedupConfig holds settings for dropping events already ingested within a time window.
+type DedupConfig struct {
+	Enabled       bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	WindowSeconds int  `mapstructure:"windowSeconds" json:"windowSeconds" yaml:"windowSeconds"` // 去重时间窗口 Deduplication window
+	MaxEntries    int  `mapstructure:"maxEntries" json:"maxEntries" yaml:"maxEntries"`          // 缓存的最大键数量，超出时淘汰最旧的键 Max keys cached, the oldest are evicted beyond it
+}
+
+// RateLimitConfig 采集限流与背压配置
+// RateLimitConfig holds the per-data-source rate limits and the in-flight byte budget of the ingestion path.
+type RateLimitConfig struct {
+	Enabled          bool                    `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	EventsPerSecond  float64                 `mapstructure:"eventsPerSecond" json:"eventsPerSecond" yaml:"eventsPerSecond"`    // 每个数据源的默认事件速率，0表示不限制 Default events per second of each data source, 0 is unlimited
+	Burst            int                     `mapstructure:"burst" json:"burst" yaml:"burst"`                                  // 每个数据源的默认突发容量，0表示等于速率 Default burst of each data source, 0 means one second of rate
+	MaxInFlightBytes int64                   `mapstructure:"maxInFlightBytes" json:"maxInFlightBytes" yaml:"maxInFlightBytes"` // 同时处理的最大字节数，0表示不限制 Max bytes processed at once, 0 is unlimited
+	RetryAfterMillis int                     `mapstructure:"retryAfterMillis" json:"retryAfterMillis" yaml:"retryAfterMillis"` // 字节预算耗尽时的重试提示 Retry hint when the byte budget is exhausted
+	IdleSeconds      int                     `mapstructure:"idleSeconds" json:"idleSeconds" yaml:"idleSeconds"`                // 空闲令牌桶的回收时间 Time after which idle buckets are dropped
+	Sources          []SourceRateLimitConfig `mapstructure:"sources" json:"sources" yaml:"sources"`                            // 按数据源覆盖的限制 Per-data-source overrides
+}
+
+// SourceRateLimitConfig 单个数据源的限流配置
+// SourceRateLimitConfig overrides the rate limit of one data source.
+type SourceRateLimitConfig struct {
+	DataSourceID    string  `mapstructure:"dataSourceId" json:"dataSourceId" yaml:"dataSourceId"`
+	EventsPerSecond float64 `mapstructure:"eventsPerSecond" json:"eventsPerSecond" yaml:"eventsPerSecond"` // 0表示不限制 0 is unlimited
+	Burst           int     `mapstructure:"burst" json:"burst" yaml:"burst"`
+}
+
+// BulkIngestConfig 流式批量上传配置
+// BulkIngestConfig holds settings for streaming bulk uploads.
+type BulkIngestConfig struct {
+	ChunkBytes   int `mapstructure:"chunkBytes" json:"chunkBytes" yaml:"chunkBytes"`       // 单次Stream Load的最大字节数 Max bytes per Stream Load
+	MaxLineBytes int `mapstructure:"maxLineBytes" json:"maxLineBytes" yaml:"maxLineBytes"` // 单行记录的最大字节数 Max bytes of a single record line
+}
+
+// TransformConfig 字段映射与转换配置
+// TransformConfig holds settings for the per-data-source transformation stage.
+type TransformConfig struct {
+	Enabled   bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	RulesFile string `mapstructure:"rulesFile" json:"rulesFile" yaml:"rulesFile"` // 转换规则文件路径 Path of the transformation rules file
+	HotReload bool   `mapstructure:"hotReload" json:"hotReload" yaml:"hotReload"` // 规则文件变化时自动重新加载 Reload rules when the file changes
+}
+
+// SchemaValidationConfig 事件模式校验配置
+// SchemaValidationConfig holds settings for validating events against the schema of their target table.
+type SchemaValidationConfig struct {
+	Enabled             bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	RejectUnknownFields bool `mapstructure:"rejectUnknownFields" json:"rejectUnknownFields" yaml:"rejectUnknownFields"` // 拒绝表中不存在的字段 Reject fields missing from the table
+	RefreshSeconds      int  `mapstructure:"refreshSeconds" json:"refreshSeconds" yaml:"refreshSeconds"`                // 推导模式的缓存时长，0表示不刷新 Cache lifetime of derived schemas, 0 never refreshes
+}
+
+// DeadLetterConfig 死信存储配置
+// DeadLetterConfig holds settings for the dead-letter store of rejected events.
+type DeadLetterConfig struct {
+	Enabled          bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	Backend          string `mapstructure:"backend" json:"backend" yaml:"backend"`                            // "file" 或 "pulsar" "file" or "pulsar"
+	Directory        string `mapstructure:"directory" json:"directory" yaml:"directory"`                      // file后端的存储目录 Directory of the file backend
+	Topic            string `mapstructure:"topic" json:"topic" yaml:"topic"`                                  // pulsar后端的DLQ主题 DLQ topic of the pulsar backend
+	FetchErrorLog    bool   `mapstructure:"fetchErrorLog" json:"fetchErrorLog" yaml:"fetchErrorLog"`          // 是否下载ErrorURL内容 Whether to download ErrorURL content
+	MaxErrorLogBytes int    `mapstructure:"maxErrorLogBytes" json:"maxErrorLogBytes" yaml:"maxErrorLogBytes"` // 错误日志最大保留字节数 Max bytes of error log kept
+}
+
+// IngestionBufferConfig Pulsar缓冲采集配置
+// IngestionBufferConfig holds settings for the Pulsar-buffered ingestion mode.
+type IngestionBufferConfig struct {
+	TopicPrefix      string `mapstructure:"topicPrefix" json:"topicPrefix" yaml:"topicPrefix"`                // 主题前缀，主题名为前缀+DataType Topic prefix, topic is prefix + DataType
+	SubscriptionName string `mapstructure:"subscriptionName" json:"subscriptionName" yaml:"subscriptionName"` // Sink消费者订阅名 Subscription name of the sink consumer
+	SinkBatchSize    int    `mapstructure:"sinkBatchSize" json:"sinkBatchSize" yaml:"sinkBatchSize"`          // Sink单批最大事件数 Max events per sink batch
+	SinkFlushMillis  int    `mapstructure:"sinkFlushMillis" json:"sinkFlushMillis" yaml:"sinkFlushMillis"`    // Sink最长刷新间隔（毫秒） Max sink flush interval in milliseconds
+	SinkLedgerTable  string `mapstructure:"sinkLedgerTable" json:"sinkLedgerTable" yaml:"sinkLedgerTable"`    // 2PC模式下记录消息所属批次标签的表 Table recording the batch label of each message in 2PC mode
+}
+
 var (
 	globalConfig *Config
 	configOnce   sync.Once
@@ -78,6 +272,9 @@ func LoadConfig(filePath ...string) (*Config, error) {
 		v.SetDefault("server.readTimeout", 30)       // 30 seconds
 		v.SetDefault("server.writeTimeout", 30)      // 30 seconds
 		v.SetDefault("server.maxHeaderBytes", 1<<20) // 1MB
+		v.SetDefault("server.ingestStream.batchSize", constants.IngestStreamDefaultBatchSize)
+		v.SetDefault("server.ingestStream.flushMillis", constants.IngestStreamDefaultFlushMillis)
+		v.SetDefault("server.ingestStream.bufferSize", constants.IngestStreamDefaultBufferSize)
 
 		defaultLoggerCfg := logger.DefaultConfig()
 		v.SetDefault("logger.level", defaultLoggerCfg.Level)
@@ -94,6 +291,60 @@ func LoadConfig(filePath ...string) (*Config, error) {
 
 		v.SetDefault("pulsar.operationTimeout", constants.PulsarDefaultOperationTimeout)
 
+		v.SetDefault("ingestion.mode", constants.IngestionModeDirect)
+		v.SetDefault("ingestion.batchSize", constants.IngestionDefaultBatchSize)
+		v.SetDefault("ingestion.loadTimeout", constants.IngestionDefaultLoadTimeout)
+		v.SetDefault("ingestion.maxFilterRatio", 0)
+		v.SetDefault("ingestion.twoPhaseCommit", false)
+		v.SetDefault("ingestion.buffer.topicPrefix", constants.IngestionDefaultTopicPrefix)
+		v.SetDefault("ingestion.buffer.subscriptionName", constants.IngestionDefaultSinkSubscription)
+		v.SetDefault("ingestion.buffer.sinkBatchSize", constants.IngestionDefaultBatchSize)
+		v.SetDefault("ingestion.buffer.sinkFlushMillis", constants.IngestionDefaultSinkFlushMillis)
+		v.SetDefault("ingestion.buffer.sinkLedgerTable", constants.IngestionDefaultSinkLedgerTable)
+		v.SetDefault("ingestion.deadLetter.enabled", false)
+		v.SetDefault("ingestion.deadLetter.backend", constants.DeadLetterBackendFile)
+		v.SetDefault("ingestion.deadLetter.directory", constants.DeadLetterDefaultDirectory)
+		v.SetDefault("ingestion.deadLetter.topic", constants.DeadLetterDefaultTopic)
+		v.SetDefault("ingestion.deadLetter.fetchErrorLog", true)
+		v.SetDefault("ingestion.deadLetter.maxErrorLogBytes", constants.DeadLetterDefaultMaxErrorLogBytes)
+		v.SetDefault("ingestion.schema.enabled", false)
+		v.SetDefault("ingestion.schema.rejectUnknownFields", true)
+		v.SetDefault("ingestion.schema.refreshSeconds", constants.IngestionDefaultSchemaRefreshSeconds)
+		v.SetDefault("ingestion.transform.enabled", false)
+		v.SetDefault("ingestion.transform.rulesFile", constants.IngestionDefaultTransformRulesFile)
+		v.SetDefault("ingestion.transform.hotReload", true)
+		v.SetDefault("ingestion.bulk.chunkBytes", constants.IngestionDefaultBulkChunkBytes)
+		v.SetDefault("ingestion.bulk.maxLineBytes", constants.IngestionDefaultBulkMaxLineBytes)
+		v.SetDefault("ingestion.rateLimit.enabled", false)
+		v.SetDefault("ingestion.rateLimit.eventsPerSecond", 0)
+		v.SetDefault("ingestion.rateLimit.maxInFlightBytes", constants.IngestionDefaultMaxInFlightBytes)
+		v.SetDefault("ingestion.rateLimit.retryAfterMillis", constants.IngestionDefaultRetryAfterMillis)
+		v.SetDefault("ingestion.rateLimit.idleSeconds", constants.IngestionDefaultRateLimitIdleSeconds)
+		v.SetDefault("ingestion.dedup.enabled", false)
+		v.SetDefault("ingestion.dedup.windowSeconds", constants.IngestionDefaultDedupWindowSeconds)
+		v.SetDefault("ingestion.dedup.maxEntries", constants.IngestionDefaultDedupMaxEntries)
+		v.SetDefault("ingestion.eventTime.enabled", false)
+		v.SetDefault("ingestion.eventTime.default.maxLatenessSeconds", constants.IngestionDefaultMaxLatenessSeconds)
+		v.SetDefault("ingestion.eventTime.default.maxFutureSeconds", constants.IngestionDefaultMaxFutureSeconds)
+		v.SetDefault("ingestion.eventTime.default.action", constants.EventTimeActionReject)
+		v.SetDefault("ingestion.autoProvision.enabled", false)
+		v.SetDefault("ingestion.autoProvision.tablePrefix", constants.IngestionDefaultAutoProvisionTablePrefix)
+		v.SetDefault("ingestion.autoProvision.invertedIndexes", true)
+		v.SetDefault("ingestion.autoProvision.invertedIndexParser", constants.IngestionDefaultInvertedIndexParser)
+		v.SetDefault("ingestion.autoProvision.addColumns", true)
+		v.SetDefault("migration.historyTable", constants.MigrationDefaultHistoryTable)
+		v.SetDefault("advisor.enabled", false)
+		v.SetDefault("advisor.maxPatterns", constants.AdvisorDefaultMaxPatterns)
+		v.SetDefault("advisor.windowHours", constants.AdvisorDefaultWindowHours)
+		v.SetDefault("advisor.minExecutions", constants.AdvisorDefaultMinExecutions)
+		v.SetDefault("advisor.minAvgDurationMillis", constants.AdvisorDefaultMinAvgDurationMillis)
+		v.SetDefault("advisor.maxRecommendations", constants.AdvisorDefaultMaxRecommendations)
+		v.SetDefault("advisor.indexSampleRows", constants.AdvisorDefaultIndexSampleRows)
+		v.SetDefault("advisor.bitmapMaxCardinality", constants.AdvisorDefaultBitmapMaxCardinality)
+		v.SetDefault("query.guard.enabled", true)
+		v.SetDefault("query.guard.default.allowStatements", constants.QueryDefaultAllowedStatements)
+		v.SetDefault("query.guard.default.allowTableFunctions", constants.QueryDefaultAllowedTableFunctions)
+
 		// 设置配置文件路径和类型
 		// Set config file path and type
 		if len(filePath) > 0 && filePath[0] != "" {
@@ -133,6 +384,12 @@ func LoadConfig(filePath ...string) (*Config, error) {
 			err = fmt.Errorf("failed to unmarshal config: %w", errUnmarshal)
 			return
 		}
+		if cfg.Ingestion.Database == "" {
+			cfg.Ingestion.Database = cfg.StarRocks.Database
+		}
+		if cfg.Migration.HistoryDatabase == "" {
+			cfg.Migration.HistoryDatabase = cfg.StarRocks.Database
+		}
 		globalConfig = &cfg
 	})
 
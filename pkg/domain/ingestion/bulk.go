@@ -0,0 +1,356 @@
+package ingestion
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// maxRejectedSample bounds the number of rejected-line errors reported in a bulk summary.
+// maxRejectedSample 限制批量汇总中报告的被拒绝行错误数量。
+const maxRejectedSample = 10
+
+// uploadIDPattern restricts client-supplied upload IDs to characters StarRocks accepts in labels, and to a
+// length that keeps "dataseap_bulk_<table>_<uploadID>_<n>" within the 128 character label limit.
+// uploadIDPattern 将客户端提供的上传标识限制为StarRocks标签可接受的字符，并限制其长度，
+// 使 "dataseap_bulk_<table>_<uploadID>_<n>" 不超过128个字符的标签上限。
+var uploadIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,36}$`)
+
+// IngestStream loads a newline-delimited NDJSON or CSV body into the table of req.DataType without
+// holding the whole body in memory. The body is cut at line boundaries into chunks of at most the
+// configured chunk size, each sent as one Stream Load labelled "<jobID>_<n>". Chunks are buffered rather
+// than piped so the load can follow the FE-to-BE redirect, which replays the request body.
+// When the client supplies req.UploadID the job ID is derived from it, so a retried upload of the same body
+// reuses the chunk labels and skips the chunks an earlier attempt committed.
+// Events loaded this way bypass payload parsing, transformation and schema validation.
+// IngestStream 将以换行分隔的NDJSON或CSV请求体写入req.DataType对应的表，而无需将整个请求体保存在内存中。
+// 请求体在行边界处被切分为不超过配置大小的分块，每个分块作为一次标签为"<jobID>_<n>"的Stream Load发送。
+// 分块采用缓冲而非管道方式，以便写入可以跟随FE到BE的重定向（该重定向会重放请求体）。
+// 客户端提供req.UploadID时任务ID由其派生，因此重试上传同一请求体时会复用分块标签，并跳过之前的尝试已提交的分块。
+// 以此方式写入的事件不经过负载解析、转换与模式校验。
+func (s *serviceImpl) IngestStream(ctx context.Context, req *model.BulkIngestRequest, body io.Reader) (*model.BulkIngestSummary, error) {
+	l := logger.L().With("method", "IngestStream", "data_type", req.DataType, "format", req.Format)
+	l.Info("Attempting to ingest bulk upload")
+
+	if req.DataType == "" {
+		return nil, errors.New(errors.InvalidArgument, "DataType cannot be empty")
+	}
+	if req.Format != model.BulkFormatNDJSON && req.Format != model.BulkFormatCSV {
+		return nil, errors.Newf(errors.InvalidArgument, "unsupported bulk format '%s', expected '%s' or '%s'", req.Format, model.BulkFormatNDJSON, model.BulkFormatCSV)
+	}
+	table, _, ok := s.targetTable(req.DataType)
+	if !ok {
+		return nil, errors.Newf(errors.InvalidArgument, "no target table configured for data type %s", req.DataType)
+	}
+	if s.cfg.Database == "" {
+		return nil, errors.New(errors.ConfigError, "ingestion target database is not configured")
+	}
+	jobID := "dataseap_bulk_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	if req.UploadID != "" {
+		if !uploadIDPattern.MatchString(req.UploadID) {
+			return nil, errors.Newf(errors.InvalidArgument, "invalid upload ID '%s', expected up to 36 letters, digits, '_' or '-'", req.UploadID)
+		}
+		// Labels are unique per database, so the table keeps equal upload IDs of different data types apart.
+		jobID = fmt.Sprintf("dataseap_bulk_%s_%s", table, req.UploadID)
+	}
+
+	// A bulk upload holds one chunk in memory at a time, which is what it takes from the in-flight byte budget.
+	// 批量上传同一时刻只在内存中保存一个分块，因此从在途字节预算中预留一个分块的大小。
+	if s.limiter != nil {
+		release, err := s.limiter.AcquireBytes(int64(bulkChunkBytes(s.cfg.Bulk)))
+		if err != nil {
+			recordRateLimited(map[string]struct{}{req.DataType: {}}, err)
+			l.Warnw("Bulk upload rate limited", "error", err)
+			return nil, err
+		}
+		defer release()
+	}
+
+	reader, closeReader, err := decompress(body, req.Compression)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader()
+
+	summary := &model.BulkIngestSummary{
+		JobID:     jobID,
+		DataType:  req.DataType,
+		Table:     table,
+		Format:    req.Format,
+		StartedAt: time.Now().UTC(),
+	}
+	job := &bulkJob{
+		service: s,
+		req:     req,
+		table:   table,
+		summary: summary,
+		chunk:   newBulkChunk(req.Format),
+	}
+	if len(req.Columns) > 0 {
+		job.columns = strings.Join(req.Columns, ",")
+	}
+
+	err = job.run(ctx, reader)
+	summary.FinishedAt = time.Now().UTC()
+	switch {
+	case err != nil && summary.LoadedRows == 0:
+		summary.Status = model.BulkStatusFailed
+	case err != nil || summary.FilteredRows > 0 || summary.RejectedRows > 0:
+		summary.Status = model.BulkStatusPartial
+	default:
+		summary.Status = model.BulkStatusSuccess
+	}
+	if err != nil {
+		l.Errorw("Bulk upload failed", "job_id", summary.JobID, "chunks", summary.Chunks, "loaded", summary.LoadedRows, "error", err)
+		return summary, err
+	}
+	l.Infow("Bulk upload completed", "job_id", summary.JobID, "status", summary.Status, "chunks", summary.Chunks,
+		"total", summary.TotalRows, "loaded", summary.LoadedRows, "filtered", summary.FilteredRows, "rejected", summary.RejectedRows,
+		"skipped", summary.SkippedRows)
+	return summary, nil
+}
+
+// bulkJob carries the state of one IngestStream call.
+// bulkJob 保存一次IngestStream调用的状态。
+type bulkJob struct {
+	service *serviceImpl
+	req     *model.BulkIngestRequest
+	table   string
+	columns string // CSV列名 CSV column names
+	summary *model.BulkIngestSummary
+	chunk   *bulkChunk
+}
+
+func (j *bulkJob) run(ctx context.Context, r io.Reader) error {
+	chunkBytes := bulkChunkBytes(j.service.cfg.Bulk)
+	maxLineBytes := j.service.cfg.Bulk.MaxLineBytes
+	if maxLineBytes <= 0 {
+		maxLineBytes = constants.IngestionDefaultBulkMaxLineBytes
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	lineNo := 0
+	headerPending := j.req.Format == model.BulkFormatCSV && j.req.CSVHeader
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if headerPending {
+			j.columns = strings.ReplaceAll(string(line), j.separator(), ",")
+			headerPending = false
+			continue
+		}
+		if j.req.Format == model.BulkFormatNDJSON && !json.Valid(line) {
+			j.reject(fmt.Sprintf("line %d: invalid JSON", lineNo))
+			continue
+		}
+		j.summary.TotalRows++
+		j.chunk.add(line)
+		if j.chunk.size() >= chunkBytes {
+			if err := j.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return errors.Newf(errors.InvalidArgument, "line %d exceeds the maximum line size of %d bytes", lineNo+1, maxLineBytes)
+		}
+		return errors.Wrap(err, errors.NetworkError, "failed to read bulk upload body")
+	}
+	return j.flush(ctx)
+}
+
+// flush sends the current chunk, if any, as one Stream Load.
+// flush 将当前分块（如有）作为一次Stream Load发送。
+func (j *bulkJob) flush(ctx context.Context) error {
+	if j.chunk.rows == 0 {
+		return nil
+	}
+	label := fmt.Sprintf("%s_%d", j.summary.JobID, j.summary.Chunks)
+	rows := int64(j.chunk.rows)
+	payload := j.chunk.bytes()
+	defer j.chunk.reset()
+	j.summary.Chunks++
+
+	opts := &starrocks.StreamLoadOptions{
+		TimeoutSeconds: j.service.cfg.LoadTimeout,
+		MaxFilterRatio: j.service.cfg.MaxFilterRatio,
+		Label:          label,
+	}
+	if j.req.Format == model.BulkFormatNDJSON {
+		opts.Format = "json"
+		opts.StripOuterArray = true
+	} else {
+		opts.Format = "csv"
+		opts.ColumnSeparator = j.separator()
+		opts.RowDelimiter = "\n"
+		if j.columns != "" {
+			opts.Headers = map[string]string{"columns": j.columns}
+		}
+	}
+
+	resp, err := j.service.starrocksClient.StreamLoad(ctx, j.service.cfg.Database, j.table, bytes.NewReader(payload), opts)
+	result := &model.BulkChunkResult{Label: label, Rows: rows}
+	j.summary.ChunkResults = append(j.summary.ChunkResults, result)
+	if resp != nil {
+		result.Status = resp.Status
+		result.TxnID = resp.TxnID
+		result.LoadedRows = resp.NumberLoadedRows
+		result.FilteredRows = resp.NumberFilteredRows
+		result.LoadBytes = resp.LoadBytes
+		result.LoadTimeMs = resp.LoadTimeMs
+		result.ErrorURL = resp.ErrorURL
+		result.Message = resp.Message
+	}
+	if errors.Is(err, errors.AlreadyExistsError) && j.req.UploadID != "" {
+		err = j.committedBefore(ctx, label)
+		if err == nil {
+			result.Skipped = true
+			result.Message = "committed by an earlier attempt of the upload"
+			j.summary.SkippedRows += rows
+			return nil
+		}
+	}
+	if err != nil {
+		if result.Message == "" {
+			result.Message = err.Error()
+		}
+		return errors.Wrapf(err, errors.GetCode(err), "bulk chunk %s failed after %d loaded rows", label, j.summary.LoadedRows)
+	}
+	j.summary.LoadedRows += result.LoadedRows
+	j.summary.FilteredRows += result.FilteredRows
+	j.summary.LoadBytes += result.LoadBytes
+	return nil
+}
+
+// committedBefore returns nil when the chunk label is in use because an earlier attempt of the upload committed
+// the chunk, and an error when that load is still running or in an unexpected state.
+// committedBefore 当分块标签因之前的上传尝试已提交该分块而被占用时返回nil；若该导入仍在进行或处于意外状态则返回错误。
+func (j *bulkJob) committedBefore(ctx context.Context, label string) error {
+	state, err := j.service.starrocksClient.GetLoadState(ctx, j.service.cfg.Database, label)
+	if err != nil {
+		return err
+	}
+	switch state {
+	case starrocks.LoadStateCommitted, starrocks.LoadStateVisible:
+		logger.L().With("method", "IngestStream", "label", label).Infow("Bulk chunk already committed, skipping it", "state", state)
+		return nil
+	case starrocks.LoadStatePrepare, starrocks.LoadStatePrepared:
+		return errors.Newf(errors.AlreadyExistsError, "bulk chunk %s is still being loaded by an earlier attempt of the upload", label)
+	default:
+		return errors.Newf(errors.DatabaseError, "bulk chunk label %s is in use by a load in state %s", label, state)
+	}
+}
+
+func (j *bulkJob) separator() string {
+	if j.req.ColumnSeparator != "" {
+		return j.req.ColumnSeparator
+	}
+	return ","
+}
+
+func (j *bulkJob) reject(reason string) {
+	j.summary.RejectedRows++
+	if len(j.summary.RejectedSample) < maxRejectedSample {
+		j.summary.RejectedSample = append(j.summary.RejectedSample, reason)
+	}
+}
+
+// bulkChunk accumulates lines in the body format Stream Load expects: a JSON array for NDJSON,
+// newline-terminated rows for CSV.
+// bulkChunk 以Stream Load所期望的格式累积行：NDJSON为JSON数组，CSV为以换行结尾的行。
+type bulkChunk struct {
+	format model.BulkFormat
+	buf    bytes.Buffer
+	rows   int
+}
+
+func newBulkChunk(format model.BulkFormat) *bulkChunk {
+	return &bulkChunk{format: format}
+}
+
+func (c *bulkChunk) add(line []byte) {
+	if c.format == model.BulkFormatNDJSON {
+		if c.rows == 0 {
+			c.buf.WriteByte('[')
+		} else {
+			c.buf.WriteByte(',')
+		}
+		c.buf.Write(line)
+	} else {
+		c.buf.Write(line)
+		c.buf.WriteByte('\n')
+	}
+	c.rows++
+}
+
+func (c *bulkChunk) size() int {
+	return c.buf.Len()
+}
+
+// bytes returns the chunk body. The result is only valid until the next reset.
+// bytes 返回分块内容，结果仅在下次reset之前有效。
+func (c *bulkChunk) bytes() []byte {
+	if c.format == model.BulkFormatNDJSON && c.rows > 0 {
+		c.buf.WriteByte(']')
+	}
+	return c.buf.Bytes()
+}
+
+func (c *bulkChunk) reset() {
+	c.buf.Reset()
+	c.rows = 0
+}
+
+// bulkChunkBytes returns the configured chunk size of bulk uploads, or the default.
+// bulkChunkBytes 返回配置的批量上传分块大小，未配置时返回默认值。
+func bulkChunkBytes(cfg config.BulkIngestConfig) int {
+	if cfg.ChunkBytes <= 0 {
+		return constants.IngestionDefaultBulkChunkBytes
+	}
+	return cfg.ChunkBytes
+}
+
+// decompress wraps body according to its compression ("", "identity", "gzip" or "zstd").
+// decompress 根据压缩方式（""、"identity"、"gzip" 或 "zstd"）包装请求体。
+func decompress(body io.Reader, compression string) (io.Reader, func(), error) {
+	switch strings.ToLower(strings.TrimSpace(compression)) {
+	case "", "identity":
+		return body, func() {}, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, errors.InvalidArgument, "invalid gzip body")
+		}
+		return zr, func() { _ = zr.Close() }, nil
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, errors.InvalidArgument, "invalid zstd body")
+		}
+		return zr, zr.Close, nil
+	default:
+		return nil, nil, errors.Newf(errors.InvalidArgument, "unsupported compression '%s'", compression)
+	}
+}
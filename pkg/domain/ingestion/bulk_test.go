@@ -0,0 +1,120 @@
+package ingestion
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// fakeStreamLoads is a StarRocks client that remembers the states of the labels it loaded. Loading under a label
+// in states fails with an AlreadyExistsError, like StarRocks does.
+type fakeStreamLoads struct {
+	starrocks.Client
+	states map[string]string
+	loaded []string
+}
+
+func (f *fakeStreamLoads) StreamLoad(_ context.Context, _, _ string, data io.Reader, opts *starrocks.StreamLoadOptions) (*starrocks.StreamLoadResponse, error) {
+	if _, ok := f.states[opts.Label]; ok {
+		resp := &starrocks.StreamLoadResponse{Label: opts.Label, Status: starrocks.StreamLoadStatusLabelAlreadyExists}
+		return resp, errors.Newf(errors.AlreadyExistsError, "StreamLoad label %s already exists", opts.Label)
+	}
+	_, _ = io.ReadAll(data)
+	f.states[opts.Label] = starrocks.LoadStateVisible
+	f.loaded = append(f.loaded, opts.Label)
+	return &starrocks.StreamLoadResponse{Label: opts.Label, Status: starrocks.StreamLoadStatusSuccess, NumberLoadedRows: 2}, nil
+}
+
+func (f *fakeStreamLoads) GetLoadState(_ context.Context, _, label string) (string, error) {
+	if state, ok := f.states[label]; ok {
+		return state, nil
+	}
+	return starrocks.LoadStateUnknown, nil
+}
+
+func TestIngestStreamUploadID(t *testing.T) {
+	const body = "{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n{\"a\":4}\n"
+	cfg := config.IngestionConfig{
+		Database:     "logs",
+		TableMapping: map[string]string{"events": "events_tbl"},
+		Bulk:         config.BulkIngestConfig{ChunkBytes: 14}, // two lines per chunk
+	}
+
+	tests := []struct {
+		name        string
+		uploadID    string
+		states      map[string]string
+		wantLoaded  []string
+		wantSkipped int64
+		wantErr     bool
+		wantCode    errors.ErrorCode
+	}{
+		{
+			name:       "first attempt",
+			uploadID:   "u-1",
+			wantLoaded: []string{"dataseap_bulk_events_tbl_u-1_0", "dataseap_bulk_events_tbl_u-1_1"},
+		},
+		{
+			name:        "retry skips committed chunks",
+			uploadID:    "u-1",
+			states:      map[string]string{"dataseap_bulk_events_tbl_u-1_0": starrocks.LoadStateVisible},
+			wantLoaded:  []string{"dataseap_bulk_events_tbl_u-1_1"},
+			wantSkipped: 2,
+		},
+		{
+			name:     "chunk still loading",
+			uploadID: "u-1",
+			states:   map[string]string{"dataseap_bulk_events_tbl_u-1_0": starrocks.LoadStatePrepare},
+			wantErr:  true,
+			wantCode: errors.AlreadyExistsError,
+		},
+		{name: "invalid upload id", uploadID: "a b", wantErr: true, wantCode: errors.InvalidArgument},
+		{name: "upload id too long", uploadID: strings.Repeat("x", 37), wantErr: true, wantCode: errors.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeStreamLoads{states: map[string]string{}}
+			for label, state := range tt.states {
+				client.states[label] = state
+			}
+			s := &serviceImpl{starrocksClient: client, cfg: cfg, loader: newBatchLoader(client, cfg)}
+			req := &model.BulkIngestRequest{DataType: "events", Format: model.BulkFormatNDJSON, UploadID: tt.uploadID}
+			summary, err := s.IngestStream(context.Background(), req, strings.NewReader(body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IngestStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, tt.wantCode) {
+					t.Errorf("IngestStream() error code = %s, want %s", errors.GetCode(err), tt.wantCode)
+				}
+				return
+			}
+			if !reflect.DeepEqual(client.loaded, tt.wantLoaded) {
+				t.Errorf("loaded labels = %v, want %v", client.loaded, tt.wantLoaded)
+			}
+			if summary.SkippedRows != tt.wantSkipped || summary.Status != model.BulkStatusSuccess {
+				t.Errorf("summary skipped %d rows with status %s, want %d and %s", summary.SkippedRows, summary.Status, tt.wantSkipped, model.BulkStatusSuccess)
+			}
+		})
+	}
+
+	// Without an upload ID every attempt gets labels of its own.
+	client := &fakeStreamLoads{states: map[string]string{}}
+	s := &serviceImpl{starrocksClient: client, cfg: cfg, loader: newBatchLoader(client, cfg)}
+	for attempt := 0; attempt < 2; attempt++ {
+		req := &model.BulkIngestRequest{DataType: "events", Format: model.BulkFormatNDJSON}
+		if _, err := s.IngestStream(context.Background(), req, strings.NewReader(body)); err != nil {
+			t.Fatalf("IngestStream() attempt %d error = %v", attempt, err)
+		}
+	}
+	if len(client.loaded) != 4 {
+		t.Errorf("loaded labels = %v, want 4 distinct labels", client.loaded)
+	}
+}
@@ -0,0 +1,206 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/deadletter"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// deadLetterWriter turns rejected events into dead letters, attaching StarRocks error log details.
+// A nil writer discards everything, so callers do not need to check whether dead-lettering is enabled.
+// deadLetterWriter 将被拒绝的事件转换为死信，并附带StarRocks错误日志详情。
+// nil写入器会丢弃所有内容，调用方无需检查是否启用了死信。
+type deadLetterWriter struct {
+	store  deadletter.Store
+	client starrocks.Client
+	cfg    config.DeadLetterConfig
+}
+
+// newDeadLetterWriter returns a writer for the store, or nil when no store is configured.
+// newDeadLetterWriter 返回存储对应的写入器，未配置存储时返回nil。
+func newDeadLetterWriter(store deadletter.Store, client starrocks.Client, cfg config.DeadLetterConfig) *deadLetterWriter {
+	if store == nil {
+		return nil
+	}
+	return &deadLetterWriter{store: store, client: client, cfg: cfg}
+}
+
+// failure describes why a group of events is being dead-lettered.
+// failure 描述一组事件被记录为死信的原因。
+type failure struct {
+	stage        model.DeadLetterStage
+	reason       string
+	table        string
+	label        string
+	response     *starrocks.StreamLoadResponse
+	errorDetails string // 已获取的错误日志，为空时按需获取 Error log already fetched, fetched when empty
+	partial      bool   // 批次已部分写入 The batch was partially loaded
+}
+
+// record stores one dead letter per event and reports whether they were stored. Errors are logged, never
+// returned, so dead-lettering cannot fail the ingestion request itself.
+// record 为每个事件存储一条死信，并报告是否存储成功。错误只记录日志而不返回，避免死信处理导致采集请求失败。
+func (w *deadLetterWriter) record(ctx context.Context, f failure, events ...*model.RawEvent) bool {
+	if w == nil || len(events) == 0 {
+		return false
+	}
+	l := logger.L().With("method", "recordDeadLetters", "stage", f.stage, "table", f.table)
+
+	var errorURL string
+	errorDetails := f.errorDetails
+	if f.response != nil && f.response.ErrorURL != "" {
+		errorURL = f.response.ErrorURL
+		if errorDetails == "" {
+			errorDetails = w.errorLog(ctx, errorURL)
+		}
+	}
+
+	now := time.Now().UTC()
+	letters := make([]*model.DeadLetter, 0, len(events))
+	for _, event := range events {
+		letters = append(letters, &model.DeadLetter{
+			ID:           uuid.NewString(),
+			Event:        event,
+			Stage:        f.stage,
+			Reason:       f.reason,
+			Table:        f.table,
+			Label:        f.label,
+			ErrorURL:     errorURL,
+			ErrorDetails: errorDetails,
+			Partial:      f.partial,
+			CreatedAt:    now,
+		})
+	}
+	if err := w.store.Put(ctx, letters); err != nil {
+		l.Errorw("Failed to store dead letters", "count", len(letters), "error", err)
+		return false
+	}
+	l.Infow("Events dead-lettered", "count", len(letters))
+	return true
+}
+
+// deadLetter records events as dead letters, keeping them in track when they could not be stored.
+// deadLetter 将事件记录为死信，无法存储时将其保留在track中。
+func (s *serviceImpl) deadLetter(ctx context.Context, track *settlement, f failure, events ...*model.RawEvent) {
+	if !s.deadLetters.record(ctx, f, events...) {
+		track.keep(events...)
+	}
+}
+
+// settlement tracks the events of a dead-letter replay that were not settled, so that their dead letters are kept.
+// Events derived from the payload of a replayed event are tracked as that event.
+// settlement 跟踪死信重放中未落定的事件，以便保留其死信。由重放事件负载派生出的事件按该事件跟踪。
+type settlement struct {
+	origins   map[*model.RawEvent]*model.RawEvent
+	unsettled map[*model.RawEvent]bool
+}
+
+func newSettlement() *settlement {
+	return &settlement{
+		origins:   make(map[*model.RawEvent]*model.RawEvent),
+		unsettled: make(map[*model.RawEvent]bool),
+	}
+}
+
+// derive records that event was derived from the payload of origin.
+// derive 记录event派生自origin的负载。
+func (t *settlement) derive(origin, event *model.RawEvent) {
+	if t == nil {
+		return
+	}
+	if o, ok := t.origins[origin]; ok {
+		origin = o
+	}
+	t.origins[event] = origin
+}
+
+// keep marks events as not settled.
+// keep 将事件标记为未落定。
+func (t *settlement) keep(events ...*model.RawEvent) {
+	if t == nil {
+		return
+	}
+	for _, event := range events {
+		if origin, ok := t.origins[event]; ok {
+			event = origin
+		}
+		t.unsettled[event] = true
+	}
+}
+
+// errorLog fetches the StarRocks error log at errorURL, when enabled. Errors are logged and yield an empty log.
+// errorLog 在启用时获取errorURL处的StarRocks错误日志。错误只记录日志并返回空日志。
+func (w *deadLetterWriter) errorLog(ctx context.Context, errorURL string) string {
+	if !w.cfg.FetchErrorLog {
+		return ""
+	}
+	maxBytes := w.cfg.MaxErrorLogBytes
+	if maxBytes <= 0 {
+		maxBytes = constants.DeadLetterDefaultMaxErrorLogBytes
+	}
+	details, err := w.client.FetchLoadErrorLog(ctx, errorURL, maxBytes)
+	if err != nil {
+		logger.L().Warnw("Failed to fetch StarRocks error log for dead letters", "error_url", errorURL, "error", err)
+	}
+	return details
+}
+
+// filteredFailure describes a batch in which StarRocks filtered rows, and returns the events to dead-letter.
+// Stream Load does not say which rows were dropped, so they are looked up by event ID in the error log of the
+// load. When the log identifies all of them, only those are returned; otherwise the whole batch is, marked as
+// partially loaded, since its other events are already stored.
+// filteredFailure 描述StarRocks过滤了部分行的批次，并返回需记录为死信的事件。Stream Load不会指明被丢弃的行，
+// 因此按事件ID在导入的错误日志中查找。日志能确认所有被过滤的行时只返回这些事件；否则返回整个批次并标记为部分写入，
+// 因为其余事件已被存储。
+func (w *deadLetterWriter) filteredFailure(ctx context.Context, table string, result *loadResult, events []*model.RawEvent) (failure, []*model.RawEvent) {
+	f := failure{
+		stage:    model.DeadLetterStageFiltered,
+		reason:   fmt.Sprintf("%d of %d rows filtered by StarRocks", result.Failed, result.Failed+result.Loaded),
+		table:    table,
+		label:    result.Label,
+		response: result.Response,
+	}
+	if w != nil && result.Response != nil && result.Response.ErrorURL != "" {
+		f.errorDetails = w.errorLog(ctx, result.Response.ErrorURL)
+		if filtered := filteredEvents(f.errorDetails, events, result.Failed); filtered != nil {
+			return f, filtered
+		}
+	}
+	f.partial = true
+	return f, events
+}
+
+// filteredEvents returns the events whose ID appears in the error log, or nil unless exactly count of them do.
+// filteredEvents 返回ID出现在错误日志中的事件，除非恰好有count个，否则返回nil。
+func filteredEvents(errorLog string, events []*model.RawEvent, count int) []*model.RawEvent {
+	if errorLog == "" || count <= 0 {
+		return nil
+	}
+	var filtered []*model.RawEvent
+	for _, event := range events {
+		if event.ID == "" {
+			return nil
+		}
+		quoted, err := json.Marshal(event.ID)
+		if err != nil {
+			return nil
+		}
+		if strings.Contains(errorLog, string(quoted)) {
+			filtered = append(filtered, event)
+		}
+	}
+	if len(filtered) != count {
+		return nil
+	}
+	return filtered
+}
@@ -0,0 +1,218 @@
+package ingestion
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/deadletter"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// fakeDeadLetterStore records the dead letters it is given, or fails with err.
+type fakeDeadLetterStore struct {
+	deadletter.Store
+	letters []*model.DeadLetter
+	err     error
+}
+
+func (f *fakeDeadLetterStore) Put(_ context.Context, letters []*model.DeadLetter) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.letters = append(f.letters, letters...)
+	return nil
+}
+
+// fakeErrorLogClient serves errorLog as the StarRocks error log of every load.
+type fakeErrorLogClient struct {
+	starrocks.Client
+	errorLog string
+	fetched  []string
+}
+
+func (f *fakeErrorLogClient) FetchLoadErrorLog(_ context.Context, errorURL string, _ int) (string, error) {
+	f.fetched = append(f.fetched, errorURL)
+	return f.errorLog, nil
+}
+
+func TestDeadLetterWriterRecord(t *testing.T) {
+	events := []*model.RawEvent{{ID: "e1"}, {ID: "e2"}}
+	response := &starrocks.StreamLoadResponse{ErrorURL: "http://be:8040/api/_load_error_log?file=x"}
+
+	tests := []struct {
+		name        string
+		failure     failure
+		storeErr    error
+		fetch       bool
+		want        bool
+		wantDetails string
+		wantFetched int
+	}{
+		{
+			name:        "error log is fetched",
+			failure:     failure{stage: model.DeadLetterStageLoad, reason: "load failed", table: "events", label: "l1", response: response, partial: true},
+			fetch:       true,
+			want:        true,
+			wantDetails: "Error: bad row",
+			wantFetched: 1,
+		},
+		{
+			name:        "fetched error log is reused",
+			failure:     failure{stage: model.DeadLetterStageFiltered, table: "events", response: response, errorDetails: "known"},
+			fetch:       true,
+			want:        true,
+			wantDetails: "known",
+		},
+		{
+			name:    "error log fetching disabled",
+			failure: failure{stage: model.DeadLetterStageLoad, table: "events", response: response},
+			want:    true,
+		},
+		{
+			name:     "store failure",
+			failure:  failure{stage: model.DeadLetterStageLoad, table: "events"},
+			storeErr: errors.New(errors.DatabaseError, "disk full"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeDeadLetterStore{err: tt.storeErr}
+			client := &fakeErrorLogClient{errorLog: "Error: bad row"}
+			w := newDeadLetterWriter(store, client, config.DeadLetterConfig{FetchErrorLog: tt.fetch})
+
+			if got := w.record(context.Background(), tt.failure, events...); got != tt.want {
+				t.Fatalf("record() = %v, want %v", got, tt.want)
+			}
+			if len(client.fetched) != tt.wantFetched {
+				t.Errorf("fetched error log %d times, want %d", len(client.fetched), tt.wantFetched)
+			}
+			if !tt.want {
+				return
+			}
+			if len(store.letters) != len(events) {
+				t.Fatalf("stored %d dead letters, want %d", len(store.letters), len(events))
+			}
+			for i, dl := range store.letters {
+				if dl.ID == "" || dl.CreatedAt.IsZero() {
+					t.Errorf("dead letter %d has no ID or creation time", i)
+				}
+				if dl.Event != events[i] || dl.Stage != tt.failure.stage || dl.Reason != tt.failure.reason ||
+					dl.Table != tt.failure.table || dl.Label != tt.failure.label || dl.Partial != tt.failure.partial {
+					t.Errorf("dead letter %d = %+v, does not describe failure %+v", i, dl, tt.failure)
+				}
+				if dl.ErrorURL != response.ErrorURL || dl.ErrorDetails != tt.wantDetails {
+					t.Errorf("dead letter %d error = %q, %q, want %q, %q", i, dl.ErrorURL, dl.ErrorDetails, response.ErrorURL, tt.wantDetails)
+				}
+			}
+		})
+	}
+
+	var nilWriter *deadLetterWriter
+	if nilWriter.record(context.Background(), failure{}, events...) {
+		t.Error("record() on a nil writer = true, want false")
+	}
+}
+
+func TestDeadLetterWriterFilteredFailure(t *testing.T) {
+	events := []*model.RawEvent{{ID: "e1"}, {ID: "e2"}, {ID: "e3"}}
+	response := &starrocks.StreamLoadResponse{ErrorURL: "http://be:8040/api/_load_error_log?file=x"}
+
+	tests := []struct {
+		name        string
+		errorLog    string
+		result      *loadResult
+		events      []*model.RawEvent
+		want        []string
+		wantPartial bool
+	}{
+		{
+			name:     "error log names the filtered rows",
+			errorLog: `Error: Value too long. Row: {"event_id":"e2","msg":"..."}`,
+			result:   &loadResult{Loaded: 2, Failed: 1, Response: response},
+			want:     []string{"e2"},
+		},
+		{
+			name:        "error log names fewer rows than were filtered",
+			errorLog:    `Error: Value too long. Row: {"event_id":"e2"}`,
+			result:      &loadResult{Loaded: 1, Failed: 2, Response: response},
+			want:        []string{"e1", "e2", "e3"},
+			wantPartial: true,
+		},
+		{
+			name:        "event ID only as a prefix of another",
+			errorLog:    `Error: Value too long. Row: {"event_id":"e22"}`,
+			result:      &loadResult{Loaded: 2, Failed: 1, Response: response},
+			want:        []string{"e1", "e2", "e3"},
+			wantPartial: true,
+		},
+		{
+			name:        "event without an ID",
+			errorLog:    `Error: Value too long. Row: {"event_id":"e2"}`,
+			result:      &loadResult{Loaded: 2, Failed: 1, Response: response},
+			events:      []*model.RawEvent{{ID: "e2"}, {}},
+			want:        []string{"e2", ""},
+			wantPartial: true,
+		},
+		{
+			name:        "no error log",
+			result:      &loadResult{Loaded: 2, Failed: 1},
+			want:        []string{"e1", "e2", "e3"},
+			wantPartial: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeErrorLogClient{errorLog: tt.errorLog}
+			w := newDeadLetterWriter(&fakeDeadLetterStore{}, client, config.DeadLetterConfig{FetchErrorLog: true})
+			batch := tt.events
+			if batch == nil {
+				batch = events
+			}
+
+			f, filtered := w.filteredFailure(context.Background(), "events", tt.result, batch)
+			var got []string
+			for _, event := range filtered {
+				got = append(got, event.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filteredFailure() events = %q, want %q", got, tt.want)
+			}
+			if f.partial != tt.wantPartial {
+				t.Errorf("filteredFailure() partial = %v, want %v", f.partial, tt.wantPartial)
+			}
+			if f.stage != model.DeadLetterStageFiltered || f.table != "events" {
+				t.Errorf("filteredFailure() = %+v, want a filtered failure of table events", f)
+			}
+			if tt.errorLog != "" && f.errorDetails != tt.errorLog {
+				t.Errorf("filteredFailure() error details = %q, want %q", f.errorDetails, tt.errorLog)
+			}
+		})
+	}
+}
+
+func TestSettlement(t *testing.T) {
+	replayed := &model.RawEvent{ID: "r"}
+	other := &model.RawEvent{ID: "o"}
+	derived := &model.RawEvent{ID: "d"}
+	nested := &model.RawEvent{ID: "n"}
+
+	track := newSettlement()
+	track.derive(replayed, derived)
+	track.derive(derived, nested)
+	track.keep(nested)
+
+	if !track.unsettled[replayed] {
+		t.Error("event derived from a replayed event did not keep the replayed event")
+	}
+	if track.unsettled[other] || track.unsettled[derived] || track.unsettled[nested] {
+		t.Errorf("unsettled = %v, want only the replayed event", track.unsettled)
+	}
+
+	var nilTrack *settlement
+	nilTrack.derive(replayed, derived)
+	nilTrack.keep(derived)
+}
@@ -0,0 +1,161 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+const fileExt = ".json"
+
+// fileStore keeps one JSON file per dead letter in a local directory.
+// fileStore 在本地目录中为每个死信保存一个JSON文件。
+type fileStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileStore creates a file-backed dead-letter store rooted at dir, creating the directory if needed.
+// NewFileStore 创建以dir为根目录的文件死信存储，必要时创建目录。
+func NewFileStore(dir string) (Store, error) {
+	if dir == "" {
+		return nil, errors.New(errors.ConfigError, "dead-letter directory is not configured")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, errors.ConfigError, "failed to create dead-letter directory %s", dir)
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+// Put writes each dead letter to its own file. Files are written to a temporary name and renamed,
+// so readers never observe a partially written record.
+// Put 将每个死信写入单独的文件。先写入临时文件再重命名，读取方不会看到写了一半的记录。
+func (s *fileStore) Put(ctx context.Context, letters []*model.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, dl := range letters {
+		if !validID(dl.ID) {
+			return errors.Newf(errors.InvalidArgument, "invalid dead-letter ID '%s'", dl.ID)
+		}
+		data, err := json.Marshal(dl)
+		if err != nil {
+			return errors.Wrap(err, errors.SerializationError, "failed to serialize dead letter")
+		}
+		tmp := filepath.Join(s.dir, "."+dl.ID+".tmp")
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return errors.Wrapf(err, errors.InternalError, "failed to write dead letter %s", dl.ID)
+		}
+		if err := os.Rename(tmp, s.path(dl.ID)); err != nil {
+			_ = os.Remove(tmp)
+			return errors.Wrapf(err, errors.InternalError, "failed to write dead letter %s", dl.ID)
+		}
+	}
+	return nil
+}
+
+// List reads every record in the directory, filters and paginates them.
+// List 读取目录中的所有记录并进行筛选与分页。
+func (s *fileStore) List(ctx context.Context, filter *model.DeadLetterFilter, pagination *commontypes.PaginationRequest) ([]*model.DeadLetter, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.InternalError, "failed to read dead-letter directory")
+	}
+	var matched []*model.DeadLetter
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, fileExt) {
+			continue
+		}
+		dl, err := s.read(strings.TrimSuffix(name, fileExt))
+		if err != nil {
+			continue // Skip unreadable records rather than failing the whole listing.
+		}
+		if filter.Matches(dl) {
+			matched = append(matched, dl)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+
+	total := int64(len(matched))
+	if pagination == nil {
+		return matched, total, nil
+	}
+	offset, limit := pagination.GetOffset(), pagination.GetLimit()
+	if offset >= len(matched) {
+		return []*model.DeadLetter{}, total, nil
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[offset:end], total, nil
+}
+
+// Get reads a single record.
+// Get 读取单条记录。
+func (s *fileStore) Get(ctx context.Context, id string) (*model.DeadLetter, error) {
+	if !validID(id) {
+		return nil, errors.Newf(errors.InvalidArgument, "invalid dead-letter ID '%s'", id)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read(id)
+}
+
+// Delete removes the files of the given records.
+// Delete 删除给定记录的文件。
+func (s *fileStore) Delete(ctx context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		if !validID(id) {
+			continue
+		}
+		if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, errors.InternalError, "failed to delete dead letter %s", id)
+		}
+	}
+	return nil
+}
+
+// Close is a no-op for the file store.
+// Close 对文件存储无操作。
+func (s *fileStore) Close() error {
+	return nil
+}
+
+func (s *fileStore) path(id string) string {
+	return filepath.Join(s.dir, id+fileExt)
+}
+
+func (s *fileStore) read(id string) (*model.DeadLetter, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Newf(errors.NotFoundError, "dead letter with ID '%s' not found", id)
+		}
+		return nil, errors.Wrapf(err, errors.InternalError, "failed to read dead letter %s", id)
+	}
+	var dl model.DeadLetter
+	if err := json.Unmarshal(data, &dl); err != nil {
+		return nil, errors.Wrapf(err, errors.DeserializationError, "failed to decode dead letter %s", id)
+	}
+	return &dl, nil
+}
+
+// validID guards against IDs that would escape the store directory.
+// validID 防止ID逃逸出存储目录。
+func validID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\`) && !strings.HasPrefix(id, ".")
+}
@@ -0,0 +1,62 @@
+package deadletter
+
+import (
+	"context"
+
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// Store persists dead-lettered events.
+// Store 持久化死信事件。
+type Store interface {
+	// Put stores one or more dead letters.
+	// Put 存储一个或多个死信。
+	Put(ctx context.Context, letters []*model.DeadLetter) error
+
+	// List returns dead letters matching the filter, newest first.
+	// List 返回满足筛选条件的死信，按时间倒序。
+	List(ctx context.Context, filter *model.DeadLetterFilter, pagination *commontypes.PaginationRequest) (letters []*model.DeadLetter, total int64, err error)
+
+	// Get returns a single dead letter by ID.
+	// Get 通过ID返回单个死信。
+	Get(ctx context.Context, id string) (*model.DeadLetter, error)
+
+	// Delete removes dead letters by ID. Unknown IDs are ignored.
+	// Delete 通过ID删除死信，未知ID将被忽略。
+	Delete(ctx context.Context, ids ...string) error
+
+	// Close releases resources held by the store.
+	// Close 释放存储持有的资源。
+	Close() error
+}
+
+// Replayer re-ingests events. It is satisfied by ingestion.Service.
+// Replayer 重新采集事件，ingestion.Service 满足此接口。
+type Replayer interface {
+	ReplayEvents(ctx context.Context, events []*model.RawEvent, result *model.ReplayResult) (unsettled []bool, err error)
+}
+
+// Service defines the interface for inspecting and replaying dead-lettered events.
+// Service 定义了查看和重放死信事件的接口。
+type Service interface {
+	// ListDeadLetters lists dead letters matching the filter, with pagination.
+	// ListDeadLetters 分页列出满足筛选条件的死信。
+	ListDeadLetters(ctx context.Context, filter *model.DeadLetterFilter, pagination *commontypes.PaginationRequest) (letters []*model.DeadLetter, total int64, err error)
+
+	// GetDeadLetter retrieves a dead letter by ID.
+	// GetDeadLetter 通过ID检索死信。
+	GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error)
+
+	// ReplayDeadLetters sends the events of the given dead letters back through the ingestion pipeline and
+	// removes the records whose events were ingested, dropped as duplicates or dead-lettered anew by the
+	// ingestion service. The other records are kept and listed in the result, as are the skipped records of
+	// partially loaded batches.
+	// ReplayDeadLetters 将给定死信中的事件重新送入采集流程，并删除其事件已采集成功、作为重复事件丢弃
+	// 或被采集服务重新记录为死信的记录。其余记录被保留并在结果中列出，跳过的部分写入批次的记录也是如此。
+	ReplayDeadLetters(ctx context.Context, ids []string) (*model.ReplayResult, error)
+
+	// DeleteDeadLetters discards dead letters without replaying them.
+	// DeleteDeadLetters 丢弃死信而不重放。
+	DeleteDeadLetters(ctx context.Context, ids []string) error
+}
@@ -0,0 +1,84 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/turtacn/dataseap/pkg/adapter/pulsar"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// pulsarStore publishes dead letters to a Pulsar DLQ topic.
+// A topic is an append-only log, so browsing, replaying and deleting individual records are left to
+// DLQ consumers; use the file store when those management operations are needed.
+// pulsarStore 将死信发布到Pulsar DLQ主题。
+// 主题是只追加的日志，因此浏览、重放和删除单条记录由DLQ消费者负责；需要这些管理操作时请使用文件存储。
+type pulsarStore struct {
+	producer pulsar.Producer
+}
+
+// NewPulsarStore creates a dead-letter store that publishes to the given topic.
+// NewPulsarStore 创建一个发布到给定主题的死信存储。
+func NewPulsarStore(client pulsar.Client, topic string) (Store, error) {
+	if client == nil {
+		return nil, errors.New(errors.ConfigError, "pulsar dead-letter store requires a Pulsar client")
+	}
+	if topic == "" {
+		return nil, errors.New(errors.ConfigError, "dead-letter topic is not configured")
+	}
+	producer, err := client.CreateProducer(topic, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &pulsarStore{producer: producer}, nil
+}
+
+// Put publishes each dead letter, keyed by its ID.
+// Put 以死信ID为键发布每个死信。
+func (s *pulsarStore) Put(ctx context.Context, letters []*model.DeadLetter) error {
+	for _, dl := range letters {
+		payload, err := json.Marshal(dl)
+		if err != nil {
+			return errors.Wrap(err, errors.SerializationError, "failed to serialize dead letter")
+		}
+		msg := &pulsar.ProducerMessage{
+			Payload:    payload,
+			Key:        dl.ID,
+			Properties: map[string]string{"stage": string(dl.Stage)},
+			EventTime:  dl.CreatedAt,
+		}
+		if _, err := s.producer.Send(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List is not supported by the Pulsar store.
+// List 不受Pulsar存储支持。
+func (s *pulsarStore) List(ctx context.Context, filter *model.DeadLetterFilter, pagination *commontypes.PaginationRequest) ([]*model.DeadLetter, int64, error) {
+	return nil, 0, errNotBrowsable
+}
+
+// Get is not supported by the Pulsar store.
+// Get 不受Pulsar存储支持。
+func (s *pulsarStore) Get(ctx context.Context, id string) (*model.DeadLetter, error) {
+	return nil, errNotBrowsable
+}
+
+// Delete is not supported by the Pulsar store.
+// Delete 不受Pulsar存储支持。
+func (s *pulsarStore) Delete(ctx context.Context, ids ...string) error {
+	return errNotBrowsable
+}
+
+// Close closes the DLQ producer.
+// Close 关闭DLQ生产者。
+func (s *pulsarStore) Close() error {
+	s.producer.Close()
+	return nil
+}
+
+var errNotBrowsable = errors.New(errors.InvalidArgument, "the pulsar dead-letter store is write-only; consume the DLQ topic or use the file backend to browse and replay")
@@ -0,0 +1,158 @@
+package deadletter
+
+import (
+	"context"
+
+	"github.com/turtacn/dataseap/pkg/adapter/pulsar"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// NewStore creates the dead-letter store selected by cfg.Backend.
+// pulsarClient is only required for the "pulsar" backend.
+// NewStore 根据cfg.Backend创建死信存储，仅"pulsar"后端需要pulsarClient。
+func NewStore(cfg config.DeadLetterConfig, pulsarClient pulsar.Client) (Store, error) {
+	switch cfg.Backend {
+	case "", constants.DeadLetterBackendFile:
+		dir := cfg.Directory
+		if dir == "" {
+			dir = constants.DeadLetterDefaultDirectory
+		}
+		return NewFileStore(dir)
+	case constants.DeadLetterBackendPulsar:
+		topic := cfg.Topic
+		if topic == "" {
+			topic = constants.DeadLetterDefaultTopic
+		}
+		return NewPulsarStore(pulsarClient, topic)
+	default:
+		return nil, errors.Newf(errors.ConfigError, "unknown dead-letter backend '%s'", cfg.Backend)
+	}
+}
+
+type serviceImpl struct {
+	store    Store
+	replayer Replayer
+}
+
+// NewService creates a new instance of the dead-letter service.
+// NewService 创建一个新的死信服务实例。
+func NewService(store Store, replayer Replayer) Service {
+	return &serviceImpl{
+		store:    store,
+		replayer: replayer,
+	}
+}
+
+// ListDeadLetters lists dead letters matching the filter, with pagination.
+// ListDeadLetters 分页列出满足筛选条件的死信。
+func (s *serviceImpl) ListDeadLetters(ctx context.Context, filter *model.DeadLetterFilter, pagination *commontypes.PaginationRequest) ([]*model.DeadLetter, int64, error) {
+	l := logger.L().With("method", "ListDeadLetters")
+	l.Info("Attempting to list dead letters")
+
+	letters, total, err := s.store.List(ctx, filter, pagination)
+	if err != nil {
+		l.Errorw("Failed to list dead letters", "error", err)
+		return nil, 0, err
+	}
+	return letters, total, nil
+}
+
+// GetDeadLetter retrieves a dead letter by ID.
+// GetDeadLetter 通过ID检索死信。
+func (s *serviceImpl) GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error) {
+	l := logger.L().With("method", "GetDeadLetter", "id", id)
+	l.Info("Attempting to get dead letter")
+
+	if id == "" {
+		return nil, errors.New(errors.InvalidArgument, "dead-letter ID cannot be empty")
+	}
+	return s.store.Get(ctx, id)
+}
+
+// ReplayDeadLetters sends the events of the given dead letters back through the ingestion pipeline, removing
+// the records whose events were settled.
+// ReplayDeadLetters 将给定死信中的事件重新送入采集流程，并删除其事件已落定的记录。
+func (s *serviceImpl) ReplayDeadLetters(ctx context.Context, ids []string) (*model.ReplayResult, error) {
+	l := logger.L().With("method", "ReplayDeadLetters", "count", len(ids))
+	l.Info("Attempting to replay dead letters")
+
+	if len(ids) == 0 {
+		return nil, errors.New(errors.InvalidArgument, "at least one dead-letter ID is required")
+	}
+
+	result := &model.ReplayResult{Requested: len(ids)}
+	var (
+		events   []*model.RawEvent
+		replayed []string
+	)
+	for _, id := range ids {
+		dl, err := s.store.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, errors.NotFoundError) {
+				result.NotFound = append(result.NotFound, id)
+				continue
+			}
+			return nil, err
+		}
+		if dl.Event == nil {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+		if dl.Partial {
+			// The event may already be stored with the rest of its batch; the error details tell whether it was.
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+		events = append(events, dl.Event)
+		replayed = append(replayed, id)
+	}
+	if len(events) == 0 {
+		return result, nil
+	}
+
+	// Only the originals of settled events are removed: events that fail again are recorded as new dead letters
+	// by the ingestion service, while the originals of events it could neither load nor record are kept.
+	unsettled, ingestErr := s.replayer.ReplayEvents(ctx, events, result)
+	var settled []string
+	for i, id := range replayed {
+		if unsettled[i] {
+			result.Kept = append(result.Kept, id)
+			continue
+		}
+		settled = append(settled, id)
+	}
+	if len(settled) == 0 && ingestErr != nil {
+		l.Warnw("Replay failed, keeping all dead letters", "error", ingestErr)
+		return nil, ingestErr
+	}
+	if len(settled) == 0 {
+		l.Warnw("No replayed event was settled, keeping all dead letters", "kept", len(result.Kept))
+		return result, nil
+	}
+	if err := s.store.Delete(ctx, settled...); err != nil {
+		l.Errorw("Failed to remove replayed dead letters", "error", err)
+		return result, err
+	}
+	if ingestErr != nil {
+		l.Warnw("Some replayed events failed again", "ingested", result.Ingested, "persist_failed", result.PersistFailed, "validation_failed", result.ValidationFailed, "kept", len(result.Kept), "error", ingestErr)
+	}
+	l.Infow("Dead letters replayed", "ingested", result.Ingested, "kept", len(result.Kept), "not_found", len(result.NotFound))
+	return result, nil
+}
+
+// DeleteDeadLetters discards dead letters without replaying them.
+// DeleteDeadLetters 丢弃死信而不重放。
+func (s *serviceImpl) DeleteDeadLetters(ctx context.Context, ids []string) error {
+	l := logger.L().With("method", "DeleteDeadLetters", "count", len(ids))
+	l.Info("Attempting to delete dead letters")
+
+	if len(ids) == 0 {
+		return errors.New(errors.InvalidArgument, "at least one dead-letter ID is required")
+	}
+	return s.store.Delete(ctx, ids...)
+}
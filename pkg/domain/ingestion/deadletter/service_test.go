@@ -0,0 +1,146 @@
+package deadletter
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// fakeStore keeps dead letters in memory.
+type fakeStore struct {
+	Store
+	letters map[string]*model.DeadLetter
+}
+
+func (f *fakeStore) Get(_ context.Context, id string) (*model.DeadLetter, error) {
+	if dl, ok := f.letters[id]; ok {
+		return dl, nil
+	}
+	return nil, errors.Newf(errors.NotFoundError, "dead letter %s not found", id)
+}
+
+func (f *fakeStore) Delete(_ context.Context, ids ...string) error {
+	for _, id := range ids {
+		delete(f.letters, id)
+	}
+	return nil
+}
+
+func (f *fakeStore) List(_ context.Context, _ *model.DeadLetterFilter, _ *commontypes.PaginationRequest) ([]*model.DeadLetter, int64, error) {
+	return nil, 0, nil
+}
+
+// fakeReplayer leaves the events whose ID is in unsettled unsettled and fails with err.
+type fakeReplayer struct {
+	unsettled map[string]bool
+	err       error
+	replayed  []string
+}
+
+func (f *fakeReplayer) ReplayEvents(_ context.Context, events []*model.RawEvent, result *model.ReplayResult) ([]bool, error) {
+	unsettled := make([]bool, len(events))
+	for i, event := range events {
+		f.replayed = append(f.replayed, event.ID)
+		unsettled[i] = f.unsettled[event.ID]
+		if !unsettled[i] {
+			result.Ingested++
+		}
+	}
+	return unsettled, f.err
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	tests := []struct {
+		name         string
+		ids          []string
+		unsettled    map[string]bool
+		replayErr    error
+		wantReplayed []string
+		wantKept     []string // 重放后仍在存储中的记录 Records still stored after the replay
+		wantResult   *model.ReplayResult
+		wantCode     errors.ErrorCode
+	}{
+		{
+			name:         "settled records are removed",
+			ids:          []string{"a", "b"},
+			wantReplayed: []string{"ea", "eb"},
+			wantKept:     []string{"partial", "nil-event"},
+			wantResult:   &model.ReplayResult{Requested: 2, Ingested: 2},
+		},
+		{
+			name:         "unsettled records are kept",
+			ids:          []string{"a", "b"},
+			unsettled:    map[string]bool{"eb": true},
+			replayErr:    errors.New(errors.DatabaseError, "load failed"),
+			wantReplayed: []string{"ea", "eb"},
+			wantKept:     []string{"b", "partial", "nil-event"},
+			wantResult:   &model.ReplayResult{Requested: 2, Ingested: 1, Kept: []string{"b"}},
+		},
+		{
+			name:         "nothing settled keeps all records",
+			ids:          []string{"a", "b"},
+			unsettled:    map[string]bool{"ea": true, "eb": true},
+			wantReplayed: []string{"ea", "eb"},
+			wantKept:     []string{"a", "b", "partial", "nil-event"},
+			wantResult:   &model.ReplayResult{Requested: 2, Kept: []string{"a", "b"}},
+		},
+		{
+			name:         "failed replay keeps all records",
+			ids:          []string{"a"},
+			unsettled:    map[string]bool{"ea": true},
+			replayErr:    errors.New(errors.DatabaseError, "load failed"),
+			wantReplayed: []string{"ea"},
+			wantKept:     []string{"a", "b", "partial", "nil-event"},
+			wantCode:     errors.DatabaseError,
+		},
+		{
+			name:       "partially loaded and missing records are not replayed",
+			ids:        []string{"partial", "nil-event", "gone"},
+			wantKept:   []string{"a", "b", "partial", "nil-event"},
+			wantResult: &model.ReplayResult{Requested: 3, NotFound: []string{"nil-event", "gone"}, Skipped: []string{"partial"}},
+		},
+		{name: "no ids", wantKept: []string{"a", "b", "partial", "nil-event"}, wantCode: errors.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{letters: map[string]*model.DeadLetter{
+				"a":         {ID: "a", Event: &model.RawEvent{ID: "ea"}},
+				"b":         {ID: "b", Event: &model.RawEvent{ID: "eb"}},
+				"partial":   {ID: "partial", Event: &model.RawEvent{ID: "ep"}, Partial: true},
+				"nil-event": {ID: "nil-event"},
+			}}
+			replayer := &fakeReplayer{unsettled: tt.unsettled, err: tt.replayErr}
+			s := NewService(store, replayer)
+
+			result, err := s.ReplayDeadLetters(context.Background(), tt.ids)
+			if tt.wantCode != "" {
+				if !errors.Is(err, tt.wantCode) {
+					t.Fatalf("ReplayDeadLetters() error = %v, want %s", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Fatalf("ReplayDeadLetters() error = %v", err)
+			}
+			if tt.wantResult != nil && !reflect.DeepEqual(result, tt.wantResult) {
+				t.Errorf("ReplayDeadLetters() = %+v, want %+v", result, tt.wantResult)
+			}
+			if !reflect.DeepEqual(replayer.replayed, tt.wantReplayed) {
+				t.Errorf("replayed events %v, want %v", replayer.replayed, tt.wantReplayed)
+			}
+			var kept []string
+			for id := range store.letters {
+				kept = append(kept, id)
+			}
+			sort.Strings(kept)
+			wantKept := append([]string(nil), tt.wantKept...)
+			sort.Strings(wantKept)
+			if !reflect.DeepEqual(kept, wantKept) {
+				t.Errorf("stored records after replay = %v, want %v", kept, wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package dedup
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/config"
+)
+
+// Cache remembers the keys of recently ingested events for a time window. It is bounded both by the window
+// and by a maximum number of keys; beyond that the oldest keys are evicted first.
+// Cache 在一个时间窗口内记住最近采集的事件的键。它同时受时间窗口与最大键数量的限制，超出时最先淘汰最旧的键。
+type Cache struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // 按记录时间排序的*entry，最旧的在前 *entry ordered by time recorded, oldest first
+	now        func() time.Time
+}
+
+type entry struct {
+	key    string
+	seenAt time.Time
+}
+
+// New creates a deduplication cache from the configuration.
+// New 根据配置创建去重缓存。
+func New(cfg config.DedupConfig) *Cache {
+	c := &Cache{
+		window:     time.Duration(cfg.WindowSeconds) * time.Second,
+		maxEntries: cfg.MaxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+	if c.window <= 0 {
+		c.window = constants.IngestionDefaultDedupWindowSeconds * time.Second
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = constants.IngestionDefaultDedupMaxEntries
+	}
+	return c
+}
+
+// Claim records key and returns true, or returns false if key was already recorded within the window.
+// Claim 记录key并返回true；如果key在时间窗口内已被记录则返回false。
+func (c *Cache) Claim(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.expire(now)
+	if _, ok := c.entries[key]; ok {
+		return false
+	}
+	c.entries[key] = c.order.PushBack(&entry{key: key, seenAt: now})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Front())
+	}
+	return true
+}
+
+// Forget removes keys, so that events which failed to persist are accepted when they are resent.
+// Forget 移除若干键，使持久化失败的事件在重新发送时能够被接收。
+func (c *Cache) Forget(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// Len returns the number of keys currently cached.
+// Len 返回当前缓存的键数量。
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// expire drops the keys recorded before the window. Keys are appended in time order, so it stops at the first live one.
+// expire 丢弃在时间窗口之前记录的键。键按时间顺序追加，因此遇到第一个未过期的键即停止。
+func (c *Cache) expire(now time.Time) {
+	cutoff := now.Add(-c.window)
+	for elem := c.order.Front(); elem != nil; elem = c.order.Front() {
+		if elem.Value.(*entry).seenAt.After(cutoff) {
+			return
+		}
+		c.remove(elem)
+	}
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*entry).key)
+	c.order.Remove(elem)
+}
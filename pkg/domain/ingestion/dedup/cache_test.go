@@ -0,0 +1,130 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+func TestCache(t *testing.T) {
+	type op struct {
+		advance time.Duration
+		forget  []string
+		claim   string
+		want    bool
+	}
+	tests := []struct {
+		name string
+		cfg  config.DedupConfig
+		ops  []op
+	}{
+		{
+			name: "duplicate within the window",
+			cfg:  config.DedupConfig{WindowSeconds: 60, MaxEntries: 10},
+			ops:  []op{{claim: "a", want: true}, {advance: 59 * time.Second, claim: "a", want: false}},
+		},
+		{
+			name: "expired after the window",
+			cfg:  config.DedupConfig{WindowSeconds: 60, MaxEntries: 10},
+			ops:  []op{{claim: "a", want: true}, {advance: 60 * time.Second, claim: "a", want: true}},
+		},
+		{
+			name: "oldest key evicted beyond max entries",
+			cfg:  config.DedupConfig{WindowSeconds: 60, MaxEntries: 2},
+			ops: []op{
+				{claim: "a", want: true},
+				{claim: "b", want: true},
+				{claim: "c", want: true},
+				{claim: "b", want: false},
+				{claim: "a", want: true},
+			},
+		},
+		{
+			name: "forgotten key is claimed again",
+			cfg:  config.DedupConfig{WindowSeconds: 60, MaxEntries: 10},
+			ops:  []op{{claim: "a", want: true}, {forget: []string{"a", "unknown"}, claim: "a", want: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			c := New(tt.cfg)
+			c.now = func() time.Time { return now }
+			for i, o := range tt.ops {
+				now = now.Add(o.advance)
+				c.Forget(o.forget...)
+				if got := c.Claim(o.claim); got != o.want {
+					t.Errorf("op %d: Claim(%q) = %v, want %v", i, o.claim, got, o.want)
+				}
+			}
+			if c.Len() > c.maxEntries {
+				t.Errorf("Len() = %d, exceeds max entries %d", c.Len(), c.maxEntries)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	base := &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, Data: map[string]interface{}{"a": 1, "b": map[string]interface{}{"x": "y"}}}
+	tests := []struct {
+		name  string
+		a, b  *model.RawEvent
+		equal bool
+	}{
+		{
+			name:  "same id",
+			a:     &model.RawEvent{ID: "e1", DataSourceID: "fw-1", Timestamp: ts},
+			b:     &model.RawEvent{ID: "e1", DataSourceID: "fw-1", Timestamp: ts.Add(time.Hour)},
+			equal: true,
+		},
+		{
+			name: "same id of other data sources",
+			a:    &model.RawEvent{ID: "e1", DataSourceID: "fw-1"},
+			b:    &model.RawEvent{ID: "e1", DataSourceID: "fw-2"},
+		},
+		{
+			name:  "same content",
+			a:     base,
+			b:     &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, Data: map[string]interface{}{"b": map[string]interface{}{"x": "y"}, "a": 1}},
+			equal: true,
+		},
+		{
+			name: "other timestamp",
+			a:    base,
+			b:    &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts.Add(time.Second), Data: base.Data},
+		},
+		{
+			name:  "defaulted timestamps",
+			a:     &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, TimestampDefaulted: true, Data: base.Data},
+			b:     &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts.Add(time.Minute), TimestampDefaulted: true, Data: base.Data},
+			equal: true,
+		},
+		{
+			name: "other data",
+			a:    base,
+			b:    &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, Data: map[string]interface{}{"a": 2}},
+		},
+		{
+			name:  "same raw payload",
+			a:     &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, RawPayload: []byte("<13>msg")},
+			b:     &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, RawPayload: []byte("<13>msg")},
+			equal: true,
+		},
+		{
+			name: "other raw payload",
+			a:    &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, RawPayload: []byte("<13>msg")},
+			b:    &model.RawEvent{DataSourceID: "fw-1", Timestamp: ts, RawPayload: []byte("<13>other")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := Key(tt.a), Key(tt.b)
+			if (a == b) != tt.equal {
+				t.Errorf("Key() = %s and %s, want equal %v", a, b, tt.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// Key returns the deduplication key of an event. Events carrying an ID are keyed by their data source and ID;
+// other events by a hash over DataSourceID, Timestamp and Data (or RawPayload when there is no Data). A defaulted
+// Timestamp is left out, so that a resent event gets the same key.
+// Key 返回事件的去重键。携带ID的事件以其数据源和ID为键；其他事件以DataSourceID、Timestamp和Data
+// （没有Data时为RawPayload）的哈希为键。默认填入的Timestamp不参与计算，使重发的事件得到相同的键。
+func Key(event *model.RawEvent) string {
+	if event.ID != "" {
+		return "id:" + event.DataSourceID + "\x00" + event.ID
+	}
+	h := sha256.New()
+	h.Write([]byte(event.DataSourceID))
+	h.Write([]byte{0})
+	var ts [8]byte
+	if !event.TimestampDefaulted {
+		binary.BigEndian.PutUint64(ts[:], uint64(event.Timestamp.UnixNano()))
+	}
+	h.Write(ts[:])
+	if event.Data != nil {
+		// fmt prints map keys in sorted order, which makes the output canonical for nested maps as well.
+		// fmt按排序后的键输出map，因此对嵌套map同样得到规范化的输出。
+		fmt.Fprint(h, event.Data)
+	} else {
+		h.Write(event.RawPayload)
+	}
+	return "h:" + hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,51 @@
+package ingestion
+
+import (
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/dedup"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// claimedKeys tracks the deduplication keys claimed by the events of one request, so that they can be
+// released again when the events fail to persist and may legitimately be resent. A nil *claimedKeys
+// disables deduplication.
+// claimedKeys 记录一次请求中的事件所占用的去重键，以便在事件持久化失败、可能被合理重发时释放这些键。
+// nil的*claimedKeys表示不去重。
+type claimedKeys struct {
+	cache *dedup.Cache
+	keys  map[*model.RawEvent]string
+}
+
+func newClaimedKeys(cache *dedup.Cache) *claimedKeys {
+	if cache == nil {
+		return nil
+	}
+	return &claimedKeys{cache: cache, keys: make(map[*model.RawEvent]string)}
+}
+
+// claim returns false if the event is a duplicate of one ingested within the window, including earlier in this request.
+// claim 如果事件与时间窗口内（包括本次请求中更早的）已采集的事件重复，则返回false。
+func (c *claimedKeys) claim(event *model.RawEvent) bool {
+	if c == nil {
+		return true
+	}
+	key := dedup.Key(event)
+	if !c.cache.Claim(key) {
+		return false
+	}
+	c.keys[event] = key
+	return true
+}
+
+// release forgets the keys claimed by events that were not persisted.
+// release 忘记未被持久化的事件所占用的键。
+func (c *claimedKeys) release(events ...*model.RawEvent) {
+	if c == nil {
+		return
+	}
+	for _, event := range events {
+		if key, ok := c.keys[event]; ok {
+			c.cache.Forget(key)
+			delete(c.keys, event)
+		}
+	}
+}
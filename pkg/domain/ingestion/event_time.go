@@ -0,0 +1,145 @@
+package ingestion
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+	"github.com/turtacn/dataseap/pkg/observability/metrics"
+)
+
+// eventTimeVerdict is the outcome of checking an event time against its policy.
+// eventTimeVerdict 是按策略检查事件时间的结果。
+type eventTimeVerdict int
+
+const (
+	eventTimeAccepted    eventTimeVerdict = iota // 事件时间在允许范围内 Event time within bounds
+	eventTimeRejected                            // 事件应被拒绝 Event must be rejected
+	eventTimeClamped                             // 事件时间已钳制为接收时间 Event time clamped to the receive time
+	eventTimeQuarantined                         // 事件应写入隔离表 Event goes to the quarantine table
+)
+
+// eventTimePolicy is an EventTimePolicy with the defaults applied.
+// eventTimePolicy 是应用了默认值的EventTimePolicy。
+type eventTimePolicy struct {
+	maxLateness     time.Duration // 0表示不限制 0 is unlimited
+	maxFuture       time.Duration // 0表示不限制 0 is unlimited
+	action          string
+	quarantineTable string
+}
+
+// eventTimePolicies bounds event times per DataType. A nil *eventTimePolicies accepts every event.
+// eventTimePolicies 按DataType限制事件时间。nil的*eventTimePolicies接受所有事件。
+type eventTimePolicies struct {
+	defaults  eventTimePolicy
+	dataTypes map[string]eventTimePolicy // 小写DataType -> 策略 Lower-cased DataType -> policy
+}
+
+// newEventTimePolicies builds the policies of the configuration, or returns nil when the policy is disabled.
+// newEventTimePolicies 根据配置构建策略，未启用时返回nil。
+func newEventTimePolicies(cfg config.EventTimeConfig) *eventTimePolicies {
+	if !cfg.Enabled {
+		return nil
+	}
+	p := &eventTimePolicies{dataTypes: make(map[string]eventTimePolicy, len(cfg.DataTypes))}
+	p.defaults = mergeEventTimePolicy(eventTimePolicy{action: constants.EventTimeActionReject}, cfg.Default, "default")
+	for dataType, policy := range cfg.DataTypes {
+		p.dataTypes[strings.ToLower(dataType)] = mergeEventTimePolicy(p.defaults, policy, dataType)
+	}
+	return p
+}
+
+// mergeEventTimePolicy overlays the non-zero fields of override on base. Negative limits remove the bound.
+// mergeEventTimePolicy 将override中的非零字段覆盖到base上，负数限制表示取消该限制。
+func mergeEventTimePolicy(base eventTimePolicy, override config.EventTimePolicy, name string) eventTimePolicy {
+	merged := base
+	switch {
+	case override.MaxLatenessSeconds < 0:
+		merged.maxLateness = 0
+	case override.MaxLatenessSeconds > 0:
+		merged.maxLateness = time.Duration(override.MaxLatenessSeconds) * time.Second
+	}
+	switch {
+	case override.MaxFutureSeconds < 0:
+		merged.maxFuture = 0
+	case override.MaxFutureSeconds > 0:
+		merged.maxFuture = time.Duration(override.MaxFutureSeconds) * time.Second
+	}
+	if override.QuarantineTable != "" {
+		merged.quarantineTable = override.QuarantineTable
+	}
+	switch action := strings.ToLower(override.Action); action {
+	case "":
+	case constants.EventTimeActionReject, constants.EventTimeActionClamp, constants.EventTimeActionQuarantine:
+		merged.action = action
+	default:
+		logger.L().Warnw("Unknown event time action, keeping the inherited action", "policy", name, "action", override.Action, "inherited", merged.action)
+	}
+	return merged
+}
+
+func (p *eventTimePolicies) policyOf(dataType string) eventTimePolicy {
+	if policy, ok := p.dataTypes[strings.ToLower(dataType)]; ok {
+		return policy
+	}
+	return p.defaults
+}
+
+// check compares the event time with receivedAt, records the ingestion lag and applies the policy of the event's
+// DataType. A clamped event gets a ClampedTimestamp, its Timestamp is kept for the batch label; for a quarantined
+// event the returned table is the quarantine table of target. reason explains any verdict other than
+// eventTimeAccepted.
+// check 将事件时间与receivedAt比较，记录采集延迟并应用事件DataType的策略。被钳制的事件会设置ClampedTimestamp，
+// 其Timestamp保留用于批次标签；对于被隔离的事件，返回的table为target对应的隔离表。
+// 除eventTimeAccepted外的结论都会附带reason说明。
+func (p *eventTimePolicies) check(event *model.RawEvent, receivedAt time.Time, target string) (verdict eventTimeVerdict, table string, reason string) {
+	event.ClampedTimestamp = time.Time{}
+	lag := receivedAt.Sub(event.Timestamp)
+	observeIngestionLag(event.DataType, lag)
+	if p == nil {
+		return eventTimeAccepted, target, ""
+	}
+
+	policy := p.policyOf(event.DataType)
+	switch {
+	case policy.maxLateness > 0 && lag > policy.maxLateness:
+		reason = fmt.Sprintf("event time %s is %s before its receive time, more than the allowed %s",
+			event.Timestamp.Format(time.RFC3339), lag.Truncate(time.Second), policy.maxLateness)
+	case policy.maxFuture > 0 && -lag > policy.maxFuture:
+		reason = fmt.Sprintf("event time %s is %s after its receive time, more than the allowed %s",
+			event.Timestamp.Format(time.RFC3339), (-lag).Truncate(time.Second), policy.maxFuture)
+	default:
+		return eventTimeAccepted, target, ""
+	}
+
+	switch policy.action {
+	case constants.EventTimeActionClamp:
+		event.ClampedTimestamp = receivedAt
+		return eventTimeClamped, target, reason
+	case constants.EventTimeActionQuarantine:
+		if policy.quarantineTable != "" {
+			return eventTimeQuarantined, policy.quarantineTable, reason
+		}
+		return eventTimeQuarantined, target + constants.IngestionQuarantineTableSuffix, reason
+	default:
+		return eventTimeRejected, target, reason
+	}
+}
+
+// observeIngestionLag records the time between an event occurring and being received. Events dated in the
+// future are recorded with a lag of zero.
+// observeIngestionLag 记录事件发生到被接收之间的时间，未来时间的事件记为零延迟。
+func observeIngestionLag(dataType string, lag time.Duration) {
+	m := metrics.Current()
+	if m == nil || m.EventIngestionLag == nil {
+		return
+	}
+	if lag < 0 {
+		lag = 0
+	}
+	m.EventIngestionLag.With(dataType).Observe(lag.Seconds())
+}
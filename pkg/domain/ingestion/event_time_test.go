@@ -0,0 +1,136 @@
+package ingestion
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+func TestMergeEventTimePolicy(t *testing.T) {
+	base := eventTimePolicy{maxLateness: time.Hour, maxFuture: time.Minute, action: constants.EventTimeActionClamp, quarantineTable: "late"}
+
+	tests := []struct {
+		name     string
+		override config.EventTimePolicy
+		want     eventTimePolicy
+	}{
+		{name: "zero fields inherit the base", want: base},
+		{
+			name:     "positive limits and the action override the base",
+			override: config.EventTimePolicy{MaxLatenessSeconds: 30, MaxFutureSeconds: 5, Action: "Quarantine", QuarantineTable: "odd"},
+			want:     eventTimePolicy{maxLateness: 30 * time.Second, maxFuture: 5 * time.Second, action: constants.EventTimeActionQuarantine, quarantineTable: "odd"},
+		},
+		{
+			name:     "negative limits remove the bound",
+			override: config.EventTimePolicy{MaxLatenessSeconds: -1, MaxFutureSeconds: -1},
+			want:     eventTimePolicy{action: constants.EventTimeActionClamp, quarantineTable: "late"},
+		},
+		{
+			name:     "unknown action keeps the inherited action",
+			override: config.EventTimePolicy{Action: "drop"},
+			want:     base,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeEventTimePolicy(base, tt.override, "test"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeEventTimePolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEventTimePolicies(t *testing.T) {
+	if p := newEventTimePolicies(config.EventTimeConfig{Default: config.EventTimePolicy{MaxLatenessSeconds: 60}}); p != nil {
+		t.Errorf("newEventTimePolicies() of a disabled policy = %+v, want nil", p)
+	}
+
+	p := newEventTimePolicies(config.EventTimeConfig{
+		Enabled: true,
+		Default: config.EventTimePolicy{MaxLatenessSeconds: 3600, MaxFutureSeconds: 60},
+		DataTypes: map[string]config.EventTimePolicy{
+			"Netflow": {MaxLatenessSeconds: -1, Action: constants.EventTimeActionClamp},
+			"audit":   {QuarantineTable: "audit_late", Action: constants.EventTimeActionQuarantine},
+		},
+	})
+	tests := []struct {
+		dataType string
+		want     eventTimePolicy
+	}{
+		{dataType: "log", want: eventTimePolicy{maxLateness: time.Hour, maxFuture: time.Minute, action: constants.EventTimeActionReject}},
+		{dataType: "netflow", want: eventTimePolicy{maxFuture: time.Minute, action: constants.EventTimeActionClamp}},
+		{dataType: "AUDIT", want: eventTimePolicy{maxLateness: time.Hour, maxFuture: time.Minute, action: constants.EventTimeActionQuarantine, quarantineTable: "audit_late"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			if got := p.policyOf(tt.dataType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("policyOf(%q) = %+v, want %+v", tt.dataType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventTimePoliciesCheck(t *testing.T) {
+	received := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	p := newEventTimePolicies(config.EventTimeConfig{
+		Enabled: true,
+		Default: config.EventTimePolicy{MaxLatenessSeconds: 3600, MaxFutureSeconds: 60},
+		DataTypes: map[string]config.EventTimePolicy{
+			"metric":  {Action: constants.EventTimeActionClamp},
+			"audit":   {Action: constants.EventTimeActionQuarantine, QuarantineTable: "audit_late"},
+			"netflow": {Action: constants.EventTimeActionQuarantine},
+			"archive": {MaxLatenessSeconds: -1},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		policies    *eventTimePolicies
+		dataType    string
+		timestamp   time.Time
+		wantVerdict eventTimeVerdict
+		wantTable   string
+		wantReason  string // 原因需包含的片段 Fragment the reason must contain
+		wantClamped bool
+	}{
+		{name: "within bounds", policies: p, dataType: "log", timestamp: received.Add(-time.Minute), wantVerdict: eventTimeAccepted, wantTable: "events"},
+		{name: "exactly at the lateness bound", policies: p, dataType: "log", timestamp: received.Add(-time.Hour), wantVerdict: eventTimeAccepted, wantTable: "events"},
+		{name: "too late is rejected by default", policies: p, dataType: "log", timestamp: received.Add(-2 * time.Hour), wantVerdict: eventTimeRejected, wantTable: "events", wantReason: "before its receive time"},
+		{name: "too far in the future is rejected", policies: p, dataType: "log", timestamp: received.Add(time.Hour), wantVerdict: eventTimeRejected, wantTable: "events", wantReason: "after its receive time"},
+		{name: "clamp", policies: p, dataType: "Metric", timestamp: received.Add(-2 * time.Hour), wantVerdict: eventTimeClamped, wantTable: "events", wantReason: "before its receive time", wantClamped: true},
+		{name: "quarantine into the configured table", policies: p, dataType: "audit", timestamp: received.Add(time.Hour), wantVerdict: eventTimeQuarantined, wantTable: "audit_late", wantReason: "after its receive time"},
+		{name: "quarantine into the default table", policies: p, dataType: "netflow", timestamp: received.Add(-2 * time.Hour), wantVerdict: eventTimeQuarantined, wantTable: "events" + constants.IngestionQuarantineTableSuffix, wantReason: "before its receive time"},
+		{name: "negative limit accepts any lateness", policies: p, dataType: "archive", timestamp: received.AddDate(-1, 0, 0), wantVerdict: eventTimeAccepted, wantTable: "events"},
+		{name: "negative limit keeps the inherited future bound", policies: p, dataType: "archive", timestamp: received.Add(time.Hour), wantVerdict: eventTimeRejected, wantTable: "events", wantReason: "after its receive time"},
+		{name: "disabled policy accepts every event", dataType: "log", timestamp: received.AddDate(-1, 0, 0), wantVerdict: eventTimeAccepted, wantTable: "events"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A ClampedTimestamp left from an earlier check must not survive.
+			event := &model.RawEvent{DataType: tt.dataType, Timestamp: tt.timestamp, ClampedTimestamp: received.Add(time.Minute)}
+
+			verdict, table, reason := tt.policies.check(event, received, "events")
+			if verdict != tt.wantVerdict || table != tt.wantTable {
+				t.Errorf("check() = (%v, %q), want (%v, %q)", verdict, table, tt.wantVerdict, tt.wantTable)
+			}
+			if tt.wantReason == "" && reason != "" || !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("check() reason = %q, want it to contain %q", reason, tt.wantReason)
+			}
+			wantClamped := time.Time{}
+			if tt.wantClamped {
+				wantClamped = received
+			}
+			if !event.ClampedTimestamp.Equal(wantClamped) {
+				t.Errorf("check() clamped timestamp = %v, want %v", event.ClampedTimestamp, wantClamped)
+			}
+			if !event.Timestamp.Equal(tt.timestamp) {
+				t.Errorf("check() timestamp = %v, want it unchanged at %v", event.Timestamp, tt.timestamp)
+			}
+		})
+	}
+}
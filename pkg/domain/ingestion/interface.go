@@ -2,6 +2,7 @@ package ingestion
 
 import (
 	"context"
+	"io"
 
 	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
 )
@@ -12,11 +13,23 @@ type Service interface {
 	// IngestEvents ingests one or more raw events into the system.
 	// IngestEvents 将一个或多个原始事件采集到系统中。
 	// It returns the count of successfully ingested events, successfully parsed but failed to persist events,
-	// and events that failed parsing/validation.
-	// 它返回成功采集的事件数量，成功解析但持久化失败的事件数量，以及解析/验证失败的事件数量。
-	IngestEvents(ctx context.Context, events []*model.RawEvent) (ingestedCount int, persistFailedCount int, validationFailedCount int, err error)
+	// events that failed parsing/validation, and duplicate events that were dropped.
+	// 它返回成功采集的事件数量，成功解析但持久化失败的事件数量，解析/验证失败的事件数量，以及被丢弃的重复事件数量。
+	IngestEvents(ctx context.Context, events []*model.RawEvent) (ingestedCount int, persistFailedCount int, validationFailedCount int, duplicateCount int, err error)
 
 	// IngestEvent ingests a single raw event. Convenience method for IngestEvents.
 	// IngestEvent 采集单个原始事件。是对 IngestEvents 的便捷方法。
 	IngestEvent(ctx context.Context, event *model.RawEvent) error
+
+	// ReplayEvents ingests the events of dead letters like IngestEvents, filling the counts of result. It reports
+	// by index the events that were not settled: neither ingested, dropped as duplicates nor recorded as new dead
+	// letters, such as all of them when the request is rate limited.
+	// ReplayEvents 像IngestEvents一样采集死信中的事件，并填充result中的计数。它按下标报告未落定的事件：
+	// 既未采集成功、未作为重复事件丢弃，也未被记录为新的死信，例如请求被限流时的所有事件。
+	ReplayEvents(ctx context.Context, events []*model.RawEvent, result *model.ReplayResult) (unsettled []bool, err error)
+
+	// IngestStream streams a large NDJSON or CSV body, optionally compressed, into the table of req.DataType
+	// in chunked Stream Loads, and returns a summary of the job.
+	// IngestStream 将大型NDJSON或CSV请求体（可压缩）以分块Stream Load的方式流式写入req.DataType对应的表，并返回任务汇总。
+	IngestStream(ctx context.Context, req *model.BulkIngestRequest, body io.Reader) (*model.BulkIngestSummary, error)
 }
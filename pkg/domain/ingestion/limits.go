@@ -0,0 +1,93 @@
+package ingestion
+
+import (
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/ratelimit"
+	"github.com/turtacn/dataseap/pkg/observability/metrics"
+)
+
+// admit applies the rate limits to a request before any work is done on it: the estimated size of the events
+// is reserved from the in-flight byte budget, then the events are counted against the rate of their data source.
+// The returned release gives the bytes back and must be called once the request is finished.
+// admit 在处理请求之前对其应用限流：先从在途字节预算中预留事件的估算大小，再按数据源的速率计数事件。
+// 返回的release归还预留的字节，必须在请求结束后调用。
+func (s *serviceImpl) admit(events []*model.RawEvent) (release func(), err error) {
+	if s.limiter == nil {
+		return func() {}, nil
+	}
+	var size int64
+	counts := make(map[string]int)
+	dataTypes := make(map[string]struct{})
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+		size += estimateEventSize(event)
+		counts[event.DataSourceID]++
+		dataTypes[event.DataType] = struct{}{}
+	}
+	release, err = s.limiter.AcquireBytes(size)
+	if err != nil {
+		recordRateLimited(dataTypes, err)
+		return nil, err
+	}
+	if err := s.limiter.AdmitEvents(counts); err != nil {
+		release()
+		recordRateLimited(dataTypes, err)
+		return nil, err
+	}
+	return release, nil
+}
+
+// recordRateLimited counts a request rejected by the rate limits once for each data type among its events. The
+// data source over its rate is only logged by the caller: data sources are not bounded, data types are.
+// recordRateLimited 对被限流拒绝的请求，按其事件中的每个数据类型各计数一次。超出速率的数据源仅由调用方记录日志：
+// 数据源的数量没有上限，而数据类型有。
+func recordRateLimited(dataTypes map[string]struct{}, err error) {
+	m := metrics.Current()
+	if m == nil || m.IngestionRateLimitedTotal == nil {
+		return
+	}
+	exceeded, ok := ratelimit.Exceeded(err)
+	if !ok {
+		return
+	}
+	for dataType := range dataTypes {
+		m.IngestionRateLimitedTotal.With(dataType, exceeded.Reason).Inc()
+	}
+}
+
+// estimateEventSize approximates the memory held by an event without serialising it.
+// estimateEventSize 在不序列化的情况下估算事件占用的内存。
+func estimateEventSize(event *model.RawEvent) int64 {
+	size := int64(len(event.ID) + len(event.DataSourceID) + len(event.DataType) + len(event.RawPayload))
+	for k, v := range event.Tags {
+		size += int64(len(k) + len(v))
+	}
+	return size + estimateValueSize(event.Data)
+}
+
+func estimateValueSize(v interface{}) int64 {
+	switch val := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(val))
+	case []byte:
+		return int64(len(val))
+	case map[string]interface{}:
+		var size int64
+		for k, item := range val {
+			size += int64(len(k)) + estimateValueSize(item)
+		}
+		return size
+	case []interface{}:
+		var size int64
+		for _, item := range val {
+			size += estimateValueSize(item)
+		}
+		return size
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,365 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// Reserved column names filled from RawEvent metadata when the event data does not already carry them.
+// 保留列名，当事件数据中不存在时由RawEvent元数据填充。
+const (
+	ColumnEventID      = "event_id"
+	ColumnDataSourceID = "data_source_id"
+	ColumnEventTime    = "event_time"
+	ColumnReceivedAt   = "received_at"
+)
+
+const (
+	labelPrefix    = "dataseap_"
+	maxLabelLength = 128 // StarRocks label length limit
+	abortTimeout   = 30 * time.Second
+)
+
+// labelUnsafeChars matches characters not allowed in StarRocks labels.
+var labelUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// loadResult summarises the outcome of a single Stream Load batch.
+// loadResult 汇总单个Stream Load批次的结果。
+type loadResult struct {
+	Loaded    int                           // 成功写入的事件数 Events written successfully
+	Failed    int                           // 写入失败或被过滤的事件数 Events that failed or were filtered
+	Label     string                        // 批次标签 (仅精确一次模式) Batch label (exactly-once mode only)
+	Duplicate bool                          // 批次此前已提交 Batch was already committed earlier
+	Response  *starrocks.StreamLoadResponse // StarRocks原始响应 (可能为nil) Raw StarRocks response (may be nil)
+}
+
+// batchLoader writes batches of events into StarRocks tables via Stream Load.
+// batchLoader 通过Stream Load将事件批次写入StarRocks表。
+type batchLoader struct {
+	client starrocks.Client
+	cfg    config.IngestionConfig
+}
+
+// newBatchLoader creates a batchLoader for the given client and ingestion configuration.
+// newBatchLoader 根据给定的客户端与采集配置创建batchLoader。
+func newBatchLoader(client starrocks.Client, cfg config.IngestionConfig) *batchLoader {
+	return &batchLoader{client: client, cfg: cfg}
+}
+
+// resolveTable returns the target table configured for a data type.
+// resolveTable 返回数据类型配置的目标表。
+func (b *batchLoader) resolveTable(dataType string) (string, bool) {
+	if table, ok := b.cfg.TableMapping[dataType]; ok && table != "" {
+		return table, true
+	}
+	// viper lower-cases map keys, so fall back to the lower-cased data type.
+	table, ok := b.cfg.TableMapping[strings.ToLower(dataType)]
+	return table, ok && table != ""
+}
+
+// batchSize returns the configured maximum batch size.
+// batchSize 返回配置的最大批次大小。
+func (b *batchLoader) batchSize() int {
+	if b.cfg.BatchSize > 0 {
+		return b.cfg.BatchSize
+	}
+	return constants.IngestionDefaultBatchSize
+}
+
+// load Stream Loads the events into the table as a single JSON array.
+// load 将事件作为一个JSON数组通过Stream Load写入表中。
+func (b *batchLoader) load(ctx context.Context, table string, events []*model.RawEvent) (*loadResult, error) {
+	label := ""
+	if b.cfg.TwoPhaseCommit {
+		label = batchLabel(table, events)
+	}
+	return b.loadLabeled(ctx, table, label, events, nil)
+}
+
+// loadLabeled Stream Loads the events into the table as a single JSON array. In exactly-once mode the batch is
+// loaded under label and prepared, when not nil, is called with the label of each attempt once its data is
+// prepared and before it is committed; an error from prepared aborts the attempt.
+// loadLabeled 将事件作为一个JSON数组通过Stream Load写入表中。精确一次模式下批次以label写入，prepared不为nil时，
+// 每次尝试的数据预提交后、提交前以该次尝试的标签调用prepared；prepared返回错误时中止该次尝试。
+func (b *batchLoader) loadLabeled(ctx context.Context, table, label string, events []*model.RawEvent, prepared func(context.Context, string) error) (*loadResult, error) {
+	if b.cfg.Database == "" {
+		return &loadResult{Failed: len(events)}, errors.New(errors.ConfigError, "ingestion target database is not configured")
+	}
+
+	rows := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, eventToRow(event))
+	}
+	payload, err := json.Marshal(rows)
+	if err != nil {
+		return &loadResult{Failed: len(events)}, errors.Wrap(err, errors.SerializationError, "failed to serialize events for stream load")
+	}
+
+	opts := &starrocks.StreamLoadOptions{
+		Format:          "json",
+		StripOuterArray: true,
+		TimeoutSeconds:  b.cfg.LoadTimeout,
+		MaxFilterRatio:  b.cfg.MaxFilterRatio,
+	}
+	if b.cfg.TwoPhaseCommit {
+		return b.loadTwoPhase(ctx, table, label, payload, len(events), opts, prepared)
+	}
+
+	resp, err := b.client.StreamLoad(ctx, b.cfg.Database, table, bytes.NewReader(payload), opts)
+	if err != nil {
+		return &loadResult{Failed: len(events), Response: resp}, err
+	}
+	return newLoadResult(resp, len(events), ""), nil
+}
+
+// loadTwoPhase loads a batch exactly once: it begins a transaction under the batch label,
+// streams the payload into it and commits, aborting the transaction on any failure.
+// A label that already exists belongs to a previous attempt at the same batch, whose state
+// decides the outcome: a committed attempt is reported as an idempotent success, a prepared one
+// is committed now, one still loading fails the batch for a later retry, and an aborted one is
+// retried under the label of the next attempt.
+// loadTwoPhase 以精确一次语义写入批次：以批次标签开启事务、写入数据并提交，任何失败都会中止事务。
+// 标签已存在表示之前对相同批次的尝试，由其状态决定结果：已提交的视为幂等成功，已预提交的此时提交，
+// 仍在写入的使批次失败以待稍后重试，已中止的则以下一次尝试的标签重试。
+func (b *batchLoader) loadTwoPhase(ctx context.Context, table, label string, payload []byte, count int, opts *starrocks.StreamLoadOptions, prepared func(context.Context, string) error) (*loadResult, error) {
+	for attempt := 0; attempt < constants.IngestionMaxLabelAttempts; attempt++ {
+		result, retry, err := b.loadAttempt(ctx, table, attemptLabel(label, attempt), payload, count, opts, prepared)
+		if !retry {
+			return result, err
+		}
+	}
+	return &loadResult{Failed: count, Label: label}, errors.Newf(errors.DatabaseError,
+		"batch %s was aborted in all %d attempts", label, constants.IngestionMaxLabelAttempts)
+}
+
+// loadAttempt loads a batch under the label of one attempt. It reports retry when an earlier load under the
+// label was aborted, so that the next attempt should be made.
+// loadAttempt 以某次尝试的标签写入批次。若该标签之前的导入已中止，则报告retry，以进行下一次尝试。
+func (b *batchLoader) loadAttempt(ctx context.Context, table, label string, payload []byte, count int, opts *starrocks.StreamLoadOptions, prepared func(context.Context, string) error) (*loadResult, bool, error) {
+	l := logger.L().With("method", "loadTwoPhase", "table", table, "label", label)
+
+	txnID, err := b.client.BeginTransaction(ctx, b.cfg.Database, table, label, opts.TimeoutSeconds)
+	if err != nil {
+		if errors.Is(err, errors.AlreadyExistsError) {
+			return b.resolveExisting(ctx, label, txnID, count)
+		}
+		return &loadResult{Failed: count, Label: label}, false, err
+	}
+
+	// The label is bound to the transaction at begin time, so it is not repeated on the load itself.
+	loadOpts := *opts
+	loadOpts.TwoPhaseCommit = true
+	loadOpts.TransactionID = strconv.FormatInt(txnID, 10)
+	resp, err := b.client.StreamLoad(ctx, b.cfg.Database, table, bytes.NewReader(payload), &loadOpts)
+	if err != nil {
+		b.abort(txnID)
+		return &loadResult{Failed: count, Label: label, Response: resp}, false, err
+	}
+	if prepared != nil {
+		if err := prepared(ctx, label); err != nil {
+			b.abort(txnID)
+			return &loadResult{Failed: count, Label: label, Response: resp}, false, err
+		}
+	}
+
+	if err := b.client.CommitTransaction(ctx, b.cfg.Database, txnID); err != nil {
+		b.abort(txnID)
+		return &loadResult{Failed: count, Label: label, Response: resp}, false, err
+	}
+	l.Debugw("Two-phase load committed", "txn_id", txnID, "loaded", resp.NumberLoadedRows)
+	return newLoadResult(resp, count, label), false, nil
+}
+
+// resolveExisting settles a batch whose label a previous attempt already used, from the state of that attempt.
+// resolveExisting 根据之前使用该标签的尝试的状态处理批次。
+func (b *batchLoader) resolveExisting(ctx context.Context, label string, txnID int64, count int) (*loadResult, bool, error) {
+	l := logger.L().With("method", "resolveExisting", "label", label, "existing_txn_id", txnID)
+
+	state, err := b.client.GetLoadState(ctx, b.cfg.Database, label)
+	if err != nil {
+		return &loadResult{Failed: count, Label: label}, false, err
+	}
+	switch state {
+	case starrocks.LoadStateCommitted, starrocks.LoadStateVisible:
+		l.Infow("Batch label already committed, treating batch as already loaded", "state", state)
+		return &loadResult{Loaded: count, Label: label, Duplicate: true}, false, nil
+	case starrocks.LoadStatePrepared:
+		if txnID == 0 {
+			return &loadResult{Failed: count, Label: label}, false, errors.Newf(errors.DatabaseError, "batch %s is prepared in an unknown transaction", label)
+		}
+		if err := b.client.CommitTransaction(ctx, b.cfg.Database, txnID); err != nil {
+			return &loadResult{Failed: count, Label: label}, false, err
+		}
+		l.Infow("Committed the prepared transaction of a previous attempt")
+		return &loadResult{Loaded: count, Label: label, Duplicate: true}, false, nil
+	case starrocks.LoadStateAborted:
+		l.Infow("Previous attempt was aborted, retrying under a new label")
+		return nil, true, nil
+	default:
+		return &loadResult{Failed: count, Label: label}, false, errors.Newf(errors.DatabaseError,
+			"batch %s is still being loaded by a previous attempt (state %s), retry later", label, state)
+	}
+}
+
+// labelLoaded reports whether the batch loaded into table under label, the label of one attempt, has been
+// committed. A prepared attempt is committed now; an aborted or unknown one was not loaded, and an attempt still
+// loading is reported as an error so that the caller retries later.
+// labelLoaded 报告以label（某次尝试的标签）写入table的批次是否已提交。已预提交的尝试此时提交；已中止或未知的尝试
+// 视为未写入，仍在写入的尝试则报告错误，以便调用方稍后重试。
+func (b *batchLoader) labelLoaded(ctx context.Context, table, label string) (bool, error) {
+	state, err := b.client.GetLoadState(ctx, b.cfg.Database, label)
+	if err != nil {
+		return false, err
+	}
+	switch state {
+	case starrocks.LoadStateCommitted, starrocks.LoadStateVisible:
+		return true, nil
+	case starrocks.LoadStateAborted, starrocks.LoadStateUnknown:
+		return false, nil
+	case starrocks.LoadStatePrepared:
+		// Beginning a transaction under the label reports the ID of the prepared transaction holding it.
+		// 以该标签开启事务会返回持有该标签的已预提交事务的ID。
+		txnID, err := b.client.BeginTransaction(ctx, b.cfg.Database, table, label, b.cfg.LoadTimeout)
+		if err == nil {
+			b.abort(txnID)
+			return false, errors.Newf(errors.DatabaseError, "label %s is no longer held by its prepared transaction", label)
+		}
+		if !errors.Is(err, errors.AlreadyExistsError) {
+			return false, err
+		}
+		result, retry, err := b.resolveExisting(ctx, label, txnID, 0)
+		if err != nil || retry {
+			return false, err
+		}
+		return result.Duplicate, nil
+	default:
+		return false, errors.Newf(errors.DatabaseError, "batch %s is still being loaded (state %s), retry later", label, state)
+	}
+}
+
+// abort aborts a transaction on a context detached from the caller, so a cancelled request
+// does not leave a prepared transaction behind.
+// abort 使用与调用方分离的上下文中止事务，避免已取消的请求遗留已预提交的事务。
+func (b *batchLoader) abort(txnID int64) {
+	abortCtx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+	defer cancel()
+	if err := b.client.AbortTransaction(abortCtx, b.cfg.Database, txnID); err != nil {
+		logger.L().Warnw("Failed to abort transaction", "txn_id", txnID, "error", err)
+	}
+}
+
+// newLoadResult derives per-event counters from a successful Stream Load response.
+// newLoadResult 根据成功的Stream Load响应计算事件计数。
+func newLoadResult(resp *starrocks.StreamLoadResponse, count int, label string) *loadResult {
+	loaded := int(resp.NumberLoadedRows)
+	if loaded > count {
+		loaded = count
+	}
+	if label == "" {
+		label = resp.Label
+	}
+	return &loadResult{Loaded: loaded, Failed: count - loaded, Label: label, Response: resp}
+}
+
+// batchLabel derives a deterministic Stream Load label from the table and the identity of the
+// batch events. ReceivedAt, and the timestamps the platform filled in or clamped, are left out so that a
+// retried request maps to the same label.
+// batchLabel 根据表名和批次事件的标识生成确定性的Stream Load标签。
+// 计算时不包含ReceivedAt以及平台填入或钳制的时间戳，使重试的请求得到相同的标签。
+func batchLabel(table string, events []*model.RawEvent) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, event := range events {
+		timestamp := event.Timestamp
+		if event.TimestampDefaulted {
+			timestamp = time.Time{}
+		}
+		_ = enc.Encode(struct {
+			ID           string                 `json:"id"`
+			DataSourceID string                 `json:"dataSourceId"`
+			DataType     string                 `json:"dataType"`
+			Timestamp    time.Time              `json:"timestamp"`
+			Data         map[string]interface{} `json:"data"`
+			RawPayload   []byte                 `json:"rawPayload"`
+		}{event.ID, event.DataSourceID, event.DataType, timestamp, event.Data, event.RawPayload})
+	}
+	label := labelPrefix + labelUnsafeChars.ReplaceAllString(table, "_") + "_" + hex.EncodeToString(h.Sum(nil))[:32]
+	if len(label) > maxLabelLength {
+		label = label[len(label)-maxLabelLength:]
+	}
+	return label
+}
+
+// attemptLabel returns the label of a retry of a batch after its earlier attempts were aborted. The first attempt
+// uses the batch label itself.
+// attemptLabel 返回批次在之前的尝试被中止后重试所用的标签。第一次尝试直接使用批次标签。
+func attemptLabel(label string, attempt int) string {
+	if attempt == 0 {
+		return label
+	}
+	label += "_" + strconv.Itoa(attempt)
+	if len(label) > maxLabelLength {
+		label = label[len(label)-maxLabelLength:]
+	}
+	return label
+}
+
+// eventToRow flattens an event into a row keyed by column name.
+// Data fields take precedence over the reserved metadata columns.
+// eventToRow 将事件转换为以列名为键的行，Data字段优先于保留的元数据列。
+func eventToRow(event *model.RawEvent) map[string]interface{} {
+	row := make(map[string]interface{}, len(event.Data)+4)
+	for k, v := range event.Data {
+		row[k] = v
+	}
+	setIfAbsent(row, ColumnDataSourceID, event.DataSourceID)
+	eventTime := event.Timestamp
+	if !event.ClampedTimestamp.IsZero() {
+		eventTime = event.ClampedTimestamp
+	}
+	setIfAbsent(row, ColumnEventTime, eventTime.UTC().Format(constants.DefaultTimeFormat))
+	if event.ID != "" {
+		setIfAbsent(row, ColumnEventID, event.ID)
+	}
+	if !event.ReceivedAt.IsZero() {
+		setIfAbsent(row, ColumnReceivedAt, event.ReceivedAt.UTC().Format(constants.DefaultTimeFormat))
+	}
+	return row
+}
+
+func setIfAbsent(row map[string]interface{}, key string, value interface{}) {
+	if _, ok := row[key]; !ok {
+		row[key] = value
+	}
+}
+
+// chunkEvents splits events into consecutive chunks of at most size events.
+// chunkEvents 将事件切分为每块最多size个事件的连续分块。
+func chunkEvents(events []*model.RawEvent, size int) [][]*model.RawEvent {
+	if size <= 0 || len(events) <= size {
+		return [][]*model.RawEvent{events}
+	}
+	chunks := make([][]*model.RawEvent, 0, (len(events)+size-1)/size)
+	for start := 0; start < len(events); start += size {
+		end := start + size
+		if end > len(events) {
+			end = len(events)
+		}
+		chunks = append(chunks, events[start:end])
+	}
+	return chunks
+}
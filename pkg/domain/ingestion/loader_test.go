@@ -0,0 +1,227 @@
+package ingestion
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// fakeTransactions is a StarRocks client for two-phase loads. Labels in states already exist; beginning a
+// transaction under any other label succeeds.
+type fakeTransactions struct {
+	starrocks.Client
+	states    map[string]string
+	loadErr   error
+	begun     []string
+	committed []int64
+	aborted   []int64
+}
+
+func (f *fakeTransactions) BeginTransaction(_ context.Context, _, _, label string, _ int) (int64, error) {
+	f.begun = append(f.begun, label)
+	if _, ok := f.states[label]; ok {
+		return 7, errors.Newf(errors.AlreadyExistsError, "label %s already exists", label)
+	}
+	return int64(100 + len(f.begun)), nil
+}
+
+func (f *fakeTransactions) StreamLoad(_ context.Context, _, _ string, data io.Reader, _ *starrocks.StreamLoadOptions) (*starrocks.StreamLoadResponse, error) {
+	if f.loadErr != nil {
+		return nil, f.loadErr
+	}
+	_, _ = io.ReadAll(data)
+	return &starrocks.StreamLoadResponse{Status: starrocks.StreamLoadStatusSuccess, NumberLoadedRows: 3}, nil
+}
+
+func (f *fakeTransactions) CommitTransaction(_ context.Context, _ string, txnID int64) error {
+	f.committed = append(f.committed, txnID)
+	return nil
+}
+
+func (f *fakeTransactions) AbortTransaction(_ context.Context, _ string, txnID int64) error {
+	f.aborted = append(f.aborted, txnID)
+	return nil
+}
+
+func (f *fakeTransactions) GetLoadState(_ context.Context, _, label string) (string, error) {
+	if state, ok := f.states[label]; ok {
+		return state, nil
+	}
+	return starrocks.LoadStateUnknown, nil
+}
+
+func TestLoadTwoPhase(t *testing.T) {
+	const label = "dataseap_events_0123"
+	aborted := map[string]string{}
+	var attempts []string
+	for attempt := 0; attempt < constants.IngestionMaxLabelAttempts; attempt++ {
+		aborted[attemptLabel(label, attempt)] = starrocks.LoadStateAborted
+		attempts = append(attempts, attemptLabel(label, attempt))
+	}
+
+	tests := []struct {
+		name          string
+		states        map[string]string
+		loadErr       error
+		wantLoaded    int
+		wantDuplicate bool
+		wantErr       bool
+		wantBegun     []string
+		wantCommitted []int64
+		wantAborted   []int64
+	}{
+		{
+			name:          "new label",
+			wantLoaded:    3,
+			wantBegun:     []string{label},
+			wantCommitted: []int64{101},
+		},
+		{
+			name:          "visible label is a duplicate",
+			states:        map[string]string{label: starrocks.LoadStateVisible},
+			wantLoaded:    3,
+			wantDuplicate: true,
+			wantBegun:     []string{label},
+		},
+		{
+			name:          "committed label is a duplicate",
+			states:        map[string]string{label: starrocks.LoadStateCommitted},
+			wantLoaded:    3,
+			wantDuplicate: true,
+			wantBegun:     []string{label},
+		},
+		{
+			name:          "prepared label is committed",
+			states:        map[string]string{label: starrocks.LoadStatePrepared},
+			wantLoaded:    3,
+			wantDuplicate: true,
+			wantBegun:     []string{label},
+			wantCommitted: []int64{7},
+		},
+		{
+			name:      "label still loading fails",
+			states:    map[string]string{label: starrocks.LoadStatePrepare},
+			wantErr:   true,
+			wantBegun: []string{label},
+		},
+		{
+			name:          "aborted label is retried under the next label",
+			states:        map[string]string{label: starrocks.LoadStateAborted},
+			wantLoaded:    3,
+			wantBegun:     []string{label, label + "_1"},
+			wantCommitted: []int64{102},
+		},
+		{
+			name:      "all attempts aborted",
+			states:    aborted,
+			wantErr:   true,
+			wantBegun: attempts,
+		},
+		{
+			name:        "failed load is aborted",
+			loadErr:     errors.New(errors.NetworkError, "connection reset"),
+			wantErr:     true,
+			wantBegun:   []string{label},
+			wantAborted: []int64{101},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeTransactions{states: tt.states, loadErr: tt.loadErr}
+			loader := newBatchLoader(client, config.IngestionConfig{Database: "db", TwoPhaseCommit: true})
+			result, err := loader.loadTwoPhase(context.Background(), "events", label, []byte("[]"), 3, &starrocks.StreamLoadOptions{}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadTwoPhase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (result.Loaded != tt.wantLoaded || result.Duplicate != tt.wantDuplicate) {
+				t.Errorf("loadTwoPhase() = loaded %d duplicate %v, want loaded %d duplicate %v", result.Loaded, result.Duplicate, tt.wantLoaded, tt.wantDuplicate)
+			}
+			if tt.wantErr && result.Failed != 3 {
+				t.Errorf("loadTwoPhase() failed = %d, want 3", result.Failed)
+			}
+			if !reflect.DeepEqual(client.begun, tt.wantBegun) {
+				t.Errorf("begun labels = %v, want %v", client.begun, tt.wantBegun)
+			}
+			if !reflect.DeepEqual(client.committed, tt.wantCommitted) {
+				t.Errorf("committed transactions = %v, want %v", client.committed, tt.wantCommitted)
+			}
+			if !reflect.DeepEqual(client.aborted, tt.wantAborted) {
+				t.Errorf("aborted transactions = %v, want %v", client.aborted, tt.wantAborted)
+			}
+		})
+	}
+}
+
+func TestBatchLabel(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := func(mutate func(*model.RawEvent)) []*model.RawEvent {
+		e := &model.RawEvent{ID: "e1", DataSourceID: "fw-1", DataType: "firewall", Timestamp: ts, Data: map[string]interface{}{"action": "deny"}}
+		if mutate != nil {
+			mutate(e)
+		}
+		return []*model.RawEvent{e}
+	}
+	base := batchLabel("events", event(nil))
+
+	tests := []struct {
+		name   string
+		table  string
+		events []*model.RawEvent
+		same   bool
+	}{
+		{"identical batch", "events", event(nil), true},
+		{"received at is ignored", "events", event(func(e *model.RawEvent) { e.ReceivedAt = time.Now() }), true},
+		{"clamped timestamp is ignored", "events", event(func(e *model.RawEvent) { e.ClampedTimestamp = ts.Add(time.Hour) }), true},
+		{"other table", "alerts", event(nil), false},
+		{"other id", "events", event(func(e *model.RawEvent) { e.ID = "e2" }), false},
+		{"other data", "events", event(func(e *model.RawEvent) { e.Data["action"] = "allow" }), false},
+		{"other timestamp", "events", event(func(e *model.RawEvent) { e.Timestamp = ts.Add(time.Second) }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchLabel(tt.table, tt.events)
+			if (got == base) != tt.same {
+				t.Errorf("batchLabel() = %s, base %s, want same %v", got, base, tt.same)
+			}
+		})
+	}
+
+	defaulted := func(at time.Time) []*model.RawEvent {
+		return event(func(e *model.RawEvent) { e.Timestamp, e.TimestampDefaulted = at, true })
+	}
+	if a, b := batchLabel("events", defaulted(ts)), batchLabel("events", defaulted(ts.Add(time.Minute))); a != b {
+		t.Errorf("labels of batches with defaulted timestamps differ: %s, %s", a, b)
+	}
+	if label := batchLabel(strings.Repeat("t", 200), event(nil)); len(label) > maxLabelLength {
+		t.Errorf("batchLabel() length = %d, want at most %d", len(label), maxLabelLength)
+	}
+}
+
+func TestAttemptLabel(t *testing.T) {
+	long := strings.Repeat("l", maxLabelLength)
+	tests := []struct {
+		label   string
+		attempt int
+		want    string
+	}{
+		{"dataseap_t_ab", 0, "dataseap_t_ab"},
+		{"dataseap_t_ab", 1, "dataseap_t_ab_1"},
+		{"dataseap_t_ab", 12, "dataseap_t_ab_12"},
+		{long, 0, long},
+		{long, 3, long[2:] + "_3"},
+	}
+	for _, tt := range tests {
+		if got := attemptLabel(tt.label, tt.attempt); got != tt.want {
+			t.Errorf("attemptLabel(%q, %d) = %q, want %q", tt.label, tt.attempt, got, tt.want)
+		}
+	}
+}
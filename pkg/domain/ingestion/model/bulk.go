@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+)
+
+// BulkFormat is the record format of a bulk upload.
+// BulkFormat 是批量上传的记录格式。
+type BulkFormat string
+
+const (
+	// BulkFormatNDJSON 每行一个JSON对象
+	// BulkFormatNDJSON one JSON object per line.
+	BulkFormatNDJSON BulkFormat = "ndjson"
+	// BulkFormatCSV 每行一条CSV记录
+	// BulkFormatCSV one CSV record per line.
+	BulkFormatCSV BulkFormat = "csv"
+)
+
+// BulkIngestRequest describes a streaming bulk upload. The body itself is passed separately as an io.Reader.
+// BulkIngestRequest 描述一次流式批量上传，请求体本身作为io.Reader单独传入。
+type BulkIngestRequest struct {
+	// DataType 数据类型，决定目标表
+	// DataType Type of data, selects the target table.
+	DataType string `json:"dataType"`
+
+	// Format 记录格式
+	// Format Record format.
+	Format BulkFormat `json:"format"`
+
+	// Compression (可选) 请求体的压缩方式: "", "gzip" 或 "zstd"
+	// Compression (Optional) Compression of the body: "", "gzip" or "zstd".
+	Compression string `json:"compression,omitempty"`
+
+	// CSVHeader (仅CSV) 首行为列名
+	// CSVHeader (CSV only) The first line holds the column names.
+	CSVHeader bool `json:"csvHeader,omitempty"`
+
+	// Columns (仅CSV, 可选) 列名列表，未提供首行列名时使用
+	// Columns (CSV only, Optional) Column names, used when there is no header line.
+	Columns []string `json:"columns,omitempty"`
+
+	// ColumnSeparator (仅CSV, 可选) 列分隔符，默认为 ","
+	// ColumnSeparator (CSV only, Optional) Column separator, defaults to ",".
+	ColumnSeparator string `json:"columnSeparator,omitempty"`
+
+	// UploadID (可选) 客户端提供的上传标识，分块标签由其派生。以相同的UploadID重新发送同一请求体时，已提交的分块会被跳过
+	// UploadID (Optional) Client-supplied upload identifier the chunk labels are derived from. Resending the same body
+	// under the same UploadID skips the chunks that were already committed.
+	UploadID string `json:"uploadId,omitempty"`
+}
+
+// BulkChunkResult is the outcome of loading one chunk of a bulk upload.
+// BulkChunkResult 是批量上传中一个分块的写入结果。
+type BulkChunkResult struct {
+	Label        string `json:"label"`              // 分块的Stream Load标签 Stream Load label of the chunk
+	Status       string `json:"status"`             // Stream Load状态 Stream Load status
+	TxnID        int64  `json:"txnId,omitempty"`    // 事务ID Transaction ID
+	Rows         int64  `json:"rows"`               // 分块中的行数 Rows in the chunk
+	LoadedRows   int64  `json:"loadedRows"`         // 成功导入行数 Rows loaded
+	FilteredRows int64  `json:"filteredRows"`       // 被过滤的行数 Rows filtered out
+	LoadBytes    int64  `json:"loadBytes"`          // 加载字节数 Bytes loaded
+	LoadTimeMs   int64  `json:"loadTimeMs"`         // 加载耗时 Load time in milliseconds
+	ErrorURL     string `json:"errorUrl,omitempty"` // 错误日志URL Error log URL
+	Message      string `json:"message,omitempty"`  // 详细信息 Detailed message
+	Skipped      bool   `json:"skipped,omitempty"`  // 该分块已由之前的上传尝试提交 The chunk was committed by an earlier attempt of the upload
+}
+
+// BulkIngestSummary summarises a bulk upload job.
+// BulkIngestSummary 汇总一次批量上传任务。
+type BulkIngestSummary struct {
+	JobID          string             `json:"jobId"`                    // 任务ID，也是分块标签的前缀 Job ID, also the prefix of the chunk labels
+	DataType       string             `json:"dataType"`                 // 数据类型 Data type
+	Table          string             `json:"table"`                    // 目标表 Target table
+	Format         BulkFormat         `json:"format"`                   // 记录格式 Record format
+	Status         string             `json:"status"`                   // SUCCESS, PARTIAL 或 FAILED SUCCESS, PARTIAL or FAILED
+	Chunks         int                `json:"chunks"`                   // 已发送的分块数 Chunks sent
+	TotalRows      int64              `json:"totalRows"`                // 读取的记录行数 Record lines read
+	LoadedRows     int64              `json:"loadedRows"`               // 成功导入行数 Rows loaded
+	FilteredRows   int64              `json:"filteredRows"`             // StarRocks过滤的行数 Rows filtered by StarRocks
+	RejectedRows   int64              `json:"rejectedRows"`             // 发送前被拒绝的格式错误行数 Malformed lines rejected before sending
+	SkippedRows    int64              `json:"skippedRows"`              // 之前的上传尝试已提交的分块中的行数 Rows of chunks an earlier attempt of the upload committed
+	LoadBytes      int64              `json:"loadBytes"`                // 加载字节数 Bytes loaded
+	StartedAt      time.Time          `json:"startedAt"`                // 开始时间 Start time
+	FinishedAt     time.Time          `json:"finishedAt"`               // 结束时间 Finish time
+	ChunkResults   []*BulkChunkResult `json:"chunkResults"`             // 各分块结果 Per-chunk results
+	RejectedSample []string           `json:"rejectedSample,omitempty"` // 部分被拒绝行的错误 Errors of some rejected lines
+}
+
+// Bulk job status values.
+// 批量任务状态值。
+const (
+	BulkStatusSuccess = "SUCCESS"
+	BulkStatusPartial = "PARTIAL"
+	BulkStatusFailed  = "FAILED"
+)
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"time"
+)
+
+// DeadLetterStage identifies the ingestion stage at which an event was rejected.
+// DeadLetterStage 标识事件在哪个采集阶段被拒绝。
+type DeadLetterStage string
+
+const (
+	// DeadLetterStageValidation 事件未通过校验
+	// DeadLetterStageValidation the event failed validation.
+	DeadLetterStageValidation DeadLetterStage = "VALIDATION"
+	// DeadLetterStageRouting 事件没有可写入的目标表
+	// DeadLetterStageRouting no target table could be resolved for the event.
+	DeadLetterStageRouting DeadLetterStage = "ROUTING"
+	// DeadLetterStageLoad 事件所在批次写入失败
+	// DeadLetterStageLoad the batch carrying the event failed to load.
+	DeadLetterStageLoad DeadLetterStage = "LOAD"
+	// DeadLetterStageFiltered 事件所在批次中有行被StarRocks过滤
+	// DeadLetterStageFiltered StarRocks filtered rows out of the batch carrying the event.
+	DeadLetterStageFiltered DeadLetterStage = "FILTERED"
+)
+
+// DeadLetter is an event that could not be ingested, together with why it was rejected.
+// DeadLetter 是无法被采集的事件及其被拒绝的原因。
+type DeadLetter struct {
+	// ID 死信记录的唯一标识符
+	// ID Unique identifier of the dead-letter record.
+	ID string `json:"id"`
+
+	// Event 原始事件
+	// Event The original event.
+	Event *RawEvent `json:"event"`
+
+	// Stage 事件被拒绝的阶段
+	// Stage The stage at which the event was rejected.
+	Stage DeadLetterStage `json:"stage"`
+
+	// Reason 失败原因
+	// Reason Failure reason.
+	Reason string `json:"reason"`
+
+	// Table (可选) 目标表
+	// Table (Optional) Target table.
+	Table string `json:"table,omitempty"`
+
+	// Label (可选) 写入批次的标签
+	// Label (Optional) Label of the load batch.
+	Label string `json:"label,omitempty"`
+
+	// ErrorURL (可选) StarRocks返回的错误日志URL
+	// ErrorURL (Optional) Error log URL returned by StarRocks.
+	ErrorURL string `json:"errorUrl,omitempty"`
+
+	// ErrorDetails (可选) 从ErrorURL获取的错误日志内容
+	// ErrorDetails (Optional) Error log content fetched from ErrorURL.
+	ErrorDetails string `json:"errorDetails,omitempty"`
+
+	// Partial 事件所在批次已部分写入，该事件可能已被存储，因此重放时跳过
+	// Partial The batch carrying the event was partially loaded, so the event may already be stored and is
+	// skipped by replay.
+	Partial bool `json:"partial,omitempty"`
+
+	// CreatedAt 记录创建时间
+	// CreatedAt Time the record was created.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DeadLetterFilter narrows a dead-letter listing. Empty fields match everything.
+// DeadLetterFilter 用于筛选死信列表，空字段匹配所有记录。
+type DeadLetterFilter struct {
+	DataSourceID string          `json:"dataSourceId,omitempty" form:"dataSourceId"` // 数据来源 Data source
+	DataType     string          `json:"dataType,omitempty" form:"dataType"`         // 数据类型 Data type
+	Stage        DeadLetterStage `json:"stage,omitempty" form:"stage"`               // 失败阶段 Failure stage
+}
+
+// Matches reports whether the dead letter satisfies the filter.
+// Matches 判断死信是否满足筛选条件。
+func (f *DeadLetterFilter) Matches(dl *DeadLetter) bool {
+	if f == nil {
+		return true
+	}
+	if f.Stage != "" && f.Stage != dl.Stage {
+		return false
+	}
+	if dl.Event == nil {
+		return f.DataSourceID == "" && f.DataType == ""
+	}
+	if f.DataSourceID != "" && f.DataSourceID != dl.Event.DataSourceID {
+		return false
+	}
+	if f.DataType != "" && f.DataType != dl.Event.DataType {
+		return false
+	}
+	return true
+}
+
+// ReplayResult summarises a dead-letter replay.
+// ReplayResult 汇总一次死信重放的结果。
+type ReplayResult struct {
+	Requested        int      `json:"requested"`          // 请求重放的记录数 Records requested
+	NotFound         []string `json:"notFound,omitempty"` // 未找到的记录ID IDs that were not found
+	Ingested         int      `json:"ingested"`           // 重新采集成功的事件数 Events ingested successfully
+	PersistFailed    int      `json:"persistFailed"`      // 再次写入失败的事件数 Events that failed to persist again
+	ValidationFailed int      `json:"validationFailed"`   // 再次校验失败的事件数 Events that failed validation again
+	Duplicates       int      `json:"duplicates"`         // 作为重复事件被丢弃的事件数 Events dropped as duplicates
+	Kept             []string `json:"kept,omitempty"`     // 事件未落定而保留的记录ID IDs kept because their events were not settled
+	Skipped          []string `json:"skipped,omitempty"`  // 批次已部分写入而跳过的记录ID IDs skipped because their batch was partially loaded
+}
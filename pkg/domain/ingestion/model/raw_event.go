@@ -23,6 +23,16 @@ type RawEvent struct {
 	// Timestamp Timestamp of when the event occurred.
 	Timestamp time.Time `json:"timestamp"`
 
+	// TimestampDefaulted 为true表示客户端未提供Timestamp，其值为平台填入的默认值，不属于事件内容
+	// TimestampDefaulted When true, the client did not send Timestamp and it holds a default filled in by the
+	// platform, which is not part of the event content.
+	TimestampDefaulted bool `json:"timestampDefaulted,omitempty"`
+
+	// ClampedTimestamp (可选) 事件时间策略钳制后的时间戳，非零时代替Timestamp写入，Timestamp保留客户端发送的值
+	// ClampedTimestamp (Optional) Timestamp clamped by the event time policy. When set it is stored in place of
+	// Timestamp, which keeps the value the client sent.
+	ClampedTimestamp time.Time `json:"clampedTimestamp,omitempty"`
+
 	// Data 事件的具体内容，通常是map[string]interface{}形式的结构化数据
 	// Data The actual content of the event, typically structured data in map[string]interface{} form.
 	Data map[string]interface{} `json:"data"`
@@ -38,6 +48,11 @@ type RawEvent struct {
 	// ReceivedAt (可选) DataSeaP平台接收到此事件的时间戳
 	// ReceivedAt (Optional) Timestamp when DataSeaP platform received this event.
 	ReceivedAt time.Time `json:"receivedAt,omitempty"`
+
+	// Transformed 为true表示Data已经过字段转换。死信与缓冲消息保留该标记，使重放时不会再次转换
+	// Transformed When true, Data has already been through the field transformations. Dead letters and buffered
+	// messages keep the mark, so that a replayed event is not transformed twice.
+	Transformed bool `json:"transformed,omitempty"`
 }
 
 // Validate performs basic validation on the RawEvent.
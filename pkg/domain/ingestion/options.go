@@ -0,0 +1,94 @@
+package ingestion
+
+import (
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/deadletter"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/dedup"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/parser"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/ratelimit"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/schema"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/transform"
+)
+
+// options holds the optional collaborators shared by the ingestion service and the sink worker.
+// options 保存采集服务与Sink工作者共用的可选依赖。
+type options struct {
+	deadLetters deadletter.Store
+	schemas     *schema.Registry
+	transformer *transform.Transformer
+	parsers     *parser.Registry
+	limiter     *ratelimit.Limiter
+	dedup       *dedup.Cache
+	ddl         starrocks.DDLExecutor
+}
+
+// Option configures optional behaviour of the ingestion service and sink worker.
+// Option 配置采集服务与Sink工作者的可选行为。
+type Option func(*options)
+
+// WithDeadLetterStore records rejected events in the given dead-letter store.
+// WithDeadLetterStore 将被拒绝的事件记录到给定的死信存储中。
+func WithDeadLetterStore(store deadletter.Store) Option {
+	return func(o *options) {
+		o.deadLetters = store
+	}
+}
+
+// WithSchemaRegistry type-checks and coerces event data against the schema registered for its DataType.
+// WithSchemaRegistry 按DataType注册的模式对事件数据进行类型检查和转换。
+func WithSchemaRegistry(registry *schema.Registry) Option {
+	return func(o *options) {
+		o.schemas = registry
+	}
+}
+
+// WithTransformer applies per-data-source field transformations to events before they are validated.
+// WithTransformer 在事件校验之前对其应用按数据源配置的字段转换。
+func WithTransformer(transformer *transform.Transformer) Option {
+	return func(o *options) {
+		o.transformer = transformer
+	}
+}
+
+// WithPayloadParsers decodes the RawPayload of events into Data using the parser bound to their DataType.
+// WithPayloadParsers 使用绑定到事件DataType的解析器将其RawPayload解码为Data。
+func WithPayloadParsers(registry *parser.Registry) Option {
+	return func(o *options) {
+		o.parsers = registry
+	}
+}
+
+// WithRateLimiter rejects requests over the per-data-source event rate or the in-flight byte budget with RateLimitExceeded.
+// WithRateLimiter 以RateLimitExceeded拒绝超出数据源事件速率或在途字节预算的请求。
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
+
+// WithDeduplication drops events whose ID, or content hash when they have no ID, was already ingested within the cache window.
+// WithDeduplication 丢弃其ID（无ID时为内容哈希）在缓存时间窗口内已被采集的事件。
+func WithDeduplication(cache *dedup.Cache) Option {
+	return func(o *options) {
+		o.dedup = cache
+	}
+}
+
+// WithDDLExecutor lets the service create the target table of data types without a table mapping, and add
+// columns for new fields, when cfg.AutoProvision is enabled.
+// WithDDLExecutor 在启用cfg.AutoProvision时，允许服务为没有表映射的数据类型创建目标表，并为新字段添加列。
+func WithDDLExecutor(ddl starrocks.DDLExecutor) Option {
+	return func(o *options) {
+		o.ddl = ddl
+	}
+}
+
+func applyOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
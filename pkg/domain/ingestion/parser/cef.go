@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// Header field names produced by the CEF and LEEF parsers.
+// CEF与LEEF解析器产生的头部字段名。
+const (
+	FieldCEFVersion    = "cef_version"
+	FieldLEEFVersion   = "leef_version"
+	FieldDeviceVendor  = "device_vendor"
+	FieldDeviceProduct = "device_product"
+	FieldDeviceVersion = "device_version"
+	FieldSignatureID   = "signature_id"
+	FieldName          = "name"
+	FieldEventID       = "event_id"
+)
+
+// cefTimeLayouts are the formats CEF allows for the rt/end/start extension fields, besides epoch milliseconds.
+// cefTimeLayouts 是CEF的rt/end/start扩展字段除毫秒时间戳外允许的格式。
+var cefTimeLayouts = []string{
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05 MST",
+	"Jan 02 2006 15:04:05",
+	"Jan 02 15:04:05.000 MST",
+	"Jan 02 15:04:05",
+}
+
+// parseCEF parses "CEF:Version|Vendor|Product|Version|SignatureID|Name|Severity|Extension",
+// optionally preceded by a syslog header. The event time is taken from the rt extension field.
+// parseCEF 解析 "CEF:Version|Vendor|Product|Version|SignatureID|Name|Severity|Extension"，
+// 前面可以带有syslog头部。事件时间取自rt扩展字段。
+func parseCEF(payload []byte) ([]Record, error) {
+	line := strings.TrimSpace(string(payload))
+	start := strings.Index(line, "CEF:")
+	if start < 0 {
+		return nil, errors.New(errors.InvalidArgument, "cef: missing 'CEF:' prefix")
+	}
+	header, extension, err := splitHeader(line[start+len("CEF:"):], 7)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "cef: malformed header")
+	}
+	data := map[string]interface{}{
+		FieldCEFVersion:    header[0],
+		FieldDeviceVendor:  header[1],
+		FieldDeviceProduct: header[2],
+		FieldDeviceVersion: header[3],
+		FieldSignatureID:   header[4],
+		FieldName:          header[5],
+		FieldSeverity:      header[6],
+	}
+	for k, v := range parseCEFExtension(extension) {
+		data[k] = v
+	}
+
+	var ts time.Time
+	if rt, ok := data["rt"].(string); ok {
+		ts = parseDeviceTime(rt, "", cefTimeLayouts)
+	}
+	return []Record{{Data: data, Timestamp: ts, Raw: payload}}, nil
+}
+
+// splitHeader splits n pipe-delimited header fields, unescaping \| and \\, and returns the remainder.
+// splitHeader 拆分n个以竖线分隔的头部字段（处理 \| 与 \\ 转义），并返回剩余部分。
+func splitHeader(s string, n int) ([]string, string, error) {
+	fields := make([]string, 0, n)
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\') {
+			i++
+			b.WriteByte(s[i])
+			continue
+		}
+		if c == '|' {
+			fields = append(fields, b.String())
+			b.Reset()
+			if len(fields) == n {
+				return fields, s[i+1:], nil
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return nil, "", errors.Newf(errors.InvalidArgument, "expected %d header fields, found %d", n, len(fields))
+}
+
+// parseCEFExtension parses space-separated key=value pairs whose values may themselves contain spaces:
+// a value runs until the last space before the next unescaped '='.
+// parseCEFExtension 解析以空格分隔的key=value对，值本身可以包含空格：值一直延续到下一个未转义'='之前的最后一个空格。
+func parseCEFExtension(s string) map[string]interface{} {
+	ext := make(map[string]interface{})
+	s = strings.TrimSpace(s)
+
+	// Positions of unescaped '=' signs.
+	var eqs []int
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '=' {
+			eqs = append(eqs, i)
+		}
+	}
+
+	if len(eqs) == 0 {
+		return ext
+	}
+	key, valueStart := strings.TrimSpace(s[:eqs[0]]), eqs[0]+1
+	for _, eq := range eqs[1:] {
+		sp := strings.LastIndexByte(s[valueStart:eq], ' ')
+		if sp < 0 {
+			continue // An '=' with no key in front of it belongs to the current value.
+		}
+		if key != "" {
+			ext[key] = unescapeCEFValue(strings.TrimSpace(s[valueStart : valueStart+sp]))
+		}
+		key, valueStart = strings.TrimSpace(s[valueStart+sp+1:eq]), eq+1
+	}
+	if key != "" {
+		ext[key] = unescapeCEFValue(strings.TrimSpace(s[valueStart:]))
+	}
+	return ext
+}
+
+func unescapeCEFValue(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseDeviceTime parses epoch milliseconds or one of the layouts (or the explicit format, when given).
+// Unknown formats yield the zero time, leaving the event timestamp to the caller.
+// parseDeviceTime 解析毫秒时间戳或给定格式之一（若指定了format则使用该格式）。无法识别时返回零值，由调用方决定事件时间。
+func parseDeviceTime(value, format string, layouts []string) time.Time {
+	value = strings.TrimSpace(value)
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC()
+	}
+	if format != "" {
+		layouts = []string{format}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			if t.Year() == 0 {
+				t = t.AddDate(time.Now().UTC().Year(), 0, 0)
+			}
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// Names of the built-in payload formats.
+// 内置负载格式的名称。
+const (
+	FormatSyslog     = "syslog"     // 自动识别RFC 3164/5424 Auto-detects RFC 3164 or RFC 5424
+	FormatSyslog3164 = "syslog3164" // BSD syslog
+	FormatSyslog5424 = "syslog5424" // IETF syslog
+	FormatCEF        = "cef"        // ArcSight Common Event Format
+	FormatLEEF       = "leef"       // IBM QRadar Log Event Extended Format
+	FormatNDJSON     = "ndjson"     // 换行分隔的JSON Newline-delimited JSON
+)
+
+// Record is one event decoded from a raw payload.
+// Record 是从原始负载中解码出的一个事件。
+type Record struct {
+	// Data 解码后的字段
+	// Data Decoded fields.
+	Data map[string]interface{}
+
+	// Timestamp (可选) 负载中携带的事件时间，未知时为零值
+	// Timestamp (Optional) Event time carried by the payload, zero when unknown.
+	Timestamp time.Time
+
+	// Raw 产生此记录的负载片段
+	// Raw The part of the payload this record was decoded from.
+	Raw []byte
+
+	// Err 非nil表示该片段解析失败，其余记录仍然有效
+	// Err Non-nil when this part failed to parse; the other records are still valid.
+	Err error
+}
+
+// Parser decodes a raw payload into one or more records. Parsers that decode a single event return
+// an error for malformed input; multi-record parsers report malformed parts through Record.Err.
+// Parser 将原始负载解码为一个或多个记录。单事件解析器对格式错误的输入返回错误；
+// 多记录解析器通过Record.Err报告格式错误的部分。
+type Parser interface {
+	Parse(payload []byte) ([]Record, error)
+}
+
+// ParserFunc adapts a function to the Parser interface.
+// ParserFunc 将函数适配为Parser接口。
+type ParserFunc func(payload []byte) ([]Record, error)
+
+// Parse calls f(payload).
+// Parse 调用 f(payload)。
+func (f ParserFunc) Parse(payload []byte) ([]Record, error) {
+	return f(payload)
+}
+
+// Registry selects the parser of each DataType.
+// Registry 为每个DataType选择解析器。
+type Registry struct {
+	mu        sync.RWMutex
+	formats   map[string]Parser // 格式名 -> 解析器 Format name -> parser
+	dataTypes map[string]string // 小写DataType -> 格式名 Lower-cased DataType -> format name
+}
+
+// NewRegistry creates a registry with the built-in formats, mapping each DataType in dataTypes to a format name.
+// NewRegistry 创建包含内置格式的注册表，并将dataTypes中的每个DataType映射到格式名。
+func NewRegistry(dataTypes map[string]string) (*Registry, error) {
+	r := &Registry{
+		formats: map[string]Parser{
+			FormatSyslog:     ParserFunc(parseSyslog),
+			FormatSyslog3164: ParserFunc(parseRFC3164),
+			FormatSyslog5424: ParserFunc(parseRFC5424),
+			FormatCEF:        ParserFunc(parseCEF),
+			FormatLEEF:       ParserFunc(parseLEEF),
+			FormatNDJSON:     ParserFunc(parseNDJSON),
+		},
+		dataTypes: make(map[string]string, len(dataTypes)),
+	}
+	for dataType, format := range dataTypes {
+		if err := r.Bind(dataType, format); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds or replaces a payload format.
+// Register 添加或替换一种负载格式。
+func (r *Registry) Register(format string, p Parser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formats[strings.ToLower(format)] = p
+}
+
+// Bind selects the format used for payloads of a DataType.
+// Bind 指定某个DataType的负载所使用的格式。
+func (r *Registry) Bind(dataType, format string) error {
+	format = strings.ToLower(format)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.formats[format]; !ok {
+		return errors.Newf(errors.ConfigError, "unknown payload format '%s' for data type '%s'", format, dataType)
+	}
+	r.dataTypes[strings.ToLower(dataType)] = format
+	return nil
+}
+
+// ForDataType returns the parser bound to a DataType.
+// ForDataType 返回绑定到某个DataType的解析器。
+func (r *Registry) ForDataType(dataType string) (Parser, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	format, ok := r.dataTypes[strings.ToLower(dataType)]
+	if !ok {
+		return nil, false
+	}
+	p, ok := r.formats[format]
+	return p, ok
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// leefTimeLayouts are the devTime formats tried when no epoch value is given.
+// leefTimeLayouts 是devTime不是时间戳时依次尝试的格式。
+var leefTimeLayouts = append([]string{time.RFC3339Nano, time.RFC3339}, cefTimeLayouts...)
+
+// parseLEEF parses "LEEF:1.0|Vendor|Product|Version|EventID|attrs" with tab-separated attributes, and
+// "LEEF:2.0|Vendor|Product|Version|EventID|Delimiter|attrs" where Delimiter is a character or a hex
+// code such as "x5E" or "0x5E". The event time is taken from the devTime attribute.
+// parseLEEF 解析属性以制表符分隔的 "LEEF:1.0|Vendor|Product|Version|EventID|attrs"，以及
+// "LEEF:2.0|Vendor|Product|Version|EventID|Delimiter|attrs"，其中Delimiter为字符或如"x5E"、"0x5E"的十六进制编码。
+// 事件时间取自devTime属性。
+func parseLEEF(payload []byte) ([]Record, error) {
+	line := strings.TrimRight(string(payload), "\r\n")
+	start := strings.Index(line, "LEEF:")
+	if start < 0 {
+		return nil, errors.New(errors.InvalidArgument, "leef: missing 'LEEF:' prefix")
+	}
+	line = line[start+len("LEEF:"):]
+
+	version := line
+	if i := strings.IndexByte(line, '|'); i >= 0 {
+		version = line[:i]
+	}
+	headerFields := 5
+	if strings.HasPrefix(version, "2") {
+		headerFields = 6
+	}
+	header, attrs, err := splitHeader(line, headerFields)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "leef: malformed header")
+	}
+
+	delimiter := "\t"
+	if headerFields == 6 && header[5] != "" {
+		delimiter, err = leefDelimiter(header[5])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := map[string]interface{}{
+		FieldLEEFVersion:   header[0],
+		FieldDeviceVendor:  header[1],
+		FieldDeviceProduct: header[2],
+		FieldDeviceVersion: header[3],
+		FieldEventID:       header[4],
+	}
+	for _, attr := range strings.Split(attrs, delimiter) {
+		eq := strings.IndexByte(attr, '=')
+		if eq <= 0 {
+			continue
+		}
+		data[strings.TrimSpace(attr[:eq])] = attr[eq+1:]
+	}
+
+	var ts time.Time
+	if devTime, ok := data["devTime"].(string); ok {
+		ts = parseDeviceTime(devTime, "", leefTimeLayouts)
+	}
+	return []Record{{Data: data, Timestamp: ts, Raw: payload}}, nil
+}
+
+func leefDelimiter(spec string) (string, error) {
+	if len(spec) == 1 {
+		return spec, nil
+	}
+	hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(spec), "0"), "x")
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return "", errors.Newf(errors.InvalidArgument, "leef: invalid delimiter %q", spec)
+	}
+	return string(rune(code)), nil
+}
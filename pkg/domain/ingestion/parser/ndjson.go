@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// parseNDJSON decodes one JSON object per line. Blank lines are skipped and each malformed line is
+// reported through its own Record.Err, so one bad line does not reject the others.
+// parseNDJSON 每行解码一个JSON对象。空行被跳过，每个格式错误的行通过其自身的Record.Err报告，
+// 因此一行错误不会导致其他行被拒绝。
+func parseNDJSON(payload []byte) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(payload)+1)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		raw := append([]byte(nil), line...)
+		var data map[string]interface{}
+		if err := json.Unmarshal(raw, &data); err != nil || data == nil {
+			if err == nil {
+				err = errors.New(errors.InvalidArgument, "not a JSON object")
+			}
+			records = append(records, Record{Raw: raw, Err: errors.Wrapf(err, errors.InvalidArgument, "ndjson line %d", lineNo)})
+			continue
+		}
+		records = append(records, Record{Data: data, Raw: raw})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "ndjson: unreadable payload")
+	}
+	if len(records) == 0 {
+		return nil, errors.New(errors.InvalidArgument, "ndjson: payload contains no records")
+	}
+	return records, nil
+}
@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		payload  string
+		wantErr  bool
+		want     []map[string]interface{} // 每条记录期望包含的字段，nil表示记录解析失败 Fields each record must hold, nil for a failed record
+		wantTime time.Time
+	}{
+		{
+			name:    "rfc5424",
+			format:  FormatSyslog,
+			payload: `<165>1 2024-05-01T12:00:00.123Z fw01 sshd 4321 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication"] Failed password`,
+			want: []map[string]interface{}{{
+				FieldFacility: 20, FieldSeverity: 5, FieldVersion: 1, FieldHostname: "fw01", FieldAppName: "sshd",
+				FieldProcID: "4321", FieldMsgID: "ID47", FieldMessage: "Failed password",
+				FieldStructuredData: map[string]interface{}{"exampleSDID@32473": map[string]interface{}{"iut": "3", "eventSource": `App"lication`}},
+			}},
+			wantTime: time.Date(2024, 5, 1, 12, 0, 0, 123000000, time.UTC),
+		},
+		{
+			name:    "rfc5424 with nil fields",
+			format:  FormatSyslog5424,
+			payload: "<14>1 - - - - - -",
+			want:    []map[string]interface{}{{FieldFacility: 1, FieldSeverity: 6, FieldVersion: 1}},
+		},
+		{
+			name:    "rfc5424 truncated header",
+			format:  FormatSyslog5424,
+			payload: "<14>1 2024-05-01T12:00:00Z host",
+			wantErr: true,
+		},
+		{
+			name:    "rfc5424 unterminated structured data",
+			format:  FormatSyslog5424,
+			payload: `<14>1 - - - - - [id a="b" msg`,
+			wantErr: true,
+		},
+		{
+			name:    "rfc3164",
+			format:  FormatSyslog,
+			payload: "<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8",
+			want: []map[string]interface{}{{
+				FieldFacility: 4, FieldSeverity: 2, FieldHostname: "mymachine", FieldAppName: "su", FieldProcID: "230",
+				FieldMessage: "'su root' failed for lonvick on /dev/pts/8",
+			}},
+		},
+		{
+			name:    "missing PRI",
+			format:  FormatSyslog,
+			payload: "Oct 11 22:14:15 mymachine su: failed",
+			wantErr: true,
+		},
+		{
+			name:    "PRI out of range",
+			format:  FormatSyslog3164,
+			payload: "<192>Oct 11 22:14:15 mymachine su: failed",
+			wantErr: true,
+		},
+		{
+			name:    "cef",
+			format:  FormatCEF,
+			payload: `CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 msg=Detected a\=b in the traffic rt=1714564800000`,
+			want: []map[string]interface{}{{
+				FieldCEFVersion: "0", FieldDeviceVendor: "Security", FieldDeviceProduct: "threatmanager", FieldDeviceVersion: "1.0",
+				FieldSignatureID: "100", FieldName: "worm successfully stopped", FieldSeverity: "10",
+				"src": "10.0.0.1", "dst": "2.1.2.2", "msg": "Detected a=b in the traffic",
+			}},
+			wantTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "cef behind a syslog header with escaped pipes",
+			format:  FormatCEF,
+			payload: `<13>May  1 12:00:00 host CEF:0|Ven\|dor|Prod|1|sig|name|3|`,
+			want:    []map[string]interface{}{{FieldDeviceVendor: "Ven|dor", FieldSeverity: "3"}},
+		},
+		{
+			name:    "cef with too few header fields",
+			format:  FormatCEF,
+			payload: "CEF:0|Security|threatmanager|1.0",
+			wantErr: true,
+		},
+		{
+			name:    "leef 1.0",
+			format:  FormatLEEF,
+			payload: "LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tdevTime=2024-05-01T12:00:00Z",
+			want: []map[string]interface{}{{
+				FieldLEEFVersion: "1.0", FieldDeviceVendor: "Microsoft", FieldEventID: "15345", "src": "192.0.2.0", "dst": "172.50.123.1",
+			}},
+			wantTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "leef 2.0 with a hex delimiter",
+			format:  FormatLEEF,
+			payload: "LEEF:2.0|Lancope|StealthWatch|1.0|41|x5E|src=10.0.1.8^dst=10.0.0.5^sev=5",
+			want:    []map[string]interface{}{{FieldLEEFVersion: "2.0", FieldEventID: "41", "src": "10.0.1.8", "dst": "10.0.0.5", "sev": "5"}},
+		},
+		{
+			name:    "leef 2.0 with an invalid delimiter",
+			format:  FormatLEEF,
+			payload: "LEEF:2.0|Lancope|StealthWatch|1.0|41|xZZ|src=10.0.1.8",
+			wantErr: true,
+		},
+		{
+			name:    "ndjson with a malformed line",
+			format:  FormatNDJSON,
+			payload: "{\"a\":1}\n\n not json\n[1]\n{\"b\":\"x\"}\n",
+			want:    []map[string]interface{}{{"a": float64(1)}, nil, nil, {"b": "x"}},
+		},
+		{
+			name:    "ndjson without records",
+			format:  FormatNDJSON,
+			payload: "\n  \n",
+			wantErr: true,
+		},
+	}
+
+	registry, err := NewRegistry(nil)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := registry.Bind(tt.name, tt.format); err != nil {
+				t.Fatalf("Bind() error = %v", err)
+			}
+			p, ok := registry.ForDataType(tt.name)
+			if !ok {
+				t.Fatalf("ForDataType(%q) found no parser", tt.name)
+			}
+			records, err := p.Parse([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, errors.InvalidArgument) {
+					t.Errorf("Parse() error code = %s, want %s", errors.GetCode(err), errors.InvalidArgument)
+				}
+				return
+			}
+			if len(records) != len(tt.want) {
+				t.Fatalf("Parse() returned %d records, want %d", len(records), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if (records[i].Err != nil) != (want == nil) {
+					t.Errorf("record %d: Err = %v, want failed %v", i, records[i].Err, want == nil)
+					continue
+				}
+				if records[i].Err != nil && !errors.Is(records[i].Err, errors.InvalidArgument) {
+					t.Errorf("record %d: Err code = %s, want %s", i, errors.GetCode(records[i].Err), errors.InvalidArgument)
+				}
+				for field, value := range want {
+					if got := records[i].Data[field]; !reflect.DeepEqual(got, value) {
+						t.Errorf("record %d: field %s = %#v, want %#v", i, field, got, value)
+					}
+				}
+			}
+			if !tt.wantTime.IsZero() && !records[0].Timestamp.Equal(tt.wantTime) {
+				t.Errorf("Parse() timestamp = %s, want %s", records[0].Timestamp, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	tests := []struct {
+		name      string
+		dataTypes map[string]string
+		lookup    string
+		wantErr   bool
+		wantFound bool
+	}{
+		{name: "bound data type", dataTypes: map[string]string{"Firewall": "CEF"}, lookup: "firewall", wantFound: true},
+		{name: "unbound data type", dataTypes: map[string]string{"firewall": "cef"}, lookup: "proxy"},
+		{name: "unknown format", dataTypes: map[string]string{"firewall": "xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRegistry(tt.dataTypes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, found := r.ForDataType(tt.lookup); found != tt.wantFound {
+				t.Errorf("ForDataType(%q) found = %v, want %v", tt.lookup, found, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// Field names produced by the syslog parsers.
+// syslog解析器产生的字段名。
+const (
+	FieldFacility       = "facility"
+	FieldSeverity       = "severity"
+	FieldVersion        = "version"
+	FieldTimestamp      = "timestamp"
+	FieldHostname       = "hostname"
+	FieldAppName        = "app_name"
+	FieldProcID         = "proc_id"
+	FieldMsgID          = "msg_id"
+	FieldStructuredData = "structured_data"
+	FieldMessage        = "message"
+)
+
+const syslogNil = "-"
+
+// parseSyslog detects whether the payload is RFC 5424 (a version digit follows the PRI) or RFC 3164.
+// parseSyslog 检测负载是RFC 5424（PRI之后紧跟版本号）还是RFC 3164。
+func parseSyslog(payload []byte) ([]Record, error) {
+	line := strings.TrimSpace(string(payload))
+	_, rest, err := splitPRI(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 1 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+		return parseRFC5424(payload)
+	}
+	return parseRFC3164(payload)
+}
+
+// parseRFC5424 parses "<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]".
+// parseRFC5424 解析 "<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]"。
+func parseRFC5424(payload []byte) ([]Record, error) {
+	line := strings.TrimRight(string(payload), "\r\n")
+	pri, rest, err := splitPRI(strings.TrimLeft(line, " "))
+	if err != nil {
+		return nil, err
+	}
+	data := priFields(pri)
+
+	// VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID
+	var header [6]string
+	for i := range header {
+		var token string
+		token, rest = nextToken(rest)
+		if token == "" {
+			return nil, errors.New(errors.InvalidArgument, "rfc5424: truncated header")
+		}
+		header[i] = token
+	}
+	version, err := strconv.Atoi(header[0])
+	if err != nil {
+		return nil, errors.Newf(errors.InvalidArgument, "rfc5424: invalid version %q", header[0])
+	}
+	data[FieldVersion] = version
+
+	var ts time.Time
+	if header[1] != syslogNil {
+		ts, err = time.Parse(time.RFC3339Nano, header[1])
+		if err != nil {
+			return nil, errors.Newf(errors.InvalidArgument, "rfc5424: invalid timestamp %q", header[1])
+		}
+		data[FieldTimestamp] = header[1]
+	}
+	setUnlessNil(data, FieldHostname, header[2])
+	setUnlessNil(data, FieldAppName, header[3])
+	setUnlessNil(data, FieldProcID, header[4])
+	setUnlessNil(data, FieldMsgID, header[5])
+
+	if strings.HasPrefix(rest, syslogNil) {
+		rest = rest[1:]
+	} else if strings.HasPrefix(rest, "[") {
+		sd, remaining, err := parseStructuredData(rest)
+		if err != nil {
+			return nil, err
+		}
+		data[FieldStructuredData] = sd
+		rest = remaining
+	} else {
+		return nil, errors.New(errors.InvalidArgument, "rfc5424: missing structured data")
+	}
+	if msg := strings.TrimPrefix(rest, " "); msg != "" {
+		// A UTF-8 BOM may precede the message.
+		data[FieldMessage] = strings.TrimPrefix(msg, "\ufeff")
+	}
+	return []Record{{Data: data, Timestamp: ts, Raw: payload}}, nil
+}
+
+// parseStructuredData parses one or more "[SD-ID PARAM="VALUE" ...]" elements.
+// parseStructuredData 解析一个或多个 "[SD-ID PARAM="VALUE" ...]" 元素。
+func parseStructuredData(s string) (map[string]interface{}, string, error) {
+	sd := make(map[string]interface{})
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		end := strings.IndexAny(s, " ]")
+		if end < 0 {
+			return nil, "", errors.New(errors.InvalidArgument, "rfc5424: unterminated structured data element")
+		}
+		id := s[:end]
+		params := make(map[string]interface{})
+		s = s[end:]
+		for {
+			s = strings.TrimLeft(s, " ")
+			if s == "" {
+				return nil, "", errors.Newf(errors.InvalidArgument, "rfc5424: unterminated structured data element %q", id)
+			}
+			if s[0] == ']' {
+				s = s[1:]
+				break
+			}
+			eq := strings.Index(s, "=\"")
+			if eq <= 0 {
+				return nil, "", errors.Newf(errors.InvalidArgument, "rfc5424: malformed parameter in element %q", id)
+			}
+			name := s[:eq]
+			value, remaining, err := readQuoted(s[eq+2:])
+			if err != nil {
+				return nil, "", errors.Wrapf(err, errors.InvalidArgument, "rfc5424: parameter %q of element %q", name, id)
+			}
+			params[name] = value
+			s = remaining
+		}
+		sd[id] = params
+	}
+	return sd, s, nil
+}
+
+// readQuoted reads a PARAM-VALUE up to the closing quote, unescaping \" \\ and \].
+// readQuoted 读取直到闭合引号的PARAM-VALUE，并处理 \" \\ \] 转义。
+func readQuoted(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\' || s[i+1] == ']') {
+				i++
+				b.WriteByte(s[i])
+				continue
+			}
+			b.WriteByte(c)
+		case '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New(errors.InvalidArgument, "unterminated quoted value")
+}
+
+// parseRFC3164 parses "<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG". The year is not part of the
+// format, so the current year is assumed unless that puts the event more than a day in the future.
+// parseRFC3164 解析 "<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG"。该格式不包含年份，
+// 因此假定为当前年份，除非这会使事件时间超前一天以上。
+func parseRFC3164(payload []byte) ([]Record, error) {
+	line := strings.TrimRight(string(payload), "\r\n")
+	pri, rest, err := splitPRI(strings.TrimLeft(line, " "))
+	if err != nil {
+		return nil, err
+	}
+	data := priFields(pri)
+
+	var ts time.Time
+	const stampLen = len(time.Stamp) // "Jan _2 15:04:05"
+	if len(rest) >= stampLen {
+		if parsed, err := time.Parse(time.Stamp, rest[:stampLen]); err == nil {
+			now := time.Now().UTC()
+			ts = time.Date(now.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, time.UTC)
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			data[FieldTimestamp] = ts.Format(time.RFC3339)
+			rest = strings.TrimLeft(rest[stampLen:], " ")
+
+			var host string
+			host, rest = nextToken(rest)
+			if host != "" {
+				data[FieldHostname] = host
+			}
+		}
+	}
+
+	// TAG is alphanumeric and ends at '[', ':' or a space.
+	tagEnd := strings.IndexAny(rest, "[: ")
+	if tagEnd > 0 && tagEnd <= 48 {
+		data[FieldAppName] = rest[:tagEnd]
+		rest = rest[tagEnd:]
+		if strings.HasPrefix(rest, "[") {
+			if closing := strings.IndexByte(rest, ']'); closing > 0 {
+				data[FieldProcID] = rest[1:closing]
+				rest = rest[closing+1:]
+			}
+		}
+		rest = strings.TrimPrefix(rest, ":")
+	}
+	data[FieldMessage] = strings.TrimLeft(rest, " ")
+	return []Record{{Data: data, Timestamp: ts, Raw: payload}}, nil
+}
+
+// splitPRI splits "<PRI>rest" and returns the PRI value.
+// splitPRI 拆分 "<PRI>rest" 并返回PRI值。
+func splitPRI(line string) (int, string, error) {
+	if !strings.HasPrefix(line, "<") {
+		return 0, "", errors.New(errors.InvalidArgument, "syslog: missing PRI")
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return 0, "", errors.New(errors.InvalidArgument, "syslog: malformed PRI")
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri > 191 {
+		return 0, "", errors.Newf(errors.InvalidArgument, "syslog: invalid PRI %q", line[1:end])
+	}
+	return pri, line[end+1:], nil
+}
+
+func priFields(pri int) map[string]interface{} {
+	return map[string]interface{}{
+		FieldFacility: pri / 8,
+		FieldSeverity: pri % 8,
+	}
+}
+
+// nextToken returns the next space-delimited token and the remainder after its trailing space.
+// nextToken 返回下一个以空格分隔的词元以及其后空格之后的剩余部分。
+func nextToken(s string) (string, string) {
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+func setUnlessNil(data map[string]interface{}, key, value string) {
+	if value != syslogNil {
+		data[key] = value
+	}
+}
@@ -0,0 +1,97 @@
+package ingestion
+
+import (
+	"fmt"
+
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/parser"
+)
+
+// parseFailure is an event whose RawPayload could not be decoded.
+// parseFailure 是RawPayload无法解码的事件。
+type parseFailure struct {
+	event *model.RawEvent
+	err   error
+}
+
+// decodePayloads fills Data from RawPayload for events whose DataType has a parser. Fields already present
+// in Data take precedence over decoded ones. A payload decoding to several records (e.g. NDJSON) is expanded
+// into one event per record, sharing the envelope of the original event, and recorded in track as derived from it.
+// decodePayloads 为DataType配置了解析器的事件从RawPayload填充Data，Data中已有的字段优先于解码出的字段。
+// 解码为多条记录的负载（如NDJSON）会展开为每条记录一个事件，共享原事件的信封信息，并在track中记录为派生自原事件。
+func decodePayloads(parsers *parser.Registry, events []*model.RawEvent, track *settlement) ([]*model.RawEvent, []parseFailure) {
+	if parsers == nil {
+		return events, nil
+	}
+	decoded := make([]*model.RawEvent, 0, len(events))
+	var failures []parseFailure
+	for _, event := range events {
+		if event == nil || len(event.RawPayload) == 0 {
+			decoded = append(decoded, event)
+			continue
+		}
+		p, ok := parsers.ForDataType(event.DataType)
+		if !ok {
+			decoded = append(decoded, event)
+			continue
+		}
+		records, err := p.Parse(event.RawPayload)
+		if err != nil {
+			failures = append(failures, parseFailure{event: event, err: err})
+			continue
+		}
+		for i, record := range records {
+			e := event
+			if len(records) > 1 {
+				e = deriveEvent(event, i, record.Raw)
+				track.derive(event, e)
+			}
+			if record.Err != nil {
+				failures = append(failures, parseFailure{event: e, err: record.Err})
+				continue
+			}
+			mergeRecord(e, record)
+			decoded = append(decoded, e)
+		}
+	}
+	return decoded, failures
+}
+
+// deriveEvent copies the envelope of event for the i-th record of its payload.
+// deriveEvent 为负载中的第i条记录复制event的信封信息。
+func deriveEvent(event *model.RawEvent, i int, raw []byte) *model.RawEvent {
+	e := &model.RawEvent{
+		DataSourceID:       event.DataSourceID,
+		DataType:           event.DataType,
+		Timestamp:          event.Timestamp,
+		TimestampDefaulted: event.TimestampDefaulted,
+		RawPayload:         raw,
+		Tags:               event.Tags,
+		ReceivedAt:         event.ReceivedAt,
+	}
+	if event.ID != "" {
+		e.ID = fmt.Sprintf("%s-%d", event.ID, i)
+	}
+	if event.Data != nil {
+		e.Data = make(map[string]interface{}, len(event.Data))
+		for k, v := range event.Data {
+			e.Data[k] = v
+		}
+	}
+	return e
+}
+
+func mergeRecord(event *model.RawEvent, record parser.Record) {
+	if event.Data == nil {
+		event.Data = record.Data
+	} else {
+		for k, v := range record.Data {
+			if _, exists := event.Data[k]; !exists {
+				event.Data[k] = v
+			}
+		}
+	}
+	if (event.Timestamp.IsZero() || event.TimestampDefaulted) && !record.Timestamp.IsZero() {
+		event.Timestamp, event.TimestampDefaulted = record.Timestamp, false
+	}
+}
@@ -0,0 +1,439 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// Column types of automatically provisioned tables.
+// 自动创建的表所使用的列类型。
+const (
+	provisionTypeBoolean  = "BOOLEAN"
+	provisionTypeBigInt   = "BIGINT"
+	provisionTypeDouble   = "DOUBLE"
+	provisionTypeString   = "VARCHAR(65533)"
+	provisionTypeJSON     = "JSON"
+	provisionTypeDateTime = "DATETIME"
+	provisionTypeID       = "VARCHAR(256)"
+)
+
+// provisionColumnName matches the field names that can be used as column names as they are.
+// provisionColumnName 匹配可以直接用作列名的字段名。
+var provisionColumnName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+// provisionTableUnsafeChars matches runs of characters not allowed in provisioned table names.
+// provisionTableUnsafeChars 匹配自动创建的表名中不允许出现的连续字符。
+var provisionTableUnsafeChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// tableProvisioner creates the target table of data types without a table mapping, inferring its columns from
+// the events, and adds columns when new fields appear. A nil *tableProvisioner provisions nothing.
+// tableProvisioner 为没有表映射的数据类型创建目标表，其列由事件推断，并在出现新字段时添加列。
+// nil的*tableProvisioner不创建任何表。
+type tableProvisioner struct {
+	ddl      starrocks.DDLExecutor
+	database string
+	cfg      config.AutoProvisionConfig
+
+	ddlMu  sync.Mutex // 串行化DDL，避免并发请求重复建表或加列 Serializes DDL so concurrent requests do not create tables or add columns twice
+	mu     sync.RWMutex
+	tables map[string]*provisionedTable // 小写DataType -> 表 Lower-cased DataType -> table
+}
+
+// provisionedTable is a table known to the provisioner. columns is only written while holding both ddlMu and mu,
+// so holding either is enough to read it.
+// provisionedTable 是provisioner已知的表。columns只在同时持有ddlMu与mu时写入，因此持有其一即可读取。
+type provisionedTable struct {
+	name    string
+	columns map[string]string // 小写列名 -> 列类型类别，空表示接受任意值 Lower-cased column name -> type class, empty accepts any value
+}
+
+// inferredField is a data field and the column type inferred from its values.
+// inferredField 是一个数据字段及由其值推断出的列类型。
+type inferredField struct {
+	name string
+	typ  string
+	text bool // 值中含有空白，按自由文本建立倒排索引 Values contain whitespace and get an inverted index as free text
+}
+
+// newTableProvisioner creates the provisioner of the configuration, or returns nil when provisioning is disabled.
+// newTableProvisioner 根据配置创建provisioner，未启用自动建表时返回nil。
+func newTableProvisioner(ddl starrocks.DDLExecutor, cfg config.IngestionConfig) *tableProvisioner {
+	if !cfg.AutoProvision.Enabled {
+		return nil
+	}
+	if ddl == nil {
+		logger.L().Warnw("Automatic table provisioning is enabled but no DDL executor is configured, disabling it")
+		return nil
+	}
+	return &tableProvisioner{
+		ddl:      ddl,
+		database: cfg.Database,
+		cfg:      cfg.AutoProvision,
+		tables:   make(map[string]*provisionedTable),
+	}
+}
+
+// table returns the table provisioned for a data type by an earlier ensure.
+// table 返回先前ensure为数据类型创建的表。
+func (p *tableProvisioner) table(dataType string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	known, ok := p.tables[strings.ToLower(dataType)]
+	if !ok {
+		return "", false
+	}
+	return known.name, true
+}
+
+// ensure makes sure the table of a data type exists, creating it from the fields of events when it does not,
+// and adds a column for each field of events the table lacks. It returns the table, which is empty if the
+// events cannot be routed, the names of the columns added, and the events with a value that does not fit the
+// type of its column, keyed to the reason. Those are left out when inferring the columns to add.
+// DDL is serialized, but a batch whose fields all have a column only takes the read lock.
+// ensure 确保数据类型的表存在，不存在时根据events的字段创建，并为表中缺少的事件字段添加列。
+// 返回表名（事件无法路由时为空）、新增的列名，以及字段值与其列类型不符的事件及原因，推断需添加的列时不考虑这些事件。
+// DDL是串行执行的，但所有字段都已有列的批次只需获取读锁。
+func (p *tableProvisioner) ensure(ctx context.Context, dataType string, events []*model.RawEvent) (table string, added []string, conflicts map[*model.RawEvent]string, err error) {
+	l := logger.L().With("method", "ensureTable", "data_type", dataType)
+	key := strings.ToLower(dataType)
+
+	p.mu.RLock()
+	known, ok := p.tables[key]
+	var missing []inferredField
+	if ok {
+		conflicts, missing = known.plan(events)
+	}
+	p.mu.RUnlock()
+	if ok && (len(missing) == 0 || !p.cfg.AddColumns) {
+		return known.name, nil, conflicts, nil
+	}
+
+	p.ddlMu.Lock()
+	defer p.ddlMu.Unlock()
+	// Another request may have provisioned the table or added the columns while waiting for ddlMu.
+	// 等待ddlMu期间，其他请求可能已经创建了表或添加了列。
+	p.mu.RLock()
+	known, ok = p.tables[key]
+	p.mu.RUnlock()
+	if !ok {
+		name := p.tableName(dataType)
+		if !provisionColumnName.MatchString(name) {
+			return "", nil, nil, errors.Newf(errors.InvalidArgument, "data type %q does not give a valid table name", dataType)
+		}
+		columns, err := p.loadOrCreate(ctx, dataType, name, inferFields(events))
+		if err != nil {
+			return "", nil, nil, err
+		}
+		known = &provisionedTable{name: name, columns: columns}
+		p.mu.Lock()
+		p.tables[key] = known
+		p.mu.Unlock()
+	}
+	conflicts, missing = known.plan(events)
+	if !p.cfg.AddColumns || len(missing) == 0 {
+		return known.name, nil, conflicts, nil
+	}
+
+	ddl, err := starrocks.BuildAddColumnsDDL(p.database, known.name, columnDefs(missing))
+	if err != nil {
+		return known.name, nil, conflicts, err
+	}
+	if err := p.ddl.AlterTable(ctx, p.database, known.name, ddl); err != nil {
+		// The events are still loaded; StarRocks ignores the fields without a column.
+		// 事件仍会被写入，StarRocks会忽略没有对应列的字段。
+		return known.name, nil, conflicts, errors.Wrapf(err, errors.DatabaseError, "failed to add columns to table %s", known.name)
+	}
+	p.mu.Lock()
+	for _, field := range missing {
+		known.columns[strings.ToLower(field.name)] = columnTypeClass(field.typ)
+		added = append(added, field.name)
+	}
+	p.mu.Unlock()
+	l.Infow("Added columns to provisioned table", "table", known.name, "columns", added)
+	for _, index := range p.invertedIndexes(missing) {
+		if err := p.ddl.CreateIndex(ctx, p.database, known.name, &index); err != nil {
+			l.Warnw("Failed to create inverted index on added column", "table", known.name, "index", index.IndexName, "error", err)
+		}
+	}
+	return known.name, added, conflicts, nil
+}
+
+// plan returns the events with a value that does not fit the type of its column, keyed to the reason, and the
+// inferred fields of the other events the table has no column for. The caller holds ddlMu or mu.
+// plan 返回字段值与其列类型不符的事件及原因，以及其余事件中表没有对应列的推断字段。调用方需持有ddlMu或mu。
+func (t *provisionedTable) plan(events []*model.RawEvent) (conflicts map[*model.RawEvent]string, missing []inferredField) {
+	accepted := events
+	for i, event := range events {
+		reason := t.conflict(event)
+		if reason == "" {
+			if conflicts != nil {
+				accepted = append(accepted, event)
+			}
+			continue
+		}
+		if conflicts == nil {
+			conflicts = make(map[*model.RawEvent]string)
+			accepted = append([]*model.RawEvent(nil), events[:i]...)
+		}
+		conflicts[event] = reason
+	}
+	for _, field := range inferFields(accepted) {
+		if _, ok := t.columns[strings.ToLower(field.name)]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	return conflicts, missing
+}
+
+// conflict describes the fields of an event whose value does not fit the type of their column, or returns "".
+// conflict 描述事件中值与其列类型不符的字段，没有时返回""。
+func (t *provisionedTable) conflict(event *model.RawEvent) string {
+	var fields []string
+	for name, value := range event.Data {
+		column, ok := t.columns[strings.ToLower(name)]
+		if !ok || implicitColumns[strings.ToLower(name)] {
+			continue
+		}
+		typ, _, ok := inferColumnType(value)
+		if ok && !fitsColumn(column, typ) {
+			fields = append(fields, fmt.Sprintf("%s is %s but its column is %s", name, typ, column))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("value does not match the column type of provisioned table %s: %s", t.name, strings.Join(fields, "; "))
+}
+
+// loadOrCreate returns the columns of an existing table, or creates the table and returns its columns.
+// loadOrCreate 返回已存在的表的列，或创建该表并返回其列。
+func (p *tableProvisioner) loadOrCreate(ctx context.Context, dataType, name string, fields []inferredField) (map[string]string, error) {
+	if existing, err := p.ddl.GetTableSchema(ctx, p.database, name); err == nil && len(existing.Fields) > 0 {
+		return columnSet(existing.Fields), nil
+	}
+
+	schema := &starrocks.TableSchemaDef{
+		DatabaseName:        p.database,
+		TableName:           name,
+		Fields:              append(envelopeColumns(), columnDefs(fields)...),
+		Indexes:             p.invertedIndexes(fields),
+		KeysType:            starrocks.KeysTypeDuplicate,
+		Partition:           &starrocks.PartitionDef{Type: starrocks.PartitionTypeExpression, Expression: fmt.Sprintf("date_trunc('day', %s)", ColumnEventTime)},
+		DistributionColumns: []string{ColumnDataSourceID},
+		Buckets:             p.cfg.Buckets,
+		Properties:          p.cfg.Properties,
+		Comment:             "Provisioned for data type " + dataType,
+		IfNotExists:         true,
+	}
+	if err := p.ddl.CreateTable(ctx, schema); err != nil {
+		return nil, errors.Wrapf(err, errors.DatabaseError, "failed to provision table %s for data type %s", name, dataType)
+	}
+	logger.L().Infow("Provisioned table for new data type", "data_type", dataType, "table", name, "columns", len(schema.Fields))
+
+	// IF NOT EXISTS keeps a table created concurrently elsewhere, so read back what was actually created.
+	// IF NOT EXISTS会保留其他地方并发创建的表，因此读回实际创建的表结构。
+	if created, err := p.ddl.GetTableSchema(ctx, p.database, name); err == nil && len(created.Fields) > 0 {
+		return columnSet(created.Fields), nil
+	}
+	return columnSet(schema.Fields), nil
+}
+
+func (p *tableProvisioner) tableName(dataType string) string {
+	name := strings.Trim(provisionTableUnsafeChars.ReplaceAllString(strings.ToLower(dataType), "_"), "_")
+	if name == "" {
+		return ""
+	}
+	return p.cfg.TablePrefix + name
+}
+
+// invertedIndexes returns the inverted indexes of the free-text fields, if they are enabled.
+// invertedIndexes 在启用时返回自由文本字段的倒排索引。
+func (p *tableProvisioner) invertedIndexes(fields []inferredField) []starrocks.IndexDefinitionDef {
+	if !p.cfg.InvertedIndexes {
+		return nil
+	}
+	parser := p.cfg.InvertedIndexParser
+	if parser == "" {
+		parser = constants.IngestionDefaultInvertedIndexParser
+	}
+	var indexes []starrocks.IndexDefinitionDef
+	for _, field := range fields {
+		if field.text {
+			indexes = append(indexes, starrocks.IndexDefinitionDef{
+				IndexName:  "idx_" + strings.ToLower(field.name),
+				IndexType:  "INVERTED",
+				Fields:     []string{field.name},
+				Properties: map[string]string{"parser": parser},
+			})
+		}
+	}
+	return indexes
+}
+
+// envelopeColumns are the leading columns of every provisioned table, filled in from the RawEvent envelope.
+// event_time and data_source_id lead the sort key, as most queries filter on them.
+// envelopeColumns 是每个自动创建的表的前导列，由RawEvent信封填充。
+// event_time与data_source_id位于排序键前部，因为大多数查询都按它们过滤。
+func envelopeColumns() []starrocks.FieldSchemaDef {
+	return []starrocks.FieldSchemaDef{
+		{Name: ColumnEventTime, Type: provisionTypeDateTime, IsKey: true},
+		{Name: ColumnDataSourceID, Type: provisionTypeID, IsKey: true},
+		{Name: ColumnEventID, Type: provisionTypeID, IsNullable: true},
+		{Name: ColumnReceivedAt, Type: provisionTypeDateTime, IsNullable: true},
+	}
+}
+
+// inferFields infers a column type for each data field of the events, sorted by name. Fields whose name is not
+// a valid column name, envelope columns, and fields that are only ever null are skipped. A field seen with both
+// integral and fractional numbers is a DOUBLE; any other conflict falls back to a string.
+// inferFields 为事件的每个数据字段推断列类型，并按名称排序。名称不是合法列名的字段、信封列以及始终为null的字段
+// 会被跳过。同时出现整数与小数的字段推断为DOUBLE，其他类型冲突退化为字符串。
+func inferFields(events []*model.RawEvent) []inferredField {
+	byName := make(map[string]*inferredField)
+	for _, event := range events {
+		for name, value := range event.Data {
+			if implicitColumns[strings.ToLower(name)] || !provisionColumnName.MatchString(name) {
+				continue
+			}
+			typ, text, ok := inferColumnType(value)
+			if !ok {
+				continue
+			}
+			key := strings.ToLower(name)
+			field, seen := byName[key]
+			if !seen {
+				byName[key] = &inferredField{name: name, typ: typ, text: text}
+				continue
+			}
+			field.typ = mergeColumnTypes(field.typ, typ)
+			field.text = field.text || text
+		}
+	}
+
+	fields := make([]inferredField, 0, len(byName))
+	for _, field := range byName {
+		if field.typ != provisionTypeString {
+			field.text = false
+		}
+		fields = append(fields, *field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return strings.ToLower(fields[i].name) < strings.ToLower(fields[j].name) })
+	return fields
+}
+
+// inferColumnType returns the column type of a decoded JSON value, and whether it is free text. ok is false for null.
+// inferColumnType 返回已解码JSON值的列类型及其是否为自由文本，值为null时ok为false。
+func inferColumnType(v interface{}) (typ string, text bool, ok bool) {
+	switch t := v.(type) {
+	case nil:
+		return "", false, false
+	case bool:
+		return provisionTypeBoolean, false, true
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<63 {
+			return provisionTypeBigInt, false, true
+		}
+		return provisionTypeDouble, false, true
+	case float32:
+		return provisionTypeDouble, false, true
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		return provisionTypeBigInt, false, true
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return provisionTypeBigInt, false, true
+		}
+		return provisionTypeDouble, false, true
+	case string:
+		return provisionTypeString, strings.ContainsAny(t, " \t\r\n"), true
+	case map[string]interface{}, []interface{}:
+		return provisionTypeJSON, false, true
+	default:
+		return provisionTypeString, false, true
+	}
+}
+
+func mergeColumnTypes(a, b string) string {
+	switch {
+	case a == b:
+		return a
+	case (a == provisionTypeBigInt || a == provisionTypeDouble) && (b == provisionTypeBigInt || b == provisionTypeDouble):
+		return provisionTypeDouble
+	default:
+		return provisionTypeString
+	}
+}
+
+// columnDefs returns the nullable column definitions of inferred fields.
+// columnDefs 返回推断字段的可空列定义。
+func columnDefs(fields []inferredField) []starrocks.FieldSchemaDef {
+	defs := make([]starrocks.FieldSchemaDef, len(fields))
+	for i, field := range fields {
+		defs[i] = starrocks.FieldSchemaDef{Name: field.name, Type: field.typ, IsNullable: true}
+	}
+	return defs
+}
+
+// columnSet returns the type class of each column, keyed by lower-cased column name.
+// columnSet 返回每一列的类型类别，以小写列名为键。
+func columnSet(fields []starrocks.FieldSchemaDef) map[string]string {
+	columns := make(map[string]string, len(fields))
+	for _, field := range fields {
+		columns[strings.ToLower(field.Name)] = columnTypeClass(field.Type)
+	}
+	return columns
+}
+
+// columnTypeClass maps a StarRocks column type, as created or as reported by DESCRIBE, to the inferred type its
+// values are checked against. Types without a class accept any value.
+// columnTypeClass 将StarRocks列类型（建表时的或DESCRIBE返回的）映射为检查其值所用的推断类型，没有类别的类型接受任意值。
+func columnTypeClass(typ string) string {
+	base := strings.ToUpper(strings.TrimSpace(typ))
+	if i := strings.IndexByte(base, '('); i >= 0 {
+		base = base[:i]
+	}
+	switch base {
+	case "BOOLEAN":
+		return provisionTypeBoolean
+	case "TINYINT", "SMALLINT", "INT", "INTEGER", "BIGINT", "LARGEINT":
+		return provisionTypeBigInt
+	case "FLOAT", "DOUBLE", "DECIMAL", "DECIMALV2", "DECIMAL32", "DECIMAL64", "DECIMAL128":
+		return provisionTypeDouble
+	case "DATE", "DATETIME":
+		return provisionTypeDateTime
+	default:
+		return ""
+	}
+}
+
+// fitsColumn reports whether a value of inferred type typ can be loaded into a column of type class column.
+// Strings and JSON hold any value, and date-time columns parse strings.
+// fitsColumn 报告推断类型为typ的值能否写入类型类别为column的列。字符串与JSON列可容纳任意值，日期时间列会解析字符串。
+func fitsColumn(column, typ string) bool {
+	switch column {
+	case "", provisionTypeString, provisionTypeJSON:
+		return true
+	case provisionTypeDouble:
+		return typ == provisionTypeDouble || typ == provisionTypeBigInt
+	case provisionTypeDateTime:
+		return typ == provisionTypeString
+	default:
+		return column == typ
+	}
+}
@@ -0,0 +1,246 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// fakeProvisionDDL serves the tables it created, or existing, and records the DDL it is asked to run.
+type fakeProvisionDDL struct {
+	starrocks.DDLExecutor
+	existing *starrocks.TableSchemaDef
+	created  []*starrocks.TableSchemaDef
+	altered  []string
+}
+
+func (f *fakeProvisionDDL) GetTableSchema(_ context.Context, _, _ string) (*starrocks.TableSchemaDef, error) {
+	if f.existing != nil {
+		return f.existing, nil
+	}
+	if len(f.created) > 0 {
+		return f.created[len(f.created)-1], nil
+	}
+	return nil, errors.New(errors.NotFoundError, "table not found")
+}
+
+func (f *fakeProvisionDDL) CreateTable(_ context.Context, schema *starrocks.TableSchemaDef) error {
+	f.created = append(f.created, schema)
+	return nil
+}
+
+func (f *fakeProvisionDDL) AlterTable(_ context.Context, _, _, statement string) error {
+	f.altered = append(f.altered, statement)
+	return nil
+}
+
+func (f *fakeProvisionDDL) CreateIndex(_ context.Context, _, _ string, _ *starrocks.IndexDefinitionDef) error {
+	return nil
+}
+
+func TestInferFields(t *testing.T) {
+	event := func(data map[string]interface{}) *model.RawEvent { return &model.RawEvent{Data: data} }
+
+	tests := []struct {
+		name   string
+		events []*model.RawEvent
+		want   []inferredField
+	}{
+		{
+			name: "one type per value",
+			events: []*model.RawEvent{event(map[string]interface{}{
+				"ok": true, "count": float64(3), "ratio": 0.5, "n": json.Number("7"), "host": "web-1",
+				"msg": "connection refused", "attrs": map[string]interface{}{"a": 1}, "tags": []interface{}{"x"},
+			})},
+			want: []inferredField{
+				{name: "attrs", typ: provisionTypeJSON},
+				{name: "count", typ: provisionTypeBigInt},
+				{name: "host", typ: provisionTypeString},
+				{name: "msg", typ: provisionTypeString, text: true},
+				{name: "n", typ: provisionTypeBigInt},
+				{name: "ok", typ: provisionTypeBoolean},
+				{name: "ratio", typ: provisionTypeDouble},
+				{name: "tags", typ: provisionTypeJSON},
+			},
+		},
+		{
+			name: "types merge across events",
+			events: []*model.RawEvent{
+				event(map[string]interface{}{"bytes": float64(10), "code": float64(200), "msg": "a b"}),
+				event(map[string]interface{}{"bytes": 10.5, "code": "OK", "msg": float64(1)}),
+			},
+			want: []inferredField{
+				{name: "bytes", typ: provisionTypeDouble},
+				{name: "code", typ: provisionTypeString},
+				{name: "msg", typ: provisionTypeString, text: true},
+			},
+		},
+		{
+			name: "text only applies to strings",
+			events: []*model.RawEvent{
+				event(map[string]interface{}{"note": "free text"}),
+				event(map[string]interface{}{"note": map[string]interface{}{}}),
+			},
+			want: []inferredField{{name: "note", typ: provisionTypeString, text: true}},
+		},
+		{
+			name: "nulls, envelope columns and invalid names are skipped",
+			events: []*model.RawEvent{
+				event(map[string]interface{}{"gone": nil, ColumnEventTime: "2024-05-01", "data_source_id": "fw", "bad-name": 1, "1st": 1, "Host": "a"}),
+				event(map[string]interface{}{"host": "b"}),
+			},
+			want: []inferredField{{name: "Host", typ: provisionTypeString}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferFields(tt.events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inferFields() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeColumnTypes(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{provisionTypeBigInt, provisionTypeBigInt, provisionTypeBigInt},
+		{provisionTypeBigInt, provisionTypeDouble, provisionTypeDouble},
+		{provisionTypeDouble, provisionTypeBigInt, provisionTypeDouble},
+		{provisionTypeBigInt, provisionTypeString, provisionTypeString},
+		{provisionTypeBoolean, provisionTypeBigInt, provisionTypeString},
+		{provisionTypeJSON, provisionTypeString, provisionTypeString},
+		{provisionTypeJSON, provisionTypeJSON, provisionTypeJSON},
+	}
+	for _, tt := range tests {
+		if got := mergeColumnTypes(tt.a, tt.b); got != tt.want {
+			t.Errorf("mergeColumnTypes(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestProvisionerTableName(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		dataType string
+		want     string
+	}{
+		{"", "firewall_log", "firewall_log"},
+		{"auto_", "Firewall-Log", "auto_firewall_log"},
+		{"auto_", "  dns.query/v2 ", "auto_dns_query_v2"},
+		{"auto_", "--", ""},
+		{"auto_", "日志", ""},
+	}
+	for _, tt := range tests {
+		p := &tableProvisioner{cfg: config.AutoProvisionConfig{TablePrefix: tt.prefix}}
+		if got := p.tableName(tt.dataType); got != tt.want {
+			t.Errorf("tableName(%q) with prefix %q = %q, want %q", tt.dataType, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestProvisionerEnsure(t *testing.T) {
+	event := func(id string, data map[string]interface{}) *model.RawEvent {
+		return &model.RawEvent{ID: id, DataType: "fw", Data: data}
+	}
+
+	type batch struct {
+		events        []*model.RawEvent
+		wantAdded     []string
+		wantConflicts []string // 字段值与列类型不符的事件ID Event IDs whose values do not fit their column
+		wantAltered   int      // 截至此批次的ALTER语句数 ALTER statements up to this batch
+	}
+	tests := []struct {
+		name        string
+		existing    *starrocks.TableSchemaDef
+		batches     []batch
+		wantCreated int
+	}{
+		{
+			name: "new fields are added once",
+			batches: []batch{
+				{events: []*model.RawEvent{event("e1", map[string]interface{}{"port": float64(22)})}},
+				{events: []*model.RawEvent{event("e2", map[string]interface{}{"port": float64(80), "host": "a"})}, wantAdded: []string{"host"}, wantAltered: 1},
+				{events: []*model.RawEvent{event("e3", map[string]interface{}{"port": float64(443), "host": "b"})}, wantAltered: 1},
+			},
+			wantCreated: 1,
+		},
+		{
+			name: "values of another type are rejected",
+			batches: []batch{
+				{events: []*model.RawEvent{event("e1", map[string]interface{}{"port": float64(22), "ratio": 0.5, "ok": true})}},
+				{
+					events: []*model.RawEvent{
+						event("e2", map[string]interface{}{"port": "ssh", "user": "root"}),
+						event("e3", map[string]interface{}{"port": float64(80), "ratio": float64(1)}),
+						event("e4", map[string]interface{}{"ok": float64(1)}),
+						event("e5", map[string]interface{}{"port": 8.5, "host": "a"}),
+					},
+					wantConflicts: []string{"e2", "e4", "e5"},
+				},
+			},
+			wantCreated: 1,
+		},
+		{
+			name: "existing table is checked by its described types",
+			existing: &starrocks.TableSchemaDef{Fields: []starrocks.FieldSchemaDef{
+				{Name: "event_time", Type: "datetime"}, {Name: "Port", Type: "int(11)"}, {Name: "seen", Type: "datetime"},
+				{Name: "msg", Type: "varchar(1024)"}, {Name: "attrs", Type: "json"},
+			}},
+			batches: []batch{{
+				events: []*model.RawEvent{
+					event("e1", map[string]interface{}{"port": float64(1), "seen": "2024-05-01 12:00:00", "msg": float64(1), "attrs": "x"}),
+					event("e2", map[string]interface{}{"seen": float64(1714564800)}),
+					event("e3", map[string]interface{}{"port": "one"}),
+				},
+				wantConflicts: []string{"e2", "e3"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddl := &fakeProvisionDDL{existing: tt.existing}
+			p := newTableProvisioner(ddl, config.IngestionConfig{Database: "logs", AutoProvision: config.AutoProvisionConfig{Enabled: true, TablePrefix: "auto_", AddColumns: true}})
+
+			for i, b := range tt.batches {
+				table, added, conflicts, err := p.ensure(context.Background(), "fw", b.events)
+				if err != nil {
+					t.Fatalf("batch %d: ensure() error = %v", i, err)
+				}
+				if table != "auto_fw" {
+					t.Errorf("batch %d: ensure() table = %q, want auto_fw", i, table)
+				}
+				if !reflect.DeepEqual(added, b.wantAdded) {
+					t.Errorf("batch %d: ensure() added = %v, want %v", i, added, b.wantAdded)
+				}
+				var rejected []string
+				for _, event := range b.events {
+					if reason, ok := conflicts[event]; ok {
+						rejected = append(rejected, event.ID)
+						if !strings.Contains(reason, "auto_fw") {
+							t.Errorf("batch %d: reason %q does not name the table", i, reason)
+						}
+					}
+				}
+				if !reflect.DeepEqual(rejected, b.wantConflicts) {
+					t.Errorf("batch %d: ensure() conflicts = %v, want %v", i, rejected, b.wantConflicts)
+				}
+				if len(ddl.altered) != b.wantAltered {
+					t.Errorf("batch %d: %d ALTER statements, want %d", i, len(ddl.altered), b.wantAltered)
+				}
+			}
+			if len(ddl.created) != tt.wantCreated {
+				t.Errorf("created %d tables, want %d", len(ddl.created), tt.wantCreated)
+			}
+		})
+	}
+}
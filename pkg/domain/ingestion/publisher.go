@@ -0,0 +1,114 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/turtacn/dataseap/pkg/adapter/pulsar"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// PropertyDataType is the Pulsar message property carrying the event DataType.
+// PropertyDataType 是携带事件DataType的Pulsar消息属性名。
+const PropertyDataType = "dataType"
+
+// TopicForDataType returns the buffer topic that events of the given data type are published to.
+// TopicForDataType 返回给定数据类型的事件所发布到的缓冲主题。
+func TopicForDataType(cfg config.IngestionBufferConfig, dataType string) string {
+	prefix := cfg.TopicPrefix
+	if prefix == "" {
+		prefix = constants.IngestionDefaultTopicPrefix
+	}
+	return prefix + strings.ToLower(dataType)
+}
+
+// eventPublisher publishes events to per-data-type Pulsar topics, creating producers lazily.
+// eventPublisher 将事件发布到按数据类型划分的Pulsar主题，并按需创建生产者。
+type eventPublisher struct {
+	client    pulsar.Client
+	cfg       config.IngestionBufferConfig
+	mu        sync.Mutex
+	producers map[string]pulsar.Producer // topic -> producer
+}
+
+// newEventPublisher creates an eventPublisher on top of the given Pulsar client.
+// newEventPublisher 基于给定的Pulsar客户端创建eventPublisher。
+func newEventPublisher(client pulsar.Client, cfg config.IngestionBufferConfig) *eventPublisher {
+	return &eventPublisher{
+		client:    client,
+		cfg:       cfg,
+		producers: make(map[string]pulsar.Producer),
+	}
+}
+
+// producerFor returns the cached producer for a topic, creating it on first use.
+// producerFor 返回主题对应的缓存生产者，首次使用时创建。
+func (p *eventPublisher) producerFor(topic string) (pulsar.Producer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if producer, ok := p.producers[topic]; ok {
+		return producer, nil
+	}
+	producer, err := p.client.CreateProducer(topic, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.producers[topic] = producer
+	return producer, nil
+}
+
+// publish sends the events asynchronously and waits for every send to be acknowledged by the broker.
+// It returns the number of events accepted and the events that could not be published.
+// publish 异步发送事件并等待所有发送被Broker确认，返回发布成功的事件数量及发布失败的事件。
+func (p *eventPublisher) publish(ctx context.Context, dataType string, events []*model.RawEvent) (published int, failed []*model.RawEvent, lastErr error) {
+	l := logger.L().With("method", "publish", "data_type", dataType)
+	topic := TopicForDataType(p.cfg, dataType)
+	producer, err := p.producerFor(topic)
+	if err != nil {
+		l.Errorw("Failed to get producer for buffer topic", "topic", topic, "error", err)
+		return 0, events, err
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			l.Warnw("Failed to serialize event for buffer topic", "event_id", event.ID, "error", err)
+			mu.Lock()
+			failed = append(failed, event)
+			lastErr = errors.Wrap(err, errors.SerializationError, "failed to serialize event")
+			mu.Unlock()
+			continue
+		}
+		msg := &pulsar.ProducerMessage{
+			Payload:    payload,
+			Key:        event.DataSourceID,
+			Properties: map[string]string{PropertyDataType: event.DataType},
+			EventTime:  event.Timestamp,
+		}
+		event := event
+		wg.Add(1)
+		producer.SendAsync(ctx, msg, func(_ pulsar.MessageID, _ *pulsar.ProducerMessage, sendErr error) {
+			defer wg.Done()
+			mu.Lock()
+			defer mu.Unlock()
+			if sendErr != nil {
+				failed = append(failed, event)
+				lastErr = errors.Wrapf(sendErr, errors.NetworkError, "failed to publish event to topic %s", topic)
+				return
+			}
+			published++
+		})
+	}
+	wg.Wait()
+	return published, failed, lastErr
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// bucket is a token bucket refilled at rate tokens per second up to burst tokens.
+// It is not safe for concurrent use; the Limiter serialises access to its buckets.
+// bucket 是以每秒rate个令牌的速度补充、最多容纳burst个令牌的令牌桶。
+// 它不是并发安全的，由Limiter串行化对其的访问。
+type bucket struct {
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time // 上次补充的时间 Time of the last refill
+	lastUsed time.Time // 上次被请求的时间 Time of the last request
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	b := float64(burst)
+	if b <= 0 {
+		b = math.Max(1, math.Ceil(rate))
+	}
+	return &bucket{rate: rate, burst: b, tokens: b, last: now, lastUsed: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+}
+
+// wait returns how long a request for n tokens has to wait, zero if it can be admitted now.
+// A request larger than the burst is admitted once the bucket is full and leaves it in debt,
+// so oversized batches are slowed down rather than rejected forever.
+// wait 返回请求n个令牌需要等待的时间，可立即放行时返回零。
+// 超过突发容量的请求在令牌桶装满时放行并使其透支，因此过大的批次会被减速而不是永远被拒绝。
+func (b *bucket) wait(n float64) time.Duration {
+	need := math.Min(n, b.burst)
+	if b.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *bucket) take(n float64, now time.Time) {
+	b.tokens -= n
+	b.lastUsed = now
+}
+
+// idle reports whether the bucket has been unused for d and is full again, so dropping it loses nothing.
+// idle 报告令牌桶是否已有d时长未被使用且已重新装满，此时丢弃它不会丢失任何状态。
+func (b *bucket) idle(now time.Time, d time.Duration) bool {
+	b.refill(now)
+	return now.Sub(b.lastUsed) >= d && b.tokens >= b.burst
+}
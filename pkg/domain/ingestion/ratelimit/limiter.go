@@ -0,0 +1,218 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/observability/metrics"
+)
+
+// Reasons a request can be rate limited, also used as the "reason" metric label.
+// 请求被限流的原因，同时用作"reason"度量标签。
+const (
+	ReasonSourceRate    = "source_rate"     // 数据源超出事件速率 The data source exceeded its event rate
+	ReasonInFlightBytes = "in_flight_bytes" // 在途字节预算耗尽 The in-flight byte budget is exhausted
+)
+
+// ExceededError describes why a request was rate limited and when it may be retried.
+// Limiter methods return it wrapped in an AppError with code RateLimitExceeded.
+// ExceededError 描述请求被限流的原因以及何时可以重试。
+// Limiter的方法将其包装在错误码为RateLimitExceeded的AppError中返回。
+type ExceededError struct {
+	Reason       string        // ReasonSourceRate 或 ReasonInFlightBytes ReasonSourceRate or ReasonInFlightBytes
+	DataSourceID string        // 超出速率的数据源 Data source over its rate, empty for the byte budget
+	RetryAfter   time.Duration // 建议的重试等待时间 Suggested wait before retrying
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *ExceededError) Error() string {
+	if e.Reason == ReasonSourceRate {
+		return fmt.Sprintf("event rate of data source '%s' exceeded, retry after %s", e.DataSourceID, e.RetryAfter)
+	}
+	return fmt.Sprintf("in-flight byte budget exhausted, retry after %s", e.RetryAfter)
+}
+
+// Exceeded returns the rate limit error anywhere in err's chain.
+// Exceeded 返回err错误链中的限流错误。
+func Exceeded(err error) (*ExceededError, bool) {
+	for err != nil {
+		if exceeded, ok := err.(*ExceededError); ok {
+			return exceeded, true
+		}
+		unwrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = unwrapper.Unwrap()
+	}
+	return nil, false
+}
+
+// RetryAfter returns the retry hint carried by a rate limit error anywhere in err's chain.
+// RetryAfter 返回err错误链中限流错误携带的重试提示。
+func RetryAfter(err error) (time.Duration, bool) {
+	if exceeded, ok := Exceeded(err); ok {
+		return exceeded.RetryAfter, true
+	}
+	return 0, false
+}
+
+type limit struct {
+	rate  float64
+	burst int
+}
+
+// Limiter enforces token-bucket event rates per DataSourceID and a global budget of in-flight bytes.
+// Limiter 按DataSourceID执行令牌桶事件速率限制，并维护全局的在途字节预算。
+type Limiter struct {
+	mu        sync.Mutex
+	defaults  limit
+	overrides map[string]limit
+	buckets   map[string]*bucket
+	idleAfter time.Duration
+	lastSweep time.Time
+
+	maxBytes   int64
+	inFlight   int64
+	retryAfter time.Duration // 字节预算耗尽时的重试提示 Retry hint when the byte budget is exhausted
+
+	now func() time.Time
+}
+
+// New creates a limiter from the rate limit configuration.
+// New 根据限流配置创建限流器。
+func New(cfg config.RateLimitConfig) *Limiter {
+	l := &Limiter{
+		defaults:   limit{rate: cfg.EventsPerSecond, burst: cfg.Burst},
+		overrides:  make(map[string]limit, len(cfg.Sources)),
+		buckets:    make(map[string]*bucket),
+		idleAfter:  time.Duration(cfg.IdleSeconds) * time.Second,
+		maxBytes:   cfg.MaxInFlightBytes,
+		retryAfter: time.Duration(cfg.RetryAfterMillis) * time.Millisecond,
+		now:        time.Now,
+	}
+	for _, src := range cfg.Sources {
+		l.overrides[src.DataSourceID] = limit{rate: src.EventsPerSecond, burst: src.Burst}
+	}
+	if l.idleAfter <= 0 {
+		l.idleAfter = constants.IngestionDefaultRateLimitIdleSeconds * time.Second
+	}
+	if l.retryAfter <= 0 {
+		l.retryAfter = constants.IngestionDefaultRetryAfterMillis * time.Millisecond
+	}
+	l.lastSweep = l.now()
+	return l
+}
+
+// AdmitEvents takes counts[dataSourceID] tokens from the bucket of each data source. The request is admitted
+// as a whole or not at all: if any data source is over its rate, no tokens are taken and the returned error
+// carries the longest wait among the limited sources.
+// AdmitEvents 从每个数据源的令牌桶中取出counts[dataSourceID]个令牌。请求要么整体放行，要么整体拒绝：
+// 任一数据源超出速率时不取出任何令牌，返回的错误携带受限数据源中最长的等待时间。
+func (l *Limiter) AdmitEvents(counts map[string]int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	var exceeded *ExceededError
+	admitted := make(map[*bucket]float64, len(counts))
+	for source, n := range counts {
+		lim := l.limitOf(source)
+		if lim.rate <= 0 || n <= 0 {
+			continue
+		}
+		b, ok := l.buckets[source]
+		if !ok {
+			b = newBucket(lim.rate, lim.burst, now)
+			l.buckets[source] = b
+		}
+		b.refill(now)
+		if wait := b.wait(float64(n)); wait > 0 {
+			if exceeded == nil || wait > exceeded.RetryAfter {
+				exceeded = &ExceededError{Reason: ReasonSourceRate, DataSourceID: source, RetryAfter: wait}
+			}
+			continue
+		}
+		admitted[b] = float64(n)
+	}
+	if exceeded != nil {
+		return errors.Wrap(exceeded, errors.RateLimitExceeded, exceeded.Error())
+	}
+	for b, n := range admitted {
+		b.take(n, now)
+	}
+	return nil
+}
+
+// AcquireBytes reserves n bytes of the in-flight budget until release is called. A request larger than the whole
+// budget is admitted only when nothing else is in flight. release is safe to call more than once.
+// AcquireBytes 预留n字节的在途预算，直到调用release为止。超过整个预算的请求仅在没有其他在途请求时放行。
+// release 可以安全地多次调用。
+func (l *Limiter) AcquireBytes(n int64) (release func(), err error) {
+	if l.maxBytes <= 0 || n <= 0 {
+		return func() {}, nil
+	}
+	if n > l.maxBytes {
+		n = l.maxBytes
+	}
+	l.mu.Lock()
+	if l.inFlight+n > l.maxBytes {
+		l.mu.Unlock()
+		exceeded := &ExceededError{Reason: ReasonInFlightBytes, RetryAfter: l.retryAfter}
+		return nil, errors.Wrap(exceeded, errors.RateLimitExceeded, exceeded.Error())
+	}
+	l.inFlight += n
+	recordInFlight(l.inFlight)
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.inFlight -= n
+			recordInFlight(l.inFlight)
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// InFlightBytes returns the bytes currently reserved.
+// InFlightBytes 返回当前已预留的字节数。
+func (l *Limiter) InFlightBytes() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+func (l *Limiter) limitOf(source string) limit {
+	if lim, ok := l.overrides[source]; ok {
+		return lim
+	}
+	return l.defaults
+}
+
+// sweep drops the buckets of data sources that have been idle for a while, bounding memory for short-lived sources.
+// sweep 丢弃空闲一段时间的数据源的令牌桶，以限制短生命周期数据源占用的内存。
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleAfter {
+		return
+	}
+	l.lastSweep = now
+	for source, b := range l.buckets {
+		if b.idle(now, l.idleAfter) {
+			delete(l.buckets, source)
+		}
+	}
+}
+
+func recordInFlight(bytes int64) {
+	if m := metrics.Current(); m != nil && m.IngestionInFlightBytes != nil {
+		m.IngestionInFlightBytes.Set(float64(bytes))
+	}
+}
@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+)
+
+// fakeClock is a clock advanced by the tests.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestLimiter(cfg config.RateLimitConfig) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(cfg)
+	l.now = clock.Now
+	l.lastSweep = clock.now
+	return l, clock
+}
+
+func TestAdmitEvents(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		EventsPerSecond: 10,
+		Burst:           20,
+		Sources:         []config.SourceRateLimitConfig{{DataSourceID: "noisy", EventsPerSecond: 1, Burst: 1}, {DataSourceID: "trusted"}},
+	}
+	type step struct {
+		advance   time.Duration
+		counts    map[string]int
+		wantErr   bool
+		wantRetry time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name:  "within burst",
+			steps: []step{{counts: map[string]int{"fw": 20}}},
+		},
+		{
+			name: "over burst waits for the refill",
+			steps: []step{
+				{counts: map[string]int{"fw": 15}},
+				{counts: map[string]int{"fw": 10}, wantErr: true, wantRetry: 500 * time.Millisecond},
+				{advance: 500 * time.Millisecond, counts: map[string]int{"fw": 10}},
+			},
+		},
+		{
+			name: "larger than burst is admitted when full and leaves debt",
+			steps: []step{
+				{counts: map[string]int{"fw": 50}},
+				{counts: map[string]int{"fw": 1}, wantErr: true, wantRetry: 3100 * time.Millisecond},
+				{advance: 4 * time.Second, counts: map[string]int{"fw": 1}},
+			},
+		},
+		{
+			name: "per source override",
+			steps: []step{
+				{counts: map[string]int{"noisy": 1}},
+				{counts: map[string]int{"noisy": 1}, wantErr: true, wantRetry: time.Second},
+			},
+		},
+		{
+			name:  "zero rate is unlimited",
+			steps: []step{{counts: map[string]int{"trusted": 1000000}}},
+		},
+		{
+			name: "rejected request takes no tokens",
+			steps: []step{
+				{counts: map[string]int{"noisy": 1}},
+				{counts: map[string]int{"fw": 20, "noisy": 1}, wantErr: true, wantRetry: time.Second},
+				{counts: map[string]int{"fw": 20}},
+			},
+		},
+		{
+			name: "longest wait is reported",
+			steps: []step{
+				{counts: map[string]int{"fw": 20, "noisy": 1}},
+				{counts: map[string]int{"fw": 15, "noisy": 1}, wantErr: true, wantRetry: 1500 * time.Millisecond},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter(cfg)
+			for i, s := range tt.steps {
+				clock.now = clock.now.Add(s.advance)
+				err := l.AdmitEvents(s.counts)
+				if (err != nil) != s.wantErr {
+					t.Fatalf("step %d: AdmitEvents() error = %v, wantErr %v", i, err, s.wantErr)
+				}
+				if err == nil {
+					continue
+				}
+				if !errors.Is(err, errors.RateLimitExceeded) {
+					t.Errorf("step %d: AdmitEvents() error code = %s, want RateLimitExceeded", i, errors.GetCode(err))
+				}
+				if retry, ok := RetryAfter(err); !ok || retry != s.wantRetry {
+					t.Errorf("step %d: RetryAfter() = %s, %v, want %s", i, retry, ok, s.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestAdmitEventsDropsIdleBuckets(t *testing.T) {
+	l, clock := newTestLimiter(config.RateLimitConfig{EventsPerSecond: 10, IdleSeconds: 60})
+	if err := l.AdmitEvents(map[string]int{"short-lived": 5}); err != nil {
+		t.Fatalf("AdmitEvents() error = %v", err)
+	}
+	clock.now = clock.now.Add(2 * time.Minute)
+	if err := l.AdmitEvents(map[string]int{"other": 1}); err != nil {
+		t.Fatalf("AdmitEvents() error = %v", err)
+	}
+	if _, ok := l.buckets["short-lived"]; ok {
+		t.Error("bucket of an idle data source was not dropped")
+	}
+}
+
+func TestAcquireBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int64
+		held     []int64
+		request  int64
+		wantErr  bool
+	}{
+		{name: "unlimited", maxBytes: 0, held: []int64{1 << 30}, request: 1 << 30},
+		{name: "within budget", maxBytes: 100, held: []int64{40}, request: 60},
+		{name: "over budget", maxBytes: 100, held: []int64{40}, request: 61, wantErr: true},
+		{name: "larger than budget when idle", maxBytes: 100, request: 500},
+		{name: "larger than budget when busy", maxBytes: 100, held: []int64{1}, request: 500, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(config.RateLimitConfig{MaxInFlightBytes: tt.maxBytes, RetryAfterMillis: 250})
+			for _, n := range tt.held {
+				if _, err := l.AcquireBytes(n); err != nil {
+					t.Fatalf("AcquireBytes(%d) error = %v", n, err)
+				}
+			}
+			release, err := l.AcquireBytes(tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AcquireBytes(%d) error = %v, wantErr %v", tt.request, err, tt.wantErr)
+			}
+			if err != nil {
+				if retry, ok := RetryAfter(err); !ok || retry != 250*time.Millisecond {
+					t.Errorf("RetryAfter() = %s, %v, want 250ms", retry, ok)
+				}
+				return
+			}
+			before := l.InFlightBytes()
+			release()
+			release()
+			if released := before - l.InFlightBytes(); tt.maxBytes > 0 && released != min64(tt.request, tt.maxBytes) {
+				t.Errorf("release() returned %d bytes, want %d", released, min64(tt.request, tt.maxBytes))
+			}
+		})
+	}
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
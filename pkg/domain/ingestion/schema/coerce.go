@@ -0,0 +1,239 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+)
+
+const dateFormat = "2006-01-02"
+
+// timeLayouts are the string layouts accepted for DATE and DATETIME fields, tried in order.
+// timeLayouts 是DATE与DATETIME字段可接受的字符串格式，按顺序尝试。
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	constants.DefaultTimeFormat,
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02T15:04:05",
+	dateFormat,
+}
+
+// coerce converts v to the representation StarRocks expects for the field type.
+// coerce 将v转换为StarRocks对该字段类型所期望的表示形式。
+func coerce(v interface{}, spec *FieldSpec) (interface{}, error) {
+	switch spec.Type {
+	case enum.DataTypeBoolean:
+		return toBool(v)
+	case enum.DataTypeTinyInt:
+		return toInt(v, math.MinInt8, math.MaxInt8)
+	case enum.DataTypeSmallInt:
+		return toInt(v, math.MinInt16, math.MaxInt16)
+	case enum.DataTypeInt:
+		return toInt(v, math.MinInt32, math.MaxInt32)
+	case enum.DataTypeBigInt:
+		return toInt(v, math.MinInt64, math.MaxInt64)
+	case enum.DataTypeLargeInt:
+		return toLargeInt(v)
+	case enum.DataTypeFloat, enum.DataTypeDouble:
+		return toFloat(v)
+	case enum.DataTypeDecimal:
+		return toDecimal(v)
+	case enum.DataTypeDate:
+		return toTime(v, dateFormat)
+	case enum.DataTypeDateTime:
+		return toTime(v, constants.DefaultTimeFormat)
+	case enum.DataTypeChar, enum.DataTypeVarchar, enum.DataTypeString:
+		return toString(v, spec.Length)
+	case enum.DataTypeArray:
+		if _, ok := v.([]interface{}); !ok {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+		return v, nil
+	case enum.DataTypeMap, enum.DataTypeStruct:
+		if _, ok := v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("expected an object, got %T", v)
+		}
+		return v, nil
+	default: // JSON and types the registry does not know accept any value.
+		return v, nil
+	}
+}
+
+func toBool(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case float64:
+		if t == 0 || t == 1 {
+			return t == 1, nil
+		}
+	case int, int64:
+		n := fmt.Sprint(t)
+		if n == "0" || n == "1" {
+			return n == "1", nil
+		}
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(t)); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %v (%T) to BOOLEAN", v, v)
+}
+
+func toInt(v interface{}, min, max int64) (interface{}, error) {
+	var n int64
+	switch t := v.(type) {
+	case float64:
+		// float64(math.MaxInt64) rounds up to 2^63, so the upper bound is compared exclusively against max+1.
+		if t != math.Trunc(t) || t < float64(min) || t >= float64(max)+1 {
+			return nil, fmt.Errorf("value %v is not an integer in range [%d, %d]", t, min, max)
+		}
+		n = int64(t)
+	case int:
+		n = int64(t)
+	case int32:
+		n = int64(t)
+	case int64:
+		n = t
+	case json.Number:
+		parsed, err := t.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to an integer", t.String())
+		}
+		n = parsed
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to an integer", t)
+		}
+		n = parsed
+	case bool:
+		if t {
+			n = 1
+		}
+	default:
+		return nil, fmt.Errorf("cannot convert %T to an integer", v)
+	}
+	if n < min || n > max {
+		return nil, fmt.Errorf("value %d out of range [%d, %d]", n, min, max)
+	}
+	return n, nil
+}
+
+// toLargeInt validates a 128-bit integer, keeping string input as a string to avoid precision loss.
+// toLargeInt 校验128位整数，字符串输入保持为字符串以避免精度损失。
+func toLargeInt(v interface{}) (interface{}, error) {
+	if s, ok := v.(string); ok {
+		s = strings.TrimSpace(s)
+		digits := strings.TrimPrefix(s, "-")
+		if digits == "" || strings.TrimLeft(digits, "0123456789") != "" || len(digits) > 39 {
+			return nil, fmt.Errorf("cannot convert %q to LARGEINT", s)
+		}
+		return s, nil
+	}
+	return toInt(v, math.MinInt64, math.MaxInt64)
+}
+
+func toFloat(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case float32:
+		return float64(t), nil
+	case int:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case json.Number:
+		return t.Float64()
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to a number", t)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to a number", v)
+}
+
+// toDecimal validates a decimal, keeping string input verbatim so no precision is lost.
+// toDecimal 校验十进制数，字符串输入原样保留以避免精度损失。
+func toDecimal(v interface{}) (interface{}, error) {
+	if s, ok := v.(string); ok {
+		s = strings.TrimSpace(s)
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return nil, fmt.Errorf("cannot convert %q to DECIMAL", s)
+		}
+		return s, nil
+	}
+	return toFloat(v)
+}
+
+// toTime accepts time.Time, formatted strings and Unix epochs in seconds or milliseconds.
+// toTime 接受time.Time、格式化字符串以及以秒或毫秒为单位的Unix时间戳。
+func toTime(v interface{}, layout string) (interface{}, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC().Format(layout), nil
+	case float64:
+		return epochToTime(int64(t)).Format(layout), nil
+	case int64:
+		return epochToTime(t).Format(layout), nil
+	case int:
+		return epochToTime(int64(t)).Format(layout), nil
+	case string:
+		s := strings.TrimSpace(t)
+		for _, candidate := range timeLayouts {
+			if parsed, err := time.Parse(candidate, s); err == nil {
+				return parsed.UTC().Format(layout), nil
+			}
+		}
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return epochToTime(n).Format(layout), nil
+		}
+		return nil, fmt.Errorf("cannot parse %q as a time", t)
+	}
+	return nil, fmt.Errorf("cannot convert %T to a time", v)
+}
+
+// epochToTime treats values beyond the year 5138 in seconds as milliseconds.
+// epochToTime 将以秒计超过5138年的值视为毫秒。
+func epochToTime(n int64) time.Time {
+	if n > 1e11 || n < -1e11 {
+		return time.UnixMilli(n).UTC()
+	}
+	return time.Unix(n, 0).UTC()
+}
+
+func toString(v interface{}, maxLen int) (interface{}, error) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	case bool, int, int64, json.Number:
+		s = fmt.Sprint(t)
+	case time.Time:
+		s = t.UTC().Format(constants.DefaultTimeFormat)
+	default:
+		encoded, err := json.Marshal(t)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %T to a string", v)
+		}
+		s = string(encoded)
+	}
+	// StarRocks measures VARCHAR length in bytes.
+	if maxLen > 0 && len(s) > maxLen {
+		return nil, fmt.Errorf("value of %d bytes (%d characters) exceeds maximum length %d", len(s), utf8.RuneCountInString(s), maxLen)
+	}
+	return s, nil
+}
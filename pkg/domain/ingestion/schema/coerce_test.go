@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+)
+
+func TestCoerce(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 45, 0, time.FixedZone("CEST", 2*3600))
+	tests := []struct {
+		name    string
+		spec    FieldSpec
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "boolean", spec: FieldSpec{Type: enum.DataTypeBoolean}, value: true, want: true},
+		{name: "boolean from number", spec: FieldSpec{Type: enum.DataTypeBoolean}, value: float64(1), want: true},
+		{name: "boolean from int", spec: FieldSpec{Type: enum.DataTypeBoolean}, value: int64(0), want: false},
+		{name: "boolean from string", spec: FieldSpec{Type: enum.DataTypeBoolean}, value: " TRUE ", want: true},
+		{name: "boolean from other number", spec: FieldSpec{Type: enum.DataTypeBoolean}, value: float64(2), wantErr: true},
+		{name: "boolean from other string", spec: FieldSpec{Type: enum.DataTypeBoolean}, value: "yes", wantErr: true},
+
+		{name: "tinyint", spec: FieldSpec{Type: enum.DataTypeTinyInt}, value: float64(127), want: int64(127)},
+		{name: "tinyint overflow", spec: FieldSpec{Type: enum.DataTypeTinyInt}, value: float64(128), wantErr: true},
+		{name: "tinyint underflow", spec: FieldSpec{Type: enum.DataTypeTinyInt}, value: int64(-129), wantErr: true},
+		{name: "smallint from string", spec: FieldSpec{Type: enum.DataTypeSmallInt}, value: " -32768 ", want: int64(-32768)},
+		{name: "smallint overflow", spec: FieldSpec{Type: enum.DataTypeSmallInt}, value: "32768", wantErr: true},
+		{name: "int from json number", spec: FieldSpec{Type: enum.DataTypeInt}, value: json.Number("2147483647"), want: int64(2147483647)},
+		{name: "int overflow", spec: FieldSpec{Type: enum.DataTypeInt}, value: json.Number("2147483648"), wantErr: true},
+		{name: "int from fraction", spec: FieldSpec{Type: enum.DataTypeInt}, value: 1.5, wantErr: true},
+		{name: "int from bool", spec: FieldSpec{Type: enum.DataTypeInt}, value: true, want: int64(1)},
+		{name: "int from int32", spec: FieldSpec{Type: enum.DataTypeInt}, value: int32(-7), want: int64(-7)},
+		{name: "int from object", spec: FieldSpec{Type: enum.DataTypeInt}, value: map[string]interface{}{}, wantErr: true},
+		{name: "bigint", spec: FieldSpec{Type: enum.DataTypeBigInt}, value: int64(math.MaxInt64), want: int64(math.MaxInt64)},
+		{name: "bigint from 2^63", spec: FieldSpec{Type: enum.DataTypeBigInt}, value: math.Pow(2, 63), wantErr: true},
+		{name: "bigint from -2^63", spec: FieldSpec{Type: enum.DataTypeBigInt}, value: -math.Pow(2, 63), want: int64(math.MinInt64)},
+		{name: "bigint from below -2^63", spec: FieldSpec{Type: enum.DataTypeBigInt}, value: -math.Pow(2, 64), wantErr: true},
+		{name: "bigint from NaN", spec: FieldSpec{Type: enum.DataTypeBigInt}, value: math.NaN(), wantErr: true},
+		{name: "bigint from infinity", spec: FieldSpec{Type: enum.DataTypeBigInt}, value: math.Inf(1), wantErr: true},
+		{name: "bigint from malformed json number", spec: FieldSpec{Type: enum.DataTypeBigInt}, value: json.Number("1e3"), wantErr: true},
+
+		{name: "largeint string", spec: FieldSpec{Type: enum.DataTypeLargeInt}, value: " -170141183460469231731687303715884105728 ", want: "-170141183460469231731687303715884105728"},
+		{name: "largeint number", spec: FieldSpec{Type: enum.DataTypeLargeInt}, value: float64(42), want: int64(42)},
+		{name: "largeint malformed string", spec: FieldSpec{Type: enum.DataTypeLargeInt}, value: "12a", wantErr: true},
+		{name: "largeint too many digits", spec: FieldSpec{Type: enum.DataTypeLargeInt}, value: "1234567890123456789012345678901234567890", wantErr: true},
+
+		{name: "float", spec: FieldSpec{Type: enum.DataTypeFloat}, value: float32(1.5), want: 1.5},
+		{name: "double from int", spec: FieldSpec{Type: enum.DataTypeDouble}, value: 3, want: float64(3)},
+		{name: "double from int64", spec: FieldSpec{Type: enum.DataTypeDouble}, value: int64(-3), want: float64(-3)},
+		{name: "double from json number", spec: FieldSpec{Type: enum.DataTypeDouble}, value: json.Number("2.25"), want: 2.25},
+		{name: "double from string", spec: FieldSpec{Type: enum.DataTypeDouble}, value: " 1e3 ", want: float64(1000)},
+		{name: "double from other string", spec: FieldSpec{Type: enum.DataTypeDouble}, value: "one", wantErr: true},
+		{name: "double from bool", spec: FieldSpec{Type: enum.DataTypeDouble}, value: true, wantErr: true},
+
+		{name: "decimal string kept verbatim", spec: FieldSpec{Type: enum.DataTypeDecimal}, value: " 12345678901234567890.123 ", want: "12345678901234567890.123"},
+		{name: "decimal number", spec: FieldSpec{Type: enum.DataTypeDecimal}, value: 0.5, want: 0.5},
+		{name: "decimal malformed string", spec: FieldSpec{Type: enum.DataTypeDecimal}, value: "1,5", wantErr: true},
+
+		{name: "date from time", spec: FieldSpec{Type: enum.DataTypeDate}, value: ts, want: "2024-05-01"},
+		{name: "date from epoch seconds", spec: FieldSpec{Type: enum.DataTypeDate}, value: float64(1714566645), want: "2024-05-01"},
+		{name: "datetime from time", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: ts, want: "2024-05-01 10:30:45"},
+		{name: "datetime from rfc3339", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: "2024-05-01T12:30:45+02:00", want: "2024-05-01 10:30:45"},
+		{name: "datetime from starrocks format", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: "2024-05-01 10:30:45", want: "2024-05-01 10:30:45"},
+		{name: "datetime from fractional seconds", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: "2024-05-01 10:30:45.123", want: "2024-05-01 10:30:45"},
+		{name: "datetime from date", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: "2024-05-01", want: "2024-05-01 00:00:00"},
+		{name: "datetime from epoch milliseconds", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: int64(1714559445000), want: "2024-05-01 10:30:45"},
+		{name: "datetime from epoch string", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: "1714559445", want: "2024-05-01 10:30:45"},
+		{name: "datetime from int", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: 0, want: "1970-01-01 00:00:00"},
+		{name: "datetime from other string", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: "yesterday", wantErr: true},
+		{name: "datetime from bool", spec: FieldSpec{Type: enum.DataTypeDateTime}, value: false, wantErr: true},
+
+		{name: "varchar", spec: FieldSpec{Type: enum.DataTypeVarchar, Length: 5}, value: "hello", want: "hello"},
+		{name: "varchar length in bytes", spec: FieldSpec{Type: enum.DataTypeVarchar, Length: 5}, value: "héllo", wantErr: true},
+		{name: "char from number", spec: FieldSpec{Type: enum.DataTypeChar}, value: 1.25, want: "1.25"},
+		{name: "string from bool", spec: FieldSpec{Type: enum.DataTypeString}, value: true, want: "true"},
+		{name: "string from json number", spec: FieldSpec{Type: enum.DataTypeString}, value: json.Number("12"), want: "12"},
+		{name: "string from time", spec: FieldSpec{Type: enum.DataTypeString}, value: ts, want: "2024-05-01 10:30:45"},
+		{name: "string from object", spec: FieldSpec{Type: enum.DataTypeString}, value: map[string]interface{}{"a": 1}, want: `{"a":1}`},
+		{name: "string from unencodable value", spec: FieldSpec{Type: enum.DataTypeString}, value: make(chan int), wantErr: true},
+
+		{name: "array", spec: FieldSpec{Type: enum.DataTypeArray}, value: []interface{}{1.0}, want: []interface{}{1.0}},
+		{name: "array from scalar", spec: FieldSpec{Type: enum.DataTypeArray}, value: "x", wantErr: true},
+		{name: "map", spec: FieldSpec{Type: enum.DataTypeMap}, value: map[string]interface{}{"a": "b"}, want: map[string]interface{}{"a": "b"}},
+		{name: "struct from array", spec: FieldSpec{Type: enum.DataTypeStruct}, value: []interface{}{}, wantErr: true},
+		{name: "json accepts anything", spec: FieldSpec{Type: enum.DataTypeJSON}, value: []interface{}{"x"}, want: []interface{}{"x"}},
+		{name: "unknown type accepts anything", spec: FieldSpec{Type: enum.DataTypeUnknown}, value: 7, want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := coerce(tt.value, &tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("coerce(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("coerce(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
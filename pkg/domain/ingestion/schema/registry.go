@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// Source provides table schemas to derive event schemas from. It is satisfied by metadata.Service.
+// Source 提供用于推导事件模式的表模式，metadata.Service 满足此接口。
+type Source interface {
+	GetTableSchema(ctx context.Context, databaseName, tableName string) (*metadatamodel.TableSchema, error)
+}
+
+// Registry maps each DataType to the schema its events are validated against.
+// Schemas are either registered explicitly or derived on demand from the target table and
+// cached for cfg.RefreshSeconds.
+// Registry 将每个DataType映射到其事件所依据的模式。
+// 模式可以显式注册，也可以按需从目标表推导并缓存cfg.RefreshSeconds秒。
+type Registry struct {
+	source  Source
+	cfg     config.SchemaValidationConfig
+	mu      sync.RWMutex
+	schemas map[string]*registration // lower-cased DataType -> registration
+}
+
+type registration struct {
+	schema   *EventSchema
+	explicit bool
+	loadedAt time.Time
+}
+
+// NewRegistry creates a schema registry. source may be nil when only explicit registrations are used.
+// NewRegistry 创建模式注册表。仅使用显式注册时source可以为nil。
+func NewRegistry(source Source, cfg config.SchemaValidationConfig) *Registry {
+	return &Registry{
+		source:  source,
+		cfg:     cfg,
+		schemas: make(map[string]*registration),
+	}
+}
+
+// Register registers an explicit schema for a DataType. Explicit schemas are never refreshed from the table.
+// Register 为DataType注册显式模式，显式模式不会从表中刷新。
+func (r *Registry) Register(dataType string, schema *EventSchema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[strings.ToLower(dataType)] = &registration{schema: schema, explicit: true, loadedAt: time.Now()}
+}
+
+// Invalidate drops a cached schema so it is derived again on next use.
+// Invalidate 丢弃缓存的模式，以便下次使用时重新推导。
+func (r *Registry) Invalidate(dataType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.schemas, strings.ToLower(dataType))
+}
+
+// Lookup returns the schema for a DataType, deriving it from database.table when it is not registered
+// or the cached copy is stale.
+// Lookup 返回DataType的模式；若未注册或缓存已过期，则从database.table推导。
+func (r *Registry) Lookup(ctx context.Context, dataType, database, table string) (*EventSchema, error) {
+	key := strings.ToLower(dataType)
+	r.mu.RLock()
+	reg, ok := r.schemas[key]
+	r.mu.RUnlock()
+	if ok && (reg.explicit || !r.stale(reg)) {
+		return reg.schema, nil
+	}
+	if r.source == nil {
+		return nil, nil
+	}
+
+	tableSchema, err := r.source.GetTableSchema(ctx, database, table)
+	if err != nil {
+		if ok {
+			// Keep validating against the last known schema rather than failing ingestion.
+			logger.L().With("method", "Lookup", "data_type", dataType).Warnw("Failed to refresh event schema, using cached copy", "error", err)
+			return reg.schema, nil
+		}
+		return nil, err
+	}
+	derived := FromTableSchema(dataType, tableSchema)
+	r.mu.Lock()
+	r.schemas[key] = &registration{schema: derived, loadedAt: time.Now()}
+	r.mu.Unlock()
+	return derived, nil
+}
+
+// Validate type-checks and coerces data against the schema of its DataType.
+// Data without a schema is accepted unchanged. Mismatches are reported as InvalidArgument errors
+// wrapping a *ValidationError.
+// Validate 按DataType的模式对data进行类型检查和转换，没有模式的数据原样接受。
+// 不匹配时返回包装了*ValidationError的InvalidArgument错误。
+func (r *Registry) Validate(ctx context.Context, dataType, database, table string, data map[string]interface{}, implicit map[string]bool) error {
+	schema, err := r.Lookup(ctx, dataType, database, table)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+	if err := schema.Apply(data, implicit, r.cfg.RejectUnknownFields); err != nil {
+		return errors.Wrap(err, errors.InvalidArgument, "event failed schema validation")
+	}
+	return nil
+}
+
+func (r *Registry) stale(reg *registration) bool {
+	if r.cfg.RefreshSeconds <= 0 {
+		return false
+	}
+	return time.Since(reg.loadedAt) > time.Duration(r.cfg.RefreshSeconds)*time.Second
+}
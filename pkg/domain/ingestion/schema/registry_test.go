@@ -0,0 +1,197 @@
+package schema
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+	"github.com/turtacn/dataseap/pkg/config"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// fakeSource serves one table schema and counts how often it was asked for it.
+type fakeSource struct {
+	table *metadatamodel.TableSchema
+	err   error
+	calls int
+}
+
+func (f *fakeSource) GetTableSchema(_ context.Context, _, _ string) (*metadatamodel.TableSchema, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.table, nil
+}
+
+func eventsTable() *metadatamodel.TableSchema {
+	return &metadatamodel.TableSchema{
+		DatabaseName: "logs",
+		TableName:    "events",
+		Fields: []*metadatamodel.FieldSchema{
+			{Name: "ts", DataType: enum.DataTypeDateTime, TypeString: "DATETIME"},
+			{Name: "host", TypeString: "varchar(8)"},
+			{Name: "bytes", DataType: enum.DataTypeBigInt, TypeString: "BIGINT", IsNullable: true},
+			{Name: "severity", DataType: enum.DataTypeTinyInt, TypeString: "TINYINT", DefaultValue: "0"},
+		},
+	}
+}
+
+func TestRegistryLookup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("derived schemas are cached", func(t *testing.T) {
+		source := &fakeSource{table: eventsTable()}
+		r := NewRegistry(source, config.SchemaValidationConfig{})
+		for i := 0; i < 2; i++ {
+			s, err := r.Lookup(ctx, "Events", "logs", "events")
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if s.Source != "logs.events" || len(s.Fields) != 4 {
+				t.Fatalf("Lookup() = %+v, want the 4 fields of logs.events", s)
+			}
+		}
+		if source.calls != 1 {
+			t.Errorf("source called %d times, want 1", source.calls)
+		}
+		if spec := r.schemas["events"].schema.Fields["host"]; spec.Type != enum.DataTypeVarchar || spec.Length != 8 || !spec.Required {
+			t.Errorf("host spec = %+v, want a required VARCHAR of length 8", spec)
+		}
+		if spec := r.schemas["events"].schema.Fields["severity"]; spec.Required {
+			t.Errorf("severity spec = %+v, want optional because of its default", spec)
+		}
+	})
+
+	t.Run("stale schemas are refreshed", func(t *testing.T) {
+		source := &fakeSource{table: eventsTable()}
+		r := NewRegistry(source, config.SchemaValidationConfig{RefreshSeconds: 60})
+		if _, err := r.Lookup(ctx, "events", "logs", "events"); err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+		r.schemas["events"].loadedAt = time.Now().Add(-2 * time.Minute)
+		if _, err := r.Lookup(ctx, "events", "logs", "events"); err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+		if source.calls != 2 {
+			t.Errorf("source called %d times, want 2", source.calls)
+		}
+	})
+
+	t.Run("failed refresh keeps the cached copy", func(t *testing.T) {
+		source := &fakeSource{table: eventsTable()}
+		r := NewRegistry(source, config.SchemaValidationConfig{RefreshSeconds: 60})
+		cached, _ := r.Lookup(ctx, "events", "logs", "events")
+		r.schemas["events"].loadedAt = time.Now().Add(-2 * time.Minute)
+		source.err = errors.New(errors.DatabaseError, "unreachable")
+		s, err := r.Lookup(ctx, "events", "logs", "events")
+		if err != nil || s != cached {
+			t.Errorf("Lookup() = %p, %v, want the cached schema %p", s, err, cached)
+		}
+	})
+
+	t.Run("failed derivation without a cached copy", func(t *testing.T) {
+		r := NewRegistry(&fakeSource{err: errors.New(errors.DatabaseError, "unreachable")}, config.SchemaValidationConfig{})
+		if _, err := r.Lookup(ctx, "events", "logs", "events"); !errors.Is(err, errors.DatabaseError) {
+			t.Errorf("Lookup() error = %v, want DatabaseError", err)
+		}
+	})
+
+	t.Run("explicit schemas are never refreshed", func(t *testing.T) {
+		source := &fakeSource{table: eventsTable()}
+		r := NewRegistry(source, config.SchemaValidationConfig{RefreshSeconds: 1})
+		explicit := &EventSchema{DataType: "events", Fields: map[string]*FieldSpec{}}
+		r.Register("EVENTS", explicit)
+		r.schemas["events"].loadedAt = time.Now().Add(-time.Hour)
+		if s, _ := r.Lookup(ctx, "events", "logs", "events"); s != explicit || source.calls != 0 {
+			t.Errorf("Lookup() = %p after %d source calls, want the explicit schema %p and none", s, source.calls, explicit)
+		}
+		r.Invalidate("Events")
+		if s, _ := r.Lookup(ctx, "events", "logs", "events"); s == explicit || source.calls != 1 {
+			t.Errorf("Lookup() after Invalidate() = %p after %d source calls, want a derived schema", s, source.calls)
+		}
+	})
+
+	t.Run("no source and no registration", func(t *testing.T) {
+		r := NewRegistry(nil, config.SchemaValidationConfig{})
+		if s, err := r.Lookup(ctx, "events", "logs", "events"); s != nil || err != nil {
+			t.Errorf("Lookup() = %v, %v, want no schema", s, err)
+		}
+	})
+}
+
+func TestRegistryValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		rejectUnknown bool
+		data          map[string]interface{}
+		implicit      map[string]bool
+		want          map[string]interface{}
+		wantProblems  []string
+	}{
+		{
+			name: "values are coerced in place",
+			data: map[string]interface{}{"ts": "2024-05-01T10:30:45Z", "host": "web-1", "bytes": "1024", "extra": 1.0},
+			want: map[string]interface{}{"ts": "2024-05-01 10:30:45", "host": "web-1", "bytes": int64(1024), "extra": 1.0},
+		},
+		{
+			name:     "implicit fields are not missing",
+			data:     map[string]interface{}{"host": "web-1"},
+			implicit: map[string]bool{"ts": true},
+			want:     map[string]interface{}{"host": "web-1"},
+		},
+		{
+			name: "every problem is reported",
+			data: map[string]interface{}{"host": "a-very-long-host", "bytes": 1.5, "severity": nil},
+			wantProblems: []string{
+				"field 'bytes': value 1.5 is not an integer in range [-9223372036854775808, 9223372036854775807]",
+				"field 'host': value of 16 bytes (16 characters) exceeds maximum length 8",
+				"missing required field 'ts'",
+			},
+		},
+		{
+			name:         "null required field",
+			data:         map[string]interface{}{"ts": nil, "host": "web-1"},
+			wantProblems: []string{"field 'ts' cannot be null"},
+		},
+		{
+			name:          "unknown fields rejected",
+			rejectUnknown: true,
+			data:          map[string]interface{}{"ts": "2024-05-01", "host": "web-1", "extra": 1.0},
+			wantProblems:  []string{"unknown field 'extra'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(&fakeSource{table: eventsTable()}, config.SchemaValidationConfig{RejectUnknownFields: tt.rejectUnknown})
+			err := r.Validate(context.Background(), "events", "logs", "events", tt.data, tt.implicit)
+			if tt.wantProblems == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				if !reflect.DeepEqual(tt.data, tt.want) {
+					t.Errorf("Validate() data = %#v, want %#v", tt.data, tt.want)
+				}
+				return
+			}
+			appErr, ok := err.(*errors.AppError)
+			if !ok || appErr.Code != errors.InvalidArgument {
+				t.Fatalf("Validate() error = %v, want InvalidArgument", err)
+			}
+			validationErr, ok := appErr.Err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() error wraps %T, want *ValidationError", appErr.Err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.wantProblems) {
+				t.Errorf("Validate() problems = %q, want %q", validationErr.Problems, tt.wantProblems)
+			}
+		})
+	}
+
+	if err := NewRegistry(nil, config.SchemaValidationConfig{}).Validate(context.Background(), "events", "logs", "events", map[string]interface{}{"x": 1}, nil); err != nil {
+		t.Errorf("Validate() without a schema error = %v, want nil", err)
+	}
+}
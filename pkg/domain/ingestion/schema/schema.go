@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// FieldSpec describes the expected type of one event field.
+// FieldSpec 描述一个事件字段的预期类型。
+type FieldSpec struct {
+	Name       string        `json:"name"`                 // 字段名 Field name
+	Type       enum.DataType `json:"type"`                 // 字段类型 Field type
+	TypeString string        `json:"typeString,omitempty"` // 原始类型字符串 Raw type string, e.g. "VARCHAR(64)"
+	Length     int           `json:"length,omitempty"`     // CHAR/VARCHAR的最大长度 Max length of CHAR/VARCHAR
+	Required   bool          `json:"required"`             // 是否必须提供 Whether the field must be present
+}
+
+// EventSchema is the schema events of one DataType are checked and coerced against.
+// EventSchema 是某个DataType的事件进行类型检查和转换所依据的模式。
+type EventSchema struct {
+	DataType string                `json:"dataType"`         // 数据类型 Data type
+	Source   string                `json:"source,omitempty"` // 模式来源，例如 "db.table" Where the schema came from, e.g. "db.table"
+	Fields   map[string]*FieldSpec `json:"fields"`           // 字段名 -> 规格 Field name -> spec
+}
+
+// FromTableSchema derives an EventSchema from a table schema. Non-nullable columns without a
+// default value are required; every other column is optional.
+// FromTableSchema 从表模式推导EventSchema。没有默认值的非空列为必填字段，其余列为可选字段。
+func FromTableSchema(dataType string, table *metadatamodel.TableSchema) *EventSchema {
+	s := &EventSchema{
+		DataType: dataType,
+		Source:   table.DatabaseName + "." + table.TableName,
+		Fields:   make(map[string]*FieldSpec, len(table.Fields)),
+	}
+	for _, f := range table.Fields {
+		dt := f.DataType
+		if dt == "" || dt == enum.DataTypeUnknown {
+			dt = baseDataType(f.TypeString)
+		}
+		s.Fields[f.Name] = &FieldSpec{
+			Name:       f.Name,
+			Type:       dt,
+			TypeString: f.TypeString,
+			Length:     typeLength(f.TypeString),
+			Required:   !f.IsNullable && f.DefaultValue == "",
+		}
+	}
+	return s
+}
+
+// ValidationError lists every problem found while applying a schema to an event.
+// ValidationError 列出将模式应用于事件时发现的所有问题。
+type ValidationError struct {
+	DataType string
+	Problems []string
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("event does not match schema of data type %s: %s", e.DataType, strings.Join(e.Problems, "; "))
+}
+
+// Apply type-checks data against the schema and coerces values in place.
+// Fields listed in implicit are filled in later by the pipeline and are not reported as missing.
+// When rejectUnknown is set, fields the schema does not know about are rejected.
+// Apply 按模式对data进行类型检查并就地转换取值。
+// implicit中的字段稍后由流水线填充，不会被报告为缺失；rejectUnknown为true时拒绝模式中未知的字段。
+func (s *EventSchema) Apply(data map[string]interface{}, implicit map[string]bool, rejectUnknown bool) error {
+	var problems []string
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		spec, ok := s.Fields[k]
+		if !ok {
+			if rejectUnknown {
+				problems = append(problems, fmt.Sprintf("unknown field '%s'", k))
+			}
+			continue
+		}
+		v := data[k]
+		if v == nil {
+			if spec.Required {
+				problems = append(problems, fmt.Sprintf("field '%s' cannot be null", k))
+			}
+			continue
+		}
+		coerced, err := coerce(v, spec)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("field '%s': %s", k, err.Error()))
+			continue
+		}
+		data[k] = coerced
+	}
+
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !s.Fields[name].Required || implicit[name] {
+			continue
+		}
+		if _, ok := data[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required field '%s'", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{DataType: s.DataType, Problems: problems}
+	}
+	return nil
+}
+
+// baseDataType maps a raw StarRocks type string such as "varchar(64)" or "ARRAY<INT>" to its base DataType.
+// baseDataType 将原始StarRocks类型字符串 (如 "varchar(64)", "ARRAY<INT>") 映射为基础DataType。
+func baseDataType(typeString string) enum.DataType {
+	base := strings.ToUpper(strings.TrimSpace(typeString))
+	if i := strings.IndexAny(base, "(<"); i >= 0 {
+		base = strings.TrimSpace(base[:i])
+	}
+	switch base {
+	case "DECIMALV2", "DECIMAL32", "DECIMAL64", "DECIMAL128":
+		return enum.DataTypeDecimal
+	case "INTEGER":
+		return enum.DataTypeInt
+	case "TEXT":
+		return enum.DataTypeString
+	}
+	switch dt := enum.DataType(base); dt {
+	case enum.DataTypeBoolean, enum.DataTypeTinyInt, enum.DataTypeSmallInt, enum.DataTypeInt, enum.DataTypeBigInt,
+		enum.DataTypeLargeInt, enum.DataTypeFloat, enum.DataTypeDouble, enum.DataTypeDecimal, enum.DataTypeDate,
+		enum.DataTypeDateTime, enum.DataTypeChar, enum.DataTypeVarchar, enum.DataTypeString, enum.DataTypeJSON,
+		enum.DataTypeArray, enum.DataTypeMap, enum.DataTypeStruct:
+		return dt
+	}
+	return enum.DataTypeUnknown
+}
+
+// typeLength extracts the declared length of CHAR(n)/VARCHAR(n) types, or 0.
+// typeLength 提取CHAR(n)/VARCHAR(n)类型声明的长度，无长度时返回0。
+func typeLength(typeString string) int {
+	upper := strings.ToUpper(strings.TrimSpace(typeString))
+	if !strings.HasPrefix(upper, "CHAR(") && !strings.HasPrefix(upper, "VARCHAR(") {
+		return 0
+	}
+	start, end := strings.IndexByte(upper, '('), strings.IndexByte(upper, ')')
+	if end <= start {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(upper[start+1 : end]))
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -3,68 +3,240 @@ package ingestion
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/turtacn/dataseap/pkg/adapter/pulsar"    // Pulsar adapter for buffered ingestion
 	"github.com/turtacn/dataseap/pkg/adapter/starrocks" // StarRocks adapter for data persistence
+	"github.com/turtacn/dataseap/pkg/common/constants"
 	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/dedup"
 	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/parser"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/ratelimit"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/schema"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/transform"
 	"github.com/turtacn/dataseap/pkg/logger"
-	// "github.com/turtacn/dataseap/pkg/adapter/pulsar" // Optional: Pulsar adapter for message queue
 )
 
 type serviceImpl struct {
 	starrocksClient starrocks.Client
-	// pulsarProducer pulsar.Producer // Optional: if data is first sent to a message queue
+	cfg             config.IngestionConfig
+	loader          *batchLoader
+	publisher       *eventPublisher        // 仅在Pulsar缓冲模式下非空 Non-nil only in Pulsar buffered mode
+	deadLetters     *deadLetterWriter      // 未配置死信存储时为nil Nil when no dead-letter store is configured
+	schemas         *schema.Registry       // 未启用模式校验时为nil Nil when schema validation is disabled
+	transformer     *transform.Transformer // 未启用字段转换时为nil Nil when transformation is disabled
+	parsers         *parser.Registry       // 未配置负载解析器时为nil Nil when no payload parsers are configured
+	limiter         *ratelimit.Limiter     // 未启用限流时为nil Nil when rate limiting is disabled
+	dedupCache      *dedup.Cache           // 未启用去重时为nil Nil when deduplication is disabled
+	eventTimes      *eventTimePolicies     // 未启用事件时间策略时为nil Nil when the event time policy is disabled
+	provisioner     *tableProvisioner      // 未启用自动建表时为nil Nil when automatic table provisioning is disabled
 	// validator       some_validation_package.Validator // Optional: for complex validation logic
 }
 
 // NewService creates a new instance of the ingestion service.
+// When cfg.Mode is "pulsar", events are published to per-data-type Pulsar topics and
+// loaded into StarRocks by a SinkWorker; pulsarClient may be nil in "direct" mode.
 // NewService 创建一个新的采集服务实例。
-func NewService(srClient starrocks.Client /*, pulsarProd pulsar.Producer */) Service {
-	return &serviceImpl{
+// 当cfg.Mode为"pulsar"时，事件被发布到按数据类型划分的Pulsar主题，并由SinkWorker写入StarRocks；
+// "direct"模式下pulsarClient可以为nil。
+func NewService(srClient starrocks.Client, pulsarClient pulsar.Client, cfg config.IngestionConfig, opts ...Option) Service {
+	o := applyOptions(opts)
+	s := &serviceImpl{
 		starrocksClient: srClient,
-		// pulsarProducer: pulsarProd,
+		cfg:             cfg,
+		loader:          newBatchLoader(srClient, cfg),
+		deadLetters:     newDeadLetterWriter(o.deadLetters, srClient, cfg.DeadLetter),
+		schemas:         o.schemas,
+		transformer:     o.transformer,
+		parsers:         o.parsers,
+		limiter:         o.limiter,
+		dedupCache:      o.dedup,
+		eventTimes:      newEventTimePolicies(cfg.EventTime),
+		provisioner:     newTableProvisioner(o.ddl, cfg),
 	}
+	if cfg.Mode == constants.IngestionModePulsar && pulsarClient != nil {
+		s.publisher = newEventPublisher(pulsarClient, cfg.Buffer)
+	}
+	return s
 }
 
 // IngestEvents ingests one or more raw events into the system.
+// Valid events are grouped by DataType, mapped to their configured target table and
+// written in batches through StarRocks Stream Load. In Pulsar buffered mode the events
+// are published to the buffer topics instead, and ingestedCount reports the accepted events.
+// When deduplication is enabled, events already ingested within the window are dropped and
+// counted in duplicateCount.
+// Events dated too far before or after their receive time are rejected, clamped or loaded into a
+// quarantine table according to the event time policy of their DataType.
+// With automatic provisioning enabled, a DataType without a table mapping gets a table inferred from its
+// events, which are then always loaded directly.
 // IngestEvents 将一个或多个原始事件采集到系统中。
-func (s *serviceImpl) IngestEvents(ctx context.Context, events []*model.RawEvent) (ingestedCount int, persistFailedCount int, validationFailedCount int, err error) {
+// 有效事件按DataType分组，映射到配置的目标表，并通过StarRocks Stream Load分批写入。
+// 在Pulsar缓冲模式下事件改为发布到缓冲主题，ingestedCount表示被接收的事件数。
+// 启用去重时，时间窗口内已采集过的事件被丢弃并计入duplicateCount。
+// 事件时间早于或晚于接收时间过多的事件，按其DataType的事件时间策略被拒绝、钳制或写入隔离表。
+// 启用自动建表时，没有表映射的DataType会获得一张由其事件推断出的表，这些事件始终直接写入。
+func (s *serviceImpl) IngestEvents(ctx context.Context, events []*model.RawEvent) (ingestedCount int, persistFailedCount int, validationFailedCount int, duplicateCount int, err error) {
+	return s.ingest(ctx, events, nil)
+}
+
+// ingest runs the ingestion pipeline of IngestEvents. When track is not nil, it records the events that were
+// not settled: neither ingested, dropped as duplicates nor recorded as new dead letters.
+// ingest 执行IngestEvents的采集流程。track非nil时，记录未落定的事件：既未采集成功、未作为重复事件丢弃，
+// 也未被记录为新的死信。
+func (s *serviceImpl) ingest(ctx context.Context, events []*model.RawEvent, track *settlement) (ingestedCount int, persistFailedCount int, validationFailedCount int, duplicateCount int, err error) {
 	l := logger.L().Ctx(ctx).With("method", "IngestEvents", "event_count", len(events))
 	l.Info("Attempting to ingest events")
 
 	if len(events) == 0 {
 		l.Info("No events to ingest")
-		return 0, 0, 0, nil
+		return 0, 0, 0, 0, nil
+	}
+
+	release, err := s.admit(events)
+	if err != nil {
+		if exceeded, ok := ratelimit.Exceeded(err); ok {
+			l.Warnw("Ingestion request rate limited", "reason", exceeded.Reason, "data_source_id", exceeded.DataSourceID, "retry_after", exceeded.RetryAfter)
+		} else {
+			l.Warnw("Ingestion request rate limited", "error", err)
+		}
+		track.keep(events...)
+		return 0, 0, 0, 0, err
 	}
+	defer release()
 
-	// Placeholder implementation:
-	// 1. Validate events
-	// 2. Transform events if necessary
-	// 3. Batch events
-	// 4. Send to StarRocks (or Pulsar first)
+	// Decode RawPayload into Data first so that transformation and validation see the decoded fields.
+	// 先将RawPayload解码为Data，以便转换与校验能看到解码出的字段。
+	events, parseFailures := decodePayloads(s.parsers, events, track)
+	for _, pf := range parseFailures {
+		l.Warnw("Failed to parse event payload", "event_id", pf.event.ID, "data_type", pf.event.DataType, "error", pf.err)
+		validationFailedCount++
+		s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageValidation, reason: pf.err.Error()}, pf.event)
+	}
 
+	valid := make([]*model.RawEvent, 0, len(events))
 	for _, event := range events {
+		if s.transformer != nil {
+			if err := s.transformer.Apply(event); err != nil {
+				l.Warnw("Event transformation failed", "event_id", event.ID, "data_source_id", event.DataSourceID, "error", err)
+				validationFailedCount++
+				s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageValidation, reason: err.Error()}, event)
+				continue
+			}
+		}
 		if err := event.Validate(); err != nil {
 			l.Warnw("Event validation failed", "event_id", event.ID, "data_source_id", event.DataSourceID, "error", err)
 			validationFailedCount++
+			s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageValidation, reason: err.Error()}, event)
 			continue // Skip this event or collect errors
 		}
+		valid = append(valid, event)
+	}
+	provisionFailures, typeConflicts := s.provisionTables(ctx, valid)
+	if len(typeConflicts) > 0 {
+		routable := valid[:0]
+		for _, event := range valid {
+			conflict, ok := typeConflicts[event]
+			if !ok {
+				routable = append(routable, event)
+				continue
+			}
+			l.Warnw("Event does not match the columns of its provisioned table", "event_id", event.ID, "data_type", event.DataType, "table", conflict.table, "reason", conflict.reason)
+			validationFailedCount++
+			s.deadLetter(ctx, track, conflict, event)
+		}
+		valid = routable
+	}
 
-		// TODO: Implement actual data ingestion logic using s.starrocksClient.StreamLoad or similar
-		// For now, simulate ingestion
-		l.Debugw("Simulating ingestion for event", "event_id", event.ID, "data_type", event.DataType)
-		// Example:
-		// jsonData, _ := json.Marshal(event.Data)
-		// streamLoadOpts := &starrocks.StreamLoadOptions{Format: "json"}
-		// _, err := s.starrocksClient.StreamLoad(ctx, "your_database", event.DataType /*table name*/, bytes.NewReader(jsonData), streamLoadOpts)
-		// if err != nil {
-		//     l.Errorw("Failed to persist event to StarRocks", "event_id", event.ID, "error", err)
-		//     persistFailedCount++
-		//     continue
-		// }
+	// Group valid events by target table (or by DataType when buffering through Pulsar),
+	// keeping the order groups were first seen.
+	// 按目标表（Pulsar缓冲模式下按DataType）对有效事件分组，并保持分组首次出现的顺序。
+	receivedAt := time.Now().UTC()
+	claimed := newClaimedKeys(s.dedupCache)
+	batches := make(map[string][]*model.RawEvent)
+	direct := make(map[string][]*model.RawEvent)
+	var keys, directTables []string
+	for _, event := range valid {
+		table, provisioned, ok := s.targetTable(event.DataType)
+		if !ok {
+			reason := "no target table configured for data type " + event.DataType
+			if provisionErr := provisionFailures[strings.ToLower(event.DataType)]; provisionErr != nil {
+				reason = "failed to provision table for data type " + event.DataType + ": " + provisionErr.Error()
+			}
+			l.Warnw("No target table for data type", "event_id", event.ID, "data_type", event.DataType, "reason", reason)
+			persistFailedCount++
+			s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageRouting, reason: reason}, event)
+			continue
+		}
+		if !claimed.claim(event) {
+			l.Debugw("Dropping duplicate event", "event_id", event.ID, "data_source_id", event.DataSourceID)
+			duplicateCount++
+			continue
+		}
+		if event.ReceivedAt.IsZero() {
+			event.ReceivedAt = receivedAt
+		}
+		verdict, routedTable, reason := s.eventTimes.check(event, event.ReceivedAt, table)
+		switch verdict {
+		case eventTimeRejected:
+			l.Warnw("Event time out of bounds", "event_id", event.ID, "data_type", event.DataType, "reason", reason)
+			validationFailedCount++
+			claimed.release(event)
+			s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageValidation, reason: reason, table: table}, event)
+			continue
+		case eventTimeClamped:
+			l.Debugw("Event time clamped to receive time", "event_id", event.ID, "data_type", event.DataType, "reason", reason)
+		case eventTimeQuarantined:
+			l.Infow("Event time out of bounds, routing to quarantine table", "event_id", event.ID, "data_type", event.DataType, "table", routedTable, "reason", reason)
+		}
+		if err := s.validateSchema(ctx, event, routedTable); err != nil {
+			l.Warnw("Event does not match schema", "event_id", event.ID, "data_type", event.DataType, "table", routedTable, "error", err)
+			validationFailedCount++
+			claimed.release(event)
+			s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageValidation, reason: err.Error(), table: routedTable}, event)
+			continue
+		}
+		if verdict == eventTimeQuarantined || provisioned {
+			// Quarantined events and events of provisioned tables are always loaded directly: the buffer topics
+			// and the sink only know the tables of the configured table mapping.
+			// 被隔离的事件与自动建表的事件始终直接写入：缓冲主题与Sink只知道表映射中配置的表。
+			if _, seen := direct[routedTable]; !seen {
+				directTables = append(directTables, routedTable)
+			}
+			direct[routedTable] = append(direct[routedTable], event)
+			continue
+		}
+		key := table
+		if s.publisher != nil {
+			key = event.DataType
+		}
+		if _, seen := batches[key]; !seen {
+			keys = append(keys, key)
+		}
+		batches[key] = append(batches[key], event)
+	}
 
-		// Simulate success for skeleton
-		ingestedCount++
+	var (
+		persisted, failed int
+		lastLoadErr       error
+	)
+	if s.publisher != nil {
+		persisted, failed, lastLoadErr = s.publishGroups(ctx, keys, batches, claimed, track)
+	} else {
+		persisted, failed, lastLoadErr = s.loadGroups(ctx, keys, batches, claimed, track)
+	}
+	ingestedCount += persisted
+	persistFailedCount += failed
+	if len(directTables) > 0 {
+		persisted, failed, directErr := s.loadGroups(ctx, directTables, direct, claimed, track)
+		ingestedCount += persisted
+		persistFailedCount += failed
+		if directErr != nil {
+			lastLoadErr = directErr
+		}
 	}
 
 	if validationFailedCount > 0 || persistFailedCount > 0 {
@@ -73,19 +245,157 @@ func (s *serviceImpl) IngestEvents(ctx context.Context, events []*model.RawEvent
 			"succeeded", ingestedCount,
 			"validation_failed", validationFailedCount,
 			"persist_failed", persistFailedCount,
+			"duplicates", duplicateCount,
 		)
 		// Decide on error return strategy. If some succeed, is it still an overall error?
 		// For now, return a generic error if any failures occurred.
 		if persistFailedCount > 0 {
-			return ingestedCount, persistFailedCount, validationFailedCount, errors.New(errors.DatabaseError, fmt.Sprintf("%d events failed to persist", persistFailedCount))
+			if lastLoadErr != nil {
+				return ingestedCount, persistFailedCount, validationFailedCount, duplicateCount, errors.Wrap(lastLoadErr, errors.DatabaseError, fmt.Sprintf("%d events failed to persist", persistFailedCount))
+			}
+			return ingestedCount, persistFailedCount, validationFailedCount, duplicateCount, errors.New(errors.DatabaseError, fmt.Sprintf("%d events failed to persist", persistFailedCount))
 		}
 		if validationFailedCount > 0 {
-			return ingestedCount, persistFailedCount, validationFailedCount, errors.New(errors.InvalidArgument, fmt.Sprintf("%d events failed validation", validationFailedCount))
+			return ingestedCount, persistFailedCount, validationFailedCount, duplicateCount, errors.New(errors.InvalidArgument, fmt.Sprintf("%d events failed validation", validationFailedCount))
 		}
 	}
 
-	l.Infow("Ingestion process completed", "succeeded", ingestedCount, "validation_failed", validationFailedCount, "persist_failed", persistFailedCount)
-	return ingestedCount, persistFailedCount, validationFailedCount, nil
+	l.Infow("Ingestion process completed", "succeeded", ingestedCount, "validation_failed", validationFailedCount, "persist_failed", persistFailedCount, "duplicates", duplicateCount)
+	return ingestedCount, persistFailedCount, validationFailedCount, duplicateCount, nil
+}
+
+// targetTable returns the table the events of a data type are loaded into: the table configured in the table
+// mapping, or else the table provisioned for it, in which case provisioned is true.
+// targetTable 返回数据类型的事件写入的表：表映射中配置的表，否则为为其自动创建的表，此时provisioned为true。
+func (s *serviceImpl) targetTable(dataType string) (table string, provisioned bool, ok bool) {
+	if table, ok := s.loader.resolveTable(dataType); ok {
+		return table, false, true
+	}
+	table, ok = s.provisioner.table(dataType)
+	return table, ok, ok
+}
+
+// provisionTables ensures the table of each data type without a table mapping, when automatic provisioning is
+// enabled. It returns the errors of the data types left without a table, keyed by lower-cased data type, and the
+// validation failures of the events with a value that does not fit the type of its column.
+// provisionTables 在启用自动建表时，确保每个没有表映射的数据类型的表存在。返回仍然没有表的数据类型的错误
+// （以小写的数据类型为键），以及字段值与其列类型不符的事件的校验失败。
+func (s *serviceImpl) provisionTables(ctx context.Context, events []*model.RawEvent) (map[string]error, map[*model.RawEvent]failure) {
+	if s.provisioner == nil {
+		return nil, nil
+	}
+	l := logger.L().With("method", "provisionTables")
+
+	groups := make(map[string][]*model.RawEvent)
+	var dataTypes []string
+	for _, event := range events {
+		if _, mapped := s.loader.resolveTable(event.DataType); mapped {
+			continue
+		}
+		key := strings.ToLower(event.DataType)
+		if _, seen := groups[key]; !seen {
+			dataTypes = append(dataTypes, key)
+		}
+		groups[key] = append(groups[key], event)
+	}
+
+	var (
+		failures  map[string]error
+		conflicts map[*model.RawEvent]failure
+	)
+	for _, key := range dataTypes {
+		group := groups[key]
+		table, added, rejected, err := s.provisioner.ensure(ctx, group[0].DataType, group)
+		if err != nil {
+			l.Warnw("Failed to provision table for data type", "data_type", group[0].DataType, "table", table, "error", err)
+			if table == "" {
+				if failures == nil {
+					failures = make(map[string]error)
+				}
+				failures[key] = err
+			}
+		}
+		for event, reason := range rejected {
+			if conflicts == nil {
+				conflicts = make(map[*model.RawEvent]failure)
+			}
+			conflicts[event] = failure{stage: model.DeadLetterStageValidation, reason: reason, table: table}
+		}
+		if len(added) > 0 && s.schemas != nil {
+			s.schemas.Invalidate(group[0].DataType)
+		}
+	}
+	return failures, conflicts
+}
+
+// implicitColumns are filled in from the RawEvent envelope and need not be present in Data.
+// implicitColumns 由RawEvent信封填充，无需出现在Data中。
+var implicitColumns = map[string]bool{
+	ColumnEventID:      true,
+	ColumnDataSourceID: true,
+	ColumnEventTime:    true,
+	ColumnReceivedAt:   true,
+}
+
+// validateSchema checks and coerces event.Data against the schema of its DataType, if schema validation is enabled.
+// validateSchema 在启用模式校验时，按DataType的模式检查并转换event.Data。
+func (s *serviceImpl) validateSchema(ctx context.Context, event *model.RawEvent, table string) error {
+	if s.schemas == nil {
+		return nil
+	}
+	if event.Data == nil {
+		event.Data = make(map[string]interface{})
+	}
+	return s.schemas.Validate(ctx, event.DataType, s.cfg.Database, table, event.Data, implicitColumns)
+}
+
+// loadGroups Stream Loads each table group in chunks of at most the configured batch size.
+// The deduplication keys of chunks that fail, and of the events dead-lettered as filtered, are released.
+// loadGroups 将每个表分组按配置的批次大小分块通过Stream Load写入。
+// 写入失败的分块以及作为被过滤行记录为死信的事件会释放其去重键。
+func (s *serviceImpl) loadGroups(ctx context.Context, tables []string, batches map[string][]*model.RawEvent, claimed *claimedKeys, track *settlement) (loaded int, failed int, lastErr error) {
+	l := logger.L().With("method", "loadGroups")
+	for _, table := range tables {
+		for _, chunk := range chunkEvents(batches[table], s.loader.batchSize()) {
+			result, loadErr := s.loader.load(ctx, table, chunk)
+			loaded += result.Loaded
+			failed += result.Failed
+			if loadErr != nil {
+				l.Errorw("Stream load failed for batch", "table", table, "batch_size", len(chunk), "error", loadErr)
+				lastErr = loadErr
+				s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageLoad, reason: loadErr.Error(), table: table, label: result.Label, response: result.Response}, chunk...)
+				claimed.release(chunk...)
+				continue
+			}
+			if result.Failed > 0 {
+				l.Warnw("Stream load filtered some rows", "table", table, "loaded", result.Loaded, "filtered", result.Failed, "error_url", result.Response.ErrorURL)
+				f, filtered := s.deadLetters.filteredFailure(ctx, table, result, chunk)
+				claimed.release(filtered...)
+				s.deadLetter(ctx, track, f, filtered...)
+			} else {
+				l.Debugw("Stream load batch succeeded", "table", table, "loaded", result.Loaded, "label", result.Label, "duplicate", result.Duplicate)
+			}
+		}
+	}
+	return loaded, failed, lastErr
+}
+
+// publishGroups publishes each DataType group to its Pulsar buffer topic.
+// publishGroups 将每个DataType分组发布到其Pulsar缓冲主题。
+func (s *serviceImpl) publishGroups(ctx context.Context, dataTypes []string, batches map[string][]*model.RawEvent, claimed *claimedKeys, track *settlement) (published int, failed int, lastErr error) {
+	l := logger.L().With("method", "publishGroups")
+	for _, dataType := range dataTypes {
+		ok, failedEvents, publishErr := s.publisher.publish(ctx, dataType, batches[dataType])
+		published += ok
+		failed += len(failedEvents)
+		if publishErr != nil {
+			l.Errorw("Failed to publish events to buffer topic", "data_type", dataType, "failed", len(failedEvents), "error", publishErr)
+			lastErr = publishErr
+			s.deadLetter(ctx, track, failure{stage: model.DeadLetterStageLoad, reason: publishErr.Error()}, failedEvents...)
+			claimed.release(failedEvents...)
+		}
+	}
+	return published, failed, lastErr
 }
 
 // IngestEvent ingests a single raw event.
@@ -94,7 +404,7 @@ func (s *serviceImpl) IngestEvent(ctx context.Context, event *model.RawEvent) er
 	l := logger.L().Ctx(ctx).With("method", "IngestEvent", "event_id", event.ID)
 	l.Info("Attempting to ingest a single event")
 
-	_, _, validationFailed, err := s.IngestEvents(ctx, []*model.RawEvent{event})
+	_, _, validationFailed, _, err := s.IngestEvents(ctx, []*model.RawEvent{event})
 	if err != nil {
 		return err
 	}
@@ -104,3 +414,19 @@ func (s *serviceImpl) IngestEvent(ctx context.Context, event *model.RawEvent) er
 	// If IngestEvents returns an error when persistFailed > 0, that error is already propagated.
 	return nil
 }
+
+// ReplayEvents ingests the events of dead letters like IngestEvents, filling the counts of result, and reports by
+// index the events that were not settled. Events dead-lettered after their transformation are marked as transformed
+// and are not transformed again.
+// ReplayEvents 像IngestEvents一样采集死信中的事件，填充result中的计数，并按下标报告未落定的事件。
+// 在转换之后被记录为死信的事件带有已转换标记，不会再次转换。
+func (s *serviceImpl) ReplayEvents(ctx context.Context, events []*model.RawEvent, result *model.ReplayResult) ([]bool, error) {
+	track := newSettlement()
+	var err error
+	result.Ingested, result.PersistFailed, result.ValidationFailed, result.Duplicates, err = s.ingest(ctx, events, track)
+	unsettled := make([]bool, len(events))
+	for i, event := range events {
+		unsettled[i] = track.unsettled[event]
+	}
+	return unsettled, err
+}
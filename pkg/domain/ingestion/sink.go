@@ -0,0 +1,252 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/adapter/pulsar"
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// SinkWorker consumes buffered events from the Pulsar ingestion topics and loads them into StarRocks.
+// Messages are acknowledged only after the Stream Load of their batch has succeeded; failed
+// batches are negatively acknowledged so Pulsar redelivers them. With two-phase commit, a sink
+// ledger keeps redelivered messages that were already loaded from being loaded again.
+// SinkWorker 从Pulsar采集主题消费缓冲的事件并写入StarRocks。
+// 只有在批次的Stream Load成功后才确认消息；失败的批次会被否定确认以便Pulsar重新投递。
+// 启用两阶段提交时，Sink台账防止已写入的重新投递消息被再次写入。
+type SinkWorker struct {
+	pulsarClient pulsar.Client
+	loader       *batchLoader
+	cfg          config.IngestionConfig
+	deadLetters  *deadLetterWriter
+	ledger       *sinkLedger // 仅两阶段提交模式 Two-phase commit mode only
+}
+
+// sinkBatch holds the events pending for one target table and the IDs and ledger keys of the messages carrying them.
+// sinkBatch 保存某个目标表待写入的事件及承载它们的消息的ID与台账键。
+type sinkBatch struct {
+	events []*model.RawEvent
+	msgIDs []pulsar.MessageID
+	keys   []string
+}
+
+// NewSinkWorker creates a new StarRocks sink worker.
+// NewSinkWorker 创建一个新的StarRocks Sink工作者。
+func NewSinkWorker(pulsarClient pulsar.Client, srClient starrocks.Client, cfg config.IngestionConfig, opts ...Option) *SinkWorker {
+	o := applyOptions(opts)
+	return &SinkWorker{
+		pulsarClient: pulsarClient,
+		loader:       newBatchLoader(srClient, cfg),
+		cfg:          cfg,
+		deadLetters:  newDeadLetterWriter(o.deadLetters, srClient, cfg.DeadLetter),
+		ledger:       newSinkLedger(srClient, cfg),
+	}
+}
+
+// Topics returns the buffer topics the worker subscribes to, one per configured DataType.
+// Topics 返回工作者订阅的缓冲主题，每个配置的DataType一个。
+func (w *SinkWorker) Topics() []string {
+	topics := make([]string, 0, len(w.cfg.TableMapping))
+	for dataType := range w.cfg.TableMapping {
+		topics = append(topics, TopicForDataType(w.cfg.Buffer, dataType))
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Run subscribes to the buffer topics and loads batches until ctx is cancelled.
+// Unacknowledged messages pending at shutdown are redelivered to the next consumer.
+// Run 订阅缓冲主题并持续写入批次，直到ctx被取消。关闭时未确认的消息将被重新投递给下一个消费者。
+func (w *SinkWorker) Run(ctx context.Context) error {
+	l := logger.L().With("component", "StarRocksSink")
+
+	topics := w.Topics()
+	if len(topics) == 0 {
+		return errors.New(errors.ConfigError, "no ingestion table mapping configured, sink has nothing to subscribe to")
+	}
+	subscription := w.cfg.Buffer.SubscriptionName
+	if subscription == "" {
+		subscription = constants.IngestionDefaultSinkSubscription
+	}
+
+	// nil options give a Shared subscription, so several sink replicas can split the load.
+	consumer, err := w.pulsarClient.Subscribe(topics, subscription, nil)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+	l.Infow("StarRocks sink started", "topics", topics, "subscription", subscription)
+
+	batchSize := w.cfg.Buffer.SinkBatchSize
+	if batchSize <= 0 {
+		batchSize = w.loader.batchSize()
+	}
+	flushInterval := time.Duration(w.cfg.Buffer.SinkFlushMillis) * time.Millisecond
+	if flushInterval <= 0 {
+		flushInterval = time.Duration(constants.IngestionDefaultSinkFlushMillis) * time.Millisecond
+	}
+
+	pending := make(map[string]*sinkBatch) // table -> batch
+	nextFlush := time.Now().Add(flushInterval)
+	for {
+		receiveCtx, cancel := context.WithDeadline(ctx, nextFlush)
+		msg, err := consumer.Receive(receiveCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			l.Infow("StarRocks sink stopping", "pending_tables", len(pending))
+			return nil
+		}
+		if err != nil {
+			if err != context.DeadlineExceeded {
+				l.Errorw("Failed to receive message from buffer topic", "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+			}
+		} else {
+			w.add(ctx, consumer, pending, msg)
+		}
+
+		for table, batch := range pending {
+			if len(batch.events) >= batchSize {
+				w.flush(ctx, consumer, table, batch)
+				delete(pending, table)
+			}
+		}
+		if !time.Now().Before(nextFlush) {
+			for table, batch := range pending {
+				w.flush(ctx, consumer, table, batch)
+				delete(pending, table)
+			}
+			nextFlush = time.Now().Add(flushInterval)
+		}
+	}
+}
+
+// add decodes a buffered message and appends it to the batch of its target table.
+// Messages that cannot be decoded or mapped to a table are acknowledged and dropped.
+// add 解码缓冲消息并追加到其目标表的批次；无法解码或无法映射到表的消息将被确认并丢弃。
+func (w *SinkWorker) add(ctx context.Context, consumer pulsar.Consumer, pending map[string]*sinkBatch, msg pulsar.Message) {
+	l := logger.L().With("component", "StarRocksSink", "topic", msg.Topic())
+
+	var event model.RawEvent
+	if err := json.Unmarshal(msg.Payload(), &event); err != nil {
+		l.Errorw("Dropping undecodable buffered event", "error", err)
+		w.deadLetters.record(ctx, failure{stage: model.DeadLetterStageValidation, reason: "undecodable buffered event: " + err.Error()},
+			&model.RawEvent{RawPayload: msg.Payload(), DataType: msg.Properties()[PropertyDataType]})
+		_ = consumer.AckID(msg.ID())
+		return
+	}
+	table, ok := w.loader.resolveTable(event.DataType)
+	if !ok {
+		l.Warnw("Dropping buffered event without target table", "event_id", event.ID, "data_type", event.DataType)
+		w.deadLetters.record(ctx, failure{stage: model.DeadLetterStageRouting, reason: "no target table configured for data type " + event.DataType}, &event)
+		_ = consumer.AckID(msg.ID())
+		return
+	}
+
+	batch, ok := pending[table]
+	if !ok {
+		batch = &sinkBatch{}
+		pending[table] = batch
+	}
+	batch.events = append(batch.events, &event)
+	batch.msgIDs = append(batch.msgIDs, msg.ID())
+	batch.keys = append(batch.keys, messageKey(msg))
+}
+
+// flush loads a batch and acknowledges its messages on success, or nacks them for redelivery on failure.
+// flush 写入一个批次，成功时确认其消息，失败时否定确认以便重新投递。
+func (w *SinkWorker) flush(ctx context.Context, consumer pulsar.Consumer, table string, batch *sinkBatch) {
+	l := logger.L().With("component", "StarRocksSink", "table", table, "batch_size", len(batch.events))
+
+	var result *loadResult
+	var err error
+	if w.ledger == nil {
+		result, err = w.loader.load(ctx, table, batch.events)
+	} else {
+		batch, err = w.skipLoaded(ctx, consumer, table, batch)
+		if err == nil && len(batch.events) == 0 {
+			return
+		}
+		if err == nil {
+			keys := batch.keys
+			result, err = w.loader.loadLabeled(ctx, table, sinkLabel(table, keys), batch.events, func(ctx context.Context, label string) error {
+				return w.ledger.record(ctx, label, keys)
+			})
+		}
+	}
+	if err != nil {
+		l.Errorw("Sink stream load failed, messages will be redelivered", "error", err)
+		for _, id := range batch.msgIDs {
+			_ = consumer.NackID(id)
+		}
+		return
+	}
+	if result.Failed > 0 {
+		l.Warnw("Sink stream load filtered some rows", "loaded", result.Loaded, "filtered", result.Failed, "error_url", result.Response.ErrorURL)
+		f, filtered := w.deadLetters.filteredFailure(ctx, table, result, batch.events)
+		w.deadLetters.record(ctx, f, filtered...)
+	}
+	for _, id := range batch.msgIDs {
+		if ackErr := consumer.AckID(id); ackErr != nil {
+			l.Warnw("Failed to acknowledge buffered message", "error", ackErr)
+		}
+	}
+	l.Debugw("Sink batch loaded", "loaded", result.Loaded, "label", result.Label, "duplicate", result.Duplicate)
+}
+
+// skipLoaded acknowledges the messages of a batch that the ledger shows were loaded by an earlier, committed
+// batch, and returns the batch of the remaining messages. The whole batch is returned with the error when the
+// ledger cannot be read or an earlier load cannot be settled yet.
+// skipLoaded 确认台账显示已由之前已提交的批次写入的消息，并返回其余消息组成的批次。无法读取台账或之前的导入
+// 尚无法确定结果时，返回整个批次及错误。
+func (w *SinkWorker) skipLoaded(ctx context.Context, consumer pulsar.Consumer, table string, batch *sinkBatch) (*sinkBatch, error) {
+	recorded, err := w.ledger.lookup(ctx, batch.keys)
+	if err != nil {
+		return batch, err
+	}
+	loaded := make(map[string]bool) // 标签 -> 是否已提交 Label -> committed
+	remaining := &sinkBatch{}
+	var skipped []pulsar.MessageID
+	for i, key := range batch.keys {
+		done := false
+		for _, label := range recorded[key] {
+			committed, ok := loaded[label]
+			if !ok {
+				if committed, err = w.loader.labelLoaded(ctx, table, label); err != nil {
+					return batch, err
+				}
+				loaded[label] = committed
+			}
+			if committed {
+				done = true
+				break
+			}
+		}
+		if done {
+			skipped = append(skipped, batch.msgIDs[i])
+			continue
+		}
+		remaining.events = append(remaining.events, batch.events[i])
+		remaining.msgIDs = append(remaining.msgIDs, batch.msgIDs[i])
+		remaining.keys = append(remaining.keys, key)
+	}
+	for _, id := range skipped {
+		_ = consumer.AckID(id)
+	}
+	if len(skipped) > 0 {
+		logger.L().Infow("Acknowledged redelivered messages that were already loaded", "component", "StarRocksSink", "table", table, "skipped", len(skipped))
+	}
+	return remaining, nil
+}
@@ -0,0 +1,183 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/adapter/pulsar"
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+)
+
+// sinkLedger records, per buffered message, the label of the two-phase load that carried it. A Shared
+// subscription redelivers unacknowledged messages in batches of a different make-up, so a batch label alone
+// cannot tell that a redelivered message was already loaded; the ledger can. Rows are written before the
+// load is committed and expire with their daily partition.
+// sinkLedger 按缓冲消息记录承载它的两阶段导入的标签。Shared订阅会以不同组成的批次重新投递未确认的消息，
+// 仅凭批次标签无法判断重新投递的消息是否已写入，台账则可以。记录在导入提交前写入，并随其按天的分区过期。
+type sinkLedger struct {
+	client   starrocks.Client
+	database string
+	table    string
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// sinkLedgerRow is the Stream Load row of one message of a load.
+// sinkLedgerRow 是某次导入中一条消息对应的Stream Load行。
+type sinkLedgerRow struct {
+	MessageKey string `json:"message_key"`
+	RecordedOn string `json:"recorded_on"`
+	Label      string `json:"label"`
+}
+
+// newSinkLedger creates the ledger of the configuration, or returns nil when loads are not two-phase.
+// newSinkLedger 根据配置创建台账，导入不是两阶段提交时返回nil。
+func newSinkLedger(client starrocks.Client, cfg config.IngestionConfig) *sinkLedger {
+	if !cfg.TwoPhaseCommit || cfg.Database == "" {
+		return nil
+	}
+	table := cfg.Buffer.SinkLedgerTable
+	if table == "" {
+		table = constants.IngestionDefaultSinkLedgerTable
+	}
+	return &sinkLedger{client: client, database: cfg.Database, table: table}
+}
+
+// lookup returns the labels recorded for each of keys. Keys without a row are left out.
+// lookup 返回keys中每个键记录的标签，没有记录的键不出现在结果中。
+func (s *sinkLedger) lookup(ctx context.Context, keys []string) (map[string][]string, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	literals := make([]string, 0, len(keys))
+	for _, key := range keys {
+		literals = append(literals, "'"+strings.ReplaceAll(key, "'", "''")+"'")
+	}
+	query := fmt.Sprintf("SELECT message_key, label FROM %s.%s WHERE message_key IN (%s)",
+		quoteLedgerIdentifier(s.database), quoteLedgerIdentifier(s.table), strings.Join(literals, ", "))
+	result, err := s.client.Execute(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to read sink ledger")
+	}
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.DatabaseError, "reading sink ledger resulted in StarRocks error")
+	}
+	labels := make(map[string][]string, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) < 2 {
+			return nil, errors.New(errors.InternalError, "unexpected sink ledger result format")
+		}
+		key, _ := row[0].(string)
+		label, _ := row[1].(string)
+		if key != "" && label != "" {
+			labels[key] = append(labels[key], label)
+		}
+	}
+	return labels, nil
+}
+
+// record stream-loads one row per key binding it to label.
+// record 为每个键通过Stream Load写入一行，将其与label关联。
+func (s *sinkLedger) record(ctx context.Context, label string, keys []string) error {
+	if err := s.ensureTable(ctx); err != nil {
+		return err
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	rows := make([]sinkLedgerRow, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, sinkLedgerRow{MessageKey: key, RecordedOn: today, Label: label})
+	}
+	payload, err := json.Marshal(rows)
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "failed to serialize sink ledger rows")
+	}
+	opts := &starrocks.StreamLoadOptions{Format: "json", StripOuterArray: true}
+	if _, err := s.client.StreamLoad(ctx, s.database, s.table, bytes.NewReader(payload), opts); err != nil {
+		return errors.Wrapf(err, errors.DatabaseError, "failed to record batch %s in the sink ledger", label)
+	}
+	return nil
+}
+
+// ensureTable creates the ledger table if it does not exist yet. A failed attempt is retried on the next call.
+// ensureTable 在台账表不存在时创建该表。创建失败时会在下一次调用时重试。
+func (s *sinkLedger) ensureTable(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	ddl, err := starrocks.BuildCreateTableDDL(&starrocks.TableSchemaDef{
+		DatabaseName: s.database,
+		TableName:    s.table,
+		Fields: []starrocks.FieldSchemaDef{
+			{Name: "message_key", Type: "VARCHAR(64)", IsKey: true},
+			{Name: "recorded_on", Type: "DATE", IsKey: true},
+			{Name: "label", Type: "VARCHAR(128)"},
+		},
+		KeysType: starrocks.KeysTypeDuplicate,
+		Partition: &starrocks.PartitionDef{
+			Type:    starrocks.PartitionTypeRange,
+			Columns: []string{"recorded_on"},
+			Dynamic: &starrocks.DynamicPartitionDef{TimeUnit: "DAY", Start: -constants.IngestionSinkLedgerRetentionDays, End: 1},
+		},
+		DistributionColumns: []string{"message_key"},
+		Comment:             "Buffered messages loaded by the DataSeaP sink",
+		IfNotExists:         true,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := s.client.Execute(ctx, ddl)
+	if err == nil {
+		err = result.Error
+	}
+	if err != nil {
+		return errors.Wrapf(err, errors.DatabaseError, "failed to create sink ledger table %s.%s", s.database, s.table)
+	}
+	s.ready = true
+	return nil
+}
+
+// messageKey identifies a buffered message across redeliveries by its topic and message ID.
+// messageKey 以主题与消息ID标识缓冲消息，重新投递后保持不变。
+func messageKey(msg pulsar.Message) string {
+	h := sha256.New()
+	h.Write([]byte(msg.Topic()))
+	h.Write([]byte{0})
+	h.Write(msg.ID().Serialize())
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// sinkLabel derives the Stream Load label of a sink batch from its table and the keys of its messages, so that
+// a batch redelivered with the same messages maps to the same label whatever their order.
+// sinkLabel 根据表名与消息键生成Sink批次的Stream Load标签，使以相同消息重新投递的批次无论顺序如何都得到相同的标签。
+func sinkLabel(table string, keys []string) string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	h := sha256.New()
+	for _, key := range sorted {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+	}
+	label := labelPrefix + "sink_" + labelUnsafeChars.ReplaceAllString(table, "_") + "_" + hex.EncodeToString(h.Sum(nil))[:32]
+	if len(label) > maxLabelLength {
+		label = label[len(label)-maxLabelLength:]
+	}
+	return label
+}
+
+func quoteLedgerIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,396 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/adapter/pulsar"
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+// fakeSinkStarRocks is a StarRocks client holding a target table loaded in two-phase transactions and the sink
+// ledger table. Rows of a transaction become visible when it is committed.
+type fakeSinkStarRocks struct {
+	starrocks.Client
+	ledger    map[string][]string // 消息键 -> 标签 Message key -> labels
+	states    map[string]string   // 标签 -> 状态 Label -> state
+	labels    map[int64]string
+	pending   map[int64][]string
+	loaded    []string // 已提交的事件ID Committed event IDs
+	commitErr error
+	abortErr  error
+}
+
+func newFakeSinkStarRocks() *fakeSinkStarRocks {
+	return &fakeSinkStarRocks{
+		ledger:  make(map[string][]string),
+		states:  make(map[string]string),
+		labels:  make(map[int64]string),
+		pending: make(map[int64][]string),
+	}
+}
+
+func (f *fakeSinkStarRocks) Execute(_ context.Context, query string, _ ...interface{}) (*starrocks.QueryResult, error) {
+	result := &starrocks.QueryResult{}
+	if !strings.HasPrefix(query, "SELECT") {
+		return result, nil
+	}
+	for key, labels := range f.ledger {
+		if strings.Contains(query, "'"+key+"'") {
+			for _, label := range labels {
+				result.Rows = append(result.Rows, []interface{}{key, label})
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeSinkStarRocks) StreamLoad(_ context.Context, _, table string, data io.Reader, opts *starrocks.StreamLoadOptions) (*starrocks.StreamLoadResponse, error) {
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(data).Decode(&rows); err != nil {
+		return nil, err
+	}
+	if table == "dataseap_sink_ledger" {
+		for _, row := range rows {
+			key := row["message_key"].(string)
+			f.ledger[key] = append(f.ledger[key], row["label"].(string))
+		}
+		return &starrocks.StreamLoadResponse{Status: starrocks.StreamLoadStatusSuccess, NumberLoadedRows: int64(len(rows))}, nil
+	}
+	txnID, err := strconv.ParseInt(opts.TransactionID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		f.pending[txnID] = append(f.pending[txnID], row[ColumnEventID].(string))
+	}
+	f.states[f.labels[txnID]] = starrocks.LoadStatePrepared
+	return &starrocks.StreamLoadResponse{Status: starrocks.StreamLoadStatusSuccess, NumberLoadedRows: int64(len(rows))}, nil
+}
+
+func (f *fakeSinkStarRocks) BeginTransaction(_ context.Context, _, _, label string, _ int) (int64, error) {
+	for id, existing := range f.labels {
+		if existing == label {
+			return id, errors.Newf(errors.AlreadyExistsError, "label %s already exists", label)
+		}
+	}
+	txnID := int64(len(f.labels) + 1)
+	f.labels[txnID] = label
+	f.states[label] = starrocks.LoadStatePrepare
+	return txnID, nil
+}
+
+func (f *fakeSinkStarRocks) CommitTransaction(_ context.Context, _ string, txnID int64) error {
+	if f.commitErr != nil {
+		return f.commitErr
+	}
+	f.states[f.labels[txnID]] = starrocks.LoadStateVisible
+	f.loaded = append(f.loaded, f.pending[txnID]...)
+	delete(f.pending, txnID)
+	return nil
+}
+
+func (f *fakeSinkStarRocks) AbortTransaction(_ context.Context, _ string, txnID int64) error {
+	if f.abortErr != nil {
+		return f.abortErr
+	}
+	f.states[f.labels[txnID]] = starrocks.LoadStateAborted
+	delete(f.pending, txnID)
+	return nil
+}
+
+func (f *fakeSinkStarRocks) GetLoadState(_ context.Context, _, label string) (string, error) {
+	if state, ok := f.states[label]; ok {
+		return state, nil
+	}
+	return starrocks.LoadStateUnknown, nil
+}
+
+// fakeSinkLoads is a StarRocks client whose Stream Loads fail with err, or filter the last filtered rows of each
+// load.
+type fakeSinkLoads struct {
+	starrocks.Client
+	err      error
+	filtered int
+	loaded   []string // 已写入的事件ID Loaded event IDs
+}
+
+func (f *fakeSinkLoads) StreamLoad(_ context.Context, _, _ string, data io.Reader, _ *starrocks.StreamLoadOptions) (*starrocks.StreamLoadResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(data).Decode(&rows); err != nil {
+		return nil, err
+	}
+	kept := len(rows) - f.filtered
+	for _, row := range rows[:kept] {
+		f.loaded = append(f.loaded, row[ColumnEventID].(string))
+	}
+	return &starrocks.StreamLoadResponse{
+		Status:             starrocks.StreamLoadStatusSuccess,
+		NumberLoadedRows:   int64(kept),
+		NumberFilteredRows: int64(f.filtered),
+	}, nil
+}
+
+// fakeAcks records the messages a batch acknowledged and negatively acknowledged.
+type fakeAcks struct {
+	pulsar.Consumer
+	acked  []string
+	nacked []string
+}
+
+func (f *fakeAcks) AckID(id pulsar.MessageID) error {
+	f.acked = append(f.acked, string(id.Serialize()))
+	return nil
+}
+
+func (f *fakeAcks) NackID(id pulsar.MessageID) error {
+	f.nacked = append(f.nacked, string(id.Serialize()))
+	return nil
+}
+
+// fakeMessageID is a message ID serialized as its name.
+type fakeMessageID struct {
+	pulsar.MessageID
+	name string
+}
+
+func (id fakeMessageID) Serialize() []byte { return []byte(id.name) }
+
+// fakeBufferedMessage is a buffered event as published to the ingestion topic.
+type fakeBufferedMessage struct {
+	id      string
+	payload []byte
+}
+
+func (m *fakeBufferedMessage) ID() pulsar.MessageID          { return fakeMessageID{name: m.id} }
+func (m *fakeBufferedMessage) Payload() []byte               { return m.payload }
+func (m *fakeBufferedMessage) Properties() map[string]string { return nil }
+func (m *fakeBufferedMessage) PublishTime() time.Time        { return time.Time{} }
+func (m *fakeBufferedMessage) EventTime() time.Time          { return time.Time{} }
+func (m *fakeBufferedMessage) Key() string                   { return "" }
+func (m *fakeBufferedMessage) Topic() string {
+	return "persistent://public/default/dataseap-ingest-log"
+}
+
+func TestSinkAddAndFlush(t *testing.T) {
+	message := func(id, dataType string) *fakeBufferedMessage {
+		payload, _ := json.Marshal(map[string]interface{}{"id": "e" + id, "dataType": dataType, "data": map[string]interface{}{"msg": id}})
+		return &fakeBufferedMessage{id: "m" + id, payload: payload}
+	}
+	logs := []*fakeBufferedMessage{message("1", "log"), message("2", "log")}
+
+	tests := []struct {
+		name         string
+		messages     []*fakeBufferedMessage
+		loadErr      error
+		filtered     int
+		wantTables   []string
+		wantAddAcked []string // 添加时即确认的消息 Messages acknowledged when added
+		wantAcked    []string
+		wantNacked   []string
+		wantLoaded   []string
+		wantStages   []model.DeadLetterStage
+	}{
+		{
+			name:       "loaded batch is acknowledged",
+			messages:   logs,
+			wantTables: []string{"events"},
+			wantAcked:  []string{"m1", "m2"},
+			wantLoaded: []string{"e1", "e2"},
+		},
+		{
+			name:       "events are batched per target table",
+			messages:   []*fakeBufferedMessage{message("1", "log"), message("2", "Audit"), message("3", "log")},
+			wantTables: []string{"audit_events", "events"},
+			wantAcked:  []string{"m1", "m2", "m3"},
+			wantLoaded: []string{"e1", "e2", "e3"},
+		},
+		{
+			name:       "failed load is negatively acknowledged",
+			messages:   logs,
+			loadErr:    errors.New(errors.NetworkError, "connection refused"),
+			wantTables: []string{"events"},
+			wantNacked: []string{"m1", "m2"},
+		},
+		{
+			name:       "filtered rows are dead-lettered and the batch acknowledged",
+			messages:   logs,
+			filtered:   1,
+			wantTables: []string{"events"},
+			wantAcked:  []string{"m1", "m2"},
+			wantLoaded: []string{"e1"},
+			wantStages: []model.DeadLetterStage{model.DeadLetterStageFiltered, model.DeadLetterStageFiltered},
+		},
+		{
+			name:         "undecodable message is dead-lettered and acknowledged",
+			messages:     []*fakeBufferedMessage{{id: "m1", payload: []byte("{not json")}, message("2", "log")},
+			wantTables:   []string{"events"},
+			wantAddAcked: []string{"m1"},
+			wantAcked:    []string{"m1", "m2"},
+			wantLoaded:   []string{"e2"},
+			wantStages:   []model.DeadLetterStage{model.DeadLetterStageValidation},
+		},
+		{
+			name:         "message without a target table is dead-lettered and acknowledged",
+			messages:     []*fakeBufferedMessage{message("1", "metric")},
+			wantAddAcked: []string{"m1"},
+			wantAcked:    []string{"m1"},
+			wantStages:   []model.DeadLetterStage{model.DeadLetterStageRouting},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSinkLoads{err: tt.loadErr, filtered: tt.filtered}
+			store := &fakeDeadLetterStore{}
+			cfg := config.IngestionConfig{Database: "logs", TableMapping: map[string]string{"log": "events", "audit": "audit_events"}}
+			w := &SinkWorker{loader: newBatchLoader(client, cfg), cfg: cfg, deadLetters: newDeadLetterWriter(store, client, cfg.DeadLetter)}
+			ctx := context.Background()
+
+			acks := &fakeAcks{}
+			pending := make(map[string]*sinkBatch)
+			for _, msg := range tt.messages {
+				w.add(ctx, acks, pending, msg)
+			}
+			if !reflect.DeepEqual(acks.acked, tt.wantAddAcked) || len(acks.nacked) != 0 {
+				t.Errorf("add() acked %q, nacked %q, want acked %q", acks.acked, acks.nacked, tt.wantAddAcked)
+			}
+			var tables []string
+			for table := range pending {
+				tables = append(tables, table)
+			}
+			sort.Strings(tables)
+			if !reflect.DeepEqual(tables, tt.wantTables) {
+				t.Fatalf("add() batched tables %q, want %q", tables, tt.wantTables)
+			}
+
+			for _, table := range tables {
+				w.flush(ctx, acks, table, pending[table])
+			}
+			sort.Strings(acks.acked)
+			sort.Strings(client.loaded)
+			if !reflect.DeepEqual(acks.acked, tt.wantAcked) || !reflect.DeepEqual(acks.nacked, tt.wantNacked) {
+				t.Errorf("flush() acked %q, nacked %q, want acked %q, nacked %q", acks.acked, acks.nacked, tt.wantAcked, tt.wantNacked)
+			}
+			if !reflect.DeepEqual(client.loaded, tt.wantLoaded) {
+				t.Errorf("flush() loaded events %q, want %q", client.loaded, tt.wantLoaded)
+			}
+			var stages []model.DeadLetterStage
+			for _, letter := range store.letters {
+				stages = append(stages, letter.Stage)
+			}
+			if !reflect.DeepEqual(stages, tt.wantStages) {
+				t.Errorf("dead letter stages %v, want %v", stages, tt.wantStages)
+			}
+		})
+	}
+}
+
+func TestSinkFlushTwoPhaseFailure(t *testing.T) {
+	client := newFakeSinkStarRocks()
+	client.commitErr = errors.New(errors.NetworkError, "connection reset")
+	cfg := config.IngestionConfig{Database: "logs", TwoPhaseCommit: true, TableMapping: map[string]string{"log": "events"}}
+	w := &SinkWorker{loader: newBatchLoader(client, cfg), cfg: cfg, ledger: newSinkLedger(client, cfg)}
+	ctx := context.Background()
+
+	acks := &fakeAcks{}
+	pending := make(map[string]*sinkBatch)
+	for _, id := range []string{"1", "2"} {
+		payload, _ := json.Marshal(map[string]interface{}{"id": "e" + id, "dataType": "log"})
+		w.add(ctx, acks, pending, &fakeBufferedMessage{id: "m" + id, payload: payload})
+	}
+	w.flush(ctx, acks, "events", pending["events"])
+
+	sort.Strings(acks.nacked)
+	if len(acks.acked) != 0 || !reflect.DeepEqual(acks.nacked, []string{"m1", "m2"}) {
+		t.Errorf("flush() acked %q, nacked %q, want nacked [m1 m2]", acks.acked, acks.nacked)
+	}
+	if len(client.loaded) != 0 {
+		t.Errorf("flush() loaded events %q, want none", client.loaded)
+	}
+}
+
+func TestSinkFlushSkipsRedeliveredMessages(t *testing.T) {
+	message := func(id string) *fakeBufferedMessage {
+		payload, _ := json.Marshal(map[string]interface{}{"id": "e" + id, "dataType": "log", "data": map[string]interface{}{"msg": id}})
+		return &fakeBufferedMessage{id: "m" + id, payload: payload}
+	}
+
+	tests := []struct {
+		name       string
+		prepareErr bool // 第一个批次预提交后未能提交也未能中止 The first batch is prepared but neither committed nor aborted
+		wantLoaded []string
+		wantAcked  []string
+	}{
+		{
+			name:       "committed batch lost its acknowledgements",
+			wantLoaded: []string{"e1", "e2", "e3"},
+			wantAcked:  []string{"m2", "m3"},
+		},
+		{
+			name:       "prepared batch is committed instead of reloaded",
+			prepareErr: true,
+			wantLoaded: []string{"e1", "e2", "e3"},
+			wantAcked:  []string{"m2", "m3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeSinkStarRocks()
+			cfg := config.IngestionConfig{Database: "logs", TwoPhaseCommit: true, TableMapping: map[string]string{"log": "events"}}
+			w := &SinkWorker{loader: newBatchLoader(client, cfg), cfg: cfg, ledger: newSinkLedger(client, cfg)}
+			ctx := context.Background()
+
+			// The first batch is loaded, but its acknowledgements never reach the broker.
+			if tt.prepareErr {
+				client.commitErr = errors.New(errors.NetworkError, "connection reset")
+				client.abortErr = errors.New(errors.NetworkError, "connection reset")
+			}
+			first := make(map[string]*sinkBatch)
+			w.add(ctx, &fakeAcks{}, first, message("1"))
+			w.add(ctx, &fakeAcks{}, first, message("2"))
+			w.flush(ctx, &fakeAcks{}, "events", first["events"])
+			client.commitErr, client.abortErr = nil, nil
+
+			// m2 is redelivered to another consumer and batched with m3.
+			acks := &fakeAcks{}
+			second := make(map[string]*sinkBatch)
+			w.add(ctx, acks, second, message("2"))
+			w.add(ctx, acks, second, message("3"))
+			w.flush(ctx, acks, "events", second["events"])
+
+			sort.Strings(client.loaded)
+			if !reflect.DeepEqual(client.loaded, tt.wantLoaded) {
+				t.Errorf("loaded events %q, want %q", client.loaded, tt.wantLoaded)
+			}
+			sort.Strings(acks.acked)
+			if !reflect.DeepEqual(acks.acked, tt.wantAcked) || len(acks.nacked) != 0 {
+				t.Errorf("acked %q, nacked %q, want acked %q", acks.acked, acks.nacked, tt.wantAcked)
+			}
+		})
+	}
+}
+
+func TestSinkLabel(t *testing.T) {
+	if sinkLabel("events", []string{"a", "b"}) != sinkLabel("events", []string{"b", "a"}) {
+		t.Error("sinkLabel() depends on the order of the messages")
+	}
+	if sinkLabel("events", []string{"a", "b"}) == sinkLabel("events", []string{"b", "c"}) {
+		t.Error("sinkLabel() is the same for batches of different messages")
+	}
+	if label := sinkLabel(strings.Repeat("t", 200), []string{"a"}); len(label) > maxLabelLength {
+		t.Errorf("sinkLabel() length = %d, want at most %d", len(label), maxLabelLength)
+	}
+}
@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// rulesFile is the layout of the transformation rules file.
+// rulesFile 是转换规则文件的结构。
+type rulesFile struct {
+	Rules []RuleSet `mapstructure:"rules"`
+}
+
+// NewFromConfig creates a Transformer from the rules file named in cfg. When cfg.HotReload is set the file
+// is watched and valid changes replace the active rules; invalid changes are logged and ignored.
+// NewFromConfig 根据cfg中指定的规则文件创建Transformer。cfg.HotReload为true时会监听该文件，
+// 合法的修改会替换当前规则，非法的修改只记录日志并被忽略。
+func NewFromConfig(cfg config.TransformConfig) (*Transformer, error) {
+	if cfg.RulesFile == "" {
+		return nil, errors.New(errors.ConfigError, "transformation rules file is not configured")
+	}
+	v := viper.New()
+	v.SetConfigFile(cfg.RulesFile)
+	rules, err := readRules(v)
+	if err != nil {
+		return nil, err
+	}
+	t, err := New(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.HotReload {
+		v.OnConfigChange(func(e fsnotify.Event) {
+			l := logger.L().With("method", "reloadTransformRules", "file", e.Name)
+			rules, err := readRules(v)
+			if err == nil {
+				err = t.Update(rules)
+			}
+			if err != nil {
+				l.Errorw("Failed to reload transformation rules, keeping previous rules", "error", err)
+				return
+			}
+			l.Infow("Transformation rules reloaded", "rule_sets", len(rules))
+		})
+		v.WatchConfig()
+	}
+	return t, nil
+}
+
+func readRules(v *viper.Viper) ([]RuleSet, error) {
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Wrapf(err, errors.ConfigError, "failed to read transformation rules file %s", v.ConfigFileUsed())
+	}
+	var file rulesFile
+	if err := v.Unmarshal(&file); err != nil {
+		return nil, errors.Wrapf(err, errors.ConfigError, "failed to parse transformation rules file %s", v.ConfigFileUsed())
+	}
+	return file.Rules, nil
+}
@@ -0,0 +1,84 @@
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+func TestNewFromConfigHotReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	// write replaces the rules file atomically, so that the watcher never reads it half written.
+	write := func(content string) {
+		t.Helper()
+		tmp := file + ".tmp"
+		if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write rules file: %v", err)
+		}
+		if err := os.Rename(tmp, file); err != nil {
+			t.Fatalf("failed to replace rules file: %v", err)
+		}
+	}
+	write("rules:\n  - dataSourceId: fw\n    drop: [raw]\n")
+
+	transformer, err := NewFromConfig(config.TransformConfig{Enabled: true, RulesFile: file, HotReload: true})
+	if err != nil {
+		t.Fatalf("NewFromConfig() error = %v", err)
+	}
+	apply := func() map[string]interface{} {
+		event := &model.RawEvent{DataSourceID: "fw", Data: map[string]interface{}{"raw": "x", "srcIP": "10.0.0.1"}}
+		if err := transformer.Apply(event); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		return event.Data
+	}
+	// waitFor polls until the active rules transform the event into want, as reloading is asynchronous.
+	waitFor := func(want map[string]interface{}) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for got := apply(); !reflect.DeepEqual(got, want); got = apply() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Apply() data = %v, want %v", got, want)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	waitFor(map[string]interface{}{"srcIP": "10.0.0.1"})
+
+	write("rules:\n  - dataSourceId: fw\n    rename:\n      - from: srcIP\n        to: src_ip\n")
+	waitFor(map[string]interface{}{"raw": "x", "src_ip": "10.0.0.1"})
+
+	// An invalid change is ignored and the previous rules stay active.
+	write("rules:\n  - dataSourceId: fw\n    rename:\n      - from: srcIP\n")
+	time.Sleep(200 * time.Millisecond)
+	waitFor(map[string]interface{}{"raw": "x", "src_ip": "10.0.0.1"})
+}
+
+func TestNewFromConfigErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(invalid, []byte("rules:\n  - drop: [raw]\n"), 0o644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.TransformConfig
+	}{
+		{name: "no rules file", cfg: config.TransformConfig{Enabled: true}},
+		{name: "missing rules file", cfg: config.TransformConfig{Enabled: true, RulesFile: filepath.Join(t.TempDir(), "missing.yaml")}},
+		{name: "rule set without a data source", cfg: config.TransformConfig{Enabled: true, RulesFile: invalid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFromConfig(tt.cfg); err == nil {
+				t.Error("NewFromConfig() error = nil, want an error")
+			}
+		})
+	}
+}
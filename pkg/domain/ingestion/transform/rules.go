@@ -0,0 +1,93 @@
+package transform
+
+import (
+	"fmt"
+)
+
+// WildcardSource is the DataSourceID of the rule set applied to sources without their own rules.
+// WildcardSource 是应用于没有专属规则的数据源的规则集的DataSourceID。
+const WildcardSource = "*"
+
+// RuleSet declares how the Data of events from one data source is transformed.
+// Steps run in a fixed order: flatten, rename, enrich, parseTime, defaults, drop.
+// Rules are declared as lists rather than maps so that field names keep their case when loaded through viper.
+// RuleSet 声明来自某个数据源的事件Data如何被转换。
+// 步骤按固定顺序执行：flatten、rename、enrich、parseTime、defaults、drop。
+// 规则以列表而非map声明，以便通过viper加载时字段名保留大小写。
+type RuleSet struct {
+	DataSourceID string        `mapstructure:"dataSourceId" json:"dataSourceId" yaml:"dataSourceId"` // 数据源ID，"*"表示默认规则 Data source ID, "*" for the default rules
+	Flatten      *FlattenRule  `mapstructure:"flatten" json:"flatten,omitempty" yaml:"flatten,omitempty"`
+	Rename       []RenameRule  `mapstructure:"rename" json:"rename,omitempty" yaml:"rename,omitempty"`
+	Enrich       []EnrichRule  `mapstructure:"enrich" json:"enrich,omitempty" yaml:"enrich,omitempty"`
+	ParseTime    []TimeRule    `mapstructure:"parseTime" json:"parseTime,omitempty" yaml:"parseTime,omitempty"`
+	Defaults     []DefaultRule `mapstructure:"defaults" json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Drop         []string      `mapstructure:"drop" json:"drop,omitempty" yaml:"drop,omitempty"`
+}
+
+// FlattenRule flattens nested maps in Data into top-level fields joined by Separator.
+// FlattenRule 将Data中的嵌套map展开为以Separator连接的顶层字段。
+type FlattenRule struct {
+	Separator string `mapstructure:"separator" json:"separator" yaml:"separator"` // 默认为"_" Defaults to "_"
+	MaxDepth  int    `mapstructure:"maxDepth" json:"maxDepth" yaml:"maxDepth"`    // 0表示不限制 0 means unlimited
+}
+
+// RenameRule moves the value of From to To, replacing any existing value of To.
+// RenameRule 将From的值移动到To，覆盖To已有的值。
+type RenameRule struct {
+	From string `mapstructure:"from" json:"from" yaml:"from"`
+	To   string `mapstructure:"to" json:"to" yaml:"to"`
+}
+
+// EnrichRule copies the value of event tag Tag into field Field when the tag is present.
+// EnrichRule 在事件标签Tag存在时，将其值复制到字段Field。
+type EnrichRule struct {
+	Tag       string `mapstructure:"tag" json:"tag" yaml:"tag"`
+	Field     string `mapstructure:"field" json:"field" yaml:"field"`
+	Overwrite bool   `mapstructure:"overwrite" json:"overwrite" yaml:"overwrite"` // 是否覆盖已有字段 Whether to replace an existing field
+}
+
+// TimeRule parses a string field with the given layouts and rewrites it in the StarRocks DATETIME format.
+// TimeRule 使用给定格式解析字符串字段，并以StarRocks DATETIME格式重写。
+type TimeRule struct {
+	Field        string   `mapstructure:"field" json:"field" yaml:"field"`
+	Layouts      []string `mapstructure:"layouts" json:"layouts" yaml:"layouts"`                // Go时间格式，依次尝试 Go time layouts, tried in order
+	Location     string   `mapstructure:"location" json:"location" yaml:"location"`             // 无时区格式所用的时区，默认为UTC Zone for layouts without offset, defaults to UTC
+	Target       string   `mapstructure:"target" json:"target" yaml:"target"`                   // 输出字段，默认为Field Output field, defaults to Field
+	SetTimestamp bool     `mapstructure:"setTimestamp" json:"setTimestamp" yaml:"setTimestamp"` // 同时设置事件的Timestamp Also set the event Timestamp
+}
+
+// DefaultRule sets Field to Value when the field is absent or null.
+// DefaultRule 在字段缺失或为null时将Field设置为Value。
+type DefaultRule struct {
+	Field string      `mapstructure:"field" json:"field" yaml:"field"`
+	Value interface{} `mapstructure:"value" json:"value" yaml:"value"`
+}
+
+// validate checks that the rule set is well formed.
+// validate 检查规则集是否合法。
+func (r *RuleSet) validate() error {
+	if r.DataSourceID == "" {
+		return fmt.Errorf("rule set without dataSourceId")
+	}
+	for i, rule := range r.Rename {
+		if rule.From == "" || rule.To == "" {
+			return fmt.Errorf("rule set '%s': rename[%d] needs both 'from' and 'to'", r.DataSourceID, i)
+		}
+	}
+	for i, rule := range r.Enrich {
+		if rule.Tag == "" || rule.Field == "" {
+			return fmt.Errorf("rule set '%s': enrich[%d] needs both 'tag' and 'field'", r.DataSourceID, i)
+		}
+	}
+	for i, rule := range r.ParseTime {
+		if rule.Field == "" || len(rule.Layouts) == 0 {
+			return fmt.Errorf("rule set '%s': parseTime[%d] needs a 'field' and at least one layout", r.DataSourceID, i)
+		}
+	}
+	for i, rule := range r.Defaults {
+		if rule.Field == "" {
+			return fmt.Errorf("rule set '%s': defaults[%d] needs a 'field'", r.DataSourceID, i)
+		}
+	}
+	return nil
+}
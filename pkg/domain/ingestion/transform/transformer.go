@@ -0,0 +1,199 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+const defaultSeparator = "_"
+
+// compiledRuleSet is a validated RuleSet with its time zones resolved.
+// compiledRuleSet 是已校验并解析了时区的RuleSet。
+type compiledRuleSet struct {
+	RuleSet
+	locations []*time.Location // 与ParseTime一一对应 One per ParseTime rule
+}
+
+// Transformer applies the rule set of each event's data source. Rules can be replaced at any
+// time with Update, which is how hot reloading swaps them in.
+// Transformer 对每个事件应用其数据源的规则集。规则可随时通过Update替换，热加载即以此方式生效。
+type Transformer struct {
+	mu      sync.RWMutex
+	sources map[string]*compiledRuleSet // DataSourceID -> rules
+}
+
+// New creates a Transformer from the given rule sets.
+// New 根据给定的规则集创建Transformer。
+func New(rules []RuleSet) (*Transformer, error) {
+	t := &Transformer{}
+	if err := t.Update(rules); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Update validates the rule sets and atomically replaces the active ones. On error the active rules are kept.
+// Update 校验规则集并原子地替换当前生效的规则，出错时保留当前规则。
+func (t *Transformer) Update(rules []RuleSet) error {
+	sources := make(map[string]*compiledRuleSet, len(rules))
+	for i := range rules {
+		compiled, err := compile(rules[i])
+		if err != nil {
+			return errors.Wrap(err, errors.ConfigError, "invalid transformation rules")
+		}
+		if _, dup := sources[compiled.DataSourceID]; dup {
+			return errors.Newf(errors.ConfigError, "duplicate transformation rules for data source '%s'", compiled.DataSourceID)
+		}
+		sources[compiled.DataSourceID] = compiled
+	}
+	t.mu.Lock()
+	t.sources = sources
+	t.mu.Unlock()
+	return nil
+}
+
+// Apply transforms event.Data using the rules of event.DataSourceID, falling back to the wildcard rules, and marks
+// the event as transformed. Events without Data, without matching rules or already transformed are left
+// untouched, and so is an event whose rules fail.
+// Apply 使用event.DataSourceID的规则（若无则使用通配规则）转换event.Data，并将事件标记为已转换。
+// 没有Data、没有匹配规则或已转换的事件保持不变，规则执行失败的事件同样保持不变。
+func (t *Transformer) Apply(event *model.RawEvent) error {
+	if event == nil || event.Data == nil || event.Transformed {
+		return nil
+	}
+	t.mu.RLock()
+	rules, ok := t.sources[event.DataSourceID]
+	if !ok {
+		rules = t.sources[WildcardSource]
+	}
+	t.mu.RUnlock()
+	if rules == nil {
+		return nil
+	}
+	return rules.apply(event)
+}
+
+func compile(r RuleSet) (*compiledRuleSet, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	c := &compiledRuleSet{RuleSet: r, locations: make([]*time.Location, len(r.ParseTime))}
+	for i, rule := range r.ParseTime {
+		c.locations[i] = time.UTC
+		if rule.Location != "" {
+			loc, err := time.LoadLocation(rule.Location)
+			if err != nil {
+				return nil, fmt.Errorf("rule set '%s': parseTime[%d] has unknown location '%s': %w", r.DataSourceID, i, rule.Location, err)
+			}
+			c.locations[i] = loc
+		}
+	}
+	return c, nil
+}
+
+func (c *compiledRuleSet) apply(event *model.RawEvent) error {
+	// The rules work on a copy of Data, which replaces it only once all of them succeeded.
+	// 规则作用于Data的副本，全部成功后才替换Data。
+	data := make(map[string]interface{}, len(event.Data))
+	if c.Flatten != nil {
+		sep := c.Flatten.Separator
+		if sep == "" {
+			sep = defaultSeparator
+		}
+		flatten(data, "", event.Data, sep, c.Flatten.MaxDepth, 1)
+	} else {
+		for k, v := range event.Data {
+			data[k] = v
+		}
+	}
+	var timestamp time.Time
+
+	for _, rule := range c.Rename {
+		if v, ok := data[rule.From]; ok {
+			delete(data, rule.From)
+			data[rule.To] = v
+		}
+	}
+
+	for _, rule := range c.Enrich {
+		tag, ok := event.Tags[rule.Tag]
+		if !ok {
+			continue
+		}
+		if _, exists := data[rule.Field]; exists && !rule.Overwrite {
+			continue
+		}
+		data[rule.Field] = tag
+	}
+
+	for i, rule := range c.ParseTime {
+		raw, ok := data[rule.Field]
+		if !ok || raw == nil {
+			continue
+		}
+		s, ok := raw.(string)
+		if !ok {
+			return errors.Newf(errors.InvalidArgument, "field '%s' is not a string time value", rule.Field)
+		}
+		parsed, err := parseTime(s, rule.Layouts, c.locations[i])
+		if err != nil {
+			return errors.Wrapf(err, errors.InvalidArgument, "failed to parse time field '%s'", rule.Field)
+		}
+		target := rule.Target
+		if target == "" {
+			target = rule.Field
+		}
+		data[target] = parsed.UTC().Format(constants.DefaultTimeFormat)
+		if rule.SetTimestamp {
+			timestamp = parsed
+		}
+	}
+
+	for _, rule := range c.Defaults {
+		if v, ok := data[rule.Field]; !ok || v == nil {
+			data[rule.Field] = rule.Value
+		}
+	}
+
+	for _, field := range c.Drop {
+		delete(data, field)
+	}
+
+	event.Data, event.Transformed = data, true
+	if !timestamp.IsZero() {
+		event.Timestamp, event.TimestampDefaulted = timestamp, false
+	}
+	return nil
+}
+
+// flatten copies src into dst, joining nested map keys with sep. Maps deeper than maxDepth are kept as values.
+// flatten 将src复制到dst，嵌套map的键以sep连接；超过maxDepth的map作为值保留。
+func flatten(dst map[string]interface{}, prefix string, src map[string]interface{}, sep string, maxDepth, depth int) {
+	for k, v := range src {
+		key := k
+		if prefix != "" {
+			key = prefix + sep + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && (maxDepth <= 0 || depth <= maxDepth) {
+			flatten(dst, key, nested, sep, maxDepth, depth+1)
+			continue
+		}
+		dst[key] = v
+	}
+}
+
+func parseTime(s string, layouts []string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("value %q matches none of the layouts %v", s, layouts)
+}
@@ -0,0 +1,174 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+)
+
+func TestTransformerApply(t *testing.T) {
+	received := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		rules         RuleSet
+		sourceID      string // 默认为 "fw" Defaults to "fw"
+		data          map[string]interface{}
+		tags          map[string]string
+		transformed   bool
+		want          map[string]interface{}
+		wantTimestamp time.Time // 为零时Timestamp不变 Timestamp is unchanged when zero
+		wantErr       bool
+	}{
+		{
+			name:  "flatten with the default separator",
+			rules: RuleSet{Flatten: &FlattenRule{}},
+			data:  map[string]interface{}{"src": map[string]interface{}{"ip": "10.0.0.1", "geo": map[string]interface{}{"cc": "DE"}}, "action": "deny"},
+			want:  map[string]interface{}{"src_ip": "10.0.0.1", "src_geo_cc": "DE", "action": "deny"},
+		},
+		{
+			name:  "flatten up to a depth",
+			rules: RuleSet{Flatten: &FlattenRule{Separator: ".", MaxDepth: 1}},
+			data:  map[string]interface{}{"src": map[string]interface{}{"ip": "10.0.0.1", "geo": map[string]interface{}{"cc": "DE"}}},
+			want:  map[string]interface{}{"src.ip": "10.0.0.1", "src.geo": map[string]interface{}{"cc": "DE"}},
+		},
+		{
+			name:  "rename replaces the target",
+			rules: RuleSet{Rename: []RenameRule{{From: "srcip", To: "src_ip"}, {From: "missing", To: "other"}}},
+			data:  map[string]interface{}{"srcip": "10.0.0.1", "src_ip": "old"},
+			want:  map[string]interface{}{"src_ip": "10.0.0.1"},
+		},
+		{
+			name:  "enrich from tags",
+			rules: RuleSet{Enrich: []EnrichRule{{Tag: "site", Field: "site"}, {Tag: "env", Field: "env"}, {Tag: "zone", Field: "zone", Overwrite: true}}},
+			data:  map[string]interface{}{"env": "sent", "zone": "sent"},
+			tags:  map[string]string{"site": "fra1", "env": "prod", "zone": "dmz"},
+			want:  map[string]interface{}{"site": "fra1", "env": "sent", "zone": "dmz"},
+		},
+		{
+			name:          "parse time into a target and the timestamp",
+			rules:         RuleSet{ParseTime: []TimeRule{{Field: "ts", Layouts: []string{time.RFC3339, "02/01/2006 15:04"}, Location: "Europe/Berlin", Target: "event_time", SetTimestamp: true}}},
+			data:          map[string]interface{}{"ts": "01/05/2024 14:30"},
+			want:          map[string]interface{}{"ts": "01/05/2024 14:30", "event_time": "2024-05-01 12:30:00"},
+			wantTimestamp: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "unparsable time leaves the event untouched",
+			rules:   RuleSet{Rename: []RenameRule{{From: "srcip", To: "src_ip"}}, ParseTime: []TimeRule{{Field: "ts", Layouts: []string{time.RFC3339}, SetTimestamp: true}}},
+			data:    map[string]interface{}{"srcip": "10.0.0.1", "ts": "yesterday"},
+			want:    map[string]interface{}{"srcip": "10.0.0.1", "ts": "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:    "non-string time",
+			rules:   RuleSet{ParseTime: []TimeRule{{Field: "ts", Layouts: []string{time.RFC3339}}}},
+			data:    map[string]interface{}{"ts": 1714564800},
+			want:    map[string]interface{}{"ts": 1714564800},
+			wantErr: true,
+		},
+		{
+			name:  "defaults fill absent and null fields",
+			rules: RuleSet{Defaults: []DefaultRule{{Field: "severity", Value: "info"}, {Field: "vendor", Value: "acme"}, {Field: "action", Value: "allow"}}},
+			data:  map[string]interface{}{"vendor": nil, "action": "deny"},
+			want:  map[string]interface{}{"severity": "info", "vendor": "acme", "action": "deny"},
+		},
+		{
+			name:  "drop",
+			rules: RuleSet{Drop: []string{"raw", "missing"}},
+			data:  map[string]interface{}{"raw": "<134>...", "action": "deny"},
+			want:  map[string]interface{}{"action": "deny"},
+		},
+		{
+			name:     "wildcard rules for other sources",
+			rules:    RuleSet{DataSourceID: WildcardSource, Drop: []string{"raw"}},
+			sourceID: "ids",
+			data:     map[string]interface{}{"raw": "x", "action": "deny"},
+			want:     map[string]interface{}{"action": "deny"},
+		},
+		{
+			name:     "no rules for the source",
+			rules:    RuleSet{Drop: []string{"raw"}},
+			sourceID: "ids",
+			data:     map[string]interface{}{"raw": "x"},
+			want:     map[string]interface{}{"raw": "x"},
+		},
+		{
+			name:        "already transformed event is not transformed again",
+			rules:       RuleSet{Rename: []RenameRule{{From: "a", To: "b"}, {From: "b", To: "c"}}},
+			data:        map[string]interface{}{"b": 1},
+			transformed: true,
+			want:        map[string]interface{}{"b": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			if rules.DataSourceID == "" {
+				rules.DataSourceID = "fw"
+			}
+			transformer, err := New([]RuleSet{rules})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			sourceID := tt.sourceID
+			if sourceID == "" {
+				sourceID = "fw"
+			}
+			event := &model.RawEvent{DataSourceID: sourceID, Timestamp: received, Data: tt.data, Tags: tt.tags, Transformed: tt.transformed}
+
+			err = transformer.Apply(event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(event.Data, tt.want) {
+				t.Errorf("Apply() data = %v, want %v", event.Data, tt.want)
+			}
+			wantTimestamp := tt.wantTimestamp
+			if wantTimestamp.IsZero() {
+				wantTimestamp = received
+			}
+			if !event.Timestamp.Equal(wantTimestamp) {
+				t.Errorf("Apply() timestamp = %v, want %v", event.Timestamp, wantTimestamp)
+			}
+			wantTransformed := !tt.wantErr && (tt.transformed || sourceID == rules.DataSourceID || rules.DataSourceID == WildcardSource)
+			if event.Transformed != wantTransformed {
+				t.Errorf("Apply() transformed = %v, want %v", event.Transformed, wantTransformed)
+			}
+		})
+	}
+}
+
+func TestTransformerUpdate(t *testing.T) {
+	transformer, err := New([]RuleSet{{DataSourceID: "fw", Drop: []string{"raw"}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		rules   []RuleSet
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "valid rules replace the active ones", rules: []RuleSet{{DataSourceID: "fw", Drop: []string{"debug"}}}, want: map[string]interface{}{"raw": "x"}},
+		{name: "invalid rules keep the active ones", rules: []RuleSet{{DataSourceID: "fw", Rename: []RenameRule{{From: "a"}}}}, want: map[string]interface{}{"raw": "x"}, wantErr: true},
+		{name: "duplicate sources keep the active ones", rules: []RuleSet{{DataSourceID: "fw"}, {DataSourceID: "fw"}}, want: map[string]interface{}{"raw": "x"}, wantErr: true},
+		{name: "unknown location keeps the active ones", rules: []RuleSet{{DataSourceID: "fw", ParseTime: []TimeRule{{Field: "ts", Layouts: []string{time.RFC3339}, Location: "Mars/Olympus"}}}}, want: map[string]interface{}{"raw": "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := transformer.Update(tt.rules); (err != nil) != tt.wantErr {
+				t.Fatalf("Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			event := &model.RawEvent{DataSourceID: "fw", Data: map[string]interface{}{"raw": "x", "debug": true}}
+			if err := transformer.Apply(event); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(event.Data, tt.want) {
+				t.Errorf("Apply() data = %v, want %v", event.Data, tt.want)
+			}
+		})
+	}
+}
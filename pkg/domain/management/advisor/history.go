@@ -0,0 +1,206 @@
+package advisor
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/domain/management/advisor/model"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
+)
+
+// patternStore remembers the query patterns executed within a time window. It is bounded both by the window and
+// by a maximum number of patterns; beyond that the least recently executed patterns are evicted first.
+// patternStore 记住在时间窗口内执行过的查询模式。它同时受时间窗口与最大模式数量的限制，超出时最先淘汰最久未执行的模式。
+type patternStore struct {
+	mu          sync.Mutex
+	window      time.Duration
+	maxPatterns int
+	entries     map[string]*list.Element
+	order       *list.List // 按最近执行时间排序的*patternEntry，最旧的在前 *patternEntry ordered by last execution, oldest first
+	now         func() time.Time
+}
+
+type patternEntry struct {
+	pattern model.QueryPattern
+	shape   *sqlparse.Shape // 最近一次执行的查询结构 Shape of the query as last executed
+}
+
+// execution is a single recorded execution of a query.
+// execution 是一次已记录的查询执行。
+type execution struct {
+	fingerprint string
+	normalized  string
+	sql         string
+	database    string
+	tables      []string
+	shape       *sqlparse.Shape
+	duration    time.Duration
+	scanRows    int64
+	scanBytes   int64
+}
+
+func newPatternStore(window time.Duration, maxPatterns int) *patternStore {
+	return &patternStore{
+		window:      window,
+		maxPatterns: maxPatterns,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		now:         time.Now,
+	}
+}
+
+// record adds an execution to its pattern, creating the pattern on its first execution.
+// record 将一次执行计入其查询模式，首次执行时创建该模式。
+func (s *patternStore) record(e *execution) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.expire(now)
+
+	key := e.database + "\x00" + e.fingerprint
+	elem, ok := s.entries[key]
+	if !ok {
+		elem = s.order.PushBack(&patternEntry{pattern: model.QueryPattern{
+			Fingerprint:   e.fingerprint,
+			NormalizedSQL: e.normalized,
+			Database:      e.database,
+			FirstSeen:     now,
+		}})
+		s.entries[key] = elem
+	} else {
+		s.order.MoveToBack(elem)
+	}
+
+	entry := elem.Value.(*patternEntry)
+	p := &entry.pattern
+	p.SampleSQL, p.Tables, p.LastSeen = e.sql, e.tables, now
+	p.Executions++
+	p.TotalDuration += e.duration
+	if e.duration > p.MaxDuration {
+		p.MaxDuration = e.duration
+	}
+	p.TotalScanRows += e.scanRows
+	p.TotalScanBytes += e.scanBytes
+	entry.shape = e.shape
+
+	for s.order.Len() > s.maxPatterns {
+		s.remove(s.order.Front())
+	}
+}
+
+// snapshot returns copies of the patterns executed within the window.
+// snapshot 返回在时间窗口内执行过的查询模式的副本。
+func (s *patternStore) snapshot() []*patternEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	entries := make([]*patternEntry, 0, s.order.Len())
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		entry := *elem.Value.(*patternEntry)
+		entry.pattern.Tables = append([]string(nil), entry.pattern.Tables...)
+		entries = append(entries, &entry)
+	}
+	return entries
+}
+
+// expire drops the patterns last executed before the window. Patterns are kept in execution order, so it stops
+// at the first live one.
+// expire 丢弃最近执行时间早于时间窗口的模式。模式按执行顺序保存，因此遇到第一个未过期的模式即停止。
+func (s *patternStore) expire(now time.Time) {
+	cutoff := now.Add(-s.window)
+	for elem := s.order.Front(); elem != nil; elem = s.order.Front() {
+		if elem.Value.(*patternEntry).pattern.LastSeen.After(cutoff) {
+			return
+		}
+		s.remove(elem)
+	}
+}
+
+func (s *patternStore) remove(elem *list.Element) {
+	entry := s.order.Remove(elem).(*patternEntry)
+	delete(s.entries, entry.pattern.Database+"\x00"+entry.pattern.Fingerprint)
+}
+
+// searchedField is a table field a full-text search targeted.
+// searchedField 是全文检索所针对的表字段。
+type searchedField struct {
+	database string
+	table    string
+	field    string // 小写 Lower-case
+}
+
+type searchCount struct {
+	searches int64
+	lastSeen time.Time
+}
+
+// searchStore counts the full-text searches per targeted field within a time window. It is bounded both by the
+// window and by a maximum number of fields; beyond that the least recently searched fields are evicted first.
+// searchStore 在时间窗口内按目标字段统计全文检索次数。它同时受时间窗口与最大字段数量的限制，超出时最先淘汰最久未被检索的字段。
+type searchStore struct {
+	mu        sync.Mutex
+	window    time.Duration
+	maxFields int
+	fields    map[searchedField]*searchCount
+	now       func() time.Time
+}
+
+func newSearchStore(window time.Duration, maxFields int) *searchStore {
+	return &searchStore{
+		window:    window,
+		maxFields: maxFields,
+		fields:    make(map[searchedField]*searchCount),
+		now:       time.Now,
+	}
+}
+
+// record counts a search of each of fields.
+// record 为fields中的每个字段计入一次检索。
+func (s *searchStore) record(fields []searchedField) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.expire(now)
+	for _, f := range fields {
+		c, ok := s.fields[f]
+		if !ok {
+			c = &searchCount{}
+			s.fields[f] = c
+		}
+		c.searches++
+		c.lastSeen = now
+	}
+	for len(s.fields) > s.maxFields {
+		var oldest searchedField
+		var oldestSeen time.Time
+		for f, c := range s.fields {
+			if oldestSeen.IsZero() || c.lastSeen.Before(oldestSeen) {
+				oldest, oldestSeen = f, c.lastSeen
+			}
+		}
+		delete(s.fields, oldest)
+	}
+}
+
+// snapshot returns the number of searches of each field searched within the window.
+// snapshot 返回在时间窗口内被检索过的每个字段的检索次数。
+func (s *searchStore) snapshot() map[searchedField]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.now())
+	counts := make(map[searchedField]int64, len(s.fields))
+	for f, c := range s.fields {
+		counts[f] = c.searches
+	}
+	return counts
+}
+
+func (s *searchStore) expire(now time.Time) {
+	cutoff := now.Add(-s.window)
+	for f, c := range s.fields {
+		if !c.lastSeen.After(cutoff) {
+			delete(s.fields, f)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package advisor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPatternStore(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := newPatternStore(time.Hour, 2)
+	s.now = func() time.Time { return now }
+
+	record := func(database, fingerprint string, duration time.Duration, scanRows int64) {
+		s.record(&execution{fingerprint: fingerprint, normalized: "n-" + fingerprint, sql: "sql-" + fingerprint, database: database, tables: []string{database + ".t"}, duration: duration, scanRows: scanRows, scanBytes: 10 * scanRows})
+	}
+	fingerprints := func() []string {
+		var got []string
+		for _, entry := range s.snapshot() {
+			got = append(got, entry.pattern.Database+"/"+entry.pattern.Fingerprint)
+		}
+		return got
+	}
+
+	record("logs", "a", time.Second, 100)
+	now = now.Add(time.Minute)
+	record("logs", "a", 3*time.Second, 50)
+	record("other", "a", time.Second, 1)
+
+	entries := s.snapshot()
+	if len(entries) != 2 {
+		t.Fatalf("snapshot() = %d patterns, want 2", len(entries))
+	}
+	p := entries[0].pattern
+	if p.Executions != 2 || p.TotalDuration != 4*time.Second || p.MaxDuration != 3*time.Second || p.TotalScanRows != 150 || p.TotalScanBytes != 1500 {
+		t.Errorf("pattern = %+v, want 2 executions totalling 4s, at most 3s, 150 rows and 1500 bytes", p)
+	}
+	if !p.FirstSeen.Equal(now.Add(-time.Minute)) || !p.LastSeen.Equal(now) || p.AvgDuration() != 2*time.Second {
+		t.Errorf("pattern seen %v to %v, average %v", p.FirstSeen, p.LastSeen, p.AvgDuration())
+	}
+
+	// Snapshots are copies.
+	entries[0].pattern.Tables[0] = "changed"
+	if got := s.snapshot()[0].pattern.Tables; !reflect.DeepEqual(got, []string{"logs.t"}) {
+		t.Errorf("snapshot() tables = %v after changing a copy", got)
+	}
+
+	// Executing a pattern again makes it the most recent; the least recent is evicted beyond the maximum.
+	record("logs", "a", time.Second, 1)
+	record("logs", "b", time.Second, 1)
+	if got, want := fingerprints(), []string{"logs/a", "logs/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("patterns after eviction = %v, want %v", got, want)
+	}
+
+	// Patterns last executed before the window expire.
+	now = now.Add(30 * time.Minute)
+	record("logs", "b", time.Second, 1)
+	now = now.Add(45 * time.Minute)
+	if got, want := fingerprints(), []string{"logs/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("patterns after expiry = %v, want %v", got, want)
+	}
+	now = now.Add(time.Hour)
+	if got := fingerprints(); len(got) != 0 {
+		t.Errorf("patterns after the window = %v, want none", got)
+	}
+}
+
+func TestSearchStore(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := newSearchStore(time.Hour, 2)
+	s.now = func() time.Time { return now }
+	msg := searchedField{database: "logs", table: "events", field: "msg"}
+	host := searchedField{database: "logs", table: "events", field: "host"}
+	user := searchedField{database: "logs", table: "events", field: "user"}
+
+	s.record([]searchedField{msg, host})
+	now = now.Add(time.Minute)
+	s.record([]searchedField{msg})
+	if got, want := s.snapshot(), map[searchedField]int64{msg: 2, host: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot() = %v, want %v", got, want)
+	}
+
+	// The least recently searched field is evicted beyond the maximum.
+	now = now.Add(time.Minute)
+	s.record([]searchedField{user})
+	if got, want := s.snapshot(), map[searchedField]int64{msg: 2, user: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot() after eviction = %v, want %v", got, want)
+	}
+
+	// Fields last searched before the window expire.
+	now = now.Add(59 * time.Minute)
+	if got, want := s.snapshot(), map[searchedField]int64{user: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot() after expiry = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,302 @@
+package advisor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+	"github.com/turtacn/dataseap/pkg/domain/management/advisor/model"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// equalityOperators are the filter operators a bitmap index serves.
+// equalityOperators 是位图索引能够加速的过滤运算符。
+var equalityOperators = map[string]bool{"=": true, "<=>": true, "IN": true}
+
+// textOperators are the filter operators an inverted index serves. LIKE is only served with the "none" parser,
+// MATCH_ANY and MATCH_ALL with a tokenizing one.
+// textOperators 是倒排索引能够加速的过滤运算符。LIKE仅在使用 "none" 分词器时被加速，MATCH_ANY与MATCH_ALL需要
+// 进行分词的分词器。
+var textOperators = map[string]bool{"LIKE": true, "MATCH_ANY": true, "MATCH_ALL": true}
+
+// bitmapTypes are the column types a bitmap index can be created on.
+// bitmapTypes 是可以创建位图索引的列类型。
+var bitmapTypes = map[enum.DataType]bool{
+	enum.DataTypeBoolean: true, enum.DataTypeTinyInt: true, enum.DataTypeSmallInt: true, enum.DataTypeInt: true,
+	enum.DataTypeBigInt: true, enum.DataTypeLargeInt: true, enum.DataTypeDate: true, enum.DataTypeDateTime: true,
+	enum.DataTypeChar: true, enum.DataTypeVarchar: true, enum.DataTypeString: true,
+}
+
+// textTypes are the column types an inverted index can be created on.
+// textTypes 是可以创建倒排索引的列类型。
+var textTypes = map[enum.DataType]bool{enum.DataTypeChar: true, enum.DataTypeVarchar: true, enum.DataTypeString: true}
+
+// Parsers an inverted index can be created with.
+// 创建倒排索引时可用的分词器。
+const (
+	parserNone    = "none"
+	parserEnglish = "english"
+	parserChinese = "chinese"
+)
+
+// columnKey identifies a column of a table; the column name is lower-case.
+// columnKey 标识表中的一列，列名为小写。
+type columnKey struct {
+	database string
+	table    string
+	column   string
+}
+
+type columnUsage struct {
+	equality  int64
+	like      int64
+	match     int64
+	searches  int64
+	operators map[string]bool
+}
+
+func (u *columnUsage) text() int64 { return u.like + u.match + u.searches }
+
+// collectColumnUsage counts, per column, the executions of the recorded queries filtering on it and the full-text
+// searches targeting it.
+// collectColumnUsage 按列统计过滤该列的已记录查询的执行次数，以及以该列为目标的全文检索次数。
+func collectColumnUsage(entries []*patternEntry, searches map[searchedField]int64) map[columnKey]*columnUsage {
+	usage := map[columnKey]*columnUsage{}
+	get := func(key columnKey) *columnUsage {
+		u, ok := usage[key]
+		if !ok {
+			u = &columnUsage{operators: map[string]bool{}}
+			usage[key] = u
+		}
+		return u
+	}
+
+	for _, entry := range entries {
+		if entry.shape == nil {
+			continue
+		}
+		for _, p := range entry.shape.Predicates {
+			if p.Table == "" || (!equalityOperators[p.Operator] && !textOperators[p.Operator]) {
+				continue
+			}
+			database := entry.pattern.Database
+			for _, t := range entry.shape.Tables {
+				if t.Table == p.Table && t.Database != "" {
+					database = t.Database
+					break
+				}
+			}
+			u := get(columnKey{database: database, table: p.Table, column: p.Column})
+			u.operators[p.Operator] = true
+			switch p.Operator {
+			case "LIKE":
+				u.like += entry.pattern.Executions
+			case "MATCH_ANY", "MATCH_ALL":
+				u.match += entry.pattern.Executions
+			default:
+				u.equality += entry.pattern.Executions
+			}
+		}
+	}
+	for f, n := range searches {
+		get(columnKey{database: f.database, table: f.table, column: f.field}).searches += n
+	}
+	return usage
+}
+
+// indexCandidate is a column an index may be recommended on, before its statistics are sampled.
+// indexCandidate 是在采样统计信息之前可能被推荐索引的列。
+type indexCandidate struct {
+	key       columnKey
+	field     *metadatamodel.FieldSchema
+	indexType enum.IndexType
+	usage     *columnUsage
+}
+
+// recommendTableIndexes recommends indexes on the used columns of one table. Columns that are already indexed,
+// lead the sort key or cannot carry the index are skipped; the rest are sampled in a single statement.
+// recommendTableIndexes 为一张表中被使用的列推荐索引。跳过已有索引、位于排序键首列或无法创建该索引的列，其余列通过
+// 一条语句采样。
+func (s *serviceImpl) recommendTableIndexes(ctx context.Context, database, table string, usage map[string]*columnUsage) []*model.IndexRecommendation {
+	l := logger.L().With("method", "recommendTableIndexes", "db", database, "table", table)
+
+	schema, err := s.metadataSvc.GetTableSchema(ctx, database, table)
+	if err != nil {
+		l.Warnw("Failed to read table schema, skipping table", "error", err)
+		return nil
+	}
+	indexes, err := s.metadataSvc.ListIndexes(ctx, database, table)
+	if err != nil {
+		l.Warnw("Failed to list indexes, skipping table", "error", err)
+		return nil
+	}
+	indexed := map[string]bool{}
+	for _, idx := range indexes {
+		for _, f := range idx.Fields {
+			indexed[strings.ToLower(f)] = true
+		}
+	}
+	keysType := strings.ToUpper(schema.KeysType)
+	duplicateKeys := keysType == "" || strings.HasPrefix(keysType, "DUPLICATE")
+	var leadingKey string
+	if len(schema.KeyColumns) > 0 {
+		leadingKey = strings.ToLower(schema.KeyColumns[0])
+	}
+
+	var candidates []*indexCandidate
+	for _, field := range schema.Fields {
+		column := strings.ToLower(field.Name)
+		u, ok := usage[column]
+		if !ok || indexed[column] {
+			continue
+		}
+		c := &indexCandidate{key: columnKey{database: database, table: table, column: column}, field: field, usage: u}
+		switch {
+		case textTypes[field.DataType] && u.text() >= s.minExecutions && u.text() >= u.equality:
+			// StarRocks builds inverted indexes on tables with duplicate keys only.
+			if !duplicateKeys {
+				continue
+			}
+			c.indexType = enum.IndexTypeInverted
+		case bitmapTypes[field.DataType] && u.equality >= s.minExecutions:
+			// The prefix index already serves the leading sort key column; aggregate and unique key tables only take
+			// bitmap indexes on key columns.
+			if column == leadingKey || (!duplicateKeys && !strings.HasPrefix(keysType, "PRIMARY") && !field.IsPrimaryKey) {
+				continue
+			}
+			c.indexType = enum.IndexTypeBitmap
+		default:
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	columns := make([]string, len(candidates))
+	for i, c := range candidates {
+		columns[i] = c.field.Name
+	}
+	stats, err := s.metadataSvc.SampleTableStatistics(ctx, database, table, columns, s.indexSampleRows)
+	if err != nil {
+		l.Warnw("Failed to sample column statistics, skipping table", "error", err)
+		return nil
+	}
+	rows := stats.Rows
+	if rows < stats.SampledRows {
+		rows = stats.SampledRows
+	}
+
+	var recommendations []*model.IndexRecommendation
+	for i, c := range candidates {
+		if i >= len(stats.Columns) {
+			break
+		}
+		cs := stats.Columns[i]
+		if cs.NonNull == 0 {
+			continue // nothing to learn from an empty or all-null sample
+		}
+		r := &model.IndexRecommendation{
+			Index: &metadatamodel.IndexDefinition{
+				IndexName:    "idx_" + sanitizeName(c.field.Name),
+				TableName:    table,
+				DatabaseName: database,
+				IndexType:    c.indexType.String(),
+				Fields:       []string{c.field.Name},
+			},
+			Usage:      c.usage.toModel(),
+			Statistics: cs,
+			TableRows:  rows,
+		}
+		r.ID = sqlparse.FingerprintNormalized(strings.Join([]string{database, table, c.key.column, r.Index.IndexType}, "\x00"))
+
+		if c.indexType == enum.IndexTypeBitmap {
+			if cs.Distinct > int64(s.bitmapMaxCardinality) {
+				continue
+			}
+			r.EstimatedStorageBytes = estimateBitmapIndexBytes(rows, cs)
+			r.Index.Comment = "Recommended by the query advisor for equality filters"
+			r.Reason = fmt.Sprintf("%d executions filter %s.%s with %s; about %d distinct values in %d sampled rows",
+				c.usage.equality, table, c.field.Name, strings.Join(r.Usage.Operators, ", "), cs.Distinct, stats.SampledRows)
+		} else {
+			parser := suggestParser(c.usage, cs)
+			r.Index.Properties = map[string]string{"parser": parser}
+			r.EstimatedStorageBytes = estimateInvertedIndexBytes(rows, cs, parser, stats.SampledRows)
+			r.Index.Comment = "Recommended by the query advisor for text search"
+			var uses []string
+			if n := c.usage.like + c.usage.match; n > 0 {
+				uses = append(uses, fmt.Sprintf("%d executions filter %s.%s with %s", n, table, c.field.Name, strings.Join(r.Usage.Operators, ", ")))
+			}
+			if c.usage.searches > 0 {
+				uses = append(uses, fmt.Sprintf("%d full-text searches target %s.%s", c.usage.searches, table, c.field.Name))
+			}
+			r.Reason = fmt.Sprintf("%s; values average %.0f characters, suggesting parser %q", strings.Join(uses, " and "), cs.AvgLength, parser)
+		}
+		recommendations = append(recommendations, r)
+	}
+	return recommendations
+}
+
+func (u *columnUsage) toModel() *model.ColumnUsage {
+	m := &model.ColumnUsage{EqualityFilters: u.equality, TextFilters: u.like + u.match, Searches: u.searches}
+	for op := range u.operators {
+		m.Operators = append(m.Operators, op)
+	}
+	sort.Strings(m.Operators)
+	return m
+}
+
+// suggestParser picks the parser of an inverted index. Columns only filtered with LIKE need the "none" parser,
+// which indexes whole values; otherwise values mostly without spaces are single terms too. Text averaging more than
+// 1.5 bytes per character is mostly multi-byte, taken as Chinese; other text is tokenized as English.
+// suggestParser 选择倒排索引的分词器。仅以LIKE过滤的列需要对整个值建立索引的 "none" 分词器；此外大多不含空格的值
+// 也视为单个词项。平均每字符超过1.5字节的文本以多字节字符为主，视为中文；其他文本按英文分词。
+func suggestParser(u *columnUsage, cs *metadatamodel.ColumnStatistics) string {
+	switch {
+	case u.match+u.searches == 0:
+		return parserNone
+	case cs.AvgLength > 0 && cs.AvgBytes/cs.AvgLength > 1.5:
+		return parserChinese
+	case cs.WhitespaceRatio < 0.1:
+		return parserNone
+	}
+	return parserEnglish
+}
+
+// estimateBitmapIndexBytes estimates a bitmap index as a dictionary of the distinct values plus one compressed
+// bitmap per value: a dense bitmap takes a bit per row, a sparse one about two bytes per row it holds.
+// estimateBitmapIndexBytes 将位图索引估计为不同值字典加上每个值一个压缩位图：稠密位图每行占一位，稀疏位图每个包含的行
+// 约占两字节。
+func estimateBitmapIndexBytes(rows int64, cs *metadatamodel.ColumnStatistics) int64 {
+	distinct := math.Max(float64(cs.Distinct), 1)
+	dictionary := distinct * math.Max(cs.AvgBytes, 1)
+	bitmaps := math.Min(distinct*float64(rows)/8, float64(rows)*2)
+	return int64(dictionary + bitmaps)
+}
+
+// estimateInvertedIndexBytes estimates an inverted index as about four bytes of postings per term occurrence plus
+// a dictionary of the distinct terms. Whole values are single terms; English words average six characters with
+// their separator and Chinese words two characters.
+// estimateInvertedIndexBytes 将倒排索引估计为每次词项出现约四字节的倒排表加上不同词项的字典。整个值视为单个词项；
+// 英文单词连同分隔符平均六个字符，中文词平均两个字符。
+func estimateInvertedIndexBytes(rows int64, cs *metadatamodel.ColumnStatistics, parser string, sampledRows int64) int64 {
+	nonNullRows := float64(rows)
+	if sampledRows > 0 {
+		nonNullRows *= float64(cs.NonNull) / float64(sampledRows)
+	}
+	termsPerValue, termBytes := 1.0, math.Max(cs.AvgBytes, 1)
+	switch parser {
+	case parserEnglish:
+		termsPerValue, termBytes = math.Max(cs.AvgLength/6, 1), 6
+	case parserChinese:
+		termsPerValue, termBytes = math.Max(cs.AvgLength/2, 1), 6
+	}
+	distinctTerms := math.Min(float64(cs.Distinct)*termsPerValue, nonNullRows*termsPerValue)
+	return int64(nonNullRows*termsPerValue*4 + distinctTerms*termBytes)
+}
@@ -0,0 +1,235 @@
+package advisor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// fakeIndexMetadata serves the schema, indexes and sampled column statistics of one table.
+type fakeIndexMetadata struct {
+	metadata.Service
+	schema  *metadatamodel.TableSchema
+	indexes []*metadatamodel.IndexDefinition
+	columns map[string]*metadatamodel.ColumnStatistics
+	sampled []string
+}
+
+func (f *fakeIndexMetadata) GetTableSchema(_ context.Context, _, _ string) (*metadatamodel.TableSchema, error) {
+	return f.schema, nil
+}
+
+func (f *fakeIndexMetadata) ListIndexes(_ context.Context, _, _ string) ([]*metadatamodel.IndexDefinition, error) {
+	return f.indexes, nil
+}
+
+func (f *fakeIndexMetadata) SampleTableStatistics(_ context.Context, _, _ string, columns []string, _ int) (*metadatamodel.TableStatistics, error) {
+	f.sampled = columns
+	stats := &metadatamodel.TableStatistics{Rows: 1_000_000, SampledRows: 1000}
+	for _, column := range columns {
+		stats.Columns = append(stats.Columns, f.columns[column])
+	}
+	return stats, nil
+}
+
+func TestCollectColumnUsage(t *testing.T) {
+	entries := []*patternEntry{
+		testPattern(t, "logs", "SELECT * FROM events WHERE level = 'error' AND host IN ('a') AND msg LIKE '%x%' AND status > 500", 4, time.Second, 0),
+		testPattern(t, "logs", "SELECT * FROM archive.events e JOIN hosts h ON e.host = h.name WHERE MATCH_ANY(e.msg, 'timeout') OR e.msg MATCH_ALL 'disk full' AND h.region = 'eu' AND zone = 'a'", 3, time.Second, 0),
+		testPattern(t, "logs", "SELECT level FROM events WHERE level = 'warn'", 2, time.Second, 0),
+	}
+	searches := map[searchedField]int64{{database: "logs", table: "events", field: "msg"}: 5}
+
+	got := map[columnKey]columnUsage{}
+	for key, u := range collectColumnUsage(entries, searches) {
+		got[key] = *u
+	}
+	want := map[columnKey]columnUsage{
+		{"logs", "events", "level"}:  {equality: 6, operators: map[string]bool{"=": true}},
+		{"logs", "events", "host"}:   {equality: 4, operators: map[string]bool{"IN": true}},
+		{"logs", "events", "msg"}:    {like: 4, searches: 5, operators: map[string]bool{"LIKE": true}},
+		{"archive", "events", "msg"}: {match: 3, operators: map[string]bool{"MATCH_ALL": true}},
+		{"logs", "hosts", "region"}:  {equality: 3, operators: map[string]bool{"=": true}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectColumnUsage() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecommendTableIndexes(t *testing.T) {
+	field := func(name string, typ enum.DataType) *metadatamodel.FieldSchema {
+		return &metadatamodel.FieldSchema{Name: name, DataType: typ}
+	}
+	column := func(name string, distinct int64, avgLength, avgBytes, whitespace float64) *metadatamodel.ColumnStatistics {
+		return &metadatamodel.ColumnStatistics{Name: name, Distinct: distinct, NonNull: 1000, AvgLength: avgLength, AvgBytes: avgBytes, WhitespaceRatio: whitespace}
+	}
+	columns := map[string]*metadatamodel.ColumnStatistics{
+		"Level":  column("Level", 5, 4, 4, 0),
+		"msg":    column("msg", 900, 60, 60, 0.9),
+		"note":   column("note", 900, 20, 60, 0.1),
+		"path":   column("path", 900, 30, 30, 0),
+		"status": column("status", 40, 3, 3, 0),
+		"user":   column("user", 5000, 8, 8, 0),
+		"empty":  {Name: "empty"},
+	}
+	usage := map[string]*columnUsage{
+		"ts":     {equality: 10, operators: map[string]bool{"=": true}},
+		"level":  {equality: 10, operators: map[string]bool{"=": true, "IN": true}},
+		"host":   {equality: 10, operators: map[string]bool{"=": true}},
+		"msg":    {match: 8, searches: 4, operators: map[string]bool{"MATCH_ANY": true}},
+		"note":   {searches: 6, operators: map[string]bool{}},
+		"path":   {like: 7, operators: map[string]bool{"LIKE": true}},
+		"status": {equality: 6, like: 1, operators: map[string]bool{"=": true}},
+		"user":   {equality: 10, operators: map[string]bool{"=": true}},
+		"empty":  {equality: 10, operators: map[string]bool{"=": true}},
+		"rare":   {equality: 2, operators: map[string]bool{"=": true}},
+		"ratio":  {equality: 10, operators: map[string]bool{"=": true}},
+	}
+	fields := []*metadatamodel.FieldSchema{
+		field("ts", enum.DataTypeDateTime), field("Level", enum.DataTypeVarchar), field("host", enum.DataTypeVarchar),
+		field("msg", enum.DataTypeString), field("note", enum.DataTypeString), field("path", enum.DataTypeVarchar),
+		field("status", enum.DataTypeInt), field("user", enum.DataTypeVarchar), field("empty", enum.DataTypeVarchar),
+		field("rare", enum.DataTypeVarchar), field("ratio", enum.DataTypeDouble),
+	}
+
+	type rec struct {
+		column string
+		typ    string
+		parser string
+		bytes  int64
+	}
+	tests := []struct {
+		name        string
+		keysType    string
+		keyColumns  []string
+		primaryKeys []string
+		want        []rec
+		wantSampled []string
+	}{
+		{
+			name:       "duplicate keys",
+			keysType:   "DUPLICATE",
+			keyColumns: []string{"ts"},
+			want: []rec{
+				{column: "Level", typ: "BITMAP", bytes: 625020},
+				{column: "msg", typ: "INVERTED", parser: parserEnglish, bytes: 40054000},
+				{column: "note", typ: "INVERTED", parser: parserChinese, bytes: 40054000},
+				{column: "path", typ: "INVERTED", parser: parserNone, bytes: 4027000},
+				{column: "status", typ: "BITMAP", bytes: 2000120},
+			},
+			wantSampled: []string{"Level", "msg", "note", "path", "status", "user", "empty"},
+		},
+		{
+			name:        "aggregate keys take bitmap indexes on key columns only",
+			keysType:    "AGGREGATE",
+			keyColumns:  []string{"ts", "Level"},
+			primaryKeys: []string{"ts", "Level"},
+			want:        []rec{{column: "Level", typ: "BITMAP", bytes: 625020}},
+			wantSampled: []string{"Level"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &metadatamodel.TableSchema{KeysType: tt.keysType, KeyColumns: tt.keyColumns}
+			for _, f := range fields {
+				f := *f
+				for _, key := range tt.primaryKeys {
+					f.IsPrimaryKey = f.IsPrimaryKey || key == f.Name
+				}
+				schema.Fields = append(schema.Fields, &f)
+			}
+			meta := &fakeIndexMetadata{
+				schema:  schema,
+				indexes: []*metadatamodel.IndexDefinition{{IndexName: "idx_host", Fields: []string{"HOST"}}},
+				columns: columns,
+			}
+			s := &serviceImpl{metadataSvc: meta, minExecutions: 5, indexSampleRows: 1000, bitmapMaxCardinality: 1000}
+
+			var got []rec
+			for _, r := range s.recommendTableIndexes(context.Background(), "logs", "events", usage) {
+				got = append(got, rec{column: r.Index.Fields[0], typ: r.Index.IndexType, parser: r.Index.Properties["parser"], bytes: r.EstimatedStorageBytes})
+				if r.ID == "" || r.Index.DatabaseName != "logs" || r.Index.TableName != "events" || r.TableRows != 1_000_000 || r.Reason == "" {
+					t.Errorf("recommendation %+v is incomplete", r)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recommendTableIndexes() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(meta.sampled, tt.wantSampled) {
+				t.Errorf("sampled columns %v, want %v", meta.sampled, tt.wantSampled)
+			}
+		})
+	}
+}
+
+func TestSuggestParser(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage columnUsage
+		stats metadatamodel.ColumnStatistics
+		want  string
+	}{
+		{name: "LIKE only", usage: columnUsage{like: 5}, stats: metadatamodel.ColumnStatistics{AvgLength: 40, AvgBytes: 40, WhitespaceRatio: 0.9}, want: parserNone},
+		{name: "multi-byte text", usage: columnUsage{match: 5}, stats: metadatamodel.ColumnStatistics{AvgLength: 20, AvgBytes: 58, WhitespaceRatio: 0.9}, want: parserChinese},
+		{name: "single terms", usage: columnUsage{searches: 5}, stats: metadatamodel.ColumnStatistics{AvgLength: 12, AvgBytes: 12, WhitespaceRatio: 0.05}, want: parserNone},
+		{name: "english text", usage: columnUsage{match: 1, like: 9}, stats: metadatamodel.ColumnStatistics{AvgLength: 80, AvgBytes: 81, WhitespaceRatio: 0.6}, want: parserEnglish},
+		{name: "no length statistics", usage: columnUsage{match: 5}, stats: metadatamodel.ColumnStatistics{AvgBytes: 10, WhitespaceRatio: 0.5}, want: parserEnglish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestParser(&tt.usage, &tt.stats); got != tt.want {
+				t.Errorf("suggestParser() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateIndexBytes(t *testing.T) {
+	bitmaps := []struct {
+		name  string
+		rows  int64
+		stats metadatamodel.ColumnStatistics
+		want  int64
+	}{
+		// 10 dense bitmaps of a bit per row, plus 10 values of 5 bytes.
+		{name: "low cardinality", rows: 1_000_000, stats: metadatamodel.ColumnStatistics{Distinct: 10, AvgBytes: 5}, want: 1_250_050},
+		// Sparse bitmaps hold every row once, at two bytes a row.
+		{name: "high cardinality", rows: 1_000_000, stats: metadatamodel.ColumnStatistics{Distinct: 1000, AvgBytes: 8}, want: 2_008_000},
+		{name: "no statistics", rows: 100, want: 13},
+	}
+	for _, tt := range bitmaps {
+		t.Run("bitmap "+tt.name, func(t *testing.T) {
+			if got := estimateBitmapIndexBytes(tt.rows, &tt.stats); got != tt.want {
+				t.Errorf("estimateBitmapIndexBytes() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	inverted := []struct {
+		name        string
+		rows        int64
+		stats       metadatamodel.ColumnStatistics
+		parser      string
+		sampledRows int64
+		want        int64
+	}{
+		// Half the rows are non-null, with 10 English terms of 6 bytes each.
+		{name: "english", rows: 1_000_000, stats: metadatamodel.ColumnStatistics{NonNull: 500, Distinct: 400, AvgLength: 60, AvgBytes: 60}, parser: parserEnglish, sampledRows: 1000, want: 20_024_000},
+		// Whole values are single terms.
+		{name: "whole values", rows: 1_000_000, stats: metadatamodel.ColumnStatistics{NonNull: 1000, Distinct: 50, AvgLength: 20, AvgBytes: 20}, parser: parserNone, sampledRows: 1000, want: 4_001_000},
+		// Without a sample every row counts; Chinese words are two characters.
+		{name: "chinese", rows: 1000, stats: metadatamodel.ColumnStatistics{NonNull: 100, Distinct: 100, AvgLength: 20, AvgBytes: 60}, parser: parserChinese, want: 46_000},
+	}
+	for _, tt := range inverted {
+		t.Run("inverted "+tt.name, func(t *testing.T) {
+			if got := estimateInvertedIndexBytes(tt.rows, &tt.stats, tt.parser, tt.sampledRows); got != tt.want {
+				t.Errorf("estimateInvertedIndexBytes() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package advisor
+
+import (
+	"context"
+
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/domain/management/advisor/model"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	querymodel "github.com/turtacn/dataseap/pkg/domain/query/model"
+)
+
+// Service learns query patterns from executed SQL and recommends physical design changes that speed them up.
+// Service 从执行的SQL中学习查询模式，并推荐能够加速这些查询的物理设计变更。
+type Service interface {
+	// RecordQuery records an executed query with its statistics. It implements query.Recorder.
+	// RecordQuery 记录一次执行的查询及其统计信息，实现了query.Recorder。
+	RecordQuery(ctx context.Context, req *querymodel.SQLQueryRequest, result *querymodel.SQLQueryResult)
+
+	// ListQueryPatterns lists the recorded query patterns, most expensive first.
+	// ListQueryPatterns 列出记录的查询模式，开销最大的在前。
+	ListQueryPatterns(ctx context.Context, pagination *commontypes.PaginationRequest) (patterns []*model.QueryPattern, total int64, err error)
+
+	// RecommendMaterializedViews recommends materialized views for the frequent, expensive aggregation patterns,
+	// optionally only those in databaseName, best first.
+	// RecommendMaterializedViews 为高频且昂贵的聚合查询模式推荐物化视图，可选仅限databaseName中的，收益最大的在前。
+	RecommendMaterializedViews(ctx context.Context, databaseName string) ([]*model.MaterializedViewRecommendation, error)
+
+	// AcceptMaterializedViewRecommendation creates the materialized view of a recommendation and returns its
+	// definition.
+	// AcceptMaterializedViewRecommendation 创建推荐的物化视图并返回其定义。
+	AcceptMaterializedViewRecommendation(ctx context.Context, id string, opts *model.AcceptOptions) (*metadatamodel.MaterializedViewDefinition, error)
+
+	// RecordSearch records the fields a full-text search targeted. It implements query.Recorder.
+	// RecordSearch 记录全文检索的目标字段，实现了query.Recorder。
+	RecordSearch(ctx context.Context, req *querymodel.FullTextSearchRequest, result *querymodel.FullTextSearchResult)
+
+	// RecommendIndexes recommends bitmap indexes for low-cardinality columns filtered by equality and inverted
+	// indexes for text columns filtered by pattern or searched, optionally only in databaseName, most used first.
+	// Column statistics are sampled from the tables involved.
+	// RecommendIndexes 为按等值过滤的低基数列推荐位图索引，为按模式过滤或被检索的文本列推荐倒排索引，可选仅限
+	// databaseName中的，使用最多的在前。列统计从相关的表中采样。
+	RecommendIndexes(ctx context.Context, databaseName string) ([]*model.IndexRecommendation, error)
+
+	// AcceptIndexRecommendation creates the index of a recommendation and returns its definition.
+	// AcceptIndexRecommendation 创建推荐的索引并返回其定义。
+	AcceptIndexRecommendation(ctx context.Context, id string, opts *model.IndexAcceptOptions) (*metadatamodel.IndexDefinition, error)
+}
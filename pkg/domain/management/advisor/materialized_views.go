@@ -0,0 +1,363 @@
+package advisor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/domain/management/advisor/model"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
+)
+
+// dimensionOperators are the filter operators whose columns become dimensions of a recommended view, so that
+// the filtered queries can still be answered from it. Text search operators such as LIKE and MATCH_ANY are left
+// out: their columns rarely make good grouping keys.
+// dimensionOperators 是其列会成为推荐视图维度的过滤运算符，使带过滤条件的查询仍可由视图回答。LIKE、MATCH_ANY等
+// 文本检索运算符不在其中：它们的列很少适合作为分组键。
+var dimensionOperators = map[string]bool{
+	"=": true, "<=>": true, "IN": true, "<": true, ">": true, "<=": true, ">=": true, "BETWEEN": true,
+}
+
+// maxAliasLength bounds the generated column aliases and view names.
+// maxAliasLength 限制生成的列别名与视图名称的长度。
+const maxAliasLength = 64
+
+// mvCandidate collects the aggregation patterns one materialized view can serve: those reading the same FROM
+// clause and grouping by the same expressions.
+// mvCandidate 收集一个物化视图可以服务的聚合查询模式：读取相同FROM子句并按相同表达式分组的模式。
+type mvCandidate struct {
+	database   string
+	from       string
+	tables     []sqlparse.TableRef
+	dimensions *exprSet
+	aggregates *exprSet
+	patterns   []*model.QueryPattern
+}
+
+// exprSet is an ordered set of SQL expressions, compared by exprKey.
+// exprSet 是有序的SQL表达式集合，按exprKey比较。
+type exprSet struct {
+	exprs []string
+	keys  map[string]bool
+}
+
+func newExprSet() *exprSet { return &exprSet{keys: map[string]bool{}} }
+
+func (s *exprSet) add(expr string) {
+	key := exprKey(expr)
+	if !s.keys[key] {
+		s.keys[key] = true
+		s.exprs = append(s.exprs, expr)
+	}
+}
+
+// groupCandidates groups the simple aggregation patterns into view candidates.
+// groupCandidates 将简单聚合查询模式分组为视图候选。
+func groupCandidates(entries []*patternEntry) []*mvCandidate {
+	candidates := map[string]*mvCandidate{}
+	var order []string
+	for _, entry := range entries {
+		shape := entry.shape
+		if shape == nil || !shape.IsAggregation() {
+			continue
+		}
+		database := entry.pattern.Database
+		if database == "" {
+			database = shape.Tables[0].Database
+		}
+		if database == "" {
+			continue
+		}
+
+		groupKeys := make([]string, 0, len(shape.GroupBy))
+		for _, expr := range shape.GroupBy {
+			groupKeys = append(groupKeys, exprKey(expr))
+		}
+		sort.Strings(groupKeys)
+		key := strings.Join([]string{database, exprKey(shape.From), strings.Join(groupKeys, ",")}, "\x00")
+
+		c, ok := candidates[key]
+		if !ok {
+			c = &mvCandidate{database: database, from: shape.From, tables: shape.Tables, dimensions: newExprSet(), aggregates: newExprSet()}
+			for _, expr := range shape.GroupBy {
+				c.dimensions.add(expr)
+			}
+			candidates[key] = c
+			order = append(order, key)
+		}
+		pattern := entry.pattern
+		c.patterns = append(c.patterns, &pattern)
+
+		for _, p := range shape.Predicates {
+			if column := filterDimension(p, shape); column != "" {
+				c.dimensions.add(column)
+			}
+		}
+		for _, p := range shape.Projections {
+			switch {
+			case p.Aggregate == "":
+			case p.Aggregate == "AVG" && !p.Distinct:
+				// An average is re-aggregated from its sum and count.
+				c.aggregates.add("SUM(" + p.Argument + ")")
+				c.aggregates.add("COUNT(" + p.Argument + ")")
+			default:
+				c.aggregates.add(p.Expr)
+			}
+		}
+	}
+
+	result := make([]*mvCandidate, 0, len(order))
+	for _, key := range order {
+		result = append(result, candidates[key])
+	}
+	return result
+}
+
+// filterDimension returns the column a predicate filters on, qualified as the query refers to its table, or ""
+// when the column should not become a dimension. A column that a grouping expression already derives from, such
+// as a timestamp grouped by day, is not added: grouping by it would defeat the pre-aggregation.
+// filterDimension 返回谓词所过滤的列，按查询引用其表的方式限定；列不应作为维度时返回 ""。已被分组表达式派生使用的列
+// (例如按天分组的时间戳) 不会被加入：按该列分组会使预聚合失效。
+func filterDimension(p sqlparse.Predicate, shape *sqlparse.Shape) string {
+	if !dimensionOperators[p.Operator] {
+		return ""
+	}
+	for _, expr := range shape.GroupBy {
+		tokens, err := sqlparse.Tokenize(expr)
+		if err != nil {
+			continue
+		}
+		if _, plain := plainColumn(tokens); plain {
+			continue
+		}
+		for _, t := range tokens {
+			if (t.Kind == sqlparse.TokenIdentifier || t.Kind == sqlparse.TokenQuotedIdentifier) && strings.EqualFold(t.Value, p.Column) {
+				return ""
+			}
+		}
+	}
+	if len(shape.Tables) == 1 {
+		return quoteIdentifier(p.Column)
+	}
+	if p.Table == "" {
+		return "" // ambiguous without a qualifier
+	}
+	for _, t := range shape.Tables {
+		if t.Table == p.Table {
+			qualifier := t.Alias
+			if qualifier == "" {
+				qualifier = t.Table
+			}
+			return quoteIdentifier(qualifier) + "." + quoteIdentifier(p.Column)
+		}
+	}
+	return ""
+}
+
+// recommend turns a candidate into a recommendation, estimating its benefit for a view of viewRows rows; 0 means
+// the size of the view is unknown.
+// recommend 将候选转换为推荐，并按视图行数viewRows估算其收益；0表示视图大小未知。
+func (c *mvCandidate) recommend(viewRows int64) *model.MaterializedViewRecommendation {
+	aliases := map[string]bool{}
+	var selectList, groupBy []string
+	for _, expr := range c.dimensions.exprs {
+		alias := uniqueAlias(dimensionAlias(expr), aliases)
+		selectList = append(selectList, withAlias(expr, alias))
+		groupBy = append(groupBy, expr)
+	}
+	for _, expr := range c.aggregates.exprs {
+		alias := uniqueAlias(sanitizeName(strings.ReplaceAll(expr, "*", "all")), aliases)
+		selectList = append(selectList, withAlias(expr, alias))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s GROUP BY %s", strings.Join(selectList, ", "), c.from, strings.Join(groupBy, ", "))
+	id := sqlparse.FingerprintNormalized(c.database + "\x00" + exprKey(query))
+
+	benefit := &model.Benefit{EstimatedViewRows: viewRows}
+	fingerprints := make([]string, 0, len(c.patterns))
+	for _, p := range c.patterns {
+		fingerprints = append(fingerprints, p.Fingerprint)
+		benefit.Executions += p.Executions
+		benefit.TotalDuration += p.TotalDuration
+		benefit.TotalScanRows += p.TotalScanRows
+	}
+	if benefit.Executions > 0 {
+		benefit.AvgDuration = benefit.TotalDuration / time.Duration(benefit.Executions)
+	}
+	benefit.EstimatedTimeSaved = c.estimateTimeSaved(viewRows)
+
+	tableNames := make([]string, 0, len(c.tables))
+	for _, t := range c.tables {
+		tableNames = append(tableNames, t.Table)
+	}
+	viewName := sanitizeName("mv_" + strings.Join(tableNames, "_"))
+	if len(viewName) > maxAliasLength-9 {
+		viewName = strings.TrimRight(viewName[:maxAliasLength-9], "_")
+	}
+
+	reason := fmt.Sprintf("%d executions of %d GROUP BY query pattern(s) over %s took %s in total, %s on average",
+		benefit.Executions, len(c.patterns), strings.Join(tableNames, ", "),
+		benefit.TotalDuration.Round(time.Millisecond), benefit.AvgDuration.Round(time.Millisecond))
+	if viewRows > 0 && benefit.Executions > 0 && benefit.TotalScanRows > 0 {
+		reason += fmt.Sprintf("; the view holds about %d rows against %d scanned per execution", viewRows, benefit.TotalScanRows/benefit.Executions)
+	}
+
+	return &model.MaterializedViewRecommendation{
+		ID: id,
+		Definition: &metadatamodel.MaterializedViewDefinition{
+			DatabaseName: c.database,
+			ViewName:     viewName + "_" + id[:8],
+			Query:        query,
+			RefreshType:  "ASYNC",
+			Comment:      fmt.Sprintf("Recommended by the query advisor for %d query pattern(s)", len(c.patterns)),
+		},
+		Patterns: fingerprints,
+		Benefit:  benefit,
+		Reason:   reason,
+	}
+}
+
+// estimateTimeSaved estimates the execution time a view of viewRows rows saves the patterns. A query rewritten to
+// read the view scans about viewRows rows instead of those it scanned, and its duration is taken to shrink in
+// proportion. Patterns that scanned no more rows than the view holds, or without scan statistics, save nothing.
+// estimateTimeSaved 估算行数为viewRows的视图为查询模式节省的执行时长。改写为读取视图的查询扫描约viewRows行而非原先
+// 扫描的行数，其时长按比例缩短。扫描行数不多于视图行数或没有扫描统计的模式不节省时间。
+func (c *mvCandidate) estimateTimeSaved(viewRows int64) time.Duration {
+	if viewRows <= 0 {
+		return 0
+	}
+	var saved time.Duration
+	for _, p := range c.patterns {
+		if p.Executions == 0 || p.TotalScanRows == 0 {
+			continue
+		}
+		avgScanRows := float64(p.TotalScanRows) / float64(p.Executions)
+		if avgScanRows <= float64(viewRows) {
+			continue
+		}
+		saved += time.Duration(float64(p.TotalDuration) * (1 - float64(viewRows)/avgScanRows))
+	}
+	return saved
+}
+
+// dimensionColumns returns the lower-case names of the columns the dimensions of a candidate read, in order.
+// dimensionColumns 按顺序返回候选的维度所读取的列的小写名称。
+func (c *mvCandidate) dimensionColumns() []string {
+	seen := map[string]bool{}
+	var columns []string
+	for _, expr := range c.dimensions.exprs {
+		tokens, err := sqlparse.Tokenize(expr)
+		if err != nil {
+			continue
+		}
+		for i, t := range tokens {
+			isName := t.Kind == sqlparse.TokenQuotedIdentifier || (t.Kind == sqlparse.TokenIdentifier && !sqlparse.IsKeyword(t.Text))
+			// Qualifiers and function names are not columns.
+			if !isName || (i+1 < len(tokens) && (tokens[i+1].Text == "." || tokens[i+1].Text == "(")) {
+				continue
+			}
+			if column := strings.ToLower(t.Value); !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
+
+// dimensionAlias names a dimension after its column when it is a plain column reference.
+// dimensionAlias 当维度是普通列引用时以列名命名该维度。
+func dimensionAlias(expr string) string {
+	if tokens, err := sqlparse.Tokenize(expr); err == nil {
+		if column, plain := plainColumn(tokens); plain {
+			return sanitizeName(column)
+		}
+	}
+	return sanitizeName("dim_" + expr)
+}
+
+// plainColumn reports whether tokens are a possibly qualified column reference, returning the column name.
+// plainColumn 报告词法单元是否为可能带限定符的列引用，并返回列名。
+func plainColumn(tokens []sqlparse.Token) (string, bool) {
+	if len(tokens)%2 == 0 {
+		return "", false
+	}
+	for i, t := range tokens {
+		isName := t.Kind == sqlparse.TokenQuotedIdentifier || (t.Kind == sqlparse.TokenIdentifier && !sqlparse.IsKeyword(t.Text))
+		if (i%2 == 0 && !isName) || (i%2 == 1 && t.Text != ".") {
+			return "", false
+		}
+	}
+	return tokens[len(tokens)-1].Value, true
+}
+
+func uniqueAlias(alias string, used map[string]bool) string {
+	if alias == "" {
+		alias = "col"
+	}
+	unique := alias
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", alias, n)
+	}
+	used[unique] = true
+	return unique
+}
+
+func withAlias(expr, alias string) string {
+	if strings.EqualFold(strings.Trim(expr, "`"), alias) {
+		return expr
+	}
+	return expr + " AS " + quoteIdentifier(alias)
+}
+
+// sanitizeName lower-cases s and replaces every run of characters other than letters and digits with "_".
+// sanitizeName 将s转为小写，并将字母与数字以外的连续字符替换为 "_"。
+func sanitizeName(s string) string {
+	var sb strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(s) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			sb.WriteRune(r)
+			underscore = false
+		} else if !underscore && sb.Len() > 0 {
+			sb.WriteByte('_')
+			underscore = true
+		}
+	}
+	name := strings.TrimRight(sb.String(), "_")
+	if len(name) > maxAliasLength {
+		name = strings.TrimRight(name[:maxAliasLength], "_")
+	}
+	return name
+}
+
+// quoteIdentifier quotes an identifier with backticks unless it is a plain lower-case name.
+// quoteIdentifier 除非标识符是普通的小写名称，否则用反引号将其括起。
+func quoteIdentifier(name string) string {
+	if name != "" && !sqlparse.IsKeyword(name) && sanitizeName(name) == name && !('0' <= name[0] && name[0] <= '9') {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// exprKey returns the form expressions are compared by: letter case, quotes of identifiers, whitespace and
+// comments do not matter, but unlike in query fingerprints literals do, since "DATE_TRUNC('day', ts)" and
+// "DATE_TRUNC('hour', ts)" group differently. The expression itself is returned when it cannot be tokenized.
+// exprKey 返回比较表达式所用的形式：大小写、标识符引号、空白与注释不影响比较；与查询指纹不同，字面量会影响比较，
+// 因为 "DATE_TRUNC('day', ts)" 与 "DATE_TRUNC('hour', ts)" 的分组不同。无法进行词法分析时返回表达式本身。
+func exprKey(expr string) string {
+	tokens, err := sqlparse.Tokenize(expr)
+	if err != nil {
+		return expr
+	}
+	for i, t := range tokens {
+		switch t.Kind {
+		case sqlparse.TokenIdentifier:
+			tokens[i].Text = strings.ToLower(t.Text)
+		case sqlparse.TokenQuotedIdentifier:
+			tokens[i].Text, tokens[i].Kind = strings.ToLower(t.Value), sqlparse.TokenIdentifier
+		}
+	}
+	return sqlparse.Render(tokens)
+}
@@ -0,0 +1,252 @@
+package advisor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/domain/management/advisor/model"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
+)
+
+// fakeMetadata serves sampled statistics of one table, with stats[column] distinct values per column.
+type fakeMetadata struct {
+	metadata.Service
+	rows    int64
+	stats   map[string]int64
+	sampled [][]string
+}
+
+func (f *fakeMetadata) SampleTableStatistics(_ context.Context, _, _ string, columns []string, _ int) (*metadatamodel.TableStatistics, error) {
+	f.sampled = append(f.sampled, columns)
+	if f.stats == nil {
+		return nil, errors.New(errors.DatabaseError, "sampling failed")
+	}
+	result := &metadatamodel.TableStatistics{Rows: f.rows, SampledRows: 1000}
+	for _, column := range columns {
+		result.Columns = append(result.Columns, &metadatamodel.ColumnStatistics{Name: column, Distinct: f.stats[column], NonNull: 1000})
+	}
+	return result, nil
+}
+
+// testPattern records executions of a query as a pattern entry.
+func testPattern(t *testing.T, database, sql string, executions int64, duration time.Duration, scanRows int64) *patternEntry {
+	t.Helper()
+	shape, err := sqlparse.Analyze(sql)
+	if err != nil {
+		t.Fatalf("Analyze(%q) error = %v", sql, err)
+	}
+	fingerprint, normalized, err := sqlparse.Fingerprint(sql)
+	if err != nil {
+		t.Fatalf("Fingerprint(%q) error = %v", sql, err)
+	}
+	return &patternEntry{
+		pattern: model.QueryPattern{
+			Fingerprint: fingerprint, NormalizedSQL: normalized, SampleSQL: sql, Database: database,
+			Executions: executions, TotalDuration: duration, TotalScanRows: scanRows,
+		},
+		shape: shape,
+	}
+}
+
+func TestGroupCandidates(t *testing.T) {
+	type candidate struct {
+		database   string
+		from       string
+		dimensions []string
+		aggregates []string
+		patterns   int
+	}
+	tests := []struct {
+		name    string
+		queries []string // 在数据库 "logs" 中执行 Executed in database "logs"
+		want    []candidate
+	}{
+		{
+			name: "patterns grouping alike share a candidate",
+			queries: []string{
+				"SELECT host, COUNT(*) FROM events WHERE level = 'error' GROUP BY host",
+				"SELECT HOST, avg(bytes) FROM `events` WHERE region IN ('eu') AND msg LIKE '%x%' GROUP BY Host",
+			},
+			want: []candidate{{
+				database:   "logs",
+				from:       "events",
+				dimensions: []string{"host", "level", "region"},
+				aggregates: []string{"COUNT(*)", "SUM(bytes)", "COUNT(bytes)"},
+				patterns:   2,
+			}},
+		},
+		{
+			name: "different grouping makes another candidate",
+			queries: []string{
+				"SELECT host, COUNT(*) FROM events GROUP BY host",
+				"SELECT level, COUNT(*) FROM events GROUP BY level",
+				"SELECT host, MAX(bytes) FROM other.events GROUP BY host",
+			},
+			want: []candidate{
+				{database: "logs", from: "events", dimensions: []string{"host"}, aggregates: []string{"COUNT(*)"}, patterns: 1},
+				{database: "logs", from: "events", dimensions: []string{"level"}, aggregates: []string{"COUNT(*)"}, patterns: 1},
+				{database: "logs", from: "other.events", dimensions: []string{"host"}, aggregates: []string{"MAX(bytes)"}, patterns: 1},
+			},
+		},
+		{
+			name: "filters on a grouped expression are not dimensions",
+			queries: []string{
+				"SELECT DATE_TRUNC('day', ts), COUNT(DISTINCT user) FROM events WHERE ts >= '2024-05-01' AND host = 'a' GROUP BY 1",
+			},
+			want: []candidate{{
+				database:   "logs",
+				from:       "events",
+				dimensions: []string{"DATE_TRUNC('day', ts)", "host"},
+				aggregates: []string{"COUNT(DISTINCT user)"},
+				patterns:   1,
+			}},
+		},
+		{
+			name: "joined filters need a qualifier",
+			queries: []string{
+				"SELECT e.host, COUNT(*) FROM events e JOIN hosts h ON e.host = h.name WHERE h.region = 'eu' AND zone = 'a' GROUP BY e.host",
+			},
+			want: []candidate{{
+				database:   "logs",
+				from:       "events e JOIN hosts h ON e.host = h.name",
+				dimensions: []string{"e.host", "h.region"},
+				aggregates: []string{"COUNT(*)"},
+				patterns:   1,
+			}},
+		},
+		{
+			name: "queries that are not simple aggregations are skipped",
+			queries: []string{
+				"SELECT host FROM events WHERE level = 'error'",
+				"SELECT host, COUNT(*) FROM events GROUP BY ROLLUP(host)",
+				"SELECT host, COUNT(*) FROM (SELECT host FROM events) s GROUP BY host",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []*patternEntry
+			for _, sql := range tt.queries {
+				entries = append(entries, testPattern(t, "logs", sql, 10, time.Second, 0))
+			}
+			var got []candidate
+			for _, c := range groupCandidates(entries) {
+				got = append(got, candidate{database: c.database, from: c.from, dimensions: c.dimensions.exprs, aggregates: c.aggregates.exprs, patterns: len(c.patterns)})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupCandidates() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	noDatabase := testPattern(t, "", "SELECT host, COUNT(*) FROM events GROUP BY host", 10, time.Second, 0)
+	if got := groupCandidates([]*patternEntry{noDatabase}); len(got) != 0 {
+		t.Errorf("groupCandidates() of a pattern without a database = %d candidates, want 0", len(got))
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	entries := []*patternEntry{
+		testPattern(t, "logs", "SELECT host, COUNT(*), AVG(bytes) FROM events WHERE level = 'error' GROUP BY host", 10, 20*time.Second, 10_000_000),
+		testPattern(t, "logs", "SELECT host, SUM(bytes) FROM events GROUP BY host", 5, 10*time.Second, 500),
+	}
+	c := groupCandidates(entries)[0]
+
+	tests := []struct {
+		name      string
+		viewRows  int64
+		wantSaved time.Duration
+	}{
+		{name: "view of unknown size", viewRows: 0, wantSaved: 0},
+		// Only the first pattern scans more rows per execution (1,000,000) than the view holds.
+		{name: "small view", viewRows: 1000, wantSaved: time.Duration(float64(20*time.Second) * (1 - 1000.0/1_000_000))},
+		{name: "view larger than the scans", viewRows: 2_000_000, wantSaved: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := c.recommend(tt.viewRows)
+
+			wantQuery := "SELECT host, level, COUNT(*) AS count_all, SUM(bytes) AS sum_bytes, COUNT(bytes) AS count_bytes FROM events GROUP BY host, level"
+			if r.Definition.Query != wantQuery {
+				t.Errorf("recommend() query = %q, want %q", r.Definition.Query, wantQuery)
+			}
+			if r.Definition.DatabaseName != "logs" || r.Definition.ViewName != "mv_events_"+r.ID[:8] || r.Definition.RefreshType != "ASYNC" {
+				t.Errorf("recommend() definition = %+v", r.Definition)
+			}
+			wantBenefit := &model.Benefit{
+				Executions:         15,
+				TotalDuration:      30 * time.Second,
+				AvgDuration:        2 * time.Second,
+				TotalScanRows:      10_000_500,
+				EstimatedViewRows:  tt.viewRows,
+				EstimatedTimeSaved: tt.wantSaved,
+			}
+			if !reflect.DeepEqual(r.Benefit, wantBenefit) {
+				t.Errorf("recommend() benefit = %+v, want %+v", r.Benefit, wantBenefit)
+			}
+			if !reflect.DeepEqual(r.Patterns, []string{entries[0].pattern.Fingerprint, entries[1].pattern.Fingerprint}) {
+				t.Errorf("recommend() patterns = %v", r.Patterns)
+			}
+			if again := c.recommend(tt.viewRows); again.ID != r.ID {
+				t.Errorf("recommend() ID is not stable: %s, then %s", r.ID, again.ID)
+			}
+		})
+	}
+}
+
+func TestEstimateViewRows(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		meta        *fakeMetadata
+		want        int64
+		wantSampled []string
+	}{
+		{
+			name:        "product of the distinct values",
+			sql:         "SELECT host, DATE_TRUNC('day', `ts`), COUNT(*) FROM logs.events WHERE level = 'error' GROUP BY 1, 2",
+			meta:        &fakeMetadata{rows: 1_000_000, stats: map[string]int64{"host": 50, "ts": 30, "level": 4}},
+			want:        6000,
+			wantSampled: []string{"host", "ts", "level"},
+		},
+		{
+			name:        "capped by the table rows",
+			sql:         "SELECT e.user, e.session, COUNT(*) FROM events e GROUP BY e.user, e.session",
+			meta:        &fakeMetadata{rows: 5000, stats: map[string]int64{"user": 1000, "session": 1000}},
+			want:        5000,
+			wantSampled: []string{"user", "session"},
+		},
+		{
+			name:        "sampling fails",
+			sql:         "SELECT host, COUNT(*) FROM events GROUP BY host",
+			meta:        &fakeMetadata{},
+			wantSampled: []string{"host"},
+		},
+		{
+			name: "joins are not estimated",
+			sql:  "SELECT e.host, COUNT(*) FROM events e JOIN hosts h ON e.host = h.name GROUP BY e.host",
+			meta: &fakeMetadata{rows: 10, stats: map[string]int64{"host": 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &serviceImpl{metadataSvc: tt.meta, indexSampleRows: 1000}
+			c := groupCandidates([]*patternEntry{testPattern(t, "logs", tt.sql, 10, time.Second, 0)})[0]
+			if got := s.estimateViewRows(context.Background(), c); got != tt.want {
+				t.Errorf("estimateViewRows() = %d, want %d", got, tt.want)
+			}
+			var sampled []string
+			if len(tt.meta.sampled) > 0 {
+				sampled = tt.meta.sampled[0]
+			}
+			if !reflect.DeepEqual(sampled, tt.wantSampled) {
+				t.Errorf("sampled columns %v, want %v", sampled, tt.wantSampled)
+			}
+		})
+	}
+}
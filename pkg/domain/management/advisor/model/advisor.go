@@ -0,0 +1,154 @@
+package model
+
+import (
+	"time"
+
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// QueryPattern aggregates the executions of the queries sharing a fingerprint, i.e., differing only in
+// literals, letter case, whitespace and comments.
+// QueryPattern 汇总具有相同指纹 (即仅在字面量、大小写、空白与注释上不同) 的查询的执行情况。
+type QueryPattern struct {
+	// Fingerprint 规范化查询的指纹。
+	// Fingerprint Fingerprint of the normalized query.
+	Fingerprint string `json:"fingerprint"`
+
+	// NormalizedSQL 规范化后的查询，字面量替换为 "?"。
+	// NormalizedSQL The normalized query, with literals replaced by "?".
+	NormalizedSQL string `json:"normalizedSql"`
+
+	// SampleSQL 最近一次执行的原始查询。
+	// SampleSQL The query as last executed.
+	SampleSQL string `json:"sampleSql"`
+
+	// Database 查询执行时的默认数据库。
+	// Database The default database the query was executed in.
+	Database string `json:"database,omitempty"`
+
+	// Tables 查询读取的基础表，以 "db.table" 形式限定。
+	// Tables Base tables the query reads, qualified as "db.table".
+	Tables []string `json:"tables,omitempty"`
+
+	Executions     int64         `json:"executions"`               // 执行次数 Number of executions
+	TotalDuration  time.Duration `json:"totalDuration"`            // 累计执行时长 Total execution time
+	MaxDuration    time.Duration `json:"maxDuration"`              // 最长执行时长 Longest execution time
+	TotalScanRows  int64         `json:"totalScanRows,omitempty"`  // 累计扫描行数 Total rows scanned
+	TotalScanBytes int64         `json:"totalScanBytes,omitempty"` // 累计扫描字节数 Total bytes scanned
+	FirstSeen      time.Time     `json:"firstSeen"`                // 首次执行时间 Time of the first execution
+	LastSeen       time.Time     `json:"lastSeen"`                 // 最近执行时间 Time of the last execution
+}
+
+// AvgDuration returns the average execution time of the pattern.
+// AvgDuration 返回查询模式的平均执行时长。
+func (p *QueryPattern) AvgDuration() time.Duration {
+	if p.Executions == 0 {
+		return 0
+	}
+	return p.TotalDuration / time.Duration(p.Executions)
+}
+
+// Benefit estimates what a recommended materialized view is worth, from the recorded executions of the query
+// patterns it serves.
+// Benefit 根据物化视图所服务的查询模式的执行记录，估算推荐的物化视图的收益。
+type Benefit struct {
+	Executions    int64         `json:"executions"`              // 所服务查询的执行次数 Executions of the served queries
+	TotalDuration time.Duration `json:"totalDuration"`           // 所服务查询的累计执行时长 Total execution time of the served queries
+	AvgDuration   time.Duration `json:"avgDuration"`             // 所服务查询的平均执行时长 Average execution time of the served queries
+	TotalScanRows int64         `json:"totalScanRows,omitempty"` // 所服务查询的累计扫描行数 Total rows scanned by the served queries
+
+	// EstimatedViewRows 物化视图的估计行数，由维度列的不同值数推算，0表示未知。
+	// EstimatedViewRows Estimated number of rows in the view, from the distinct values of its dimension columns;
+	// 0 when unknown.
+	EstimatedViewRows int64 `json:"estimatedViewRows,omitempty"`
+
+	// EstimatedTimeSaved 所服务查询改写为读取物化视图后节省的执行时长：假设每次执行的时长按扫描行数从原扫描行数
+	// 降至视图行数的比例缩短。没有扫描统计的查询模式或视图行数未知时不计入。
+	// EstimatedTimeSaved Execution time saved once the served queries are rewritten to read the view, taking the
+	// duration of an execution to shrink as its scanned rows drop from those recorded to the rows of the view.
+	// Patterns without scan statistics, and views of unknown size, count for nothing.
+	EstimatedTimeSaved time.Duration `json:"estimatedTimeSaved"`
+}
+
+// MaterializedViewRecommendation proposes a materialized view that pre-aggregates frequent, expensive GROUP BY
+// queries over the same base tables.
+// MaterializedViewRecommendation 建议创建一个物化视图，对相同基础表上高频且昂贵的GROUP BY查询进行预聚合。
+type MaterializedViewRecommendation struct {
+	// ID 推荐的稳定标识，由视图查询的指纹得出。
+	// ID Stable identifier of the recommendation, derived from the fingerprint of the view query.
+	ID string `json:"id"`
+
+	// Definition 建议创建的物化视图。
+	// Definition The materialized view proposed.
+	Definition *metadatamodel.MaterializedViewDefinition `json:"definition"`
+
+	// Patterns 物化视图所服务的查询模式的指纹。
+	// Patterns Fingerprints of the query patterns the view serves.
+	Patterns []string `json:"patterns"`
+
+	Benefit *Benefit `json:"benefit"`
+
+	// Reason 推荐理由的简要说明。
+	// Reason A short explanation of the recommendation.
+	Reason string `json:"reason"`
+}
+
+// AcceptOptions adjusts a recommended materialized view when it is accepted. Empty fields keep the recommended
+// values.
+// AcceptOptions 在接受推荐时调整建议的物化视图，空字段保留推荐值。
+type AcceptOptions struct {
+	ViewName        string            `json:"viewName,omitempty"`        // 视图名称 View name
+	RefreshType     string            `json:"refreshType,omitempty"`     // 刷新类型 (ASYNC 或 MANUAL) Refresh type (ASYNC or MANUAL)
+	RefreshSchedule string            `json:"refreshSchedule,omitempty"` // 异步刷新的调度表达式 Schedule of asynchronous refreshes
+	Properties      map[string]string `json:"properties,omitempty"`      // 视图属性 View properties
+	Comment         string            `json:"comment,omitempty"`         // 视图注释 View comment
+}
+
+// ColumnUsage counts how the recorded queries and full-text searches use a column.
+// ColumnUsage 统计记录的查询与全文检索对某一列的使用情况。
+type ColumnUsage struct {
+	EqualityFilters int64    `json:"equalityFilters"` // 以 =、IN 过滤该列的查询执行次数 Executions of queries filtering the column with =, IN
+	TextFilters     int64    `json:"textFilters"`     // 以 LIKE、MATCH_ANY、MATCH_ALL 过滤该列的查询执行次数 Executions of queries filtering the column with LIKE, MATCH_ANY, MATCH_ALL
+	Searches        int64    `json:"searches"`        // 以该列为目标字段的全文检索次数 Full-text searches targeting the column
+	Operators       []string `json:"operators,omitempty"`
+}
+
+// IndexRecommendation proposes a bitmap or inverted index on a column that recorded queries filter or search on.
+// IndexRecommendation 建议在记录的查询所过滤或检索的列上创建位图索引或倒排索引。
+type IndexRecommendation struct {
+	// ID 推荐的稳定标识，由表、列与索引类型得出。
+	// ID Stable identifier of the recommendation, derived from the table, column and index type.
+	ID string `json:"id"`
+
+	// Index 建议创建的索引；倒排索引的 "parser" 属性为建议的分词器。
+	// Index The index proposed; the "parser" property of an inverted index is the suggested parser.
+	Index *metadatamodel.IndexDefinition `json:"index"`
+
+	Usage *ColumnUsage `json:"usage"`
+
+	// Statistics 列在样本中的统计信息。
+	// Statistics Statistics of the column in the sample.
+	Statistics *metadatamodel.ColumnStatistics `json:"statistics"`
+
+	// TableRows 表的估计行数。
+	// TableRows Estimated number of rows in the table.
+	TableRows int64 `json:"tableRows"`
+
+	// EstimatedStorageBytes 索引大小的粗略估计 (数量级)，由表行数与列统计推算。
+	// EstimatedStorageBytes Rough, order-of-magnitude estimate of the index size, from the table rows and column
+	// statistics.
+	EstimatedStorageBytes int64 `json:"estimatedStorageBytes"`
+
+	// Reason 推荐理由的简要说明。
+	// Reason A short explanation of the recommendation.
+	Reason string `json:"reason"`
+}
+
+// IndexAcceptOptions adjusts a recommended index when it is accepted. Empty fields keep the recommended values;
+// Properties are merged into the recommended ones.
+// IndexAcceptOptions 在接受推荐时调整建议的索引。空字段保留推荐值，Properties合并到推荐的属性中。
+type IndexAcceptOptions struct {
+	IndexName  string            `json:"indexName,omitempty"`  // 索引名称 Index name
+	Properties map[string]string `json:"properties,omitempty"` // 索引属性，例如 "parser" Index properties, e.g., "parser"
+	Comment    string            `json:"comment,omitempty"`    // 索引注释 Index comment
+}
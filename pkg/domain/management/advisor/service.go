@@ -0,0 +1,423 @@
+package advisor
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/management/advisor/model"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	querymodel "github.com/turtacn/dataseap/pkg/domain/query/model"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// existingViewsPageSize is the page size used to read all materialized views of a database.
+// existingViewsPageSize 读取数据库全部物化视图时使用的分页大小。
+const existingViewsPageSize = 1000
+
+type serviceImpl struct {
+	metadataSvc          metadata.Service
+	patterns             *patternStore
+	searches             *searchStore
+	defaultDatabase      string
+	minExecutions        int64
+	minAvgDuration       time.Duration
+	maxRecommendations   int
+	indexSampleRows      int
+	bitmapMaxCardinality int
+}
+
+// NewService creates a new instance of the query advisor service. Queries executed without a database are
+// attributed to defaultDatabase.
+// NewService 创建一个新的查询顾问服务实例。未指定数据库执行的查询归属于defaultDatabase。
+func NewService(metadataSvc metadata.Service, cfg config.AdvisorConfig, defaultDatabase string) Service {
+	window := time.Duration(cfg.WindowHours) * time.Hour
+	if window <= 0 {
+		window = constants.AdvisorDefaultWindowHours * time.Hour
+	}
+	maxPatterns := cfg.MaxPatterns
+	if maxPatterns <= 0 {
+		maxPatterns = constants.AdvisorDefaultMaxPatterns
+	}
+	s := &serviceImpl{
+		metadataSvc:          metadataSvc,
+		patterns:             newPatternStore(window, maxPatterns),
+		searches:             newSearchStore(window, maxPatterns),
+		defaultDatabase:      defaultDatabase,
+		minExecutions:        int64(cfg.MinExecutions),
+		minAvgDuration:       time.Duration(cfg.MinAvgDurationMillis) * time.Millisecond,
+		maxRecommendations:   cfg.MaxRecommendations,
+		indexSampleRows:      cfg.IndexSampleRows,
+		bitmapMaxCardinality: cfg.BitmapMaxCardinality,
+	}
+	if s.minExecutions <= 0 {
+		s.minExecutions = constants.AdvisorDefaultMinExecutions
+	}
+	if s.minAvgDuration <= 0 {
+		s.minAvgDuration = constants.AdvisorDefaultMinAvgDurationMillis * time.Millisecond
+	}
+	if s.maxRecommendations <= 0 {
+		s.maxRecommendations = constants.AdvisorDefaultMaxRecommendations
+	}
+	if s.indexSampleRows <= 0 {
+		s.indexSampleRows = constants.AdvisorDefaultIndexSampleRows
+	}
+	if s.bitmapMaxCardinality <= 0 {
+		s.bitmapMaxCardinality = constants.AdvisorDefaultBitmapMaxCardinality
+	}
+	return s
+}
+
+// RecordQuery records an executed query with its statistics. Only queries are recorded; statements that cannot
+// be tokenized are ignored.
+// RecordQuery 记录一次执行的查询及其统计信息。只记录查询语句，无法进行词法分析的语句会被忽略。
+func (s *serviceImpl) RecordQuery(ctx context.Context, req *querymodel.SQLQueryRequest, result *querymodel.SQLQueryResult) {
+	if req == nil || result == nil {
+		return
+	}
+	l := logger.L().With("method", "RecordQuery")
+
+	shape, err := sqlparse.Analyze(req.SQL)
+	if err != nil || shape.Statement != "SELECT" {
+		return
+	}
+	fingerprint, normalized, err := sqlparse.Fingerprint(req.SQL)
+	if err != nil {
+		l.Debugw("Skipping query that cannot be fingerprinted", "error", err)
+		return
+	}
+
+	e := &execution{fingerprint: fingerprint, normalized: normalized, sql: req.SQL, shape: shape, duration: result.ExecutionTime}
+	e.database = req.Database
+	if e.database == "" {
+		e.database = s.defaultDatabase
+	}
+	if result.Stats != nil {
+		if result.Stats.Duration > 0 {
+			e.duration = result.Stats.Duration
+		}
+		e.scanRows, e.scanBytes = result.Stats.ScanRows, result.Stats.ScanBytes
+	}
+	for _, t := range shape.Tables {
+		database := t.Database
+		if database == "" {
+			database = e.database
+		}
+		e.tables = append(e.tables, database+"."+t.Table)
+	}
+	s.patterns.record(e)
+}
+
+// ListQueryPatterns lists the recorded query patterns, most expensive first.
+// ListQueryPatterns 列出记录的查询模式，开销最大的在前。
+func (s *serviceImpl) ListQueryPatterns(ctx context.Context, pagination *commontypes.PaginationRequest) ([]*model.QueryPattern, int64, error) {
+	l := logger.L().With("method", "ListQueryPatterns")
+	l.Info("Attempting to list query patterns")
+
+	entries := s.patterns.snapshot()
+	patterns := make([]*model.QueryPattern, 0, len(entries))
+	for _, entry := range entries {
+		pattern := entry.pattern
+		patterns = append(patterns, &pattern)
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		if patterns[i].TotalDuration != patterns[j].TotalDuration {
+			return patterns[i].TotalDuration > patterns[j].TotalDuration
+		}
+		return patterns[i].Fingerprint < patterns[j].Fingerprint
+	})
+
+	total := int64(len(patterns))
+	if pagination != nil {
+		offset, limit := pagination.GetOffset(), pagination.GetLimit()
+		if offset >= len(patterns) {
+			return []*model.QueryPattern{}, total, nil
+		}
+		end := offset + limit
+		if end > len(patterns) {
+			end = len(patterns)
+		}
+		patterns = patterns[offset:end]
+	}
+	return patterns, total, nil
+}
+
+// RecommendMaterializedViews recommends materialized views for the frequent, expensive aggregation patterns.
+// Candidates whose query an existing materialized view already defines are left out.
+// RecommendMaterializedViews 为高频且昂贵的聚合查询模式推荐物化视图。已有物化视图定义了相同查询的候选会被排除。
+func (s *serviceImpl) RecommendMaterializedViews(ctx context.Context, databaseName string) ([]*model.MaterializedViewRecommendation, error) {
+	l := logger.L().With("method", "RecommendMaterializedViews", "databaseName", databaseName)
+	l.Info("Attempting to recommend materialized views")
+
+	recommendations := s.recommendMaterializedViews(ctx, databaseName)
+	if len(recommendations) > s.maxRecommendations {
+		recommendations = recommendations[:s.maxRecommendations]
+	}
+	l.Infow("Materialized views recommended", "count", len(recommendations))
+	return recommendations, nil
+}
+
+// AcceptMaterializedViewRecommendation creates the materialized view of a recommendation.
+// AcceptMaterializedViewRecommendation 创建推荐的物化视图。
+func (s *serviceImpl) AcceptMaterializedViewRecommendation(ctx context.Context, id string, opts *model.AcceptOptions) (*metadatamodel.MaterializedViewDefinition, error) {
+	l := logger.L().With("method", "AcceptMaterializedViewRecommendation", "id", id)
+	l.Info("Attempting to accept materialized view recommendation")
+
+	if id == "" {
+		return nil, errors.New(errors.InvalidArgument, "recommendation id cannot be empty")
+	}
+	var def *metadatamodel.MaterializedViewDefinition
+	for _, r := range s.recommendMaterializedViews(ctx, "") {
+		if r.ID == id {
+			def = r.Definition
+			break
+		}
+	}
+	if def == nil {
+		return nil, errors.Newf(errors.NotFoundError, "materialized view recommendation %s not found", id)
+	}
+
+	if opts != nil {
+		if opts.ViewName != "" {
+			def.ViewName = opts.ViewName
+		}
+		if opts.RefreshType != "" {
+			def.RefreshType = strings.ToUpper(opts.RefreshType)
+		}
+		if opts.RefreshSchedule != "" {
+			def.RefreshSchedule = opts.RefreshSchedule
+		}
+		if len(opts.Properties) > 0 {
+			def.Properties = opts.Properties
+		}
+		if opts.Comment != "" {
+			def.Comment = opts.Comment
+		}
+	}
+	if err := s.metadataSvc.CreateMaterializedView(ctx, def); err != nil {
+		l.Errorw("Failed to create recommended materialized view", "viewName", def.ViewName, "error", err)
+		return nil, err
+	}
+	l.Infow("Materialized view recommendation accepted", "database", def.DatabaseName, "viewName", def.ViewName)
+	return def, nil
+}
+
+// RecordSearch records the fields a full-text search targeted. Searches without target fields use the indexes
+// that already exist and are not recorded.
+// RecordSearch 记录全文检索的目标字段。未指定目标字段的检索使用已存在的索引，不会被记录。
+func (s *serviceImpl) RecordSearch(ctx context.Context, req *querymodel.FullTextSearchRequest, result *querymodel.FullTextSearchResult) {
+	if req == nil || len(req.TargetFields) == 0 {
+		return
+	}
+	var fields []searchedField
+	for _, target := range req.TargetTables {
+		database, table := s.defaultDatabase, target
+		if i := strings.IndexByte(target, '.'); i >= 0 {
+			database, table = target[:i], target[i+1:]
+		}
+		if database == "" || table == "" {
+			continue
+		}
+		for _, field := range req.TargetFields {
+			fields = append(fields, searchedField{database: database, table: table, field: strings.ToLower(field)})
+		}
+	}
+	s.searches.record(fields)
+}
+
+// RecommendIndexes recommends bitmap and inverted indexes on the columns the recorded queries and searches use
+// most, sampling the statistics of each table involved.
+// RecommendIndexes 为记录的查询与检索使用最多的列推荐位图索引与倒排索引，并采样每张相关表的统计信息。
+func (s *serviceImpl) RecommendIndexes(ctx context.Context, databaseName string) ([]*model.IndexRecommendation, error) {
+	l := logger.L().With("method", "RecommendIndexes", "databaseName", databaseName)
+	l.Info("Attempting to recommend indexes")
+
+	recommendations := s.recommendIndexes(ctx, databaseName)
+	if len(recommendations) > s.maxRecommendations {
+		recommendations = recommendations[:s.maxRecommendations]
+	}
+	l.Infow("Indexes recommended", "count", len(recommendations))
+	return recommendations, nil
+}
+
+// AcceptIndexRecommendation creates the index of a recommendation.
+// AcceptIndexRecommendation 创建推荐的索引。
+func (s *serviceImpl) AcceptIndexRecommendation(ctx context.Context, id string, opts *model.IndexAcceptOptions) (*metadatamodel.IndexDefinition, error) {
+	l := logger.L().With("method", "AcceptIndexRecommendation", "id", id)
+	l.Info("Attempting to accept index recommendation")
+
+	if id == "" {
+		return nil, errors.New(errors.InvalidArgument, "recommendation id cannot be empty")
+	}
+	var index *metadatamodel.IndexDefinition
+	for _, r := range s.recommendIndexes(ctx, "") {
+		if r.ID == id {
+			index = r.Index
+			break
+		}
+	}
+	if index == nil {
+		return nil, errors.Newf(errors.NotFoundError, "index recommendation %s not found", id)
+	}
+
+	if opts != nil {
+		if opts.IndexName != "" {
+			index.IndexName = opts.IndexName
+		}
+		if len(opts.Properties) > 0 && index.Properties == nil {
+			index.Properties = map[string]string{}
+		}
+		for k, v := range opts.Properties {
+			index.Properties[k] = v
+		}
+		if opts.Comment != "" {
+			index.Comment = opts.Comment
+		}
+	}
+	if err := s.metadataSvc.CreateIndex(ctx, index); err != nil {
+		l.Errorw("Failed to create recommended index", "index", index.IndexName, "error", err)
+		return nil, err
+	}
+	l.Infow("Index recommendation accepted", "database", index.DatabaseName, "table", index.TableName, "index", index.IndexName)
+	return index, nil
+}
+
+// recommendIndexes returns all eligible index recommendations, optionally only those in databaseName, by
+// decreasing use of their columns.
+// recommendIndexes 按列的使用次数从多到少返回所有符合条件的索引推荐，可选仅限databaseName中的。
+func (s *serviceImpl) recommendIndexes(ctx context.Context, databaseName string) []*model.IndexRecommendation {
+	type tableKey struct{ database, table string }
+	tables := map[tableKey]map[string]*columnUsage{}
+	var order []tableKey
+	for key, u := range collectColumnUsage(s.patterns.snapshot(), s.searches.snapshot()) {
+		if databaseName != "" && !strings.EqualFold(key.database, databaseName) {
+			continue
+		}
+		if u.equality < s.minExecutions && u.text() < s.minExecutions {
+			continue
+		}
+		tk := tableKey{key.database, key.table}
+		if _, ok := tables[tk]; !ok {
+			tables[tk] = map[string]*columnUsage{}
+			order = append(order, tk)
+		}
+		tables[tk][key.column] = u
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].database != order[j].database {
+			return order[i].database < order[j].database
+		}
+		return order[i].table < order[j].table
+	})
+
+	var recommendations []*model.IndexRecommendation
+	for _, tk := range order {
+		recommendations = append(recommendations, s.recommendTableIndexes(ctx, tk.database, tk.table, tables[tk])...)
+	}
+	uses := func(r *model.IndexRecommendation) int64 {
+		return r.Usage.EqualityFilters + r.Usage.TextFilters + r.Usage.Searches
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if uses(recommendations[i]) != uses(recommendations[j]) {
+			return uses(recommendations[i]) > uses(recommendations[j])
+		}
+		return recommendations[i].ID < recommendations[j].ID
+	})
+	return recommendations
+}
+
+// recommendMaterializedViews returns all eligible recommendations, optionally only those in databaseName, by
+// decreasing estimated benefit.
+// recommendMaterializedViews 按预估收益从大到小返回所有符合条件的推荐，可选仅限databaseName中的。
+func (s *serviceImpl) recommendMaterializedViews(ctx context.Context, databaseName string) []*model.MaterializedViewRecommendation {
+	l := logger.L().With("method", "recommendMaterializedViews")
+
+	existing := map[string]map[string]bool{} // database -> keys of existing view queries
+	var recommendations []*model.MaterializedViewRecommendation
+	for _, c := range groupCandidates(s.patterns.snapshot()) {
+		if databaseName != "" && !strings.EqualFold(c.database, databaseName) {
+			continue
+		}
+		r := c.recommend(0)
+		if r.Benefit.Executions < s.minExecutions || r.Benefit.AvgDuration < s.minAvgDuration {
+			continue
+		}
+
+		keys, ok := existing[c.database]
+		if !ok {
+			keys = map[string]bool{}
+			views, _, err := s.metadataSvc.ListMaterializedViews(ctx, c.database, &commontypes.PaginationRequest{Page: 1, PageSize: existingViewsPageSize})
+			if err != nil {
+				// Recommend anyway; accepting a duplicate fails on the view name at worst.
+				l.Warnw("Failed to list existing materialized views", "database", c.database, "error", err)
+			}
+			for _, v := range views {
+				keys[exprKey(v.Query)] = true
+			}
+			existing[c.database] = keys
+		}
+		if keys[exprKey(r.Definition.Query)] {
+			continue
+		}
+		recommendations = append(recommendations, c.recommend(s.estimateViewRows(ctx, c)))
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		bi, bj := recommendations[i].Benefit, recommendations[j].Benefit
+		if bi.EstimatedTimeSaved != bj.EstimatedTimeSaved {
+			return bi.EstimatedTimeSaved > bj.EstimatedTimeSaved
+		}
+		if bi.TotalDuration != bj.TotalDuration {
+			return bi.TotalDuration > bj.TotalDuration
+		}
+		return recommendations[i].ID < recommendations[j].ID
+	})
+	return recommendations
+}
+
+// estimateViewRows estimates the rows of a candidate view as the product of the distinct values of the columns its
+// dimensions read, sampled like the columns of recommended indexes and capped by the rows of the table. Only views
+// over a single table are estimated; 0 means unknown.
+// estimateViewRows 将候选视图的行数估计为其维度所读取列的不同值数之积，这些列与推荐索引的列一样采样，并以表的行数为上限。
+// 只估计基于单张表的视图；0表示未知。
+func (s *serviceImpl) estimateViewRows(ctx context.Context, c *mvCandidate) int64 {
+	if len(c.tables) != 1 {
+		return 0
+	}
+	database := c.tables[0].Database
+	if database == "" {
+		database = c.database
+	}
+	table := c.tables[0].Table
+	columns := c.dimensionColumns()
+	stats, err := s.metadataSvc.SampleTableStatistics(ctx, database, table, columns, s.indexSampleRows)
+	if err != nil {
+		logger.L().With("method", "estimateViewRows").Warnw("Failed to sample dimension statistics", "db", database, "table", table, "error", err)
+		return 0
+	}
+	rows := stats.Rows
+	if rows < stats.SampledRows {
+		rows = stats.SampledRows
+	}
+	if rows == 0 || len(stats.Columns) < len(columns) {
+		return 0
+	}
+	estimate := int64(1)
+	for _, cs := range stats.Columns {
+		if cs.Distinct <= 1 {
+			continue
+		}
+		if estimate > rows/cs.Distinct {
+			return rows
+		}
+		estimate *= cs.Distinct
+	}
+	return estimate
+}
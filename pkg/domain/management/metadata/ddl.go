@@ -0,0 +1,201 @@
+package metadata
+
+import (
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// RenderCreateTableDDL renders the StarRocks CREATE TABLE statement of a table schema without executing it. The
+// given indexes are declared inline.
+// RenderCreateTableDDL 生成表结构对应的StarRocks CREATE TABLE语句，但不执行。给定的索引以内联方式声明。
+func RenderCreateTableDDL(ts *model.TableSchema, indexes ...*model.IndexDefinition) (string, error) {
+	def, err := toAdapterTableSchema(ts)
+	if err != nil {
+		return "", err
+	}
+	if def != nil {
+		for _, id := range indexes {
+			def.Indexes = append(def.Indexes, *toAdapterIndex(id))
+		}
+	}
+	return starrocks.BuildCreateTableDDL(def)
+}
+
+// RenderAlterationDDL renders the ALTER TABLE statement of one alteration of databaseName.tableName. For ADD_COLUMN
+// and MODIFY_COLUMN the column is taken from altered, the schema the alteration has been applied to.
+// RenderAlterationDDL 生成对databaseName.tableName的一次修改对应的ALTER TABLE语句。ADD_COLUMN与MODIFY_COLUMN的列
+// 取自已应用该修改的表结构altered。
+func RenderAlterationDDL(databaseName, tableName string, altered *model.TableSchema, ta *model.TableAlteration) (string, error) {
+	switch ta.Type {
+	case model.AlterationAddColumn, model.AlterationModifyColumn:
+		column := altered.Field(ta.Column.Name)
+		if column == nil {
+			return "", errors.Newf(errors.InvalidArgument, "column %s is not part of the altered table %s", ta.Column.Name, tableName)
+		}
+		field, err := toAdapterField(column)
+		if err != nil {
+			return "", err
+		}
+		if ta.Type == model.AlterationAddColumn {
+			return starrocks.BuildAddColumnsDDL(databaseName, tableName, []starrocks.FieldSchemaDef{field})
+		}
+		return starrocks.BuildModifyColumnDDL(databaseName, tableName, field)
+	case model.AlterationDropColumn:
+		return starrocks.BuildDropColumnDDL(databaseName, tableName, ta.ColumnName)
+	case model.AlterationRenameTable:
+		return starrocks.BuildRenameTableDDL(databaseName, tableName, ta.NewTableName)
+	default:
+		return "", errors.Newf(errors.InvalidArgument, "unsupported table alteration %q", ta.Type)
+	}
+}
+
+// RenderAddColumnsDDL renders one ALTER TABLE statement adding all of columns to databaseName.tableName.
+// RenderAddColumnsDDL 生成一条向databaseName.tableName添加全部columns的ALTER TABLE语句。
+func RenderAddColumnsDDL(databaseName, tableName string, columns []*model.FieldSchema) (string, error) {
+	fields, err := toAdapterFields(columns)
+	if err != nil {
+		return "", err
+	}
+	return starrocks.BuildAddColumnsDDL(databaseName, tableName, fields)
+}
+
+// RenderSchemaChangeDDL renders one ALTER TABLE statement adding, modifying and dropping columns of
+// databaseName.tableName.
+// RenderSchemaChangeDDL 生成一条添加、修改与删除databaseName.tableName中列的ALTER TABLE语句。
+func RenderSchemaChangeDDL(databaseName, tableName string, added, modified []*model.FieldSchema, dropped []string) (string, error) {
+	addedFields, err := toAdapterFields(added)
+	if err != nil {
+		return "", err
+	}
+	modifiedFields, err := toAdapterFields(modified)
+	if err != nil {
+		return "", err
+	}
+	return starrocks.BuildSchemaChangeDDL(databaseName, tableName, addedFields, modifiedFields, dropped)
+}
+
+// RenderCreateIndexDDL renders the ALTER TABLE statement creating an index.
+// RenderCreateIndexDDL 生成创建索引的ALTER TABLE语句。
+func RenderCreateIndexDDL(id *model.IndexDefinition) (string, error) {
+	return starrocks.BuildCreateIndexDDL(id.DatabaseName, id.TableName, toAdapterIndex(id))
+}
+
+// RenderCreateMaterializedViewDDL renders the CREATE MATERIALIZED VIEW statement of a view definition.
+// RenderCreateMaterializedViewDDL 生成物化视图定义对应的CREATE MATERIALIZED VIEW语句。
+func RenderCreateMaterializedViewDDL(mvd *model.MaterializedViewDefinition) (string, error) {
+	return starrocks.BuildCreateMaterializedViewDDL(&starrocks.MaterializedViewDef{
+		DatabaseName:    mvd.DatabaseName,
+		ViewName:        mvd.ViewName,
+		Query:           mvd.Query,
+		RefreshType:     mvd.RefreshType,
+		RefreshSchedule: mvd.RefreshSchedule,
+		Properties:      mvd.Properties,
+		Comment:         mvd.Comment,
+	})
+}
+
+// toAdapterTableSchema converts a domain table schema into the adapter definition its DDL is rendered from.
+// toAdapterTableSchema 将领域表结构转换为用于生成DDL的适配器定义。
+func toAdapterTableSchema(ts *model.TableSchema) (*starrocks.TableSchemaDef, error) {
+	if ts == nil {
+		return nil, nil
+	}
+	def := &starrocks.TableSchemaDef{
+		DatabaseName: ts.DatabaseName,
+		TableName:    ts.TableName,
+		Fields:       make([]starrocks.FieldSchemaDef, 0, len(ts.Fields)),
+		KeysType:     ts.KeysType,
+		KeyColumns:   ts.KeyColumns,
+		Properties:   ts.Properties,
+		Comment:      ts.Comment,
+	}
+	for _, f := range ts.Fields {
+		if f == nil {
+			continue
+		}
+		field, err := toAdapterField(f)
+		if err != nil {
+			return nil, err
+		}
+		def.Fields = append(def.Fields, field)
+	}
+	if p := ts.Partition; p != nil {
+		def.Partition = &starrocks.PartitionDef{
+			Type:       p.Type,
+			Columns:    p.Columns,
+			Expression: p.Expression,
+		}
+		for _, r := range p.Ranges {
+			if r != nil {
+				def.Partition.Ranges = append(def.Partition.Ranges, starrocks.RangePartitionDef{Name: r.Name, Lower: r.Lower, Upper: r.Upper})
+			}
+		}
+		if d := p.Dynamic; d != nil {
+			def.Partition.Dynamic = &starrocks.DynamicPartitionDef{
+				TimeUnit:            d.TimeUnit,
+				Start:               d.Start,
+				End:                 d.End,
+				Prefix:              d.Prefix,
+				Buckets:             d.Buckets,
+				HistoryPartitionNum: d.HistoryPartitionNum,
+			}
+		}
+	}
+	if d := ts.Distribution; d != nil {
+		def.DistributionColumns = d.Columns
+		def.Buckets = d.Buckets
+	}
+	return def, nil
+}
+
+// toAdapterField converts a domain field into an adapter column, falling back to the name of its DataType when
+// the field has no type string. The type is parsed and rendered back, so that only a column type reaches the DDL.
+// toAdapterField 将领域字段转换为适配器列定义，字段没有类型字符串时使用其DataType的名称。类型会被解析后重新生成，
+// 确保写入DDL的只有列类型。
+func toAdapterField(f *model.FieldSchema) (starrocks.FieldSchemaDef, error) {
+	typ := f.TypeString
+	if typ == "" {
+		typ = f.DataType.String()
+	}
+	ct, err := ParseColumnType(typ)
+	if err != nil {
+		return starrocks.FieldSchemaDef{}, errors.Wrapf(err, errors.InvalidArgument, "column '%s' has an invalid type", f.Name)
+	}
+	return starrocks.FieldSchemaDef{
+		Name:            f.Name,
+		Type:            ct.String(),
+		IsNullable:      f.IsNullable,
+		IsKey:           f.IsPrimaryKey,
+		DefaultValue:    f.DefaultValue,
+		Comment:         f.Comment,
+		AggregationType: f.AggregationType,
+	}, nil
+}
+
+// toAdapterFields converts domain fields into adapter columns, skipping nil fields.
+// toAdapterFields 将领域字段转换为适配器列定义，跳过nil字段。
+func toAdapterFields(columns []*model.FieldSchema) ([]starrocks.FieldSchemaDef, error) {
+	fields := make([]starrocks.FieldSchemaDef, 0, len(columns))
+	for _, c := range columns {
+		if c == nil {
+			continue
+		}
+		field, err := toAdapterField(c)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func toAdapterIndex(id *model.IndexDefinition) *starrocks.IndexDefinitionDef {
+	return &starrocks.IndexDefinitionDef{
+		IndexName:  id.IndexName,
+		IndexType:  id.IndexType,
+		Fields:     id.Fields,
+		Properties: id.Properties,
+		Comment:    id.Comment,
+	}
+}
@@ -18,13 +18,19 @@ type Service interface {
 	// ListTables 列出给定数据库中的表，可选分页。
 	ListTables(ctx context.Context, databaseName string, pagination *commontypes.PaginationRequest) (tableNames []string, total int64, err error)
 
-	// CreateTable (可选的高级功能) 创建表。
-	// CreateTable (Optional advanced feature) Creates a table.
-	// CreateTable(ctx context.Context, tableSchema *model.TableSchema) error
+	// CreateTable creates a table from its schema. With dryRun the DDL is only generated and returned.
+	// CreateTable 根据表结构创建表。dryRun为true时仅生成并返回DDL。
+	CreateTable(ctx context.Context, tableSchema *model.TableSchema, dryRun bool) (*model.DDLResult, error)
 
-	// AlterTable (可选的高级功能) 修改表结构。
-	// AlterTable (Optional advanced feature) Alters a table structure.
-	// AlterTable(ctx context.Context, databaseName, tableName string, alterations []model.TableAlteration) error
+	// AlterTable applies alterations to a table in order. The altered schema is validated before the single
+	// statement carrying the net column changes, or the rename, runs. With dryRun the DDL is only generated and returned.
+	// AlterTable 按顺序对表应用修改，在执行承载列最终变更或重命名的单条语句之前先校验修改后的表结构。dryRun为true时
+	// 仅生成并返回DDL。
+	AlterTable(ctx context.Context, databaseName, tableName string, alterations []*model.TableAlteration, dryRun bool) (*model.DDLResult, error)
+
+	// DropTable drops a table. With dryRun the DDL is only generated and returned.
+	// DropTable 删除表。dryRun为true时仅生成并返回DDL。
+	DropTable(ctx context.Context, databaseName, tableName string, dryRun bool) (*model.DDLResult, error)
 
 	// CreateIndex creates an index on a table.
 	// CreateIndex 在表上创建索引。
@@ -38,6 +44,11 @@ type Service interface {
 	// ListIndexes 检索特定表的所有索引定义。
 	ListIndexes(ctx context.Context, databaseName, tableName string) ([]*model.IndexDefinition, error)
 
+	// SampleTableStatistics reads the estimated size of a table and samples statistics of columns of scalar types
+	// from up to sampleRows of its rows.
+	// SampleTableStatistics 读取表的估计大小，并从最多sampleRows行中采样标量类型列的统计信息。
+	SampleTableStatistics(ctx context.Context, databaseName, tableName string, columns []string, sampleRows int) (*model.TableStatistics, error)
+
 	// CreateMaterializedView creates a new materialized view.
 	// CreateMaterializedView 创建一个新的物化视图。
 	CreateMaterializedView(ctx context.Context, mvDef *model.MaterializedViewDefinition) error
@@ -53,4 +64,17 @@ type Service interface {
 	// GetMaterializedViewDefinition retrieves the definition of a specific materialized view.
 	// GetMaterializedViewDefinition 检索特定物化视图的定义。
 	GetMaterializedViewDefinition(ctx context.Context, databaseName, viewName string) (*model.MaterializedViewDefinition, error)
+
+	// RefreshMaterializedView submits a refresh of a materialized view; its progress is reported in the view status.
+	// With force the view is refreshed even when its base tables did not change.
+	// RefreshMaterializedView 提交物化视图的刷新，刷新进度在视图状态中报告。force为true时即使基表未变化也会刷新。
+	RefreshMaterializedView(ctx context.Context, databaseName, viewName string, force bool) error
+
+	// PauseMaterializedView deactivates a materialized view, stopping its scheduled refreshes.
+	// PauseMaterializedView 停用物化视图，停止其定时刷新。
+	PauseMaterializedView(ctx context.Context, databaseName, viewName string) error
+
+	// ResumeMaterializedView reactivates a paused materialized view, resuming its scheduled refreshes.
+	// ResumeMaterializedView 重新激活已暂停的物化视图，恢复其定时刷新。
+	ResumeMaterializedView(ctx context.Context, databaseName, viewName string) error
 }
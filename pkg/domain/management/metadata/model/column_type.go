@@ -0,0 +1,105 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+)
+
+// ColumnType is the parsed form of a StarRocks column type, including the parameters and nested types that
+// enum.DataType leaves out.
+// ColumnType 是StarRocks列类型的解析结果，包含enum.DataType所不包含的类型参数与嵌套类型。
+type ColumnType struct {
+	// DataType 基础数据类型，无法识别的类型为 DataTypeUnknown。
+	// DataType Base data type, DataTypeUnknown for types that are not recognized.
+	DataType enum.DataType `json:"dataType"`
+
+	// Name 类型名称，例如 "DECIMAL64", "BITMAP"。
+	// Name Type name as written, e.g., "DECIMAL64", "BITMAP".
+	Name string `json:"name"`
+
+	// Length (可选) CHAR/VARCHAR的长度。
+	// Length (Optional) Length of CHAR/VARCHAR.
+	Length int `json:"length,omitempty"`
+
+	// Precision (可选) DECIMAL的精度。
+	// Precision (Optional) Precision of DECIMAL.
+	Precision int `json:"precision,omitempty"`
+
+	// Scale (可选) DECIMAL的小数位数。
+	// Scale (Optional) Scale of DECIMAL.
+	Scale int `json:"scale,omitempty"`
+
+	// Element (可选) ARRAY的元素类型。
+	// Element (Optional) Element type of ARRAY.
+	Element *ColumnType `json:"element,omitempty"`
+
+	// Key (可选) MAP的键类型。
+	// Key (Optional) Key type of MAP.
+	Key *ColumnType `json:"key,omitempty"`
+
+	// Value (可选) MAP的值类型。
+	// Value (Optional) Value type of MAP.
+	Value *ColumnType `json:"value,omitempty"`
+
+	// Fields (可选) STRUCT的字段。
+	// Fields (Optional) Fields of STRUCT.
+	Fields []*StructField `json:"fields,omitempty"`
+}
+
+// StructField is one field of a STRUCT type.
+// StructField 是STRUCT类型中的一个字段。
+type StructField struct {
+	// Name 字段名称。
+	// Name Field name.
+	Name string `json:"name"`
+
+	// Type 字段类型。
+	// Type Field type.
+	Type *ColumnType `json:"type"`
+}
+
+// String renders the type in StarRocks syntax, e.g., "ARRAY<STRUCT<a INT, b VARCHAR(10)>>".
+// String 以StarRocks语法输出类型，例如 "ARRAY<STRUCT<a INT, b VARCHAR(10)>>"。
+func (ct *ColumnType) String() string {
+	if ct == nil {
+		return ""
+	}
+	switch ct.DataType {
+	case enum.DataTypeArray:
+		return fmt.Sprintf("ARRAY<%s>", ct.Element)
+	case enum.DataTypeMap:
+		return fmt.Sprintf("MAP<%s,%s>", ct.Key, ct.Value)
+	case enum.DataTypeStruct:
+		fields := make([]string, len(ct.Fields))
+		for i, f := range ct.Fields {
+			fields[i] = structFieldName(f.Name) + " " + f.Type.String()
+		}
+		return "STRUCT<" + strings.Join(fields, ", ") + ">"
+	case enum.DataTypeChar, enum.DataTypeVarchar:
+		if ct.Length > 0 {
+			return fmt.Sprintf("%s(%d)", ct.Name, ct.Length)
+		}
+	case enum.DataTypeDecimal:
+		if ct.Precision > 0 {
+			return fmt.Sprintf("%s(%d,%d)", ct.Name, ct.Precision, ct.Scale)
+		}
+	}
+	return ct.Name
+}
+
+// structFieldName renders the name of a STRUCT field, quoting it with backticks unless it is a plain identifier.
+// structFieldName 生成STRUCT字段的名称，除非是普通标识符，否则用反引号括起。
+func structFieldName(name string) string {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	if name == "" {
+		return "``"
+	}
+	return name
+}
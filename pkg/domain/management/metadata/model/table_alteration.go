@@ -0,0 +1,149 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AlterationType identifies the kind of change a TableAlteration makes.
+// AlterationType 标识TableAlteration所做修改的类型。
+type AlterationType string
+
+const (
+	// AlterationAddColumn adds Column to the table.
+	// AlterationAddColumn 向表中添加Column。
+	AlterationAddColumn AlterationType = "ADD_COLUMN"
+
+	// AlterationDropColumn drops the column named ColumnName.
+	// AlterationDropColumn 删除名为ColumnName的列。
+	AlterationDropColumn AlterationType = "DROP_COLUMN"
+
+	// AlterationModifyColumn replaces the definition of the column named Column.Name.
+	// AlterationModifyColumn 替换名为Column.Name的列的定义。
+	AlterationModifyColumn AlterationType = "MODIFY_COLUMN"
+
+	// AlterationRenameTable renames the table to NewTableName.
+	// AlterationRenameTable 将表重命名为NewTableName。
+	AlterationRenameTable AlterationType = "RENAME_TABLE"
+)
+
+// TableAlteration describes one change to the structure of a table.
+// TableAlteration 描述对表结构的一次修改。
+type TableAlteration struct {
+	// Type 修改类型。
+	// Type Kind of change.
+	Type AlterationType `json:"type"`
+
+	// Column (ADD_COLUMN, MODIFY_COLUMN) 新的列定义。
+	// Column (ADD_COLUMN, MODIFY_COLUMN) The new column definition.
+	Column *FieldSchema `json:"column,omitempty"`
+
+	// ColumnName (DROP_COLUMN) 要删除的列名。
+	// ColumnName (DROP_COLUMN) Name of the column to drop.
+	ColumnName string `json:"columnName,omitempty"`
+
+	// NewTableName (RENAME_TABLE) 新的表名。
+	// NewTableName (RENAME_TABLE) The new table name.
+	NewTableName string `json:"newTableName,omitempty"`
+}
+
+// DDLResult reports the DDL statements of a table operation, in execution order.
+// DDLResult 按执行顺序报告表操作的DDL语句。
+type DDLResult struct {
+	// Statements 生成的DDL语句。
+	// Statements The generated DDL statements.
+	Statements []string `json:"statements"`
+
+	// DryRun 为true时语句仅被生成而未执行。
+	// DryRun When true the statements were only generated, not executed.
+	DryRun bool `json:"dryRun"`
+}
+
+// Validate performs basic validation.
+func (ta *TableAlteration) Validate() error {
+	switch ta.Type {
+	case AlterationAddColumn, AlterationModifyColumn:
+		if ta.Column == nil {
+			return NewDomainError(fmt.Sprintf("TableAlteration %s requires a Column", ta.Type))
+		}
+		return ta.Column.Validate()
+	case AlterationDropColumn:
+		if ta.ColumnName == "" {
+			return NewDomainError("TableAlteration DROP_COLUMN requires a ColumnName")
+		}
+	case AlterationRenameTable:
+		if ta.NewTableName == "" {
+			return NewDomainError("TableAlteration RENAME_TABLE requires a NewTableName")
+		}
+	default:
+		return NewDomainError(fmt.Sprintf("unsupported TableAlteration type %q", ta.Type))
+	}
+	return nil
+}
+
+// Apply applies an alteration to the schema in memory, so that the altered schema can be validated before any
+// DDL is executed. A modified column keeps whether it is a key column, which StarRocks cannot change.
+// Apply 在内存中将修改应用到表结构上，以便在执行DDL之前校验修改后的表结构。被修改的列保持其是否为键列，
+// StarRocks不支持修改这一点。
+func (ts *TableSchema) Apply(ta *TableAlteration) error {
+	if err := ta.Validate(); err != nil {
+		return err
+	}
+	switch ta.Type {
+	case AlterationAddColumn:
+		if ts.Field(ta.Column.Name) != nil {
+			return NewDomainError(fmt.Sprintf("column %s already exists in table %s", ta.Column.Name, ts.TableName))
+		}
+		column := *ta.Column
+		ts.Fields = append(ts.Fields, &column)
+	case AlterationDropColumn:
+		i := ts.fieldIndex(ta.ColumnName)
+		if i < 0 {
+			return NewDomainError(fmt.Sprintf("column %s does not exist in table %s", ta.ColumnName, ts.TableName))
+		}
+		if len(ts.Fields) == 1 {
+			return NewDomainError(fmt.Sprintf("cannot drop %s, the only column of table %s", ta.ColumnName, ts.TableName))
+		}
+		ts.Fields = append(ts.Fields[:i:i], ts.Fields[i+1:]...)
+		ts.KeyColumns = withoutName(ts.KeyColumns, ta.ColumnName)
+	case AlterationModifyColumn:
+		i := ts.fieldIndex(ta.Column.Name)
+		if i < 0 {
+			return NewDomainError(fmt.Sprintf("column %s does not exist in table %s", ta.Column.Name, ts.TableName))
+		}
+		column := *ta.Column
+		column.IsPrimaryKey = ts.Fields[i].IsPrimaryKey
+		ts.Fields[i] = &column
+	case AlterationRenameTable:
+		ts.TableName = ta.NewTableName
+	}
+	return nil
+}
+
+// Field returns the field with the given name, compared case-insensitively, or nil.
+// Field 返回给定名称（不区分大小写）的字段，不存在时返回nil。
+func (ts *TableSchema) Field(name string) *FieldSchema {
+	if i := ts.fieldIndex(name); i >= 0 {
+		return ts.Fields[i]
+	}
+	return nil
+}
+
+func (ts *TableSchema) fieldIndex(name string) int {
+	for i, f := range ts.Fields {
+		if f != nil && strings.EqualFold(f.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func withoutName(names []string, name string) []string {
+	kept := make([]string, 0, len(names))
+	for _, n := range names {
+		if !strings.EqualFold(n, name) {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/turtacn/dataseap/pkg/common/types/enum"
 )
 
@@ -19,6 +23,10 @@ type FieldSchema struct {
 	// TypeString Original type string, e.g., "VARCHAR(255)", "DECIMAL(10,2)".
 	TypeString string `json:"typeString"`
 
+	// ColumnType (可选) 解析后的类型，包含类型参数与嵌套类型，读取线上表结构时填充。
+	// ColumnType (Optional) Parsed type with its parameters and nested types, filled when a live schema is read.
+	ColumnType *ColumnType `json:"columnType,omitempty"`
+
 	// IsNullable 字段是否允许为空。
 	// IsNullable Whether the field can be null.
 	IsNullable bool `json:"isNullable"`
@@ -67,6 +75,18 @@ type TableSchema struct {
 	// KeysType (Optional) StarRocks specific: Key type for OLAP tables (e.g., "DUPLICATE KEY", "AGGREGATE KEY", "UNIQUE KEY", "PRIMARY KEY").
 	KeysType string `json:"keysType,omitempty"`
 
+	// KeyColumns (可选) 键列，为空时取IsPrimaryKey为true的字段，键列必须按顺序位于字段列表的最前面。
+	// KeyColumns (Optional) Key columns, the fields with IsPrimaryKey when empty. Key columns must lead the fields in order.
+	KeyColumns []string `json:"keyColumns,omitempty"`
+
+	// Partition (可选) 结构化的分区定义，建表时使用。
+	// Partition (Optional) Structured partitioning, used when creating the table.
+	Partition *PartitionSpec `json:"partition,omitempty"`
+
+	// Distribution (可选) 结构化的分桶定义，建表时使用。
+	// Distribution (Optional) Structured bucketing, used when creating the table.
+	Distribution *DistributionSpec `json:"distribution,omitempty"`
+
 	// PartitionInfo (可选) StarRocks特定：表的分区信息描述 (可能是结构化对象或字符串)。
 	// PartitionInfo (Optional) StarRocks specific: Description of the table's partitioning information (could be a structured object or string).
 	PartitionInfo string `json:"partitionInfo,omitempty"` // Could be a more structured type
@@ -88,6 +108,86 @@ type TableSchema struct {
 	CreateTableDDL string `json:"createTableDdl,omitempty"`
 }
 
+// PartitionSpec describes how a table is partitioned.
+// PartitionSpec 描述表的分区方式。
+type PartitionSpec struct {
+	// Type 分区类型 ("RANGE" 或 "EXPRESSION")。
+	// Type Partitioning type ("RANGE" or "EXPRESSION").
+	Type string `json:"type"`
+
+	// Columns 分区列。
+	// Columns Partition columns.
+	Columns []string `json:"columns,omitempty"`
+
+	// Expression (可选) EXPRESSION分区的表达式，例如 "date_trunc('day', event_time)"；为空时按Columns分区。
+	// Expression (Optional) Expression of EXPRESSION partitioning, e.g., "date_trunc('day', event_time)"; partitions by Columns when empty.
+	Expression string `json:"expression,omitempty"`
+
+	// Ranges (可选) RANGE分区在建表时创建的分区。
+	// Ranges (Optional) Partitions a RANGE partitioned table is created with.
+	Ranges []*RangePartition `json:"ranges,omitempty"`
+
+	// Dynamic (可选) RANGE分区的动态分区属性。
+	// Dynamic (Optional) Dynamic partition properties of RANGE partitioning.
+	Dynamic *DynamicPartition `json:"dynamic,omitempty"`
+}
+
+// RangePartition describes one RANGE partition: [Lower, Upper), or LESS THAN Upper when Lower is empty.
+// RangePartition 描述一个RANGE分区：[Lower, Upper)，Lower为空时为 LESS THAN Upper。
+type RangePartition struct {
+	// Name 分区名称。
+	// Name Partition name.
+	Name string `json:"name"`
+
+	// Lower (可选) 下界 (含)，每个分区列一个值。
+	// Lower (Optional) Inclusive lower bound, one value per partition column.
+	Lower []string `json:"lower,omitempty"`
+
+	// Upper (可选) 上界 (不含)，为空表示MAXVALUE。
+	// Upper (Optional) Exclusive upper bound, MAXVALUE when empty.
+	Upper []string `json:"upper,omitempty"`
+}
+
+// DynamicPartition describes the dynamic partitions StarRocks creates and drops for a RANGE partitioned table.
+// DynamicPartition 描述StarRocks为RANGE分区表自动创建与删除的动态分区。
+type DynamicPartition struct {
+	// TimeUnit 分区粒度 ("HOUR", "DAY", "WEEK", "MONTH", "YEAR")。
+	// TimeUnit Partition granularity ("HOUR", "DAY", "WEEK", "MONTH", "YEAR").
+	TimeUnit string `json:"timeUnit"`
+
+	// Start (可选) 保留的历史分区数 (负数)，0表示不删除历史分区。
+	// Start (Optional) Partitions kept in the past (negative), 0 never drops old partitions.
+	Start int `json:"start,omitempty"`
+
+	// End 预先创建的未来分区数。
+	// End Partitions created ahead.
+	End int `json:"end"`
+
+	// Prefix (可选) 分区名前缀，默认为 "p"。
+	// Prefix (Optional) Partition name prefix, "p" by default.
+	Prefix string `json:"prefix,omitempty"`
+
+	// Buckets (可选) 动态分区的分桶数。
+	// Buckets (Optional) Number of buckets of dynamic partitions.
+	Buckets int `json:"buckets,omitempty"`
+
+	// HistoryPartitionNum (可选) 建表时创建的历史分区数。
+	// HistoryPartitionNum (Optional) History partitions created with the table.
+	HistoryPartitionNum int `json:"historyPartitionNum,omitempty"`
+}
+
+// DistributionSpec describes how a table is bucketed.
+// DistributionSpec 描述表的分桶方式。
+type DistributionSpec struct {
+	// Columns (可选) 哈希分桶列，为空时随机分桶 (仅DUPLICATE KEY表支持)。
+	// Columns (Optional) Hash distribution columns, random distribution when empty (DUPLICATE KEY tables only).
+	Columns []string `json:"columns,omitempty"`
+
+	// Buckets (可选) 分桶数，0表示由StarRocks自动决定。
+	// Buckets (Optional) Number of buckets, 0 lets StarRocks decide.
+	Buckets int `json:"buckets,omitempty"`
+}
+
 // IndexDefinition represents the definition of an index on a table.
 // IndexDefinition 代表表上索引的定义。
 type IndexDefinition struct {
@@ -151,6 +251,58 @@ type MaterializedViewDefinition struct {
 	// Comment (可选) 物化视图的注释。
 	// Comment (Optional) Comment for the materialized view.
 	Comment string `json:"comment,omitempty"`
+
+	// Definition (只读) StarRocks报告的完整建视图语句。
+	// Definition (Read-only) The full CREATE statement StarRocks reports.
+	Definition string `json:"definition,omitempty"`
+
+	// Status (只读) 激活状态与最近一次刷新的结果。
+	// Status (Read-only) Activation state and outcome of the last refresh.
+	Status *MaterializedViewStatus `json:"status,omitempty"`
+}
+
+// MaterializedViewStatus represents the activation state of a materialized view and the outcome of its last refresh.
+// MaterializedViewStatus 代表物化视图的激活状态及其最近一次刷新的结果。
+type MaterializedViewStatus struct {
+	// IsActive 视图是否处于激活状态，停用的视图不刷新也不用于查询改写。
+	// IsActive Whether the view is active; an inactive view is neither refreshed nor used to rewrite queries.
+	IsActive bool `json:"isActive"`
+
+	// InactiveReason (可选) 视图被停用的原因。
+	// InactiveReason (Optional) Why the view is inactive.
+	InactiveReason string `json:"inactiveReason,omitempty"`
+
+	// TaskName (可选) 刷新任务的名称。
+	// TaskName (Optional) Name of the refresh task.
+	TaskName string `json:"taskName,omitempty"`
+
+	// LastRefreshState (可选) 最近一次刷新的状态 (例如 "SUCCESS", "FAILED", "RUNNING")。
+	// LastRefreshState (Optional) State of the last refresh (e.g., "SUCCESS", "FAILED", "RUNNING").
+	LastRefreshState string `json:"lastRefreshState,omitempty"`
+
+	// LastRefreshStartTime (可选) 最近一次刷新的开始时间。
+	// LastRefreshStartTime (Optional) When the last refresh started.
+	LastRefreshStartTime *time.Time `json:"lastRefreshStartTime,omitempty"`
+
+	// LastRefreshFinishedTime (可选) 最近一次刷新的结束时间。
+	// LastRefreshFinishedTime (Optional) When the last refresh finished.
+	LastRefreshFinishedTime *time.Time `json:"lastRefreshFinishedTime,omitempty"`
+
+	// LastRefreshDurationSeconds (可选) 最近一次刷新的耗时（秒）。
+	// LastRefreshDurationSeconds (Optional) Duration of the last refresh, in seconds.
+	LastRefreshDurationSeconds float64 `json:"lastRefreshDurationSeconds,omitempty"`
+
+	// LastRefreshErrorCode (可选) 最近一次刷新失败的错误码。
+	// LastRefreshErrorCode (Optional) Error code of the last refresh when it failed.
+	LastRefreshErrorCode string `json:"lastRefreshErrorCode,omitempty"`
+
+	// LastRefreshError (可选) 最近一次刷新失败的错误信息。
+	// LastRefreshError (Optional) Error message of the last refresh when it failed.
+	LastRefreshError string `json:"lastRefreshError,omitempty"`
+
+	// Rows (可选) 视图中的行数。
+	// Rows (Optional) Number of rows in the view.
+	Rows int64 `json:"rows,omitempty"`
 }
 
 // Validate performs basic validation.
@@ -175,10 +327,49 @@ func (ts *TableSchema) Validate() error {
 	if len(ts.Fields) == 0 {
 		return NewDomainError("TableSchema must have at least one field")
 	}
+	seen := make(map[string]bool, len(ts.Fields))
 	for _, f := range ts.Fields {
+		if f == nil {
+			return NewDomainError("TableSchema Fields cannot contain an empty field")
+		}
 		if err := f.Validate(); err != nil {
 			return err
 		}
+		if seen[strings.ToLower(f.Name)] {
+			return NewDomainError(fmt.Sprintf("TableSchema field %s is defined more than once", f.Name))
+		}
+		seen[strings.ToLower(f.Name)] = true
+	}
+
+	keyColumns := ts.KeyColumns
+	if len(keyColumns) == 0 {
+		for _, f := range ts.Fields {
+			if f.IsPrimaryKey {
+				keyColumns = append(keyColumns, f.Name)
+			}
+		}
+	}
+	for i, name := range keyColumns {
+		if i >= len(ts.Fields) || !strings.EqualFold(ts.Fields[i].Name, name) {
+			return NewDomainError(fmt.Sprintf("TableSchema key column %s must be field %d, key columns lead the fields in order", name, i+1))
+		}
+	}
+	if p := ts.Partition; p != nil {
+		if p.Expression == "" && len(p.Columns) == 0 {
+			return NewDomainError("TableSchema Partition requires Columns or an Expression")
+		}
+		for _, name := range p.Columns {
+			if !seen[strings.ToLower(name)] {
+				return NewDomainError(fmt.Sprintf("TableSchema partition column %s is not a field", name))
+			}
+		}
+	}
+	if d := ts.Distribution; d != nil {
+		for _, name := range d.Columns {
+			if !seen[strings.ToLower(name)] {
+				return NewDomainError(fmt.Sprintf("TableSchema distribution column %s is not a field", name))
+			}
+		}
 	}
 	return nil
 }
@@ -214,6 +405,15 @@ func (mvd *MaterializedViewDefinition) Validate() error {
 	if mvd.Query == "" {
 		return NewDomainError("MaterializedViewDefinition Query cannot be empty")
 	}
+	switch strings.ToUpper(strings.TrimSpace(mvd.RefreshType)) {
+	case "", "ASYNC":
+	case "MANUAL":
+		if strings.TrimSpace(mvd.RefreshSchedule) != "" {
+			return NewDomainError("MaterializedViewDefinition RefreshSchedule requires RefreshType ASYNC")
+		}
+	default:
+		return NewDomainError(fmt.Sprintf("MaterializedViewDefinition RefreshType %q is not supported, expected ASYNC or MANUAL", mvd.RefreshType))
+	}
 	return nil
 }
 
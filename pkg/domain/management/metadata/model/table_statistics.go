@@ -0,0 +1,34 @@
+package model
+
+// TableStatistics holds the estimated size of a table and statistics of some of its columns, sampled from a
+// bounded number of rows.
+// TableStatistics 保存表的估计大小及其部分列的统计信息，统计信息从有限行数中采样得到。
+type TableStatistics struct {
+	DatabaseName string `json:"databaseName"`
+	TableName    string `json:"tableName"`
+
+	// Rows 表的估计行数。
+	// Rows Estimated number of rows in the table.
+	Rows int64 `json:"rows"`
+
+	// DataBytes 表数据的估计大小 (字节)。
+	// DataBytes Estimated size of the table data in bytes.
+	DataBytes int64 `json:"dataBytes"`
+
+	// SampledRows 采样的行数，列统计基于这些行。
+	// SampledRows Number of rows sampled; the column statistics describe these rows.
+	SampledRows int64 `json:"sampledRows"`
+
+	Columns []*ColumnStatistics `json:"columns"`
+}
+
+// ColumnStatistics holds the statistics of a column in a sample. Text measures are of the values as strings.
+// ColumnStatistics 保存列在样本中的统计信息，文本度量基于列值的字符串形式。
+type ColumnStatistics struct {
+	Name            string  `json:"name"`
+	Distinct        int64   `json:"distinct"`        // 近似不同值数 Approximate number of distinct values
+	NonNull         int64   `json:"nonNull"`         // 非空值数 Number of non-null values
+	AvgLength       float64 `json:"avgLength"`       // 平均字符数 Average length in characters
+	AvgBytes        float64 `json:"avgBytes"`        // 平均字节数 Average length in bytes
+	WhitespaceRatio float64 `json:"whitespaceRatio"` // 含空格的非空值所占比例 Share of non-null values containing a space
+}
@@ -2,6 +2,8 @@ package metadata
 
 import (
 	"context"
+	"sort"
+	"strings"
 
 	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
 	"github.com/turtacn/dataseap/pkg/common/errors"
@@ -43,26 +45,42 @@ func (s *serviceImpl) GetTableSchema(ctx context.Context, databaseName, tableNam
 		DatabaseName: adapterSchema.DatabaseName,
 		TableName:    adapterSchema.TableName,
 		Fields:       make([]*model.FieldSchema, len(adapterSchema.Fields)),
-		// TODO: Map TableType, KeysType, PartitionInfo, DistributionInfo, Properties, Comment from adapterSchema
 	}
 	for i, adf := range adapterSchema.Fields {
-		domainSchema.Fields[i] = &model.FieldSchema{
-			Name:       adf.Name,
-			TypeString: adf.Type, // TypeString is the raw type from DB
-			// TODO: Map adf.Type to enum.DataType
-			// DataType:   parseStarRocksTypeToDomainEnum(adf.Type),
-			IsNullable: adf.IsNullable,
-			Comment:    adf.Comment,
-			// TODO: Map IsPrimaryKey, DefaultValue, AggregationType
+		field := &model.FieldSchema{
+			Name:            adf.Name,
+			DataType:        enum.DataTypeUnknown,
+			TypeString:      adf.Type, // TypeString is the raw type from DB
+			IsNullable:      adf.IsNullable,
+			IsPrimaryKey:    adf.IsKey,
+			DefaultValue:    adf.DefaultValue,
+			Comment:         adf.Comment,
+			AggregationType: adf.AggregationType,
 		}
+		if columnType, err := ParseColumnType(adf.Type); err == nil {
+			field.DataType = columnType.DataType
+			field.ColumnType = columnType
+		} else {
+			l.Warnw("Failed to parse column type", "column", adf.Name, "type", adf.Type, "error", err)
+		}
+		domainSchema.Fields[i] = field
+	}
+
+	// Keys, partitioning and properties are only reported by SHOW CREATE TABLE. Tables it cannot be parsed for,
+	// such as external tables, are still described by their columns.
+	definition, err := s.srDDLExecutor.ShowCreateTable(ctx, databaseName, tableName)
+	if err != nil {
+		l.Warnw("Failed to read CREATE TABLE statement, returning columns only", "error", err)
+	} else {
+		applyTableDefinition(domainSchema, definition)
 	}
 
 	l.Info("Table schema retrieved successfully")
 	return domainSchema, nil
 }
 
-// ListTables lists tables within a given database.
-// ListTables 列出给定数据库中的表。
+// ListTables lists the base tables of a database in name order, one page at a time when pagination is given.
+// ListTables 按名称顺序列出数据库中的基础表，给定pagination时分页返回。
 func (s *serviceImpl) ListTables(ctx context.Context, databaseName string, pagination *commontypes.PaginationRequest) (tableNames []string, total int64, err error) {
 	l := logger.L().Ctx(ctx).With("method", "ListTables", "database", databaseName)
 	l.Info("Attempting to list tables")
@@ -71,13 +89,184 @@ func (s *serviceImpl) ListTables(ctx context.Context, databaseName string, pagin
 		return nil, 0, errors.New(errors.InvalidArgument, "database name cannot be empty")
 	}
 
-	// TODO: The starrocks.DDLExecutor interface doesn't currently have ListTables.
-	// This would typically be "SHOW TABLES [IN databaseName];"
-	l.Warn("ListTables skeleton: returning empty list and not implemented error")
-	// Example:
-	// tables, t, e := s.srDDLExecutor.ListTables(ctx, databaseName, pagination) // Assuming adapter supports this
-	// return tables, t, e
-	return []string{}, 0, errors.New(errors.UnknownError, "ListTables not fully implemented")
+	tables, err := s.srDDLExecutor.ShowTables(ctx, databaseName)
+	if err != nil {
+		l.Errorw("Failed to list tables via DDL executor", "error", err)
+		return nil, 0, errors.Wrap(err, errors.DatabaseError, "failed to list tables")
+	}
+	sort.Strings(tables)
+
+	total = int64(len(tables))
+	if pagination == nil {
+		return tables, total, nil
+	}
+	return paginate(tables, pagination), total, nil
+}
+
+// CreateTable creates a table from its schema. With dryRun the DDL is only generated and returned.
+// CreateTable 根据表结构创建表。dryRun为true时仅生成并返回DDL。
+func (s *serviceImpl) CreateTable(ctx context.Context, tableSchema *model.TableSchema, dryRun bool) (*model.DDLResult, error) {
+	if tableSchema == nil {
+		return nil, errors.New(errors.InvalidArgument, "table schema is required")
+	}
+	l := logger.L().With("method", "CreateTable", "db", tableSchema.DatabaseName, "table", tableSchema.TableName, "dryRun", dryRun)
+	l.Info("Attempting to create table")
+
+	if err := tableSchema.Validate(); err != nil {
+		l.Warnw("TableSchema validation failed", "error", err)
+		return nil, errors.Wrap(err, errors.InvalidArgument, "invalid table schema")
+	}
+	ddl, err := RenderCreateTableDDL(tableSchema)
+	if err != nil {
+		l.Warnw("Failed to render CREATE TABLE statement", "error", err)
+		return nil, err
+	}
+	result := &model.DDLResult{Statements: []string{ddl}, DryRun: dryRun}
+	if dryRun {
+		return result, nil
+	}
+
+	def, err := toAdapterTableSchema(tableSchema)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.srDDLExecutor.CreateTable(ctx, def); err != nil {
+		l.Errorw("Failed to create table via DDL executor", "error", err)
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to create table")
+	}
+
+	l.Info("Table created successfully")
+	return result, nil
+}
+
+// AlterTable applies alterations to a table in order. The current schema is read, every alteration is applied to
+// it in memory and the result is validated before any statement runs. StarRocks runs a schema change as an
+// asynchronous job and rejects the next one on the table until it finishes, so the net column changes are sent as a
+// single ALTER TABLE statement. Renaming the table cannot be combined with column changes and is rejected together
+// with them.
+// AlterTable 按顺序对表应用修改。先读取当前表结构，在内存中应用所有修改并校验结果，然后才执行语句。StarRocks以异步
+// 任务执行模式变更，并在其完成之前拒绝该表上的下一次变更，因此列的最终变更以一条ALTER TABLE语句发送。表重命名无法与
+// 列变更合并，二者同时出现时拒绝请求。
+func (s *serviceImpl) AlterTable(ctx context.Context, databaseName, tableName string, alterations []*model.TableAlteration, dryRun bool) (*model.DDLResult, error) {
+	l := logger.L().With("method", "AlterTable", "db", databaseName, "table", tableName, "dryRun", dryRun)
+	l.Info("Attempting to alter table")
+
+	if databaseName == "" || tableName == "" {
+		return nil, errors.New(errors.InvalidArgument, "database name and table name cannot be empty")
+	}
+	if len(alterations) == 0 {
+		return nil, errors.New(errors.InvalidArgument, "at least one table alteration is required")
+	}
+
+	altered, err := s.GetTableSchema(ctx, databaseName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	original := &model.TableSchema{TableName: altered.TableName, Fields: append([]*model.FieldSchema(nil), altered.Fields...)}
+
+	var touched []string // 按首次修改顺序排列的列名 Column names in the order they were first altered
+	seen := make(map[string]bool)
+	renamed := false
+	for i, alteration := range alterations {
+		if alteration == nil {
+			return nil, errors.Newf(errors.InvalidArgument, "table alteration %d is empty", i+1)
+		}
+		if err := altered.Apply(alteration); err != nil {
+			l.Warnw("Table alteration rejected", "alteration", i+1, "error", err)
+			return nil, errors.Wrapf(err, errors.InvalidArgument, "invalid table alteration %d", i+1)
+		}
+		column := alteration.ColumnName
+		switch alteration.Type {
+		case model.AlterationRenameTable:
+			renamed = true
+			continue
+		case model.AlterationAddColumn:
+			if original.Field(alteration.Column.Name) != nil {
+				return nil, errors.Newf(errors.InvalidArgument, "table alteration %d adds column %s again after dropping it, drop and add it in separate requests", i+1, alteration.Column.Name)
+			}
+			column = alteration.Column.Name
+		case model.AlterationModifyColumn:
+			column = alteration.Column.Name
+		}
+		if key := strings.ToLower(column); !seen[key] {
+			seen[key] = true
+			touched = append(touched, column)
+		}
+	}
+	if err := altered.Validate(); err != nil {
+		l.Warnw("Altered TableSchema validation failed", "error", err)
+		return nil, errors.Wrap(err, errors.InvalidArgument, "table alterations leave an invalid table schema")
+	}
+
+	var added, modified []*model.FieldSchema
+	var dropped []string
+	for _, name := range touched {
+		before, after := original.Field(name), altered.Field(name)
+		switch {
+		case before == nil && after != nil:
+			added = append(added, after)
+		case before != nil && after != nil:
+			modified = append(modified, after)
+		case before != nil:
+			dropped = append(dropped, before.Name)
+		}
+	}
+	columnChanges := len(added) + len(modified) + len(dropped)
+	if renamed && columnChanges > 0 {
+		return nil, errors.New(errors.InvalidArgument, "renaming a table cannot be combined with column alterations, rename it in a separate request")
+	}
+
+	var ddl string
+	switch {
+	case columnChanges > 0:
+		ddl, err = RenderSchemaChangeDDL(databaseName, tableName, added, modified, dropped)
+	case renamed && altered.TableName != original.TableName:
+		ddl, err = starrocks.BuildRenameTableDDL(databaseName, original.TableName, altered.TableName)
+	default:
+		return nil, errors.New(errors.InvalidArgument, "the table alterations cancel each other out")
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &model.DDLResult{Statements: []string{ddl}, DryRun: dryRun}
+	if dryRun {
+		return result, nil
+	}
+
+	if err := s.srDDLExecutor.AlterTable(ctx, databaseName, tableName, ddl); err != nil {
+		l.Errorw("Failed to alter table via DDL executor", "statement", ddl, "error", err)
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to alter table")
+	}
+
+	l.Infow("Table altered successfully", "statement", ddl)
+	return result, nil
+}
+
+// DropTable drops a table. With dryRun the DDL is only generated and returned.
+// DropTable 删除表。dryRun为true时仅生成并返回DDL。
+func (s *serviceImpl) DropTable(ctx context.Context, databaseName, tableName string, dryRun bool) (*model.DDLResult, error) {
+	l := logger.L().With("method", "DropTable", "db", databaseName, "table", tableName, "dryRun", dryRun)
+	l.Info("Attempting to drop table")
+
+	if databaseName == "" || tableName == "" {
+		return nil, errors.New(errors.InvalidArgument, "database name and table name cannot be empty")
+	}
+	ddl, err := starrocks.BuildDropTableDDL(databaseName, tableName, true)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.DDLResult{Statements: []string{ddl}, DryRun: dryRun}
+	if dryRun {
+		return result, nil
+	}
+
+	if err := s.srDDLExecutor.DropTable(ctx, databaseName, tableName); err != nil {
+		l.Errorw("Failed to drop table via DDL executor", "error", err)
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to drop table")
+	}
+
+	l.Info("Table dropped successfully")
+	return result, nil
 }
 
 // CreateIndex creates an index on a table.
@@ -91,15 +280,7 @@ func (s *serviceImpl) CreateIndex(ctx context.Context, indexDef *model.IndexDefi
 		return errors.Wrap(err, errors.InvalidArgument, "invalid index definition")
 	}
 
-	adapterIndexDef := &starrocks.IndexDefinitionDef{
-		IndexName:  indexDef.IndexName,
-		IndexType:  indexDef.IndexType, // Assuming string type for now
-		Fields:     indexDef.Fields,
-		Properties: indexDef.Properties,
-		Comment:    indexDef.Comment,
-	}
-
-	err := s.srDDLExecutor.CreateIndex(ctx, indexDef.DatabaseName, indexDef.TableName, adapterIndexDef)
+	err := s.srDDLExecutor.CreateIndex(ctx, indexDef.DatabaseName, indexDef.TableName, toAdapterIndex(indexDef))
 	if err != nil {
 		l.Errorw("Failed to create index via DDL executor", "error", err)
 		return errors.Wrap(err, errors.DatabaseError, "failed to create index")
@@ -139,14 +320,66 @@ func (s *serviceImpl) ListIndexes(ctx context.Context, databaseName, tableName s
 		return nil, errors.New(errors.InvalidArgument, "database and table name cannot be empty")
 	}
 
-	// TODO: The starrocks.DDLExecutor interface doesn't currently have ListIndexes.
-	// This would typically be "SHOW INDEXES FROM [tableName] [FROM databaseName];"
-	l.Warn("ListIndexes skeleton: returning empty list and not implemented error")
-	// Example:
-	// adapterIndexes, err := s.srDDLExecutor.ListIndexes(ctx, databaseName, tableName)
-	// if err != nil { ... }
-	// transform to domain model ...
-	return []*model.IndexDefinition{}, errors.New(errors.UnknownError, "ListIndexes not fully implemented")
+	adapterIndexes, err := s.srDDLExecutor.ShowIndexes(ctx, databaseName, tableName)
+	if err != nil {
+		l.Errorw("Failed to list indexes via DDL executor", "error", err)
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to list indexes")
+	}
+	indexes := make([]*model.IndexDefinition, len(adapterIndexes))
+	for i, idx := range adapterIndexes {
+		indexes[i] = &model.IndexDefinition{
+			IndexName:    idx.IndexName,
+			TableName:    tableName,
+			DatabaseName: databaseName,
+			IndexType:    idx.IndexType,
+			Fields:       idx.Fields,
+			Properties:   idx.Properties,
+			Comment:      idx.Comment,
+		}
+	}
+	return indexes, nil
+}
+
+// SampleTableStatistics reads the estimated size of a table and samples column statistics.
+// SampleTableStatistics 读取表的估计大小并采样列统计信息。
+func (s *serviceImpl) SampleTableStatistics(ctx context.Context, databaseName, tableName string, columns []string, sampleRows int) (*model.TableStatistics, error) {
+	l := logger.L().With("method", "SampleTableStatistics", "db", databaseName, "table", tableName)
+	l.Info("Attempting to sample table statistics")
+
+	if databaseName == "" || tableName == "" {
+		return nil, errors.New(errors.InvalidArgument, "database and table name cannot be empty")
+	}
+	if sampleRows <= 0 {
+		return nil, errors.Newf(errors.InvalidArgument, "sample size must be positive, got %d", sampleRows)
+	}
+
+	adapterStats, err := s.srDDLExecutor.SampleTableStats(ctx, databaseName, tableName, columns, sampleRows)
+	if err != nil {
+		l.Errorw("Failed to sample table statistics via DDL executor", "error", err)
+		if errors.GetCode(err) == errors.NotFoundError {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to sample table statistics")
+	}
+	stats := &model.TableStatistics{
+		DatabaseName: databaseName,
+		TableName:    tableName,
+		Rows:         adapterStats.Rows,
+		DataBytes:    adapterStats.DataBytes,
+		SampledRows:  adapterStats.SampledRows,
+		Columns:      make([]*model.ColumnStatistics, len(adapterStats.Columns)),
+	}
+	for i, c := range adapterStats.Columns {
+		stats.Columns[i] = &model.ColumnStatistics{
+			Name:            c.Name,
+			Distinct:        c.Distinct,
+			NonNull:         c.NonNull,
+			AvgLength:       c.AvgLength,
+			AvgBytes:        c.AvgBytes,
+			WhitespaceRatio: c.WhitespaceRatio,
+		}
+	}
+	return stats, nil
 }
 
 // CreateMaterializedView creates a new materialized view.
@@ -159,19 +392,19 @@ func (s *serviceImpl) CreateMaterializedView(ctx context.Context, mvDef *model.M
 		l.Warnw("MaterializedViewDefinition validation failed", "error", err)
 		return errors.Wrap(err, errors.InvalidArgument, "invalid materialized view definition")
 	}
+	ddl, err := RenderCreateMaterializedViewDDL(mvDef)
+	if err != nil {
+		l.Warnw("Failed to render materialized view DDL", "error", err)
+		return err
+	}
 
-	// TODO: The starrocks.DDLExecutor interface doesn't currently have CreateMaterializedView.
-	// DDL is "CREATE MATERIALIZED VIEW [db.]mv_name AS SELECT ..."
-	l.Warn("CreateMaterializedView skeleton: not implemented")
-	// Example:
-	// ddl := fmt.Sprintf("CREATE MATERIALIZED VIEW %s.%s ", mvDef.DatabaseName, mvDef.ViewName)
-	// if mvDef.RefreshType != "" { ddl += fmt.Sprintf("BUILD %s ", mvDef.RefreshType) } // Or REFRESH keyword
-	// if mvDef.RefreshSchedule != "" { ddl += fmt.Sprintf("REFRESH ASYNC %s ", mvDef.RefreshSchedule) } // Simplified
-	// // Add PROPERTIES clause
-	// ddl += " AS " + mvDef.Query
-	// err := s.srDDLExecutor.ExecuteRawDDL(ctx, ddl) // Assuming a raw DDL execution method
-	// if err != nil { ... }
-	return errors.New(errors.UnknownError, "CreateMaterializedView not fully implemented")
+	if err := s.srDDLExecutor.ExecuteRawDDL(ctx, ddl); err != nil {
+		l.Errorw("Failed to create materialized view via DDL executor", "error", err)
+		return errors.Wrap(err, errors.DatabaseError, "failed to create materialized view")
+	}
+
+	l.Info("Materialized view created successfully")
+	return nil
 }
 
 // DropMaterializedView drops an existing materialized view.
@@ -183,10 +416,18 @@ func (s *serviceImpl) DropMaterializedView(ctx context.Context, databaseName, vi
 	if databaseName == "" || viewName == "" {
 		return errors.New(errors.InvalidArgument, "database and view name cannot be empty")
 	}
-	// TODO: The starrocks.DDLExecutor interface doesn't currently have DropMaterializedView.
-	// DDL is "DROP MATERIALIZED VIEW [IF EXISTS] [db.]mv_name;"
-	l.Warn("DropMaterializedView skeleton: not implemented")
-	return errors.New(errors.UnknownError, "DropMaterializedView not fully implemented")
+	ddl, err := starrocks.BuildDropMaterializedViewDDL(databaseName, viewName, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.srDDLExecutor.ExecuteRawDDL(ctx, ddl); err != nil {
+		l.Errorw("Failed to drop materialized view via DDL executor", "error", err)
+		return errors.Wrap(err, errors.DatabaseError, "failed to drop materialized view")
+	}
+
+	l.Info("Materialized view dropped successfully")
+	return nil
 }
 
 // ListMaterializedViews lists materialized views in a database.
@@ -198,10 +439,20 @@ func (s *serviceImpl) ListMaterializedViews(ctx context.Context, databaseName st
 	if databaseName == "" {
 		return nil, 0, errors.New(errors.InvalidArgument, "database name cannot be empty")
 	}
-	// TODO: The starrocks.DDLExecutor interface doesn't currently have ListMaterializedViews.
-	// DDL is "SHOW MATERIALIZED VIEWS [FROM databaseName];"
-	l.Warn("ListMaterializedViews skeleton: returning empty list and not implemented error")
-	return []*model.MaterializedViewDefinition{}, 0, errors.New(errors.UnknownError, "ListMaterializedViews not fully implemented")
+
+	adapterViews, err := s.srDDLExecutor.ShowMaterializedViews(ctx, databaseName)
+	if err != nil {
+		l.Errorw("Failed to list materialized views via DDL executor", "error", err)
+		return nil, 0, errors.Wrap(err, errors.DatabaseError, "failed to list materialized views")
+	}
+	sort.Slice(adapterViews, func(i, j int) bool { return adapterViews[i].ViewName < adapterViews[j].ViewName })
+
+	page := paginate(adapterViews, pagination)
+	views = make([]*model.MaterializedViewDefinition, len(page))
+	for i, mv := range page {
+		views[i] = toDomainMaterializedView(mv)
+	}
+	return views, int64(len(adapterViews)), nil
 }
 
 // GetMaterializedViewDefinition retrieves the definition of a specific materialized view.
@@ -213,21 +464,152 @@ func (s *serviceImpl) GetMaterializedViewDefinition(ctx context.Context, databas
 	if databaseName == "" || viewName == "" {
 		return nil, errors.New(errors.InvalidArgument, "database and view name cannot be empty")
 	}
-	// TODO: The starrocks.DDLExecutor interface doesn't currently have GetMaterializedViewDefinition.
-	// DDL is "SHOW CREATE MATERIALIZED VIEW [db.]mv_name;" Then parse output.
-	l.Warn("GetMaterializedViewDefinition skeleton: not implemented")
-	return nil, errors.New(errors.UnknownError, "GetMaterializedViewDefinition not fully implemented")
-}
-
-// Helper function (example, not fully implemented)
-func parseStarRocksTypeToDomainEnum(srType string) enum.DataType {
-	// TODO: Implement proper mapping
-	// Example:
-	// upperType := strings.ToUpper(srType)
-	// if strings.HasPrefix(upperType, "VARCHAR") { return enum.DataTypeVarchar }
-	// switch upperType {
-	// case "INT": return enum.DataTypeInt
-	// ...
-	// }
-	return enum.DataTypeUnknown
+
+	mv, err := s.srDDLExecutor.ShowMaterializedView(ctx, databaseName, viewName)
+	if err != nil {
+		if errors.GetCode(err) == errors.NotFoundError {
+			return nil, err
+		}
+		l.Errorw("Failed to get materialized view via DDL executor", "error", err)
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to get materialized view definition")
+	}
+	return toDomainMaterializedView(mv), nil
+}
+
+// RefreshMaterializedView submits a refresh of a materialized view.
+// RefreshMaterializedView 提交物化视图的刷新。
+func (s *serviceImpl) RefreshMaterializedView(ctx context.Context, databaseName, viewName string, force bool) error {
+	l := logger.L().With("method", "RefreshMaterializedView", "db", databaseName, "view", viewName, "force", force)
+	l.Info("Attempting to refresh materialized view")
+
+	if databaseName == "" || viewName == "" {
+		return errors.New(errors.InvalidArgument, "database and view name cannot be empty")
+	}
+	ddl, err := starrocks.BuildRefreshMaterializedViewDDL(databaseName, viewName, force, false)
+	if err != nil {
+		return err
+	}
+
+	if err := s.srDDLExecutor.ExecuteRawDDL(ctx, ddl); err != nil {
+		l.Errorw("Failed to refresh materialized view via DDL executor", "error", err)
+		return errors.Wrap(err, errors.DatabaseError, "failed to refresh materialized view")
+	}
+
+	l.Info("Materialized view refresh submitted")
+	return nil
+}
+
+// PauseMaterializedView deactivates a materialized view.
+// PauseMaterializedView 停用物化视图。
+func (s *serviceImpl) PauseMaterializedView(ctx context.Context, databaseName, viewName string) error {
+	return s.setMaterializedViewActive(ctx, databaseName, viewName, false)
+}
+
+// ResumeMaterializedView reactivates a materialized view.
+// ResumeMaterializedView 重新激活物化视图。
+func (s *serviceImpl) ResumeMaterializedView(ctx context.Context, databaseName, viewName string) error {
+	return s.setMaterializedViewActive(ctx, databaseName, viewName, true)
+}
+
+func (s *serviceImpl) setMaterializedViewActive(ctx context.Context, databaseName, viewName string, active bool) error {
+	l := logger.L().With("method", "setMaterializedViewActive", "db", databaseName, "view", viewName, "active", active)
+	l.Info("Attempting to change materialized view state")
+
+	if databaseName == "" || viewName == "" {
+		return errors.New(errors.InvalidArgument, "database and view name cannot be empty")
+	}
+	ddl, err := starrocks.BuildAlterMaterializedViewStateDDL(databaseName, viewName, active)
+	if err != nil {
+		return err
+	}
+
+	if err := s.srDDLExecutor.ExecuteRawDDL(ctx, ddl); err != nil {
+		l.Errorw("Failed to change materialized view state via DDL executor", "error", err)
+		return errors.Wrap(err, errors.DatabaseError, "failed to change materialized view state")
+	}
+
+	l.Info("Materialized view state changed successfully")
+	return nil
+}
+
+// toDomainMaterializedView converts a materialized view the adapter reports into its domain definition.
+// toDomainMaterializedView 将适配器报告的物化视图转换为领域定义。
+func toDomainMaterializedView(mv *starrocks.MaterializedViewDef) *model.MaterializedViewDefinition {
+	mvd := &model.MaterializedViewDefinition{
+		DatabaseName:    mv.DatabaseName,
+		ViewName:        mv.ViewName,
+		Query:           mv.Query,
+		RefreshType:     mv.RefreshType,
+		RefreshSchedule: mv.RefreshSchedule,
+		Properties:      mv.Properties,
+		Comment:         mv.Comment,
+		Definition:      mv.Definition,
+	}
+	if st := mv.Status; st != nil {
+		mvd.Status = &model.MaterializedViewStatus{
+			IsActive:                   st.IsActive,
+			InactiveReason:             st.InactiveReason,
+			TaskName:                   st.TaskName,
+			LastRefreshState:           st.LastRefreshState,
+			LastRefreshDurationSeconds: st.LastRefreshDuration,
+			LastRefreshErrorCode:       st.LastRefreshErrorCode,
+			LastRefreshError:           st.LastRefreshErrorMessage,
+			Rows:                       st.Rows,
+		}
+		if !st.LastRefreshStartTime.IsZero() {
+			started := st.LastRefreshStartTime
+			mvd.Status.LastRefreshStartTime = &started
+		}
+		if !st.LastRefreshFinishedTime.IsZero() {
+			finished := st.LastRefreshFinishedTime
+			mvd.Status.LastRefreshFinishedTime = &finished
+		}
+	}
+	return mvd
+}
+
+// applyTableDefinition fills the table-level information SHOW CREATE TABLE reports into a table schema.
+// applyTableDefinition 将SHOW CREATE TABLE报告的表级信息填充到表结构中。
+func applyTableDefinition(ts *model.TableSchema, def *starrocks.TableDefinitionDef) {
+	ts.TableType = def.Engine
+	ts.KeysType = def.KeysType
+	ts.KeyColumns = def.KeyColumns
+	ts.PartitionInfo = def.PartitionClause
+	ts.DistributionInfo = def.DistributionClause
+	ts.Properties = def.Properties
+	ts.Comment = def.Comment
+	ts.CreateTableDDL = def.Statement
+	if p := def.Partition; p != nil {
+		ts.Partition = &model.PartitionSpec{Type: p.Type, Columns: p.Columns, Expression: p.Expression}
+		for _, r := range p.Ranges {
+			ts.Partition.Ranges = append(ts.Partition.Ranges, &model.RangePartition{Name: r.Name, Lower: r.Lower, Upper: r.Upper})
+		}
+		if d := p.Dynamic; d != nil {
+			ts.Partition.Dynamic = &model.DynamicPartition{
+				TimeUnit:            d.TimeUnit,
+				Start:               d.Start,
+				End:                 d.End,
+				Prefix:              d.Prefix,
+				Buckets:             d.Buckets,
+				HistoryPartitionNum: d.HistoryPartitionNum,
+			}
+		}
+	}
+	if len(def.DistributionColumns) > 0 || def.Buckets > 0 {
+		ts.Distribution = &model.DistributionSpec{Columns: def.DistributionColumns, Buckets: def.Buckets}
+	}
+}
+
+// paginate returns the page of items the pagination selects.
+// paginate 返回分页参数所选中的那一页。
+func paginate[T any](items []T, pagination *commontypes.PaginationRequest) []T {
+	offset, limit := pagination.GetOffset(), pagination.GetLimit()
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
 }
@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// fakeDDLExecutor serves one table and records the ALTER TABLE statements it is asked to run.
+type fakeDDLExecutor struct {
+	starrocks.DDLExecutor
+	table   *starrocks.TableSchemaDef
+	altered []string
+}
+
+func (f *fakeDDLExecutor) GetTableSchema(_ context.Context, _, _ string) (*starrocks.TableSchemaDef, error) {
+	return f.table, nil
+}
+
+func (f *fakeDDLExecutor) ShowCreateTable(_ context.Context, _, _ string) (*starrocks.TableDefinitionDef, error) {
+	return nil, errors.New(errors.NotFoundError, "no CREATE TABLE statement")
+}
+
+func (f *fakeDDLExecutor) AlterTable(_ context.Context, _, _, statement string) error {
+	f.altered = append(f.altered, statement)
+	return nil
+}
+
+func TestAlterTable(t *testing.T) {
+	column := func(name, typ string) *model.FieldSchema {
+		return &model.FieldSchema{Name: name, TypeString: typ, IsNullable: true}
+	}
+	add := func(name, typ string) *model.TableAlteration {
+		return &model.TableAlteration{Type: model.AlterationAddColumn, Column: column(name, typ)}
+	}
+	modify := func(name, typ string) *model.TableAlteration {
+		return &model.TableAlteration{Type: model.AlterationModifyColumn, Column: column(name, typ)}
+	}
+	drop := func(name string) *model.TableAlteration {
+		return &model.TableAlteration{Type: model.AlterationDropColumn, ColumnName: name}
+	}
+	rename := func(name string) *model.TableAlteration {
+		return &model.TableAlteration{Type: model.AlterationRenameTable, NewTableName: name}
+	}
+
+	tests := []struct {
+		name        string
+		alterations []*model.TableAlteration
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "column changes form one statement",
+			alterations: []*model.TableAlteration{add("region", "VARCHAR(16)"), drop("msg"), modify("host", "VARCHAR(128)")},
+			want:        "ALTER TABLE `logs`.`events` ADD COLUMN (`region` VARCHAR(16) NULL), MODIFY COLUMN `host` VARCHAR(128) NULL, DROP COLUMN `msg`",
+		},
+		{
+			name:        "added column modified later",
+			alterations: []*model.TableAlteration{add("region", "VARCHAR(16)"), modify("REGION", "VARCHAR(32)")},
+			want:        "ALTER TABLE `logs`.`events` ADD COLUMN (`REGION` VARCHAR(32) NULL)",
+		},
+		{
+			name:        "modified column dropped later",
+			alterations: []*model.TableAlteration{modify("host", "VARCHAR(128)"), drop("host")},
+			want:        "ALTER TABLE `logs`.`events` DROP COLUMN `host`",
+		},
+		{
+			name:        "renames collapse",
+			alterations: []*model.TableAlteration{rename("tmp"), rename("events_v2")},
+			want:        "ALTER TABLE `logs`.`events` RENAME `events_v2`",
+		},
+		{name: "rename with column changes", alterations: []*model.TableAlteration{add("region", "INT"), rename("events_v2")}, wantErr: true},
+		{name: "column dropped and added again", alterations: []*model.TableAlteration{drop("msg"), add("msg", "INT")}, wantErr: true},
+		{name: "alterations cancel out", alterations: []*model.TableAlteration{add("region", "INT"), drop("region")}, wantErr: true},
+		{name: "rename back to the current name", alterations: []*model.TableAlteration{rename("tmp"), rename("events")}, wantErr: true},
+		{name: "unknown column", alterations: []*model.TableAlteration{drop("nope")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &fakeDDLExecutor{table: &starrocks.TableSchemaDef{
+				DatabaseName: "logs",
+				TableName:    "events",
+				Fields: []starrocks.FieldSchemaDef{
+					{Name: "id", Type: "BIGINT", IsKey: true},
+					{Name: "host", Type: "VARCHAR(64)", IsNullable: true},
+					{Name: "msg", Type: "STRING", IsNullable: true},
+				},
+			}}
+			s := NewService(executor)
+
+			result, err := s.AlterTable(context.Background(), "logs", "events", tt.alterations, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AlterTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, errors.InvalidArgument) {
+					t.Errorf("AlterTable() error code = %s, want %s", errors.GetCode(err), errors.InvalidArgument)
+				}
+				if len(executor.altered) != 0 {
+					t.Errorf("executed %q after a rejected request", executor.altered)
+				}
+				return
+			}
+			if want := []string{tt.want}; !reflect.DeepEqual(result.Statements, want) || !reflect.DeepEqual(executor.altered, want) {
+				t.Errorf("AlterTable() statements = %q, executed %q, want %q", result.Statements, executor.altered, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package metadata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// baseDataTypes maps StarRocks type names to data types. DESCRIBE reports sized DECIMAL variants and DATETIME
+// aliases under their own names.
+// baseDataTypes 将StarRocks类型名称映射为数据类型。DESCRIBE会以各自的名称报告带位宽的DECIMAL变体与DATETIME别名。
+var baseDataTypes = map[string]enum.DataType{
+	"BOOLEAN":    enum.DataTypeBoolean,
+	"BOOL":       enum.DataTypeBoolean,
+	"TINYINT":    enum.DataTypeTinyInt,
+	"SMALLINT":   enum.DataTypeSmallInt,
+	"INT":        enum.DataTypeInt,
+	"INTEGER":    enum.DataTypeInt,
+	"BIGINT":     enum.DataTypeBigInt,
+	"LARGEINT":   enum.DataTypeLargeInt,
+	"FLOAT":      enum.DataTypeFloat,
+	"DOUBLE":     enum.DataTypeDouble,
+	"DECIMAL":    enum.DataTypeDecimal,
+	"DECIMALV2":  enum.DataTypeDecimal,
+	"DECIMALV3":  enum.DataTypeDecimal,
+	"DECIMAL32":  enum.DataTypeDecimal,
+	"DECIMAL64":  enum.DataTypeDecimal,
+	"DECIMAL128": enum.DataTypeDecimal,
+	"NUMERIC":    enum.DataTypeDecimal,
+	"DATE":       enum.DataTypeDate,
+	"DATEV2":     enum.DataTypeDate,
+	"DATETIME":   enum.DataTypeDateTime,
+	"DATETIMEV2": enum.DataTypeDateTime,
+	"TIMESTAMP":  enum.DataTypeDateTime,
+	"CHAR":       enum.DataTypeChar,
+	"VARCHAR":    enum.DataTypeVarchar,
+	"STRING":     enum.DataTypeString,
+	"TEXT":       enum.DataTypeString,
+	"JSON":       enum.DataTypeJSON,
+	"ARRAY":      enum.DataTypeArray,
+	"MAP":        enum.DataTypeMap,
+	"STRUCT":     enum.DataTypeStruct,
+}
+
+// ParseColumnType parses a StarRocks column type as DESCRIBE reports or CREATE TABLE declares it, e.g.,
+// "DECIMAL64(10, 2)", "varchar(255)" or "ARRAY<STRUCT<a int(11), b MAP<varchar(10),bigint(20)>>>". Type names
+// that are not recognized, such as BITMAP or HLL, parse to DataTypeUnknown; only malformed types are an error.
+// ParseColumnType 解析DESCRIBE报告或CREATE TABLE声明的StarRocks列类型，例如 "DECIMAL64(10, 2)"、"varchar(255)"
+// 或 "ARRAY<STRUCT<a int(11), b MAP<varchar(10),bigint(20)>>>"。无法识别的类型名 (如BITMAP或HLL) 解析为
+// DataTypeUnknown，只有格式错误的类型才会返回错误。
+func ParseColumnType(typeString string) (*model.ColumnType, error) {
+	p := &typeParser{s: typeString}
+	ct, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return ct, nil
+}
+
+// parseStarRocksTypeToDomainEnum returns the data type of a StarRocks column type, DataTypeUnknown when it cannot
+// be parsed.
+// parseStarRocksTypeToDomainEnum 返回StarRocks列类型对应的数据类型，无法解析时返回DataTypeUnknown。
+func parseStarRocksTypeToDomainEnum(srType string) enum.DataType {
+	ct, err := ParseColumnType(srType)
+	if err != nil {
+		return enum.DataTypeUnknown
+	}
+	return ct.DataType
+}
+
+// typeParser is a recursive descent parser over a column type string.
+// typeParser 是针对列类型字符串的递归下降解析器。
+type typeParser struct {
+	s   string
+	pos int
+}
+
+func (p *typeParser) parseType() (*model.ColumnType, error) {
+	name := p.identifier()
+	if name == "" || name[0] == '`' {
+		return nil, p.errorf("expected a type name")
+	}
+	upper := strings.ToUpper(name)
+	ct := &model.ColumnType{Name: upper, DataType: enum.DataTypeUnknown}
+	if dt, ok := baseDataTypes[upper]; ok {
+		ct.DataType = dt
+	}
+
+	switch ct.DataType {
+	case enum.DataTypeArray:
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		element, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		ct.Element = element
+		return ct, p.expect('>')
+	case enum.DataTypeMap:
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		key, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		value, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		ct.Key, ct.Value = key, value
+		return ct, p.expect('>')
+	case enum.DataTypeStruct:
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		for {
+			fieldName := strings.Trim(p.identifier(), "`")
+			if fieldName == "" {
+				return nil, p.errorf("expected a STRUCT field name")
+			}
+			p.skipSpace()
+			if p.pos < len(p.s) && p.s[p.pos] == ':' { // Hive-style "name:type"
+				p.pos++
+			}
+			fieldType, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			ct.Fields = append(ct.Fields, &model.StructField{Name: fieldName, Type: fieldType})
+			if p.skipSpace(); p.pos < len(p.s) && p.s[p.pos] == ',' {
+				p.pos++
+				continue
+			}
+			return ct, p.expect('>')
+		}
+	}
+
+	params, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	switch ct.DataType {
+	case enum.DataTypeChar, enum.DataTypeVarchar:
+		if len(params) > 0 {
+			ct.Length = params[0]
+		}
+	case enum.DataTypeDecimal:
+		if len(params) > 0 {
+			ct.Precision = params[0]
+		}
+		if len(params) > 1 {
+			ct.Scale = params[1]
+		}
+	}
+	// Other parameters, such as the display width of integer types, carry no type information.
+	return ct, nil
+}
+
+// parameters reads an optional parenthesized list of integers, e.g., "(10, 2)".
+// parameters 读取可选的括号内整数列表，例如 "(10, 2)"。
+func (p *typeParser) parameters() ([]int, error) {
+	if p.skipSpace(); p.pos >= len(p.s) || p.s[p.pos] != '(' {
+		return nil, nil
+	}
+	end := strings.IndexByte(p.s[p.pos:], ')')
+	if end < 0 {
+		return nil, p.errorf("unterminated type parameters")
+	}
+	var params []int
+	for _, part := range strings.Split(p.s[p.pos+1:p.pos+end], ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, p.errorf("invalid type parameter %q", part)
+		}
+		params = append(params, n)
+	}
+	p.pos += end + 1
+	return params, nil
+}
+
+// identifier reads a type or field name, which may be quoted with backticks.
+// identifier 读取类型名或字段名，名称可以用反引号括起。
+func (p *typeParser) identifier() string {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '`' {
+		if end := strings.IndexByte(p.s[p.pos+1:], '`'); end >= 0 {
+			p.pos += end + 2
+			return p.s[start:p.pos]
+		}
+	}
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *typeParser) expect(c byte) error {
+	if p.skipSpace(); p.pos >= len(p.s) || p.s[p.pos] != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *typeParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *typeParser) errorf(format string, args ...interface{}) error {
+	return errors.Newf(errors.InvalidArgument, "invalid column type %q at position %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
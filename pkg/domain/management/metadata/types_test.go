@@ -0,0 +1,63 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/common/types/enum"
+)
+
+func TestParseColumnType(t *testing.T) {
+	tests := []struct {
+		typeString string
+		want       string // String() of the parsed type
+		dataType   enum.DataType
+		wantErr    bool
+	}{
+		{typeString: "int(11)", want: "INT", dataType: enum.DataTypeInt},
+		{typeString: "BIGINT", want: "BIGINT", dataType: enum.DataTypeBigInt},
+		{typeString: "varchar(255)", want: "VARCHAR(255)", dataType: enum.DataTypeVarchar},
+		{typeString: "DECIMAL64( 10 , 2 )", want: "DECIMAL64(10,2)", dataType: enum.DataTypeDecimal},
+		{typeString: "datetime", want: "DATETIME", dataType: enum.DataTypeDateTime},
+		{typeString: "BITMAP", want: "BITMAP", dataType: enum.DataTypeUnknown},
+		{typeString: "ARRAY<int(11)>", want: "ARRAY<INT>", dataType: enum.DataTypeArray},
+		{typeString: "MAP<varchar(10),bigint(20)>", want: "MAP<VARCHAR(10),BIGINT>", dataType: enum.DataTypeMap},
+		{
+			typeString: "ARRAY<STRUCT<a int(11), b MAP<varchar(10),bigint(20)>>>",
+			want:       "ARRAY<STRUCT<a INT, b MAP<VARCHAR(10),BIGINT>>>",
+			dataType:   enum.DataTypeArray,
+		},
+		{typeString: "struct<a:int,b:string>", want: "STRUCT<a INT, b STRING>", dataType: enum.DataTypeStruct},
+		{typeString: "STRUCT<`a b` INT, `c-d` STRING>", want: "STRUCT<`a b` INT, `c-d` STRING>", dataType: enum.DataTypeStruct},
+		{typeString: "", wantErr: true},
+		{typeString: "`INT`", wantErr: true},
+		{typeString: "INT) ENGINE=mysql --", wantErr: true},
+		{typeString: "VARCHAR(10) COMMENT 'x'", wantErr: true},
+		{typeString: "VARCHAR(x)", wantErr: true},
+		{typeString: "VARCHAR(10", wantErr: true},
+		{typeString: "ARRAY<INT", wantErr: true},
+		{typeString: "MAP<INT>", wantErr: true},
+		{typeString: "STRUCT<>", wantErr: true},
+		{typeString: "STRUCT<a INT; DROP TABLE t>", wantErr: true},
+	}
+	for _, tt := range tests {
+		ct, err := ParseColumnType(tt.typeString)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseColumnType(%q) error = %v, wantErr %v", tt.typeString, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := ct.String(); got != tt.want {
+			t.Errorf("ParseColumnType(%q).String() = %q, want %q", tt.typeString, got, tt.want)
+		}
+		if ct.DataType != tt.dataType {
+			t.Errorf("ParseColumnType(%q).DataType = %v, want %v", tt.typeString, ct.DataType, tt.dataType)
+		}
+		// The rendered type is written to DDL, so it must parse back to itself.
+		again, err := ParseColumnType(ct.String())
+		if err != nil || again.String() != ct.String() {
+			t.Errorf("ParseColumnType(%q) = %v, %v, want the type to round trip", ct.String(), again, err)
+		}
+	}
+}
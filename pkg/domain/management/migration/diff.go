@@ -0,0 +1,397 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+)
+
+var (
+	// integerDisplayWidth matches the display width DESCRIBE reports for integer types, e.g. "BIGINT(20)".
+	// integerDisplayWidth 匹配DESCRIBE为整数类型报告的显示宽度，例如 "BIGINT(20)"。
+	integerDisplayWidth = regexp.MustCompile(`^(TINYINT|SMALLINT|INT|BIGINT|LARGEINT)\(\d+\)$`)
+	// decimalVariant matches the sized DECIMAL types DESCRIBE reports, e.g. "DECIMAL64(10,2)".
+	// decimalVariant 匹配DESCRIBE报告的带位宽的DECIMAL类型，例如 "DECIMAL64(10,2)"。
+	decimalVariant = regexp.MustCompile(`^DECIMAL(32|64|128|V3)\(`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// liveState is the introspected schema of the objects a desired state refers to. Keys are model.ObjectKey values.
+// liveState 是期望状态所涉及对象的线上模式，键为model.ObjectKey的值。
+type liveState struct {
+	tables  map[string]*metadatamodel.TableSchema      // 已声明且存在的表 Declared tables that exist
+	indexes map[string][]*starrocks.IndexDefinitionDef // 表键 -> 索引 Table key -> indexes
+	views   map[string]*starrocks.MaterializedViewDef  // 物化视图键 -> 物化视图 View key -> view
+	managed map[string][]string                        // 受管数据库 -> 其中的基础表 Managed database -> its base tables
+	exists  map[string]bool                            // 存在的基础表 Base tables that exist
+}
+
+// diff computes the plan that turns live into desired. Changes are ordered so that each statement only depends on
+// earlier ones: views and indexes are dropped first, then tables are created and altered, indexes and views are
+// created, and finally columns and tables are dropped.
+// diff 计算将live变为desired的计划。变更的顺序保证每条语句只依赖其之前的语句：先删除物化视图与索引，然后创建与修改表，
+// 再创建索引与物化视图，最后删除列与表。
+func diff(desired *model.DesiredState, live *liveState) (*model.Plan, error) {
+	var (
+		dropViews, dropIndexes, createTables, alterTables []*model.Change
+		createIndexes, createViews, dropColumns, dropTbls []*model.Change
+		warnings                                          []string
+	)
+
+	desiredIndexes := make(map[string][]*metadatamodel.IndexDefinition)
+	for _, idx := range desired.Indexes {
+		key := model.ObjectKey(idx.DatabaseName, idx.TableName)
+		desiredIndexes[key] = append(desiredIndexes[key], idx)
+	}
+	declaredTables := make(map[string]bool, len(desired.Tables))
+
+	for _, table := range desired.Tables {
+		key := model.ObjectKey(table.DatabaseName, table.TableName)
+		declaredTables[key] = true
+		current, exists := live.tables[key]
+		if !exists {
+			change, err := createTableChange(table, desiredIndexes[key])
+			if err != nil {
+				return nil, err
+			}
+			createTables = append(createTables, change)
+			continue
+		}
+
+		alters, drops, tableWarnings, err := diffColumns(table, current)
+		if err != nil {
+			return nil, err
+		}
+		alterTables = append(alterTables, alters...)
+		dropColumns = append(dropColumns, drops...)
+		warnings = append(warnings, tableWarnings...)
+
+		creates, removals, err := diffIndexes(table.DatabaseName, table.TableName, desiredIndexes[key], live.indexes[key])
+		if err != nil {
+			return nil, err
+		}
+		createIndexes = append(createIndexes, creates...)
+		dropIndexes = append(dropIndexes, removals...)
+	}
+
+	// Indexes on tables the desired state does not declare are created or recreated, but undeclared ones are kept.
+	for _, key := range sortedKeys(desiredIndexes) {
+		indexes := desiredIndexes[key]
+		if declaredTables[key] {
+			continue
+		}
+		if !live.exists[key] {
+			warnings = append(warnings, fmt.Sprintf("indexes are declared on %s, which is neither declared nor exists", key))
+			continue
+		}
+		var declaredLive []*starrocks.IndexDefinitionDef
+		for _, current := range live.indexes[key] {
+			for _, idx := range indexes {
+				if strings.EqualFold(idx.IndexName, current.IndexName) {
+					declaredLive = append(declaredLive, current)
+				}
+			}
+		}
+		creates, removals, err := diffIndexes(indexes[0].DatabaseName, indexes[0].TableName, indexes, declaredLive)
+		if err != nil {
+			return nil, err
+		}
+		createIndexes = append(createIndexes, creates...)
+		dropIndexes = append(dropIndexes, removals...)
+	}
+
+	declaredViews := make(map[string]bool, len(desired.MaterializedViews))
+	for _, mv := range desired.MaterializedViews {
+		key := model.ObjectKey(mv.DatabaseName, mv.ViewName)
+		declaredViews[key] = true
+		current, exists := live.views[key]
+		if exists {
+			if current.Query == "" || normalizeQuery(current.Query) == normalizeQuery(mv.Query) {
+				continue
+			}
+			change, err := dropViewChange(mv.DatabaseName, mv.ViewName, "defining query changed, the view is recreated")
+			if err != nil {
+				return nil, err
+			}
+			dropViews = append(dropViews, change)
+		}
+		ddl, err := metadata.RenderCreateMaterializedViewDDL(mv)
+		if err != nil {
+			return nil, err
+		}
+		createViews = append(createViews, &model.Change{Kind: model.ChangeCreateMaterializedView, Object: key, Statement: ddl})
+	}
+
+	for _, db := range sortedKeys(live.managed) {
+		for _, table := range live.managed[db] {
+			key := model.ObjectKey(db, table)
+			if declaredTables[key] || declaredViews[key] {
+				continue
+			}
+			ddl, err := starrocks.BuildDropTableDDL(db, table, true)
+			if err != nil {
+				return nil, err
+			}
+			dropTbls = append(dropTbls, &model.Change{Kind: model.ChangeDropTable, Object: key, Statement: ddl, Destructive: true, Reason: "not declared in managed database " + db})
+		}
+	}
+	for _, key := range sortedKeys(live.views) {
+		mv := live.views[key]
+		if declaredViews[key] || !isManaged(live, mv.DatabaseName) {
+			continue
+		}
+		change, err := dropViewChange(mv.DatabaseName, mv.ViewName, "not declared in managed database "+mv.DatabaseName)
+		if err != nil {
+			return nil, err
+		}
+		dropViews = append(dropViews, change)
+	}
+
+	plan := &model.Plan{Warnings: warnings}
+	for _, group := range [][]*model.Change{dropViews, dropIndexes, createTables, alterTables, createIndexes, createViews, dropColumns, dropTbls} {
+		plan.Changes = append(plan.Changes, group...)
+	}
+	plan.Checksum = planChecksum(plan)
+	return plan, nil
+}
+
+// createTableChange creates a declared table. Its declared indexes are part of the CREATE TABLE statement, so that
+// no schema change has to run right after the table is created.
+// createTableChange 创建声明的表。其声明的索引包含在CREATE TABLE语句中，从而无需在建表后立即执行模式变更。
+func createTableChange(table *metadatamodel.TableSchema, indexes []*metadatamodel.IndexDefinition) (*model.Change, error) {
+	ddl, err := metadata.RenderCreateTableDDL(table, indexes...)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Change{Kind: model.ChangeCreateTable, Object: model.ObjectKey(table.DatabaseName, table.TableName), Statement: ddl}, nil
+}
+
+// diffColumns compares the columns of a declared table with the live table. Added columns are combined into one
+// statement; changed key membership cannot be altered and is reported as a warning instead.
+// diffColumns 比较声明的表与线上表的列。新增列合并为一条语句；键列的变化无法修改，以警告的形式报告。
+func diffColumns(desired, live *metadatamodel.TableSchema) (alters, drops []*model.Change, warnings []string, err error) {
+	db, table := desired.DatabaseName, desired.TableName
+	tableKey := model.ObjectKey(db, table)
+
+	var added []*metadatamodel.FieldSchema
+	for _, field := range desired.Fields {
+		current := live.Field(field.Name)
+		if current == nil {
+			added = append(added, field)
+			continue
+		}
+		if isKeyColumn(desired, field) != current.IsPrimaryKey {
+			warnings = append(warnings, fmt.Sprintf("key membership of column %s.%s differs from the live table, recreate the table to change its keys", tableKey, field.Name))
+			continue
+		}
+		typeChanged := normalizeType(columnType(field)) != normalizeType(current.TypeString)
+		if !typeChanged && field.IsNullable == current.IsNullable &&
+			normalizeAggregation(field.AggregationType) == normalizeAggregation(current.AggregationType) &&
+			strings.Trim(field.DefaultValue, `'"`) == strings.Trim(current.DefaultValue, `'"`) {
+			continue
+		}
+		ddl, err := metadata.RenderAlterationDDL(db, table, desired, &metadatamodel.TableAlteration{Type: metadatamodel.AlterationModifyColumn, Column: field})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		reason := fmt.Sprintf("definition changed from %s to %s", describeColumn(current), describeColumn(field))
+		alters = append(alters, &model.Change{Kind: model.ChangeModifyColumn, Object: model.ObjectKey(db, table, field.Name), Statement: ddl, Destructive: typeChanged, Reason: reason, Database: db, Table: table})
+	}
+	if len(added) > 0 {
+		ddl, err := metadata.RenderAddColumnsDDL(db, table, added)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		names := make([]string, len(added))
+		for i, f := range added {
+			names[i] = f.Name
+		}
+		alters = append([]*model.Change{{Kind: model.ChangeAddColumns, Object: tableKey, Statement: ddl, Reason: "add " + strings.Join(names, ", "), Database: db, Table: table}}, alters...)
+	}
+
+	for _, field := range live.Fields {
+		if desired.Field(field.Name) != nil {
+			continue
+		}
+		ddl, err := starrocks.BuildDropColumnDDL(db, table, field.Name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		drops = append(drops, &model.Change{Kind: model.ChangeDropColumn, Object: model.ObjectKey(db, table, field.Name), Statement: ddl, Destructive: true, Reason: "column is not declared", Database: db, Table: table})
+	}
+	return alters, drops, warnings, nil
+}
+
+// diffIndexes compares declared and live indexes by type and columns. A changed index is dropped and recreated.
+// diffIndexes 按类型与列比较声明的索引与线上索引，发生变化的索引会被删除后重建。
+func diffIndexes(db, table string, desired []*metadatamodel.IndexDefinition, live []*starrocks.IndexDefinitionDef) (creates, drops []*model.Change, err error) {
+	liveByName := make(map[string]*starrocks.IndexDefinitionDef, len(live))
+	for _, idx := range live {
+		liveByName[strings.ToLower(idx.IndexName)] = idx
+	}
+	declared := make(map[string]bool, len(desired))
+	for _, idx := range desired {
+		name := strings.ToLower(idx.IndexName)
+		declared[name] = true
+		if current, ok := liveByName[name]; ok {
+			if sameIndex(idx, current) {
+				continue
+			}
+			ddl, err := starrocks.BuildDropIndexDDL(db, table, current.IndexName)
+			if err != nil {
+				return nil, nil, err
+			}
+			drops = append(drops, &model.Change{Kind: model.ChangeDropIndex, Object: model.ObjectKey(db, table, current.IndexName), Statement: ddl, Destructive: true, Reason: "index definition changed, the index is recreated", Database: db, Table: table})
+		}
+		ddl, err := metadata.RenderCreateIndexDDL(idx)
+		if err != nil {
+			return nil, nil, err
+		}
+		creates = append(creates, &model.Change{Kind: model.ChangeCreateIndex, Object: model.ObjectKey(db, table, idx.IndexName), Statement: ddl, Database: db, Table: table})
+	}
+	for _, idx := range live {
+		if declared[strings.ToLower(idx.IndexName)] {
+			continue
+		}
+		ddl, err := starrocks.BuildDropIndexDDL(db, table, idx.IndexName)
+		if err != nil {
+			return nil, nil, err
+		}
+		drops = append(drops, &model.Change{Kind: model.ChangeDropIndex, Object: model.ObjectKey(db, table, idx.IndexName), Statement: ddl, Destructive: true, Reason: "index is not declared", Database: db, Table: table})
+	}
+	return creates, drops, nil
+}
+
+func dropViewChange(db, view, reason string) (*model.Change, error) {
+	ddl, err := starrocks.BuildDropMaterializedViewDDL(db, view, true)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Change{Kind: model.ChangeDropMaterializedView, Object: model.ObjectKey(db, view), Statement: ddl, Destructive: true, Reason: reason}, nil
+}
+
+func sameIndex(desired *metadatamodel.IndexDefinition, live *starrocks.IndexDefinitionDef) bool {
+	if !strings.EqualFold(normalizeIndexType(desired.IndexType), normalizeIndexType(live.IndexType)) || len(desired.Fields) != len(live.Fields) {
+		return false
+	}
+	for i := range desired.Fields {
+		if !strings.EqualFold(desired.Fields[i], live.Fields[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isKeyColumn(table *metadatamodel.TableSchema, field *metadatamodel.FieldSchema) bool {
+	if len(table.KeyColumns) == 0 {
+		return field.IsPrimaryKey
+	}
+	for _, name := range table.KeyColumns {
+		if strings.EqualFold(name, field.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isManaged(live *liveState, db string) bool {
+	for managed := range live.managed {
+		if strings.EqualFold(managed, db) {
+			return true
+		}
+	}
+	return false
+}
+
+func columnType(field *metadatamodel.FieldSchema) string {
+	if field.TypeString != "" {
+		return field.TypeString
+	}
+	return field.DataType.String()
+}
+
+func describeColumn(field *metadatamodel.FieldSchema) string {
+	desc := columnType(field)
+	if aggregation := normalizeAggregation(field.AggregationType); aggregation != "" {
+		desc += " " + aggregation
+	}
+	if field.IsNullable {
+		desc += " NULL"
+	} else {
+		desc += " NOT NULL"
+	}
+	if field.DefaultValue != "" {
+		desc += " DEFAULT " + field.DefaultValue
+	}
+	return desc
+}
+
+// normalizeType canonicalizes a column type so that declared types compare equal to the types DESCRIBE reports.
+// normalizeType 规范化列类型，使声明的类型与DESCRIBE报告的类型可以直接比较。
+func normalizeType(t string) string {
+	t = strings.ToUpper(whitespace.ReplaceAllString(t, ""))
+	switch t {
+	case "STRING":
+		return "VARCHAR(65533)"
+	case "BOOL":
+		return "BOOLEAN"
+	case "INTEGER":
+		return "INT"
+	}
+	if m := integerDisplayWidth.FindStringSubmatch(t); m != nil {
+		return m[1]
+	}
+	return decimalVariant.ReplaceAllString(t, "DECIMAL(")
+}
+
+func normalizeAggregation(aggregation string) string {
+	aggregation = strings.ToUpper(strings.TrimSpace(aggregation))
+	if aggregation == "NONE" {
+		return ""
+	}
+	return aggregation
+}
+
+func normalizeIndexType(indexType string) string {
+	indexType = strings.ToUpper(strings.TrimSpace(indexType))
+	if indexType == "GIN" {
+		return "INVERTED"
+	}
+	return indexType
+}
+
+// normalizeQuery compares view queries ignoring whitespace, case, identifier quoting and trailing semicolons.
+// normalizeQuery 比较视图查询时忽略空白、大小写、标识符引号以及末尾的分号。
+func normalizeQuery(query string) string {
+	query = strings.ReplaceAll(query, "`", "")
+	query = whitespace.ReplaceAllString(strings.TrimSpace(query), " ")
+	return strings.ToLower(strings.TrimRight(query, "; "))
+}
+
+// planChecksum digests the statements of a plan in order.
+// planChecksum 按顺序计算计划语句的摘要。
+func planChecksum(plan *model.Plan) string {
+	h := sha256.New()
+	for _, statement := range plan.Statements() {
+		h.Write([]byte(statement))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,219 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+)
+
+func TestDiff(t *testing.T) {
+	events := func(mutate func(*metadatamodel.TableSchema)) *metadatamodel.TableSchema {
+		table := &metadatamodel.TableSchema{
+			DatabaseName: "db",
+			TableName:    "events",
+			Fields: []*metadatamodel.FieldSchema{
+				{Name: "ts", TypeString: "DATETIME", IsPrimaryKey: true},
+				{Name: "host", TypeString: "VARCHAR(64)", IsNullable: true},
+				{Name: "bytes", TypeString: "BIGINT", IsNullable: true},
+			},
+		}
+		if mutate != nil {
+			mutate(table)
+		}
+		return table
+	}
+	// live reports the events table the way DESCRIBE does.
+	live := func(mutate func(*metadatamodel.TableSchema)) *liveState {
+		table := events(func(t *metadatamodel.TableSchema) { t.Fields[2].TypeString = "BIGINT(20)" })
+		if mutate != nil {
+			mutate(table)
+		}
+		return &liveState{
+			tables:  map[string]*metadatamodel.TableSchema{"db.events": table},
+			indexes: map[string][]*starrocks.IndexDefinitionDef{},
+			views:   map[string]*starrocks.MaterializedViewDef{},
+			exists:  map[string]bool{"db.events": true},
+		}
+	}
+	hostIndex := &metadatamodel.IndexDefinition{DatabaseName: "db", TableName: "events", IndexName: "idx_host", IndexType: "BITMAP", Fields: []string{"host"}}
+	view := &metadatamodel.MaterializedViewDefinition{DatabaseName: "db", ViewName: "hourly", Query: "SELECT host, count(*) FROM events GROUP BY host"}
+
+	tests := []struct {
+		name        string
+		desired     *model.DesiredState
+		live        *liveState
+		want        []string // 按执行顺序的 "KIND object" Changes as "KIND object" in execution order
+		destructive int
+		warnings    int
+		wantErr     bool
+	}{
+		{
+			name:    "in sync",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(nil)}},
+			live:    live(nil),
+		},
+		{
+			name:    "new table with its index",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(nil)}, Indexes: []*metadatamodel.IndexDefinition{hostIndex}},
+			live:    &liveState{},
+			want:    []string{"CREATE_TABLE db.events"},
+		},
+		{
+			name: "added, modified and dropped columns",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(func(t *metadatamodel.TableSchema) {
+				t.Fields[1].TypeString = "VARCHAR(128)"
+				t.Fields[2] = &metadatamodel.FieldSchema{Name: "region", TypeString: "VARCHAR(8)", IsNullable: true}
+			})}},
+			live:        live(nil),
+			want:        []string{"ADD_COLUMNS db.events", "MODIFY_COLUMN db.events.host", "DROP_COLUMN db.events.bytes"},
+			destructive: 2,
+		},
+		{
+			name: "nullability change is not destructive",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(func(t *metadatamodel.TableSchema) {
+				t.Fields[1].IsNullable = false
+			})}},
+			live: live(nil),
+			want: []string{"MODIFY_COLUMN db.events.host"},
+		},
+		{
+			name: "changed key membership is a warning",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(func(t *metadatamodel.TableSchema) {
+				t.Fields[0].IsPrimaryKey = false
+			})}},
+			live:     live(nil),
+			warnings: 1,
+		},
+		{
+			name:    "undeclared index on a declared table is dropped",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(nil)}},
+			live: func() *liveState {
+				l := live(nil)
+				l.indexes["db.events"] = []*starrocks.IndexDefinitionDef{{IndexName: "idx_old", IndexType: "BITMAP", Fields: []string{"host"}}}
+				return l
+			}(),
+			want:        []string{"DROP_INDEX db.events.idx_old"},
+			destructive: 1,
+		},
+		{
+			name:    "changed index is recreated",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(nil)}, Indexes: []*metadatamodel.IndexDefinition{hostIndex}},
+			live: func() *liveState {
+				l := live(nil)
+				l.indexes["db.events"] = []*starrocks.IndexDefinitionDef{{IndexName: "IDX_HOST", IndexType: "INVERTED", Fields: []string{"host"}}}
+				return l
+			}(),
+			want:        []string{"DROP_INDEX db.events.idx_host", "CREATE_INDEX db.events.idx_host"},
+			destructive: 1,
+		},
+		{
+			name:    "index on an undeclared table keeps the other indexes",
+			desired: &model.DesiredState{Indexes: []*metadatamodel.IndexDefinition{hostIndex}},
+			live: func() *liveState {
+				l := live(nil)
+				l.indexes["db.events"] = []*starrocks.IndexDefinitionDef{{IndexName: "idx_other", IndexType: "BITMAP", Fields: []string{"bytes"}}}
+				return l
+			}(),
+			want: []string{"CREATE_INDEX db.events.idx_host"},
+		},
+		{
+			name:     "index on a missing table is a warning",
+			desired:  &model.DesiredState{Indexes: []*metadatamodel.IndexDefinition{hostIndex}},
+			live:     &liveState{},
+			warnings: 1,
+		},
+		{
+			name:    "unchanged view",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(nil)}, MaterializedViews: []*metadatamodel.MaterializedViewDefinition{view}},
+			live: func() *liveState {
+				l := live(nil)
+				l.views["db.hourly"] = &starrocks.MaterializedViewDef{DatabaseName: "db", ViewName: "hourly", Query: "select `host`, COUNT(*)\nfrom `events` group by `host`;"}
+				return l
+			}(),
+		},
+		{
+			name:    "changed view is recreated",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(nil)}, MaterializedViews: []*metadatamodel.MaterializedViewDefinition{view}},
+			live: func() *liveState {
+				l := live(nil)
+				l.views["db.hourly"] = &starrocks.MaterializedViewDef{DatabaseName: "db", ViewName: "hourly", Query: "SELECT host FROM events"}
+				return l
+			}(),
+			want:        []string{"DROP_MATERIALIZED_VIEW db.hourly", "CREATE_MATERIALIZED_VIEW db.hourly"},
+			destructive: 1,
+		},
+		{
+			name:    "undeclared objects of a managed database are dropped",
+			desired: &model.DesiredState{ManagedDatabases: []string{"db"}, Tables: []*metadatamodel.TableSchema{events(nil)}},
+			live: func() *liveState {
+				l := live(nil)
+				l.managed = map[string][]string{"db": {"events", "legacy"}}
+				l.views["db.old_view"] = &starrocks.MaterializedViewDef{DatabaseName: "db", ViewName: "old_view", Query: "SELECT 1"}
+				l.views["other.kept"] = &starrocks.MaterializedViewDef{DatabaseName: "other", ViewName: "kept", Query: "SELECT 1"}
+				return l
+			}(),
+			want:        []string{"DROP_MATERIALIZED_VIEW db.old_view", "DROP_TABLE db.legacy"},
+			destructive: 2,
+		},
+		{
+			name: "invalid column type",
+			desired: &model.DesiredState{Tables: []*metadatamodel.TableSchema{events(func(t *metadatamodel.TableSchema) {
+				t.Fields[2] = &metadatamodel.FieldSchema{Name: "evil", TypeString: "INT) ENGINE=mysql --"}
+			})}},
+			live:    live(nil),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := diff(tt.desired, tt.live)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("diff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var got []string
+			for _, c := range plan.Changes {
+				got = append(got, string(c.Kind)+" "+c.Object)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() changes = %v, want %v", got, tt.want)
+			}
+			if n := len(plan.Destructive()); n != tt.destructive {
+				t.Errorf("diff() destructive changes = %d, want %d", n, tt.destructive)
+			}
+			if len(plan.Warnings) != tt.warnings {
+				t.Errorf("diff() warnings = %v, want %d", plan.Warnings, tt.warnings)
+			}
+			again, _ := diff(tt.desired, tt.live)
+			if plan.Checksum == "" || again.Checksum != plan.Checksum {
+				t.Errorf("diff() checksum = %q, then %q, want a stable checksum", plan.Checksum, again.Checksum)
+			}
+		})
+	}
+}
+
+func TestNormalizeType(t *testing.T) {
+	tests := []struct {
+		declared, described string
+		same                bool
+	}{
+		{"BIGINT", "BIGINT(20)", true},
+		{"int", "INT(11)", true},
+		{"INTEGER", "INT(11)", true},
+		{"STRING", "VARCHAR(65533)", true},
+		{"BOOL", "BOOLEAN", true},
+		{"DECIMAL(10, 2)", "DECIMAL64(10,2)", true},
+		{"VARCHAR(64)", "VARCHAR(128)", false},
+		{"INT", "BIGINT(20)", false},
+	}
+	for _, tt := range tests {
+		if same := normalizeType(tt.declared) == normalizeType(tt.described); same != tt.same {
+			t.Errorf("normalizeType(%q) == normalizeType(%q) is %v, want %v", tt.declared, tt.described, same, tt.same)
+		}
+	}
+}
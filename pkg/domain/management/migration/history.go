@@ -0,0 +1,191 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+)
+
+// historyTimeLayout is the layout of the DATETIME columns of the history table.
+// historyTimeLayout 是历史表DATETIME列的格式。
+const historyTimeLayout = "2006-01-02 15:04:05"
+
+// historyColumns are the columns of the history table, in the order List selects them.
+// historyColumns 是历史表的列，顺序与List查询的顺序一致。
+var historyColumns = []string{"id", "checksum", "status", "statements", "applied", "applied_by", "error", "started_at", "finished_at"}
+
+// historyRow is the Stream Load row of a migration record.
+// historyRow 是迁移记录对应的Stream Load行。
+type historyRow struct {
+	ID         string `json:"id"`
+	Checksum   string `json:"checksum"`
+	Status     string `json:"status"`
+	Statements string `json:"statements"`
+	Applied    int    `json:"applied"`
+	AppliedBy  string `json:"applied_by"`
+	Error      string `json:"error"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+}
+
+// starrocksHistoryStore keeps the migration history in a StarRocks table, created on first use.
+// starrocksHistoryStore 将迁移历史保存在StarRocks表中，该表在首次使用时创建。
+type starrocksHistoryStore struct {
+	client   starrocks.Client
+	ddl      starrocks.DDLExecutor
+	database string
+	table    string
+	cfg      config.MigrationConfig
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// NewHistoryStore creates a history store backed by the StarRocks table configured in cfg.
+// NewHistoryStore 创建以cfg中配置的StarRocks表为存储的迁移历史。
+func NewHistoryStore(client starrocks.Client, ddl starrocks.DDLExecutor, cfg config.MigrationConfig) (HistoryStore, error) {
+	if cfg.HistoryDatabase == "" || cfg.HistoryTable == "" {
+		return nil, errors.New(errors.ConfigError, "migration history database and table must be configured")
+	}
+	return &starrocksHistoryStore{
+		client:   client,
+		ddl:      ddl,
+		database: cfg.HistoryDatabase,
+		table:    cfg.HistoryTable,
+		cfg:      cfg,
+	}, nil
+}
+
+// Record stream-loads the record as one JSON row.
+// Record 以一行JSON的形式通过Stream Load写入记录。
+func (s *starrocksHistoryStore) Record(ctx context.Context, record *model.MigrationRecord) error {
+	if err := s.ensureTable(ctx); err != nil {
+		return err
+	}
+	statements, err := json.Marshal(record.Statements)
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "failed to serialize migration statements")
+	}
+	payload, err := json.Marshal(historyRow{
+		ID:         record.ID,
+		Checksum:   record.Checksum,
+		Status:     string(record.Status),
+		Statements: string(statements),
+		Applied:    record.Applied,
+		AppliedBy:  record.AppliedBy,
+		Error:      record.Error,
+		StartedAt:  record.StartedAt.UTC().Format(historyTimeLayout),
+		FinishedAt: record.FinishedAt.UTC().Format(historyTimeLayout),
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "failed to serialize migration record")
+	}
+	opts := &starrocks.StreamLoadOptions{Format: "json", Label: "migration_" + record.ID}
+	if _, err := s.client.StreamLoad(ctx, s.database, s.table, bytes.NewReader(payload), opts); err != nil {
+		return errors.Wrapf(err, errors.DatabaseError, "failed to record migration %s", record.ID)
+	}
+	return nil
+}
+
+// List returns the most recent migrations, newest first.
+// List 返回最近的迁移记录，按时间倒序。
+func (s *starrocksHistoryStore) List(ctx context.Context, limit int) ([]*model.MigrationRecord, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s.%s ORDER BY started_at DESC LIMIT %d",
+		strings.Join(historyColumns, ", "), quoteIdentifier(s.database), quoteIdentifier(s.table), limit)
+	result, err := s.client.Execute(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.DatabaseError, "failed to read migration history")
+	}
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.DatabaseError, "reading migration history resulted in StarRocks error")
+	}
+
+	records := make([]*model.MigrationRecord, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if len(row) < len(historyColumns) {
+			return nil, errors.New(errors.InternalError, "unexpected migration history result format")
+		}
+		record := &model.MigrationRecord{
+			ID:        cellString(row[0]),
+			Checksum:  cellString(row[1]),
+			Status:    model.MigrationStatus(cellString(row[2])),
+			AppliedBy: cellString(row[5]),
+			Error:     cellString(row[6]),
+		}
+		if statements := cellString(row[3]); statements != "" {
+			if err := json.Unmarshal([]byte(statements), &record.Statements); err != nil {
+				return nil, errors.Wrapf(err, errors.SerializationError, "failed to parse statements of migration %s", record.ID)
+			}
+		}
+		record.Applied, _ = strconv.Atoi(cellString(row[4]))
+		record.StartedAt, _ = time.ParseInLocation(historyTimeLayout, cellString(row[7]), time.UTC)
+		record.FinishedAt, _ = time.ParseInLocation(historyTimeLayout, cellString(row[8]), time.UTC)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ensureTable creates the history table if it does not exist yet. A failed attempt is retried on the next call.
+// ensureTable 在历史表不存在时创建该表。创建失败时会在下一次调用时重试。
+func (s *starrocksHistoryStore) ensureTable(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	schema := &starrocks.TableSchemaDef{
+		DatabaseName: s.database,
+		TableName:    s.table,
+		Fields: []starrocks.FieldSchemaDef{
+			{Name: "id", Type: "VARCHAR(64)", IsKey: true},
+			{Name: "checksum", Type: "VARCHAR(64)", IsNullable: true},
+			{Name: "status", Type: "VARCHAR(16)", IsNullable: true},
+			{Name: "statements", Type: "STRING", IsNullable: true},
+			{Name: "applied", Type: "INT", IsNullable: true},
+			{Name: "applied_by", Type: "VARCHAR(255)", IsNullable: true},
+			{Name: "error", Type: "STRING", IsNullable: true},
+			{Name: "started_at", Type: "DATETIME", IsNullable: true},
+			{Name: "finished_at", Type: "DATETIME", IsNullable: true},
+		},
+		KeysType:            starrocks.KeysTypePrimary,
+		DistributionColumns: []string{"id"},
+		Properties:          s.cfg.Properties,
+		Comment:             "Schema migrations applied by DataSeaP",
+		IfNotExists:         true,
+	}
+	if err := s.ddl.CreateTable(ctx, schema); err != nil {
+		return errors.Wrapf(err, errors.DatabaseError, "failed to create migration history table %s.%s", s.database, s.table)
+	}
+	s.ready = true
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func cellString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,52 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+)
+
+// ApplyOptions controls how a plan is applied.
+// ApplyOptions 控制计划的应用方式。
+type ApplyOptions struct {
+	// AllowDestructive 允许执行具有破坏性的变更，否则包含此类变更的计划会被拒绝。
+	// AllowDestructive Permits destructive changes; otherwise a plan containing any is refused.
+	AllowDestructive bool
+
+	// ExpectedChecksum (可选) 审阅过的计划的摘要，重新计算的计划与之不同时拒绝应用。
+	// ExpectedChecksum (Optional) Checksum of the reviewed plan; the apply is refused when the recomputed plan differs.
+	ExpectedChecksum string
+
+	// AppliedBy (可选) 记录在迁移历史中的操作者。
+	// AppliedBy (Optional) Operator recorded in the migration history.
+	AppliedBy string
+}
+
+// Service defines the interface for declarative schema migrations.
+// Service 定义了声明式模式迁移的接口。
+type Service interface {
+	// Plan compares the desired state with the live schema and returns the ordered changes that reconcile them.
+	// Plan 比较期望状态与线上模式，返回使二者一致的有序变更。
+	Plan(ctx context.Context, desired *model.DesiredState) (*model.Plan, error)
+
+	// Apply plans the desired state and executes the plan, recording the migration in the history. A plan without
+	// changes is not recorded and returns a nil record.
+	// Apply 为期望状态生成计划并执行，同时在历史中记录此次迁移。没有变更的计划不会被记录，返回的记录为nil。
+	Apply(ctx context.Context, desired *model.DesiredState, opts ApplyOptions) (*model.Plan, *model.MigrationRecord, error)
+
+	// History returns the most recent migrations, newest first.
+	// History 返回最近的迁移记录，按时间倒序。
+	History(ctx context.Context, limit int) ([]*model.MigrationRecord, error)
+}
+
+// HistoryStore persists the migration history.
+// HistoryStore 持久化迁移历史。
+type HistoryStore interface {
+	// Record stores a finished migration.
+	// Record 存储一次已结束的迁移。
+	Record(ctx context.Context, record *model.MigrationRecord) error
+
+	// List returns the most recent migrations, newest first.
+	// List 返回最近的迁移记录，按时间倒序。
+	List(ctx context.Context, limit int) ([]*model.MigrationRecord, error)
+}
@@ -0,0 +1,113 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+)
+
+// document is the layout of a desired-state YAML file. Database is the default database of the objects the file
+// declares without one.
+// document 是期望状态YAML文件的结构。Database是文件中未指定数据库的对象所使用的默认数据库。
+type document struct {
+	Database          string
+	ManagedDatabases  []string
+	Tables            []*metadatamodel.TableSchema
+	Indexes           []*metadatamodel.IndexDefinition
+	MaterializedViews []*metadatamodel.MaterializedViewDefinition
+}
+
+// LoadDesiredState reads and merges the desired-state YAML files at paths. A directory contributes its *.yaml and
+// *.yml files in name order. Field names follow the JSON names of the metadata models, e.g. tableName, keyColumns.
+// LoadDesiredState 读取并合并paths中的期望状态YAML文件。目录会按文件名顺序读取其中的 *.yaml 与 *.yml 文件。
+// 字段名与元数据模型的JSON名称一致，例如 tableName、keyColumns。
+func LoadDesiredState(paths ...string) (*model.DesiredState, error) {
+	files, err := desiredStateFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New(errors.InvalidArgument, "no desired-state files found")
+	}
+
+	state := &model.DesiredState{}
+	for _, file := range files {
+		doc, err := readDocument(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range doc.Tables {
+			if t != nil && t.DatabaseName == "" {
+				t.DatabaseName = doc.Database
+			}
+		}
+		for _, idx := range doc.Indexes {
+			if idx != nil && idx.DatabaseName == "" {
+				idx.DatabaseName = doc.Database
+			}
+		}
+		for _, mv := range doc.MaterializedViews {
+			if mv != nil && mv.DatabaseName == "" {
+				mv.DatabaseName = doc.Database
+			}
+		}
+		state.ManagedDatabases = append(state.ManagedDatabases, doc.ManagedDatabases...)
+		state.Tables = append(state.Tables, doc.Tables...)
+		state.Indexes = append(state.Indexes, doc.Indexes...)
+		state.MaterializedViews = append(state.MaterializedViews, doc.MaterializedViews...)
+	}
+	if err := state.Validate(); err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "invalid desired state")
+	}
+	return state, nil
+}
+
+func desiredStateFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.InvalidArgument, "cannot read desired-state path %s", path)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.InvalidArgument, "cannot read desired-state directory %s", path)
+		}
+		var names []string
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				names = append(names, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(names)
+		files = append(files, names...)
+	}
+	return files, nil
+}
+
+func readDocument(file string) (*document, error) {
+	// Property keys such as "dynamic_partition.enable" contain dots, so keys are not split on them.
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	v.SetConfigFile(file)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Wrapf(err, errors.InvalidArgument, "failed to read desired-state file %s", file)
+	}
+	var doc document
+	if err := v.Unmarshal(&doc); err != nil {
+		return nil, errors.Wrapf(err, errors.InvalidArgument, "failed to parse desired-state file %s", file)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+)
+
+// DesiredState is the declared schema of the StarRocks objects managed as code.
+// DesiredState 是以代码方式管理的StarRocks对象的声明式模式。
+type DesiredState struct {
+	// ManagedDatabases (可选) 完全由期望状态管理的数据库，其中未声明的表与物化视图会被删除。
+	// ManagedDatabases (Optional) Databases owned entirely by the desired state; undeclared tables and materialized views in them are dropped.
+	ManagedDatabases []string `json:"managedDatabases,omitempty"`
+
+	// Tables 期望存在的表。
+	// Tables Tables that should exist.
+	Tables []*metadatamodel.TableSchema `json:"tables,omitempty"`
+
+	// Indexes 期望存在的索引。已声明表上未声明的索引会被删除。
+	// Indexes Indexes that should exist. Undeclared indexes on declared tables are dropped.
+	Indexes []*metadatamodel.IndexDefinition `json:"indexes,omitempty"`
+
+	// MaterializedViews 期望存在的物化视图。
+	// MaterializedViews Materialized views that should exist.
+	MaterializedViews []*metadatamodel.MaterializedViewDefinition `json:"materializedViews,omitempty"`
+}
+
+// ChangeKind identifies the kind of a planned change.
+// ChangeKind 标识计划中变更的类型。
+type ChangeKind string
+
+const (
+	ChangeCreateTable            ChangeKind = "CREATE_TABLE"             // 创建表 Create a table
+	ChangeDropTable              ChangeKind = "DROP_TABLE"               // 删除表 Drop a table
+	ChangeAddColumns             ChangeKind = "ADD_COLUMNS"              // 添加列 Add columns
+	ChangeModifyColumn           ChangeKind = "MODIFY_COLUMN"            // 修改列定义 Change a column definition
+	ChangeDropColumn             ChangeKind = "DROP_COLUMN"              // 删除列 Drop a column
+	ChangeCreateIndex            ChangeKind = "CREATE_INDEX"             // 创建索引 Create an index
+	ChangeDropIndex              ChangeKind = "DROP_INDEX"               // 删除索引 Drop an index
+	ChangeCreateMaterializedView ChangeKind = "CREATE_MATERIALIZED_VIEW" // 创建物化视图 Create a materialized view
+	ChangeDropMaterializedView   ChangeKind = "DROP_MATERIALIZED_VIEW"   // 删除物化视图 Drop a materialized view
+)
+
+// Change is one DDL statement of a migration plan.
+// Change 是迁移计划中的一条DDL语句。
+type Change struct {
+	// Kind 变更类型。
+	// Kind Kind of change.
+	Kind ChangeKind `json:"kind"`
+
+	// Object 变更的对象，例如 "db.table" 或 "db.table.column"。
+	// Object The changed object, e.g., "db.table" or "db.table.column".
+	Object string `json:"object"`
+
+	// Statement 执行该变更的DDL语句。
+	// Statement The DDL statement carrying out the change.
+	Statement string `json:"statement"`
+
+	// Destructive 该变更是否会删除对象或数据，或可能截断数据。
+	// Destructive Whether the change drops objects or data, or may truncate data.
+	Destructive bool `json:"destructive"`
+
+	// Reason (可选) 变更原因的说明。
+	// Reason (Optional) Why the change is needed.
+	Reason string `json:"reason,omitempty"`
+
+	// Database, Table 该变更以异步模式变更任务修改的表，其他变更为空。应用时会等待该任务完成后再执行下一条语句。
+	// Database, Table The table the change alters through an asynchronous schema change job, empty for other
+	// changes. Applying waits for the job to finish before the next statement runs.
+	Database string `json:"-"`
+	Table    string `json:"-"`
+}
+
+// Plan is the ordered list of changes that brings the live schema to the desired state.
+// Plan 是将线上模式变更为期望状态的有序变更列表。
+type Plan struct {
+	// Changes 按执行顺序排列的变更。
+	// Changes Changes in execution order.
+	Changes []*Change `json:"changes"`
+
+	// Warnings 计划无法自动消除的差异，例如键列的变化。
+	// Warnings Differences the plan cannot reconcile, such as changed key columns.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Checksum 计划语句的SHA-256摘要，用于确保应用的是审阅过的计划。
+	// Checksum SHA-256 digest of the plan statements, to make sure the reviewed plan is the one applied.
+	Checksum string `json:"checksum"`
+}
+
+// Destructive returns the destructive changes of the plan.
+// Destructive 返回计划中具有破坏性的变更。
+func (p *Plan) Destructive() []*Change {
+	var destructive []*Change
+	for _, c := range p.Changes {
+		if c.Destructive {
+			destructive = append(destructive, c)
+		}
+	}
+	return destructive
+}
+
+// Statements returns the DDL statements of the plan in execution order.
+// Statements 按执行顺序返回计划的DDL语句。
+func (p *Plan) Statements() []string {
+	statements := make([]string, len(p.Changes))
+	for i, c := range p.Changes {
+		statements[i] = c.Statement
+	}
+	return statements
+}
+
+// MigrationStatus is the outcome of applying a plan.
+// MigrationStatus 是应用计划的结果。
+type MigrationStatus string
+
+const (
+	MigrationStatusApplied MigrationStatus = "APPLIED" // 所有语句均已执行 Every statement was executed
+	MigrationStatusFailed  MigrationStatus = "FAILED"  // 某条语句执行失败 A statement failed
+)
+
+// MigrationRecord is one entry of the migration history.
+// MigrationRecord 是迁移历史中的一条记录。
+type MigrationRecord struct {
+	// ID 迁移的唯一标识。
+	// ID Unique identifier of the migration.
+	ID string `json:"id"`
+
+	// Checksum 所应用计划的摘要。
+	// Checksum Digest of the applied plan.
+	Checksum string `json:"checksum"`
+
+	// Status 迁移结果。
+	// Status Outcome of the migration.
+	Status MigrationStatus `json:"status"`
+
+	// Statements 计划中的全部语句。
+	// Statements All statements of the plan.
+	Statements []string `json:"statements"`
+
+	// Applied 成功执行的语句数。
+	// Applied Number of statements executed successfully.
+	Applied int `json:"applied"`
+
+	// AppliedBy (可选) 执行迁移的操作者。
+	// AppliedBy (Optional) Who ran the migration.
+	AppliedBy string `json:"appliedBy,omitempty"`
+
+	// Error (可选) 失败语句的错误信息。
+	// Error (Optional) Error of the failed statement.
+	Error string `json:"error,omitempty"`
+
+	// StartedAt 开始时间。
+	// StartedAt Start time.
+	StartedAt time.Time `json:"startedAt"`
+
+	// FinishedAt 结束时间。
+	// FinishedAt End time.
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// Validate performs basic validation.
+func (ds *DesiredState) Validate() error {
+	tables := make(map[string]bool, len(ds.Tables))
+	for _, t := range ds.Tables {
+		if t == nil {
+			return metadatamodel.NewDomainError("DesiredState Tables cannot contain an empty table")
+		}
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		key := ObjectKey(t.DatabaseName, t.TableName)
+		if tables[key] {
+			return metadatamodel.NewDomainError(fmt.Sprintf("table %s is declared more than once", key))
+		}
+		tables[key] = true
+	}
+	indexes := make(map[string]bool, len(ds.Indexes))
+	for _, idx := range ds.Indexes {
+		if idx == nil {
+			return metadatamodel.NewDomainError("DesiredState Indexes cannot contain an empty index")
+		}
+		if err := idx.Validate(); err != nil {
+			return err
+		}
+		key := ObjectKey(idx.DatabaseName, idx.TableName, idx.IndexName)
+		if indexes[key] {
+			return metadatamodel.NewDomainError(fmt.Sprintf("index %s is declared more than once", key))
+		}
+		indexes[key] = true
+	}
+	views := make(map[string]bool, len(ds.MaterializedViews))
+	for _, mv := range ds.MaterializedViews {
+		if mv == nil {
+			return metadatamodel.NewDomainError("DesiredState MaterializedViews cannot contain an empty view")
+		}
+		if err := mv.Validate(); err != nil {
+			return err
+		}
+		key := ObjectKey(mv.DatabaseName, mv.ViewName)
+		if views[key] || tables[key] {
+			return metadatamodel.NewDomainError(fmt.Sprintf("materialized view %s is declared more than once or clashes with a table", key))
+		}
+		views[key] = true
+	}
+	for _, db := range ds.ManagedDatabases {
+		if db == "" {
+			return metadatamodel.NewDomainError("DesiredState ManagedDatabases cannot contain an empty name")
+		}
+	}
+	return nil
+}
+
+// ObjectKey joins the parts of an object name into the lower-cased key objects are compared by.
+// ObjectKey 将对象名称的各部分连接为用于比较的小写键。
+func ObjectKey(parts ...string) string {
+	return strings.ToLower(strings.Join(parts, "."))
+}
@@ -0,0 +1,279 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+	"github.com/turtacn/dataseap/pkg/logger"
+)
+
+// schemaChangePollInterval is how often Apply checks whether the schema change job of a table has finished.
+// schemaChangePollInterval 是Apply检查表的模式变更任务是否完成的间隔。
+const schemaChangePollInterval = 2 * time.Second
+
+type serviceImpl struct {
+	metadataSvc  metadata.Service
+	ddl          starrocks.DDLExecutor
+	history      HistoryStore
+	pollInterval time.Duration
+}
+
+// NewService creates a new schema migration service. Live table schemas are read through metadataSvc, everything
+// else through ddl. A nil history leaves migrations unrecorded.
+// NewService 创建一个新的模式迁移服务。线上表结构通过metadataSvc读取，其余信息通过ddl读取。history为nil时不记录迁移。
+func NewService(metadataSvc metadata.Service, ddl starrocks.DDLExecutor, history HistoryStore) Service {
+	return &serviceImpl{
+		metadataSvc:  metadataSvc,
+		ddl:          ddl,
+		history:      history,
+		pollInterval: schemaChangePollInterval,
+	}
+}
+
+// Plan compares the desired state with the live schema and returns the ordered changes that reconcile them.
+// Plan 比较期望状态与线上模式，返回使二者一致的有序变更。
+func (s *serviceImpl) Plan(ctx context.Context, desired *model.DesiredState) (*model.Plan, error) {
+	l := logger.L().With("method", "Plan")
+
+	if desired == nil {
+		return nil, errors.New(errors.InvalidArgument, "desired state is required")
+	}
+	if err := desired.Validate(); err != nil {
+		l.Warnw("DesiredState validation failed", "error", err)
+		return nil, errors.Wrap(err, errors.InvalidArgument, "invalid desired state")
+	}
+	live, err := s.introspect(ctx, desired)
+	if err != nil {
+		l.Errorw("Failed to introspect the live schema", "error", err)
+		return nil, err
+	}
+	plan, err := diff(desired, live)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Infow("Migration plan computed", "changes", len(plan.Changes), "destructive", len(plan.Destructive()), "warnings", len(plan.Warnings))
+	return plan, nil
+}
+
+// Apply plans the desired state and executes the plan statement by statement, recording the migration in the
+// history. StarRocks runs column and index changes as asynchronous schema change jobs and rejects a statement on a
+// table whose previous job is still running, so after each of them Apply waits for the job to finish. A cancelled
+// job fails the migration; the time spent waiting is bounded by ctx.
+// Apply 为期望状态生成计划并逐条执行语句，同时在历史中记录此次迁移。StarRocks以异步模式变更任务执行列与索引的变更，
+// 并拒绝对仍有任务在执行的表执行新的语句，因此Apply在每条此类语句之后等待其任务完成。任务被取消时迁移失败；等待的
+// 时间受ctx限制。
+func (s *serviceImpl) Apply(ctx context.Context, desired *model.DesiredState, opts ApplyOptions) (*model.Plan, *model.MigrationRecord, error) {
+	l := logger.L().With("method", "Apply", "allowDestructive", opts.AllowDestructive)
+
+	plan, err := s.Plan(ctx, desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.ExpectedChecksum != "" && !strings.EqualFold(opts.ExpectedChecksum, plan.Checksum) {
+		return plan, nil, errors.Newf(errors.InvalidArgument, "the plan changed since it was reviewed: expected checksum %s, got %s", opts.ExpectedChecksum, plan.Checksum)
+	}
+	if len(plan.Changes) == 0 {
+		l.Info("Live schema matches the desired state, nothing to apply")
+		return plan, nil, nil
+	}
+	if destructive := plan.Destructive(); len(destructive) > 0 && !opts.AllowDestructive {
+		objects := make([]string, len(destructive))
+		for i, c := range destructive {
+			objects[i] = fmt.Sprintf("%s %s", c.Kind, c.Object)
+		}
+		return plan, nil, errors.Newf(errors.InvalidArgument, "plan contains %d destructive changes that were not allowed: %s", len(destructive), strings.Join(objects, ", "))
+	}
+
+	record := &model.MigrationRecord{
+		ID:         uuid.NewString(),
+		Checksum:   plan.Checksum,
+		Status:     model.MigrationStatusApplied,
+		Statements: plan.Statements(),
+		AppliedBy:  opts.AppliedBy,
+		StartedAt:  time.Now().UTC(),
+	}
+	var applyErr error
+	for i, change := range plan.Changes {
+		err := s.ddl.ExecuteRawDDL(ctx, change.Statement)
+		if err == nil && change.Table != "" {
+			err = s.waitForSchemaChange(ctx, change.Database, change.Table)
+		}
+		if err != nil {
+			l.Errorw("Migration statement failed", "change", i+1, "kind", change.Kind, "object", change.Object, "error", err)
+			record.Status = model.MigrationStatusFailed
+			record.Error = err.Error()
+			applyErr = errors.Wrapf(err, errors.DatabaseError, "change %d of %d (%s %s) failed, the changes before it were applied", i+1, len(plan.Changes), change.Kind, change.Object)
+			break
+		}
+		record.Applied++
+	}
+	record.FinishedAt = time.Now().UTC()
+
+	if s.history != nil {
+		if err := s.history.Record(ctx, record); err != nil {
+			l.Errorw("Failed to record migration in the history", "migration", record.ID, "error", err)
+			if applyErr == nil {
+				applyErr = errors.Wrapf(err, errors.DatabaseError, "migration %s was applied but could not be recorded in the history", record.ID)
+			}
+		}
+	}
+	if applyErr == nil {
+		l.Infow("Migration applied", "migration", record.ID, "changes", record.Applied)
+	}
+	return plan, record, applyErr
+}
+
+// waitForSchemaChange polls the latest schema change job of a table until it has finished.
+// waitForSchemaChange 轮询表上最新的模式变更任务，直到其完成。
+func (s *serviceImpl) waitForSchemaChange(ctx context.Context, database, table string) error {
+	for {
+		jobs, err := s.ddl.ShowAlterColumnJobs(ctx, database, table)
+		if err != nil {
+			return errors.Wrapf(err, errors.DatabaseError, "failed to read the schema change jobs of table %s.%s", database, table)
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		switch job := jobs[0]; job.State {
+		case starrocks.AlterJobStateFinished:
+			return nil
+		case starrocks.AlterJobStateCancelled:
+			return errors.Newf(errors.DatabaseError, "schema change job %s of table %s.%s was cancelled: %s", job.JobID, database, table, job.Msg)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), errors.TimeoutError, "stopped waiting for the schema change of table %s.%s", database, table)
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// History returns the most recent migrations, newest first.
+// History 返回最近的迁移记录，按时间倒序。
+func (s *serviceImpl) History(ctx context.Context, limit int) ([]*model.MigrationRecord, error) {
+	if s.history == nil {
+		return nil, errors.New(errors.ConfigError, "migration history is not configured")
+	}
+	if limit <= 0 {
+		return nil, errors.New(errors.InvalidArgument, "history limit must be positive")
+	}
+	return s.history.List(ctx, limit)
+}
+
+// introspect reads the live state of every database the desired state refers to: its base tables and
+// materialized views, and the schema and indexes of the tables that are declared or carry declared indexes.
+// introspect 读取期望状态涉及的每个数据库的线上状态：其基础表与物化视图，以及被声明或带有声明索引的表的结构与索引。
+func (s *serviceImpl) introspect(ctx context.Context, desired *model.DesiredState) (*liveState, error) {
+	live := &liveState{
+		tables:  make(map[string]*metadatamodel.TableSchema),
+		indexes: make(map[string][]*starrocks.IndexDefinitionDef),
+		views:   make(map[string]*starrocks.MaterializedViewDef),
+		managed: make(map[string][]string),
+		exists:  make(map[string]bool),
+	}
+
+	databases := make(map[string]string)
+	managed := make(map[string]bool)
+	addDatabase := func(db string) {
+		if _, ok := databases[strings.ToLower(db)]; !ok {
+			databases[strings.ToLower(db)] = db
+		}
+	}
+	for _, db := range desired.ManagedDatabases {
+		addDatabase(db)
+		managed[strings.ToLower(db)] = true
+	}
+	for _, t := range desired.Tables {
+		addDatabase(t.DatabaseName)
+	}
+	for _, idx := range desired.Indexes {
+		addDatabase(idx.DatabaseName)
+	}
+	for _, mv := range desired.MaterializedViews {
+		addDatabase(mv.DatabaseName)
+	}
+
+	names := make([]string, 0, len(databases))
+	for key := range databases {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		db := databases[key]
+		views, err := s.ddl.ShowMaterializedViews(ctx, db)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.DatabaseError, "failed to list materialized views of database %s", db)
+		}
+		for _, mv := range views {
+			live.views[model.ObjectKey(db, mv.ViewName)] = mv
+		}
+		tables, err := s.ddl.ShowTables(ctx, db)
+		if err != nil {
+			return nil, errors.Wrapf(err, errors.DatabaseError, "failed to list tables of database %s", db)
+		}
+		for _, table := range tables {
+			tableKey := model.ObjectKey(db, table)
+			if _, isView := live.views[tableKey]; isView {
+				continue
+			}
+			live.exists[tableKey] = true
+			if managed[key] {
+				live.managed[db] = append(live.managed[db], table)
+			}
+		}
+	}
+
+	for _, t := range desired.Tables {
+		tableKey := model.ObjectKey(t.DatabaseName, t.TableName)
+		if !live.exists[tableKey] {
+			continue
+		}
+		schema, err := s.metadataSvc.GetTableSchema(ctx, t.DatabaseName, t.TableName)
+		if err != nil {
+			return nil, err
+		}
+		live.tables[tableKey] = schema
+	}
+	for _, idx := range desired.Indexes {
+		tableKey := model.ObjectKey(idx.DatabaseName, idx.TableName)
+		if _, done := live.indexes[tableKey]; done || !live.exists[tableKey] {
+			continue
+		}
+		if err := s.showIndexes(ctx, live, idx.DatabaseName, idx.TableName); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range desired.Tables {
+		tableKey := model.ObjectKey(t.DatabaseName, t.TableName)
+		if _, done := live.indexes[tableKey]; done || !live.exists[tableKey] {
+			continue
+		}
+		if err := s.showIndexes(ctx, live, t.DatabaseName, t.TableName); err != nil {
+			return nil, err
+		}
+	}
+	return live, nil
+}
+
+func (s *serviceImpl) showIndexes(ctx context.Context, live *liveState, db, table string) error {
+	indexes, err := s.ddl.ShowIndexes(ctx, db, table)
+	if err != nil {
+		return errors.Wrapf(err, errors.DatabaseError, "failed to list indexes of table %s.%s", db, table)
+	}
+	if indexes == nil {
+		indexes = []*starrocks.IndexDefinitionDef{}
+	}
+	live.indexes[model.ObjectKey(db, table)] = indexes
+	return nil
+}
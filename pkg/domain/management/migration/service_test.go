@@ -0,0 +1,146 @@
+package migration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
+	"github.com/turtacn/dataseap/pkg/domain/management/migration/model"
+)
+
+// fakeSchemaChanges serves a database holding the events table, records the statements it executes and reports
+// the states of the schema change job of each statement in turn.
+type fakeSchemaChanges struct {
+	starrocks.DDLExecutor
+	states   []string // 依次报告的任务状态，用尽后保持最后一个 Job states in turn, the last one repeats
+	executed []string
+	polled   int
+}
+
+func (f *fakeSchemaChanges) ShowTables(_ context.Context, _ string) ([]string, error) {
+	return []string{"events"}, nil
+}
+
+func (f *fakeSchemaChanges) ShowMaterializedViews(_ context.Context, _ string) ([]*starrocks.MaterializedViewDef, error) {
+	return nil, nil
+}
+
+func (f *fakeSchemaChanges) ShowIndexes(_ context.Context, _, _ string) ([]*starrocks.IndexDefinitionDef, error) {
+	return nil, nil
+}
+
+func (f *fakeSchemaChanges) GetTableSchema(_ context.Context, db, table string) (*starrocks.TableSchemaDef, error) {
+	return &starrocks.TableSchemaDef{DatabaseName: db, TableName: table, Fields: []starrocks.FieldSchemaDef{
+		{Name: "ts", Type: "DATETIME", IsKey: true},
+		{Name: "host", Type: "VARCHAR(64)", IsNullable: true},
+	}}, nil
+}
+
+func (f *fakeSchemaChanges) ShowCreateTable(_ context.Context, _, _ string) (*starrocks.TableDefinitionDef, error) {
+	return nil, errors.New(errors.NotFoundError, "no CREATE TABLE statement")
+}
+
+func (f *fakeSchemaChanges) ExecuteRawDDL(_ context.Context, statement string) error {
+	f.executed = append(f.executed, statement)
+	return nil
+}
+
+func (f *fakeSchemaChanges) ShowAlterColumnJobs(_ context.Context, _, table string) ([]*starrocks.AlterJobDef, error) {
+	state := f.states[len(f.states)-1]
+	if f.polled < len(f.states) {
+		state = f.states[f.polled]
+	}
+	f.polled++
+	return []*starrocks.AlterJobDef{{JobID: "10001", TableName: table, State: state, Msg: "column type is incompatible"}}, nil
+}
+
+func TestApplyWaitsForSchemaChanges(t *testing.T) {
+	desired := &model.DesiredState{
+		Tables: []*metadatamodel.TableSchema{{
+			DatabaseName: "db",
+			TableName:    "events",
+			Fields: []*metadatamodel.FieldSchema{
+				{Name: "ts", TypeString: "DATETIME", IsPrimaryKey: true},
+				{Name: "host", TypeString: "VARCHAR(64)", IsNullable: true},
+				{Name: "region", TypeString: "VARCHAR(8)", IsNullable: true},
+			},
+		}},
+		Indexes: []*metadatamodel.IndexDefinition{{DatabaseName: "db", TableName: "events", IndexName: "idx_host", IndexType: "BITMAP", Fields: []string{"host"}}},
+	}
+
+	tests := []struct {
+		name        string
+		states      []string
+		timeout     time.Duration
+		wantApplied int
+		wantPolled  int
+		wantCode    errors.ErrorCode
+	}{
+		{
+			name:        "each statement waits for its job",
+			states:      []string{"RUNNING", "WAITING_TXN", starrocks.AlterJobStateFinished, "PENDING", starrocks.AlterJobStateFinished},
+			wantApplied: 2,
+			wantPolled:  5,
+		},
+		{
+			name:       "cancelled job fails the migration",
+			states:     []string{"RUNNING", starrocks.AlterJobStateCancelled},
+			wantPolled: 2,
+			wantCode:   errors.DatabaseError,
+		},
+		{
+			name:     "waiting is bounded by the context",
+			states:   []string{"RUNNING"},
+			timeout:  20 * time.Millisecond,
+			wantCode: errors.DatabaseError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddl := &fakeSchemaChanges{states: tt.states}
+			s := &serviceImpl{metadataSvc: metadata.NewService(ddl), ddl: ddl, pollInterval: time.Millisecond}
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			_, record, err := s.Apply(ctx, desired, ApplyOptions{})
+			if tt.wantCode != "" {
+				if !errors.Is(err, tt.wantCode) {
+					t.Fatalf("Apply() error = %v, want %s", err, tt.wantCode)
+				}
+				if record.Status != model.MigrationStatusFailed || len(ddl.executed) != 1 {
+					t.Errorf("Apply() record status %s after executing %q, want FAILED after the first statement", record.Status, ddl.executed)
+				}
+			} else if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if record.Applied != tt.wantApplied {
+				t.Errorf("Apply() applied %d statements, want %d", record.Applied, tt.wantApplied)
+			}
+			if tt.wantPolled > 0 && ddl.polled != tt.wantPolled {
+				t.Errorf("polled %d times, want %d", ddl.polled, tt.wantPolled)
+			}
+		})
+	}
+
+	ddl := &fakeSchemaChanges{states: []string{starrocks.AlterJobStateFinished}}
+	s := &serviceImpl{metadataSvc: metadata.NewService(ddl), ddl: ddl, pollInterval: time.Millisecond}
+	if _, _, err := s.Apply(context.Background(), desired, ApplyOptions{}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []string{
+		"ALTER TABLE `db`.`events` ADD COLUMN (`region` VARCHAR(8) NULL)",
+		"ALTER TABLE `db`.`events` ADD INDEX `idx_host` (`host`) USING BITMAP",
+	}
+	if !reflect.DeepEqual(ddl.executed, want) {
+		t.Errorf("executed %q, want %q", ddl.executed, want)
+	}
+}
@@ -0,0 +1,226 @@
+package query
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
+)
+
+// defaultPolicyName names the policy of callers without a policy of their own in rejections.
+// defaultPolicyName 是拒绝信息中没有独立策略的调用方所用策略的名称。
+const defaultPolicyName = "default"
+
+// guard restricts the SQL statements callers may execute to those their policies allow.
+// guard 将调用方可执行的SQL语句限制为其策略允许的语句。
+type guard struct {
+	defaultDatabase string
+	defaultPolicy   *policy
+	callers         map[string]*policy
+}
+
+// policy is a compiled config.QueryPolicy. Statement types are upper-case; catalogs, databases, table patterns and
+// table functions lower-case.
+// policy 是编译后的config.QueryPolicy。语句类型为大写，目录、数据库、表模式与表函数为小写。
+type policy struct {
+	name                string
+	allowStatements     map[string]bool
+	denyStatements      map[string]bool
+	allowCatalogs       map[string]bool
+	allowDatabases      map[string]bool
+	denyDatabases       map[string]bool
+	allowTables         []string
+	denyTables          []string
+	allowTableFunctions map[string]bool
+}
+
+// newGuard compiles the policies of cfg, or returns nil when the guardrails are disabled. Unqualified tables are
+// taken to be in defaultDatabase.
+// newGuard 编译cfg中的策略，防护被禁用时返回nil。未限定数据库的表视为位于defaultDatabase中。
+func newGuard(cfg config.QueryGuardConfig, defaultDatabase string) *guard {
+	if !cfg.Enabled {
+		return nil
+	}
+	g := &guard{
+		defaultDatabase: defaultDatabase,
+		defaultPolicy:   compilePolicy(defaultPolicyName, cfg.Default),
+		callers:         make(map[string]*policy, len(cfg.Callers)),
+	}
+	for caller, p := range cfg.Callers {
+		g.callers[caller] = compilePolicy(caller, inheritPolicy(p, cfg.Default))
+	}
+	return g
+}
+
+// inheritPolicy fills the lists p leaves unset from def.
+// inheritPolicy 用def中的列表补全p中未设置的列表。
+func inheritPolicy(p, def config.QueryPolicy) config.QueryPolicy {
+	inherit := func(list, defList []string) []string {
+		if list == nil {
+			return defList
+		}
+		return list
+	}
+	return config.QueryPolicy{
+		AllowStatements:     inherit(p.AllowStatements, def.AllowStatements),
+		DenyStatements:      inherit(p.DenyStatements, def.DenyStatements),
+		AllowDatabases:      inherit(p.AllowDatabases, def.AllowDatabases),
+		DenyDatabases:       inherit(p.DenyDatabases, def.DenyDatabases),
+		AllowTables:         inherit(p.AllowTables, def.AllowTables),
+		DenyTables:          inherit(p.DenyTables, def.DenyTables),
+		AllowCatalogs:       inherit(p.AllowCatalogs, def.AllowCatalogs),
+		AllowTableFunctions: inherit(p.AllowTableFunctions, def.AllowTableFunctions),
+	}
+}
+
+func compilePolicy(name string, p config.QueryPolicy) *policy {
+	set := func(items []string, normalize func(string) string) map[string]bool {
+		m := make(map[string]bool, len(items))
+		for _, item := range items {
+			m[normalize(strings.TrimSpace(item))] = true
+		}
+		return m
+	}
+	patterns := func(items []string) []string {
+		lower := make([]string, len(items))
+		for i, item := range items {
+			lower[i] = strings.ToLower(strings.TrimSpace(item))
+		}
+		return lower
+	}
+	return &policy{
+		name:                name,
+		allowStatements:     set(p.AllowStatements, strings.ToUpper),
+		denyStatements:      set(p.DenyStatements, strings.ToUpper),
+		allowCatalogs:       set(p.AllowCatalogs, strings.ToLower),
+		allowDatabases:      set(p.AllowDatabases, strings.ToLower),
+		denyDatabases:       set(p.DenyDatabases, strings.ToLower),
+		allowTables:         patterns(p.AllowTables),
+		denyTables:          patterns(p.DenyTables),
+		allowTableFunctions: set(p.AllowTableFunctions, strings.ToLower),
+	}
+}
+
+// check rejects a script unless it is a single statement the policy of the caller allows. Unqualified tables are
+// taken to be in database, or in the default database when it is empty.
+// check 拒绝调用方策略不允许的脚本，脚本必须是单条语句。未限定数据库的表视为位于database中，database为空时视为位于默认数据库中。
+func (g *guard) check(ctx context.Context, sql, database string) error {
+	statements, err := sqlparse.Classify(sql)
+	if err != nil {
+		return errors.Wrap(err, errors.InvalidArgument, "failed to parse SQL statement")
+	}
+	if len(statements) > 1 {
+		return errors.Newf(errors.InvalidArgument, "the query API executes a single statement, got %d", len(statements))
+	}
+	if database == "" {
+		database = g.defaultDatabase
+	}
+	return g.policyOf(ctx).check(statements[0], strings.ToLower(database))
+}
+
+// policyOf returns the policy of the caller of a request, identified by the user in its context.
+// policyOf 返回请求调用方的策略，调用方由其上下文中的用户标识。
+func (g *guard) policyOf(ctx context.Context) *policy {
+	if caller, ok := ctx.Value(constants.ContextKeyUser).(string); ok {
+		if p, ok := g.callers[caller]; ok {
+			return p
+		}
+	}
+	return g.defaultPolicy
+}
+
+func (p *policy) check(s *sqlparse.Statement, database string) error {
+	for e := s; e != nil; e = e.Explained {
+		if p.denyStatements[e.Type] {
+			return errors.Newf(errors.PermissionDenied, "query policy %q denies %s statements", p.name, e.Type)
+		}
+		if len(p.allowStatements) > 0 && !p.allowStatements[e.Type] {
+			return errors.Newf(errors.PermissionDenied, "query policy %q does not allow %s statements", p.name, e.Type)
+		}
+	}
+
+	for _, catalog := range s.Catalogs {
+		if err := p.checkCatalog(strings.ToLower(catalog)); err != nil {
+			return err
+		}
+	}
+	for _, db := range s.Databases {
+		if err := p.checkDatabase(strings.ToLower(db)); err != nil {
+			return err
+		}
+	}
+	for _, fn := range s.TableFunctions {
+		if fn = strings.ToLower(fn); !p.allowTableFunctions[fn] {
+			return errors.Newf(errors.PermissionDenied, "query policy %q does not allow table function %s", p.name, fn)
+		}
+	}
+	for _, t := range s.Tables {
+		if err := p.checkCatalog(strings.ToLower(t.Catalog)); err != nil {
+			return err
+		}
+		db := strings.ToLower(t.Database)
+		if db == "" {
+			db = database
+		}
+		if err := p.checkDatabase(db); err != nil {
+			return err
+		}
+		table := strings.ToLower(t.Table)
+		if matchTable(p.denyTables, db, table) {
+			return errors.Newf(errors.PermissionDenied, "query policy %q denies table %s", p.name, qualify(db, table))
+		}
+		if len(p.allowTables) > 0 && !matchTable(p.allowTables, db, table) {
+			return errors.Newf(errors.PermissionDenied, "query policy %q does not allow table %s", p.name, qualify(db, table))
+		}
+	}
+	return nil
+}
+
+// checkCatalog rejects a catalog other than the default one unless the policy allows it. An empty catalog is the
+// default catalog.
+// checkCatalog 拒绝策略未允许的非默认目录。空目录即默认目录。
+func (p *policy) checkCatalog(catalog string) error {
+	if catalog == "" || catalog == constants.QueryDefaultCatalog || p.allowCatalogs[catalog] {
+		return nil
+	}
+	return errors.Newf(errors.PermissionDenied, "query policy %q does not allow catalog %s", p.name, catalog)
+}
+
+func (p *policy) checkDatabase(db string) error {
+	switch {
+	case db == "" && len(p.allowDatabases) > 0:
+		return errors.Newf(errors.PermissionDenied, "query policy %q does not allow tables without a database", p.name)
+	case p.denyDatabases[db]:
+		return errors.Newf(errors.PermissionDenied, "query policy %q denies database %s", p.name, db)
+	case len(p.allowDatabases) > 0 && !p.allowDatabases[db]:
+		return errors.Newf(errors.PermissionDenied, "query policy %q does not allow database %s", p.name, db)
+	}
+	return nil
+}
+
+// matchTable reports whether a table matches one of patterns. A pattern without a database matches the table in
+// any database.
+// matchTable 报告表是否匹配patterns中的某个模式。不含数据库的模式匹配任意数据库中的该表。
+func matchTable(patterns []string, db, table string) bool {
+	for _, pattern := range patterns {
+		name := table
+		if strings.Contains(pattern, ".") {
+			name = db + "." + table
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func qualify(db, table string) string {
+	if db == "" {
+		return table
+	}
+	return db + "." + table
+}
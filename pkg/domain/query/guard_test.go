@@ -0,0 +1,85 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+)
+
+func TestGuardCheck(t *testing.T) {
+	cfg := config.QueryGuardConfig{
+		Enabled: true,
+		Default: config.QueryPolicy{
+			AllowStatements:     []string{"select", "with", "explain"},
+			DenyDatabases:       []string{"mysql"},
+			DenyTables:          []string{"*.secrets", "audit.*"},
+			AllowTableFunctions: []string{"UNNEST"},
+		},
+		Callers: map[string]config.QueryPolicy{
+			"etl":       {AllowStatements: []string{"SELECT", "INSERT"}, AllowDatabases: []string{"logs"}},
+			"dashboard": {AllowTables: []string{"logs.events_*"}},
+			"lake":      {AllowCatalogs: []string{"hive_prod"}, AllowTableFunctions: []string{"files"}},
+		},
+	}
+	g := newGuard(cfg, "logs")
+
+	tests := []struct {
+		name     string
+		caller   string
+		sql      string
+		database string
+		wantErr  bool
+		wantCode errors.ErrorCode
+	}{
+		{name: "default allows a select", sql: "SELECT * FROM events"},
+		{name: "default allows a cte", sql: "WITH x AS (SELECT * FROM events) SELECT * FROM x"},
+		{name: "default denies an insert", sql: "INSERT INTO events SELECT * FROM staging", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "explain is checked as its statement", sql: "EXPLAIN DELETE FROM events", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "denied database", sql: "SELECT * FROM mysql.user", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "denied database of the request", sql: "SELECT * FROM user", database: "MySQL", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "denied table in any database", sql: "SELECT * FROM app.secrets", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "denied table in a subquery", sql: "SELECT * FROM events WHERE id IN (SELECT id FROM audit.trail)", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "several statements", sql: "SELECT 1; SELECT 2", wantErr: true, wantCode: errors.InvalidArgument},
+		{name: "optimizer hint", sql: "SELECT /*+ SET_VAR(query_timeout=100000) */ * FROM events", wantErr: true, wantCode: errors.InvalidArgument},
+		{name: "etl may insert into its database", caller: "etl", sql: "INSERT INTO events SELECT * FROM staging"},
+		{name: "etl inherits the denied tables", caller: "etl", sql: "SELECT * FROM logs.secrets", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "etl may not read other databases", caller: "etl", sql: "SELECT * FROM app.users", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "dashboard allowed table", caller: "dashboard", sql: "SELECT * FROM events_2024"},
+		{name: "dashboard other table", caller: "dashboard", sql: "SELECT * FROM alerts", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "dashboard allowed table in another database", caller: "dashboard", sql: "SELECT * FROM app.events_2024", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "default catalog", sql: "SELECT * FROM default_catalog.logs.events"},
+		{name: "external catalog", sql: "SELECT * FROM hive_prod.sales.orders", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "use an external catalog", sql: "USE hive_prod.sales", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "explain reading an external catalog", sql: "EXPLAIN SELECT * FROM hive_prod.sales.orders", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "allowed table function", sql: "SELECT * FROM events, unnest(tags) AS t(tag)"},
+		{name: "files table function", sql: "SELECT * FROM FILES('path' = 's3://bucket/x.parquet', 'format' = 'parquet')", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "table function inside TABLE", sql: "SELECT * FROM TABLE(files('path' = 's3://bucket/x.csv'))", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "lake may read its catalog", caller: "lake", sql: "SELECT * FROM hive_prod.sales.orders"},
+		{name: "lake inherits the denied tables in its catalog", caller: "lake", sql: "SELECT * FROM hive_prod.app.secrets", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "lake may not read other catalogs", caller: "lake", sql: "SELECT * FROM iceberg.sales.orders", wantErr: true, wantCode: errors.PermissionDenied},
+		{name: "lake may read files", caller: "lake", sql: "SELECT * FROM FILES('path' = 's3://bucket/x.parquet', 'format' = 'parquet')"},
+		{name: "unknown caller gets the default policy", caller: "intruder", sql: "DROP TABLE events", wantErr: true, wantCode: errors.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.caller != "" {
+				ctx = context.WithValue(ctx, constants.ContextKeyUser, tt.caller)
+			}
+			err := g.check(ctx, tt.sql, tt.database)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, tt.wantCode) {
+				t.Errorf("check() error code = %s, want %s", errors.GetCode(err), tt.wantCode)
+			}
+		})
+	}
+
+	if newGuard(config.QueryGuardConfig{}, "logs") != nil {
+		t.Error("newGuard() of a disabled config is not nil")
+	}
+}
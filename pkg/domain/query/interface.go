@@ -13,6 +13,11 @@ type Service interface {
 	// ExecuteSQL 执行给定的SQL查询并返回结果。
 	ExecuteSQL(ctx context.Context, req *model.SQLQueryRequest) (*model.SQLQueryResult, error)
 
+	// StreamSQL executes a given SQL query and hands its rows to sink as they arrive, holding one row at a time.
+	// It stops at the first error sink returns.
+	// StreamSQL 执行给定的SQL查询，并在数据行到达时将其交给sink，每次只保存一行。sink返回错误时立即停止。
+	StreamSQL(ctx context.Context, req *model.SQLQueryRequest, sink RowSink) (*model.SQLStreamSummary, error)
+
 	// SearchFullText performs a full-text search based on the provided request.
 	// SearchFullText 根据提供的请求执行全文检索。
 	SearchFullText(ctx context.Context, req *model.FullTextSearchRequest) (*model.FullTextSearchResult, error)
@@ -20,3 +25,10 @@ type Service interface {
 	// TODO: Add other query capabilities as needed, e.g.,
 	// GetAggregatedData(ctx context.Context, aggRequest *model.AggregationRequest) (*model.AggregationResult, error)
 }
+
+// RowSink receives the results of a streamed SQL query: its columns first, then its rows one by one.
+// RowSink 接收流式SQL查询的结果：先接收列名，再逐行接收数据。
+type RowSink interface {
+	Columns(columns []string) error
+	Row(row map[string]interface{}) error
+}
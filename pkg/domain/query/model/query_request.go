@@ -1,9 +1,16 @@
 package model
 
 import (
+	"fmt"
+	"regexp"
+
 	commontypes "github.com/turtacn/dataseap/pkg/common/types"
 )
 
+// objectName matches the names of the databases and workload groups a query may name.
+// objectName 匹配查询可指定的数据库与工作负载组名称。
+var objectName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]{0,255}$`)
+
 // SQLQueryRequest represents a request to execute an SQL query.
 // SQLQueryRequest 代表执行SQL查询的请求。
 type SQLQueryRequest struct {
@@ -11,9 +18,10 @@ type SQLQueryRequest struct {
 	// SQL 是原始的SQL查询字符串。
 	SQL string `json:"sql"`
 
-	// Params (可选) SQL查询的参数，用于防止SQL注入，键为参数名，值为参数值。
+	// Params (可选) SQL查询的参数，用于防止SQL注入，键为参数名，值为参数值。":name" 占位符按名称绑定，第n个 "?" 占位符绑定键 "n"（从1开始）。
 	// Params (Optional) Parameters for the SQL query to prevent SQL injection.
 	// The key is the parameter name, and the value is the parameter value.
+	// ":name" placeholders bind by name; the n-th "?" placeholder binds key "n" (1-based).
 	Params map[string]interface{} `json:"params,omitempty"`
 
 	// Pagination (可选) 分页参数。
@@ -31,6 +39,12 @@ type SQLQueryRequest struct {
 	// Database (可选) 指定查询的数据库。如果为空，则使用连接的默认数据库。
 	// Database (Optional) Specifies the database for the query. If empty, uses the connection's default database.
 	Database string `json:"database,omitempty"`
+
+	// SortKey (可选) 游标分页所用的结果列，可带 " DESC" 后缀，应能唯一标识行。设置后按键集而非OFFSET读取分页，深分页无需跳过之前的行。
+	// SortKey (Optional) Result columns pages are read by keyset over, optionally suffixed " DESC"; they should
+	// identify rows uniquely. With it, pages are read by keyset rather than OFFSET, so deep pages do not skip over
+	// the rows before them.
+	SortKey []string `json:"sortKey,omitempty"`
 }
 
 // FullTextSearchRequest represents a request for a full-text search operation.
@@ -81,7 +95,13 @@ func (req *SQLQueryRequest) Validate() error {
 	if req.SQL == "" {
 		return NewDomainError("SQL query string cannot be empty")
 	}
-	// Further validation for pagination, timeout, etc. can be added here.
+	if req.Database != "" && !objectName.MatchString(req.Database) {
+		return NewDomainError(fmt.Sprintf("invalid database name %q", req.Database))
+	}
+	if req.WorkloadGroup != "" && !objectName.MatchString(req.WorkloadGroup) {
+		return NewDomainError(fmt.Sprintf("invalid workload group name %q", req.WorkloadGroup))
+	}
+	// Further validation for pagination, etc. can be added here.
 	return nil
 }
 
@@ -45,6 +45,26 @@ type SQLQueryResult struct {
 	ExecutionTime time.Duration `json:"executionTime,omitempty"`
 }
 
+// SQLStreamSummary summarizes a streamed SQL query once all its rows were sent.
+// SQLStreamSummary 在流式SQL查询的所有数据行发送完毕后对其进行汇总。
+type SQLStreamSummary struct {
+	// Columns 列名列表。
+	// Columns List of column names.
+	Columns []string `json:"columns,omitempty"`
+
+	// RowCount 发送的数据行数。
+	// RowCount Number of rows sent.
+	RowCount int64 `json:"rowCount"`
+
+	// Stats (可选) 查询执行的统计信息。
+	// Stats (Optional) Statistics about the query execution.
+	Stats *QueryStats `json:"stats,omitempty"`
+
+	// ExecutionTime (可选) 查询在服务端的总执行时间，包括发送数据行的时间。
+	// ExecutionTime (Optional) Total execution time of the query on the server side, including sending its rows.
+	ExecutionTime time.Duration `json:"executionTime,omitempty"`
+}
+
 // SearchHit represents a single item found in a full-text search.
 // SearchHit 代表全文检索中找到的单个条目。
 type SearchHit struct {
@@ -0,0 +1,82 @@
+package query
+
+import (
+	"context"
+	"crypto/rand"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/query/model"
+)
+
+// Recorder observes the SQL queries and full-text searches the service executed successfully, e.g., to learn
+// frequent query patterns. It is called on the request path and must return quickly.
+// Recorder 观察服务成功执行的SQL查询与全文检索，例如用于学习高频查询模式。它在请求路径上被调用，必须快速返回。
+type Recorder interface {
+	RecordQuery(ctx context.Context, req *model.SQLQueryRequest, result *model.SQLQueryResult)
+	RecordSearch(ctx context.Context, req *model.FullTextSearchRequest, result *model.FullTextSearchResult)
+}
+
+// options holds the optional collaborators of the query service.
+// options 保存查询服务的可选依赖。
+type options struct {
+	recorder     Recorder
+	guard        *guard
+	pageTokenKey []byte
+}
+
+// Option configures optional behaviour of the query service.
+// Option 配置查询服务的可选行为。
+type Option func(*options)
+
+// WithRecorder reports every successfully executed SQL query, with its statistics, and full-text search to
+// recorder.
+// WithRecorder 将每个成功执行的SQL查询及其统计信息以及每次全文检索报告给recorder。
+func WithRecorder(recorder Recorder) Option {
+	return func(o *options) {
+		o.recorder = recorder
+	}
+}
+
+// WithGuard restricts the SQL statements callers may execute to those the policies of cfg allow. Unqualified tables
+// are taken to be in defaultDatabase. Without it, only the read-only statement types of
+// constants.QueryDefaultAllowedStatements and the table functions of constants.QueryDefaultAllowedTableFunctions are
+// allowed.
+// WithGuard 将调用方可执行的SQL语句限制为cfg中策略允许的语句。未限定数据库的表视为位于defaultDatabase中。未设置时，
+// 仅允许constants.QueryDefaultAllowedStatements中的只读语句类型与constants.QueryDefaultAllowedTableFunctions中的表函数。
+func WithGuard(cfg config.QueryGuardConfig, defaultDatabase string) Option {
+	return func(o *options) {
+		o.guard = newGuard(cfg, defaultDatabase)
+	}
+}
+
+// WithPageTokenSecret signs the page tokens of paginated SQL queries with secret, so that tokens issued by one
+// instance are accepted by the others. Without it, each service signs with a random key of its own.
+// WithPageTokenSecret 使用secret签名分页SQL查询的分页令牌，使一个实例签发的令牌可被其他实例接受。未设置时，每个服务
+// 使用自己的随机密钥签名。
+func WithPageTokenSecret(secret string) Option {
+	return func(o *options) {
+		if secret != "" {
+			o.pageTokenKey = []byte(secret)
+		}
+	}
+}
+
+func applyOptions(opts []Option) *options {
+	o := &options{guard: newGuard(config.QueryGuardConfig{
+		Enabled: true,
+		Default: config.QueryPolicy{
+			AllowStatements:     constants.QueryDefaultAllowedStatements,
+			AllowTableFunctions: constants.QueryDefaultAllowedTableFunctions,
+		},
+	}, ""), pageTokenKey: make([]byte, constants.QueryPageTokenKeyBytes)}
+	if _, err := rand.Read(o.pageTokenKey); err != nil {
+		panic("query: failed to generate the page token key: " + err.Error())
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
@@ -0,0 +1,235 @@
+package query
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
+)
+
+// pageToken is the decoded form of the opaque next page tokens of paginated SQL queries. A token is its JSON
+// encoding followed by an HMAC of it, so that clients cannot forge the offsets and sort key values it carries.
+// pageToken 是分页SQL查询不透明的下一页令牌解码后的形式。令牌由其JSON编码及其HMAC组成，客户端无法伪造其中的
+// 偏移量与排序键值。
+type pageToken struct {
+	Query  string        `json:"q"`           // 查询、数据库、排序键与每页大小的指纹 Fingerprint of the query, database, sort key and page size
+	Page   int           `json:"p"`           // 令牌读取的页码 Page the token reads
+	Offset int           `json:"o,omitempty"` // OFFSET分页的偏移量 Offset of OFFSET pagination
+	After  []interface{} `json:"a,omitempty"` // 上一页最后一行的排序键值 Sort key values of the last row of the previous page
+	Total  int64         `json:"t"`           // 读取第一页时统计的总行数 Total rows counted when the first page was read
+}
+
+// sqlPage is the page of a SQL query a request asks for. Pages are read by OFFSET, or by keyset when the request
+// has a sort key.
+// sqlPage 是请求所要读取的SQL查询分页。分页按OFFSET读取，请求带有排序键时按键集读取。
+type sqlPage struct {
+	query    string // 查询、数据库、排序键与每页大小的指纹 Fingerprint of the query, database, sort key and page size
+	key      []byte // 签名令牌的密钥 Key signing the tokens
+	keys     []sqlparse.SortKey
+	page     int
+	pageSize int
+	offset   int
+	after    []interface{}
+	total    int64
+	counted  bool // total是否已由令牌给出 Whether total is known from the token
+}
+
+// newSQLPage resolves the page of sql, the statement with its parameters bound, a request asks for, from its page
+// token or, without one, its page number. Tokens are signed with key and only accepted for the statement, database,
+// sort key and page size they were issued for.
+// newSQLPage 根据请求的页令牌（或在没有令牌时根据页码）确定其所要读取的sql（已绑定参数的语句）分页。令牌使用key
+// 签名，且只对其签发时的语句、数据库、排序键与每页大小有效。
+func newSQLPage(sql, database string, sortKey []string, pagination *commontypes.PaginationRequest, key []byte) (*sqlPage, error) {
+	if pagination == nil {
+		pagination = &commontypes.PaginationRequest{}
+	}
+	p := &sqlPage{key: key, page: pagination.Page, pageSize: pagination.PageSize}
+	if p.page <= 0 {
+		p.page = 1
+	}
+	if p.pageSize <= 0 {
+		p.pageSize = constants.DefaultPageSize
+	}
+	if p.pageSize > constants.QueryMaxPageSize {
+		return nil, errors.Newf(errors.InvalidArgument, "page size %d exceeds the maximum of %d", p.pageSize, constants.QueryMaxPageSize)
+	}
+	for _, k := range sortKey {
+		fields := strings.Fields(k)
+		switch {
+		case len(fields) == 1:
+			p.keys = append(p.keys, sqlparse.SortKey{Column: fields[0]})
+		case len(fields) == 2 && (strings.EqualFold(fields[1], "ASC") || strings.EqualFold(fields[1], "DESC")):
+			p.keys = append(p.keys, sqlparse.SortKey{Column: fields[0], Descending: strings.EqualFold(fields[1], "DESC")})
+		default:
+			return nil, errors.Newf(errors.InvalidArgument, "invalid sort key column %q, expected a column optionally followed by ASC or DESC", k)
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(sql + "\x00" + database))
+	for _, k := range p.keys {
+		h.Write([]byte("\x00" + k.Column + "\x00" + strconv.FormatBool(k.Descending)))
+	}
+	h.Write([]byte("\x00" + strconv.Itoa(p.pageSize)))
+	p.query = hex.EncodeToString(h.Sum(nil))[:16]
+
+	if pagination.PageToken == "" {
+		p.offset = (p.page - 1) * p.pageSize
+		return p, nil
+	}
+	token, err := decodePageToken(pagination.PageToken, p.key)
+	if err != nil {
+		return nil, err
+	}
+	if token.Query != p.query {
+		return nil, errors.New(errors.InvalidArgument, "page token was issued for a different query, sort key or page size")
+	}
+	p.page, p.offset, p.after, p.total, p.counted = token.Page, token.Offset, token.After, token.Total, true
+	return p, nil
+}
+
+// statement returns the query reading the page, with one row more than the page holds to learn whether another
+// page follows.
+// statement 返回读取该页的查询，比每页行数多读取一行以判断是否还有下一页。
+func (p *sqlPage) statement(sql string) (string, error) {
+	if len(p.keys) > 0 {
+		return sqlparse.Keyset(sql, p.keys, p.after, p.pageSize+1, p.offset)
+	}
+	return sqlparse.Limit(sql, p.pageSize+1, p.offset)
+}
+
+// needsCount reports whether the total rows must be counted, given the rows read for the page. They need not be
+// when a token carries them or the first page holds them all.
+// needsCount 根据为该页读取的行判断是否需要统计总行数。令牌已携带总行数或第一页已包含全部行时无需统计。
+func (p *sqlPage) needsCount(rows int) bool {
+	if p.counted {
+		return false
+	}
+	if p.offset == 0 && len(p.after) == 0 && rows <= p.pageSize {
+		p.total, p.counted = int64(rows), true
+		return false
+	}
+	return true
+}
+
+// setTotal records the total rows from the result of the count query.
+// setTotal 从统计查询的结果中记录总行数。
+func (p *sqlPage) setTotal(rows [][]interface{}) error {
+	if len(rows) != 1 || len(rows[0]) != 1 {
+		return errors.New(errors.DatabaseError, "unexpected result of the row count query")
+	}
+	switch v := rows[0][0].(type) {
+	case float64:
+		p.total = int64(v)
+	case json.Number:
+		total, err := v.Int64()
+		if err != nil {
+			return errors.Wrap(err, errors.DatabaseError, "unexpected result of the row count query")
+		}
+		p.total = total
+	case string:
+		total, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, errors.DatabaseError, "unexpected result of the row count query")
+		}
+		p.total = total
+	default:
+		return errors.Newf(errors.DatabaseError, "unexpected row count of type %T", v)
+	}
+	p.counted = true
+	return nil
+}
+
+// finish trims the rows read for the page to the page and describes it, with a token for the next page when one
+// follows.
+// finish 将为该页读取的行裁剪为一页并返回分页信息，存在下一页时附带下一页令牌。
+func (p *sqlPage) finish(columns []string, rows [][]interface{}) ([][]interface{}, *commontypes.PaginationResponse, error) {
+	resp := &commontypes.PaginationResponse{Page: p.page, PageSize: p.pageSize, Total: p.total}
+	if len(rows) <= p.pageSize {
+		return rows, resp, nil
+	}
+	rows = rows[:p.pageSize]
+
+	next := pageToken{Query: p.query, Page: p.page + 1, Total: p.total}
+	if len(p.keys) == 0 {
+		next.Offset = p.offset + p.pageSize
+	} else {
+		last := rows[len(rows)-1]
+		for _, k := range p.keys {
+			i := indexOfColumn(columns, k.Column)
+			if i < 0 || i >= len(last) {
+				return nil, nil, errors.Newf(errors.InvalidArgument, "sort key column %s is not a column of the result", k.Column)
+			}
+			if last[i] == nil {
+				return nil, nil, errors.Newf(errors.InvalidArgument, "sort key column %s is NULL; keyset pagination needs non-NULL keys", k.Column)
+			}
+			next.After = append(next.After, last[i])
+		}
+	}
+	token, err := json.Marshal(next)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.SerializationError, "failed to encode the next page token")
+	}
+	resp.NextPageToken = base64.RawURLEncoding.EncodeToString(token) + "." + base64.RawURLEncoding.EncodeToString(signPageToken(token, p.key))
+	return rows, resp, nil
+}
+
+// signPageToken returns the HMAC-SHA256 of the JSON encoding of a token.
+// signPageToken 返回令牌JSON编码的HMAC-SHA256。
+func signPageToken(token, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(token)
+	return mac.Sum(nil)
+}
+
+// decodePageToken verifies the signature of a token and decodes it.
+// decodePageToken 校验令牌的签名并对其解码。
+func decodePageToken(s string, key []byte) (*pageToken, error) {
+	encoded, encodedSignature, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, errors.New(errors.InvalidArgument, "invalid page token")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "invalid page token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "invalid page token")
+	}
+	if !hmac.Equal(signature, signPageToken(raw, key)) {
+		return nil, errors.New(errors.InvalidArgument, "page token signature does not match")
+	}
+	token := &pageToken{}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	if err := dec.Decode(token); err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "invalid page token")
+	}
+	if token.Page < 2 || token.Offset < 0 {
+		return nil, errors.New(errors.InvalidArgument, "invalid page token")
+	}
+	return token, nil
+}
+
+func indexOfColumn(columns []string, name string) int {
+	for i, c := range columns {
+		if c == name {
+			return i
+		}
+	}
+	for i, c := range columns {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,184 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+)
+
+// readPage resolves a page and finishes it with rows, returning the rows of the page and the next page token.
+func readPage(t *testing.T, sql string, sortKey []string, pagination *commontypes.PaginationRequest, columns []string, rows [][]interface{}) ([][]interface{}, string) {
+	t.Helper()
+	p, err := newSQLPage(sql, "logs", sortKey, pagination, []byte("secret"))
+	if err != nil {
+		t.Fatalf("newSQLPage() error = %v", err)
+	}
+	page, resp, err := p.finish(columns, rows)
+	if err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+	return page, resp.NextPageToken
+}
+
+func TestSQLPageTokens(t *testing.T) {
+	const sql = "SELECT id, ts FROM events"
+	columns := []string{"id", "ts"}
+	rows := [][]interface{}{{float64(1), "a"}, {float64(2), "b"}, {float64(3), "c"}}
+
+	t.Run("offset", func(t *testing.T) {
+		page, token := readPage(t, sql, nil, &commontypes.PaginationRequest{PageSize: 2}, columns, rows)
+		if len(page) != 2 || token == "" {
+			t.Fatalf("finish() = %d rows, token %q, want 2 rows and a token", len(page), token)
+		}
+		p, err := newSQLPage(sql, "logs", nil, &commontypes.PaginationRequest{PageSize: 2, PageToken: token}, []byte("secret"))
+		if err != nil {
+			t.Fatalf("newSQLPage() with the token error = %v", err)
+		}
+		if p.page != 2 || p.offset != 2 || !p.counted {
+			t.Errorf("newSQLPage() = page %d offset %d counted %v, want page 2 offset 2 counted", p.page, p.offset, p.counted)
+		}
+		if statement, _ := p.statement(sql); statement != sql+" LIMIT 3 OFFSET 2" {
+			t.Errorf("statement() = %q", statement)
+		}
+	})
+
+	t.Run("keyset", func(t *testing.T) {
+		_, token := readPage(t, sql, []string{"ts desc", "ID"}, &commontypes.PaginationRequest{PageSize: 2}, columns, rows)
+		p, err := newSQLPage(sql, "logs", []string{"ts desc", "ID"}, &commontypes.PaginationRequest{PageSize: 2, PageToken: token}, []byte("secret"))
+		if err != nil {
+			t.Fatalf("newSQLPage() with the token error = %v", err)
+		}
+		if want := []interface{}{"b", json.Number("2")}; !reflect.DeepEqual(p.after, want) {
+			t.Errorf("newSQLPage() after = %#v, want %#v", p.after, want)
+		}
+		if statement, _ := p.statement(sql); !strings.Contains(statement, "WHERE (`ts` < 'b') OR (`ts` = 'b' AND `ID` > 2)") {
+			t.Errorf("statement() = %q", statement)
+		}
+	})
+
+	t.Run("keyset keys beyond 2^53", func(t *testing.T) {
+		big := [][]interface{}{{json.Number("9007199254740993"), "a"}, {json.Number("9007199254740995"), "b"}}
+		_, token := readPage(t, sql, []string{"id"}, &commontypes.PaginationRequest{PageSize: 1}, columns, big)
+		p, err := newSQLPage(sql, "logs", []string{"id"}, &commontypes.PaginationRequest{PageSize: 1, PageToken: token}, []byte("secret"))
+		if err != nil {
+			t.Fatalf("newSQLPage() with the token error = %v", err)
+		}
+		if statement, _ := p.statement(sql); !strings.Contains(statement, "WHERE (`id` > 9007199254740993)") {
+			t.Errorf("statement() = %q", statement)
+		}
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		if _, token := readPage(t, sql, nil, &commontypes.PaginationRequest{PageSize: 3}, columns, rows); token != "" {
+			t.Errorf("finish() token = %q on the last page, want none", token)
+		}
+	})
+
+	t.Run("rejected tokens", func(t *testing.T) {
+		_, token := readPage(t, sql, nil, &commontypes.PaginationRequest{PageSize: 2}, columns, rows)
+		payload, signature, _ := strings.Cut(token, ".")
+		forged, _ := json.Marshal(map[string]interface{}{"q": "x", "p": 2, "o": 1000000, "t": 1})
+		raw, _ := base64.RawURLEncoding.DecodeString(payload)
+		tampered := strings.Replace(string(raw), `"o":2`, `"o":200`, 1)
+
+		tests := []struct {
+			name     string
+			sql      string
+			database string
+			sortKey  []string
+			pageSize int
+			token    string
+			key      string
+		}{
+			{name: "other statement", sql: sql + " WHERE id > 0", token: token},
+			{name: "other database", database: "audit", token: token},
+			{name: "other sort key", sortKey: []string{"id"}, token: token},
+			{name: "other page size", pageSize: 3, token: token},
+			{name: "other key", key: "another secret", token: token},
+			{name: "tampered payload", token: base64.RawURLEncoding.EncodeToString([]byte(tampered)) + "." + signature},
+			{name: "forged token", token: base64.RawURLEncoding.EncodeToString(forged) + "." + signature},
+			{name: "unsigned token", token: payload},
+			{name: "garbage", token: "!!!.???"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if tt.sql == "" {
+					tt.sql = sql
+				}
+				if tt.database == "" {
+					tt.database = "logs"
+				}
+				if tt.pageSize == 0 {
+					tt.pageSize = 2
+				}
+				if tt.key == "" {
+					tt.key = "secret"
+				}
+				_, err := newSQLPage(tt.sql, tt.database, tt.sortKey, &commontypes.PaginationRequest{PageSize: tt.pageSize, PageToken: tt.token}, []byte(tt.key))
+				if !errors.Is(err, errors.InvalidArgument) {
+					t.Errorf("newSQLPage() error = %v, want InvalidArgument", err)
+				}
+			})
+		}
+	})
+}
+
+func TestNewSQLPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		sortKey    []string
+		pagination *commontypes.PaginationRequest
+		wantOffset int
+		wantErr    bool
+	}{
+		{name: "defaults", wantOffset: 0},
+		{name: "page number", pagination: &commontypes.PaginationRequest{Page: 3, PageSize: 20}, wantOffset: 40},
+		{name: "page size too large", pagination: &commontypes.PaginationRequest{PageSize: 10001}, wantErr: true},
+		{name: "sort key with a direction", sortKey: []string{"ts DESC", "id asc"}},
+		{name: "sort key with an expression", sortKey: []string{"ts DESC, (SELECT 1)"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newSQLPage("SELECT 1", "", tt.sortKey, tt.pagination, []byte("secret"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSQLPage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.offset != tt.wantOffset {
+				t.Errorf("newSQLPage() offset = %d, want %d", p.offset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestSetTotal(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    [][]interface{}
+		want    int64
+		wantErr bool
+	}{
+		{name: "json number", rows: [][]interface{}{{json.Number("9007199254740993")}}, want: 9007199254740993},
+		{name: "float", rows: [][]interface{}{{float64(42)}}, want: 42},
+		{name: "string", rows: [][]interface{}{{"7"}}, want: 7},
+		{name: "fractional json number", rows: [][]interface{}{{json.Number("1.5")}}, wantErr: true},
+		{name: "no rows", rows: nil, wantErr: true},
+		{name: "other type", rows: [][]interface{}{{true}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &sqlPage{}
+			err := p.setTotal(tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setTotal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (p.total != tt.want || !p.counted) {
+				t.Errorf("setTotal() total = %d counted %v, want %d counted", p.total, p.counted, tt.want)
+			}
+		})
+	}
+}
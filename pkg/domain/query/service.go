@@ -2,10 +2,15 @@ package query
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/turtacn/dataseap/pkg/adapter/starrocks"
+	"github.com/turtacn/dataseap/pkg/common/constants"
 	"github.com/turtacn/dataseap/pkg/common/errors"
+	commontypes "github.com/turtacn/dataseap/pkg/common/types"
 	"github.com/turtacn/dataseap/pkg/domain/query/model"
+	"github.com/turtacn/dataseap/pkg/domain/query/sqlparse"
 	"github.com/turtacn/dataseap/pkg/logger"
 	// metadataService "github.com/turtacn/dataseap/pkg/domain/management/metadata" // For schema info, etc.
 )
@@ -18,18 +23,25 @@ type FullTextSearchSubService interface {
 	Search(ctx context.Context, req *model.FullTextSearchRequest) (*model.FullTextSearchResult, error)
 }
 
+// noWorkloadGroup stands in QueryStats.Message for the workload group of queries that do not request one, which
+// the resource group classifiers of StarRocks choose.
+// noWorkloadGroup 在QueryStats.Message中表示未指定工作负载组的查询，其工作负载组由StarRocks的资源组分类器选择。
+const noWorkloadGroup = "none, chosen by StarRocks"
+
 type serviceImpl struct {
 	starrocksClient  starrocks.Client
 	fullTextSearcher FullTextSearchSubService
+	opts             *options
 	// metadataSvc      metadataService.Service // Optional: for query planning or validation
 }
 
 // NewService creates a new instance of the query service.
 // NewService 创建一个新的查询服务实例。
-func NewService(srClient starrocks.Client, ftSearcher FullTextSearchSubService /*, metaSvc metadataService.Service*/) Service {
+func NewService(srClient starrocks.Client, ftSearcher FullTextSearchSubService /*, metaSvc metadataService.Service*/, opts ...Option) Service {
 	return &serviceImpl{
 		starrocksClient:  srClient,
 		fullTextSearcher: ftSearcher,
+		opts:             applyOptions(opts),
 		// metadataSvc:      metaSvc,
 	}
 }
@@ -40,34 +52,64 @@ func (s *serviceImpl) ExecuteSQL(ctx context.Context, req *model.SQLQueryRequest
 	l := logger.L().Ctx(ctx).With("method", "ExecuteSQL", "sql_query_length", len(req.SQL))
 	l.Info("Attempting to execute SQL query")
 
-	if err := req.Validate(); err != nil {
-		l.Warnw("SQLQueryRequest validation failed", "error", err)
-		return nil, errors.Wrap(err, errors.InvalidArgument, "invalid SQL query request")
+	sql, execOpts, err := s.prepareSQL(ctx, req)
+	if err != nil {
+		l.Warnw("SQL query rejected", "error", err)
+		return nil, err
 	}
 
-	// TODO: Add query sanitization or validation if necessary, though StarRocks client should handle parameters safely if supported.
-	// For basic HTTP API, parameters are not directly supported, so SQL must be pre-formatted.
-
-	// TODO: If req.Database is provided, ensure it's used. The current starrocksClient.Execute
-	// might use a default database from its config or require specific handling.
-	// Potentially use a temporary session property: SET DATABASE = req.Database;
+	// Paginated queries read a page, and count the rows only when the page itself does not tell their number.
+	statement := sql
+	var page *sqlPage
+	if req.Pagination != nil || len(req.SortKey) > 0 {
+		if page, err = newSQLPage(sql, req.Database, req.SortKey, req.Pagination, s.opts.pageTokenKey); err == nil {
+			statement, err = page.statement(sql)
+		}
+		if err != nil {
+			l.Warnw("Failed to paginate SQL query", "error", err)
+			return nil, errors.Wrap(err, errors.InvalidArgument, "invalid SQL query pagination")
+		}
+	}
 
-	srResult, err := s.starrocksClient.Execute(ctx, req.SQL /*, req.Params - if supported */)
+	start := time.Now()
+	srResult, err := s.starrocksClient.ExecuteWithOptions(ctx, statement, execOpts)
 	if err != nil {
 		l.Errorw("Failed to execute SQL query via StarRocks client", "error", err)
-		return nil, errors.Wrap(err, errors.DatabaseError, "failed to execute SQL query")
+		return nil, executionError(err)
+	}
+
+	rows := srResult.Rows
+	var pagination *commontypes.PaginationResponse
+	if page != nil {
+		if page.needsCount(len(rows)) {
+			countSQL, err := sqlparse.Count(sql)
+			if err != nil {
+				return nil, errors.Wrap(err, errors.InvalidArgument, "invalid SQL query pagination")
+			}
+			countResult, err := s.starrocksClient.ExecuteWithOptions(ctx, countSQL, execOpts)
+			if err != nil {
+				l.Errorw("Failed to count the rows of the SQL query", "error", err)
+				return nil, executionError(err)
+			}
+			if err := page.setTotal(countResult.Rows); err != nil {
+				return nil, err
+			}
+		}
+		if rows, pagination, err = page.finish(srResult.Columns, rows); err != nil {
+			l.Warnw("Failed to paginate SQL query", "error", err)
+			return nil, err
+		}
 	}
 
 	// Transform starrocks.QueryResult to model.SQLQueryResult
 	domainResult := &model.SQLQueryResult{
-		Columns: srResult.Columns,
-		Rows:    make([]map[string]interface{}, len(srResult.Rows)),
+		Columns:    srResult.Columns,
+		Rows:       make([]map[string]interface{}, len(rows)),
+		Pagination: pagination,
 		// AffectedRows: srResult.AffectedRows, // Assuming starrocks.QueryResult has this
-		// Stats: &model.QueryStats{...} // Map from srResult.Stats
-		// Pagination: req.Pagination ... // This should be part of the result from adapter if it handles pagination
 	}
 
-	for i, srRow := range srResult.Rows {
+	for i, srRow := range rows {
 		rowData := make(map[string]interface{})
 		for j, colName := range srResult.Columns {
 			if j < len(srRow) {
@@ -77,21 +119,139 @@ func (s *serviceImpl) ExecuteSQL(ctx context.Context, req *model.SQLQueryRequest
 		domainResult.Rows[i] = rowData
 	}
 
-	if srResult.Stats != nil {
-		domainResult.Stats = &model.QueryStats{
-			ScanRows:   srResult.Stats.ScanRows,
-			ScanBytes:  srResult.Stats.ScanBytes,
-			Duration:   srResult.Stats.Duration,
-			PeakMemory: srResult.Stats.PeakMemory,
-			CPUTime:    srResult.Stats.CPUTime,
-			Message:    srResult.Stats.Message,
-		}
+	domainResult.Stats = queryStats(srResult.Stats, req.WorkloadGroup)
+	domainResult.ExecutionTime = time.Since(start)
+	if s.opts.recorder != nil {
+		s.opts.recorder.RecordQuery(ctx, req, domainResult)
 	}
 
 	l.Info("SQL query executed successfully")
 	return domainResult, nil
 }
 
+// StreamSQL executes a given SQL query and hands its rows to sink as they arrive from StarRocks.
+// StreamSQL 执行给定的SQL查询，并在数据行从StarRocks到达时将其交给sink。
+func (s *serviceImpl) StreamSQL(ctx context.Context, req *model.SQLQueryRequest, sink RowSink) (*model.SQLStreamSummary, error) {
+	l := logger.L().With("method", "StreamSQL", "sql_query_length", len(req.SQL))
+	l.Info("Attempting to stream SQL query")
+
+	if req.Pagination != nil || len(req.SortKey) > 0 {
+		return nil, errors.New(errors.InvalidArgument, "streamed SQL queries cannot be paginated")
+	}
+	sql, execOpts, err := s.prepareSQL(ctx, req)
+	if err != nil {
+		l.Warnw("SQL query rejected", "error", err)
+		return nil, err
+	}
+	// Extracts outlive the timeout of interactive queries, so streams get a longer one of their own.
+	if execOpts.TimeoutSeconds == 0 {
+		execOpts.TimeoutSeconds = constants.QueryStreamDefaultTimeout
+	}
+
+	start := time.Now()
+	rows, err := s.starrocksClient.ExecuteStream(ctx, sql, execOpts)
+	if err != nil {
+		l.Errorw("Failed to execute SQL query via StarRocks client", "error", err)
+		return nil, executionError(err)
+	}
+	defer rows.Close()
+
+	columns := rows.Columns()
+	if err := sink.Columns(columns); err != nil {
+		l.Warnw("Failed to send SQL query columns", "error", err)
+		return nil, err
+	}
+	summary := &model.SQLStreamSummary{Columns: columns}
+	for rows.Next() {
+		srRow := rows.Row()
+		rowData := make(map[string]interface{}, len(columns))
+		for j, colName := range columns {
+			if j < len(srRow) {
+				rowData[colName] = srRow[j]
+			}
+		}
+		// Returning at the first failed send closes the rows, which stops reading the response of StarRocks.
+		if err := sink.Row(rowData); err != nil {
+			l.Warnw("Stopped streaming SQL query rows", "rows_sent", summary.RowCount, "error", err)
+			return nil, err
+		}
+		summary.RowCount++
+	}
+	if err := rows.Err(); err != nil {
+		l.Errorw("Failed to read SQL query rows from StarRocks", "rows_sent", summary.RowCount, "error", err)
+		return nil, executionError(err)
+	}
+
+	summary.Stats = queryStats(rows.Stats(), req.WorkloadGroup)
+	summary.ExecutionTime = time.Since(start)
+	if s.opts.recorder != nil {
+		s.opts.recorder.RecordQuery(ctx, req, &model.SQLQueryResult{Columns: columns, Stats: summary.Stats, ExecutionTime: summary.ExecutionTime})
+	}
+
+	l.Infow("SQL query streamed successfully", "rows_sent", summary.RowCount)
+	return summary, nil
+}
+
+// prepareSQL validates a request and binds its parameters, returning the statement the query policy allows and the
+// options to execute it with.
+// prepareSQL 校验请求并绑定其参数，返回查询策略允许的语句及其执行选项。
+func (s *serviceImpl) prepareSQL(ctx context.Context, req *model.SQLQueryRequest) (string, *starrocks.ExecuteOptions, error) {
+	if err := req.Validate(); err != nil {
+		return "", nil, errors.Wrap(err, errors.InvalidArgument, "invalid SQL query request")
+	}
+
+	// The StarRocks HTTP API cannot parameterize statements, so parameters are bound here as escaped literals.
+	sql, err := sqlparse.Bind(req.SQL, req.Params)
+	if err != nil {
+		return "", nil, errors.Wrap(err, errors.InvalidArgument, "invalid SQL query parameters")
+	}
+	if s.opts.guard != nil {
+		if err := s.opts.guard.check(ctx, sql, req.Database); err != nil {
+			return "", nil, err
+		}
+	}
+
+	// A non-positive timeout falls back to the configured StarRocks query timeout.
+	// Numbers are kept exact, so that BIGINT values and the keyset page tokens built from them are not rounded.
+	execOpts := &starrocks.ExecuteOptions{Database: req.Database, WorkloadGroup: req.WorkloadGroup, UseNumber: true}
+	if req.QueryTimeoutSecs > 0 {
+		execOpts.TimeoutSeconds = req.QueryTimeoutSecs
+	}
+	return sql, execOpts, nil
+}
+
+// queryStats maps the statistics StarRocks reported for a query, noting the workload group it requested. The query
+// API of StarRocks does not report the group a query ran in, so the note is only the request.
+// queryStats 转换StarRocks为查询报告的统计信息，并注明其请求的工作负载组。StarRocks的查询API不报告查询实际所在的组，
+// 因此该说明仅为请求的组。
+func queryStats(srStats *starrocks.QueryStats, workloadGroup string) *model.QueryStats {
+	stats := &model.QueryStats{}
+	if srStats != nil {
+		stats = &model.QueryStats{
+			ScanRows:   srStats.ScanRows,
+			ScanBytes:  srStats.ScanBytes,
+			Duration:   srStats.Duration,
+			PeakMemory: srStats.PeakMemory,
+			CPUTime:    srStats.CPUTime,
+			Message:    srStats.Message,
+		}
+	}
+	if workloadGroup == "" {
+		workloadGroup = noWorkloadGroup
+	}
+	stats.Message = strings.TrimPrefix(stats.Message+"; Requested workload group: "+workloadGroup, "; ")
+	return stats
+}
+
+// executionError classifies an error of the StarRocks client, keeping timeouts apart from other failures.
+// executionError 对StarRocks客户端的错误进行分类，将超时与其他失败区分开。
+func executionError(err error) error {
+	if errors.GetCode(err) == errors.TimeoutError {
+		return errors.Wrap(err, errors.TimeoutError, "SQL query exceeded its timeout")
+	}
+	return errors.Wrap(err, errors.DatabaseError, "failed to execute SQL query")
+}
+
 // SearchFullText performs a full-text search based on the provided request.
 // SearchFullText 根据提供的请求执行全文检索。
 func (s *serviceImpl) SearchFullText(ctx context.Context, req *model.FullTextSearchRequest) (*model.FullTextSearchResult, error) {
@@ -114,6 +274,9 @@ func (s *serviceImpl) SearchFullText(ctx context.Context, req *model.FullTextSea
 		// Error already wrapped by sub-service or adapter
 		return nil, err
 	}
+	if s.opts.recorder != nil {
+		s.opts.recorder.RecordSearch(ctx, req, result)
+	}
 
 	l.Info("Full-text search completed successfully")
 	return result, nil
@@ -0,0 +1,461 @@
+package sqlparse
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// aggregateFunctions are the aggregate functions recognized in select lists.
+// aggregateFunctions 是在SELECT列表中识别的聚合函数。
+var aggregateFunctions = map[string]bool{
+	"COUNT": true, "SUM": true, "MIN": true, "MAX": true, "AVG": true, "ANY_VALUE": true,
+	"APPROX_COUNT_DISTINCT": true, "NDV": true, "BITMAP_UNION": true, "BITMAP_UNION_COUNT": true,
+	"HLL_UNION": true, "HLL_UNION_AGG": true, "HLL_RAW_AGG": true, "PERCENTILE_APPROX": true,
+	"PERCENTILE_UNION": true, "STDDEV": true, "STDDEV_SAMP": true, "VARIANCE": true, "VAR_SAMP": true,
+	"GROUP_CONCAT": true, "ARRAY_AGG": true,
+}
+
+// comparisonOperators are the operators that compare a column with a value in a predicate.
+// comparisonOperators 是谓词中将列与值进行比较的运算符。
+var comparisonOperators = map[string]bool{"=": true, "<": true, ">": true, "<=": true, ">=": true, "!=": true, "<>": true, "<=>": true}
+
+// TableRef is a table a statement reads from.
+// TableRef 是语句读取的一张表。
+type TableRef struct {
+	Catalog  string // 未限定目录时为空 Empty when the table is not qualified with a catalog
+	Database string // 未限定数据库时为空 Empty when the table is not qualified
+	Table    string
+	Alias    string // 未指定别名时为空 Empty without an alias
+}
+
+// Projection is an item of a select list.
+// Projection 是SELECT列表中的一项。
+type Projection struct {
+	Expr      string // 原样书写的表达式，不含别名 The expression as written, without its alias
+	Alias     string // 未指定别名时为空 Empty without an alias
+	Aggregate string // 大写的聚合函数名，非聚合项为空 Upper-case aggregate function, empty for other items
+	Argument  string // 聚合函数的参数，不含DISTINCT Argument of the aggregate function, without DISTINCT
+	Distinct  bool   // 聚合函数是否带有DISTINCT Whether the aggregate function has DISTINCT
+}
+
+// Predicate is a WHERE condition comparing a column with a value.
+// Predicate 是将列与值进行比较的WHERE条件。
+type Predicate struct {
+	Table    string // 列所属的表，无法确定时为空 Table of the column, empty when it cannot be determined
+	Column   string // 小写的列名 Lower-case column name
+	Operator string // 例如 "=", "IN", "LIKE", "MATCH_ANY" E.g., "=", "IN", "LIKE", "MATCH_ANY"
+}
+
+// Shape is what Analyze learns of a statement: its type and, for a query, the tables it reads, its select list,
+// grouping and filters.
+// Shape 是Analyze对语句的分析结果：语句类型，以及对于查询语句，其读取的表、SELECT列表、分组与过滤条件。
+type Shape struct {
+	// Statement 大写的语句类型，例如 "SELECT", "INSERT", "SHOW"。
+	// Statement Upper-case statement type, e.g., "SELECT", "INSERT", "SHOW".
+	Statement string
+	// Tables FROM子句中的基础表，按出现顺序排列。
+	// Tables Base tables of the FROM clause, in order.
+	Tables []TableRef
+	// From 原样书写的FROM子句，不含FROM关键字。
+	// From The FROM clause as written, without the FROM keyword.
+	From        string
+	Projections []Projection
+	// GroupBy 原样书写的分组表达式，序号引用已替换为对应的SELECT项。
+	// GroupBy Grouping expressions as written, with ordinal references replaced by their select items.
+	GroupBy    []string
+	Predicates []Predicate
+	// Simple 为true表示语句是单个SELECT块，不含CTE、集合运算、派生表、窗口函数或GROUPING SETS。
+	// Simple True for a single SELECT block without CTEs, set operations, derived tables, window functions or
+	// grouping sets.
+	Simple bool
+}
+
+// IsAggregation reports whether a simple query groups rows and computes aggregates.
+// IsAggregation 报告简单查询是否对行分组并计算聚合值。
+func (s *Shape) IsAggregation() bool {
+	if !s.Simple || len(s.GroupBy) == 0 || len(s.Tables) == 0 {
+		return false
+	}
+	for _, p := range s.Projections {
+		if p.Aggregate != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyze learns the shape of a statement. It is a shallow analysis of the top-level SELECT block rather than a
+// full parse: statements it cannot follow are reported with Simple set to false.
+// Analyze 分析语句的结构。这是对顶层SELECT块的浅层分析而非完整解析：无法分析的语句以Simple为false报告。
+func Analyze(sql string) (*Shape, error) {
+	tokens, err := Tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].Text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New(errors.InvalidArgument, "statement is empty")
+	}
+
+	shape := &Shape{Statement: strings.ToUpper(tokens[0].Text)}
+	switch {
+	case tokens[0].Text == "(" || tokens[0].Is("WITH"):
+		shape.Statement = "SELECT"
+		return shape, nil
+	case !tokens[0].Is("SELECT"):
+		return shape, nil
+	}
+	shape.Simple = true
+
+	clauses := splitClauses(tokens, shape)
+	shape.Projections = analyzeSelectList(clauses["SELECT"], shape)
+	if from, ok := clauses["FROM"]; ok {
+		shape.From = Render(from)
+		shape.Tables = analyzeFrom(from, shape)
+	}
+	if groupBy, ok := clauses["GROUP BY"]; ok {
+		shape.GroupBy = analyzeGroupBy(groupBy, shape)
+	}
+	if where, ok := clauses["WHERE"]; ok {
+		shape.Predicates = analyzeWhere(where, shape.Tables)
+	}
+	return shape, nil
+}
+
+// splitClauses splits the top-level SELECT block into its clauses, keyed by their keywords. A set operation ends
+// the block.
+// splitClauses 将顶层SELECT块按子句拆分，以子句关键字为键。集合运算结束该块。
+func splitClauses(tokens []Token, shape *Shape) map[string][]Token {
+	clauses := map[string][]Token{}
+	current, start, depth := "SELECT", 1, 0
+	closeClause := func(end int) { clauses[current] = tokens[start:end] }
+	for i := 1; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.Text == "(":
+			depth++
+			continue
+		case t.Text == ")":
+			depth--
+			continue
+		case depth > 0 || t.Kind != TokenIdentifier:
+			continue
+		}
+
+		var next string
+		width := 1
+		switch word := strings.ToUpper(t.Text); word {
+		case "FROM", "WHERE", "HAVING", "LIMIT", "QUALIFY", "WINDOW":
+			next = word
+		case "GROUP", "ORDER":
+			if i+1 < len(tokens) && tokens[i+1].Is("BY") {
+				next, width = word+" BY", 2
+			}
+		case "UNION", "EXCEPT", "INTERSECT", "MINUS":
+			shape.Simple = false
+			closeClause(i)
+			return clauses
+		}
+		if next == "" {
+			continue
+		}
+		closeClause(i)
+		current, start = next, i+width
+		i += width - 1
+	}
+	closeClause(len(tokens))
+	if _, ok := clauses["QUALIFY"]; ok {
+		shape.Simple = false
+	}
+	if _, ok := clauses["WINDOW"]; ok {
+		shape.Simple = false
+	}
+	return clauses
+}
+
+func analyzeSelectList(tokens []Token, shape *Shape) []Projection {
+	if len(tokens) > 0 && (tokens[0].Is("DISTINCT") || tokens[0].Is("ALL")) {
+		if tokens[0].Is("DISTINCT") {
+			shape.Simple = false
+		}
+		tokens = tokens[1:]
+	}
+	var projections []Projection
+	for _, item := range splitTopLevel(tokens) {
+		if len(item) == 0 {
+			continue
+		}
+		p := Projection{}
+		n := len(item)
+		switch {
+		case n > 2 && item[n-2].Is("AS"):
+			p.Alias, item = item[n-1].Value, item[:n-2]
+		case n > 1 && isAliasToken(item[n-1]) && isOperand(item[n-2]):
+			p.Alias, item = item[n-1].Value, item[:n-1]
+		}
+		for _, t := range item {
+			if t.Is("OVER") {
+				shape.Simple = false
+			}
+		}
+		p.Expr = Render(item)
+		if len(item) >= 3 && item[0].Kind == TokenIdentifier && item[1].Text == "(" && matchingParen(item, 1) == len(item)-1 {
+			if name := strings.ToUpper(item[0].Text); aggregateFunctions[name] {
+				args := item[2 : len(item)-1]
+				if len(args) > 0 && args[0].Is("DISTINCT") {
+					p.Distinct, args = true, args[1:]
+				}
+				p.Aggregate, p.Argument = name, Render(args)
+			}
+		}
+		projections = append(projections, p)
+	}
+	return projections
+}
+
+func analyzeFrom(tokens []Token, shape *Shape) []TableRef {
+	var tables []TableRef
+	expectTable := true
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.Text == "(":
+			if expectTable {
+				shape.Simple = false // derived table or parenthesized join
+				expectTable = false
+			}
+			i = matchingParen(tokens, i)
+			if i < 0 {
+				return tables
+			}
+			continue
+		case t.Text == ",", t.Is("JOIN"):
+			expectTable = true
+			continue
+		case !expectTable:
+			continue
+		case t.Is("LATERAL"):
+			shape.Simple = false
+			continue
+		case t.Kind != TokenIdentifier && t.Kind != TokenQuotedIdentifier:
+			continue
+		case t.Kind == TokenIdentifier && IsKeyword(t.Text):
+			// INNER, LEFT, OUTER and the like precede JOIN.
+			continue
+		}
+
+		expectTable = false
+		ref := TableRef{Table: t.Value}
+		if i+2 < len(tokens) && tokens[i+1].Text == "." {
+			ref.Database, ref.Table = t.Value, tokens[i+2].Value
+			i += 2
+		}
+		if i+1 < len(tokens) && tokens[i+1].Text == "(" {
+			shape.Simple = false // table function
+			continue
+		}
+		switch {
+		case i+2 < len(tokens) && tokens[i+1].Is("AS"):
+			ref.Alias = tokens[i+2].Value
+			i += 2
+		case i+1 < len(tokens) && isAliasToken(tokens[i+1]):
+			ref.Alias = tokens[i+1].Value
+			i++
+		}
+		tables = append(tables, ref)
+	}
+	return tables
+}
+
+func analyzeGroupBy(tokens []Token, shape *Shape) []string {
+	if len(tokens) > 0 && (tokens[0].Is("ROLLUP") || tokens[0].Is("CUBE") || tokens[0].Is("GROUPING")) {
+		shape.Simple = false
+	}
+	var groupBy []string
+	for _, item := range splitTopLevel(tokens) {
+		if len(item) == 0 {
+			continue
+		}
+		if len(item) == 1 && item[0].Kind == TokenNumber {
+			if ordinal, err := strconv.Atoi(item[0].Text); err == nil && ordinal >= 1 && ordinal <= len(shape.Projections) {
+				groupBy = append(groupBy, shape.Projections[ordinal-1].Expr)
+				continue
+			}
+		}
+		groupBy = append(groupBy, Render(item))
+	}
+	return groupBy
+}
+
+// analyzeWhere finds the predicates comparing a column with a value. Comparisons between two columns, as in
+// implicit joins, are not predicates.
+// analyzeWhere 查找将列与值进行比较的谓词。两列之间的比较（如隐式连接）不视为谓词。
+func analyzeWhere(tokens []Token, tables []TableRef) []Predicate {
+	var predicates []Predicate
+	seen := map[Predicate]bool{}
+	add := func(qualifier, column, operator string) {
+		p := Predicate{Table: resolveTable(qualifier, tables), Column: strings.ToLower(column), Operator: operator}
+		if !seen[p] {
+			seen[p] = true
+			predicates = append(predicates, p)
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		qualifier, column, end := columnRef(tokens, i)
+		if end < 0 {
+			continue
+		}
+		// value <op> column
+		if i >= 2 && comparisonOperators[tokens[i-1].Text] && tokens[i-2].IsLiteral() {
+			add(qualifier, column, mirrorOperator(tokens[i-1].Text))
+			i = end - 1
+			continue
+		}
+		if operator, valueAt := predicateOperator(tokens, end); operator != "" && !isColumnStart(tokens, valueAt) {
+			add(qualifier, column, operator)
+		}
+		i = end - 1
+	}
+	return predicates
+}
+
+// predicateOperator reads the operator following a column reference, returning it with the position of the
+// value it compares the column with.
+// predicateOperator 读取列引用之后的运算符，返回该运算符以及与列比较的值的位置。
+func predicateOperator(tokens []Token, i int) (string, int) {
+	if i >= len(tokens) {
+		return "", -1
+	}
+	t := tokens[i]
+	if comparisonOperators[t.Text] {
+		return t.Text, i + 1
+	}
+	if t.Kind != TokenIdentifier {
+		return "", -1
+	}
+	switch word := strings.ToUpper(t.Text); word {
+	case "IN", "LIKE", "BETWEEN", "REGEXP", "RLIKE", "MATCH_ANY", "MATCH_ALL":
+		return word, i + 1
+	case "IS":
+		return word, -1
+	case "NOT":
+		if i+1 < len(tokens) {
+			switch next := strings.ToUpper(tokens[i+1].Text); next {
+			case "IN", "LIKE", "BETWEEN", "REGEXP", "RLIKE":
+				return "NOT " + next, i + 2
+			}
+		}
+	}
+	return "", -1
+}
+
+// columnRef reads a possibly qualified column reference at i, returning the position after it, or -1 when there
+// is none.
+// columnRef 读取位置i处可能带限定符的列引用，返回其后的位置，不存在列引用时返回-1。
+func columnRef(tokens []Token, i int) (qualifier, column string, end int) {
+	if !isColumnStart(tokens, i) || (i > 0 && tokens[i-1].Text == ".") {
+		return "", "", -1
+	}
+	column, end = tokens[i].Value, i+1
+	for end+1 < len(tokens) && tokens[end].Text == "." && (tokens[end+1].Kind == TokenIdentifier || tokens[end+1].Kind == TokenQuotedIdentifier) {
+		qualifier, column = column, tokens[end+1].Value
+		end += 2
+	}
+	return qualifier, column, end
+}
+
+// isColumnStart reports whether a column reference starts at i: an identifier that is neither a keyword nor a
+// function name.
+// isColumnStart 报告位置i处是否开始一个列引用：既不是关键字也不是函数名的标识符。
+func isColumnStart(tokens []Token, i int) bool {
+	if i < 0 || i >= len(tokens) {
+		return false
+	}
+	t := tokens[i]
+	switch t.Kind {
+	case TokenQuotedIdentifier:
+		return true
+	case TokenIdentifier:
+		return !IsKeyword(t.Text) && !strings.HasPrefix(t.Text, "@") && (i+1 >= len(tokens) || tokens[i+1].Text != "(")
+	}
+	return false
+}
+
+func resolveTable(qualifier string, tables []TableRef) string {
+	if qualifier == "" {
+		if len(tables) == 1 {
+			return tables[0].Table
+		}
+		return ""
+	}
+	for _, t := range tables {
+		if strings.EqualFold(qualifier, t.Alias) || (t.Alias == "" && strings.EqualFold(qualifier, t.Table)) {
+			return t.Table
+		}
+	}
+	return ""
+}
+
+func mirrorOperator(operator string) string {
+	switch operator {
+	case "<":
+		return ">"
+	case ">":
+		return "<"
+	case "<=":
+		return ">="
+	case ">=":
+		return "<="
+	}
+	return operator
+}
+
+// isAliasToken reports whether a token can be an alias given without AS.
+// isAliasToken 报告词法单元能否作为省略AS的别名。
+func isAliasToken(t Token) bool {
+	return t.Kind == TokenQuotedIdentifier || (t.Kind == TokenIdentifier && !IsKeyword(t.Text))
+}
+
+// splitTopLevel splits tokens at the commas outside brackets.
+// splitTopLevel 在括号之外的逗号处拆分词法单元。
+func splitTopLevel(tokens []Token) [][]Token {
+	var parts [][]Token
+	depth, start := 0, 0
+	for i, t := range tokens {
+		switch t.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[start:])
+}
+
+// matchingParen returns the position of the ")" closing the "(" at open, or -1.
+// matchingParen 返回与open处 "(" 匹配的 ")" 的位置，不存在时返回-1。
+func matchingParen(tokens []Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,148 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    *Shape
+		wantAgg bool
+		wantErr bool
+	}{
+		{
+			name: "aggregation",
+			sql:  "SELECT host, DATE_TRUNC('day', ts) AS day, COUNT(*), AVG(bytes) avg_bytes, COUNT(DISTINCT user) FROM logs.events WHERE level = 'error' AND 500 <= status GROUP BY 1, DATE_TRUNC('day', ts);",
+			want: &Shape{
+				Statement: "SELECT",
+				Tables:    []TableRef{{Database: "logs", Table: "events"}},
+				From:      "logs.events",
+				Projections: []Projection{
+					{Expr: "host"},
+					{Expr: "DATE_TRUNC('day', ts)", Alias: "day"},
+					{Expr: "COUNT(*)", Aggregate: "COUNT", Argument: "*"},
+					{Expr: "AVG(bytes)", Alias: "avg_bytes", Aggregate: "AVG", Argument: "bytes"},
+					{Expr: "COUNT(DISTINCT user)", Aggregate: "COUNT", Argument: "user", Distinct: true},
+				},
+				GroupBy: []string{"host", "DATE_TRUNC('day', ts)"},
+				Predicates: []Predicate{
+					{Table: "events", Column: "level", Operator: "="},
+					{Table: "events", Column: "status", Operator: ">="},
+				},
+				Simple: true,
+			},
+			wantAgg: true,
+		},
+		{
+			name: "join predicates are resolved through aliases",
+			sql:  "SELECT e.host, COUNT(*) FROM events e JOIN hosts AS h ON e.host = h.name WHERE h.region IN ('eu', 'us') AND e.msg LIKE '%fail%' AND e.host = h.name GROUP BY e.host",
+			want: &Shape{
+				Statement: "SELECT",
+				Tables:    []TableRef{{Table: "events", Alias: "e"}, {Table: "hosts", Alias: "h"}},
+				From:      "events e JOIN hosts AS h ON e.host = h.name",
+				Projections: []Projection{
+					{Expr: "e.host"},
+					{Expr: "COUNT(*)", Aggregate: "COUNT", Argument: "*"},
+				},
+				GroupBy: []string{"e.host"},
+				Predicates: []Predicate{
+					{Table: "hosts", Column: "region", Operator: "IN"},
+					{Table: "events", Column: "msg", Operator: "LIKE"},
+				},
+				Simple: true,
+			},
+			wantAgg: true,
+		},
+		{
+			name: "unqualified columns of several tables",
+			sql:  "SELECT a FROM t1, t2 WHERE b NOT LIKE 'x' AND c IS NULL",
+			want: &Shape{
+				Statement:   "SELECT",
+				Tables:      []TableRef{{Table: "t1"}, {Table: "t2"}},
+				From:        "t1, t2",
+				Projections: []Projection{{Expr: "a"}},
+				Predicates:  []Predicate{{Column: "b", Operator: "NOT LIKE"}, {Column: "c", Operator: "IS"}},
+				Simple:      true,
+			},
+		},
+		{
+			name: "set operation",
+			sql:  "SELECT host, COUNT(*) FROM a GROUP BY host UNION ALL SELECT host, COUNT(*) FROM b GROUP BY host",
+			want: &Shape{
+				Statement:   "SELECT",
+				Tables:      []TableRef{{Table: "a"}},
+				From:        "a",
+				Projections: []Projection{{Expr: "host"}, {Expr: "COUNT(*)", Aggregate: "COUNT", Argument: "*"}},
+				GroupBy:     []string{"host"},
+			},
+		},
+		{
+			name: "derived table",
+			sql:  "SELECT k, SUM(v) FROM (SELECT k, v FROM t) s GROUP BY k",
+			want: &Shape{
+				Statement:   "SELECT",
+				From:        "(SELECT k, v FROM t) s",
+				Projections: []Projection{{Expr: "k"}, {Expr: "SUM(v)", Aggregate: "SUM", Argument: "v"}},
+				GroupBy:     []string{"k"},
+			},
+		},
+		{
+			name: "window function",
+			sql:  "SELECT k, SUM(v) OVER (PARTITION BY k) FROM t",
+			want: &Shape{
+				Statement:   "SELECT",
+				Tables:      []TableRef{{Table: "t"}},
+				From:        "t",
+				Projections: []Projection{{Expr: "k"}, {Expr: "SUM(v) OVER (PARTITION BY k)"}},
+			},
+		},
+		{
+			name: "rollup",
+			sql:  "SELECT k, COUNT(*) FROM t GROUP BY ROLLUP(k)",
+			want: &Shape{
+				Statement:   "SELECT",
+				Tables:      []TableRef{{Table: "t"}},
+				From:        "t",
+				Projections: []Projection{{Expr: "k"}, {Expr: "COUNT(*)", Aggregate: "COUNT", Argument: "*"}},
+				GroupBy:     []string{"ROLLUP(k)"},
+			},
+		},
+		{
+			name: "select distinct without grouping",
+			sql:  "SELECT DISTINCT host FROM t",
+			want: &Shape{Statement: "SELECT", Tables: []TableRef{{Table: "t"}}, From: "t", Projections: []Projection{{Expr: "host"}}},
+		},
+		{
+			name: "common table expression",
+			sql:  "WITH x AS (SELECT 1) SELECT * FROM x",
+			want: &Shape{Statement: "SELECT"},
+		},
+		{
+			name: "other statements",
+			sql:  "show tables",
+			want: &Shape{Statement: "SHOW"},
+		},
+		{name: "empty", sql: " ; ", wantErr: true},
+		{name: "untokenizable", sql: "SELECT 'x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Analyze(tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Analyze() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Analyze() = %+v, want %+v", got, tt.want)
+			}
+			if got.IsAggregation() != tt.wantAgg {
+				t.Errorf("IsAggregation() = %v, want %v", got.IsAggregation(), tt.wantAgg)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package sqlparse
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// bindTimeLayout is the layout time values are bound with, as DATETIME literals in the location of the value.
+// bindTimeLayout 是绑定时间值所用的格式，以值自身所在时区的DATETIME字面量表示。
+const bindTimeLayout = "2006-01-02 15:04:05.999999"
+
+// maxExactFloatInt is 2^53, the magnitude from which a float64 can no longer represent every integer.
+// maxExactFloatInt 即2^53，从该量级起float64无法再精确表示每个整数。
+const maxExactFloatInt = 1 << 53
+
+// Bind replaces the placeholders of a statement with the literals of params. A named placeholder ":name" takes
+// params["name"]; the n-th positional placeholder "?" takes params[strconv.Itoa(n)], counting from 1. Values
+// are rendered by type: nil as NULL, strings, byte slices and time.Time values as escaped quoted literals,
+// booleans and numbers as such, and slices and arrays as comma-separated lists for "IN (...)", parenthesized
+// unless the placeholder already is. Placeholders without a parameter, parameters no placeholder uses and values
+// of other types are InvalidArgument errors.
+// Bind 用params中的字面量替换语句中的占位符。命名占位符 ":name" 取params["name"]；第n个位置占位符 "?" 取
+// params[strconv.Itoa(n)]，从1开始计数。值按类型渲染：nil渲染为NULL；字符串、字节切片与time.Time渲染为转义后的
+// 带引号字面量；布尔值与数值按原样渲染；切片与数组渲染为用于 "IN (...)" 的逗号分隔列表，占位符未被括号包围时加上括号。
+// 没有参数的占位符、未被任何占位符使用的参数以及其他类型的值均返回InvalidArgument错误。
+func Bind(sql string, params map[string]interface{}) (string, error) {
+	tokens, err := Tokenize(sql)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	used := make(map[string]bool, len(params))
+	var unbound []string
+	last, position := 0, 0
+	for i, t := range tokens {
+		if t.Kind != TokenPlaceholder {
+			continue
+		}
+		name, label := t.Value, t.Text
+		if t.Text == "?" {
+			position++
+			name = strconv.Itoa(position)
+			label = "?" + name
+		}
+		value, ok := params[name]
+		if !ok {
+			unbound = append(unbound, label)
+			continue
+		}
+		used[name] = true
+
+		parenthesized := i > 0 && tokens[i-1].Text == "(" && i+1 < len(tokens) && tokens[i+1].Text == ")"
+		literal, err := bindLiteral(value, !parenthesized)
+		if err != nil {
+			return "", errors.Wrapf(err, errors.InvalidArgument, "invalid value for SQL parameter %s", label)
+		}
+		sb.WriteString(sql[last:t.Pos])
+		sb.WriteString(literal)
+		last = t.Pos + len(t.Text)
+	}
+	if len(unbound) > 0 {
+		return "", errors.Newf(errors.InvalidArgument, "no value for SQL parameters %s", strings.Join(unbound, ", "))
+	}
+	var unused []string
+	for name := range params {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", errors.Newf(errors.InvalidArgument, "SQL parameters %s are not used by the statement", strings.Join(unused, ", "))
+	}
+	sb.WriteString(sql[last:])
+	return sb.String(), nil
+}
+
+// bindLiteral renders a parameter value as a SQL literal. List values are parenthesized when wrapList is set.
+// bindLiteral 将参数值渲染为SQL字面量。wrapList为true时列表值带括号。
+func bindLiteral(value interface{}, wrapList bool) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteLiteral(v), nil
+	case []byte:
+		return quoteLiteral(string(v)), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case json.Number:
+		if _, err := strconv.ParseFloat(string(v), 64); err != nil {
+			return "", errors.Newf(errors.InvalidArgument, "%q is not a number", string(v))
+		}
+		return string(v), nil
+	case time.Time:
+		return quoteLiteral(v.Format(bindTimeLayout)), nil
+	case *time.Time:
+		if v == nil {
+			return "NULL", nil
+		}
+		return quoteLiteral(v.Format(bindTimeLayout)), nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", errors.Newf(errors.InvalidArgument, "%v cannot be written as a SQL literal", f)
+		}
+		// A float64 holds integers exactly only up to 2^53, so a larger integral value has most likely been rounded
+		// on its way here, e.g. by a JSON or protobuf number.
+		if rv.Kind() == reflect.Float64 && f == math.Trunc(f) && math.Abs(f) >= maxExactFloatInt {
+			return "", errors.Newf(errors.InvalidArgument, "integer %s is beyond 2^53 and may have been rounded, pass it as a string",
+				strconv.FormatFloat(f, 'f', -1, 64))
+		}
+		return strconv.FormatFloat(f, 'f', -1, rv.Type().Bits()), nil
+	case reflect.String:
+		return quoteLiteral(rv.String()), nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return bindLiteral(rv.Elem().Interface(), wrapList)
+	case reflect.Slice, reflect.Array:
+		if rv.Len() == 0 {
+			return "", errors.New(errors.InvalidArgument, "an empty list cannot be bound")
+		}
+		items := make([]string, rv.Len())
+		for i := range items {
+			item := rv.Index(i).Interface()
+			if isList(item) {
+				return "", errors.New(errors.InvalidArgument, "lists cannot be nested")
+			}
+			literal, err := bindLiteral(item, false)
+			if err != nil {
+				return "", err
+			}
+			items[i] = literal
+		}
+		list := strings.Join(items, ", ")
+		if wrapList {
+			list = "(" + list + ")"
+		}
+		return list, nil
+	}
+	return "", errors.Newf(errors.InvalidArgument, "values of type %T cannot be bound", value)
+}
+
+func isList(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, []byte, json.Number:
+		return false
+	}
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// quoteLiteral quotes a string literal with single quotes, escaping backslashes and quotes as StarRocks expects.
+// quoteLiteral 用单引号括起字符串字面量，并按StarRocks的要求转义反斜杠与引号。
+func quoteLiteral(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`).Replace(s) + "'"
+}
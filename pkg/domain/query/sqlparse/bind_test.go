@@ -0,0 +1,107 @@
+package sqlparse
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+func TestBind(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 250000000, time.UTC)
+	host := "fw01"
+	var noHost *string
+	tests := []struct {
+		name    string
+		sql     string
+		params  map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "named",
+			sql:    "SELECT * FROM events WHERE host = :host AND bytes > :min",
+			params: map[string]interface{}{"host": "fw01", "min": 1024},
+			want:   "SELECT * FROM events WHERE host = 'fw01' AND bytes > 1024",
+		},
+		{
+			name:   "positional",
+			sql:    "SELECT * FROM events WHERE host = ? AND bytes > ?",
+			params: map[string]interface{}{"1": "fw01", "2": int64(1024)},
+			want:   "SELECT * FROM events WHERE host = 'fw01' AND bytes > 1024",
+		},
+		{
+			name:   "named placeholder used twice",
+			sql:    "SELECT :v, :v",
+			params: map[string]interface{}{"v": true},
+			want:   "SELECT TRUE, TRUE",
+		},
+		{
+			name:   "quotes, backslashes and NUL are escaped",
+			sql:    "SELECT :s",
+			params: map[string]interface{}{"s": "a'b\\c\x00' OR 1=1 --"},
+			want:   `SELECT 'a\'b\\c\0\' OR 1=1 --'`,
+		},
+		{
+			name:   "placeholders in strings, identifiers and comments are not bound",
+			sql:    "SELECT ':a', `:a`, \"?\" -- :a ?\n, :a",
+			params: map[string]interface{}{"a": 1},
+			want:   "SELECT ':a', `:a`, \"?\" -- :a ?\n, 1",
+		},
+		{
+			name:   "time, floats, json numbers, bytes and nil",
+			sql:    "SELECT ?, ?, ?, ?, ?",
+			params: map[string]interface{}{"1": ts, "2": 0.5, "3": json.Number("12.50"), "4": []byte("x"), "5": nil},
+			want:   "SELECT '2024-05-01 12:30:00.25', 0.5, 12.50, 'x', NULL",
+		},
+		{
+			name:   "pointers",
+			sql:    "SELECT :a, :b",
+			params: map[string]interface{}{"a": &host, "b": noHost},
+			want:   "SELECT 'fw01', NULL",
+		},
+		{
+			name:   "list in parentheses",
+			sql:    "SELECT * FROM t WHERE id IN (:ids)",
+			params: map[string]interface{}{"ids": []int{1, 2, 3}},
+			want:   "SELECT * FROM t WHERE id IN (1, 2, 3)",
+		},
+		{
+			name:   "list without parentheses",
+			sql:    "SELECT * FROM t WHERE host IN :hosts",
+			params: map[string]interface{}{"hosts": []string{"a", "b'"}},
+			want:   `SELECT * FROM t WHERE host IN ('a', 'b\'')`,
+		},
+		{name: "missing parameter", sql: "SELECT :a, ?", params: map[string]interface{}{}, wantErr: true},
+		{name: "unused parameter", sql: "SELECT :a", params: map[string]interface{}{"a": 1, "b": 2}, wantErr: true},
+		{name: "empty list", sql: "SELECT :a", params: map[string]interface{}{"a": []int{}}, wantErr: true},
+		{name: "nested list", sql: "SELECT :a", params: map[string]interface{}{"a": []interface{}{[]int{1}}}, wantErr: true},
+		{name: "NaN", sql: "SELECT :a", params: map[string]interface{}{"a": math.NaN()}, wantErr: true},
+		{name: "integral float below 2^53", sql: "SELECT :a, :b", params: map[string]interface{}{"a": float64(1<<53 - 1), "b": -1e15}, want: "SELECT 9007199254740991, -1000000000000000"},
+		{name: "integral float of 2^53", sql: "SELECT :a", params: map[string]interface{}{"a": float64(1 << 53)}, wantErr: true},
+		{name: "integral float below -2^53", sql: "SELECT :a", params: map[string]interface{}{"a": -1e18}, wantErr: true},
+		{name: "json number beyond 2^53", sql: "SELECT :a", params: map[string]interface{}{"a": json.Number("9007199254740993")}, want: "SELECT 9007199254740993"},
+		{name: "invalid json number", sql: "SELECT :a", params: map[string]interface{}{"a": json.Number("1; DROP TABLE t")}, wantErr: true},
+		{name: "unsupported type", sql: "SELECT :a", params: map[string]interface{}{"a": map[string]int{"x": 1}}, wantErr: true},
+		{name: "unterminated string", sql: "SELECT ':a", params: map[string]interface{}{"a": 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Bind(tt.sql, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Bind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, errors.InvalidArgument) {
+					t.Errorf("Bind() error code = %s, want InvalidArgument", errors.GetCode(err))
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Bind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
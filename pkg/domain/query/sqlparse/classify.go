@@ -0,0 +1,400 @@
+package sqlparse
+
+import (
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// explainOptions are the words that may follow EXPLAIN before the explained statement.
+// explainOptions 是EXPLAIN之后、被解释语句之前可能出现的单词。
+var explainOptions = map[string]bool{"LOGICAL": true, "VERBOSE": true, "COSTS": true, "ANALYZE": true}
+
+// tableIntroducers are the words a list of table names follows.
+// tableIntroducers 是其后跟随表名列表的单词。
+var tableIntroducers = map[string]bool{"FROM": true, "JOIN": true, "INTO": true, "OVERWRITE": true, "UPDATE": true, "TABLE": true, "VIEW": true}
+
+// tableModifiers are the words that may follow a table name, with an optional parenthesized list, before the next
+// table of a list.
+// tableModifiers 是表名之后、列表中下一张表之前可能出现的单词，其后可带括号列表。
+var tableModifiers = map[string]bool{"PARTITION": true, "PARTITIONS": true, "TEMPORARY": true, "TABLET": true}
+
+// Statement is what Classify learns of a statement: its type and the tables, table functions, databases and catalogs
+// it refers to.
+// Statement 是Classify对语句的分类结果：语句类型以及其引用的表、表函数、数据库与目录。
+type Statement struct {
+	// Type 大写的语句类型，即语句的首个关键字，例如 "SELECT", "WITH", "EXPLAIN", "INSERT", "SET"。带括号的查询为
+	// "SELECT"，CTE之后不是查询时为其后的关键字，写入文件的查询为 "SELECT INTO"。
+	// Type Upper-case statement type, the leading keyword of the statement, e.g., "SELECT", "WITH", "EXPLAIN",
+	// "INSERT", "SET". A parenthesized query is a "SELECT", CTEs followed by something other than a query take the
+	// type of what follows them and a query writing to a file is a "SELECT INTO".
+	Type string
+	// Explained EXPLAIN语句所解释的语句，其他语句为nil。
+	// Explained The statement an EXPLAIN explains, nil for other statements.
+	Explained *Statement
+	// Tables 语句在任意位置引用的表，包括子查询中的，但不包括对CTE的引用。
+	// Tables Tables the statement refers to anywhere, subqueries included, references to CTEs excluded.
+	Tables []TableRef
+	// TableFunctions 语句在FROM或JOIN中读取的表函数名，例如FILES或TABLE(...)中的函数，不含参数与限定符。
+	// TableFunctions Names of the table functions the statement reads from in a FROM or JOIN, e.g., FILES or the
+	// function inside TABLE(...), without arguments or qualifiers.
+	TableFunctions []string
+	// Databases 语句直接操作的数据库，例如USE或DROP DATABASE的对象，查询为空。
+	// Databases Databases the statement acts on directly, e.g., the subject of USE or DROP DATABASE, empty for
+	// queries.
+	Databases []string
+	// Catalogs 语句直接切换到的目录，例如 "USE catalog.db" 或 "SET CATALOG catalog" 的对象。
+	// Catalogs Catalogs the statement switches to directly, e.g., the subject of "USE catalog.db" or
+	// "SET CATALOG catalog".
+	Catalogs []string
+	// SQL 语句的文本，不含语句结束符。
+	// SQL Text of the statement, without its terminator.
+	SQL string
+}
+
+// Classify splits a script into its statements and classifies each of them. Empty statements are skipped. Scripts
+// with optimizer hints or executable comments are rejected, since what they do is not part of the classification.
+// Classify 将脚本拆分为语句并对每条语句分类。空语句被跳过。包含优化器提示或可执行注释的脚本被拒绝，因为其作用不在
+// 分类范围内。
+func Classify(sql string) ([]*Statement, error) {
+	tokens, err := Tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if t.Kind == TokenComment {
+			return nil, errors.Newf(errors.InvalidArgument, "optimizer hints and executable comments are not allowed, got %s at position %d", t.Text, t.Pos)
+		}
+	}
+	var statements []*Statement
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && tokens[i].Text != ";" {
+			continue
+		}
+		if i > start {
+			first, last := tokens[start], tokens[i-1]
+			s := classifyTokens(tokens[start:i])
+			s.SQL = sql[first.Pos : last.Pos+len(last.Text)]
+			statements = append(statements, s)
+		}
+		start = i + 1
+	}
+	if len(statements) == 0 {
+		return nil, errors.New(errors.InvalidArgument, "statement is empty")
+	}
+	return statements, nil
+}
+
+func classifyTokens(tokens []Token) *Statement {
+	s := &Statement{Type: strings.ToUpper(tokens[0].Text)}
+	switch {
+	case tokens[0].Text == "(":
+		s.Type = "SELECT"
+	case tokens[0].Is("WITH"):
+		if main := skipCTEs(tokens, 0); main < len(tokens) && !tokens[main].Is("SELECT") && tokens[main].Text != "(" {
+			s.Type = strings.ToUpper(tokens[main].Text)
+		}
+	case tokens[0].Is("EXPLAIN"):
+		i := 1
+		for i < len(tokens) && explainOptions[strings.ToUpper(tokens[i].Text)] {
+			i++
+		}
+		if i < len(tokens) {
+			s.Explained = classifyTokens(tokens[i:])
+			s.Tables, s.TableFunctions = s.Explained.Tables, s.Explained.TableFunctions
+			s.Databases, s.Catalogs = s.Explained.Databases, s.Explained.Catalogs
+		}
+		return s
+	case tokens[0].Is("DESC"), tokens[0].Is("DESCRIBE"):
+		if ref, end := tableName(tokens, 1); end > 1 {
+			s.Tables = append(s.Tables, ref)
+		}
+	case tokens[0].Is("USE"), tokens[0].Is("SET"):
+		i := 1
+		if i < len(tokens) && tokens[i].Is("CATALOG") {
+			if ref, end := tableName(tokens, i+1); end > i+1 {
+				s.Catalogs = append(s.Catalogs, ref.Table)
+			}
+		} else if ref, end := tableName(tokens, i); end > i && tokens[0].Is("USE") {
+			s.Databases = append(s.Databases, ref.Table)
+			if ref.Database != "" {
+				s.Catalogs = append(s.Catalogs, ref.Database)
+			}
+		}
+	}
+	if (s.Type == "SELECT" || s.Type == "WITH") && hasTopLevel(tokens, "INTO") {
+		s.Type = "SELECT INTO"
+	}
+	tables, functions := referencedTables(tokens)
+	s.Tables = append(s.Tables, tables...)
+	s.TableFunctions = functions
+	if s.Type == "SELECT" || s.Type == "WITH" || s.Type == "SELECT INTO" {
+		return s
+	}
+	for i, t := range tokens {
+		if t.Is("DATABASE") || t.Is("SCHEMA") {
+			if ref, end := tableName(tokens, skipIfExists(tokens, i+1)); end > 0 {
+				s.Databases = append(s.Databases, ref.Table)
+			}
+		}
+	}
+	return s
+}
+
+// cte is a common table expression, visible from the end of its definition to the end of its scope.
+// cte 是公用表表达式，从其定义结束处到其作用域结束处可见。
+type cte struct {
+	name     string
+	bodyEnd  int
+	scopeEnd int
+}
+
+// referencedTables finds the tables named after FROM, JOIN, INTO and the like, skipping references to CTEs and
+// the FROM of functions such as EXTRACT(... FROM ...), and the table functions read from in a FROM or JOIN.
+// referencedTables 查找FROM、JOIN、INTO等之后的表名，跳过对CTE的引用以及EXTRACT(... FROM ...)等函数中的FROM，
+// 并查找FROM或JOIN中读取的表函数。
+func referencedTables(tokens []Token) ([]TableRef, []string) {
+	var ctes []cte
+	var refs []TableRef
+	var functions []string
+	var positions []int
+	var open []int // 未闭合的 "(" 的位置 Positions of the unclosed "("
+	for i, t := range tokens {
+		switch {
+		case t.Text == "(":
+			open = append(open, i)
+			continue
+		case t.Text == ")":
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+			continue
+		case t.Kind != TokenIdentifier:
+			continue
+		case t.Is("WITH"):
+			scopeEnd := len(tokens)
+			if len(open) > 0 {
+				if end := matchingParen(tokens, open[len(open)-1]); end > 0 {
+					scopeEnd = end
+				}
+			}
+			ctes = append(ctes, defineCTEs(tokens, i, scopeEnd)...)
+			continue
+		case !tableIntroducers[strings.ToUpper(t.Text)]:
+			continue
+		case t.Is("INTO") && i+1 < len(tokens) && tokens[i+1].Is("OUTFILE"),
+			t.Is("TABLE") && i+1 < len(tokens) && tokens[i+1].Text == "(":
+			continue
+		case (t.Is("FROM") || t.Is("JOIN")) && len(open) > 0 && isFunctionArguments(tokens, open[len(open)-1]):
+			continue
+		}
+
+		fromList := t.Is("FROM") || t.Is("JOIN")
+		for j := skipIfExists(tokens, i+1); j < len(tokens); j++ {
+			switch {
+			case tokens[j].Text == "(":
+				// A derived table or a parenthesized join. The first table of a join is read here, the other
+				// tables are found on the way.
+				if k := j + 1; fromList && k < len(tokens) && !tokens[k].Is("SELECT") && !tokens[k].Is("WITH") {
+					if ref, end := tableName(tokens, k); end > 0 && (end >= len(tokens) || tokens[end].Text != "(") {
+						refs, positions = append(refs, ref), append(positions, k)
+					}
+				}
+				if j = matchingParen(tokens, j); j < 0 {
+					j = len(tokens)
+				}
+			case fromList && tokens[j].Is("TABLE") && j+1 < len(tokens) && tokens[j+1].Text == "(":
+				name := tokens[j].Value
+				if ref, end := tableName(tokens, j+2); end > 0 && end < len(tokens) && tokens[end].Text == "(" {
+					name = ref.Table
+				}
+				functions = append(functions, name)
+				if j = matchingParen(tokens, j+1); j < 0 {
+					j = len(tokens)
+				}
+			default:
+				ref, end := tableName(tokens, j)
+				if end < 0 {
+					j = len(tokens)
+					break
+				}
+				if fromList && end < len(tokens) && tokens[end].Text == "(" {
+					// A table function.
+					functions = append(functions, ref.Table)
+					if end = matchingParen(tokens, end); end < 0 {
+						end = len(tokens) - 1
+					}
+					end++
+				} else {
+					refs, positions = append(refs, ref), append(positions, j)
+				}
+				j = end - 1
+			}
+			if j >= len(tokens) {
+				break
+			}
+			j = skipTableSuffix(tokens, j+1)
+			if j >= len(tokens) || tokens[j].Text != "," {
+				break
+			}
+		}
+	}
+
+	var tables []TableRef
+	for k, ref := range refs {
+		if !refersToCTE(ref, positions[k], ctes) {
+			tables = append(tables, ref)
+		}
+	}
+	return tables, functions
+}
+
+// defineCTEs reads the CTEs of the WITH clause at i.
+// defineCTEs 读取位置i处WITH子句中的CTE。
+func defineCTEs(tokens []Token, i, scopeEnd int) []cte {
+	var ctes []cte
+	j := i + 1
+	if j < len(tokens) && tokens[j].Is("RECURSIVE") {
+		j++
+	}
+	for j < len(tokens) && (tokens[j].Kind == TokenIdentifier || tokens[j].Kind == TokenQuotedIdentifier) {
+		name := tokens[j].Value
+		j++
+		if j < len(tokens) && tokens[j].Text == "(" {
+			if j = matchingParen(tokens, j); j < 0 {
+				break
+			}
+			j++
+		}
+		if j+1 >= len(tokens) || !tokens[j].Is("AS") || tokens[j+1].Text != "(" {
+			break
+		}
+		end := matchingParen(tokens, j+1)
+		if end < 0 {
+			break
+		}
+		ctes = append(ctes, cte{name: name, bodyEnd: end, scopeEnd: scopeEnd})
+		j = end + 1
+		if j >= len(tokens) || tokens[j].Text != "," {
+			break
+		}
+		j++
+	}
+	return ctes
+}
+
+// skipCTEs returns the position of the statement following the WITH clause at i.
+// skipCTEs 返回位置i处WITH子句之后的语句的位置。
+func skipCTEs(tokens []Token, i int) int {
+	ctes := defineCTEs(tokens, i, len(tokens))
+	if len(ctes) == 0 {
+		return len(tokens)
+	}
+	return ctes[len(ctes)-1].bodyEnd + 1
+}
+
+// refersToCTE reports whether the table reference at position i names a CTE visible there. A CTE is not visible
+// in its own definition, where the name still refers to the table.
+// refersToCTE 报告位置i处的表引用是否指向在该处可见的CTE。CTE在其自身定义中不可见，此时名称仍指向表。
+func refersToCTE(ref TableRef, i int, ctes []cte) bool {
+	if ref.Database != "" {
+		return false
+	}
+	for _, c := range ctes {
+		if strings.EqualFold(ref.Table, c.name) && i > c.bodyEnd && i < c.scopeEnd {
+			return true
+		}
+	}
+	return false
+}
+
+// tableName reads a possibly qualified table name at i, returning the position after it, or -1 when there is none.
+// tableName 读取位置i处可能带限定符的表名，返回其后的位置，不存在表名时返回-1。
+func tableName(tokens []Token, i int) (TableRef, int) {
+	if i >= len(tokens) || !isAliasToken(tokens[i]) {
+		return TableRef{}, -1
+	}
+	parts := []string{tokens[i].Value}
+	end := i + 1
+	for end+1 < len(tokens) && tokens[end].Text == "." && (tokens[end+1].Kind == TokenIdentifier || tokens[end+1].Kind == TokenQuotedIdentifier) {
+		parts = append(parts, tokens[end+1].Value)
+		end += 2
+	}
+	ref := TableRef{Table: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		ref.Database = parts[len(parts)-2]
+	}
+	if len(parts) > 2 {
+		ref.Catalog = parts[len(parts)-3]
+	}
+	return ref, end
+}
+
+// skipTableSuffix skips the alias and modifiers such as PARTITION (...) following a table at i.
+// skipTableSuffix 跳过位置i处表之后的别名以及PARTITION (...)等修饰。
+func skipTableSuffix(tokens []Token, i int) int {
+	for i < len(tokens) {
+		t := tokens[i]
+		switch {
+		case t.Is("AS"):
+			i += 2
+		case tableModifiers[strings.ToUpper(t.Text)]:
+			i++
+			for i < len(tokens) && tableModifiers[strings.ToUpper(tokens[i].Text)] {
+				i++
+			}
+			if i < len(tokens) && tokens[i].Text == "(" {
+				if i = matchingParen(tokens, i); i < 0 {
+					return len(tokens)
+				}
+				i++
+			}
+		case isAliasToken(t):
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func skipIfExists(tokens []Token, i int) int {
+	if i < len(tokens) && tokens[i].Is("IF") {
+		i++
+		if i < len(tokens) && tokens[i].Is("NOT") {
+			i++
+		}
+		if i < len(tokens) && tokens[i].Is("EXISTS") {
+			i++
+		}
+	}
+	return i
+}
+
+// isFunctionArguments reports whether the "(" at open encloses the arguments of a function call rather than a
+// subquery or a parenthesized join.
+// isFunctionArguments 报告open处的 "(" 包围的是函数调用的参数，而不是子查询或带括号的连接。
+func isFunctionArguments(tokens []Token, open int) bool {
+	if open == 0 || tokens[open-1].Kind != TokenIdentifier || IsKeyword(tokens[open-1].Text) {
+		return false
+	}
+	return open+1 < len(tokens) && !tokens[open+1].Is("SELECT") && !tokens[open+1].Is("WITH")
+}
+
+// hasTopLevel reports whether word appears outside brackets.
+// hasTopLevel 报告word是否出现在括号之外。
+func hasTopLevel(tokens []Token, word string) bool {
+	depth := 0
+	for _, t := range tokens {
+		switch {
+		case t.Text == "(":
+			depth++
+		case t.Text == ")":
+			depth--
+		case depth == 0 && t.Is(word):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		types     []string
+		tables    []TableRef // 第一条语句引用的表 Tables of the first statement
+		functions []string   // 第一条语句读取的表函数 Table functions of the first statement
+		databases []string   // 第一条语句操作的数据库 Databases of the first statement
+		catalogs  []string   // 第一条语句切换到的目录 Catalogs of the first statement
+		wantErr   bool
+	}{
+		{
+			name:   "select with joins and a subquery",
+			sql:    "SELECT * FROM db.a x JOIN b ON x.id = b.id WHERE x.id IN (SELECT id FROM c)",
+			types:  []string{"SELECT"},
+			tables: []TableRef{{Database: "db", Table: "a"}, {Table: "b"}, {Table: "c"}},
+		},
+		{
+			name:   "comma separated tables with partitions",
+			sql:    "SELECT * FROM a PARTITION (p1), `db`.`b` AS y",
+			types:  []string{"SELECT"},
+			tables: []TableRef{{Table: "a"}, {Database: "db", Table: "b"}},
+		},
+		{
+			name:   "ctes are not tables",
+			sql:    "WITH recent AS (SELECT * FROM events) SELECT * FROM recent JOIN hosts USING (host)",
+			types:  []string{"WITH"},
+			tables: []TableRef{{Table: "events"}, {Table: "hosts"}},
+		},
+		{
+			name:   "functions using FROM are not tables",
+			sql:    "SELECT EXTRACT(YEAR FROM ts), TRIM(BOTH 'x' FROM s) FROM t",
+			types:  []string{"SELECT"},
+			tables: []TableRef{{Table: "t"}},
+		},
+		{
+			name:      "table functions are not tables",
+			sql:       "SELECT * FROM TABLE(generate_series(1, 3)), unnest_me(1)",
+			types:     []string{"SELECT"},
+			tables:    nil,
+			functions: []string{"generate_series", "unnest_me"},
+		},
+		{
+			name:      "files table function joined with a table",
+			sql:       "SELECT * FROM t JOIN FILES('path' = 's3://bucket/x.parquet', 'format' = 'parquet') f ON t.id = f.id",
+			types:     []string{"SELECT"},
+			tables:    []TableRef{{Table: "t"}},
+			functions: []string{"FILES"},
+		},
+		{
+			name:   "table of another catalog",
+			sql:    "SELECT * FROM hive_prod.sales.orders",
+			types:  []string{"SELECT"},
+			tables: []TableRef{{Catalog: "hive_prod", Database: "sales", Table: "orders"}},
+		},
+		{
+			name:   "parenthesized query",
+			sql:    "(SELECT 1)",
+			types:  []string{"SELECT"},
+			tables: nil,
+		},
+		{
+			name:   "select into outfile",
+			sql:    "SELECT * FROM t INTO OUTFILE 's3://bucket/x'",
+			types:  []string{"SELECT INTO"},
+			tables: []TableRef{{Table: "t"}},
+		},
+		{
+			name:   "insert after ctes",
+			sql:    "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x",
+			types:  []string{"INSERT"},
+			tables: []TableRef{{Table: "t"}},
+		},
+		{
+			name:   "explain",
+			sql:    "EXPLAIN VERBOSE DELETE FROM t WHERE id = 1",
+			types:  []string{"EXPLAIN"},
+			tables: []TableRef{{Table: "t"}},
+		},
+		{
+			name:      "drop database",
+			sql:       "DROP DATABASE IF EXISTS Sales",
+			types:     []string{"DROP"},
+			databases: []string{"Sales"},
+		},
+		{
+			name:      "use",
+			sql:       "use analytics",
+			types:     []string{"USE"},
+			databases: []string{"analytics"},
+		},
+		{
+			name:      "use a database of another catalog",
+			sql:       "USE hive_prod.sales",
+			types:     []string{"USE"},
+			databases: []string{"sales"},
+			catalogs:  []string{"hive_prod"},
+		},
+		{
+			name:     "set catalog",
+			sql:      "SET CATALOG hive_prod",
+			types:    []string{"SET"},
+			catalogs: []string{"hive_prod"},
+		},
+		{
+			name:   "script",
+			sql:    "SELECT 1;; DROP TABLE t; -- done",
+			types:  []string{"SELECT", "DROP"},
+			tables: nil,
+		},
+		{name: "empty", sql: " ; -- nothing", wantErr: true},
+		{name: "optimizer hint", sql: "SELECT /*+ SET_VAR(query_timeout=100000) */ * FROM t", wantErr: true},
+		{name: "executable comment", sql: "SELECT 1 /*! ; DROP TABLE t */", wantErr: true},
+		{name: "unterminated string", sql: "SELECT 'x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := Classify(tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Classify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var types []string
+			for _, s := range statements {
+				types = append(types, s.Type)
+			}
+			if !reflect.DeepEqual(types, tt.types) {
+				t.Errorf("Classify() types = %v, want %v", types, tt.types)
+			}
+			if got := statements[0].Tables; !reflect.DeepEqual(got, tt.tables) {
+				t.Errorf("Classify() tables = %+v, want %+v", got, tt.tables)
+			}
+			if got := statements[0].TableFunctions; !reflect.DeepEqual(got, tt.functions) {
+				t.Errorf("Classify() table functions = %v, want %v", got, tt.functions)
+			}
+			if got := statements[0].Databases; !reflect.DeepEqual(got, tt.databases) {
+				t.Errorf("Classify() databases = %v, want %v", got, tt.databases)
+			}
+			if got := statements[0].Catalogs; !reflect.DeepEqual(got, tt.catalogs) {
+				t.Errorf("Classify() catalogs = %v, want %v", got, tt.catalogs)
+			}
+		})
+	}
+}
+
+func TestClassifyStatementText(t *testing.T) {
+	statements, err := Classify("  SELECT 1 ;\nDELETE FROM t WHERE a = ';'  ")
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	want := []string{"SELECT 1", "DELETE FROM t WHERE a = ';'"}
+	for i, s := range statements {
+		if i >= len(want) || s.SQL != want[i] {
+			t.Errorf("statement %d SQL = %q, want %q", i, s.SQL, want)
+		}
+	}
+	if len(statements) != len(want) {
+		t.Errorf("Classify() returned %d statements, want %d", len(statements), len(want))
+	}
+}
@@ -0,0 +1,217 @@
+package sqlparse
+
+import (
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// TokenKind classifies a token of a SQL statement.
+// TokenKind 对SQL语句中的词法单元进行分类。
+type TokenKind int
+
+const (
+	// TokenIdentifier is a keyword, function name or unquoted identifier.
+	// TokenIdentifier 关键字、函数名或未加引号的标识符。
+	TokenIdentifier TokenKind = iota
+	// TokenQuotedIdentifier is an identifier quoted with backticks.
+	// TokenQuotedIdentifier 用反引号括起的标识符。
+	TokenQuotedIdentifier
+	// TokenString is a string literal quoted with single or double quotes.
+	// TokenString 用单引号或双引号括起的字符串字面量。
+	TokenString
+	// TokenNumber is a numeric literal.
+	// TokenNumber 数值字面量。
+	TokenNumber
+	// TokenPlaceholder is a "?" or ":name" parameter placeholder.
+	// TokenPlaceholder "?" 或 ":name" 形式的参数占位符。
+	TokenPlaceholder
+	// TokenOperator is an operator such as "=", "<=" or "||".
+	// TokenOperator 运算符，例如 "="、"<=" 或 "||"。
+	TokenOperator
+	// TokenPunctuation is one of "(", ")", ",", "." and ";".
+	// TokenPunctuation "("、")"、","、"." 与 ";" 之一。
+	TokenPunctuation
+	// TokenComment is a "/*+ */" optimizer hint or a "/*! */" executable comment, which StarRocks does not ignore.
+	// TokenComment "/*+ */" 优化器提示或 "/*! */" 可执行注释，StarRocks不会忽略它们。
+	TokenComment
+)
+
+// Token is a lexical token of a SQL statement. Whitespace and plain comments are not tokens.
+// Token 是SQL语句中的一个词法单元，空白与普通注释不属于词法单元。
+type Token struct {
+	Kind TokenKind
+	// Text 词法单元在语句中的原始文本。
+	// Text The token as written in the statement.
+	Text string
+	// Value 去掉引号与转义后的值，用于字符串与带引号的标识符，其余为Text。
+	// Value The value without quotes and escapes for strings and quoted identifiers, Text otherwise.
+	Value string
+	// Pos 词法单元在语句中的字节偏移。
+	// Pos Byte offset of the token in the statement.
+	Pos int
+}
+
+// Is reports whether the token is the keyword or identifier word, compared case-insensitively.
+// Is 报告词法单元是否为关键字或标识符word（不区分大小写）。
+func (t Token) Is(word string) bool {
+	return t.Kind == TokenIdentifier && strings.EqualFold(t.Text, word)
+}
+
+// IsLiteral reports whether the token is a string, number or placeholder.
+// IsLiteral 报告词法单元是否为字符串、数值或占位符。
+func (t Token) IsLiteral() bool {
+	return t.Kind == TokenString || t.Kind == TokenNumber || t.Kind == TokenPlaceholder
+}
+
+// multiCharOperators are the operators longer than one character, longest first.
+// multiCharOperators 是长度超过一个字符的运算符，按长度从长到短排列。
+var multiCharOperators = []string{"<=>", "<=", ">=", "<>", "!=", "||", "&&", "<<", ">>", "->"}
+
+// Tokenize splits a SQL statement into tokens, dropping whitespace and "--", "#" and "/* */" comments. Optimizer
+// hints and executable comments are kept as TokenComment tokens.
+// Tokenize 将SQL语句拆分为词法单元，丢弃空白以及 "--"、"#" 与 "/* */" 注释。优化器提示与可执行注释保留为
+// TokenComment词法单元。
+func Tokenize(sql string) ([]Token, error) {
+	var tokens []Token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "--")):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end + 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.Newf(errors.InvalidArgument, "unterminated comment at position %d", i)
+			}
+			if strings.HasPrefix(sql[i:], "/*+") || strings.HasPrefix(sql[i:], "/*!") {
+				tokens = append(tokens, Token{Kind: TokenComment, Text: sql[i : i+end+4], Value: sql[i+3 : i+end+2], Pos: i})
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			value, n, ok := scanQuoted(sql[i:])
+			if !ok {
+				return nil, errors.Newf(errors.InvalidArgument, "unterminated quoted text at position %d", i)
+			}
+			kind := TokenString
+			if c == '`' {
+				kind = TokenQuotedIdentifier
+			}
+			tokens = append(tokens, Token{Kind: kind, Text: sql[i : i+n], Value: value, Pos: i})
+			i += n
+		case isDigit(c) || (c == '.' && i+1 < len(sql) && isDigit(sql[i+1])):
+			n := scanNumber(sql[i:])
+			tokens = append(tokens, Token{Kind: TokenNumber, Text: sql[i : i+n], Value: sql[i : i+n], Pos: i})
+			i += n
+		case isIdentifierStart(c):
+			n := 1
+			for i+n < len(sql) && isIdentifierPart(sql[i+n]) {
+				n++
+			}
+			tokens = append(tokens, Token{Kind: TokenIdentifier, Text: sql[i : i+n], Value: sql[i : i+n], Pos: i})
+			i += n
+		case c == '?':
+			tokens = append(tokens, Token{Kind: TokenPlaceholder, Text: "?", Value: "?", Pos: i})
+			i++
+		case c == ':' && i+1 < len(sql) && isIdentifierStart(sql[i+1]):
+			n := 2
+			for i+n < len(sql) && isIdentifierPart(sql[i+n]) {
+				n++
+			}
+			tokens = append(tokens, Token{Kind: TokenPlaceholder, Text: sql[i : i+n], Value: sql[i+1 : i+n], Pos: i})
+			i += n
+		case strings.IndexByte("(),.;", c) >= 0:
+			tokens = append(tokens, Token{Kind: TokenPunctuation, Text: sql[i : i+1], Value: sql[i : i+1], Pos: i})
+			i++
+		default:
+			n := 1
+			for _, op := range multiCharOperators {
+				if strings.HasPrefix(sql[i:], op) {
+					n = len(op)
+					break
+				}
+			}
+			tokens = append(tokens, Token{Kind: TokenOperator, Text: sql[i : i+n], Value: sql[i : i+n], Pos: i})
+			i += n
+		}
+	}
+	return tokens, nil
+}
+
+// scanQuoted reads the quoted text at the start of s, returning its unescaped value and length. A quote is
+// escaped by a backslash or by doubling it.
+// scanQuoted 读取s开头的引号文本，返回去转义后的值及其长度。引号可以用反斜杠或重复自身来转义。
+func scanQuoted(s string) (string, int, bool) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && quote != '`' && i+1 < len(s):
+			i++
+			sb.WriteByte(unescapeByte(s[i]))
+		case c == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			sb.WriteByte(quote)
+		case c == quote:
+			return sb.String(), i + 1, true
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+func unescapeByte(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	default:
+		return c
+	}
+}
+
+func scanNumber(s string) int {
+	n := 0
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n = 2
+		for n < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[n]) >= 0 {
+			n++
+		}
+		return n
+	}
+	for n < len(s) && (isDigit(s[n]) || s[n] == '.') {
+		n++
+	}
+	if n < len(s) && (s[n] == 'e' || s[n] == 'E') {
+		m := n + 1
+		if m < len(s) && (s[m] == '+' || s[m] == '-') {
+			m++
+		}
+		if m < len(s) && isDigit(s[m]) {
+			for n = m; n < len(s) && isDigit(s[n]); n++ {
+			}
+		}
+	}
+	return n
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '@' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c >= 0x80
+}
+
+func isIdentifierPart(c byte) bool { return isIdentifierStart(c) || isDigit(c) }
@@ -0,0 +1,82 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    []Token
+		wantErr bool
+	}{
+		{
+			name: "keywords, identifiers and numbers",
+			sql:  "SELECT a1, 1.5e3 FROM t",
+			want: []Token{
+				{Kind: TokenIdentifier, Text: "SELECT", Value: "SELECT", Pos: 0},
+				{Kind: TokenIdentifier, Text: "a1", Value: "a1", Pos: 7},
+				{Kind: TokenPunctuation, Text: ",", Value: ",", Pos: 9},
+				{Kind: TokenNumber, Text: "1.5e3", Value: "1.5e3", Pos: 11},
+				{Kind: TokenIdentifier, Text: "FROM", Value: "FROM", Pos: 17},
+				{Kind: TokenIdentifier, Text: "t", Value: "t", Pos: 22},
+			},
+		},
+		{
+			name: "quoted text",
+			sql:  `'it''s' "a\"b" ` + "`x``y`",
+			want: []Token{
+				{Kind: TokenString, Text: `'it''s'`, Value: "it's", Pos: 0},
+				{Kind: TokenString, Text: `"a\"b"`, Value: `a"b`, Pos: 8},
+				{Kind: TokenQuotedIdentifier, Text: "`x``y`", Value: "x`y", Pos: 15},
+			},
+		},
+		{
+			name: "operators and placeholders",
+			sql:  "a<=>?||:name!=0x1F",
+			want: []Token{
+				{Kind: TokenIdentifier, Text: "a", Value: "a", Pos: 0},
+				{Kind: TokenOperator, Text: "<=>", Value: "<=>", Pos: 1},
+				{Kind: TokenPlaceholder, Text: "?", Value: "?", Pos: 4},
+				{Kind: TokenOperator, Text: "||", Value: "||", Pos: 5},
+				{Kind: TokenPlaceholder, Text: ":name", Value: "name", Pos: 7},
+				{Kind: TokenOperator, Text: "!=", Value: "!=", Pos: 12},
+				{Kind: TokenNumber, Text: "0x1F", Value: "0x1F", Pos: 14},
+			},
+		},
+		{
+			name: "plain comments are dropped",
+			sql:  "a -- x\n# y\n/* z */ b -- end",
+			want: []Token{
+				{Kind: TokenIdentifier, Text: "a", Value: "a", Pos: 0},
+				{Kind: TokenIdentifier, Text: "b", Value: "b", Pos: 19},
+			},
+		},
+		{
+			name: "optimizer hints and executable comments are kept",
+			sql:  "SELECT /*+ SET_VAR(query_timeout=1) */ 1 /*!50000 DROP */",
+			want: []Token{
+				{Kind: TokenIdentifier, Text: "SELECT", Value: "SELECT", Pos: 0},
+				{Kind: TokenComment, Text: "/*+ SET_VAR(query_timeout=1) */", Value: " SET_VAR(query_timeout=1) ", Pos: 7},
+				{Kind: TokenNumber, Text: "1", Value: "1", Pos: 39},
+				{Kind: TokenComment, Text: "/*!50000 DROP */", Value: "50000 DROP ", Pos: 41},
+			},
+		},
+		{name: "unterminated comment", sql: "SELECT 1 /* x", wantErr: true},
+		{name: "unterminated string", sql: "SELECT 'x", wantErr: true},
+		{name: "unterminated identifier", sql: "SELECT `x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tokenize(tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Tokenize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
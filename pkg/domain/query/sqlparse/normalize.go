@@ -0,0 +1,142 @@
+package sqlparse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// keywords are the SQL keywords that are upper-cased when a statement is normalized. Other words are identifiers
+// and are lower-cased, except for function names, which are upper-cased too.
+// keywords 是规范化语句时转为大写的SQL关键字。其他单词视为标识符并转为小写，函数名除外，函数名同样转为大写。
+var keywords = map[string]bool{
+	"ALL": true, "ALTER": true, "AND": true, "ANTI": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true,
+	"CASE": true, "CREATE": true, "CROSS": true, "DELETE": true, "DESC": true, "DISTINCT": true, "DROP": true,
+	"ELSE": true, "END": true, "EXCEPT": true, "EXISTS": true, "EXPLAIN": true, "FALSE": true, "FROM": true,
+	"FULL": true, "GROUP": true, "HAVING": true, "IN": true, "INNER": true, "INSERT": true, "INTERSECT": true,
+	"INTERVAL": true, "INTO": true, "IS": true, "JOIN": true, "LATERAL": true, "LEFT": true, "LIKE": true,
+	"LIMIT": true, "MATCH_ALL": true, "MATCH_ANY": true, "MINUS": true, "NOT": true, "NULL": true, "OFFSET": true,
+	"ON": true, "OR": true, "ORDER": true, "OUTER": true, "OVER": true, "PARTITION": true, "REGEXP": true,
+	"RIGHT": true, "RLIKE": true, "SELECT": true, "SEMI": true, "SET": true, "SHOW": true, "TABLE": true,
+	"THEN": true, "TRUE": true, "TRUNCATE": true, "UNION": true, "UPDATE": true, "USING": true, "VALUES": true,
+	"WHEN": true, "WHERE": true, "WITH": true,
+}
+
+// IsKeyword reports whether word is a SQL keyword, compared case-insensitively.
+// IsKeyword 报告word是否为SQL关键字（不区分大小写）。
+func IsKeyword(word string) bool {
+	return keywords[strings.ToUpper(word)]
+}
+
+// Normalize rewrites a statement into the canonical form queries differing only in literals, letter case,
+// whitespace and comments share: literals become "?", "IN (?, ?, ...)" lists collapse to "IN (?)", keywords and
+// function names are upper-cased and identifiers lower-cased without their quotes.
+// Normalize 将语句改写为规范形式，仅在字面量、大小写、空白与注释上不同的查询具有相同的规范形式：字面量替换为 "?"，
+// "IN (?, ?, ...)" 列表折叠为 "IN (?)"，关键字与函数名转为大写，标识符转为小写并去掉引号。
+func Normalize(sql string) (string, error) {
+	tokens, err := Tokenize(sql)
+	if err != nil {
+		return "", err
+	}
+	return Render(NormalizeTokens(tokens)), nil
+}
+
+// NormalizeTokens applies the rewrites of Normalize to tokens.
+// NormalizeTokens 对词法单元应用Normalize的改写规则。
+func NormalizeTokens(tokens []Token) []Token {
+	// A trailing statement terminator is not part of the statement.
+	for len(tokens) > 0 && tokens[len(tokens)-1].Text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	out := make([]Token, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.Kind {
+		case TokenComment:
+			continue
+		case TokenString, TokenNumber, TokenPlaceholder:
+			t = Token{Kind: TokenPlaceholder, Text: "?", Value: "?", Pos: t.Pos}
+			// A sign directly after an operator or opening bracket belongs to the number: "= -1" is "= ?".
+			if n := len(out); n > 1 && (out[n-1].Text == "-" || out[n-1].Text == "+") && !isOperand(out[n-2]) {
+				out = out[:n-1]
+			}
+		case TokenQuotedIdentifier:
+			t.Text, t.Kind = strings.ToLower(t.Value), TokenIdentifier
+		case TokenIdentifier:
+			if keywords[strings.ToUpper(t.Text)] || (i+1 < len(tokens) && tokens[i+1].Text == "(") {
+				t.Text = strings.ToUpper(t.Text)
+			} else {
+				t.Text = strings.ToLower(t.Text)
+			}
+		}
+		out = append(out, t)
+
+		// Collapse "IN (?, ?, ...)" to "IN (?)".
+		if n := len(out); t.Text == "?" && n >= 3 && out[n-2].Text == "(" && out[n-3].Is("IN") {
+			j := i + 1
+			for j+1 < len(tokens) && tokens[j].Text == "," && tokens[j+1].IsLiteral() {
+				j += 2
+			}
+			i = j - 1
+		}
+	}
+	return out
+}
+
+// isOperand reports whether a token ends an operand, after which "-" and "+" are binary operators.
+// isOperand 报告词法单元是否结束一个操作数，其后的 "-" 与 "+" 为二元运算符。
+func isOperand(t Token) bool {
+	switch t.Kind {
+	case TokenString, TokenNumber, TokenPlaceholder, TokenQuotedIdentifier:
+		return true
+	case TokenIdentifier:
+		return !keywords[strings.ToUpper(t.Text)] || t.Is("NULL") || t.Is("TRUE") || t.Is("FALSE") || t.Is("END")
+	case TokenPunctuation:
+		return t.Text == ")"
+	}
+	return false
+}
+
+// Fingerprint returns a short stable identifier of the normalized form of a statement.
+// Fingerprint 返回语句规范形式的简短稳定标识。
+func Fingerprint(sql string) (fingerprint, normalized string, err error) {
+	normalized, err = Normalize(sql)
+	if err != nil {
+		return "", "", err
+	}
+	return FingerprintNormalized(normalized), normalized, nil
+}
+
+// FingerprintNormalized returns the fingerprint of a statement that is already normalized.
+// FingerprintNormalized 返回已规范化语句的指纹。
+func FingerprintNormalized(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Render joins tokens back into SQL text, with a single space between tokens except around "." and inside
+// brackets.
+// Render 将词法单元重新拼接为SQL文本，词法单元之间以单个空格分隔，"." 两侧与括号内侧除外。
+func Render(tokens []Token) string {
+	var sb strings.Builder
+	for i, t := range tokens {
+		if i > 0 && needsSpace(tokens[i-1], t) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(t.Text)
+	}
+	return sb.String()
+}
+
+func needsSpace(prev, next Token) bool {
+	switch {
+	case next.Text == "," || next.Text == ")" || next.Text == "." || next.Text == ";":
+		return false
+	case prev.Text == "(" || prev.Text == ".":
+		return false
+	case next.Text == "(":
+		// A function name stays attached to its arguments; keywords such as IN and FROM are followed by a space.
+		return prev.Kind != TokenIdentifier || keywords[strings.ToUpper(prev.Text)]
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package sqlparse
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "literals become placeholders",
+			sql:  "SELECT * FROM logs WHERE host = 'web-1' AND status >= 500 AND ratio < -0.5",
+			want: "SELECT * FROM logs WHERE host = ? AND status >= ? AND ratio < ?",
+		},
+		{
+			name: "binary minus is kept",
+			sql:  "SELECT a - 1, (b)-2 FROM t",
+			want: "SELECT a - ?, (b) - ? FROM t",
+		},
+		{
+			name: "in lists collapse",
+			sql:  "SELECT id FROM t WHERE id IN (1, 2, 3) AND code NOT IN ('a','b')",
+			want: "SELECT id FROM t WHERE id IN (?) AND code NOT IN (?)",
+		},
+		{
+			name: "case, quotes, whitespace and comments",
+			sql:  "select  Count(*) from `Logs`\n-- recent\nwhere `Host`=?;",
+			want: "SELECT COUNT(*) FROM logs WHERE host = ?",
+		},
+		{
+			name: "function names are upper-cased",
+			sql:  "select date_trunc('day', ts), k from t group by 1, 2",
+			want: "SELECT DATE_TRUNC(?, ts), k FROM t GROUP BY ?, ?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.sql)
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := "SELECT host, COUNT(*) FROM logs WHERE level = 'error' AND id IN (1, 2) GROUP BY host"
+	same := []string{
+		"select HOST, count(*) from `logs` where level='warn' and id in (7) group by host;",
+		"SELECT host, COUNT(*)\nFROM logs /* dashboard */ WHERE level = ? AND id IN (3, 4, 5) GROUP BY host",
+	}
+	different := []string{
+		"SELECT host, COUNT(*) FROM logs WHERE level = 'error' GROUP BY host",
+		"SELECT host, COUNT(*) FROM events WHERE level = 'error' AND id IN (1, 2) GROUP BY host",
+		"SELECT host, SUM(bytes) FROM logs WHERE level = 'error' AND id IN (1, 2) GROUP BY host",
+	}
+
+	want, normalized, err := Fingerprint(base)
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	if len(want) != 16 {
+		t.Errorf("Fingerprint() = %q, want 16 hex characters", want)
+	}
+	if got := FingerprintNormalized(normalized); got != want {
+		t.Errorf("FingerprintNormalized() = %q, want %q", got, want)
+	}
+	for _, sql := range same {
+		if got, _, err := Fingerprint(sql); err != nil || got != want {
+			t.Errorf("Fingerprint(%q) = %q, %v, want %q", sql, got, err, want)
+		}
+	}
+	for _, sql := range different {
+		if got, _, err := Fingerprint(sql); err != nil || got == want {
+			t.Errorf("Fingerprint(%q) = %q, %v, want a different fingerprint", sql, got, err)
+		}
+	}
+	if _, _, err := Fingerprint("SELECT 'unterminated"); err == nil {
+		t.Error("Fingerprint() of an untokenizable statement succeeded")
+	}
+}
@@ -0,0 +1,132 @@
+package sqlparse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turtacn/dataseap/pkg/common/errors"
+)
+
+// pageAlias is the alias of the derived table paginated queries are wrapped in.
+// pageAlias 是分页查询所包装的派生表的别名。
+const pageAlias = "dataseap_page"
+
+// SortKey is a column of the result of a query that pages are ordered by.
+// SortKey 是查询结果中用于对分页排序的列。
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// Limit restricts a query to limit rows starting at offset. A query without a LIMIT of its own gets one appended,
+// so that its ORDER BY keeps applying; other queries are wrapped in a derived table.
+// Limit 将查询限制为从offset开始的limit行。自身不含LIMIT的查询直接追加LIMIT，使其ORDER BY继续生效；其他查询被包装在派生表中。
+func Limit(sql string, limit, offset int) (string, error) {
+	body, tokens, err := queryBody(sql)
+	if err != nil {
+		return "", err
+	}
+	if !hasTopLevel(tokens, "LIMIT") {
+		return fmt.Sprintf("%s LIMIT %d OFFSET %d", body, limit, offset), nil
+	}
+	return fmt.Sprintf("SELECT * FROM (%s) %s LIMIT %d OFFSET %d", body, pageAlias, limit, offset), nil
+}
+
+// Count returns a query counting the rows of a query.
+// Count 返回统计查询结果行数的查询。
+func Count(sql string) (string, error) {
+	body, _, err := queryBody(sql)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM (%s) %s", body, pageAlias), nil
+}
+
+// Keyset returns the limit rows of a query following the row whose sort key values are after, ordered by keys and
+// skipping offset rows first. Without after, it starts at the first row. Keys should identify rows uniquely, or
+// rows sharing key values may be skipped between pages.
+// Keyset 返回查询中排序键值位于after所在行之后的limit行，按keys排序并先跳过offset行。after为空时从第一行开始。排序键应能
+// 唯一标识行，否则键值相同的行可能在分页之间被跳过。
+func Keyset(sql string, keys []SortKey, after []interface{}, limit, offset int) (string, error) {
+	body, _, err := queryBody(sql)
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", errors.New(errors.InvalidArgument, "keyset pagination needs a sort key")
+	}
+	if len(after) > 0 && len(after) != len(keys) {
+		return "", errors.Newf(errors.InvalidArgument, "keyset pagination got %d values for %d sort key columns", len(after), len(keys))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT * FROM (%s) %s", body, pageAlias)
+	if len(after) > 0 {
+		// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with "<" for descending columns.
+		literals := make([]string, len(after))
+		for i, v := range after {
+			if v == nil || isList(v) {
+				return "", errors.Newf(errors.InvalidArgument, "sort key column %s must be a non-NULL scalar for keyset pagination", keys[i].Column)
+			}
+			literal, err := bindLiteral(v, false)
+			if err != nil {
+				return "", errors.Wrapf(err, errors.InvalidArgument, "invalid value for sort key column %s", keys[i].Column)
+			}
+			literals[i] = literal
+		}
+		disjuncts := make([]string, len(keys))
+		for i, k := range keys {
+			var conjuncts []string
+			for j := 0; j < i; j++ {
+				conjuncts = append(conjuncts, fmt.Sprintf("%s = %s", quoteIdentifier(keys[j].Column), literals[j]))
+			}
+			operator := ">"
+			if k.Descending {
+				operator = "<"
+			}
+			conjuncts = append(conjuncts, fmt.Sprintf("%s %s %s", quoteIdentifier(k.Column), operator, literals[i]))
+			disjuncts[i] = "(" + strings.Join(conjuncts, " AND ") + ")"
+		}
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(disjuncts, " OR "))
+	}
+	orderBy := make([]string, len(keys))
+	for i, k := range keys {
+		orderBy[i] = quoteIdentifier(k.Column)
+		if k.Descending {
+			orderBy[i] += " DESC"
+		}
+	}
+	fmt.Fprintf(&sb, " ORDER BY %s LIMIT %d", strings.Join(orderBy, ", "), limit)
+	if offset > 0 {
+		fmt.Fprintf(&sb, " OFFSET %d", offset)
+	}
+	return sb.String(), nil
+}
+
+// queryBody returns the text and tokens of a single query without its terminator, rejecting other statements.
+// queryBody 返回单条查询不含结束符的文本与词法单元，拒绝其他语句。
+func queryBody(sql string) (string, []Token, error) {
+	statements, err := Classify(sql)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(statements) > 1 {
+		return "", nil, errors.New(errors.InvalidArgument, "only a single query can be paginated")
+	}
+	s := statements[0]
+	if s.Type != "SELECT" && s.Type != "WITH" {
+		return "", nil, errors.Newf(errors.InvalidArgument, "%s statements cannot be paginated", s.Type)
+	}
+	tokens, err := Tokenize(s.SQL)
+	if err != nil {
+		return "", nil, err
+	}
+	return s.SQL, tokens, nil
+}
+
+// quoteIdentifier quotes an identifier with backticks.
+// quoteIdentifier 用反引号括起标识符。
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,87 @@
+package sqlparse
+
+import "testing"
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		sql     string
+		want    string
+		wantErr bool
+	}{
+		{sql: "SELECT * FROM t ORDER BY ts;", want: "SELECT * FROM t ORDER BY ts LIMIT 11 OFFSET 20"},
+		{sql: "SELECT * FROM t WHERE id IN (SELECT id FROM u LIMIT 5)", want: "SELECT * FROM t WHERE id IN (SELECT id FROM u LIMIT 5) LIMIT 11 OFFSET 20"},
+		{sql: "SELECT * FROM t LIMIT 100", want: "SELECT * FROM (SELECT * FROM t LIMIT 100) dataseap_page LIMIT 11 OFFSET 20"},
+		{sql: "WITH x AS (SELECT 1) SELECT * FROM x", want: "WITH x AS (SELECT 1) SELECT * FROM x LIMIT 11 OFFSET 20"},
+		{sql: "SELECT 1; SELECT 2", wantErr: true},
+		{sql: "DELETE FROM t", wantErr: true},
+		{sql: "SELECT * FROM t INTO OUTFILE 'x'", wantErr: true},
+		{sql: "SELECT /*+ SET_VAR(query_timeout=1) */ 1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Limit(tt.sql, 11, 20)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Limit(%q) error = %v, wantErr %v", tt.sql, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Limit(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	keys := []SortKey{{Column: "ts", Descending: true}, {Column: "id"}}
+	tests := []struct {
+		name    string
+		keys    []SortKey
+		after   []interface{}
+		offset  int
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "first page",
+			keys: keys,
+			want: "SELECT * FROM (SELECT * FROM t) dataseap_page ORDER BY `ts` DESC, `id` LIMIT 11",
+		},
+		{
+			name:   "following page",
+			keys:   keys,
+			after:  []interface{}{"2024-05-01 12:00:00", 42},
+			offset: 3,
+			want: "SELECT * FROM (SELECT * FROM t) dataseap_page WHERE (`ts` < '2024-05-01 12:00:00') OR " +
+				"(`ts` = '2024-05-01 12:00:00' AND `id` > 42) ORDER BY `ts` DESC, `id` LIMIT 11 OFFSET 3",
+		},
+		{
+			name:  "values are escaped",
+			keys:  []SortKey{{Column: "na`me"}},
+			after: []interface{}{"x' OR '1'='1"},
+			want:  "SELECT * FROM (SELECT * FROM t) dataseap_page WHERE (`na``me` > 'x\\' OR \\'1\\'=\\'1') ORDER BY `na``me` LIMIT 11",
+		},
+		{name: "no sort key", wantErr: true},
+		{name: "values of the wrong arity", keys: keys, after: []interface{}{1}, wantErr: true},
+		{name: "NULL value", keys: []SortKey{{Column: "id"}}, after: []interface{}{nil}, wantErr: true},
+		{name: "list value", keys: []SortKey{{Column: "id"}}, after: []interface{}{[]int{1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Keyset("SELECT * FROM t", tt.keys, tt.after, 11, tt.offset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Keyset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Keyset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	got, err := Count("SELECT host FROM t GROUP BY host;")
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if want := "SELECT COUNT(*) FROM (SELECT host FROM t GROUP BY host) dataseap_page"; got != want {
+		t.Errorf("Count() = %q, want %q", got, want)
+	}
+}
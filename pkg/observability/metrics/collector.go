@@ -21,8 +21,10 @@ type AppMetrics struct {
 	GRPCRequestDuration monitoring.Histogram // grpc_request_duration_seconds (service, method)
 
 	// Ingestion Metrics
-	EventsIngestedTotal monitoring.Counter   // events_ingested_total (data_type, status) status: success, validation_failed, persist_failed
-	EventIngestionLag   monitoring.Histogram // event_ingestion_lag_seconds (data_type) (event_timestamp - processing_timestamp)
+	EventsIngestedTotal       monitoring.Counter   // events_ingested_total (data_type, status) status: success, validation_failed, persist_failed
+	EventIngestionLag         monitoring.Histogram // event_ingestion_lag_seconds (data_type) (event_timestamp - processing_timestamp)
+	IngestionRateLimitedTotal monitoring.Counter   // ingestion_rate_limited_total (data_type, reason) reason: source_rate, in_flight_bytes
+	IngestionInFlightBytes    monitoring.Gauge     // ingestion_in_flight_bytes
 
 	// Database/Adapter Metrics
 	StarRocksQueryDuration monitoring.Histogram // starrocks_query_duration_seconds (query_type, table)
@@ -131,6 +133,25 @@ func NewAppMetrics(exporter monitoring.MetricsExporter) (*AppMetrics, error) {
 			return
 		}
 
+		m.IngestionRateLimitedTotal, err = exporter.RegisterCounter(
+			"dataseap_ingestion_rate_limited_total",
+			"Total number of ingestion requests rejected by rate limits.",
+			"data_type", "reason", // reason: source_rate, in_flight_bytes
+		)
+		if err != nil {
+			l.Errorw("Failed to register ingestion_rate_limited_total", "error", err)
+			return
+		}
+
+		m.IngestionInFlightBytes, err = exporter.RegisterGauge(
+			"dataseap_ingestion_in_flight_bytes",
+			"Bytes currently held by the ingestion path.",
+		)
+		if err != nil {
+			l.Errorw("Failed to register ingestion_in_flight_bytes", "error", err)
+			return
+		}
+
 		// Register StarRocks/Database Metrics
 		m.StarRocksQueryDuration, err = exporter.RegisterHistogram(
 			"dataseap_starrocks_query_duration_seconds",
@@ -184,6 +205,14 @@ func Get() *AppMetrics {
 	return globalAppMetrics
 }
 
+// Current returns the global AppMetrics instance, or nil if NewAppMetrics has not been called or failed.
+// Current 返回全局的AppMetrics实例，若NewAppMetrics未被调用或失败则返回nil。
+// Use it where metrics are optional, e.g. in components that also run without a metrics exporter.
+// 适用于度量为可选的场景，例如在没有度量导出器时也会运行的组件中。
+func Current() *AppMetrics {
+	return globalAppMetrics
+}
+
 // ExposeHandler returns an http.Handler that can be used to expose metrics
 // (e.g., for Prometheus scraping at /metrics).
 // ExposeHandler 返回一个 http.Handler，可用于暴露度量指标。
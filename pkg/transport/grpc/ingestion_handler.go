@@ -2,15 +2,22 @@ package grpc
 
 import (
 	"context"
+	"io"
 	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	apiv1 "github.com/turtacn/dataseap/api/v1"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
 	"github.com/turtacn/dataseap/pkg/domain/ingestion"
 	ingestionmodel "github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/ratelimit"
 	"github.com/turtacn/dataseap/pkg/logger"
 )
 
@@ -19,13 +26,24 @@ import (
 type ingestionHandler struct {
 	apiv1.UnimplementedIngestionServiceServer // For forward compatibility
 	domainService                             ingestion.Service
+	streamCfg                                 config.IngestStreamConfig
 }
 
 // NewIngestionHandler creates a new gRPC handler for the ingestion service.
 // NewIngestionHandler 为采集服务创建一个新的gRPC处理器。
-func NewIngestionHandler(service ingestion.Service) apiv1.IngestionServiceServer {
+func NewIngestionHandler(service ingestion.Service, streamCfg config.IngestStreamConfig) apiv1.IngestionServiceServer {
+	if streamCfg.BatchSize <= 0 {
+		streamCfg.BatchSize = constants.IngestStreamDefaultBatchSize
+	}
+	if streamCfg.FlushMillis <= 0 {
+		streamCfg.FlushMillis = constants.IngestStreamDefaultFlushMillis
+	}
+	if streamCfg.BufferSize <= 0 {
+		streamCfg.BufferSize = constants.IngestStreamDefaultBufferSize
+	}
 	return &ingestionHandler{
 		domainService: service,
+		streamCfg:     streamCfg,
 	}
 }
 
@@ -42,63 +60,217 @@ func (h *ingestionHandler) IngestData(ctx context.Context, req *apiv1.IngestData
 
 	domainEvents := make([]*ingestionmodel.RawEvent, len(req.GetRecords()))
 	for i, r := range req.GetRecords() {
-		var eventData map[string]interface{}
-		if r.GetData() != nil {
-			eventData = r.GetData().AsMap()
-		}
-
-		var ts time.Time
-		if r.GetTimestamp() != nil && r.GetTimestamp().IsValid() {
-			ts = r.GetTimestamp().AsTime()
-		} else {
-			ts = time.Now().UTC() // Default to now if not provided or invalid
-		}
-
-		domainEvents[i] = &ingestionmodel.RawEvent{
-			// ID: (if provided in proto, map here)
-			DataSourceID: r.GetDataSourceId(),
-			DataType:     r.GetDataType(),
-			Timestamp:    ts,
-			Data:         eventData,
-			// RawPayload: (if provided in proto)
-			Tags: r.GetTags(),
-		}
+		domainEvents[i] = toDomainEvent(r)
 	}
 
-	ingested, persistFailed, validationFailed, err := h.domainService.IngestEvents(ctx, domainEvents)
+	ingested, persistFailed, validationFailed, duplicates, err := h.domainService.IngestEvents(ctx, domainEvents)
 	if err != nil {
 		l.Errorw("Ingestion service returned an error", "error", err)
 		// Map domain error to gRPC status
 		// TODO: More sophisticated error mapping based on err type
 		st := status.New(codes.Internal, "Ingestion failed")
+		errorCode := "INGESTION_ERROR"
+		if errors.Is(err, errors.RateLimitExceeded) {
+			st = rateLimitedStatus(err)
+			errorCode = string(errors.RateLimitExceeded)
+		}
 		// if errors.Is(err, custom_errors.ValidationError) {
 		// 	st = status.New(codes.InvalidArgument, err.Error())
 		// } else if errors.Is(err, custom_errors.DatabaseError) {
 		//  st = status.New(codes.Unavailable, "Failed to persist data")
 		// }
 		// For now, simple mapping:
-		detailedError, _ := st.WithDetails(&apiv1.ErrorDetail{Code: "INGESTION_ERROR", Message: err.Error()})
+		detailedError, _ := st.WithDetails(&apiv1.ErrorDetail{Code: errorCode, Message: err.Error()})
 		if detailedError != nil {
 			st = detailedError
 		}
 		return &apiv1.IngestDataResponse{
-			Success:       false,
-			IngestedCount: int64(ingested),
-			FailedCount:   int64(persistFailed + validationFailed),
-			ErrorMessage:  err.Error(),
-			ErrorCode:     "INGESTION_ERROR", // Or more specific code
+			Success:        false,
+			IngestedCount:  int64(ingested),
+			FailedCount:    int64(persistFailed + validationFailed),
+			ErrorMessage:   err.Error(),
+			ErrorCode:      errorCode,
+			DuplicateCount: int64(duplicates),
 		}, st.Err()
 	}
 
-	l.Infow("Ingestion successful", "ingested", ingested, "persist_failed", persistFailed, "validation_failed", validationFailed)
+	l.Infow("Ingestion successful", "ingested", ingested, "persist_failed", persistFailed, "validation_failed", validationFailed, "duplicates", duplicates)
 	return &apiv1.IngestDataResponse{
-		Success:       true,
-		IngestedCount: int64(ingested),
-		FailedCount:   int64(persistFailed + validationFailed), // Sum of failures
-		Message:       "Data ingestion processed.",
+		Success:        true,
+		IngestedCount:  int64(ingested),
+		FailedCount:    int64(persistFailed + validationFailed), // Sum of failures
+		DuplicateCount: int64(duplicates),
+		Message:        "Data ingestion processed.",
 	}, nil
 }
 
+// IngestStreamData handles a long-lived ingestion stream. Incoming records are buffered and loaded in
+// batches of up to BatchSize records, at least every FlushMillis, or when a message asks for a flush;
+// each batch is acknowledged with its counts. A receiver goroutine hands messages over through a channel
+// of BufferSize slots: when loading falls behind, the channel fills up, Recv stops being called and gRPC
+// flow control pushes back on the client.
+// IngestStreamData 处理长连接的采集流。收到的记录被缓冲，并在达到BatchSize条、至少每隔FlushMillis
+// 或消息要求刷新时分批写入，每个批次都会返回带有计数的确认。接收协程通过容量为BufferSize的通道传递消息：
+// 写入跟不上时通道被填满，停止调用Recv，由gRPC流量控制对客户端施加背压。
+func (h *ingestionHandler) IngestStreamData(stream apiv1.IngestionService_IngestStreamDataServer) error {
+	ctx := stream.Context()
+	l := logger.L().With("handler", "IngestStreamData")
+	l.Info("Ingestion stream opened")
+
+	messages := make(chan *apiv1.IngestStreamRequest, h.streamCfg.BufferSize)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(messages)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			select {
+			case messages <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Duration(h.streamCfg.FlushMillis) * time.Millisecond)
+	defer ticker.Stop()
+
+	var (
+		batch                      []*ingestionmodel.RawEvent
+		messageSeq, batchSeq       int64
+		ackedSeq                   int64 // 最后一次确认的消息序号 LastMessageSequence of the last ack
+		totalIngested, totalFailed int64
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		batchSeq++
+		ingested, persistFailed, validationFailed, duplicates, err := h.domainService.IngestEvents(ctx, batch)
+		batch = nil
+		totalIngested += int64(ingested)
+		totalFailed += int64(persistFailed + validationFailed)
+		ack := &apiv1.IngestStreamAck{
+			BatchSequence:         batchSeq,
+			LastMessageSequence:   messageSeq,
+			IngestedCount:         int64(ingested),
+			PersistFailedCount:    int64(persistFailed),
+			ValidationFailedCount: int64(validationFailed),
+			DuplicateCount:        int64(duplicates),
+			TotalIngestedCount:    totalIngested,
+			TotalFailedCount:      totalFailed,
+		}
+		if err != nil {
+			// A failed batch is reported in its ack; the stream stays open for the next one.
+			l.Warnw("Stream batch failed", "batch", batchSeq, "ingested", ingested, "persist_failed", persistFailed, "validation_failed", validationFailed, "error", err)
+			ack.ErrorMessage = err.Error()
+			ack.ErrorCode = string(errors.GetCode(err))
+		}
+		retryAfter, limited := ratelimit.RetryAfter(err)
+		if limited {
+			ack.RetryAfterMillis = retryAfter.Milliseconds()
+		}
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+		ackedSeq = messageSeq
+		if limited {
+			// Stop reading for the retry delay so that the rate limit turns into flow-control backpressure.
+			// 在重试等待期间停止读取，使限流转化为流量控制背压。
+			select {
+			case <-time.After(retryAfter):
+			case <-ctx.Done():
+			}
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case req, ok := <-messages:
+			if !ok {
+				// The client half-closed the stream or Recv failed: flush what is left.
+				select {
+				case err := <-recvErr:
+					// The batch is dropped unacknowledged: the client resends the messages after the last
+					// acknowledged one.
+					l.Warnw("Ingestion stream receive failed", "batches", batchSeq, "unacknowledged", len(batch), "last_acknowledged_message", ackedSeq, "error", err)
+					return err
+				default:
+				}
+				if err := flush(); err != nil {
+					return err
+				}
+				l.Infow("Ingestion stream closed", "batches", batchSeq, "messages", messageSeq, "ingested", totalIngested, "failed", totalFailed)
+				return nil
+			}
+			messageSeq++
+			for _, r := range req.GetRecords() {
+				batch = append(batch, toDomainEvent(r))
+			}
+			if req.GetFlush() || len(batch) >= h.streamCfg.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			l.Infow("Ingestion stream cancelled", "batches", batchSeq, "unacknowledged", len(batch), "last_acknowledged_message", ackedSeq)
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// toDomainEvent maps a proto event to the domain model.
+// toDomainEvent 将proto事件映射为领域模型。
+func toDomainEvent(r *apiv1.RawDataEvent) *ingestionmodel.RawEvent {
+	var eventData map[string]interface{}
+	if r.GetData() != nil {
+		eventData = r.GetData().AsMap()
+	}
+
+	var (
+		ts        time.Time
+		defaulted bool
+	)
+	if r.GetTimestamp() != nil && r.GetTimestamp().IsValid() {
+		ts = r.GetTimestamp().AsTime()
+	} else {
+		ts, defaulted = time.Now().UTC(), true // Default to now if not provided or invalid
+	}
+
+	return &ingestionmodel.RawEvent{
+		ID:                 r.GetId(),
+		DataSourceID:       r.GetDataSourceId(),
+		DataType:           r.GetDataType(),
+		Timestamp:          ts,
+		TimestampDefaulted: defaulted,
+		Data:               eventData,
+		RawPayload:         r.GetRawPayload(),
+		Tags:               r.GetTags(),
+	}
+}
+
+// rateLimitedStatus maps a RateLimitExceeded error to RESOURCE_EXHAUSTED, with a RetryInfo detail carrying the retry hint.
+// rateLimitedStatus 将RateLimitExceeded错误映射为RESOURCE_EXHAUSTED，并附带携带重试提示的RetryInfo详情。
+func rateLimitedStatus(err error) *status.Status {
+	st := status.New(codes.ResourceExhausted, errors.GetMessage(err))
+	if retryAfter, ok := ratelimit.RetryAfter(err); ok {
+		if detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); detailErr == nil {
+			st = detailed
+		}
+	}
+	return st
+}
+
 // Helper to map proto ErrorDetail to gRPC status detail
 func toProtoErrorDetail(code, message string) *apiv1.ErrorDetail {
 	return &apiv1.ErrorDetail{
@@ -0,0 +1,273 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiv1 "github.com/turtacn/dataseap/api/v1"
+	"github.com/turtacn/dataseap/pkg/common/errors"
+	"github.com/turtacn/dataseap/pkg/config"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion"
+	ingestionmodel "github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/ratelimit"
+)
+
+// fakeBatchResult is the outcome of one IngestEvents call. The events not counted otherwise are ingested.
+type fakeBatchResult struct {
+	persistFailed int
+	rejected      bool // 所有计数为零，如被限流的请求 All counts are zero, like a rate limited request
+	err           error
+}
+
+// fakeIngestion records the event IDs of each IngestEvents call and answers with results[call], counting from 1.
+type fakeIngestion struct {
+	ingestion.Service
+	mu      sync.Mutex
+	results map[int]fakeBatchResult
+	batches [][]string
+}
+
+func (f *fakeIngestion) IngestEvents(_ context.Context, events []*ingestionmodel.RawEvent) (int, int, int, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	f.batches = append(f.batches, ids)
+	result := f.results[len(f.batches)]
+	if result.rejected {
+		return 0, 0, 0, 0, result.err
+	}
+	return len(events) - result.persistFailed, result.persistFailed, 0, 0, result.err
+}
+
+// fakeIngestStream replays requests to Recv, then returns recvErr, or io.EOF when it is nil. When hold is not
+// nil, Recv blocks on it after the requests instead.
+type fakeIngestStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*apiv1.IngestStreamRequest
+	recvErr  error
+	hold     chan struct{}
+	acks     []*apiv1.IngestStreamAck
+}
+
+func (s *fakeIngestStream) Context() context.Context { return s.ctx }
+
+func (s *fakeIngestStream) Recv() (*apiv1.IngestStreamRequest, error) {
+	if len(s.requests) > 0 {
+		req := s.requests[0]
+		s.requests = s.requests[1:]
+		return req, nil
+	}
+	if s.hold != nil {
+		<-s.hold
+	}
+	if s.recvErr != nil {
+		return nil, s.recvErr
+	}
+	return nil, io.EOF
+}
+
+func (s *fakeIngestStream) Send(ack *apiv1.IngestStreamAck) error {
+	s.acks = append(s.acks, ack)
+	return nil
+}
+
+// streamRequest builds a request carrying one record per ID.
+func streamRequest(flush bool, ids ...string) *apiv1.IngestStreamRequest {
+	req := &apiv1.IngestStreamRequest{Flush: flush}
+	for _, id := range ids {
+		req.Records = append(req.Records, &apiv1.RawDataEvent{Id: id, DataSourceId: "fw", DataType: "log"})
+	}
+	return req
+}
+
+func rateLimitedError(retryAfter time.Duration) error {
+	exceeded := &ratelimit.ExceededError{Reason: ratelimit.ReasonSourceRate, DataSourceID: "fw", RetryAfter: retryAfter}
+	return errors.Wrap(exceeded, errors.RateLimitExceeded, exceeded.Error())
+}
+
+func TestIngestStreamData(t *testing.T) {
+	tests := []struct {
+		name        string
+		requests    []*apiv1.IngestStreamRequest
+		results     map[int]fakeBatchResult
+		recvErr     error
+		wantBatches [][]string
+		wantAcks    []*apiv1.IngestStreamAck
+		wantCode    codes.Code
+		wantPause   time.Duration // 流至少暂停的时长 Minimum time the stream pauses
+	}{
+		{
+			name:        "records are batched by size and the rest is flushed on close",
+			requests:    []*apiv1.IngestStreamRequest{streamRequest(false, "e1", "e2"), streamRequest(false, "e3", "e4"), streamRequest(false, "e5")},
+			wantBatches: [][]string{{"e1", "e2", "e3", "e4"}, {"e5"}},
+			wantAcks: []*apiv1.IngestStreamAck{
+				{BatchSequence: 1, LastMessageSequence: 2, IngestedCount: 4, TotalIngestedCount: 4},
+				{BatchSequence: 2, LastMessageSequence: 3, IngestedCount: 1, TotalIngestedCount: 5},
+			},
+		},
+		{
+			name:        "flush request is acknowledged at once",
+			requests:    []*apiv1.IngestStreamRequest{streamRequest(true, "e1"), streamRequest(false, "e2")},
+			wantBatches: [][]string{{"e1"}, {"e2"}},
+			wantAcks: []*apiv1.IngestStreamAck{
+				{BatchSequence: 1, LastMessageSequence: 1, IngestedCount: 1, TotalIngestedCount: 1},
+				{BatchSequence: 2, LastMessageSequence: 2, IngestedCount: 1, TotalIngestedCount: 2},
+			},
+		},
+		{
+			name:        "failed batch is acknowledged with its error and the stream stays open",
+			requests:    []*apiv1.IngestStreamRequest{streamRequest(true, "e1", "e2"), streamRequest(true, "e3")},
+			results:     map[int]fakeBatchResult{1: {persistFailed: 1, err: errors.New(errors.DatabaseError, "load failed")}},
+			wantBatches: [][]string{{"e1", "e2"}, {"e3"}},
+			wantAcks: []*apiv1.IngestStreamAck{
+				{BatchSequence: 1, LastMessageSequence: 1, IngestedCount: 1, PersistFailedCount: 1, TotalIngestedCount: 1, TotalFailedCount: 1,
+					ErrorMessage: errors.New(errors.DatabaseError, "load failed").Error(), ErrorCode: string(errors.DatabaseError)},
+				{BatchSequence: 2, LastMessageSequence: 2, IngestedCount: 1, TotalIngestedCount: 2, TotalFailedCount: 1},
+			},
+		},
+		{
+			name:        "rate limited batch carries the retry hint and pauses the stream",
+			requests:    []*apiv1.IngestStreamRequest{streamRequest(true, "e1"), streamRequest(true, "e1")},
+			results:     map[int]fakeBatchResult{1: {rejected: true, err: rateLimitedError(50 * time.Millisecond)}},
+			wantBatches: [][]string{{"e1"}, {"e1"}},
+			wantAcks: []*apiv1.IngestStreamAck{
+				{BatchSequence: 1, LastMessageSequence: 1, RetryAfterMillis: 50,
+					ErrorMessage: rateLimitedError(50 * time.Millisecond).Error(), ErrorCode: string(errors.RateLimitExceeded)},
+				{BatchSequence: 2, LastMessageSequence: 2, IngestedCount: 1, TotalIngestedCount: 1},
+			},
+			wantPause: 50 * time.Millisecond,
+		},
+		{
+			name:     "receive failure drops the unacknowledged batch",
+			requests: []*apiv1.IngestStreamRequest{streamRequest(false, "e1")},
+			recvErr:  status.Error(codes.Unavailable, "connection reset"),
+			wantCode: codes.Unavailable,
+		},
+		{
+			name: "empty stream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeIngestion{results: tt.results}
+			h := NewIngestionHandler(service, config.IngestStreamConfig{BatchSize: 3, FlushMillis: int(time.Hour / time.Millisecond)})
+			stream := &fakeIngestStream{ctx: context.Background(), requests: tt.requests, recvErr: tt.recvErr}
+
+			start := time.Now()
+			err := h.IngestStreamData(stream)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("IngestStreamData() error = %v, want code %s", err, tt.wantCode)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantPause {
+				t.Errorf("IngestStreamData() took %s, want a pause of at least %s", elapsed, tt.wantPause)
+			}
+			if !reflect.DeepEqual(service.batches, tt.wantBatches) {
+				t.Errorf("IngestStreamData() batches = %q, want %q", service.batches, tt.wantBatches)
+			}
+			if len(stream.acks) != len(tt.wantAcks) {
+				t.Fatalf("IngestStreamData() sent %d acks, want %d", len(stream.acks), len(tt.wantAcks))
+			}
+			for i, ack := range stream.acks {
+				want := tt.wantAcks[i]
+				if ack.GetBatchSequence() != want.GetBatchSequence() ||
+					ack.GetLastMessageSequence() != want.GetLastMessageSequence() ||
+					ack.GetIngestedCount() != want.GetIngestedCount() ||
+					ack.GetPersistFailedCount() != want.GetPersistFailedCount() ||
+					ack.GetTotalIngestedCount() != want.GetTotalIngestedCount() ||
+					ack.GetTotalFailedCount() != want.GetTotalFailedCount() ||
+					ack.GetErrorMessage() != want.GetErrorMessage() ||
+					ack.GetErrorCode() != want.GetErrorCode() ||
+					ack.GetRetryAfterMillis() != want.GetRetryAfterMillis() {
+					t.Errorf("ack %d = %v, want %v", i+1, ack, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIngestStreamDataCancelled(t *testing.T) {
+	service := &fakeIngestion{}
+	h := NewIngestionHandler(service, config.IngestStreamConfig{BatchSize: 3, FlushMillis: int(time.Hour / time.Millisecond)})
+	ctx, cancel := context.WithCancel(context.Background())
+	hold := make(chan struct{})
+	defer close(hold)
+	stream := &fakeIngestStream{ctx: ctx, requests: []*apiv1.IngestStreamRequest{streamRequest(false, "e1")}, hold: hold}
+
+	done := make(chan error, 1)
+	go func() { done <- h.IngestStreamData(stream) }()
+	cancel()
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("IngestStreamData() error = %v, want code %s", err, codes.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("IngestStreamData() did not return after the stream was cancelled")
+	}
+	if len(service.batches) != 0 || len(stream.acks) != 0 {
+		t.Errorf("IngestStreamData() loaded %q and sent %d acks, want neither", service.batches, len(stream.acks))
+	}
+}
+
+func TestIngestData(t *testing.T) {
+	tests := []struct {
+		name          string
+		result        fakeBatchResult
+		wantCode      codes.Code
+		wantErrorCode string
+		wantRetry     time.Duration // 0表示没有RetryInfo 0 means no RetryInfo
+	}{
+		{name: "ingested", wantCode: codes.OK},
+		{
+			name:          "rate limited request is resource exhausted with a retry hint",
+			result:        fakeBatchResult{rejected: true, err: rateLimitedError(250 * time.Millisecond)},
+			wantCode:      codes.ResourceExhausted,
+			wantErrorCode: string(errors.RateLimitExceeded),
+			wantRetry:     250 * time.Millisecond,
+		},
+		{
+			name:          "failed request is internal",
+			result:        fakeBatchResult{persistFailed: 2, err: errors.New(errors.DatabaseError, "load failed")},
+			wantCode:      codes.Internal,
+			wantErrorCode: "INGESTION_ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeIngestion{results: map[int]fakeBatchResult{1: tt.result}}
+			h := NewIngestionHandler(service, config.IngestStreamConfig{})
+			req := &apiv1.IngestDataRequest{Records: streamRequest(false, "e1", "e2").GetRecords()}
+
+			resp, err := h.IngestData(context.Background(), req)
+			st := status.Convert(err)
+			if st.Code() != tt.wantCode {
+				t.Fatalf("IngestData() error = %v, want code %s", err, tt.wantCode)
+			}
+			if resp.GetSuccess() != (err == nil) || resp.GetErrorCode() != tt.wantErrorCode {
+				t.Errorf("IngestData() response = %v, want error code %q", resp, tt.wantErrorCode)
+			}
+			var retry time.Duration
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.RetryInfo); ok {
+					retry = info.GetRetryDelay().AsDuration()
+				}
+			}
+			if retry != tt.wantRetry {
+				t.Errorf("IngestData() retry delay = %s, want %s", retry, tt.wantRetry)
+			}
+		})
+	}
+}
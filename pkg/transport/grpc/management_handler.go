@@ -9,6 +9,7 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 
 	apiv1 "github.com/turtacn/dataseap/api/v1"
+	"github.com/turtacn/dataseap/pkg/common/errors"
 	commontypes "github.com/turtacn/dataseap/pkg/common/types"
 	commonenum "github.com/turtacn/dataseap/pkg/common/types/enum"
 	"github.com/turtacn/dataseap/pkg/domain/management/lifecycle"
@@ -180,33 +181,89 @@ func (h *managementHandler) GetTableSchema(ctx context.Context, req *apiv1.GetTa
 		return &apiv1.GetTableSchemaResponse{Error: toProtoErrorDetail("GET_SCHEMA_ERROR", err.Error())}, status.Error(codes.NotFound, err.Error())
 	}
 
-	protoSchema := &apiv1.TableSchema{
-		TableName:    domainSchema.TableName,
-		DatabaseName: domainSchema.DatabaseName,
-		Fields:       make([]*apiv1.FieldSchema, len(domainSchema.Fields)),
-		// Map other fields like TableType, KeysType, etc.
-	}
-	for i, df := range domainSchema.Fields {
-		protoSchema.Fields[i] = &apiv1.FieldSchema{
-			Name:            df.Name,
-			DataType:        apiv1.DataType(apiv1.DataType_value[string(df.DataType)]), // Assumes enum names match
-			TypeString:      df.TypeString,
-			IsNullable:      df.IsNullable,
-			IsPrimaryKey:    df.IsPrimaryKey,
-			DefaultValue:    df.DefaultValue,
-			Comment:         df.Comment,
-			AggregationType: df.AggregationType,
-		}
-	}
 	l.Info("Table schema retrieved successfully")
-	return &apiv1.GetTableSchemaResponse{Schema: protoSchema}, nil
+	return &apiv1.GetTableSchemaResponse{Schema: toProtoTableSchema(domainSchema)}, nil
 }
 
 func (h *managementHandler) ListTables(ctx context.Context, req *apiv1.ListTablesRequest) (*apiv1.ListTablesResponse, error) {
 	l := logger.L().Ctx(ctx).With("handler", "ListTables", "db", req.GetDatabaseName())
 	l.Info("Received ListTables request")
-	// ... implementation mapping to h.metadataSvc.ListTables ...
-	return nil, status.Errorf(codes.Unimplemented, "method ListTables not implemented")
+
+	var pReq *commontypes.PaginationRequest
+	if req.GetPagination() != nil {
+		pReq = &commontypes.PaginationRequest{
+			Page:     int(req.GetPagination().GetPage()),
+			PageSize: int(req.GetPagination().GetPageSize()),
+		}
+	}
+
+	tables, total, err := h.metadataSvc.ListTables(ctx, req.GetDatabaseName(), pReq)
+	if err != nil {
+		l.Errorw("Failed to list tables", "error", err)
+		return &apiv1.ListTablesResponse{Error: toProtoErrorDetail("LIST_TABLES_ERROR", err.Error())}, status.Error(grpcCodeFromError(err), err.Error())
+	}
+
+	resp := &apiv1.ListTablesResponse{TableNames: tables}
+	if pReq != nil {
+		resp.Pagination = &apiv1.PaginationResponse{
+			Page:       int32(pReq.Page),
+			PageSize:   int32(pReq.PageSize),
+			TotalItems: total,
+			TotalPages: int32((total + int64(pReq.PageSize) - 1) / int64(pReq.PageSize)),
+		}
+	}
+	l.Infow("Tables listed successfully", "count", len(tables), "total", total)
+	return resp, nil
+}
+
+func (h *managementHandler) CreateTable(ctx context.Context, req *apiv1.CreateTableRequest) (*apiv1.TableDDLResponse, error) {
+	l := logger.L().With("handler", "CreateTable", "db", req.GetSchema().GetDatabaseName(), "table", req.GetSchema().GetTableName(), "dryRun", req.GetDryRun())
+	l.Info("Received CreateTable request")
+
+	if req.GetSchema() == nil {
+		return nil, status.Error(codes.InvalidArgument, "table schema is required")
+	}
+	result, err := h.metadataSvc.CreateTable(ctx, fromProtoTableSchema(req.GetSchema()), req.GetDryRun())
+	if err != nil {
+		l.Errorw("Failed to create table", "error", err)
+		return &apiv1.TableDDLResponse{Error: toProtoErrorDetail("CREATE_TABLE_ERROR", err.Error())}, status.Error(grpcCodeFromError(err), errors.GetMessage(err))
+	}
+	return &apiv1.TableDDLResponse{Statements: result.Statements, DryRun: result.DryRun}, nil
+}
+
+func (h *managementHandler) AlterTable(ctx context.Context, req *apiv1.AlterTableRequest) (*apiv1.TableDDLResponse, error) {
+	l := logger.L().With("handler", "AlterTable", "db", req.GetDatabaseName(), "table", req.GetTableName(), "dryRun", req.GetDryRun())
+	l.Info("Received AlterTable request")
+
+	alterations := make([]*metadatamodel.TableAlteration, len(req.GetAlterations()))
+	for i, a := range req.GetAlterations() {
+		alterations[i] = &metadatamodel.TableAlteration{
+			Type:         metadatamodel.AlterationType(a.GetType()),
+			ColumnName:   a.GetColumnName(),
+			NewTableName: a.GetNewTableName(),
+		}
+		if a.GetColumn() != nil {
+			alterations[i].Column = fromProtoFieldSchema(a.GetColumn())
+		}
+	}
+	result, err := h.metadataSvc.AlterTable(ctx, req.GetDatabaseName(), req.GetTableName(), alterations, req.GetDryRun())
+	if err != nil {
+		l.Errorw("Failed to alter table", "error", err)
+		return &apiv1.TableDDLResponse{Error: toProtoErrorDetail("ALTER_TABLE_ERROR", err.Error())}, status.Error(grpcCodeFromError(err), errors.GetMessage(err))
+	}
+	return &apiv1.TableDDLResponse{Statements: result.Statements, DryRun: result.DryRun}, nil
+}
+
+func (h *managementHandler) DropTable(ctx context.Context, req *apiv1.DropTableRequest) (*apiv1.TableDDLResponse, error) {
+	l := logger.L().With("handler", "DropTable", "db", req.GetDatabaseName(), "table", req.GetTableName(), "dryRun", req.GetDryRun())
+	l.Info("Received DropTable request")
+
+	result, err := h.metadataSvc.DropTable(ctx, req.GetDatabaseName(), req.GetTableName(), req.GetDryRun())
+	if err != nil {
+		l.Errorw("Failed to drop table", "error", err)
+		return &apiv1.TableDDLResponse{Error: toProtoErrorDetail("DROP_TABLE_ERROR", err.Error())}, status.Error(grpcCodeFromError(err), errors.GetMessage(err))
+	}
+	return &apiv1.TableDDLResponse{Statements: result.Statements, DryRun: result.DryRun}, nil
 }
 
 func (h *managementHandler) CreateIndex(ctx context.Context, req *apiv1.CreateIndexRequest) (*apiv1.StandardResponse, error) {
@@ -237,15 +294,47 @@ func (h *managementHandler) CreateIndex(ctx context.Context, req *apiv1.CreateIn
 func (h *managementHandler) GetIndexInfo(ctx context.Context, req *apiv1.GetIndexInfoRequest) (*apiv1.GetIndexInfoResponse, error) {
 	l := logger.L().Ctx(ctx).With("handler", "GetIndexInfo", "db", req.GetDatabaseName(), "table", req.GetTableName())
 	l.Info("Received GetIndexInfo request")
-	// ... implementation ...
-	return nil, status.Errorf(codes.Unimplemented, "method GetIndexInfo not implemented")
+
+	domainIndexes, err := h.metadataSvc.ListIndexes(ctx, req.GetDatabaseName(), req.GetTableName())
+	if err != nil {
+		l.Errorw("Failed to list indexes", "error", err)
+		return &apiv1.GetIndexInfoResponse{Error: toProtoErrorDetail("GET_INDEX_INFO_ERROR", err.Error())}, status.Error(grpcCodeFromError(err), err.Error())
+	}
+
+	protoIndexes := make([]*apiv1.IndexDefinition, len(domainIndexes))
+	for i, di := range domainIndexes {
+		protoIndexes[i] = &apiv1.IndexDefinition{
+			IndexName:  di.IndexName,
+			IndexType:  di.IndexType,
+			Fields:     di.Fields,
+			Properties: di.Properties,
+			Comment:    di.Comment,
+		}
+	}
+	l.Info("Indexes retrieved successfully")
+	return &apiv1.GetIndexInfoResponse{Indexes: protoIndexes}, nil
 }
 
 func (h *managementHandler) CreateMaterializedView(ctx context.Context, req *apiv1.CreateMaterializedViewRequest) (*apiv1.StandardResponse, error) {
 	l := logger.L().Ctx(ctx).With("handler", "CreateMaterializedView", "db", req.GetDatabaseName(), "view", req.GetViewName())
 	l.Info("Received CreateMaterializedView request")
-	// ... implementation ...
-	return nil, status.Errorf(codes.Unimplemented, "method CreateMaterializedView not implemented")
+
+	mvDef := &metadatamodel.MaterializedViewDefinition{
+		DatabaseName:    req.GetDatabaseName(),
+		ViewName:        req.GetViewName(),
+		Query:           req.GetQuery(),
+		RefreshType:     req.GetRefreshType(),
+		RefreshSchedule: req.GetRefreshSchedule(),
+		Properties:      req.GetProperties(),
+		Comment:         req.GetComment(),
+	}
+	if err := h.metadataSvc.CreateMaterializedView(ctx, mvDef); err != nil {
+		l.Errorw("Failed to create materialized view", "error", err)
+		return &apiv1.StandardResponse{Success: false, Message: err.Error(), Error: toProtoErrorDetail("CREATE_MATERIALIZED_VIEW_ERROR", err.Error())}, status.Error(grpcCodeFromError(err), errors.GetMessage(err))
+	}
+
+	l.Info("Materialized view created successfully")
+	return &apiv1.StandardResponse{Success: true, Message: "Materialized view created successfully", Id: req.GetDatabaseName() + "." + req.GetViewName()}, nil
 }
 
 // --- Lifecycle Management ---
@@ -272,3 +361,135 @@ func (h *managementHandler) GetComponentStatus(ctx context.Context, req *apiv1.G
 	l.Info("Component statuses retrieved successfully")
 	return &apiv1.GetComponentStatusResponse{Statuses: protoStatuses}, nil
 }
+
+// fromProtoTableSchema converts a proto table schema, including its structured partitioning and distribution,
+// into the domain model.
+// fromProtoTableSchema 将proto表结构（包括结构化的分区与分桶定义）转换为领域模型。
+func fromProtoTableSchema(ps *apiv1.TableSchema) *metadatamodel.TableSchema {
+	ts := &metadatamodel.TableSchema{
+		DatabaseName: ps.GetDatabaseName(),
+		TableName:    ps.GetTableName(),
+		Fields:       make([]*metadatamodel.FieldSchema, len(ps.GetFields())),
+		TableType:    ps.GetTableType(),
+		KeysType:     ps.GetKeysType(),
+		KeyColumns:   ps.GetKeyColumns(),
+		Properties:   ps.GetProperties(),
+		Comment:      ps.GetComment(),
+	}
+	for i, f := range ps.GetFields() {
+		ts.Fields[i] = fromProtoFieldSchema(f)
+	}
+	if p := ps.GetPartition(); p != nil {
+		ts.Partition = &metadatamodel.PartitionSpec{
+			Type:       p.GetType(),
+			Columns:    p.GetColumns(),
+			Expression: p.GetExpression(),
+		}
+		for _, r := range p.GetRanges() {
+			ts.Partition.Ranges = append(ts.Partition.Ranges, &metadatamodel.RangePartition{Name: r.GetName(), Lower: r.GetLower(), Upper: r.GetUpper()})
+		}
+		if d := p.GetDynamic(); d != nil {
+			ts.Partition.Dynamic = &metadatamodel.DynamicPartition{
+				TimeUnit:            d.GetTimeUnit(),
+				Start:               int(d.GetStart()),
+				End:                 int(d.GetEnd()),
+				Prefix:              d.GetPrefix(),
+				Buckets:             int(d.GetBuckets()),
+				HistoryPartitionNum: int(d.GetHistoryPartitionNum()),
+			}
+		}
+	}
+	if d := ps.GetDistribution(); d != nil {
+		ts.Distribution = &metadatamodel.DistributionSpec{Columns: d.GetColumns(), Buckets: int(d.GetBuckets())}
+	}
+	return ts
+}
+
+// toProtoTableSchema converts a domain table schema, including its keys, partitioning and distribution, into its
+// proto form.
+// toProtoTableSchema 将领域表结构（包括键、分区与分桶）转换为proto形式。
+func toProtoTableSchema(ts *metadatamodel.TableSchema) *apiv1.TableSchema {
+	ps := &apiv1.TableSchema{
+		TableName:        ts.TableName,
+		DatabaseName:     ts.DatabaseName,
+		Fields:           make([]*apiv1.FieldSchema, len(ts.Fields)),
+		TableType:        ts.TableType,
+		KeysType:         ts.KeysType,
+		KeyColumns:       ts.KeyColumns,
+		PartitionInfo:    ts.PartitionInfo,
+		DistributionInfo: ts.DistributionInfo,
+		Properties:       ts.Properties,
+		Comment:          ts.Comment,
+		CreateTableDdl:   ts.CreateTableDDL,
+	}
+	for i, df := range ts.Fields {
+		ps.Fields[i] = &apiv1.FieldSchema{
+			Name:            df.Name,
+			DataType:        apiv1.DataType(apiv1.DataType_value[string(df.DataType)]), // Assumes enum names match
+			TypeString:      df.TypeString,
+			IsNullable:      df.IsNullable,
+			IsPrimaryKey:    df.IsPrimaryKey,
+			DefaultValue:    df.DefaultValue,
+			Comment:         df.Comment,
+			AggregationType: df.AggregationType,
+		}
+	}
+	if p := ts.Partition; p != nil {
+		ps.Partition = &apiv1.PartitionSpec{Type: p.Type, Columns: p.Columns, Expression: p.Expression}
+		for _, r := range p.Ranges {
+			ps.Partition.Ranges = append(ps.Partition.Ranges, &apiv1.RangePartition{Name: r.Name, Lower: r.Lower, Upper: r.Upper})
+		}
+		if d := p.Dynamic; d != nil {
+			ps.Partition.Dynamic = &apiv1.DynamicPartition{
+				TimeUnit:            d.TimeUnit,
+				Start:               int32(d.Start),
+				End:                 int32(d.End),
+				Prefix:              d.Prefix,
+				Buckets:             int32(d.Buckets),
+				HistoryPartitionNum: int32(d.HistoryPartitionNum),
+			}
+		}
+	}
+	if d := ts.Distribution; d != nil {
+		ps.Distribution = &apiv1.DistributionSpec{Columns: d.Columns, Buckets: int32(d.Buckets)}
+	}
+	return ps
+}
+
+func fromProtoFieldSchema(pf *apiv1.FieldSchema) *metadatamodel.FieldSchema {
+	dataType := commonenum.DataTypeUnknown
+	if pf.GetDataType() != apiv1.DataType_DATA_TYPE_UNSPECIFIED {
+		dataType = commonenum.DataType(pf.GetDataType().String()) // Assumes enum names match
+	}
+	return &metadatamodel.FieldSchema{
+		Name:            pf.GetName(),
+		DataType:        dataType,
+		TypeString:      pf.GetTypeString(),
+		IsNullable:      pf.GetIsNullable(),
+		IsPrimaryKey:    pf.GetIsPrimaryKey(),
+		DefaultValue:    pf.GetDefaultValue(),
+		Comment:         pf.GetComment(),
+		AggregationType: pf.GetAggregationType(),
+	}
+}
+
+// grpcCodeFromError maps an application error code to the corresponding gRPC status code.
+// grpcCodeFromError 将应用错误码映射为对应的gRPC状态码。
+func grpcCodeFromError(err error) codes.Code {
+	switch errors.GetCode(err) {
+	case errors.InvalidArgument:
+		return codes.InvalidArgument
+	case errors.NotFoundError:
+		return codes.NotFound
+	case errors.PermissionDenied:
+		return codes.PermissionDenied
+	case errors.AlreadyExistsError:
+		return codes.AlreadyExists
+	case errors.RateLimitExceeded:
+		return codes.ResourceExhausted
+	case errors.TimeoutError:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
@@ -2,6 +2,9 @@ package grpc
 
 import (
 	"context"
+	"encoding/json"
+	"math"
+	"strings"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -9,6 +12,8 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	apiv1 "github.com/turtacn/dataseap/api/v1"
+	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/errors"
 	commontypes "github.com/turtacn/dataseap/pkg/common/types"
 	"github.com/turtacn/dataseap/pkg/domain/query"
 	querymodel "github.com/turtacn/dataseap/pkg/domain/query/model"
@@ -36,41 +41,21 @@ func (h *queryHandler) ExecuteSQLQuery(ctx context.Context, req *apiv1.ExecuteSQ
 	l := logger.L().Ctx(ctx).With("handler", "ExecuteSQLQuery", "request_id", req.GetRequestId())
 	l.Info("Received ExecuteSQLQuery request")
 
-	domainReq := &querymodel.SQLQueryRequest{
-		SQL:              req.GetSqlQuery(),
-		Params:           make(map[string]interface{}), // TODO: Convert req.GetParameters() if it's map<string, Value>
-		WorkloadGroup:    req.GetWorkloadGroup(),
-		QueryTimeoutSecs: int(req.GetQueryTimeoutSeconds()),
-		// Database: req.GetDatabase(), // If database is added to proto
-	}
-	if req.GetParameters() != nil {
-		for k, v := range req.GetParameters() {
-			domainReq.Params[k] = v.AsInterface()
-		}
-	}
-
-	if req.GetPagination() != nil {
-		domainReq.Pagination = &commontypes.PaginationRequest{
-			Page:     int(req.GetPagination().GetPage()),
-			PageSize: int(req.GetPagination().GetPageSize()),
-		}
-	}
-
+	domainReq := toDomainSQLQueryRequest(req)
 	result, err := h.domainService.ExecuteSQL(ctx, domainReq)
 	if err != nil {
 		l.Errorw("Query service ExecuteSQL returned an error", "error", err)
-		// TODO: Map domain error to gRPC status code
 		return &apiv1.ExecuteSQLQueryResponse{
 			Success: false,
 			Message: err.Error(),
 			Error:   toProtoErrorDetail("SQL_EXECUTION_ERROR", err.Error()),
-		}, status.Error(codes.Internal, err.Error()) // Or more specific code
+		}, status.Error(grpcCodeFromError(err), err.Error())
 	}
 
 	// Map domain result to gRPC response
 	rows := make([]*apiv1.DataRow, len(result.Rows))
 	for i, domainRowMap := range result.Rows {
-		pbStruct, err := structpb.NewStruct(domainRowMap)
+		pbStruct, err := rowStruct(domainRowMap)
 		if err != nil {
 			l.Errorw("Failed to convert domain row to protobuf struct", "error", err)
 			return &apiv1.ExecuteSQLQueryResponse{
@@ -91,9 +76,10 @@ func (h *queryHandler) ExecuteSQLQuery(ctx context.Context, req *apiv1.ExecuteSQ
 	}
 	if result.Pagination != nil {
 		resp.Pagination = &apiv1.PaginationResponse{
-			Page:       int32(result.Pagination.Page),
-			PageSize:   int32(result.Pagination.PageSize),
-			TotalItems: result.Pagination.Total, // Assuming PaginationResponse has TotalItems
+			Page:          int32(result.Pagination.Page),
+			PageSize:      int32(result.Pagination.PageSize),
+			TotalItems:    result.Pagination.Total, // Assuming PaginationResponse has TotalItems
+			NextPageToken: result.Pagination.NextPageToken,
 		}
 	}
 	// TODO: Map result.Stats to a proto message if defined
@@ -102,6 +88,135 @@ func (h *queryHandler) ExecuteSQLQuery(ctx context.Context, req *apiv1.ExecuteSQ
 	return resp, nil
 }
 
+// StreamSQLQuery handles streamed SQL query requests. Rows are sent in batches of constants.QueryStreamBatchRows
+// as they arrive from StarRocks; a failed send, e.g. because the client went away, stops the query.
+// StreamSQLQuery 处理流式SQL查询请求。数据行从StarRocks到达时按constants.QueryStreamBatchRows分批发送；发送失败
+// （例如客户端已断开）时停止查询。
+func (h *queryHandler) StreamSQLQuery(req *apiv1.ExecuteSQLQueryRequest, stream apiv1.QueryService_StreamSQLQueryServer) error {
+	ctx := stream.Context()
+	l := logger.L().With("handler", "StreamSQLQuery", "request_id", req.GetRequestId())
+	l.Info("Received StreamSQLQuery request")
+
+	sink := &streamRowSink{stream: stream}
+	summary, err := h.domainService.StreamSQL(ctx, toDomainSQLQueryRequest(req), sink)
+	if err != nil {
+		l.Errorw("Query service StreamSQL returned an error", "error", err)
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(grpcCodeFromError(err), err.Error())
+	}
+
+	resp := &apiv1.StreamSQLQueryResponse{Rows: sink.batch, Done: true, RowCount: summary.RowCount, Message: "Query streamed successfully"}
+	if summary.Stats != nil && summary.Stats.Message != "" {
+		resp.Message += "; " + summary.Stats.Message
+	}
+	if err := stream.Send(resp); err != nil {
+		l.Warnw("Failed to send the last StreamSQLQuery message", "error", err)
+		return err
+	}
+	l.Infow("StreamSQLQuery request processed successfully", "row_count", summary.RowCount)
+	return nil
+}
+
+// streamRowSink sends the rows of a streamed SQL query in batches, holding at most one batch.
+// streamRowSink 分批发送流式SQL查询的数据行，最多保存一个批次。
+type streamRowSink struct {
+	stream apiv1.QueryService_StreamSQLQueryServer
+	batch  []*apiv1.DataRow
+}
+
+// Columns sends the column names right away, so that clients learn them even for an empty result.
+// Columns 立即发送列名，使客户端在结果为空时也能获知列名。
+func (s *streamRowSink) Columns(columns []string) error {
+	return s.stream.Send(&apiv1.StreamSQLQueryResponse{ColumnNames: columns})
+}
+
+func (s *streamRowSink) Row(row map[string]interface{}) error {
+	pbStruct, err := rowStruct(row)
+	if err != nil {
+		return errors.Wrap(err, errors.SerializationError, "failed to convert query row to protobuf struct")
+	}
+	s.batch = append(s.batch, &apiv1.DataRow{Fields: pbStruct})
+	if len(s.batch) < constants.QueryStreamBatchRows {
+		return nil
+	}
+	if err := s.stream.Send(&apiv1.StreamSQLQueryResponse{Rows: s.batch}); err != nil {
+		return err
+	}
+	s.batch = nil
+	return nil
+}
+
+// maxExactDouble is 2^53, the magnitude from which a double can no longer represent every integer.
+// maxExactDouble 即2^53，从该量级起double无法再精确表示每个整数。
+const maxExactDouble = 1 << 53
+
+// rowStruct converts a query row to a protobuf struct. The service reads numbers as json.Number, which structpb
+// does not accept: they become doubles, except integers beyond 2^53, which become strings to keep their value.
+// rowStruct 将查询行转换为protobuf结构体。服务将数值读取为structpb不接受的json.Number：它们被转换为double，
+// 超过2^53的整数除外，这些整数被转换为字符串以保持其取值。
+func rowStruct(row map[string]interface{}) (*structpb.Struct, error) {
+	fields := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		fields[k] = protoCompatible(v)
+	}
+	return structpb.NewStruct(fields)
+}
+
+func protoCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil || (math.Abs(f) >= maxExactDouble && !strings.ContainsAny(t.String(), ".eE")) {
+			return t.String()
+		}
+		return f
+	case []interface{}:
+		items := make([]interface{}, len(t))
+		for i, item := range t {
+			items[i] = protoCompatible(item)
+		}
+		return items
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			fields[k] = protoCompatible(item)
+		}
+		return fields
+	}
+	return v
+}
+
+// toDomainSQLQueryRequest maps a SQL query request of the API to the query domain.
+// toDomainSQLQueryRequest 将API的SQL查询请求转换为查询领域的请求。
+func toDomainSQLQueryRequest(req *apiv1.ExecuteSQLQueryRequest) *querymodel.SQLQueryRequest {
+	domainReq := &querymodel.SQLQueryRequest{
+		SQL:              req.GetSqlQuery(),
+		Params:           make(map[string]interface{}),
+		WorkloadGroup:    req.GetWorkloadGroup(),
+		QueryTimeoutSecs: int(req.GetQueryTimeoutSeconds()),
+		Database:         req.GetDatabase(),
+		SortKey:          req.GetSortKey(),
+	}
+	// Numbers of google.protobuf.Value are doubles, so integers beyond 2^53 are rejected when they are bound and
+	// have to be sent as strings.
+	for k, v := range req.GetParameters() {
+		domainReq.Params[k] = v.AsInterface()
+	}
+	if req.GetPagination() != nil {
+		domainReq.Pagination = &commontypes.PaginationRequest{
+			Page:      int(req.GetPagination().GetPage()),
+			PageSize:  int(req.GetPagination().GetPageSize()),
+			PageToken: req.GetPagination().GetPageToken(),
+		}
+	}
+	return domainReq
+}
+
 // FullTextSearch handles incoming full-text search requests.
 // FullTextSearch 处理传入的全文检索请求。
 func (h *queryHandler) FullTextSearch(ctx context.Context, req *apiv1.FullTextSearchRequest) (*apiv1.FullTextSearchResponse, error) {
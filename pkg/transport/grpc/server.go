@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/utils"
 	"github.com/turtacn/dataseap/pkg/config"
 	"github.com/turtacn/dataseap/pkg/logger"
 
@@ -26,6 +30,10 @@ import (
 	apiv1 "github.com/turtacn/dataseap/api/v1" // Alias for generated proto code
 )
 
+// userMetadataKey is the metadata key naming the caller, the gRPC counterpart of constants.HeaderUser.
+// userMetadataKey 是标识调用方的元数据键，对应constants.HeaderUser。
+var userMetadataKey = strings.ToLower(constants.HeaderUser)
+
 // Server holds the gRPC server instance and its configuration.
 // Server 保存gRPC服务器实例及其配置。
 type Server struct {
@@ -50,6 +58,11 @@ type ServiceRegistry struct {
 func NewServer(cfg config.ServerConfig, services ServiceRegistry, grpcOpts ...grpc.ServerOption) (*Server, error) {
 	l := logger.L().With("component", "gRPCServer")
 
+	trusted, err := utils.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	address := fmt.Sprintf("%s:%d", cfg.Host, cfg.GRPCPort)
 	if cfg.GRPCPort == 0 {
 		address = fmt.Sprintf("%s:%d", cfg.Host, constants.DefaultGRPCPort)
@@ -65,6 +78,8 @@ func NewServer(cfg config.ServerConfig, services ServiceRegistry, grpcOpts ...gr
 	// Default gRPC server options
 	// TODO: Add interceptors for logging, metrics, auth, recovery
 	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(userUnaryInterceptor(trusted)),
+		grpc.ChainStreamInterceptor(userStreamInterceptor(trusted)),
 		// grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
 		// 	// logging.UnaryServerInterceptor(l),
 		// 	// metrics.UnaryServerInterceptor(),
@@ -79,7 +94,7 @@ func NewServer(cfg config.ServerConfig, services ServiceRegistry, grpcOpts ...gr
 
 	// Register services
 	if services.IngestionSvc != nil {
-		ingestionHandler := NewIngestionHandler(services.IngestionSvc)
+		ingestionHandler := NewIngestionHandler(services.IngestionSvc, cfg.IngestStream)
 		apiv1.RegisterIngestionServiceServer(s, ingestionHandler)
 		l.Info("Registered IngestionService")
 	}
@@ -159,3 +174,46 @@ func (s *Server) Address() string {
 	}
 	return ""
 }
+
+// withUser stores the caller named by the metadata of an incoming call in its context under
+// constants.ContextKeyUser. The metadata is only trusted when the call comes directly from one of the trusted
+// proxies; the caller of any other call stays unnamed.
+// withUser 将传入调用的元数据中标识的调用方以constants.ContextKeyUser存入其上下文。只有调用直接来自可信代理时才信任
+// 该元数据，其他调用的调用方保持未命名。
+func withUser(ctx context.Context, trusted utils.TrustedProxies) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil || !trusted.Trusts(p.Addr.String()) {
+		return ctx
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	if users := md.Get(userMetadataKey); len(users) > 0 && users[0] != "" {
+		return context.WithValue(ctx, constants.ContextKeyUser, users[0])
+	}
+	return ctx
+}
+
+func userUnaryInterceptor(trusted utils.TrustedProxies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withUser(ctx, trusted), req)
+	}
+}
+
+func userStreamInterceptor(trusted utils.TrustedProxies) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &userServerStream{ServerStream: ss, ctx: withUser(ss.Context(), trusted)})
+	}
+}
+
+// userServerStream is a server stream whose context carries the caller.
+// userServerStream 是上下文中携带调用方的服务端流。
+type userServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *userServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -1,23 +1,32 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
 	"github.com/turtacn/dataseap/pkg/common/constants"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	apiv1 "github.com/turtacn/dataseap/api/v1" // For request/response DTOs if not mapping directly to domain
+	commonerrors "github.com/turtacn/dataseap/pkg/common/errors"
 	commontypes "github.com/turtacn/dataseap/pkg/common/types"
+	"github.com/turtacn/dataseap/pkg/common/utils"
 	// Domain services (already passed via ServiceRegistry)
 	// "github.com/turtacn/dataseap/pkg/domain/ingestion"
 	// "github.com/turtacn/dataseap/pkg/domain/query"
 	// ...
 	// Domain models (for request/response bodies if not using DTOs)
 	ingestionmodel "github.com/turtacn/dataseap/pkg/domain/ingestion/model"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/ratelimit"
+	advisormodel "github.com/turtacn/dataseap/pkg/domain/management/advisor/model"
 	lifecyclemodel "github.com/turtacn/dataseap/pkg/domain/management/lifecycle/model"
+	metadatamodel "github.com/turtacn/dataseap/pkg/domain/management/metadata/model"
 	querymodel "github.com/turtacn/dataseap/pkg/domain/query/model"
 	// ... other domain models
 )
@@ -54,20 +63,59 @@ func SetupRouter(engine *gin.Engine, services ServiceRegistry) {
 					domainEvents := make([]*ingestionmodel.RawEvent, len(req.Records))
 					for i, r := range req.Records {
 						domainEvents[i] = &ingestionmodel.RawEvent{
-							DataSourceID: r.GetDataSourceId(), DataType: r.GetDataType(), Timestamp: r.GetTimestamp().AsTime(), Data: r.GetData().AsMap(), Tags: r.GetTags(),
+							ID: r.GetId(), DataSourceID: r.GetDataSourceId(), DataType: r.GetDataType(), RawPayload: r.GetRawPayload(), Tags: r.GetTags(),
+						}
+						if r.GetData() != nil {
+							domainEvents[i].Data = r.GetData().AsMap()
+						}
+						// A missing timestamp defaults to now, unless a parser finds one in the raw payload.
+						if r.GetTimestamp() != nil && r.GetTimestamp().IsValid() {
+							domainEvents[i].Timestamp = r.GetTimestamp().AsTime()
+						} else {
+							domainEvents[i].Timestamp, domainEvents[i].TimestampDefaulted = time.Now().UTC(), true
 						}
 					}
-					ingested, persistFailed, validationFailed, err := services.IngestionSvc.IngestEvents(c.Request.Context(), domainEvents)
+					ingested, persistFailed, validationFailed, duplicates, err := services.IngestionSvc.IngestEvents(c.Request.Context(), domainEvents)
 					if err != nil {
-						c.JSON(http.StatusInternalServerError, commontypes.NewErrorAPIResponse(
-							&commonerrors.AppError{Code: commonerrors.InternalError, Message: "Ingestion failed: " + err.Error()},
-						))
+						setRetryAfter(c, err)
+						c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
 						return
 					}
 					c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(gin.H{
-						"ingestedCount": ingested, "persistFailedCount": persistFailed, "validationFailedCount": validationFailed,
+						"ingestedCount": ingested, "persistFailedCount": persistFailed, "validationFailedCount": validationFailed, "duplicateCount": duplicates,
 					}))
 				})
+				// Streaming bulk upload of NDJSON or CSV bodies, optionally gzip/zstd compressed.
+				// The format comes from the "format" query parameter or the Content-Type header. Retrying with the
+				// same "uploadId" query parameter skips the chunks an earlier attempt committed.
+				ingestionRouter.POST("/bulk/:dataType", func(c *gin.Context) {
+					// Uploads outlive the read and write timeouts of the server, so their deadlines are lifted.
+					rc := http.NewResponseController(c.Writer)
+					_ = rc.SetReadDeadline(time.Time{})
+					_ = rc.SetWriteDeadline(time.Time{})
+					req := &ingestionmodel.BulkIngestRequest{
+						DataType:        c.Param("dataType"),
+						Format:          bulkFormatFromRequest(c),
+						Compression:     c.GetHeader("Content-Encoding"),
+						CSVHeader:       c.Query("header") == "true",
+						ColumnSeparator: c.Query("columnSeparator"),
+						UploadID:        c.Query("uploadId"),
+					}
+					if columns := c.Query("columns"); columns != "" {
+						req.Columns = strings.Split(columns, ",")
+					}
+					summary, err := services.IngestionSvc.IngestStream(c.Request.Context(), req, c.Request.Body)
+					if err != nil {
+						setRetryAfter(c, err)
+						if summary != nil {
+							c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err), summary))
+							return
+						}
+						c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+						return
+					}
+					c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(summary))
+				})
 			}
 		}
 
@@ -77,20 +125,39 @@ func SetupRouter(engine *gin.Engine, services ServiceRegistry) {
 			{
 				// Using querymodel.SQLQueryRequest directly as it's a Go struct
 				queryRouter.POST("/sql", func(c *gin.Context) {
-					var req querymodel.SQLQueryRequest
-					if err := c.ShouldBindJSON(&req); err != nil {
-						c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid SQL query request: " + err.Error()}))
+					req, ok := bindSQLQueryRequest(c)
+					if !ok {
 						return
 					}
-					result, err := services.QuerySvc.ExecuteSQL(c.Request.Context(), &req)
+					result, err := services.QuerySvc.ExecuteSQL(c.Request.Context(), req)
 					if err != nil {
-						// TODO: Map domain error to appropriate HTTP status code
-						c.JSON(http.StatusInternalServerError, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.DatabaseError, Message: "SQL query execution failed: " + err.Error()}))
+						c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
 						return
 					}
 					c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(result))
 				})
 
+				// Streams the rows of a query as NDJSON while they arrive from StarRocks: a {"columns": [...]} line,
+				// one {"row": {...}} line per row, then a {"summary": {...}} line, or an {"error": {...}} line when
+				// the query fails after the response started.
+				queryRouter.POST("/sql/stream", func(c *gin.Context) {
+					req, ok := bindSQLQueryRequest(c)
+					if !ok {
+						return
+					}
+					sink := &ndjsonRowSink{c: c, enc: json.NewEncoder(c.Writer)}
+					summary, err := services.QuerySvc.StreamSQL(c.Request.Context(), req, sink)
+					if err != nil {
+						if !sink.started {
+							c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+							return
+						}
+						sink.write(gin.H{"error": toAppError(err)})
+						return
+					}
+					sink.write(gin.H{"summary": summary})
+				})
+
 				queryRouter.POST("/search/fulltext", func(c *gin.Context) {
 					var req querymodel.FullTextSearchRequest
 					if err := c.ShouldBindJSON(&req); err != nil {
@@ -108,7 +175,7 @@ func SetupRouter(engine *gin.Engine, services ServiceRegistry) {
 		}
 
 		// --- Management Routes ---
-		if services.WorkloadSvc != nil || services.MetadataSvc != nil || services.LifecycleSvc != nil {
+		if services.WorkloadSvc != nil || services.MetadataSvc != nil || services.LifecycleSvc != nil || services.DeadLetterSvc != nil || services.AdvisorSvc != nil {
 			mgmtRouter := v1.Group("/management")
 			{
 				// Example: Workload Group
@@ -140,6 +207,274 @@ func SetupRouter(engine *gin.Engine, services ServiceRegistry) {
 							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(schema))
 						})
 					}
+					// Table lifecycle; every operation accepts ?dryRun=true to only return the generated DDL
+					tableRouter := mgmtRouter.Group("/databases/:dbName/tables")
+					{
+						tableRouter.GET("", func(c *gin.Context) {
+							pagination := bindPagination(c)
+							tables, total, err := services.MetadataSvc.ListTables(c.Request.Context(), c.Param("dbName"), pagination)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(gin.H{
+								"tables":     tables,
+								"pagination": commontypes.PaginationResponse{Page: pagination.Page, PageSize: pagination.PageSize, Total: total},
+							}))
+						})
+						tableRouter.GET("/:tableName/indexes", func(c *gin.Context) {
+							indexes, err := services.MetadataSvc.ListIndexes(c.Request.Context(), c.Param("dbName"), c.Param("tableName"))
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(indexes))
+						})
+						tableRouter.POST("", func(c *gin.Context) {
+							dryRun, ok := bindDryRun(c)
+							if !ok {
+								return
+							}
+							var schema metadatamodel.TableSchema
+							if err := c.ShouldBindJSON(&schema); err != nil {
+								c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid table schema: " + err.Error()}))
+								return
+							}
+							schema.DatabaseName = c.Param("dbName")
+							result, err := services.MetadataSvc.CreateTable(c.Request.Context(), &schema, dryRun)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							status := http.StatusCreated
+							if dryRun {
+								status = http.StatusOK
+							}
+							c.JSON(status, commontypes.NewSuccessAPIResponse(result))
+						})
+						tableRouter.PATCH("/:tableName", func(c *gin.Context) {
+							dryRun, ok := bindDryRun(c)
+							if !ok {
+								return
+							}
+							var req struct {
+								Alterations []*metadatamodel.TableAlteration `json:"alterations" binding:"required,min=1"`
+							}
+							if err := c.ShouldBindJSON(&req); err != nil {
+								c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid table alterations: " + err.Error()}))
+								return
+							}
+							result, err := services.MetadataSvc.AlterTable(c.Request.Context(), c.Param("dbName"), c.Param("tableName"), req.Alterations, dryRun)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(result))
+						})
+						tableRouter.DELETE("/:tableName", func(c *gin.Context) {
+							dryRun, ok := bindDryRun(c)
+							if !ok {
+								return
+							}
+							result, err := services.MetadataSvc.DropTable(c.Request.Context(), c.Param("dbName"), c.Param("tableName"), dryRun)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(result))
+						})
+					}
+					// Asynchronous materialized views; GET reports the activation state and the last refresh
+					mvRouter := mgmtRouter.Group("/databases/:dbName/materialized-views")
+					{
+						mvRouter.GET("", func(c *gin.Context) {
+							pagination := bindPagination(c)
+							views, total, err := services.MetadataSvc.ListMaterializedViews(c.Request.Context(), c.Param("dbName"), pagination)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(gin.H{
+								"materializedViews": views,
+								"pagination":        commontypes.PaginationResponse{Page: pagination.Page, PageSize: pagination.PageSize, Total: total},
+							}))
+						})
+						mvRouter.POST("", func(c *gin.Context) {
+							var mv metadatamodel.MaterializedViewDefinition
+							if err := c.ShouldBindJSON(&mv); err != nil {
+								c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid materialized view definition: " + err.Error()}))
+								return
+							}
+							mv.DatabaseName = c.Param("dbName")
+							mv.Definition, mv.Status = "", nil
+							if err := services.MetadataSvc.CreateMaterializedView(c.Request.Context(), &mv); err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusCreated, commontypes.NewSuccessAPIResponse(&mv))
+						})
+						mvRouter.GET("/:viewName", func(c *gin.Context) {
+							mv, err := services.MetadataSvc.GetMaterializedViewDefinition(c.Request.Context(), c.Param("dbName"), c.Param("viewName"))
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(mv))
+						})
+						mvRouter.DELETE("/:viewName", func(c *gin.Context) {
+							if err := services.MetadataSvc.DropMaterializedView(c.Request.Context(), c.Param("dbName"), c.Param("viewName")); err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(nil))
+						})
+						// ?force=true refreshes even when the base tables did not change
+						mvRouter.POST("/:viewName/refresh", func(c *gin.Context) {
+							force, err := strconv.ParseBool(c.DefaultQuery("force", "false"))
+							if err != nil {
+								c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid force parameter: " + c.Query("force")}))
+								return
+							}
+							if err := services.MetadataSvc.RefreshMaterializedView(c.Request.Context(), c.Param("dbName"), c.Param("viewName"), force); err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusAccepted, commontypes.NewSuccessAPIResponse(nil))
+						})
+						mvRouter.POST("/:viewName/pause", func(c *gin.Context) {
+							if err := services.MetadataSvc.PauseMaterializedView(c.Request.Context(), c.Param("dbName"), c.Param("viewName")); err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(nil))
+						})
+						mvRouter.POST("/:viewName/resume", func(c *gin.Context) {
+							if err := services.MetadataSvc.ResumeMaterializedView(c.Request.Context(), c.Param("dbName"), c.Param("viewName")); err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(nil))
+						})
+					}
+				}
+				// Dead letters: inspect and replay events rejected by ingestion
+				if services.DeadLetterSvc != nil {
+					dlRouter := mgmtRouter.Group("/dead-letters")
+					{
+						dlRouter.GET("", func(c *gin.Context) {
+							var filter ingestionmodel.DeadLetterFilter
+							if err := c.ShouldBindQuery(&filter); err != nil {
+								c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid dead-letter filter: " + err.Error()}))
+								return
+							}
+							pagination := bindPagination(c)
+							letters, total, err := services.DeadLetterSvc.ListDeadLetters(c.Request.Context(), &filter, pagination)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(gin.H{
+								"deadLetters": letters,
+								"pagination":  commontypes.PaginationResponse{Page: pagination.Page, PageSize: pagination.PageSize, Total: total},
+							}))
+						})
+						dlRouter.GET("/:id", func(c *gin.Context) {
+							letter, err := services.DeadLetterSvc.GetDeadLetter(c.Request.Context(), c.Param("id"))
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(letter))
+						})
+						dlRouter.POST("/replay", func(c *gin.Context) {
+							var req struct {
+								IDs []string `json:"ids" binding:"required,min=1"`
+							}
+							if err := c.ShouldBindJSON(&req); err != nil {
+								c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid replay request: " + err.Error()}))
+								return
+							}
+							result, err := services.DeadLetterSvc.ReplayDeadLetters(c.Request.Context(), req.IDs)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(result))
+						})
+						dlRouter.DELETE("/:id", func(c *gin.Context) {
+							if err := services.DeadLetterSvc.DeleteDeadLetters(c.Request.Context(), []string{c.Param("id")}); err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(nil))
+						})
+					}
+				}
+				// Query advisor: recorded query patterns and materialized view recommendations
+				if services.AdvisorSvc != nil {
+					advisorRouter := mgmtRouter.Group("/advisor")
+					{
+						advisorRouter.GET("/query-patterns", func(c *gin.Context) {
+							pagination := bindPagination(c)
+							patterns, total, err := services.AdvisorSvc.ListQueryPatterns(c.Request.Context(), pagination)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(gin.H{
+								"queryPatterns": patterns,
+								"pagination":    commontypes.PaginationResponse{Page: pagination.Page, PageSize: pagination.PageSize, Total: total},
+							}))
+						})
+						// ?database= limits the recommendations to one database
+						advisorRouter.GET("/materialized-views", func(c *gin.Context) {
+							recommendations, err := services.AdvisorSvc.RecommendMaterializedViews(c.Request.Context(), c.Query("database"))
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(gin.H{"recommendations": recommendations}))
+						})
+						advisorRouter.POST("/materialized-views/:id/accept", func(c *gin.Context) {
+							var opts advisormodel.AcceptOptions
+							if c.Request.ContentLength != 0 {
+								if err := c.ShouldBindJSON(&opts); err != nil {
+									c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid accept options: " + err.Error()}))
+									return
+								}
+							}
+							mv, err := services.AdvisorSvc.AcceptMaterializedViewRecommendation(c.Request.Context(), c.Param("id"), &opts)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusCreated, commontypes.NewSuccessAPIResponse(mv))
+						})
+						// ?database= limits the recommendations to one database; column statistics are sampled on request
+						advisorRouter.GET("/indexes", func(c *gin.Context) {
+							recommendations, err := services.AdvisorSvc.RecommendIndexes(c.Request.Context(), c.Query("database"))
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusOK, commontypes.NewSuccessAPIResponse(gin.H{"recommendations": recommendations}))
+						})
+						advisorRouter.POST("/indexes/:id/accept", func(c *gin.Context) {
+							var opts advisormodel.IndexAcceptOptions
+							if c.Request.ContentLength != 0 {
+								if err := c.ShouldBindJSON(&opts); err != nil {
+									c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid accept options: " + err.Error()}))
+									return
+								}
+							}
+							index, err := services.AdvisorSvc.AcceptIndexRecommendation(c.Request.Context(), c.Param("id"), &opts)
+							if err != nil {
+								c.JSON(httpStatusFromError(err), commontypes.NewErrorAPIResponse(toAppError(err)))
+								return
+							}
+							c.JSON(http.StatusCreated, commontypes.NewSuccessAPIResponse(index))
+						})
+					}
 				}
 				// Example: Lifecycle - Component Status
 				if services.LifecycleSvc != nil {
@@ -171,6 +506,94 @@ func SetupRouter(engine *gin.Engine, services ServiceRegistry) {
 	})
 }
 
+// httpStatusFromError maps an application error code to the corresponding HTTP status.
+// httpStatusFromError 将应用错误码映射为对应的HTTP状态码。
+func httpStatusFromError(err error) int {
+	switch commonerrors.GetCode(err) {
+	case commonerrors.InvalidArgument:
+		return http.StatusBadRequest
+	case commonerrors.NotFoundError:
+		return http.StatusNotFound
+	case commonerrors.PermissionDenied:
+		return http.StatusForbidden
+	case commonerrors.AlreadyExistsError:
+		return http.StatusConflict
+	case commonerrors.RateLimitExceeded:
+		return http.StatusTooManyRequests
+	case commonerrors.TimeoutError:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// setRetryAfter sets the Retry-After header, in whole seconds, from the retry hint of a rate limit error.
+// setRetryAfter 根据限流错误的重试提示设置Retry-After头（以整秒计）。
+func setRetryAfter(c *gin.Context, err error) {
+	retryAfter, ok := ratelimit.RetryAfter(err)
+	if !ok {
+		return
+	}
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
+
+// toAppError returns err as an *AppError, wrapping foreign errors as internal errors.
+// toAppError 将err转换为*AppError，非应用错误包装为内部错误。
+func toAppError(err error) *commonerrors.AppError {
+	var appErr *commonerrors.AppError
+	if commonerrors.As(err, &appErr) {
+		return appErr
+	}
+	return &commonerrors.AppError{Code: commonerrors.InternalError, Message: err.Error()}
+}
+
+// bulkFormatFromRequest picks the bulk upload format from the "format" query parameter, falling back to Content-Type.
+// bulkFormatFromRequest 从"format"查询参数中获取批量上传格式，未提供时根据Content-Type判断。
+func bulkFormatFromRequest(c *gin.Context) ingestionmodel.BulkFormat {
+	if format := c.Query("format"); format != "" {
+		return ingestionmodel.BulkFormat(strings.ToLower(format))
+	}
+	contentType := strings.ToLower(c.ContentType())
+	if strings.Contains(contentType, "csv") {
+		return ingestionmodel.BulkFormatCSV
+	}
+	return ingestionmodel.BulkFormatNDJSON
+}
+
+// bindSQLQueryRequest decodes a SQL query request body, responding with 400 and returning false when it is malformed.
+// Numbers are decoded as json.Number, so that integer parameters beyond 2^53 reach the statement exactly.
+// bindSQLQueryRequest 解码SQL查询请求体，格式错误时返回400响应并返回false。
+// 数值被解码为json.Number，以便超过2^53的整数参数被精确地写入语句。
+func bindSQLQueryRequest(c *gin.Context) (*querymodel.SQLQueryRequest, bool) {
+	var req querymodel.SQLQueryRequest
+	dec := json.NewDecoder(c.Request.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid SQL query request: " + err.Error()}))
+		return nil, false
+	}
+	return &req, true
+}
+
+// bindDryRun reads the "dryRun" query parameter, responding with 400 and returning false when it is not a boolean.
+// bindDryRun 读取"dryRun"查询参数，不是布尔值时返回400响应并返回false。
+func bindDryRun(c *gin.Context) (dryRun bool, ok bool) {
+	value := c.Query("dryRun")
+	if value == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, commontypes.NewErrorAPIResponse(&commonerrors.AppError{Code: commonerrors.InvalidArgument, Message: "Invalid dryRun parameter: " + value}))
+		return false, false
+	}
+	return dryRun, true
+}
+
 // Helper for binding and validating pagination from query parameters
 func bindPagination(c *gin.Context) *commontypes.PaginationRequest {
 	var page, pageSize int
@@ -205,3 +628,55 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// UserMiddleware stores the caller named by the X-DataSeaP-User header in the request context under
+// constants.ContextKeyUser, where the query policies look it up. The header is only trusted when the request comes
+// directly from one of the trusted proxies; the caller of any other request stays unnamed.
+// UserMiddleware 将X-DataSeaP-User头中标识的调用方以constants.ContextKeyUser存入请求上下文，供查询策略查找。
+// 只有请求直接来自可信代理时才信任该头，其他请求的调用方保持未命名。
+func UserMiddleware(trusted utils.TrustedProxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := c.GetHeader(constants.HeaderUser); user != "" && trusted.Trusts(c.Request.RemoteAddr) {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), constants.ContextKeyUser, user))
+		}
+		c.Next()
+	}
+}
+
+// ndjsonRowSink writes the results of a streamed SQL query as NDJSON, flushing every constants.QueryStreamBatchRows
+// rows. Writes fail once the client went away, which stops the query.
+// ndjsonRowSink 以NDJSON写出流式SQL查询的结果，每constants.QueryStreamBatchRows行刷新一次。客户端断开后写入失败，查询随之停止。
+type ndjsonRowSink struct {
+	c       *gin.Context
+	enc     *json.Encoder
+	rows    int
+	started bool // 响应是否已开始 Whether the response started
+}
+
+// Columns starts the response. Streams outlive the write timeout of the server, so its deadline is lifted.
+// Columns 开始写出响应。流式响应的持续时间超过服务器的写超时，因此取消其写截止时间。
+func (s *ndjsonRowSink) Columns(columns []string) error {
+	_ = http.NewResponseController(s.c.Writer).SetWriteDeadline(time.Time{})
+	s.c.Header("Content-Type", "application/x-ndjson")
+	s.c.Status(http.StatusOK)
+	s.started = true
+	return s.write(gin.H{"columns": columns})
+}
+
+func (s *ndjsonRowSink) Row(row map[string]interface{}) error {
+	if err := s.enc.Encode(gin.H{"row": row}); err != nil {
+		return err
+	}
+	if s.rows++; s.rows%constants.QueryStreamBatchRows == 0 {
+		s.c.Writer.Flush()
+	}
+	return nil
+}
+
+func (s *ndjsonRowSink) write(v interface{}) error {
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
@@ -9,11 +9,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/turtacn/dataseap/pkg/common/constants"
+	"github.com/turtacn/dataseap/pkg/common/utils"
 	"github.com/turtacn/dataseap/pkg/config"
 	"github.com/turtacn/dataseap/pkg/logger"
 	// Import domain service interfaces for handlers
 	"github.com/turtacn/dataseap/pkg/domain/ingestion"
+	"github.com/turtacn/dataseap/pkg/domain/ingestion/deadletter"
 	"github.com/turtacn/dataseap/pkg/domain/query"
+	"github.com/turtacn/dataseap/pkg/domain/management/advisor"
 	"github.com/turtacn/dataseap/pkg/domain/management/lifecycle"
 	"github.com/turtacn/dataseap/pkg/domain/management/metadata"
 	"github.com/turtacn/dataseap/pkg/domain/management/workload"
@@ -35,7 +38,9 @@ type ServiceRegistry struct {
 	QuerySvc     query.Service
 	WorkloadSvc  workload.Service
 	MetadataSvc  metadata.Service
-	LifecycleSvc lifecycle.Service
+	LifecycleSvc  lifecycle.Service
+	DeadLetterSvc deadletter.Service
+	AdvisorSvc    advisor.Service
 }
 
 
@@ -50,11 +55,17 @@ func NewServer(cfg config.ServerConfig, services ServiceRegistry) (*Server, erro
 		gin.SetMode(gin.DebugMode)
 	}
 
+	trusted, err := utils.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	engine := gin.New()
 
 	// TODO: Add standard middleware: Logger, Recovery, CORS, Metrics, Tracing, RequestID
 	engine.Use(GinLogger(l)) // Custom logger middleware
 	engine.Use(gin.Recovery())
+	engine.Use(UserMiddleware(trusted))
 	// engine.Use(cors.Default()) // Example CORS
 
 	// Setup routes